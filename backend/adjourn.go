@@ -0,0 +1,371 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"pablo/protocol"
+)
+
+// gameSnapshot is everything needed to rebuild a Game after a restart.
+// Connections are not saved; players reattach by presenting their session token.
+type gameSnapshot struct {
+	ID                        string            `json:"id"`
+	Players                   []playerSnapshot  `json:"players"`
+	SeatOrder                 []string          `json:"seatOrder"`
+	Deck                      []Card            `json:"deck"`
+	DiscardPile               []Card            `json:"discardPile"`
+	DiscardedBelow            int               `json:"discardedBelow,omitempty"`
+	DrawnCards                map[string]*Card  `json:"drawnCards"`
+	HasDrawnThisTurn          map[string]bool   `json:"hasDrawnThisTurn"`
+	DrawnFromDiscard          map[string]bool   `json:"drawnFromDiscard"`
+	PendingSpecialCard        string            `json:"pendingSpecialCard"`
+	CurrentPlayer             string            `json:"currentPlayer"`
+	Status                    string            `json:"status"`
+	HostID                    string            `json:"hostID"`
+	Locked                    bool              `json:"locked"`
+	Muted                     []string          `json:"muted,omitempty"`
+	ShadowMuted               []string          `json:"shadowMuted,omitempty"`
+	Handicaps                 map[string]int    `json:"handicaps,omitempty"`
+	PabloCalled               bool              `json:"pabloCalled"`
+	PabloCaller               string            `json:"pabloCaller"`
+	PabloBlind                bool              `json:"pabloBlind,omitempty"`
+	Waiting                   []string          `json:"waiting,omitempty"`
+	GameOver                  bool              `json:"gameOver,omitempty"`
+	StackableCardIndex        int               `json:"stackableCardIndex"`
+	StackedSpecialCardPlayers []string          `json:"stackedSpecialCardPlayers"`
+	PendingGive               *PendingGive      `json:"pendingGive,omitempty"`
+	PendingKingSwap           *PendingKingSwap  `json:"pendingKingSwap,omitempty"`
+	Config                    GameConfig        `json:"config"`
+	RoundStartedAt            time.Time         `json:"roundStartedAt"`
+	Deal                      *dealCommitment   `json:"deal,omitempty"`
+	Match                     matchStats        `json:"match"`
+	PausedAt                  time.Time         `json:"pausedAt"`
+	ClaimPenalties            map[string]int    `json:"claimPenalties,omitempty"`
+	ActedThisRound            []string          `json:"actedThisRound,omitempty"`
+	EventLog                  []GameEvent       `json:"eventLog,omitempty"`
+	ChatLog                   []AuditChatLine   `json:"chatLog,omitempty"`
+	ConnectionLog             []AuditConnection `json:"connectionLog,omitempty"`
+	AdjournedAt               time.Time         `json:"adjournedAt"`
+	ExpiresAt                 time.Time         `json:"expiresAt"`
+}
+
+type playerSnapshot struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Cards        []Card `json:"cards"`
+	Ready        bool   `json:"ready,omitempty"`
+	Score        int    `json:"score"`
+	Total        int    `json:"total"`
+	SessionToken string `json:"sessionToken"`
+}
+
+// snapshot copies the game's state. Caller must hold g.mu.
+func (g *Game) snapshot() *gameSnapshot {
+	snap := &gameSnapshot{
+		ID:                        g.ID,
+		SeatOrder:                 append([]string(nil), g.SeatOrder...),
+		Deck:                      append([]Card(nil), g.Deck...),
+		DiscardPile:               append([]Card(nil), g.DiscardPile...),
+		DiscardedBelow:            g.DiscardedBelow,
+		DrawnCards:                make(map[string]*Card),
+		HasDrawnThisTurn:          make(map[string]bool),
+		DrawnFromDiscard:          make(map[string]bool),
+		PendingSpecialCard:        g.PendingSpecialCard,
+		CurrentPlayer:             g.CurrentPlayer,
+		Status:                    g.Status,
+		HostID:                    g.HostID,
+		Locked:                    g.Locked,
+		PabloCalled:               g.PabloCalled,
+		PabloCaller:               g.PabloCaller,
+		PabloBlind:                g.PabloBlind,
+		Waiting:                   append([]string(nil), g.Waiting...),
+		GameOver:                  g.GameOver,
+		StackableCardIndex:        g.StackableCardIndex,
+		StackedSpecialCardPlayers: append([]string(nil), g.StackedSpecialCardPlayers...),
+		PendingGive:               g.PendingGive,
+		PendingKingSwap:           g.PendingKingSwap,
+		Config:                    g.Config,
+		RoundStartedAt:            g.RoundStartedAt,
+		Deal:                      g.deal,
+		Match:                     g.match,
+		PausedAt:                  g.PausedAt,
+		EventLog:                  append([]GameEvent(nil), g.eventLog...),
+		ChatLog:                   append([]AuditChatLine(nil), g.chatLog...),
+		ConnectionLog:             append([]AuditConnection(nil), g.connectionLog...),
+	}
+	for _, id := range append(append([]string(nil), g.SeatOrder...), g.Waiting...) {
+		player := g.Players[id]
+		snap.Players = append(snap.Players, playerSnapshot{
+			ID:           player.ID,
+			Name:         player.Name,
+			Cards:        append([]Card(nil), player.Cards...),
+			Ready:        player.Ready,
+			Score:        player.Score,
+			Total:        player.Total,
+			SessionToken: player.SessionToken,
+		})
+	}
+	for id, card := range g.DrawnCards {
+		if card != nil {
+			c := *card
+			snap.DrawnCards[id] = &c
+		}
+	}
+	for id := range g.Muted {
+		snap.Muted = append(snap.Muted, id)
+	}
+	for id := range g.ShadowMuted {
+		snap.ShadowMuted = append(snap.ShadowMuted, id)
+	}
+	if len(g.Handicaps) > 0 {
+		snap.Handicaps = make(map[string]int, len(g.Handicaps))
+		for id, points := range g.Handicaps {
+			snap.Handicaps[id] = points
+		}
+	}
+	if len(g.claimPenalties) > 0 {
+		snap.ClaimPenalties = make(map[string]int, len(g.claimPenalties))
+		for id, points := range g.claimPenalties {
+			snap.ClaimPenalties[id] = points
+		}
+	}
+	for id := range g.actedThisRound {
+		snap.ActedThisRound = append(snap.ActedThisRound, id)
+	}
+	for id, v := range g.HasDrawnThisTurn {
+		snap.HasDrawnThisTurn[id] = v
+	}
+	for id, v := range g.DrawnFromDiscard {
+		snap.DrawnFromDiscard[id] = v
+	}
+	return snap
+}
+
+// gameFromSnapshot rebuilds a Game. Every player starts without a connection.
+func gameFromSnapshot(snap *gameSnapshot) *Game {
+	game := NewGame(snap.ID)
+	game.Deck = snap.Deck
+	game.DiscardPile = snap.DiscardPile
+	game.DiscardedBelow = snap.DiscardedBelow
+	game.SeatOrder = snap.SeatOrder
+	game.PendingSpecialCard = snap.PendingSpecialCard
+	game.CurrentPlayer = snap.CurrentPlayer
+	game.Status = snap.Status
+	game.HostID = snap.HostID
+	game.Locked = snap.Locked
+	for _, id := range snap.Muted {
+		game.Muted[id] = true
+	}
+	for _, id := range snap.ShadowMuted {
+		game.ShadowMuted[id] = true
+	}
+	for id, points := range snap.Handicaps {
+		game.Handicaps[id] = points
+	}
+	game.PabloCalled = snap.PabloCalled
+	game.PabloCaller = snap.PabloCaller
+	game.PabloBlind = snap.PabloBlind
+	game.Waiting = snap.Waiting
+	game.GameOver = snap.GameOver
+	game.StackableCardIndex = snap.StackableCardIndex
+	game.StackedSpecialCardPlayers = snap.StackedSpecialCardPlayers
+	game.PendingGive = snap.PendingGive
+	game.PendingKingSwap = snap.PendingKingSwap
+	game.Config = snap.Config
+	game.RoundStartedAt = snap.RoundStartedAt
+	game.deal = snap.Deal
+	game.match = snap.Match
+	game.PausedAt = snap.PausedAt
+	for id, points := range snap.ClaimPenalties {
+		game.claimPenalties[id] = points
+	}
+	for _, id := range snap.ActedThisRound {
+		game.actedThisRound[id] = true
+	}
+	game.eventLog = snap.EventLog
+	game.chatLog = snap.ChatLog
+	game.connectionLog = snap.ConnectionLog
+	if snap.DrawnCards != nil {
+		game.DrawnCards = snap.DrawnCards
+	}
+	if snap.HasDrawnThisTurn != nil {
+		game.HasDrawnThisTurn = snap.HasDrawnThisTurn
+	}
+	if snap.DrawnFromDiscard != nil {
+		game.DrawnFromDiscard = snap.DrawnFromDiscard
+	}
+	if game.SeatOrder == nil {
+		game.SeatOrder = []string{}
+	}
+	if game.StackedSpecialCardPlayers == nil {
+		game.StackedSpecialCardPlayers = []string{}
+	}
+	for _, p := range snap.Players {
+		game.Players[p.ID] = &Player{
+			ID:           p.ID,
+			Name:         p.Name,
+			Cards:        p.Cards,
+			Ready:        p.Ready,
+			Score:        p.Score,
+			Total:        p.Total,
+			SessionToken: p.SessionToken,
+		}
+	}
+	game.publish()
+	return game
+}
+
+// adjournStore keeps adjourned (or hibernated) games in a GameStore, for a limited time,
+// so they survive restarts
+type adjournStore struct {
+	games GameStore
+	ttl   time.Duration // How long an adjourned game can be restored
+}
+
+var errAdjournExpired = errors.New("adjourned game expired")
+
+// newAdjournStore keeps games as JSON files in dir
+func newAdjournStore(dir string, ttl time.Duration) *adjournStore {
+	return &adjournStore{games: newFileGameStore(dir), ttl: ttl}
+}
+
+func (s *adjournStore) Save(ctx context.Context, snap *gameSnapshot) error {
+	return s.games.SaveGame(ctx, snap)
+}
+
+// Load returns the adjourned game, or an error wrapping os.ErrNotExist if there is none.
+// Expired games are deleted and reported as errAdjournExpired.
+func (s *adjournStore) Load(ctx context.Context, gameID string) (*gameSnapshot, error) {
+	snap, err := s.games.LoadGame(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(snap.ExpiresAt) {
+		s.Delete(gameID)
+		return nil, errAdjournExpired
+	}
+	return snap, nil
+}
+
+func (s *adjournStore) Delete(gameID string) error {
+	return s.games.DeleteGame(context.Background(), gameID)
+}
+
+// PurgeExpired deletes every saved game that can no longer be restored, returning how many.
+// Games are otherwise only found to have expired when someone tries to rejoin them.
+func (s *adjournStore) PurgeExpired(ctx context.Context) (int, error) {
+	ids, err := s.games.ListGames(ctx)
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, id := range ids {
+		if _, err := s.Load(ctx, id); errors.Is(err, errAdjournExpired) {
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// Adjourn pauses the game and saves it so it can be continued later, even after a
+// server restart. Only the host can adjourn.
+func (g *Game) Adjourn(ctx context.Context, playerID string, store *adjournStore) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if store == nil || playerID != g.HostID {
+		return false
+	}
+	if g.Status != protocol.StatusPlaying && g.Status != protocol.StatusPaused {
+		return false
+	}
+
+	now := time.Now()
+	snap := g.snapshot()
+	snap.Status = protocol.StatusPaused
+	snap.AdjournedAt = now
+	snap.ExpiresAt = now.Add(store.ttl)
+	if err := store.Save(ctx, snap); err != nil {
+		return false
+	}
+
+	g.Status = protocol.StatusPaused
+	g.PausedAt = now
+	g.PauseVotes = make(map[string]bool)
+	g.RejoinedSincePause = make(map[string]bool)
+	g.Adjourned = true
+	g.adjournStore = store
+
+	g.broadcast(Message{
+		Type: protocol.MsgGameAdjourned,
+		Payload: map[string]interface{}{
+			"expiresAt": snap.ExpiresAt.UnixMilli(),
+		},
+	})
+	g.broadcastGameState()
+	return true
+}
+
+// RestoreSeat reattaches a connection to the seat owning sessionToken, first sending it the
+// events the seat was sent after lastSeq (see catchUpBuffer; -1 sends none).
+// Returns the seat's player ID, or "" if no seat matches.
+func (g *Game) RestoreSeat(sessionToken string, conn *websocket.Conn, lastSeq int64) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if sessionToken == "" {
+		return ""
+	}
+	for _, player := range g.Players {
+		if player.SessionToken == sessionToken {
+			g.bindConn(player, conn)
+			g.replayMissed(player.ID, conn, lastSeq)
+			g.noteRejoin(player.ID)
+			g.broadcastLobby()
+			return player.ID
+		}
+	}
+	return ""
+}
+
+// clearAdjourned drops the saved copy once an adjourned game is back in play.
+// Caller must hold g.mu.
+func (g *Game) clearAdjourned() {
+	if !g.Adjourned {
+		return
+	}
+	g.Adjourned = false
+	if g.adjournStore != nil {
+		g.adjournStore.Delete(g.ID)
+	}
+}
+
+// SessionToken returns the secret a player presents to reclaim their seat
+func (g *Game) SessionToken(playerID string) string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if player, exists := g.Players[playerID]; exists {
+		return player.SessionToken
+	}
+	return ""
+}
+
+// SeatForToken returns the ID of the player holding sessionToken, or "" if nobody does
+func (g *Game) SeatForToken(sessionToken string) string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if sessionToken == "" {
+		return ""
+	}
+	for id, player := range g.Players {
+		if player.SessionToken == sessionToken {
+			return id
+		}
+	}
+	return ""
+}
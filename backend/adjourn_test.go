@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAdjournAndRestore(t *testing.T) {
+	store := newAdjournStore(t.TempDir(), 24*time.Hour)
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+
+	host := game.HostID
+	currentPlayer := game.CurrentPlayer
+	hand := append([]Card(nil), game.Players[playerIDs[1]].Cards...)
+
+	if game.Adjourn(context.Background(), playerIDs[1], store) {
+		t.Error("Only the host should be able to adjourn")
+	}
+	if !game.Adjourn(context.Background(), host, store) {
+		t.Fatal("Host should be able to adjourn")
+	}
+	if game.Status != "paused" {
+		t.Errorf("Expected adjourned game to be paused, got '%s'", game.Status)
+	}
+
+	// Simulate a restart: a fresh manager picks the game up from disk
+	gm := &GameManager{games: make(map[string]*Game), adjourned: store}
+	restored := gm.GetGame(context.Background(), "test-game")
+	if restored == nil {
+		t.Fatal("Adjourned game should be found after a restart")
+	}
+
+	if !restored.Adjourned || restored.Status != "paused" {
+		t.Fatal("Restored game should still be adjourned and paused")
+	}
+	if restored.CurrentPlayer != currentPlayer {
+		t.Error("Restored game should keep the current player")
+	}
+	for i, card := range restored.Players[playerIDs[1]].Cards {
+		if card != hand[i] {
+			t.Fatal("Restored game should keep each player's hand")
+		}
+	}
+
+	// Seats can't be claimed by ID alone
+	if restored.AddPlayer(playerIDs[1], "Impostor", nil) {
+		t.Error("Adjourned seats should require the session token")
+	}
+	if restored.RestoreSeat("wrong-token", nil, -1) != "" {
+		t.Error("Unknown token should not restore a seat")
+	}
+
+	for _, id := range playerIDs {
+		token := game.Players[id].SessionToken
+		if restored.RestoreSeat(token, nil, -1) != id {
+			t.Fatalf("Token should restore seat %s", id)
+		}
+	}
+
+	if restored.Status != "playing" || restored.Adjourned {
+		t.Error("Game should resume once every player reclaimed their seat")
+	}
+	if _, err := store.Load(context.Background(), "test-game"); err == nil {
+		t.Error("Saved copy should be removed once the game resumes")
+	}
+}
+
+func TestAdjournedGameExpires(t *testing.T) {
+	store := newAdjournStore(t.TempDir(), -time.Hour)
+	game := createTestGame("test-game")
+	addTestPlayers(game, 2)
+	game.StartGame()
+
+	if !game.Adjourn(context.Background(), game.HostID, store) {
+		t.Fatal("Host should be able to adjourn")
+	}
+	if _, err := store.Load(context.Background(), "test-game"); err != errAdjournExpired {
+		t.Errorf("Expected expired game, got %v", err)
+	}
+
+	gm := &GameManager{games: make(map[string]*Game), adjourned: store}
+	if gm.GetGame(context.Background(), "test-game") != nil {
+		t.Error("Expired game should not be restored")
+	}
+}
+
+func TestAdjournRespectsContext(t *testing.T) {
+	store := newAdjournStore(t.TempDir(), 24*time.Hour)
+	game := createTestGame("test-game")
+	addTestPlayers(game, 2)
+	game.StartGame()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if game.Adjourn(ctx, game.HostID, store) {
+		t.Error("Adjourning should fail once the request is cancelled")
+	}
+	if game.Status != "playing" {
+		t.Errorf("Expected game to keep playing, got '%s'", game.Status)
+	}
+}
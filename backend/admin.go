@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// adminKey is the secret admins send as "Authorization: Bearer <key>"; empty disables the admin API
+var adminKey string
+
+func authorizeAdmin(r *http.Request) bool {
+	if adminKey == "" {
+		return false
+	}
+	key := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) == 1
+}
+
+// writeAdminJSON answers an admin API request
+func writeAdminJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// handleAdminBans manages the ban list:
+//
+//	GET    /admin/bans       list bans in force
+//	POST   /admin/bans       add a ban: {"kind", "value", "reason", "durationSecs"} (0 = permanent)
+//	DELETE /admin/bans/{id}  lift a ban
+func handleAdminBans(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/bans"), "/")
+
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		writeAdminJSON(w, http.StatusOK, map[string]interface{}{"bans": bans.List()})
+
+	case r.Method == http.MethodPost && id == "":
+		var req struct {
+			Kind         string `json:"kind"`
+			Value        string `json:"value"`
+			Reason       string `json:"reason"`
+			DurationSecs int64  `json:"durationSecs"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		ban, err := bans.Add(req.Kind, req.Value, req.Reason, time.Duration(req.DurationSecs)*time.Second)
+		if err == errInvalidBan {
+			http.Error(w, "kind must be \"ip\" or \"player\" with a value, and durationSecs can't be negative", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "could not save ban", http.StatusInternalServerError)
+			return
+		}
+		writeAdminJSON(w, http.StatusCreated, ban)
+
+	case r.Method == http.MethodDelete && id != "":
+		removed, err := bans.Remove(id)
+		if err != nil {
+			http.Error(w, "could not save bans", http.StatusInternalServerError)
+			return
+		}
+		if !removed {
+			http.Error(w, "ban not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"pablo/protocol"
+)
+
+const analyticsTopScores = 5 // How many of the most common winning scores to report per day
+
+// dayStats sums up one UTC day of games from the event log
+type dayStats struct {
+	Date                 string       `json:"date"`
+	Games                int          `json:"games"`
+	Rounds               int          `json:"rounds"`
+	AvgRoundDurationSecs float64      `json:"avgRoundDurationSecs"`
+	AvgPlayersPerGame    float64      `json:"avgPlayersPerGame"`
+	WinningScores        []scoreCount `json:"winningScores"` // Most common first
+}
+
+type scoreCount struct {
+	Score int `json:"score"`
+	Count int `json:"count"`
+}
+
+type analyticsReport struct {
+	GeneratedAt time.Time  `json:"generatedAt"`
+	Days        []dayStats `json:"days"` // Oldest first
+}
+
+// aggregateEventLogs reads every daily event log in dir and builds the usage report
+func aggregateEventLogs(dir string) (*analyticsReport, error) {
+	files, err := filepath.Glob(filepath.Join(dir, eventLogName("*")))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	report := &analyticsReport{GeneratedAt: time.Now().UTC(), Days: []dayStats{}}
+	for _, file := range files {
+		date := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), "events-"), ".jsonl")
+		stats, err := aggregateDay(file)
+		if err != nil {
+			return nil, err
+		}
+		stats.Date = date
+		report.Days = append(report.Days, *stats)
+	}
+	return report, nil
+}
+
+func aggregateDay(path string) (*dayStats, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	players := make(map[string]int) // Seats at the deal, per game
+	var durationMs float64
+	var timedRounds, rounds int
+	winningScores := make(map[int]int)
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var event GameEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			// A line cut short by a crash shouldn't hide the rest of the day
+			continue
+		}
+		switch event.Type {
+		case protocol.EventGameStarted:
+			seats, _ := event.Data["seatOrder"].([]interface{})
+			if len(seats) > players[event.GameID] {
+				players[event.GameID] = len(seats)
+			}
+		case protocol.EventRoundEnded:
+			rounds++
+			if ms, ok := event.Data["durationMs"].(float64); ok {
+				durationMs += ms
+				timedRounds++
+			}
+			scores, _ := event.Data["scores"].(map[string]interface{})
+			winners, _ := event.Data["winners"].([]interface{})
+			for _, winner := range winners {
+				id, _ := winner.(string)
+				if score, ok := scores[id].(float64); ok {
+					winningScores[int(score)]++
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	stats := &dayStats{Games: len(players), Rounds: rounds, WinningScores: []scoreCount{}}
+	if timedRounds > 0 {
+		stats.AvgRoundDurationSecs = durationMs / float64(timedRounds) / 1000
+	}
+	if len(players) > 0 {
+		seats := 0
+		for _, n := range players {
+			seats += n
+		}
+		stats.AvgPlayersPerGame = float64(seats) / float64(len(players))
+	}
+	for score, count := range winningScores {
+		stats.WinningScores = append(stats.WinningScores, scoreCount{Score: score, Count: count})
+	}
+	sort.Slice(stats.WinningScores, func(i, j int) bool {
+		a, b := stats.WinningScores[i], stats.WinningScores[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Score < b.Score
+	})
+	if len(stats.WinningScores) > analyticsTopScores {
+		stats.WinningScores = stats.WinningScores[:analyticsTopScores]
+	}
+	return stats, nil
+}
+
+// analyticsJob periodically rebuilds the report from the event logs
+type analyticsJob struct {
+	dir    string
+	report *analyticsReport
+	mu     sync.RWMutex
+}
+
+// analytics is the server's analytics job; nil when event logging is off
+var analytics *analyticsJob
+
+func newAnalyticsJob(dir string) *analyticsJob {
+	return &analyticsJob{dir: dir}
+}
+
+func (a *analyticsJob) Refresh() error {
+	report, err := aggregateEventLogs(a.dir)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.report = report
+	a.mu.Unlock()
+	return nil
+}
+
+// Run refreshes the report now and then every interval until ctx is done
+func (a *analyticsJob) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := a.Refresh(); err != nil {
+			log.Println("Analytics error:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *analyticsJob) Report() *analyticsReport {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.report
+}
+
+// handleAnalytics serves GET /analytics with the latest report
+func handleAnalytics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if analytics == nil {
+		http.Error(w, "analytics disabled", http.StatusNotFound)
+		return
+	}
+	report := analytics.Report()
+	if report == nil {
+		http.Error(w, "analytics not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(report)
+}
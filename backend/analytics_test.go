@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestEventLog(t *testing.T, dir string, evs []GameEvent) {
+	t.Helper()
+	publisher, err := newEventLogPublisher(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, event := range evs {
+		if err := publisher.Publish(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	publisher.Close()
+}
+
+func TestAggregateEventLogs(t *testing.T) {
+	dir := t.TempDir()
+	day1 := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+
+	round := func(gameID string, at time.Time, durationMs int, scores map[string]int, winners []string) GameEvent {
+		return GameEvent{Type: "roundEnded", GameID: gameID, Time: at, Data: map[string]interface{}{
+			"scores": scores, "winners": winners, "durationMs": durationMs,
+		}}
+	}
+	started := func(gameID string, at time.Time, seats ...string) GameEvent {
+		return GameEvent{Type: "gameStarted", GameID: gameID, Time: at, Data: map[string]interface{}{"seatOrder": seats}}
+	}
+
+	writeTestEventLog(t, dir, []GameEvent{
+		started("g1", day1, "a", "b"),
+		round("g1", day1, 60000, map[string]int{"a": 3, "b": 10}, []string{"a"}),
+		started("g2", day1, "a", "b", "c", "d"),
+		round("g2", day1, 120000, map[string]int{"a": 3, "b": 3, "c": 9, "d": 12}, []string{"a", "b"}),
+		started("g3", day2, "x", "y", "z"),
+		round("g3", day2, 30000, map[string]int{"x": 0, "y": 4, "z": 5}, []string{"x"}),
+	})
+
+	report, err := aggregateEventLogs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Days) != 2 {
+		t.Fatalf("Expected 2 days, got %d", len(report.Days))
+	}
+
+	first := report.Days[0]
+	if first.Date != "2024-03-01" || first.Games != 2 || first.Rounds != 2 {
+		t.Errorf("Unexpected day totals: %+v", first)
+	}
+	if first.AvgRoundDurationSecs != 90 {
+		t.Errorf("Expected 90s average round, got %v", first.AvgRoundDurationSecs)
+	}
+	if first.AvgPlayersPerGame != 3 {
+		t.Errorf("Expected 3 players per game, got %v", first.AvgPlayersPerGame)
+	}
+	if len(first.WinningScores) != 1 || first.WinningScores[0] != (scoreCount{Score: 3, Count: 3}) {
+		t.Errorf("Expected score 3 to have won 3 times, got %+v", first.WinningScores)
+	}
+
+	if report.Days[1].Date != "2024-03-02" || report.Days[1].Games != 1 {
+		t.Errorf("Unexpected second day: %+v", report.Days[1])
+	}
+}
+
+func TestAggregateSkipsTruncatedLines(t *testing.T) {
+	dir := t.TempDir()
+	data := `{"type":"gameStarted","gameID":"g1","data":{"seatOrder":["a","b"]}}` + "\n" + `{"type":"roundEn`
+	if err := os.WriteFile(filepath.Join(dir, eventLogName("2024-03-01")), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := aggregateEventLogs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Days) != 1 || report.Days[0].Games != 1 || report.Days[0].Rounds != 0 {
+		t.Errorf("Expected the complete line to count and the partial one to be skipped, got %+v", report.Days)
+	}
+}
+
+func TestAnalyticsFromPlayedGame(t *testing.T) {
+	dir := t.TempDir()
+	publisher, err := newEventLogPublisher(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := events
+	events = newEventBus(publisher)
+
+	game := createTestGame("test-game")
+	addTestPlayers(game, 3)
+	game.StartGame()
+	game.mu.Lock()
+	game.EndRound()
+	game.mu.Unlock()
+
+	events.Close()
+	events = previous
+
+	job := newAnalyticsJob(dir)
+	if err := job.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	days := job.Report().Days
+	if len(days) != 1 || days[0].Games != 1 || days[0].Rounds != 1 || days[0].AvgPlayersPerGame != 3 {
+		t.Errorf("Unexpected report for one 3-player game: %+v", days)
+	}
+}
+
+func TestHandleAnalytics(t *testing.T) {
+	previous := analytics
+	defer func() { analytics = previous }()
+
+	analytics = nil
+	rec := httptest.NewRecorder()
+	handleAnalytics(rec, httptest.NewRequest(http.MethodGet, "/analytics", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 with analytics disabled, got %d", rec.Code)
+	}
+
+	analytics = newAnalyticsJob(t.TempDir())
+	if err := analytics.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	handleAnalytics(rec, httptest.NewRequest(http.MethodGet, "/analytics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	var report analyticsReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Days == nil {
+		t.Error("Expected an empty list of days, not null")
+	}
+}
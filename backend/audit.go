@@ -0,0 +1,291 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Connection events in the audit log
+const (
+	connConnected    = "connected"
+	connDisconnected = "disconnected"
+	connTimedOut     = "timedOut" // The connection stopped answering pings and was closed
+)
+
+// AuditChatLine is a chat line as it was sent to the table, after filtering
+type AuditChatLine struct {
+	Time     time.Time `json:"time"`
+	PlayerID string    `json:"playerID"`
+	Name     string    `json:"name"`
+	Text     string    `json:"text"`
+	Shadowed bool      `json:"shadowed,omitempty"` // Sent while shadow-muted; only the sender saw it
+}
+
+// AuditConnection records a seat being taken up or let go by a connection
+type AuditConnection struct {
+	Time     time.Time `json:"time"`
+	PlayerID string    `json:"playerID"`
+	Name     string    `json:"name"`
+	IP       string    `json:"ip"`
+	Event    string    `json:"event"` // "connected", "disconnected" or "timedOut"
+}
+
+// AuditExport is everything the server knows about a game, for investigating reports and disputes
+type AuditExport struct {
+	GameID      string            `json:"gameID"`
+	ExportedAt  time.Time         `json:"exportedAt"`
+	Redacted    []string          `json:"redacted,omitempty"` // Which of "ips", "names" and "chat" were redacted
+	Events      []GameEvent       `json:"events"`
+	Chat        []AuditChatLine   `json:"chat"`
+	Connections []AuditConnection `json:"connections"`
+}
+
+// auditRedaction picks the personal data left out of an export
+type auditRedaction struct {
+	IPs   bool // IP addresses become pseudonyms that stay the same within one export
+	Names bool // Player names become "Player 1", "Player 2"... in order of appearance
+	Chat  bool // Chat text is removed; who spoke and when is kept
+}
+
+// parseAuditRedaction reads a comma separated list of "ips", "names", "chat" or "all"
+func parseAuditRedaction(spec string) (auditRedaction, bool) {
+	var redact auditRedaction
+	for _, part := range strings.Split(spec, ",") {
+		switch strings.TrimSpace(part) {
+		case "":
+		case "ips":
+			redact.IPs = true
+		case "names":
+			redact.Names = true
+		case "chat":
+			redact.Chat = true
+		case "all":
+			redact = auditRedaction{IPs: true, Names: true, Chat: true}
+		default:
+			return auditRedaction{}, false
+		}
+	}
+	return redact, true
+}
+
+func (r auditRedaction) list() []string {
+	var list []string
+	if r.IPs {
+		list = append(list, "ips")
+	}
+	if r.Names {
+		list = append(list, "names")
+	}
+	if r.Chat {
+		list = append(list, "chat")
+	}
+	return list
+}
+
+// appendCapped adds an entry to one of a game's logs, dropping the oldest past auditLogLimit
+func appendCapped[T any](log []T, entry T) []T {
+	log = append(log, entry)
+	if len(log) > auditLogLimit {
+		log = log[len(log)-auditLogLimit:]
+	}
+	return log
+}
+
+// RecordConnection notes a seat's connection coming or going, with the address it came from.
+// Players who never got a seat, like those turned away from a full table, aren't recorded.
+func (g *Game) RecordConnection(playerID, ip, event string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	name := ""
+	if player, exists := g.Players[playerID]; exists {
+		name = player.Name
+	} else {
+		// Kicked or gone; use the name they had when they connected
+		for i := len(g.connectionLog) - 1; i >= 0 && name == ""; i-- {
+			if g.connectionLog[i].PlayerID == playerID {
+				name = g.connectionLog[i].Name
+			}
+		}
+		if name == "" {
+			return
+		}
+	}
+	g.connectionLog = appendCapped(g.connectionLog, AuditConnection{
+		Time:     time.Now().UTC(),
+		PlayerID: playerID,
+		Name:     name,
+		IP:       ip,
+		Event:    event,
+	})
+}
+
+// AuditExport copies the game's logs, with the chosen personal data redacted
+func (g *Game) AuditExport(redact auditRedaction) *AuditExport {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	export := &AuditExport{
+		GameID:      g.ID,
+		ExportedAt:  time.Now().UTC(),
+		Redacted:    redact.list(),
+		Events:      make([]GameEvent, 0, len(g.eventLog)),
+		Chat:        make([]AuditChatLine, 0, len(g.chatLog)),
+		Connections: make([]AuditConnection, 0, len(g.connectionLog)),
+	}
+
+	// Pseudonyms are numbered in order of appearance; IP pseudonyms are salted per export
+	// so they can't be matched against other exports or guessed from a list of addresses
+	pseudonyms := make(map[string]string)
+	pseudonym := func(playerID string) string {
+		if _, exists := pseudonyms[playerID]; !exists {
+			pseudonyms[playerID] = "Player " + strconv.Itoa(len(pseudonyms)+1)
+		}
+		return pseudonyms[playerID]
+	}
+	salt := newUUID()
+	maskIP := func(ip string) string {
+		sum := sha256.Sum256([]byte(salt + ip))
+		return "ip-" + hex.EncodeToString(sum[:4])
+	}
+
+	for _, event := range g.eventLog {
+		if redact.Names {
+			if _, named := event.Data["name"]; named {
+				data := make(map[string]interface{}, len(event.Data))
+				for key, value := range event.Data {
+					data[key] = value
+				}
+				data["name"] = pseudonym(event.PlayerID)
+				event.Data = data
+			}
+		}
+		export.Events = append(export.Events, event)
+	}
+	for _, line := range g.chatLog {
+		if redact.Names {
+			line.Name = pseudonym(line.PlayerID)
+		}
+		if redact.Chat {
+			line.Text = ""
+		}
+		export.Chat = append(export.Chat, line)
+	}
+	for _, conn := range g.connectionLog {
+		if redact.Names {
+			conn.Name = pseudonym(conn.PlayerID)
+		}
+		if redact.IPs && conn.IP != "" {
+			conn.IP = maskIP(conn.IP)
+		}
+		export.Connections = append(export.Connections, conn)
+	}
+	return export
+}
+
+// writeCSV writes the export as one table, oldest first within each kind:
+// kind, time, type, playerID, name, ip, detail
+func (e *AuditExport) writeCSV(w *csv.Writer) error {
+	w.Write([]string{"kind", "time", "type", "playerID", "name", "ip", "detail"})
+	for _, event := range e.Events {
+		detail := ""
+		if len(event.Data) > 0 {
+			data, _ := json.Marshal(event.Data)
+			detail = string(data)
+		}
+		name, _ := event.Data["name"].(string)
+		w.Write([]string{"event", event.Time.Format(time.RFC3339Nano), event.Type, event.PlayerID, name, "", detail})
+	}
+	for _, line := range e.Chat {
+		kind := ""
+		if line.Shadowed {
+			kind = "shadowed"
+		}
+		w.Write([]string{"chat", line.Time.Format(time.RFC3339Nano), kind, line.PlayerID, line.Name, "", line.Text})
+	}
+	for _, conn := range e.Connections {
+		w.Write([]string{"connection", conn.Time.Format(time.RFC3339Nano), conn.Event, conn.PlayerID, conn.Name, conn.IP, ""})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// handleAdminGames serves per-game admin tools:
+//
+//	GET  /admin/games/{id}/audit[?format=csv][&redact=ips,names,chat|all]  export the game's event log, chat and connections
+//	POST /admin/games/{id}/shadowmute                                      shadow-mute a player: {"playerID", "muted"}
+func handleAdminGames(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/games/"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && parts[1] == "audit":
+		handleAuditExport(w, r, parts[0])
+	case r.Method == http.MethodPost && parts[1] == "shadowmute":
+		handleShadowMute(w, r, parts[0])
+	case parts[1] == "audit" || parts[1] == "shadowmute":
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func handleAuditExport(w http.ResponseWriter, r *http.Request, gameID string) {
+	query := r.URL.Query()
+	redact, ok := parseAuditRedaction(query.Get("redact"))
+	if !ok {
+		http.Error(w, "redact takes ips, names, chat or all", http.StatusBadRequest)
+		return
+	}
+	game := gameManager.GetGame(r.Context(), gameID)
+	if game == nil {
+		http.Error(w, "game not found", http.StatusNotFound)
+		return
+	}
+	export := game.AuditExport(redact)
+
+	switch query.Get("format") {
+	case "", "json":
+		writeAdminJSON(w, http.StatusOK, export)
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+game.ID+`-audit.csv"`)
+		export.writeCSV(csv.NewWriter(w))
+	default:
+		http.Error(w, "format must be json or csv", http.StatusBadRequest)
+	}
+}
+
+func handleShadowMute(w http.ResponseWriter, r *http.Request, gameID string) {
+	var req struct {
+		PlayerID string `json:"playerID"`
+		Muted    bool   `json:"muted"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	game := gameManager.GetGame(r.Context(), gameID)
+	if game == nil {
+		http.Error(w, "game not found", http.StatusNotFound)
+		return
+	}
+	if !game.SetShadowMuted(req.PlayerID, req.Muted) {
+		http.Error(w, "player not found", http.StatusNotFound)
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, map[string]interface{}{"playerID": req.PlayerID, "shadowMuted": req.Muted})
+}
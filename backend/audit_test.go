@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditExport(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.RecordConnection(playerIDs[0], "203.0.113.7", connConnected)
+	game.RecordConnection("stranger", "203.0.113.8", connConnected)
+	game.Chat(playerIDs[1], "good luck")
+	game.StartGame()
+
+	export := game.AuditExport(auditRedaction{})
+	if len(export.Events) != 4 || export.Events[0].Data["name"] != "Player 1" {
+		t.Errorf("Expected both joins, the start and the first turn, got %+v", export.Events)
+	}
+	if len(export.Chat) != 1 || export.Chat[0].Text != "good luck" {
+		t.Errorf("Expected the chat line, got %+v", export.Chat)
+	}
+	if len(export.Connections) != 1 || export.Connections[0].IP != "203.0.113.7" {
+		t.Errorf("Expected only the seated player's connection, got %+v", export.Connections)
+	}
+
+	redacted := game.AuditExport(auditRedaction{IPs: true, Names: true, Chat: true})
+	if ip := redacted.Connections[0].IP; ip == "203.0.113.7" || !strings.HasPrefix(ip, "ip-") {
+		t.Errorf("Expected a masked IP, got %q", ip)
+	}
+	if redacted.Chat[0].Text != "" || redacted.Chat[0].Name != "Player 2" {
+		t.Errorf("Expected chat text and name redacted, got %+v", redacted.Chat[0])
+	}
+	if export.Chat[0].Text != "good luck" {
+		t.Error("Redacting should not change the game's own log")
+	}
+}
+
+func TestParseAuditRedaction(t *testing.T) {
+	if redact, ok := parseAuditRedaction("ips, chat"); !ok || !redact.IPs || redact.Names || !redact.Chat {
+		t.Errorf("Unexpected redaction %+v", redact)
+	}
+	if redact, _ := parseAuditRedaction("all"); redact != (auditRedaction{IPs: true, Names: true, Chat: true}) {
+		t.Errorf("all should redact everything, got %+v", redact)
+	}
+	if _, ok := parseAuditRedaction("emails"); ok {
+		t.Error("Unknown fields should be rejected")
+	}
+}
+
+func TestHandleAdminGamesAudit(t *testing.T) {
+	savedKey := adminKey
+	adminKey = "secret"
+	defer func() { adminKey = savedKey }()
+
+	game := gameManager.CreateGame(context.Background())
+	game.AddPlayer("alice", "Alice", nil)
+	game.RecordConnection("alice", "198.51.100.1", connConnected)
+
+	request := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		handleAdminGames(rec, req)
+		return rec
+	}
+
+	rec := request("/admin/games/" + game.ID + "/audit")
+	var export AuditExport
+	if err := json.NewDecoder(rec.Body).Decode(&export); err != nil || export.GameID != game.ID {
+		t.Fatalf("Expected the game's export, got %d: %v", rec.Code, err)
+	}
+
+	rec = request("/admin/games/" + game.ID + "/audit?format=csv&redact=ips")
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := rows[len(rows)-1]
+	if last[0] != "connection" || last[5] == "198.51.100.1" {
+		t.Errorf("Expected a redacted connection row last, got %v", last)
+	}
+
+	if rec := request("/admin/games/nope/audit"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", rec.Code)
+	}
+	if rec := request("/admin/games/" + game.ID + "/audit?redact=everything"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", rec.Code)
+	}
+}
+
+func TestConnectionsRecordedOverWS(t *testing.T) {
+	conn, session := createTestGameOverWS(t)
+	game := gameManager.GetGame(context.Background(), session["gameID"].(string))
+	conn.Close()
+
+	var connections []AuditConnection
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		connections = game.AuditExport(auditRedaction{}).Connections
+		if len(connections) == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the disconnect to be recorded, got %+v", connections)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if connections[0].Event != connConnected || connections[1].Event != connDisconnected {
+		t.Errorf("Expected a connect then a disconnect, got %+v", connections)
+	}
+	if connections[0].IP != "127.0.0.1" {
+		t.Errorf("Expected the loopback address, got %q", connections[0].IP)
+	}
+}
+
+func TestHandleAdminShadowMute(t *testing.T) {
+	savedKey := adminKey
+	adminKey = "secret"
+	defer func() { adminKey = savedKey }()
+
+	game := gameManager.CreateGame(context.Background())
+	game.AddPlayer("dave", "Dave", nil)
+
+	request := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/games/"+game.ID+"/shadowmute", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		handleAdminGames(rec, req)
+		return rec
+	}
+
+	if rec := request(`{"playerID":"dave","muted":true}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	game.mu.RLock()
+	muted := game.ShadowMuted["dave"]
+	game.mu.RUnlock()
+	if !muted {
+		t.Error("Dave should be shadow-muted")
+	}
+	if rec := request(`{"playerID":"nobody","muted":true}`); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a player who isn't seated, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"pablo/protocol"
+)
+
+// Kinds of ban. Players have no accounts, so player bans match the name they sit down with.
+const (
+	banIP     = "ip"
+	banPlayer = "player"
+)
+
+// Ban keeps an IP address or a player name off the server until it expires
+type Ban struct {
+	ID        string     `json:"id"`
+	Kind      string     `json:"kind"`  // "ip" or "player"
+	Value     string     `json:"value"` // The IP address, or the player name
+	Reason    string     `json:"reason"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"` // Nil for a permanent ban
+}
+
+func (b *Ban) active(now time.Time) bool {
+	return b.ExpiresAt == nil || now.Before(*b.ExpiresAt)
+}
+
+// matches reports whether the ban covers this IP address or player name
+func (b *Ban) matches(ip, name string) bool {
+	switch b.Kind {
+	case banIP:
+		return ip != "" && b.Value == ip
+	case banPlayer:
+		return name != "" && strings.EqualFold(b.Value, strings.TrimSpace(name))
+	}
+	return false
+}
+
+var errInvalidBan = errors.New("invalid ban")
+
+// banList holds the server's bans in memory, saving them to a JSON file (when one is
+// configured) every time they change
+type banList struct {
+	path string
+	bans map[string]*Ban
+	mu   sync.RWMutex
+}
+
+// bans is the server's ban list; in memory only unless main loads it from a file
+var bans = newBanList("")
+
+func newBanList(path string) *banList {
+	return &banList{path: path, bans: make(map[string]*Ban)}
+}
+
+// loadBanList reads the bans saved at path. A missing file is an empty list.
+func loadBanList(path string) (*banList, error) {
+	list := newBanList(path)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return list, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var saved []*Ban
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, ban := range saved {
+		if ban.active(now) {
+			list.bans[ban.ID] = ban
+		}
+	}
+	return list, nil
+}
+
+// Add bans an IP address or player name for duration, or for good if duration is zero
+func (l *banList) Add(kind, value, reason string, duration time.Duration) (*Ban, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || duration < 0 {
+		return nil, errInvalidBan
+	}
+	switch kind {
+	case banIP:
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, errInvalidBan
+		}
+		value = ip.String()
+	case banPlayer:
+	default:
+		return nil, errInvalidBan
+	}
+
+	now := time.Now().UTC()
+	ban := &Ban{
+		ID:        newUUID(),
+		Kind:      kind,
+		Value:     value,
+		Reason:    reason,
+		CreatedAt: now,
+	}
+	if duration > 0 {
+		expires := now.Add(duration)
+		ban.ExpiresAt = &expires
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.bans[ban.ID] = ban
+	if err := l.save(); err != nil {
+		delete(l.bans, ban.ID)
+		return nil, err
+	}
+	return ban, nil
+}
+
+// Remove lifts a ban. Returns false if there was no such ban.
+func (l *banList) Remove(id string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ban, exists := l.bans[id]
+	if !exists {
+		return false, nil
+	}
+	delete(l.bans, id)
+	if err := l.save(); err != nil {
+		l.bans[id] = ban
+		return false, err
+	}
+	return true, nil
+}
+
+// List returns the bans still in force, newest first
+func (l *banList) List() []*Ban {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	now := time.Now()
+	list := make([]*Ban, 0, len(l.bans))
+	for _, ban := range l.bans {
+		if ban.active(now) {
+			list = append(list, ban)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	return list
+}
+
+// Check returns the ban in force against this IP address or player name, or nil
+func (l *banList) Check(ip, name string) *Ban {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	now := time.Now()
+	for _, ban := range l.bans {
+		if ban.active(now) && ban.matches(ip, name) {
+			return ban
+		}
+	}
+	return nil
+}
+
+// save writes the bans to disk. Caller must hold l.mu.
+func (l *banList) save() error {
+	if l.path == "" {
+		return nil
+	}
+	list := make([]*Ban, 0, len(l.bans))
+	for _, ban := range l.bans {
+		list = append(list, ban)
+	}
+	return writeJSONFile(l.path, list)
+}
+
+// clientIP is the address a request came from
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String()
+	}
+	return host
+}
+
+// banMessage tells a banned client why, and for how long, in English; the upgrade request
+// it's refused in comes before any locale is known
+func banMessage(ban *Ban) string {
+	key, args := banKey(ban)
+	return translate(defaultLocale, key, args...)
+}
+
+// banKey picks the catalog message for ban and what fills it in
+func banKey(ban *Ban) (string, []interface{}) {
+	key := protocol.CodeBanned
+	var args []interface{}
+	switch {
+	case ban.Reason != "" && ban.ExpiresAt != nil:
+		key += ".reasonUntil"
+		args = append(args, ban.Reason, ban.ExpiresAt.Format(time.RFC3339))
+	case ban.Reason != "":
+		key += ".reason"
+		args = append(args, ban.Reason)
+	case ban.ExpiresAt != nil:
+		key += ".until"
+		args = append(args, ban.ExpiresAt.Format(time.RFC3339))
+	}
+	return key, args
+}
+
+// sendBanned tells conn it's banned, in the language it joined with
+func sendBanned(conn *websocket.Conn, ban *Ban) {
+	key, args := banKey(ban)
+	sendError(conn, key, args...)
+}
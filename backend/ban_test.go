@@ -0,0 +1,148 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// useTestBans swaps in an empty ban list for the duration of a test
+func useTestBans(t *testing.T) *banList {
+	t.Helper()
+	saved := bans
+	bans = newBanList("")
+	t.Cleanup(func() { bans = saved })
+	return bans
+}
+
+func TestBanList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bans.json")
+	list, err := loadBanList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := list.Add(banIP, "not-an-ip", "", 0); err != errInvalidBan {
+		t.Errorf("Expected errInvalidBan, got %v", err)
+	}
+	ipBan, err := list.Add(banIP, "10.0.0.1", "spam", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := list.Add(banPlayer, "Troll", "abuse", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	if list.Check("10.0.0.1", "") == nil {
+		t.Error("Banned IP should be caught")
+	}
+	if list.Check("10.0.0.2", " troll ") == nil {
+		t.Error("Player bans should ignore case and surrounding spaces")
+	}
+	if list.Check("10.0.0.2", "Alice") != nil {
+		t.Error("Alice isn't banned")
+	}
+
+	// The list survives a restart
+	reloaded, err := loadBanList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.List()) != 2 {
+		t.Errorf("Expected 2 saved bans, got %d", len(reloaded.List()))
+	}
+
+	if removed, err := reloaded.Remove(ipBan.ID); err != nil || !removed {
+		t.Fatalf("Expected ban to be lifted, got %v, %v", removed, err)
+	}
+	if reloaded.Check("10.0.0.1", "") != nil {
+		t.Error("Lifted ban should no longer apply")
+	}
+}
+
+func TestBanExpires(t *testing.T) {
+	list := newBanList("")
+	ban, err := list.Add(banPlayer, "Troll", "", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Minute)
+	ban.ExpiresAt = &past
+
+	if list.Check("", "Troll") != nil {
+		t.Error("Expired ban should not apply")
+	}
+	if len(list.List()) != 0 {
+		t.Error("Expired bans should not be listed")
+	}
+}
+
+func TestBannedIPCannotConnect(t *testing.T) {
+	list := useTestBans(t)
+	list.Add(banIP, "127.0.0.1", "spam", 0)
+
+	server := httptest.NewServer(http.HandlerFunc(handleWebSocket))
+	defer server.Close()
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err == nil {
+		t.Fatal("Banned IP should not be able to connect")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403, got %v", resp)
+	}
+}
+
+func TestBannedPlayerCannotJoin(t *testing.T) {
+	list := useTestBans(t)
+	list.Add(banPlayer, "Troll", "abuse", 0)
+
+	conn := dialTestServer(t)
+	sendTestMessage(t, conn, "createGame", map[string]interface{}{"name": "Troll"})
+	if code := readMessageOfType(t, conn, "error")["code"]; code != "BANNED" {
+		t.Errorf("Expected BANNED, got %v", code)
+	}
+}
+
+func TestHandleAdminBans(t *testing.T) {
+	useTestBans(t)
+	savedKey := adminKey
+	adminKey = "secret"
+	defer func() { adminKey = savedKey }()
+
+	request := func(method, path, body, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		rec := httptest.NewRecorder()
+		handleAdminBans(rec, req)
+		return rec
+	}
+
+	if rec := request(http.MethodGet, "/admin/bans", "", "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with the wrong key, got %d", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/admin/bans", `{"kind":"ship","value":"x"}`, "secret"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown kind, got %d", rec.Code)
+	}
+	rec := request(http.MethodPost, "/admin/bans", `{"kind":"player","value":"Troll","reason":"abuse","durationSecs":3600}`, "secret")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	list := bans.List()
+	if len(list) != 1 || list[0].Value != "Troll" || list[0].ExpiresAt == nil {
+		t.Fatalf("Expected one timed ban on Troll, got %+v", list)
+	}
+
+	if rec := request(http.MethodDelete, "/admin/bans/"+list[0].ID, "", "secret"); rec.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", rec.Code)
+	}
+	if rec := request(http.MethodDelete, "/admin/bans/"+list[0].ID, "", "secret"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a lifted ban, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+// Blind Pablo, played with the BlindPablo house rule: a player who calls Pablo on their
+// first turn of the round, before drawing or making any other move, plays the call for
+// double. Classic scoring here only counts the hands, so the stake is twice the usual
+// house bonus of 5 points off for a call that wins the round and 10 on for one that doesn't.
+const (
+	blindPabloReward  = 10
+	blindPabloPenalty = 20
+)
+
+// applyBlindPablo scores a blind Pablo call once the hands are counted. Caller must hold g.mu.
+func (g *Game) applyBlindPablo(caller string) {
+	player, seated := g.Players[caller]
+	if !seated {
+		return
+	}
+	for _, id := range g.roundWinners() {
+		if id == caller {
+			player.Score -= blindPabloReward
+			return
+		}
+	}
+	player.Score += blindPabloPenalty
+}
@@ -0,0 +1,96 @@
+package main
+
+import "testing"
+
+func startBlindPabloGame(t *testing.T) (*Game, string, string) {
+	t.Helper()
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.Config.BlindPablo = true
+	game.StartGame()
+
+	current := game.CurrentPlayer
+	other := playerIDs[0]
+	if other == current {
+		other = playerIDs[1]
+	}
+	return game, current, other
+}
+
+func setHand(game *Game, playerID string, ranks ...string) {
+	cards := make([]Card, len(ranks))
+	for i, rank := range ranks {
+		cards[i] = Card{Suit: "clubs", Rank: rank}
+	}
+	game.Players[playerID].Cards = cards
+}
+
+func TestBlindPabloWon(t *testing.T) {
+	game, current, other := startBlindPabloGame(t)
+	game.CallPablo(current)
+	if !game.PabloBlind {
+		t.Fatal("A call before any move should be blind")
+	}
+
+	setHand(game, current, "A", "A", "2", "3")
+	setHand(game, other, "10", "10", "10", "10")
+	game.EndRound()
+	if score := game.Players[current].Score; score != 7-blindPabloReward {
+		t.Errorf("Expected %d, got %d", 7-blindPabloReward, score)
+	}
+	if game.PabloBlind {
+		t.Error("The blind flag should be cleared when the round ends")
+	}
+}
+
+func TestBlindPabloLost(t *testing.T) {
+	game, current, other := startBlindPabloGame(t)
+	game.CallPablo(current)
+
+	setHand(game, current, "5", "5", "5", "5")
+	setHand(game, other, "A", "A", "A", "A")
+	game.EndRound()
+	if score := game.Players[current].Score; score != 20+blindPabloPenalty {
+		t.Errorf("Expected %d, got %d", 20+blindPabloPenalty, score)
+	}
+}
+
+func TestPabloNotBlindAfterMove(t *testing.T) {
+	game, current, other := startBlindPabloGame(t)
+	game.Deck[0] = Card{Suit: "clubs", Rank: "2"}
+	game.DrawCard(current)
+	game.DiscardDrawnCard(current)
+	game.CallPablo(current)
+	if game.PabloBlind {
+		t.Error("A call after drawing is not blind")
+	}
+
+	setHand(game, current, "A", "A", "A", "A")
+	setHand(game, other, "10", "10", "10", "10")
+	game.EndRound()
+	if score := game.Players[current].Score; score != 4 {
+		t.Errorf("A normal call should score only the hand, got %d", score)
+	}
+}
+
+func TestBlindPabloNeedsRule(t *testing.T) {
+	game, current, _ := startBlindPabloGame(t)
+	game.Config.BlindPablo = false
+	game.CallPablo(current)
+	if game.PabloBlind {
+		t.Error("Calls aren't blind without the rule")
+	}
+}
+
+func TestBlindPabloSecondSeat(t *testing.T) {
+	game, current, other := startBlindPabloGame(t)
+	game.Deck[0] = Card{Suit: "clubs", Rank: "2"}
+	game.DrawCard(current)
+	game.DiscardDrawnCard(current)
+	game.EndTurn(current)
+
+	game.CallPablo(other)
+	if !game.PabloBlind {
+		t.Error("The second seat's first turn should allow a blind call")
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"pablo/protocol"
+)
+
+// Players can block others. Players have no accounts, so a blocklist is a list of names the
+// client keeps and sends when joining, and blocking someone at the table adds their name and
+// sends the list back to be kept. Two players who end up at the same table anyway don't see
+// each other's chat, whichever of them did the blocking.
+
+// maxBlocked is how many names one blocklist can hold
+const maxBlocked = 500
+
+var errCantBlock = errors.New("can't block that player")
+
+// blocklists remembers the names each connection has blocked
+var blocklists sync.Map // *websocket.Conn -> map[string]bool, keyed by lowercased name
+
+func blockKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// setBlocklist replaces conn's blocklist with names
+func setBlocklist(conn *websocket.Conn, names []string) {
+	blocked := make(map[string]bool)
+	for _, name := range names {
+		if key := blockKey(name); key != "" && len(blocked) < maxBlocked {
+			blocked[key] = true
+		}
+	}
+	blocklists.Store(conn, blocked)
+}
+
+// blocklistOf returns the names conn has blocked. The map must not be modified.
+func blocklistOf(conn *websocket.Conn) map[string]bool {
+	if conn == nil {
+		return nil
+	}
+	blocked, _ := blocklists.Load(conn)
+	names, _ := blocked.(map[string]bool)
+	return names
+}
+
+func forgetBlocklist(conn *websocket.Conn) {
+	blocklists.Delete(conn)
+}
+
+// blocks reports whether either player has blocked the other. Caller must hold g.mu.
+func (g *Game) blocks(a, b string) bool {
+	playerA, playerB := g.Players[a], g.Players[b]
+	if playerA == nil || playerB == nil || a == b {
+		return false
+	}
+	return blocklistOf(playerA.Conn)[blockKey(playerB.Name)] || blocklistOf(playerB.Conn)[blockKey(playerA.Name)]
+}
+
+// SetBlocked blocks (or unblocks) another player at the table for blockerID and sends the
+// blocker their updated list to keep. Names can't be blocked while the table hides them,
+// since the list would give them away.
+func (g *Game) SetBlocked(blockerID, targetID string, blocked bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	blocker, target := g.Players[blockerID], g.Players[targetID]
+	if blocker == nil || blocker.Conn == nil {
+		return ErrNotInGame
+	}
+	if target == nil || targetID == blockerID {
+		return ErrTargetNotFound
+	}
+	if _, isBot := g.Bots[targetID]; isBot || g.hidesNames() {
+		return errCantBlock
+	}
+
+	current := blocklistOf(blocker.Conn)
+	if blocked && len(current) >= maxBlocked {
+		return errCantBlock
+	}
+	updated := make(map[string]bool, len(current)+1)
+	for name := range current {
+		updated[name] = true
+	}
+	if blocked {
+		updated[blockKey(target.Name)] = true
+	} else {
+		delete(updated, blockKey(target.Name))
+	}
+	blocklists.Store(blocker.Conn, updated)
+
+	names := make([]string, 0, len(updated))
+	for name := range updated {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	g.sendToPlayer(blockerID, Message{
+		Type:    protocol.MsgBlocklist,
+		Payload: map[string]interface{}{"blocked": names},
+	})
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestBlockedChatIsDroppedBothWays(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	blocker, blocked, bystander := newTestConn(t), newTestConn(t), newTestConn(t)
+	game.AddPlayer("player1", "Player 1", blocker)
+	game.AddPlayer("player2", "Player 2", blocked)
+	game.AddPlayer("player3", "Player 3", bystander)
+	watcher := newTestConn(t)
+	game.AddSpectator("spec", "Spec", watcher)
+	setBlocklist(watcher, []string{"player 2"})
+
+	if err := game.SetBlocked("player1", "player2", true); err != nil {
+		t.Fatal(err)
+	}
+	if !blocklistOf(blocker)["player 2"] {
+		t.Fatal("Blocking should add the name to the blocker's list")
+	}
+	game.Chat("player1", "hello")
+	game.Chat("player2", "hi")
+
+	recorder.mu.Lock()
+	if countOfType(recorder.players["player1"], "blocklist") != 1 {
+		t.Error("The blocker should be sent their list to keep")
+	}
+	if countOfType(recorder.players["player1"], "chat") != 1 || countOfType(recorder.players["player2"], "chat") != 1 {
+		t.Error("Blocked players should only see their own lines")
+	}
+	if countOfType(recorder.players["player3"], "chat") != 2 {
+		t.Error("Everyone else should see both lines")
+	}
+	if countOfType(recorder.spectators["spec"], "chat") != 1 {
+		t.Error("A spectator shouldn't see lines from a name they've blocked")
+	}
+	recorder.mu.Unlock()
+
+	game.SetBlocked("player1", "player2", false)
+	game.Chat("player2", "thanks")
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if countOfType(recorder.players["player1"], "chat") != 2 {
+		t.Error("Unblocking should let chat through again")
+	}
+	if err := game.SetBlocked("player1", "player1", true); err != ErrTargetNotFound {
+		t.Errorf("Expected blocking yourself refused, got %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"time"
+
+	"pablo/protocol"
+)
+
+// botTurnDelay is how long a bot "thinks" before each turn so humans can follow along
+var botTurnDelay = 800 * time.Millisecond
+
+const (
+	botUnknownCardValue = 7 // What a bot assumes an unseen card is worth
+	botPabloThreshold   = 5 // A bot calls Pablo once its fully known hand totals this or less
+)
+
+// botBrain is what a standard bot remembers about its own hand. Bots only know cards they
+// put there themselves or looked at with a 7; if another player's 9 moves their cards the
+// memory goes stale, the same as it would for a person who wasn't paying attention.
+type botBrain struct {
+	known map[int]Card // Own card slots the bot has seen
+}
+
+// AddBot seats a server-controlled player
+func (g *Game) AddBot(id, name string) bool {
+	if !g.AddPlayer(id, name, nil) {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.Bots[id] = &botBrain{known: make(map[int]Card)}
+	g.publish() // The seat now shows as a bot
+	return true
+}
+
+// scheduleBots starts playing bot turns in the background if a bot is up.
+// Caller must hold g.mu.
+func (g *Game) scheduleBots() {
+	if g.botsRunning || g.Status != protocol.StatusPlaying || g.peeking != nil {
+		return
+	}
+	if _, isBot := g.Bots[g.CurrentPlayer]; !isBot {
+		return
+	}
+	g.botsRunning = true
+	go g.runBots()
+}
+
+// runBots plays turns for as long as the current player is a bot
+func (g *Game) runBots() {
+	for {
+		select {
+		case <-g.ctx.Done():
+			g.mu.Lock()
+			g.botsRunning = false
+			g.mu.Unlock()
+			return
+		case <-time.After(botTurnDelay):
+		}
+
+		g.mu.Lock()
+		botID := g.CurrentPlayer
+		_, isBot := g.Bots[botID]
+		if !isBot || g.Status != protocol.StatusPlaying {
+			g.botsRunning = false
+			g.mu.Unlock()
+			return
+		}
+		g.mu.Unlock()
+
+		if !g.botTakeTurn(botID) {
+			// Something (like a pending give) is blocking the bot; whoever resolves it reschedules
+			g.mu.Lock()
+			g.botsRunning = false
+			g.mu.Unlock()
+			return
+		}
+	}
+}
+
+// botTakeTurn plays one full turn for a bot with the standard strategy: draw, keep the card
+// if it beats the worst card it believes it holds, peek with 7s, skip other powers, and call
+// Pablo once its whole hand is known and low. Returns false if the bot couldn't act.
+func (g *Game) botTakeTurn(botID string) bool {
+	if g.DrawCard(botID) != nil {
+		return false
+	}
+
+	g.mu.RLock()
+	brain := g.Bots[botID]
+	drawnCard := g.DrawnCards[botID]
+	player := g.Players[botID]
+	if brain == nil || drawnCard == nil || player == nil {
+		g.mu.RUnlock()
+		return false
+	}
+	drawn := *drawnCard
+	worstSlot, worstValue := brain.worstSlot(player.Cards, g.cardValue)
+	drawnValue := g.cardValue(drawn)
+	g.mu.RUnlock()
+
+	if worstSlot >= 0 && drawnValue < worstValue {
+		g.SwapCard(botID, worstSlot)
+		g.mu.Lock()
+		drawn.FaceUp = false
+		brain.known[worstSlot] = drawn
+		g.mu.Unlock()
+	} else {
+		g.DiscardDrawnCard(botID)
+	}
+
+	g.mu.RLock()
+	pendingSpecial, pendingPower := "", ""
+	if g.CurrentPlayer == botID && g.PendingSpecialCard != "" && len(g.DiscardPile) > 0 {
+		pendingSpecial = g.PendingSpecialCard
+		pendingPower = g.cardPower(g.DiscardPile[len(g.DiscardPile)-1])
+	}
+	unknownSlot := brain.unknownSlot(player.Cards)
+	g.mu.RUnlock()
+
+	if pendingPower == protocol.PowerPeekOwn && unknownSlot >= 0 && unknownSlot < 4 {
+		g.UseSpecialCardFromDiscard(botID, pendingSpecial, map[string]interface{}{"targetIndex": float64(unknownSlot)})
+		g.mu.Lock()
+		brain.known[unknownSlot] = player.Cards[unknownSlot]
+		g.mu.Unlock()
+	} else if pendingSpecial != "" {
+		g.SkipSpecialCard(botID)
+	}
+
+	g.mu.RLock()
+	callPablo := !g.PabloCalled && brain.knownTotal(player.Cards, g.cardValue) <= botPabloThreshold && brain.unknownSlot(player.Cards) < 0
+	g.mu.RUnlock()
+	if callPablo {
+		g.CallPablo(botID)
+	}
+
+	g.EndTurn(botID)
+	return true
+}
+
+// worstSlot returns the slot holding the card the bot believes is worth the most, scoring
+// cards with value
+func (b *botBrain) worstSlot(cards []Card, value func(Card) int) (int, int) {
+	worst, worstValue := -1, 0
+	for i, card := range cards {
+		if card.Rank == "" {
+			continue
+		}
+		cardValue := botUnknownCardValue
+		if known, ok := b.known[i]; ok {
+			cardValue = value(known)
+		}
+		if worst < 0 || cardValue > worstValue {
+			worst, worstValue = i, cardValue
+		}
+	}
+	return worst, worstValue
+}
+
+// unknownSlot returns the first card slot the bot hasn't seen, or -1
+func (b *botBrain) unknownSlot(cards []Card) int {
+	for i, card := range cards {
+		if card.Rank == "" {
+			continue
+		}
+		if _, ok := b.known[i]; !ok {
+			return i
+		}
+	}
+	return -1
+}
+
+func (b *botBrain) knownTotal(cards []Card, value func(Card) int) int {
+	total := 0
+	for i, card := range cards {
+		if card.Rank == "" {
+			continue
+		}
+		if known, ok := b.known[i]; ok {
+			total += value(known)
+		}
+	}
+	return total
+}
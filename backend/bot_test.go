@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// createBotTestGame seats a human and a bot with the bot to play. Bots are driven by
+// hand in tests, so the background runner is marked as already running.
+func createBotTestGame() (*Game, string) {
+	game := createTestGame("test-game")
+	game.AddPlayer("human", "Human", nil)
+	game.AddBot("bot-1", "Bot 1")
+	game.botsRunning = true
+	game.StartGame()
+	game.CurrentPlayer = "bot-1"
+	return game, "bot-1"
+}
+
+func TestBotKeepsLowCard(t *testing.T) {
+	game, botID := createBotTestGame()
+	game.Deck[0] = Card{Suit: "hearts", Rank: "A"}
+	// Keep the ace of hearts out of the dealt hand so only the drawn one can match
+	game.Players[botID].Cards = []Card{
+		{Suit: "clubs", Rank: "Q"}, {Suit: "diamonds", Rank: "Q"},
+		{Suit: "clubs", Rank: "J"}, {Suit: "diamonds", Rank: "J"},
+	}
+
+	if !game.botTakeTurn(botID) {
+		t.Fatal("Bot should be able to take its turn")
+	}
+
+	found := -1
+	for i, card := range game.Players[botID].Cards {
+		if card.Rank == "A" && card.Suit == "hearts" {
+			found = i
+		}
+	}
+	if found < 0 {
+		t.Fatal("Bot should swap in a low card")
+	}
+	if known, ok := game.Bots[botID].known[found]; !ok || known.Rank != "A" {
+		t.Error("Bot should remember the card it swapped in")
+	}
+	if game.CurrentPlayer != "human" {
+		t.Error("Bot should end its turn")
+	}
+}
+
+func TestBotDiscardsHighCard(t *testing.T) {
+	game, botID := createBotTestGame()
+	game.Deck[0] = Card{Suit: "clubs", Rank: "Q"}
+	hand := append([]Card(nil), game.Players[botID].Cards...)
+
+	game.botTakeTurn(botID)
+
+	for i, card := range game.Players[botID].Cards {
+		if card != hand[i] {
+			t.Fatal("Bot should not keep a high card")
+		}
+	}
+	topCard := game.DiscardPile[len(game.DiscardPile)-1]
+	if topCard.Rank != "Q" {
+		t.Error("Bot should discard the high card")
+	}
+}
+
+func TestBotCallsPabloWithKnownLowHand(t *testing.T) {
+	game, botID := createBotTestGame()
+	for i := range game.Players[botID].Cards {
+		card := Card{Suit: "spades", Rank: "A"}
+		game.Players[botID].Cards[i] = card
+		game.Bots[botID].known[i] = card
+	}
+	game.Deck[0] = Card{Suit: "clubs", Rank: "5"}
+
+	game.botTakeTurn(botID)
+
+	if !game.PabloCalled || game.PabloCaller != botID {
+		t.Error("Bot should call Pablo with a known hand totalling 4")
+	}
+}
+
+func TestBotPeeksWithSeven(t *testing.T) {
+	game, botID := createBotTestGame()
+	game.Deck[0] = Card{Suit: "clubs", Rank: "7"}
+
+	game.botTakeTurn(botID)
+
+	if len(game.Bots[botID].known) != 1 {
+		t.Errorf("Bot should remember one peeked card, knows %d", len(game.Bots[botID].known))
+	}
+	if game.PendingSpecialCard != "" {
+		t.Error("Bot should resolve the 7 before ending its turn")
+	}
+}
+
+func TestBotsStopWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	gm := &GameManager{ctx: ctx, games: make(map[string]*Game)}
+	game := createTestGame("test-game")
+	game.AddPlayer("human", "Human", nil)
+	game.AddBot("bot-1", "Bot 1")
+	gm.mu.Lock()
+	gm.addGame(game)
+	gm.mu.Unlock()
+
+	cancel()
+	game.mu.Lock()
+	game.Status = "playing"
+	game.CurrentPlayer = "bot-1"
+	game.scheduleBots()
+	game.mu.Unlock()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		game.mu.RLock()
+		running := game.botsRunning
+		current := game.CurrentPlayer
+		game.mu.RUnlock()
+		if !running {
+			if current != "bot-1" {
+				t.Error("Bot should not play after the server shuts down")
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Bot runner should stop once the context is done")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import "pablo/protocol"
+
+// Broadcaster delivers the engine's messages. The engine decides who should see what;
+// the Broadcaster decides how it reaches them, so the same rules can run behind the
+// websocket server, a simulator or a test, and delivery policy (delays, filtering) can
+// be swapped without touching the rules. Methods are called with g.mu held and must not
+// call back into the game.
+type Broadcaster interface {
+	ToPlayer(playerID string, message Message)
+	ToSpectator(spectatorID string, message Message)
+	ToObserver(observerID string, message Message)
+}
+
+// connBroadcaster writes to the websocket connections the game holds for each seat and
+// watcher. Recipients without a connection (bots, dropped players) are skipped.
+type connBroadcaster struct {
+	g *Game
+}
+
+// Every message is stamped with the game's ID, since a connection can be in several games.
+
+func (b connBroadcaster) ToPlayer(playerID string, message Message) {
+	// Kept even for a dropped seat, which is who'll need catching up
+	message = b.g.catchUp.Record(playerID, message)
+	if player, exists := b.g.Players[playerID]; exists && player.Conn != nil {
+		message.GameID = b.g.ID
+		writeJSON(player.Conn, message)
+	}
+}
+
+func (b connBroadcaster) ToSpectator(spectatorID string, message Message) {
+	if spectator, exists := b.g.Spectators[spectatorID]; exists && spectator.Conn != nil {
+		message.GameID = b.g.ID
+		writeJSON(spectator.Conn, message)
+	}
+}
+
+func (b connBroadcaster) ToObserver(observerID string, message Message) {
+	if conn := b.g.Observers[observerID]; conn != nil {
+		message.GameID = b.g.ID
+		writeJSON(conn, message)
+	}
+}
+
+// SetBroadcaster replaces how the game delivers its messages
+func (g *Game) SetBroadcaster(b Broadcaster) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.broadcaster = b
+}
+
+func (g *Game) sendToPlayer(playerID string, message Message) {
+	g.broadcaster.ToPlayer(playerID, message)
+}
+
+// broadcast sends the same message to every player at the table. Vacant seats have
+// nobody to send to; a player coming back to one gets the state when they rejoin.
+func (g *Game) broadcast(message Message) {
+	for playerID := range g.Players {
+		if !g.vacant[playerID] {
+			g.broadcaster.ToPlayer(playerID, message)
+		}
+	}
+}
+
+// broadcastToSpectators sends the same message to every spectator
+func (g *Game) broadcastToSpectators(message Message) {
+	for spectatorID := range g.Spectators {
+		g.broadcaster.ToSpectator(spectatorID, message)
+	}
+}
+
+func (g *Game) broadcastGameState() {
+	view := g.publish()
+	for playerID := range g.Players {
+		if g.vacant[playerID] {
+			continue
+		}
+		g.broadcaster.ToPlayer(playerID, Message{
+			Type:    protocol.MsgGameState,
+			Payload: g.getGameStateForPlayer(playerID),
+		})
+	}
+	if len(g.Spectators) > 0 {
+		g.broadcastToSpectators(Message{
+			Type:    protocol.MsgGameState,
+			Payload: view.state,
+		})
+		g.broadcastSpectatorStats()
+	}
+	if len(g.Observers) > 0 {
+		message := Message{
+			Type:    protocol.MsgGameState,
+			Payload: g.getGameStateForObserver(),
+		}
+		for observerID := range g.Observers {
+			g.broadcaster.ToObserver(observerID, message)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"sync"
+	"testing"
+
+	"pablo/protocol"
+)
+
+// recordingBroadcaster keeps every message the engine sends, by recipient
+type recordingBroadcaster struct {
+	mu         sync.Mutex
+	players    map[string][]Message
+	spectators map[string][]Message
+	observers  map[string][]Message
+}
+
+func newRecordingBroadcaster() *recordingBroadcaster {
+	return &recordingBroadcaster{
+		players:    make(map[string][]Message),
+		spectators: make(map[string][]Message),
+		observers:  make(map[string][]Message),
+	}
+}
+
+func (r *recordingBroadcaster) ToPlayer(playerID string, message Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.players[playerID] = append(r.players[playerID], message)
+}
+
+func (r *recordingBroadcaster) ToSpectator(spectatorID string, message Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spectators[spectatorID] = append(r.spectators[spectatorID], message)
+}
+
+func (r *recordingBroadcaster) ToObserver(observerID string, message Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observers[observerID] = append(r.observers[observerID], message)
+}
+
+func countOfType(messages []Message, msgType string) int {
+	count := 0
+	for _, message := range messages {
+		if message.Type == msgType {
+			count++
+		}
+	}
+	return count
+}
+
+func TestBroadcasterReceivesMessages(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	playerIDs := addTestPlayers(game, 2)
+	game.AddSpectator("spec", "Spec", nil)
+	game.AddObserver("org", nil)
+
+	game.StartGame()
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	for _, id := range playerIDs {
+		if countOfType(recorder.players[id], protocol.MsgLobbyPlayers) == 0 {
+			t.Errorf("Player %s should have received the lobby list", id)
+		}
+		if countOfType(recorder.players[id], protocol.MsgGameState) == 0 {
+			t.Errorf("Player %s should have received the game state", id)
+		}
+	}
+	// One state on arrival, one when the game starts
+	if got := countOfType(recorder.spectators["spec"], protocol.MsgGameState); got != 2 {
+		t.Errorf("Expected spectator to receive 2 states, got %d", got)
+	}
+	if got := countOfType(recorder.observers["org"], protocol.MsgGameState); got != 2 {
+		t.Errorf("Expected observer to receive 2 states, got %d", got)
+	}
+}
+
+func TestPlayerStateIsPrivate(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	states := recorder.players[playerIDs[0]]
+	players := states[len(states)-1].Payload.(*GameState).Players
+	own := players[playerIDs[0]].Cards
+	other := players[playerIDs[1]].Cards
+	if own[0].Rank == "" {
+		t.Error("Player should see their own cards")
+	}
+	if other[0].Rank != "" {
+		t.Error("Player should not see an opponent's face-down cards")
+	}
+}
+
+func TestOpponentsSeeDrawWithoutCard(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	playerIDs := addTestPlayers(game, 2)
+	game.AddSpectator("spec", "Spec", nil)
+	game.StartGame()
+	current := game.CurrentPlayer
+	other := playerIDs[0]
+	if other == current {
+		other = playerIDs[1]
+	}
+
+	game.DrawCard(current)
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if got := countOfType(recorder.spectators["spec"], protocol.MsgPlayerDrew); got != 1 {
+		t.Errorf("Expected spectator to see 1 draw, got %d", got)
+	}
+	var drew *Message
+	for i, message := range recorder.players[other] {
+		if message.Type == protocol.MsgPlayerDrew {
+			drew = &recorder.players[other][i]
+		}
+	}
+	if drew == nil {
+		t.Fatal("Opponent should be told the current player drew")
+	}
+	payload := drew.Payload.(map[string]interface{})
+	if payload["playerID"] != current || payload["source"] != "deck" {
+		t.Errorf("Unexpected draw payload %v", payload)
+	}
+	if _, leaked := payload["card"]; leaked {
+		t.Error("The draw event must not carry the card")
+	}
+
+	states := recorder.players[other]
+	state := states[len(states)-1].Payload.(*GameState)
+	if !state.Players[current].HasDrawn || state.Players[other].HasDrawn {
+		t.Error("Only the current player should show as having drawn")
+	}
+	if _, shown := state.DrawnCards[current]; shown {
+		t.Error("The drawn card should stay hidden from opponents")
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"pablo/protocol"
+)
+
+// A client that drops and comes back gets a fresh game state, but a state doesn't say
+// what happened while it was gone: the chat, the reveals, the stack attempts. So every
+// event message sent to a seat is numbered and kept in a ring buffer for a while, and a
+// client reconnecting (or asking to resync) with the last number it saw is sent what it
+// missed, in order, before the state.
+
+// catchUpSize is how many event messages a game keeps for catching clients up. Each
+// recipient's copy counts, so a six-seat table keeps about this many / 6 events.
+const catchUpSize = 512
+
+// catchUpMessages are the events worth replaying. Snapshots like gameState aren't, since
+// the client gets a fresh one, and neither are answers to its own requests.
+var catchUpMessages = map[string]bool{
+	protocol.MsgChat:            true,
+	protocol.MsgCardRevealed:    true,
+	protocol.MsgKingPeek:        true,
+	protocol.MsgSwapEvent:       true,
+	protocol.MsgStackAttempt:    true,
+	protocol.MsgStackError:      true,
+	protocol.MsgStackPenalty:    true,
+	protocol.MsgPlayerDrew:      true,
+	protocol.MsgClaimRevealed:   true,
+	protocol.MsgHandRevealed:    true,
+	protocol.MsgRoundSummary:    true,
+	protocol.MsgPlayerRenamed:   true,
+	protocol.MsgPlayerKicked:    true,
+	protocol.MsgPlayerMuted:     true,
+	protocol.MsgHostChanged:     true,
+	protocol.MsgTableLocked:     true,
+	protocol.MsgHandicapChanged: true,
+	protocol.MsgTurnTimeout:     true,
+}
+
+// catchUpEntry is one message as it was sent to one player
+type catchUpEntry struct {
+	playerID string
+	message  Message
+}
+
+// catchUpBuffer keeps the latest catchUpSize event messages sent to a game's seats
+type catchUpBuffer struct {
+	entries []catchUpEntry // Ring; entries[(seq-1) % catchUpSize] holds message seq
+	lastSeq uint64         // Number given to the latest message; numbering starts at 1
+	mu      sync.Mutex
+}
+
+func newCatchUpBuffer() *catchUpBuffer {
+	return &catchUpBuffer{entries: make([]catchUpEntry, catchUpSize)}
+}
+
+// Record numbers message and keeps it for playerID if it is an event worth replaying,
+// returning it with its number. Other messages come back as they were.
+func (b *catchUpBuffer) Record(playerID string, message Message) Message {
+	if b == nil || !catchUpMessages[message.Type] {
+		return message
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastSeq++
+	message.Seq = b.lastSeq
+	b.entries[(b.lastSeq-1)%catchUpSize] = catchUpEntry{playerID: playerID, message: message}
+	return message
+}
+
+// Since returns the messages sent to playerID after after, oldest first. Messages that
+// have already been pushed out of the buffer are gone.
+func (b *catchUpBuffer) Since(playerID string, after uint64) []Message {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	first := after + 1
+	if b.lastSeq > catchUpSize && first <= b.lastSeq-catchUpSize {
+		first = b.lastSeq - catchUpSize + 1
+	}
+	var missed []Message
+	for seq := first; seq <= b.lastSeq; seq++ {
+		if entry := b.entries[(seq-1)%catchUpSize]; entry.playerID == playerID {
+			missed = append(missed, entry.message)
+		}
+	}
+	return missed
+}
+
+// replayMissed sends conn the messages playerID was sent after after; nothing if after is
+// negative. Caller must hold g.mu, so nothing new is sent in between.
+func (g *Game) replayMissed(playerID string, conn *websocket.Conn, after int64) {
+	if after < 0 {
+		return
+	}
+	for _, message := range g.catchUp.Since(playerID, uint64(after)) {
+		message.GameID = g.ID
+		writeJSON(conn, message)
+	}
+}
+
+// Resync sends playerID the messages they were sent after after, then a fresh state, for
+// a client that thinks it has fallen behind without losing its connection
+func (g *Game) Resync(playerID string, after int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	player, exists := g.Players[playerID]
+	if !exists || player.Conn == nil {
+		return
+	}
+	g.replayMissed(playerID, player.Conn, after)
+	g.sendToPlayer(playerID, Message{Type: protocol.MsgGameState, Payload: g.getGameStateForPlayer(playerID)})
+}
+
+// lastSeqOf reads the lastSeq a client sends to be caught up: the number of the latest
+// message it saw, 0 if it saw none. It is -1 if the client sent none, so isn't asking.
+func lastSeqOf(lastSeq *int64) int64 {
+	if lastSeq == nil || *lastSeq < 0 {
+		return -1
+	}
+	return *lastSeq
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"pablo/protocol"
+)
+
+func TestCatchUpBufferWrapsAround(t *testing.T) {
+	buffer := newCatchUpBuffer()
+	if message := buffer.Record("p1", Message{Type: protocol.MsgGameState}); message.Seq != 0 {
+		t.Error("Snapshots shouldn't be numbered or kept")
+	}
+	for i := 0; i < catchUpSize+10; i++ {
+		recipient := "p1"
+		if i%2 == 1 {
+			recipient = "p2"
+		}
+		buffer.Record(recipient, Message{Type: protocol.MsgChat, Payload: i})
+	}
+
+	missed := buffer.Since("p2", 0)
+	if len(missed) != catchUpSize/2 {
+		t.Fatalf("Expected only what's still in the buffer, got %d messages", len(missed))
+	}
+	for i := 1; i < len(missed); i++ {
+		if missed[i].Seq <= missed[i-1].Seq {
+			t.Fatal("Messages should come back oldest first")
+		}
+	}
+	if last := missed[len(missed)-1]; last.Payload != catchUpSize+9 {
+		t.Errorf("Expected the latest line last, got %v", last.Payload)
+	}
+	if len(buffer.Since("p1", uint64(catchUpSize+10))) != 0 {
+		t.Error("A client that saw everything has nothing to catch up on")
+	}
+}
+
+func TestReconnectCatchesUpBeforeState(t *testing.T) {
+	host, hostSession := createTestGameOverWS(t)
+	gameID := hostSession["gameID"].(string)
+	guest := dialTestServer(t)
+	sendTestMessage(t, guest, "join", map[string]interface{}{"gameID": gameID, "name": "Dropper"})
+	session := readMessageOfType(t, guest, "session")
+	guest.Close()
+
+	sendTestMessage(t, host, "sendChat", map[string]interface{}{"text": "where did they go?"})
+	readMessageOfType(t, host, "chat")
+
+	back := dialTestServer(t)
+	sendTestMessage(t, back, "join", map[string]interface{}{
+		"gameID":       gameID,
+		"name":         "Dropper",
+		"sessionToken": session["sessionToken"],
+		"lastSeq":      0,
+	})
+	back.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var message Message
+		if err := back.ReadJSON(&message); err != nil {
+			t.Fatal(err)
+		}
+		if message.Type == "gameState" {
+			t.Fatal("Expected the missed chat before the state")
+		}
+		if message.Type == "chat" {
+			if text := message.Payload.(map[string]interface{})["text"]; text != "where did they go?" || message.Seq == 0 {
+				t.Errorf("Unexpected catch-up %+v", message)
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"pablo/protocol"
+)
+
+const chatLengthLimit = 300 // Longest chat message, in bytes
+
+var (
+	errChatEmpty    = errors.New("chat message is empty")
+	errChatTooLong  = errors.New("chat message is too long")
+	errChatRejected = errors.New("chat message was not allowed")
+)
+
+// ChatMessage is a chat line on its way through the filters
+type ChatMessage struct {
+	GameID   string
+	PlayerID string
+	Text     string
+}
+
+// ChatFilter inspects a chat line before it is broadcast. It returns the text to pass
+// along, rewritten if need be, or an error to drop the line.
+type ChatFilter interface {
+	Filter(ctx context.Context, msg ChatMessage) (string, error)
+}
+
+// chatPipeline runs a chat line through each filter in order
+type chatPipeline []ChatFilter
+
+// chatFilters is the server's chat pipeline; empty lets every line through
+var chatFilters chatPipeline
+
+func (p chatPipeline) Run(ctx context.Context, msg ChatMessage) (string, error) {
+	for _, filter := range p {
+		text, err := filter.Filter(ctx, msg)
+		if err != nil {
+			return "", err
+		}
+		msg.Text = text
+	}
+	return msg.Text, nil
+}
+
+// profanityFilter masks listed words, matched as whole words in any case
+type profanityFilter struct {
+	words map[string]bool
+}
+
+func newProfanityFilter(words []string) *profanityFilter {
+	f := &profanityFilter{words: make(map[string]bool)}
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			f.words[word] = true
+		}
+	}
+	return f
+}
+
+// loadProfanityFilter reads a word list with one word per line; blank lines and lines
+// starting with # are skipped
+func loadProfanityFilter(path string) (*profanityFilter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			words = append(words, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return newProfanityFilter(words), nil
+}
+
+func (f *profanityFilter) Filter(ctx context.Context, msg ChatMessage) (string, error) {
+	var out strings.Builder
+	word := []rune{}
+	flush := func() {
+		if f.words[strings.ToLower(string(word))] {
+			out.WriteString(strings.Repeat("*", len(word)))
+		} else {
+			out.WriteString(string(word))
+		}
+		word = word[:0]
+	}
+	for _, r := range msg.Text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' {
+			word = append(word, r)
+			continue
+		}
+		flush()
+		out.WriteRune(r)
+	}
+	flush()
+	return out.String(), nil
+}
+
+// linkPattern finds URLs and bare domains like example.com/path
+var linkPattern = regexp.MustCompile(`(?i)\b(?:[a-z][a-z0-9+.-]*://\S+|www\.\S+|[a-z0-9-]+(?:\.[a-z0-9-]+)*\.(?:com|net|org|io|gg|co|me|ly|xyz|info|app|dev)\b\S*)`)
+
+// linkFilter replaces links so chat can't be used to send players elsewhere
+type linkFilter struct{}
+
+func (linkFilter) Filter(ctx context.Context, msg ChatMessage) (string, error) {
+	return linkPattern.ReplaceAllString(msg.Text, "[link removed]"), nil
+}
+
+// moderationAPIFilter asks an external service about each line. The service gets
+// {"gameID", "playerID", "text"} and answers {"allowed": bool, "text": optional rewrite}.
+// If the service can't be reached the line goes through, so an outage doesn't silence chat.
+type moderationAPIFilter struct {
+	url    string
+	client *http.Client
+}
+
+func newModerationAPIFilter(url string) *moderationAPIFilter {
+	return &moderationAPIFilter{url: url, client: &http.Client{Timeout: 2 * time.Second}}
+}
+
+func (f *moderationAPIFilter) Filter(ctx context.Context, msg ChatMessage) (string, error) {
+	body, err := json.Marshal(map[string]string{
+		"gameID":   msg.GameID,
+		"playerID": msg.PlayerID,
+		"text":     msg.Text,
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		log.Println("Chat moderation unavailable:", err)
+		return msg.Text, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Println("Chat moderation returned", resp.Status)
+		return msg.Text, nil
+	}
+
+	var verdict struct {
+		Allowed bool    `json:"allowed"`
+		Text    *string `json:"text"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&verdict); err != nil {
+		log.Println("Chat moderation answer:", err)
+		return msg.Text, nil
+	}
+	if !verdict.Allowed {
+		return "", errChatRejected
+	}
+	if verdict.Text != nil {
+		return *verdict.Text, nil
+	}
+	return msg.Text, nil
+}
+
+// newChatPipeline builds the filters named in spec (comma separated, run in that order):
+// "profanity" needs wordList, "links" needs nothing and "moderation" needs moderationURL
+func newChatPipeline(spec, wordList, moderationURL string) (chatPipeline, error) {
+	var pipeline chatPipeline
+	for _, name := range strings.Split(spec, ",") {
+		switch name = strings.TrimSpace(name); name {
+		case "":
+		case "profanity":
+			if wordList == "" {
+				return nil, errors.New("the profanity filter needs a word list")
+			}
+			filter, err := loadProfanityFilter(wordList)
+			if err != nil {
+				return nil, err
+			}
+			pipeline = append(pipeline, filter)
+		case "links":
+			pipeline = append(pipeline, linkFilter{})
+		case "moderation":
+			if moderationURL == "" {
+				return nil, errors.New("the moderation filter needs a URL")
+			}
+			pipeline = append(pipeline, newModerationAPIFilter(moderationURL))
+		default:
+			return nil, fmt.Errorf("unknown chat filter %q", name)
+		}
+	}
+	return pipeline, nil
+}
+
+// checkChat validates a chat line before it is filtered
+func checkChat(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", errChatEmpty
+	}
+	if len(text) > chatLengthLimit {
+		return "", errChatTooLong
+	}
+	return text, nil
+}
+
+// Chat sends a line from a seated player to everyone at the table and watching.
+// The text should already have been through the chat filters. Lines from muted players are
+// dropped, lines from shadow-muted players go back to them alone, and nobody gets lines
+// from someone they've blocked or who has blocked them.
+func (g *Game) Chat(playerID, text string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, seated := g.Players[playerID]; !seated || text == "" || g.Muted[playerID] {
+		return false
+	}
+	now := time.Now()
+	g.chatLog = appendCapped(g.chatLog, AuditChatLine{
+		Time:     now.UTC(),
+		PlayerID: playerID,
+		Name:     g.Players[playerID].Name,
+		Text:     text,
+		Shadowed: g.ShadowMuted[playerID],
+	})
+	message := Message{
+		Type: protocol.MsgChat,
+		Payload: map[string]interface{}{
+			"playerID": playerID,
+			"name":     g.displayName(playerID),
+			"text":     text,
+			"time":     now.UnixMilli(),
+		},
+	}
+	if g.ShadowMuted[playerID] {
+		g.sendToPlayer(playerID, message)
+		return true
+	}
+	for recipientID := range g.Players {
+		if !g.blocks(playerID, recipientID) && !g.vacant[recipientID] {
+			g.broadcaster.ToPlayer(recipientID, message)
+		}
+	}
+	sender := blockKey(g.Players[playerID].Name)
+	for spectatorID, spectator := range g.Spectators {
+		if !blocklistOf(spectator.Conn)[sender] {
+			g.broadcaster.ToSpectator(spectatorID, message)
+		}
+	}
+	return true
+}
+
+// SetShadowMuted shadow-mutes (or lifts it from) a seated player. It's a moderator's tool:
+// nobody at the table is told, and the player keeps seeing their own lines as if sent.
+func (g *Game) SetShadowMuted(playerID string, muted bool) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, seated := g.Players[playerID]; !seated {
+		return false
+	}
+	if muted {
+		g.ShadowMuted[playerID] = true
+	} else {
+		delete(g.ShadowMuted, playerID)
+	}
+	return true
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProfanityFilter(t *testing.T) {
+	filter := newProfanityFilter([]string{"darn", "Heck"})
+	got, _ := filter.Filter(context.Background(), ChatMessage{Text: "Darn it, what the heck! darned"})
+	if want := "**** it, what the ****! darned"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestLinkFilter(t *testing.T) {
+	tests := map[string]string{
+		"go to https://evil.example/x now": "go to [link removed] now",
+		"visit www.spam.net":               "visit [link removed]",
+		"join cheats.gg/free today":        "join [link removed] today",
+		"nice play. well done":             "nice play. well done",
+	}
+	for in, want := range tests {
+		if got, _ := (linkFilter{}).Filter(context.Background(), ChatMessage{Text: in}); got != want {
+			t.Errorf("Filter(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestModerationAPIFilter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		switch req["text"] {
+		case "bad":
+			json.NewEncoder(w).Encode(map[string]interface{}{"allowed": false})
+		case "meh":
+			json.NewEncoder(w).Encode(map[string]interface{}{"allowed": true, "text": "m*h"})
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{"allowed": true})
+		}
+	}))
+	filter := newModerationAPIFilter(server.URL)
+	ctx := context.Background()
+
+	if _, err := filter.Filter(ctx, ChatMessage{Text: "bad"}); err != errChatRejected {
+		t.Errorf("Expected errChatRejected, got %v", err)
+	}
+	if got, _ := filter.Filter(ctx, ChatMessage{Text: "meh"}); got != "m*h" {
+		t.Errorf("Expected the rewritten text, got %q", got)
+	}
+	if got, _ := filter.Filter(ctx, ChatMessage{Text: "gg"}); got != "gg" {
+		t.Errorf("Expected the text unchanged, got %q", got)
+	}
+
+	server.Close()
+	if got, err := filter.Filter(ctx, ChatMessage{Text: "gg"}); err != nil || got != "gg" {
+		t.Errorf("Chat should go through while moderation is down, got %q, %v", got, err)
+	}
+}
+
+func TestNewChatPipeline(t *testing.T) {
+	wordList := filepath.Join(t.TempDir(), "words.txt")
+	os.WriteFile(wordList, []byte("# mild words\ndarn\n"), 0o644)
+
+	pipeline, err := newChatPipeline("profanity, links", wordList, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := pipeline.Run(context.Background(), ChatMessage{Text: "darn, see spam.com"})
+	if err != nil || got != "****, see [link removed]" {
+		t.Errorf("Expected both filters to run, got %q, %v", got, err)
+	}
+
+	if _, err := newChatPipeline("profanity", "", ""); err == nil {
+		t.Error("The profanity filter should need a word list")
+	}
+	if _, err := newChatPipeline("moderation", "", ""); err == nil {
+		t.Error("The moderation filter should need a URL")
+	}
+	if _, err := newChatPipeline("shouting", "", ""); err == nil {
+		t.Error("Unknown filters should be rejected")
+	}
+}
+
+func TestChatBroadcast(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	playerIDs := addTestPlayers(game, 2)
+	game.AddSpectator("spec", "Spec", nil)
+
+	if game.Chat("stranger", "hi") {
+		t.Error("Only seated players can chat")
+	}
+	if !game.Chat(playerIDs[0], "gg") {
+		t.Fatal("Seated player should be able to chat")
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if countOfType(recorder.players[playerIDs[1]], "chat") != 1 {
+		t.Error("Other players should get the chat line")
+	}
+	if countOfType(recorder.spectators["spec"], "chat") != 1 {
+		t.Error("Spectators should get the chat line")
+	}
+}
+
+func TestChatOverWS(t *testing.T) {
+	conn, _ := createTestGameOverWS(t)
+	sendTestMessage(t, conn, "sendChat", map[string]interface{}{"text": "  hello table  "})
+	if text := readMessageOfType(t, conn, "chat")["text"]; text != "hello table" {
+		t.Errorf("Expected the trimmed line back, got %v", text)
+	}
+
+	long := make([]byte, chatLengthLimit+1)
+	for i := range long {
+		long[i] = 'a'
+	}
+	sendTestMessage(t, conn, "sendChat", map[string]interface{}{"text": string(long)})
+	if code := readMessageOfType(t, conn, "error")["code"]; code != "CHAT_REJECTED" {
+		t.Errorf("Expected CHAT_REJECTED, got %v", code)
+	}
+}
+
+func TestShadowMute(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	playerIDs := addTestPlayers(game, 2)
+	game.AddSpectator("spec", "Spec", nil)
+
+	if game.SetShadowMuted("stranger", true) {
+		t.Error("Only seated players can be shadow-muted")
+	}
+	game.SetShadowMuted(playerIDs[0], true)
+	if !game.Chat(playerIDs[0], "buy gold") {
+		t.Fatal("Shadow-muted chat should look sent to the sender")
+	}
+
+	recorder.mu.Lock()
+	if countOfType(recorder.players[playerIDs[0]], "chat") != 1 {
+		t.Error("The sender should see their own line")
+	}
+	if countOfType(recorder.players[playerIDs[1]], "chat") != 0 || countOfType(recorder.spectators["spec"], "chat") != 0 {
+		t.Error("Nobody else should see a shadow-muted line")
+	}
+	recorder.mu.Unlock()
+
+	if chat := game.AuditExport(auditRedaction{}).Chat; len(chat) != 1 || !chat[0].Shadowed {
+		t.Errorf("The audit log should mark the line as shadowed, got %+v", chat)
+	}
+	game.SetShadowMuted(playerIDs[0], false)
+	game.Chat(playerIDs[0], "sorry")
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if countOfType(recorder.players[playerIDs[1]], "chat") != 1 {
+		t.Error("Lifting the shadow-mute should let chat through again")
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"pablo/protocol"
+)
+
+// Games live in the GameManager's map, so a restart used to end every match in progress.
+// With checkpointing on, each game is saved to the checkpoint store shortly after every
+// change clients can see, and the games saved there are loaded back when the server
+// starts, or when one is looked up and isn't in memory. Players reclaim their seats by session token, as after an adjournment, so a
+// deploy costs them a reconnect rather than their game.
+
+// defaultCheckpointDelay is how long after a change a game is saved. Changes that come
+// closer together than this are saved together.
+const defaultCheckpointDelay = time.Second
+
+// checkpointTimeout bounds each save or delete in the checkpoint store
+const checkpointTimeout = 5 * time.Second
+
+// checkpointer saves changed games to a store in the background
+type checkpointer struct {
+	store *adjournStore
+	delay time.Duration
+	dirty map[string]*Game // Games changed since they were last saved
+	saved map[string]bool  // Games with a checkpoint in the store; only touched by run and restore
+	wake  chan struct{}
+	done  chan struct{} // Closed once run has saved what was left at shutdown
+	mu    sync.Mutex
+}
+
+func newCheckpointer(store *adjournStore, delay time.Duration) *checkpointer {
+	return &checkpointer{
+		store: store,
+		delay: delay,
+		dirty: make(map[string]*Game),
+		saved: make(map[string]bool),
+		wake:  make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+}
+
+// checkpointable reports whether the game can be rebuilt well enough from its snapshot to
+// be brought back after a restart. Daily, puzzle and tutorial games and bots keep state a
+// snapshot leaves out, scheduled games would lose their start time, adjourned and
+// hibernated games are saved elsewhere, and finished ones have nothing left to play.
+// Caller must hold g.mu.
+func (g *Game) checkpointable() bool {
+	if g.Adjourned || g.hibernated || g.GameOver || g.Status == protocol.StatusScheduled {
+		return false
+	}
+	return g.DailyDate == "" && g.Puzzle == nil && g.Tutorial == nil && len(g.Bots) == 0
+}
+
+// mark notes that game has changed and should be saved. It never blocks, so it's safe to
+// call with g.mu held.
+func (c *checkpointer) mark(game *Game) {
+	c.mu.Lock()
+	c.dirty[game.ID] = game
+	c.mu.Unlock()
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run saves marked games, each at most once per delay, until ctx is done. It saves what is
+// still marked then before closing c.done.
+func (c *checkpointer) run(ctx context.Context) {
+	defer close(c.done)
+	for {
+		select {
+		case <-ctx.Done():
+			c.flush()
+			return
+		case <-c.wake:
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(c.delay):
+		}
+		c.flush()
+	}
+}
+
+// flush saves every marked game, or deletes its checkpoint if it no longer qualifies
+func (c *checkpointer) flush() {
+	c.mu.Lock()
+	dirty := c.dirty
+	c.dirty = make(map[string]*Game)
+	c.mu.Unlock()
+
+	now := time.Now()
+	for id, game := range dirty {
+		game.mu.Lock()
+		var snap *gameSnapshot
+		if game.checkpointable() {
+			snap = game.snapshot()
+			snap.ExpiresAt = now.Add(c.store.ttl)
+		}
+		game.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), checkpointTimeout)
+		if snap != nil {
+			if err := c.store.Save(ctx, snap); err != nil {
+				log.Printf("Checkpointing game %s: %v", id, err)
+			} else {
+				c.saved[id] = true
+			}
+		} else if c.saved[id] {
+			if err := c.store.Delete(id); err != nil {
+				log.Printf("Deleting checkpoint of game %s: %v", id, err)
+			} else {
+				delete(c.saved, id)
+			}
+		}
+		cancel()
+	}
+}
+
+// restoreCheckpoints loads the checkpointed games back into the manager, returning how
+// many it loaded. In a cluster, only games this node owns are loaded. Call it at startup,
+// before checkpoints.run.
+func (gm *GameManager) restoreCheckpoints(ctx context.Context) (int, error) {
+	c := gm.checkpoints
+	ids, err := c.store.games.ListGames(ctx)
+	if err != nil {
+		return 0, err
+	}
+	restored := 0
+	for _, id := range ids {
+		if !cluster.owns(id) {
+			continue
+		}
+		snap, err := c.store.Load(ctx, id)
+		if err != nil {
+			if !errors.Is(err, errAdjournExpired) {
+				log.Printf("Loading checkpoint of game %s: %v", id, err)
+			}
+			continue
+		}
+		c.saved[id] = true
+
+		gm.mu.Lock()
+		if _, exists := gm.games[id]; !exists {
+			gm.adoptCheckpoint(snap)
+			restored++
+		}
+		gm.mu.Unlock()
+		registerGame(id)
+	}
+	return restored, nil
+}
+
+// loadCheckpoint brings back a checkpointed game that isn't in memory, as when this node
+// takes over a game from one that went down, or returns nil if there isn't one. Caller
+// must not hold gm.mu.
+func (gm *GameManager) loadCheckpoint(ctx context.Context, gameID string) *Game {
+	if gm.checkpoints == nil || !cluster.owns(gameID) {
+		return nil
+	}
+	snap, err := gm.checkpoints.store.Load(ctx, gameID)
+	if err != nil {
+		return nil
+	}
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+	if game, exists := gm.games[gameID]; exists {
+		return game
+	}
+	game := gm.adoptCheckpoint(snap)
+	// Saving it again is what tells run the checkpoint is this node's to delete later
+	gm.checkpoints.mark(game)
+	registerGame(gameID)
+	return game
+}
+
+// adoptCheckpoint adds a game loaded from its checkpoint. Caller must hold gm.mu.
+func (gm *GameManager) adoptCheckpoint(snap *gameSnapshot) *Game {
+	game := gameFromSnapshot(snap)
+	gm.addGame(game)
+	// Nobody is connected yet; turns that run out or seats left empty are dealt with as
+	// after any disconnect
+	game.mu.Lock()
+	game.resumeTurnTimer()
+	game.resumeGiveTimer()
+	game.mu.Unlock()
+	return game
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"pablo/protocol"
+)
+
+func TestCheckpointedGameSurvivesRestart(t *testing.T) {
+	store := &adjournStore{games: newMemoryGameStore(), ttl: time.Hour}
+	gm := &GameManager{games: make(map[string]*Game), checkpoints: newCheckpointer(store, time.Second)}
+
+	game := createTestGame("cp-game")
+	gm.addGame(game)
+	addTestPlayers(game, 2)
+	game.StartGame()
+	game.DrawCard(game.CurrentPlayer)
+	gm.checkpoints.flush()
+
+	// A new process finds the game, mid-turn, and its players reclaim their seats
+	restarted := &GameManager{games: make(map[string]*Game), checkpoints: newCheckpointer(store, time.Second)}
+	restored, err := restarted.restoreCheckpoints(context.Background())
+	if err != nil || restored != 1 {
+		t.Fatalf("Expected 1 restored game, got %d, %v", restored, err)
+	}
+	loaded := restarted.GetGame(context.Background(), "cp-game")
+	if loaded == nil {
+		t.Fatal("Expected the game after the restart")
+	}
+	if loaded.Status != protocol.StatusPlaying || loaded.CurrentPlayer != game.CurrentPlayer || loaded.DrawnCards[game.CurrentPlayer] == nil {
+		t.Errorf("Expected the turn as it was left, got status %s, current %s", loaded.Status, loaded.CurrentPlayer)
+	}
+	for id, player := range game.Players {
+		if got := loaded.Players[id]; got == nil || got.SessionToken != player.SessionToken || len(got.Cards) != len(player.Cards) {
+			t.Errorf("Expected seat %s restored, got %+v", id, got)
+		}
+	}
+	if loaded.checkpoints != restarted.checkpoints {
+		t.Error("Expected the restored game to go on being checkpointed")
+	}
+}
+
+func TestCheckpointedGameLoadedWhenLookedUp(t *testing.T) {
+	store := &adjournStore{games: newMemoryGameStore(), ttl: time.Hour}
+	gm := &GameManager{games: make(map[string]*Game), checkpoints: newCheckpointer(store, time.Second)}
+
+	game := createTestGame("cp-lookup")
+	gm.addGame(game)
+	addTestPlayers(game, 2)
+	game.StartGame()
+	gm.checkpoints.flush()
+
+	// Another process, sharing the store, gets asked for a game it never loaded
+	other := &GameManager{games: make(map[string]*Game), checkpoints: newCheckpointer(store, time.Second)}
+	loaded := other.GetGame(context.Background(), "cp-lookup")
+	if loaded == nil {
+		t.Fatal("Expected the game loaded from its checkpoint")
+	}
+	if loaded.CurrentPlayer != game.CurrentPlayer || len(loaded.Players) != 2 {
+		t.Errorf("Expected the game as it was saved, got %+v", loaded)
+	}
+	if other.GetGame(context.Background(), "cp-lookup") != loaded {
+		t.Error("Expected the second lookup to find the game in memory")
+	}
+	if other.GetGame(context.Background(), "missing") != nil {
+		t.Error("Expected no game for an ID nothing saved")
+	}
+}
+
+func TestCheckpointDroppedWhenGameStopsQualifying(t *testing.T) {
+	store := &adjournStore{games: newMemoryGameStore(), ttl: time.Hour}
+	gm := &GameManager{games: make(map[string]*Game), checkpoints: newCheckpointer(store, time.Second)}
+
+	game := createTestGame("cp-over")
+	gm.addGame(game)
+	addTestPlayers(game, 2)
+	gm.checkpoints.flush()
+	if ids, _ := store.games.ListGames(context.Background()); len(ids) != 1 {
+		t.Fatalf("Expected the lobby checkpointed, got %v", ids)
+	}
+
+	game.mu.Lock()
+	game.GameOver = true
+	game.broadcastGameState()
+	game.mu.Unlock()
+	gm.checkpoints.flush()
+	if ids, _ := store.games.ListGames(context.Background()); len(ids) != 0 {
+		t.Errorf("Expected the finished game's checkpoint deleted, got %v", ids)
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"pablo/protocol"
+)
+
+// ringReplicas is how many points each node gets on the hash ring, to spread games evenly
+const ringReplicas = 64
+
+// clusterNode is one server instance, usually one per region
+type clusterNode struct {
+	ID  string
+	URL string // Base URL clients reach it on, e.g. https://eu.pablo.example
+}
+
+// wsURL is where clients open the node's WebSocket
+func (n clusterNode) wsURL() string {
+	u := strings.TrimRight(n.URL, "/")
+	switch {
+	case strings.HasPrefix(u, "https://"):
+		u = "wss://" + strings.TrimPrefix(u, "https://")
+	case strings.HasPrefix(u, "http://"):
+		u = "ws://" + strings.TrimPrefix(u, "http://")
+	}
+	return u + "/ws"
+}
+
+// hashRing maps game IDs to nodes by consistent hashing, so adding or removing a node
+// only moves the games that hashed near it
+type hashRing struct {
+	points []uint32 // Sorted
+	owners []string // Node ID of each point
+}
+
+// ringHash places a key on the ring. MD5 as in ketama: not for security, but it spreads
+// similar keys like "eu#1" and "eu#2" far better than a fast non-cryptographic hash.
+func ringHash(key string) uint32 {
+	sum := md5.Sum([]byte(key))
+	return binary.BigEndian.Uint32(sum[:4])
+}
+
+func newHashRing(nodeIDs []string) *hashRing {
+	type point struct {
+		hash  uint32
+		owner string
+	}
+	var all []point
+	for _, id := range nodeIDs {
+		for i := 0; i < ringReplicas; i++ {
+			all = append(all, point{ringHash(id + "#" + strconv.Itoa(i)), id})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].hash != all[j].hash {
+			return all[i].hash < all[j].hash
+		}
+		return all[i].owner < all[j].owner
+	})
+	ring := &hashRing{}
+	for _, p := range all {
+		ring.points = append(ring.points, p.hash)
+		ring.owners = append(ring.owners, p.owner)
+	}
+	return ring
+}
+
+// owner returns the node a game ID hashes to
+func (r *hashRing) owner(gameID string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+	h := ringHash(gameID)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[i]
+}
+
+// gameCluster keeps every player of a game on the node that hosts it. A node only creates
+// games whose IDs hash to itself, and records them in a Redis directory; a join for a game
+// hosted elsewhere is answered with a redirect to that node. The directory is what keeps
+// games findable when nodes come and go; without it the ring alone decides.
+type gameCluster struct {
+	self      string
+	nodes     map[string]clusterNode
+	ring      *hashRing
+	directory *redisDirectory // Optional
+}
+
+// cluster is set when PABLO_CLUSTER_NODES lists the instances; nil runs a single node
+var cluster *gameCluster
+
+// newGameCluster parses nodes as "id=url,id=url". self must be one of them.
+func newGameCluster(self, nodes string, directory *redisDirectory) (*gameCluster, error) {
+	c := &gameCluster{self: self, nodes: make(map[string]clusterNode), directory: directory}
+	var ids []string
+	for _, entry := range strings.Split(nodes, ",") {
+		id, nodeURL, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || id == "" || nodeURL == "" {
+			return nil, fmt.Errorf("cluster node %q is not id=url", entry)
+		}
+		c.nodes[id] = clusterNode{ID: id, URL: nodeURL}
+		ids = append(ids, id)
+	}
+	if _, exists := c.nodes[self]; !exists {
+		return nil, fmt.Errorf("this node's ID %q is not in the cluster list", self)
+	}
+	c.ring = newHashRing(ids)
+	return c, nil
+}
+
+// owns reports whether a new game with this ID belongs on this node
+func (c *gameCluster) owns(gameID string) bool {
+	return c == nil || c.ring.owner(gameID) == c.self
+}
+
+// register records in the directory that this node hosts gameID
+func (c *gameCluster) register(gameID string) error {
+	if c == nil || c.directory == nil {
+		return nil
+	}
+	return c.directory.Set(gameID, c.self)
+}
+
+// locate returns the node hosting gameID, or nil if it's this one or can't be told
+func (c *gameCluster) locate(gameID string) *clusterNode {
+	if c == nil {
+		return nil
+	}
+	nodeID := ""
+	if c.directory != nil {
+		var err error
+		if nodeID, err = c.directory.Get(gameID); err != nil {
+			log.Println("Looking up game in directory:", err)
+		}
+	}
+	if nodeID == "" {
+		nodeID = c.ring.owner(gameID)
+	}
+	node, exists := c.nodes[nodeID]
+	if !exists || nodeID == c.self {
+		return nil
+	}
+	return &node
+}
+
+// redirectToGame tells conn to reconnect to the node hosting gameID, if that's another
+// node. It reports whether it did.
+func redirectToGame(conn *websocket.Conn, gameID string) bool {
+	node := cluster.locate(gameID)
+	if node == nil {
+		return false
+	}
+	writeJSON(conn, Message{
+		Type: protocol.MsgRedirect,
+		Payload: map[string]string{
+			"gameID": gameID,
+			"node":   node.ID,
+			"url":    node.wsURL(),
+		},
+	})
+	return true
+}
+
+// directoryTTL is how long a directory entry outlives the last time it was written
+const directoryTTL = 7 * 24 * time.Hour
+
+// redisDirectory keeps game ID -> node ID in Redis under "pablo:game:<id>"
+type redisDirectory struct {
+	*redisClient
+}
+
+// newRedisDirectory takes a redis://[:password@]host[:port][/db] URL
+func newRedisDirectory(rawURL string) (*redisDirectory, error) {
+	client, err := newRedisClient(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return &redisDirectory{client}, nil
+}
+
+func (d *redisDirectory) Set(gameID, nodeID string) error {
+	_, err := d.do("SET", "pablo:game:"+gameID, nodeID, "EX", strconv.Itoa(int(directoryTTL/time.Second)))
+	return err
+}
+
+// Get returns the node hosting gameID, or "" if the directory doesn't know
+func (d *redisDirectory) Get(gameID string) (string, error) {
+	return d.do("GET", "pablo:game:"+gameID)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHashRingMovesOnlyARemovedNodesGames(t *testing.T) {
+	three := newHashRing([]string{"eu", "us", "ap"})
+	two := newHashRing([]string{"eu", "us"})
+	counts := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		id := "game-" + strconv.Itoa(i)
+		owner := three.owner(id)
+		counts[owner]++
+		if owner != "ap" && two.owner(id) != owner {
+			t.Fatalf("%s moved from %s to %s though its node stayed", id, owner, two.owner(id))
+		}
+	}
+	for node, n := range counts {
+		if n < 500 {
+			t.Errorf("Node %s got only %d of 3000 games", node, n)
+		}
+	}
+}
+
+func TestNewGameCluster(t *testing.T) {
+	if _, err := newGameCluster("eu", "eu=https://eu.example,us", nil); err == nil {
+		t.Error("Expected an entry without a URL to be refused")
+	}
+	if _, err := newGameCluster("ap", "eu=https://eu.example,us=https://us.example", nil); err == nil {
+		t.Error("Expected a node missing from its own list to be refused")
+	}
+	c, err := newGameCluster("eu", "eu=https://eu.example, us=http://us.example:8080/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.nodes["us"].wsURL(); got != "ws://us.example:8080/ws" {
+		t.Errorf("Unexpected WebSocket URL %s", got)
+	}
+}
+
+func TestRedisDirectory(t *testing.T) {
+	directory, err := newRedisDirectory(fakeRedis(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node, err := directory.Get("g1"); err != nil || node != "" {
+		t.Fatalf("Expected an unknown game, got %q %v", node, err)
+	}
+	if err := directory.Set("g1", "us"); err != nil {
+		t.Fatal(err)
+	}
+	if node, err := directory.Get("g1"); err != nil || node != "us" {
+		t.Errorf("Expected us, got %q %v", node, err)
+	}
+	if _, err := directory.do("FLUSHALL"); err == nil || !strings.Contains(err.Error(), "unknown command") {
+		t.Errorf("Expected the server's error, got %v", err)
+	}
+}
+
+func TestJoinElsewhereIsRedirected(t *testing.T) {
+	directory, _ := newRedisDirectory(fakeRedis(t))
+	saved := cluster
+	cluster, _ = newGameCluster("eu", "eu=https://eu.example,us=https://us.example", directory)
+	defer func() { cluster = saved }()
+
+	// New games get IDs this node owns, and go in the directory
+	game := gameManager.CreateGame(context.Background())
+	if !cluster.owns(game.ID) {
+		t.Errorf("Game %s was created on a node that doesn't own it", game.ID)
+	}
+	directory.Set("moved-game", "us")
+
+	conn := dialTestServer(t)
+	sendTestMessage(t, conn, "join", map[string]interface{}{"gameID": "moved-game", "name": "Ada"})
+	redirect := readMessageOfType(t, conn, "redirect")
+	if redirect["gameID"] != "moved-game" || redirect["node"] != "us" || redirect["url"] != "wss://us.example/ws" {
+		t.Errorf("Unexpected redirect %v", redirect)
+	}
+
+	// A game this node should have but doesn't is just not found
+	local := "local-game"
+	for i := 0; cluster.ring.owner(local) != "eu"; i++ {
+		local = "local-game-" + strconv.Itoa(i)
+	}
+	sendTestMessage(t, conn, "spectate", map[string]interface{}{"gameID": local, "name": "Ada"})
+	if code := readMessageOfType(t, conn, "error")["code"]; code != "GAME_NOT_FOUND" {
+		t.Errorf("Expected GAME_NOT_FOUND, got %v", code)
+	}
+}
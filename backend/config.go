@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+
+	"pablo/protocol"
+)
+
+// GameConfig holds the optional house rules a table can turn on before the game starts.
+// The zero value is the classic rule set with stack spam detection off and no stack window.
+type GameConfig struct {
+	AllowDrawFromDiscard bool             `json:"allowDrawFromDiscard"` // Current player may take the top discard instead of drawing from the deck
+	KingPeekAndSwap      bool             `json:"kingPeekAndSwap"`      // Discarded black kings let you peek at an opponent's card, then optionally swap it
+	NoOpponentStacking   bool             `json:"noOpponentStacking"`   // Classic stacking: players may only stack their own cards, so there's no opponent stack or give
+	AllowMultiDiscard    bool             `json:"allowMultiDiscard"`    // Drawn card may replace two or more declared cards of identical rank
+	AnonymousNames       bool             `json:"anonymousNames"`       // Names are replaced with "Player 1"–"Player 6" until the round ends
+	ShowLatency          bool             `json:"showLatency"`          // Seats show each player's connection round-trip time, so slow stacks make sense
+	BlindPablo           bool             `json:"blindPablo"`           // Calling Pablo on your first turn, before any other move, doubles the bonus for winning and the penalty for losing
+	RevealClaim          bool             `json:"revealClaim"`          // At the start of their turn a player may claim a hand of 5 or less to win the round outright
+	FairShuffle          bool             `json:"fairShuffle"`          // Each deal is committed to by hash when the round starts and revealed when it ends, so players can check it
+	InitialPeek          bool             `json:"initialPeek"`          // Each round opens with everyone looking at two of their own cards before the first turn
+	FailedStack          FailedStackRules `json:"failedStack"`          // What a failed stack costs: penalty cards, and whether the stacker sits out the next chance to stack
+	MercyMargin          int              `json:"mercyMargin"`          // The game ends once a player's total trails the leader's by more than this; 0 plays on
+	RevealDelayMs        int              `json:"revealDelayMs"`        // Pause between hands being turned over at the end of a round; 0 shows them all at once
+	TurnTimeLimitMs      int              `json:"turnTimeLimitMs"`      // How long a player has for their turn before it is ended for them; 0 for no limit
+	StackWindowMs        int              `json:"stackWindowMs"`        // How long a discard is open to stacking; 0 leaves it open until the next card is played
+	StackSpam            StackSpamRules   `json:"stackSpam"`            // How the table answers players who keep failing stacks on purpose
+	Deck                 *DeckDefinition  `json:"deck,omitempty"`       // A non-standard deck; nil plays with the standard 52 cards
+}
+
+func DefaultGameConfig() GameConfig {
+	return GameConfig{StackWindowMs: defaultStackWindowMs, StackSpam: defaultStackSpamRules}
+}
+
+// UpdateConfig replaces the table's house rules. Rules can only be changed
+// by a seated player before the game starts, including while it is scheduled.
+func (g *Game) UpdateConfig(playerID string, config GameConfig) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, exists := g.Players[playerID]; !exists {
+		return false
+	}
+	if g.Status != protocol.StatusWaiting && g.Status != protocol.StatusScheduled {
+		return false
+	}
+
+	// Nothing has been dealt yet, so a different deck just replaces the draw pile
+	if !reflect.DeepEqual(g.Config.Deck, config.Deck) {
+		g.Deck = config.Deck.cards()
+		shuffleDeck(g.rng, g.Deck)
+	}
+	g.Config = config
+	g.broadcastGameState()
+	return true
+}
+
+// decodeGameConfig converts the loosely typed "config" object of a message payload into a GameConfig.
+// Fields that are missing keep their default value.
+func decodeGameConfig(raw interface{}) (GameConfig, error) {
+	config := DefaultGameConfig()
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, err
+	}
+	if config.MercyMargin < 0 {
+		return config, errInvalidMercyMargin
+	}
+	if config.RevealDelayMs < 0 || config.RevealDelayMs > maxRevealDelayMs {
+		return config, errInvalidRevealDelay
+	}
+	if config.TurnTimeLimitMs != 0 && (config.TurnTimeLimitMs < minTurnTimeLimitMs || config.TurnTimeLimitMs > maxTurnTimeLimitMs) {
+		return config, errInvalidTurnTimeLimit
+	}
+	if config.StackWindowMs != 0 && (config.StackWindowMs < minStackWindowMs || config.StackWindowMs > maxStackWindowMs) {
+		return config, errInvalidStackWindow
+	}
+	if err := config.FailedStack.validate(); err != nil {
+		return config, err
+	}
+	if err := config.StackSpam.validate(); err != nil {
+		return config, err
+	}
+	if err := config.Deck.validate(); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// displayName is the name broadcast for a player. At anonymous tables every player is
+// shown by seat ("Player 1", "Player 2", ...) until the round ends and names are revealed.
+// Caller must hold g.mu.
+func (g *Game) displayName(playerID string) string {
+	player, exists := g.Players[playerID]
+	if !exists {
+		return ""
+	}
+	if !g.Config.AnonymousNames || g.Status == protocol.StatusEnded {
+		return player.Name
+	}
+	for i, id := range g.SeatOrder {
+		if id == playerID {
+			return "Player " + strconv.Itoa(i+1)
+		}
+	}
+	return "Player"
+}
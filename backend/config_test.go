@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestAnonymousNames(t *testing.T) {
+	game := createTestGame("test-game")
+	game.AddPlayer("alice", "Alice", nil)
+	game.AddPlayer("bob", "Bob", nil)
+	game.Config.AnonymousNames = true
+	game.StartGame()
+
+	players := game.getGameStateForPlayer("alice").Players
+	if name := players["alice"].Name; name != "Player 1" {
+		t.Errorf("Expected 'Player 1', got '%v'", name)
+	}
+	if name := players["bob"].Name; name != "Player 2" {
+		t.Errorf("Expected 'Player 2', got '%v'", name)
+	}
+
+	game.EndRound()
+	players = game.getGameStateForPlayer("alice").Players
+	if name := players["bob"].Name; name != "Bob" {
+		t.Errorf("Names should be revealed when the round ends, got '%v'", name)
+	}
+}
+
+func TestNamesShownByDefault(t *testing.T) {
+	game := createTestGame("test-game")
+	game.AddPlayer("alice", "Alice", nil)
+
+	if name := game.displayName("alice"); name != "Alice" {
+		t.Errorf("Expected 'Alice', got '%s'", name)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"pablo/protocol"
+)
+
+// defaultIdleTimeout is how long a socket may go without sending anything before it is closed
+const defaultIdleTimeout = 20 * time.Minute
+
+// idleTimeout closes sockets that have sent no message or ping for this long; 0 never does.
+// It holds a time.Duration and is atomic so it can change while connections are open.
+var idleTimeout atomic.Int64
+
+func init() {
+	idleTimeout.Store(int64(defaultIdleTimeout))
+}
+
+// leaveReason is why a connection's sessions are being left
+type leaveReason int
+
+const (
+	leftByClient leaveReason = iota // The client closed the connection or left the game
+	leftIdle                        // It sent nothing for idleTimeout; lobby seats are given up
+	leftDead                        // It stopped answering pings, so the peer is gone; seats are kept
+)
+
+// connWatch keeps a socket's read deadline. Whatever the client sends, including its pings
+// and the pongs answering ours, pushes the deadline back by the sooner of idleTimeout and
+// the heartbeat: missedPongLimit ping intervals. A read that runs into the deadline tells
+// which of the two ran out. Only the connection's read goroutine uses it, the ping and
+// pong handlers included.
+type connWatch struct {
+	conn      *websocket.Conn
+	heartbeat bool // The deadline set last was the heartbeat's
+}
+
+// watchConn arms conn's read deadline and installs the handlers that keep it from passing
+// while the client answers pings. Call touch after every message read.
+func watchConn(conn *websocket.Conn) *connWatch {
+	w := &connWatch{conn: conn}
+	// Same as the default ping handler, plus the deadline
+	conn.SetPingHandler(func(data string) error {
+		w.touch()
+		err := conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		var netErr net.Error
+		if err == websocket.ErrCloseSent || errors.As(err, &netErr) {
+			return nil
+		}
+		return err
+	})
+	conn.SetPongHandler(func(data string) error {
+		w.touch()
+		notePong(conn, data)
+		return nil
+	})
+	w.touch()
+	return w
+}
+
+// touch pushes the read deadline back, as the client was just heard from
+func (w *connWatch) touch() {
+	timeout := time.Duration(idleTimeout.Load())
+	heartbeat := missedPongLimit * time.Duration(latencyPingInterval.Load())
+	w.heartbeat = heartbeat > 0 && (timeout == 0 || heartbeat < timeout)
+	if w.heartbeat {
+		timeout = heartbeat
+	}
+	if timeout > 0 {
+		w.conn.SetReadDeadline(time.Now().Add(timeout))
+	}
+}
+
+// reason is why a read that failed with err should end the connection
+func (w *connWatch) reason(err error) leaveReason {
+	switch {
+	case !isQuiet(err):
+		return leftByClient
+	case w.heartbeat:
+		return leftDead
+	default:
+		return leftIdle
+	}
+}
+
+// isQuiet reports whether a read failed because the socket went quiet
+func isQuiet(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// closeIdle tells the client why its socket is being closed
+func closeIdle(conn *websocket.Conn) {
+	message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "idle timeout")
+	conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
+}
+
+// closeReplaced tells a connection that a newer one took its seat in gameID, then closes
+// it once what was already queued for it has been written. It doesn't block, so the
+// caller can hold the game's lock.
+func closeReplaced(conn *websocket.Conn, gameID string) {
+	writeJSON(conn, Message{
+		Type:    protocol.MsgSessionReplaced,
+		GameID:  gameID,
+		Payload: map[string]string{"message": localize(conn, protocol.MsgSessionReplaced)},
+	})
+	go func() {
+		releaseConn(conn)
+		message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "replaced")
+		conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
+		conn.Close()
+	}()
+}
+
+// writeJSON queues v for conn. The frame is encoded here, on the caller's goroutine, into
+// a pooled buffer and written later by the write pool, in order with everything else sent
+// to conn. It is JSON unless conn negotiated another wire format. It only fails if v
+// can't be encoded or the connection can't take more.
+func writeJSON(conn *websocket.Conn, v interface{}) error {
+	f, err := encodeFrame(wireFormatOf(conn), v)
+	if err != nil {
+		return err
+	}
+	return outboxFor(conn).enqueue(f)
+}
+
+// releaseConn sends whatever is still queued for a closing connection, then forgets it
+func releaseConn(conn *websocket.Conn) {
+	if box, exists := outboxes.LoadAndDelete(conn); exists {
+		box.(*outbox).close()
+	}
+}
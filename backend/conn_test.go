@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// useIdleTimeout shortens the idle timeout for one test
+func useIdleTimeout(t *testing.T, d time.Duration) {
+	saved := idleTimeout.Load()
+	idleTimeout.Store(int64(d))
+	t.Cleanup(func() { idleTimeout.Store(saved) })
+}
+
+func TestIdleSocketIsClosed(t *testing.T) {
+	useIdleTimeout(t, 200*time.Millisecond)
+	conn, session := createTestGameOverWS(t)
+	game := gameManager.GetGame(context.Background(), session["gameID"].(string))
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+			t.Fatalf("Expected the server to close the socket, got %v", err)
+		}
+		break
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(game.LobbyPlayers()) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Idle player should lose their seat in a game that hasn't started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPingKeepsSocketOpen(t *testing.T) {
+	useIdleTimeout(t, 300*time.Millisecond)
+	conn, _ := createTestGameOverWS(t)
+
+	for i := 0; i < 4; i++ {
+		time.Sleep(150 * time.Millisecond)
+		sendTestMessage(t, conn, "ping", nil)
+		readMessageOfType(t, conn, "pong")
+	}
+}
+
+func TestReleaseIdleSeat(t *testing.T) {
+	game := createTestGame("test-game")
+	game.SetBroadcaster(newRecordingBroadcaster())
+	conn := &websocket.Conn{} // Only compared, never written to
+	game.AddPlayer("alice", "Alice", conn)
+	game.AddPlayer("bob", "Bob", nil)
+	game.StartGame()
+
+	if game.ReleaseIdleSeat("alice", conn) {
+		t.Error("Seats in a game under way should be kept")
+	}
+	if _, seated := game.Players["alice"]; !seated {
+		t.Error("Alice should still be seated")
+	}
+}
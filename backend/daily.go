@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const dailyBotCount = 3
+
+// dailyDate is the UTC calendar day a daily challenge belongs to
+func dailyDate(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// dailySeed derives the shared deck seed for a day, so everyone playing that day gets the same deal
+func dailySeed(date string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("pablo-daily-" + date))
+	return int64(h.Sum64())
+}
+
+type dailyEntry struct {
+	PlayerID   string    `json:"playerID"`
+	Name       string    `json:"name"`
+	Score      int       `json:"score"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// dailyLeaderboard keeps each day's daily challenge results in memory
+type dailyLeaderboard struct {
+	entries map[string][]dailyEntry // Keyed by date
+	mu      sync.Mutex
+}
+
+var dailyBoard = &dailyLeaderboard{
+	entries: make(map[string][]dailyEntry),
+}
+
+func (d *dailyLeaderboard) Record(date string, entry dailyEntry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries[date] = append(d.entries[date], entry)
+}
+
+// Top returns a day's results, lowest score first (earlier finishes win ties)
+func (d *dailyLeaderboard) Top(date string) []dailyEntry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entries := append([]dailyEntry{}, d.entries[date]...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Score != entries[j].Score {
+			return entries[i].Score < entries[j].Score
+		}
+		return entries[i].FinishedAt.Before(entries[j].FinishedAt)
+	})
+	return entries
+}
+
+// CreateDailyGame starts today's daily challenge for one player: the day's fixed deal,
+// the player in the first seat and standard bots in the rest.
+func (gm *GameManager) CreateDailyGame(playerID, name string, conn *websocket.Conn) *Game {
+	date := dailyDate(time.Now())
+	game := NewGameWithSeed("daily-"+date+"-"+newSessionToken()[:8], dailySeed(date))
+	game.DailyDate = date
+	game.AddPlayer(playerID, name, conn)
+	for i := 1; i <= dailyBotCount; i++ {
+		game.AddBot(fmt.Sprintf("bot-%d", i), fmt.Sprintf("Bot %d", i))
+	}
+
+	gm.mu.Lock()
+	gm.addGame(game)
+	gm.mu.Unlock()
+
+	game.StartGame()
+	return game
+}
+
+// recordDailyResult submits the human players' scores to the daily leaderboard.
+// Caller must hold g.mu.
+func (g *Game) recordDailyResult() {
+	if g.DailyDate == "" {
+		return
+	}
+	for _, id := range g.SeatOrder {
+		if _, isBot := g.Bots[id]; isBot {
+			continue
+		}
+		player := g.Players[id]
+		dailyBoard.Record(g.DailyDate, dailyEntry{
+			PlayerID:   player.ID,
+			Name:       player.Name,
+			Score:      player.Score,
+			FinishedAt: time.Now(),
+		})
+	}
+}
+
+// handleDailyLeaderboard serves GET /daily/leaderboard?date=YYYY-MM-DD (defaults to today)
+func handleDailyLeaderboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = dailyDate(time.Now())
+	}
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		http.Error(w, "invalid date", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"date":    date,
+		"entries": dailyBoard.Top(date),
+	})
+}
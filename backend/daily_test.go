@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDailyGamesShareDeal(t *testing.T) {
+	gm := &GameManager{games: make(map[string]*Game)}
+	first := gm.CreateDailyGame("alice", "Alice", nil)
+	second := gm.CreateDailyGame("bob", "Bob", nil)
+	first.botsRunning = true
+	second.botsRunning = true
+
+	if first.ID == second.ID {
+		t.Fatal("Each daily run should be its own game")
+	}
+	if first.CurrentPlayer != "alice" || second.CurrentPlayer != "bob" {
+		t.Error("The human should always play first")
+	}
+
+	for i := range first.Players["alice"].Cards {
+		if first.Players["alice"].Cards[i] != second.Players["bob"].Cards[i] {
+			t.Fatal("Both players should be dealt the same hand")
+		}
+	}
+	for i := range first.Deck {
+		if first.Deck[i] != second.Deck[i] {
+			t.Fatal("Both games should have the same deck order")
+		}
+	}
+	if len(first.Bots) != dailyBotCount {
+		t.Errorf("Expected %d bots, got %d", dailyBotCount, len(first.Bots))
+	}
+}
+
+func TestDailySeedChangesByDay(t *testing.T) {
+	if dailySeed("2026-01-01") == dailySeed("2026-01-02") {
+		t.Error("Different days should have different seeds")
+	}
+	if dailySeed("2026-01-01") != dailySeed("2026-01-01") {
+		t.Error("The same day should always have the same seed")
+	}
+}
+
+func TestDailyResultOnLeaderboard(t *testing.T) {
+	gm := &GameManager{games: make(map[string]*Game)}
+	game := gm.CreateDailyGame("daily-tester", "Tester", nil)
+	game.botsRunning = true
+	game.EndRound()
+
+	req := httptest.NewRequest(http.MethodGet, "/daily/leaderboard?date="+dailyDate(time.Now()), nil)
+	rec := httptest.NewRecorder()
+	handleDailyLeaderboard(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	var body struct {
+		Entries []dailyEntry `json:"entries"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range body.Entries {
+		if entry.PlayerID == "daily-tester" {
+			if entry.Score != game.Players["daily-tester"].Score {
+				t.Error("Leaderboard should show the round score")
+			}
+			return
+		}
+		if len(entry.PlayerID) > 4 && entry.PlayerID[:4] == "bot-" {
+			t.Error("Bots should not be on the leaderboard")
+		}
+	}
+	t.Error("Daily result should be on the leaderboard")
+}
+
+func TestDailyLeaderboardRejectsBadDate(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/daily/leaderboard?date=yesterday", nil)
+	rec := httptest.NewRecorder()
+	handleDailyLeaderboard(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"pablo/protocol"
+)
+
+// DeckDefinition describes a non-standard deck: more or fewer suits, a stripped set of
+// ranks, several copies of every card, and what each card scores and does. Values and
+// powers are keyed by rank ("7") or by a single card ("K:hearts"), the card winning.
+type DeckDefinition struct {
+	Suits  []string          `json:"suits"`
+	Ranks  []string          `json:"ranks"`
+	Copies int               `json:"copies,omitempty"` // Copies of each card; 0 means 1
+	Values map[string]int    `json:"values,omitempty"` // Points; ranks and cards missing here score as in a standard deck, or 0
+	Powers map[string]string `json:"powers,omitempty"` // protocol.Power* names; nil keeps the standard 7, 8 and 9
+}
+
+// Limits on custom decks, so a table can't ask for something silly or too small to deal from
+const (
+	deckMaxSuits  = 8
+	deckMaxRanks  = 20
+	deckMaxCopies = 4
+	deckMinCards  = 30 // Six hands of four, and something left to draw
+	deckMaxName   = 12
+)
+
+var (
+	standardSuits = []string{"hearts", "diamonds", "clubs", "spades"}
+	standardRanks = []string{"A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K"}
+
+	// standardValues is what cards score in a standard deck. Red kings are worth -1.
+	standardValues = map[string]int{
+		"A": 1, "2": 2, "3": 3, "4": 4, "5": 5, "6": 6, "7": 7, "8": 8, "9": 9, "10": 10,
+		"J": 10, "Q": 10, "K": 10, "K:hearts": -1, "K:diamonds": -1,
+	}
+
+	// standardPowers are the ranks that always have a power. Black kings get one from
+	// the king peek-and-swap house rule.
+	standardPowers = map[string]string{
+		protocol.RankPeekOwn:   protocol.PowerPeekOwn,
+		protocol.RankPeekOther: protocol.PowerPeekOther,
+		protocol.RankSwap:      protocol.PowerSwap,
+	}
+
+	knownPowers = map[string]bool{
+		protocol.PowerPeekOwn:   true,
+		protocol.PowerPeekOther: true,
+		protocol.PowerSwap:      true,
+		protocol.PowerKingSwap:  true,
+	}
+)
+
+// suitColors and rankNames describe standard cards in words, for clients that don't show
+// color or can't show cards at all. Suits and ranks of a custom deck go by their own names.
+var (
+	suitColors = map[string]string{"hearts": "red", "diamonds": "red", "clubs": "black", "spades": "black"}
+	rankNames  = map[string]string{
+		"A": "ace", "2": "two", "3": "three", "4": "four", "5": "five", "6": "six", "7": "seven",
+		"8": "eight", "9": "nine", "10": "ten", "J": "jack", "Q": "queen", "K": "king",
+	}
+)
+
+var errInvalidDeck = errors.New("invalid deck")
+
+func cardKey(card Card) string {
+	return card.Rank + ":" + card.Suit
+}
+
+// color is "red" or "black" for a standard suit, or "" if the suit has no color
+func (c Card) color() string {
+	return suitColors[c.Suit]
+}
+
+// label names the card for screen readers, like "seven of hearts", or is "" for a card
+// the viewer can't see
+func (c Card) label() string {
+	if c.Rank == "" {
+		return ""
+	}
+	name, ok := rankNames[c.Rank]
+	if !ok {
+		name = c.Rank
+	}
+	if c.Suit == "" {
+		return name
+	}
+	return name + " of " + c.Suit
+}
+
+// cards builds the deck in order, unshuffled. A nil definition is the standard 52 cards.
+func (d *DeckDefinition) cards() []Card {
+	suits, ranks, copies := standardSuits, standardRanks, 1
+	if d != nil {
+		suits, ranks = d.Suits, d.Ranks
+		if d.Copies > 1 {
+			copies = d.Copies
+		}
+	}
+	deck := make([]Card, 0, len(suits)*len(ranks)*copies)
+	for copy := 0; copy < copies; copy++ {
+		for _, suit := range suits {
+			for _, rank := range ranks {
+				deck = append(deck, Card{Suit: suit, Rank: rank})
+			}
+		}
+	}
+	return deck
+}
+
+// value is what card scores under this definition
+func (d *DeckDefinition) value(card Card) int {
+	if d != nil {
+		if value, ok := d.Values[cardKey(card)]; ok {
+			return value
+		}
+		if value, ok := d.Values[card.Rank]; ok {
+			return value
+		}
+	}
+	if value, ok := standardValues[cardKey(card)]; ok {
+		return value
+	}
+	return standardValues[card.Rank]
+}
+
+// power is the power card has under this definition, or ""
+func (d *DeckDefinition) power(card Card) string {
+	powers := standardPowers
+	if d != nil && d.Powers != nil {
+		powers = d.Powers
+	}
+	if power, ok := powers[cardKey(card)]; ok {
+		return power
+	}
+	return powers[card.Rank]
+}
+
+// validate checks a definition sent by a client
+func (d *DeckDefinition) validate() error {
+	if d == nil {
+		return nil
+	}
+	if len(d.Suits) == 0 || len(d.Suits) > deckMaxSuits || len(d.Ranks) == 0 || len(d.Ranks) > deckMaxRanks {
+		return fmt.Errorf("%w: 1-%d suits and 1-%d ranks", errInvalidDeck, deckMaxSuits, deckMaxRanks)
+	}
+	if d.Copies < 0 || d.Copies > deckMaxCopies {
+		return fmt.Errorf("%w: at most %d copies", errInvalidDeck, deckMaxCopies)
+	}
+	keys := make(map[string]bool)
+	for _, names := range [][]string{d.Suits, d.Ranks} {
+		seen := make(map[string]bool)
+		for _, name := range names {
+			if name == "" || len(name) > deckMaxName || seen[name] {
+				return fmt.Errorf("%w: suit and rank names must be distinct and 1-%d characters", errInvalidDeck, deckMaxName)
+			}
+			seen[name] = true
+			keys[name] = true
+		}
+	}
+	for _, rank := range d.Ranks {
+		for _, suit := range d.Suits {
+			keys[rank+":"+suit] = true
+		}
+	}
+	if len(d.cards()) < deckMinCards {
+		return fmt.Errorf("%w: a deck needs at least %d cards", errInvalidDeck, deckMinCards)
+	}
+	for key := range d.Values {
+		if !keys[key] {
+			return fmt.Errorf("%w: value for unknown card %q", errInvalidDeck, key)
+		}
+	}
+	for key, power := range d.Powers {
+		if !keys[key] || !knownPowers[power] {
+			return fmt.Errorf("%w: power %q for %q", errInvalidDeck, power, key)
+		}
+	}
+	return nil
+}
+
+// cardValue is what a card scores at this table. Caller must hold g.mu.
+func (g *Game) cardValue(card Card) int {
+	return g.Config.Deck.value(card)
+}
+
+// cardPower is the power a card activates when it lands on the discard pile, or "".
+// Caller must hold g.mu.
+func (g *Game) cardPower(card Card) string {
+	if power := g.Config.Deck.power(card); power != "" {
+		return power
+	}
+	if card.Rank == protocol.RankKing && g.Config.KingPeekAndSwap && (card.Suit == "clubs" || card.Suit == "spades") {
+		return protocol.PowerKingSwap
+	}
+	return ""
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func strippedDeck() *DeckDefinition {
+	return &DeckDefinition{
+		Suits: []string{"hearts", "diamonds", "clubs", "spades"},
+		Ranks: []string{"6", "7", "8", "9", "10", "J", "Q", "K", "A"},
+	}
+}
+
+func TestStandardDeckUnchanged(t *testing.T) {
+	deck := createDeck()
+	if len(deck) != 52 {
+		t.Fatalf("Expected 52 cards, got %d", len(deck))
+	}
+	game := createTestGame("test-game")
+	cases := map[Card]int{
+		{Suit: "hearts", Rank: "K"}: -1,
+		{Suit: "spades", Rank: "K"}: 10,
+		{Suit: "clubs", Rank: "A"}:  1,
+		{Suit: "clubs", Rank: "10"}: 10,
+	}
+	for card, want := range cases {
+		if got := game.cardValue(card); got != want {
+			t.Errorf("%s of %s: expected %d, got %d", card.Rank, card.Suit, want, got)
+		}
+	}
+	if game.isSpecialCard(Card{Suit: "spades", Rank: "K"}) {
+		t.Error("Black kings have no power unless the king rule is on")
+	}
+	game.Config.KingPeekAndSwap = true
+	if game.cardPower(Card{Suit: "spades", Rank: "K"}) != "peekAndSwap" {
+		t.Error("Black kings should peek and swap with the king rule on")
+	}
+}
+
+func TestCustomDeckCards(t *testing.T) {
+	if n := len(strippedDeck().cards()); n != 36 {
+		t.Errorf("Expected a 36-card stripped deck, got %d", n)
+	}
+
+	doubled := &DeckDefinition{
+		Suits:  []string{"hearts", "diamonds", "clubs", "spades", "stars"},
+		Ranks:  []string{"A", "2", "3", "4", "5"},
+		Copies: 2,
+	}
+	cards := doubled.cards()
+	if len(cards) != 50 {
+		t.Fatalf("Expected 50 cards, got %d", len(cards))
+	}
+	stars := 0
+	for _, card := range cards {
+		if card.Suit == "stars" && card.Rank == "A" {
+			stars++
+		}
+	}
+	if stars != 2 {
+		t.Errorf("Expected two aces of stars, got %d", stars)
+	}
+}
+
+func TestCustomDeckValuesAndPowers(t *testing.T) {
+	game := createTestGame("test-game")
+	deck := strippedDeck()
+	deck.Values = map[string]int{"A": 0, "Q:spades": -5}
+	deck.Powers = map[string]string{"J": "swap", "Q:spades": "peekOwn"}
+	game.Config.Deck = deck
+
+	if v := game.cardValue(Card{Suit: "clubs", Rank: "A"}); v != 0 {
+		t.Errorf("Aces should score 0, got %d", v)
+	}
+	if v := game.cardValue(Card{Suit: "spades", Rank: "Q"}); v != -5 {
+		t.Errorf("The queen of spades should score -5, got %d", v)
+	}
+	if v := game.cardValue(Card{Suit: "hearts", Rank: "Q"}); v != 10 {
+		t.Errorf("Other queens should score as standard, got %d", v)
+	}
+	if v := game.cardValue(Card{Suit: "hearts", Rank: "K"}); v != -1 {
+		t.Errorf("Red kings should still score -1, got %d", v)
+	}
+	if game.isSpecialCard(Card{Suit: "clubs", Rank: "7"}) {
+		t.Error("A deck with its own powers replaces the standard ones")
+	}
+	if p := game.cardPower(Card{Suit: "clubs", Rank: "J"}); p != "swap" {
+		t.Errorf("Expected jacks to swap, got %q", p)
+	}
+	if p := game.cardPower(Card{Suit: "spades", Rank: "Q"}); p != "peekOwn" {
+		t.Errorf("Expected the queen of spades to peek, got %q", p)
+	}
+}
+
+func TestCustomDeckPowerInPlay(t *testing.T) {
+	game := createTestGame("test-game")
+	addTestPlayers(game, 2)
+	deck := strippedDeck()
+	deck.Powers = map[string]string{"J": "peekOwn"}
+	game.Config.Deck = deck
+	game.StartGame()
+
+	current := game.CurrentPlayer
+	game.DrawnCards[current] = &Card{Suit: "hearts", Rank: "J", FaceUp: true}
+	game.HasDrawnThisTurn[current] = true
+	game.DiscardDrawnCard(current)
+	if game.PendingSpecialCard != "J" {
+		t.Fatalf("Expected a pending jack, got %q", game.PendingSpecialCard)
+	}
+	if err := game.UseSpecialCardFromDiscard(current, "J", map[string]interface{}{"targetIndex": float64(1)}); err != nil {
+		t.Fatalf("Jack should peek: %v", err)
+	}
+	if game.PendingSpecialCard != "" {
+		t.Error("The jack's power should be used up")
+	}
+}
+
+func TestCustomDeckScoring(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	deck := strippedDeck()
+	deck.Values = map[string]int{"J": 0}
+	game.Config.Deck = deck
+	game.StartGame()
+
+	game.Players[playerIDs[0]].Cards = []Card{
+		{Suit: "hearts", Rank: "J"}, {Suit: "clubs", Rank: "J"}, {Suit: "clubs", Rank: "6"}, {Suit: "spades", Rank: "K"},
+	}
+	game.EndRound()
+	if score := game.Players[playerIDs[0]].Score; score != 16 {
+		t.Errorf("Expected 16, got %d", score)
+	}
+}
+
+func TestDeckValidation(t *testing.T) {
+	valid := strippedDeck()
+	if err := valid.validate(); err != nil {
+		t.Errorf("Stripped deck should be valid: %v", err)
+	}
+	var none *DeckDefinition
+	if err := none.validate(); err != nil {
+		t.Errorf("No deck should be valid: %v", err)
+	}
+
+	tooSmall := &DeckDefinition{Suits: []string{"hearts", "spades"}, Ranks: []string{"A", "2", "3"}}
+	duplicate := strippedDeck()
+	duplicate.Ranks = append(duplicate.Ranks, "A")
+	unknownValue := strippedDeck()
+	unknownValue.Values = map[string]int{"2": 2}
+	unknownPower := strippedDeck()
+	unknownPower.Powers = map[string]string{"J": "fly"}
+	tooManyCopies := strippedDeck()
+	tooManyCopies.Copies = 9
+	for name, deck := range map[string]*DeckDefinition{
+		"too small":       tooSmall,
+		"duplicate rank":  duplicate,
+		"unknown value":   unknownValue,
+		"unknown power":   unknownPower,
+		"too many copies": tooManyCopies,
+	} {
+		if err := deck.validate(); !errors.Is(err, errInvalidDeck) {
+			t.Errorf("%s: expected errInvalidDeck, got %v", name, err)
+		}
+	}
+}
+
+func TestDecodeConfigWithDeck(t *testing.T) {
+	raw := map[string]interface{}{
+		"deck": map[string]interface{}{
+			"suits":  []interface{}{"hearts", "diamonds", "clubs", "spades"},
+			"ranks":  []interface{}{"A", "2"},
+			"copies": float64(4),
+		},
+	}
+	config, err := decodeGameConfig(raw)
+	if err != nil {
+		t.Fatalf("Expected a valid config: %v", err)
+	}
+	if config.Deck == nil || config.Deck.Copies != 4 {
+		t.Fatalf("Deck not decoded: %+v", config.Deck)
+	}
+
+	raw["deck"] = map[string]interface{}{"suits": []interface{}{"hearts"}, "ranks": []interface{}{"A"}}
+	if _, err := decodeGameConfig(raw); err == nil {
+		t.Error("A one-card deck should be refused")
+	}
+}
+
+func TestUpdateConfigReplacesDeck(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+
+	config := game.Config
+	config.Deck = strippedDeck()
+	if !game.UpdateConfig(playerIDs[0], config) {
+		t.Fatal("Host should be able to change the deck")
+	}
+	if len(game.Deck) != 36 {
+		t.Fatalf("Expected 36 cards in the deck, got %d", len(game.Deck))
+	}
+	for _, card := range game.Deck {
+		if card.Rank == "2" {
+			t.Fatal("The stripped deck should have no twos")
+		}
+	}
+
+	config.Deck = nil
+	game.UpdateConfig(playerIDs[0], config)
+	if len(game.Deck) != 52 {
+		t.Errorf("Expected the standard deck back, got %d cards", len(game.Deck))
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+const (
+	// discardPileLimit is how many discards a game keeps. Only the top card can be drawn or
+	// stacked on, and a draw from the discard is always followed by a discard, so the pile
+	// never shrinks far; older cards are counted and let go.
+	discardPileLimit = 16
+
+	// discardHistorySize is how many recent discards clients are shown
+	discardHistorySize = 5
+)
+
+// pushDiscard puts card on top of the discard pile, trimming the bottom once the pile
+// is over discardPileLimit. Caller must hold g.mu.
+func (g *Game) pushDiscard(card Card) {
+	g.DiscardPile = append(g.DiscardPile, card)
+	if over := len(g.DiscardPile) - discardPileLimit; over > 0 {
+		// Copy down rather than reslice, so the trimmed cards don't stay in the backing array
+		n := copy(g.DiscardPile, g.DiscardPile[over:])
+		g.DiscardPile = g.DiscardPile[:n]
+		g.DiscardedBelow += over
+		if g.StackableCardIndex >= 0 {
+			g.StackableCardIndex -= over
+			if g.StackableCardIndex < 0 {
+				g.StackableCardIndex = -1
+			}
+		}
+	}
+}
+
+// discardCount is how many cards have been discarded and not drawn back, trimmed ones included
+func (g *Game) discardCount() int {
+	return len(g.DiscardPile) + g.DiscardedBelow
+}
+
+// discardHistory returns up to discardHistorySize of the most recent discards, oldest
+// first, so the last one is the top card. The slice is a copy.
+func discardHistory(pile []Card) []Card {
+	start := len(pile) - discardHistorySize
+	if start < 0 {
+		start = 0
+	}
+	return append([]Card{}, pile[start:]...)
+}
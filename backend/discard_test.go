@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+func TestDiscardPileIsTrimmed(t *testing.T) {
+	game := createTestGame("test-game")
+	for i := 0; i < 40; i++ {
+		game.pushDiscard(Card{Suit: "hearts", Rank: "A", FaceUp: true})
+		game.StackableCardIndex = len(game.DiscardPile) - 1
+	}
+	game.pushDiscard(Card{Suit: "spades", Rank: "7", FaceUp: true})
+
+	if len(game.DiscardPile) != discardPileLimit {
+		t.Errorf("Expected %d cards kept, got %d", discardPileLimit, len(game.DiscardPile))
+	}
+	if game.discardCount() != 41 {
+		t.Errorf("Expected a count of 41, got %d", game.discardCount())
+	}
+	if game.StackableCardIndex != len(game.DiscardPile)-2 {
+		t.Errorf("Stackable index should follow its card down, got %d", game.StackableCardIndex)
+	}
+
+	state := game.getGameStateForSpectator()
+	if state.DiscardCount != 41 || len(state.DiscardHistory) != discardHistorySize {
+		t.Errorf("Unexpected summary: count %d, history %v", state.DiscardCount, state.DiscardHistory)
+	}
+	if top := state.DiscardHistory[len(state.DiscardHistory)-1]; top != *state.DiscardTop || top.Rank != "7" {
+		t.Errorf("History should end with the top card, got %v", top)
+	}
+}
+
+func TestStackingAfterTrim(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+	for i := 0; i < discardPileLimit; i++ {
+		game.pushDiscard(Card{Suit: "clubs", Rank: "2", FaceUp: true})
+	}
+	game.pushDiscard(Card{Suit: "hearts", Rank: "9", FaceUp: true})
+	game.StackableCardIndex = len(game.DiscardPile) - 1
+	game.Players[playerIDs[1]].Cards[0] = Card{Suit: "spades", Rank: "9"}
+
+	if err := game.StackCard(playerIDs[1], 0); err != nil {
+		t.Fatalf("Stacking on a trimmed pile should work, got %v", err)
+	}
+	if game.discardCount() != discardPileLimit+2 {
+		t.Errorf("Expected %d discards, got %d", discardPileLimit+2, game.discardCount())
+	}
+}
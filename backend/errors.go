@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+
+	"pablo/protocol"
+)
+
+// Rule violations returned by the game actions. Actions wrap them with detail where it
+// helps the player; check them with errors.Is.
+var (
+	ErrNotPlaying       = errors.New("the game is not in play")
+	ErrNotInGame        = errors.New("player is not in this game")
+	ErrNotYourTurn      = errors.New("it's not your turn")
+	ErrAlreadyDrawn     = errors.New("you have already drawn this turn")
+	ErrNoDrawnCard      = errors.New("you have no drawn card")
+	ErrUnplayedCard     = errors.New("your drawn card must be discarded or swapped first")
+	ErrMustSwap         = errors.New("a card taken from the discard pile must be swapped in")
+	ErrPendingPower     = errors.New("a special card power must be used or skipped first")
+	ErrNoPendingPower   = errors.New("there is no special card power to use")
+	ErrPendingGive      = errors.New("a card must be given first")
+	ErrNoPendingGive    = errors.New("there is no card to give")
+	ErrEmptyDeck        = errors.New("the deck is empty")
+	ErrEmptyDiscard     = errors.New("the discard pile is empty")
+	ErrNotStackable     = errors.New("the top card can't be stacked on")
+	ErrCardMismatch     = errors.New("card rank does not match")
+	ErrInvalidCard      = errors.New("invalid card")
+	ErrInvalidTarget    = errors.New("invalid target")
+	ErrInvalidCardIndex = errors.New("there's no card in that slot")
+	ErrTargetNotFound   = errors.New("that player isn't dealt in at this table")
+	ErrRuleDisabled     = errors.New("that move is not enabled at this table")
+	ErrPabloCalled      = errors.New("pablo has already been called")
+	ErrStackCooldown    = errors.New("too many failed stacks; stacking is on cooldown")
+	ErrStackSitOut      = errors.New("after a failed stack you sit out stacking on this card and the next")
+	ErrOutOfPhase       = errors.New("that move isn't allowed at this point in the turn")
+)
+
+// errorCodes maps each rule violation to the code clients see
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrNotPlaying, protocol.CodeNotPlaying},
+	{ErrNotInGame, protocol.CodeNotInGame},
+	{ErrNotYourTurn, protocol.CodeNotYourTurn},
+	{ErrAlreadyDrawn, protocol.CodeAlreadyDrawn},
+	{ErrNoDrawnCard, protocol.CodeNoDrawnCard},
+	{ErrUnplayedCard, protocol.CodeUnplayedCard},
+	{ErrMustSwap, protocol.CodeMustSwap},
+	{ErrPendingPower, protocol.CodePendingPower},
+	{ErrNoPendingPower, protocol.CodeNoPendingPower},
+	{ErrPendingGive, protocol.CodePendingGive},
+	{ErrNoPendingGive, protocol.CodeNoPendingGive},
+	{ErrEmptyDeck, protocol.CodeEmptyDeck},
+	{ErrEmptyDiscard, protocol.CodeEmptyDiscard},
+	{ErrNotStackable, protocol.CodeNotStackable},
+	{ErrCardMismatch, protocol.CodeCardMismatch},
+	{ErrRuleDisabled, protocol.CodeRuleDisabled},
+	{ErrPabloCalled, protocol.CodePabloCalled},
+	{ErrStackCooldown, protocol.CodeStackCooldown},
+	{ErrStackSitOut, protocol.CodeStackSitOut},
+	{ErrOutOfPhase, protocol.CodeOutOfPhase},
+	{ErrInvalidCardIndex, protocol.CodeInvalidCardIndex},
+	{ErrTargetNotFound, protocol.CodeTargetNotFound},
+	{ErrTutorialStep, protocol.CodeTutorialStep},
+	{ErrNotPeeking, protocol.CodeNotPeeking},
+	{ErrPeekLimit, protocol.CodePeekLimit},
+	{ErrNoNotesKey, protocol.CodeNoNotesKey},
+}
+
+// errorCode returns the protocol code for an action's error. Errors without a code of
+// their own (bad indexes and targets) are reported as INVALID_MOVE.
+func errorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	for _, entry := range errorCodes {
+		if errors.Is(err, entry.err) {
+			return entry.code
+		}
+	}
+	return protocol.CodeInvalidMove
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"pablo/protocol"
+)
+
+func TestActionErrors(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+	current := game.CurrentPlayer
+	other := playerIDs[0]
+	if other == current {
+		other = playerIDs[1]
+	}
+
+	if err := game.DrawCard(other); !errors.Is(err, ErrNotYourTurn) {
+		t.Errorf("Expected ErrNotYourTurn, got %v", err)
+	}
+	if err := game.DrawCard(current); err != nil {
+		t.Fatalf("Expected draw to succeed, got %v", err)
+	}
+	if err := game.DrawCard(current); !errors.Is(err, ErrAlreadyDrawn) {
+		t.Errorf("Expected ErrAlreadyDrawn, got %v", err)
+	}
+	if err := game.EndTurn(current); !errors.Is(err, ErrUnplayedCard) {
+		t.Errorf("Expected ErrUnplayedCard, got %v", err)
+	}
+
+	game.DrawnCards[current] = &Card{Suit: "hearts", Rank: "7", FaceUp: true}
+	if err := game.DiscardDrawnCard(current); err != nil {
+		t.Fatalf("Expected discard to succeed, got %v", err)
+	}
+	if err := game.EndTurn(current); !errors.Is(err, ErrPendingPower) {
+		t.Errorf("Expected ErrPendingPower, got %v", err)
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		code string
+	}{
+		{nil, ""},
+		{ErrNotYourTurn, protocol.CodeNotYourTurn},
+		{fmt.Errorf("%w: penalty card added", ErrCardMismatch), protocol.CodeCardMismatch},
+		{ErrInvalidCard, protocol.CodeInvalidMove},
+		{fmt.Errorf("%w (slot %d)", ErrInvalidCardIndex, 7), protocol.CodeInvalidCardIndex},
+		{ErrTargetNotFound, protocol.CodeTargetNotFound},
+	}
+	for _, tt := range tests {
+		if got := errorCode(tt.err); got != tt.code {
+			t.Errorf("errorCode(%v) = %q, want %q", tt.err, got, tt.code)
+		}
+	}
+}
+
+func TestSlotAndTargetErrors(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+	current := game.CurrentPlayer
+	other := playerIDs[0]
+	if other == current {
+		other = playerIDs[1]
+	}
+
+	game.DrawCard(current)
+	if err := game.SwapCard(current, 9); !errors.Is(err, ErrInvalidCardIndex) {
+		t.Errorf("Expected ErrInvalidCardIndex swapping into slot 9, got %v", err)
+	}
+	game.Players[current].Cards[1] = Card{}
+	if err := game.SwapCard(current, 1); !errors.Is(err, ErrInvalidCardIndex) {
+		t.Errorf("Expected ErrInvalidCardIndex swapping into an empty slot, got %v", err)
+	}
+	if discards := len(game.DiscardPile); discards != 0 && game.DiscardPile[discards-1].Rank == "" {
+		t.Error("An empty card should never reach the discard pile")
+	}
+	game.DrawnCards[current] = &Card{Suit: "hearts", Rank: "8", FaceUp: true}
+	game.DiscardDrawnCard(current)
+
+	tests := []struct {
+		params map[string]interface{}
+		want   error
+	}{
+		{map[string]interface{}{"targetPlayerID": "nobody", "targetIndex": float64(0)}, ErrTargetNotFound},
+		{map[string]interface{}{"targetPlayerID": other, "targetIndex": float64(4)}, ErrInvalidCardIndex},
+		{map[string]interface{}{"targetPlayerID": other}, ErrInvalidCardIndex},
+	}
+	for _, tt := range tests {
+		if err := game.UseSpecialCardFromDiscard(current, "8", tt.params); !errors.Is(err, tt.want) {
+			t.Errorf("With %v expected %v, got %v", tt.params, tt.want, err)
+		}
+	}
+	if game.PendingSpecialCard != "8" {
+		t.Error("A rejected power should stay pending so it can be tried again")
+	}
+
+	game.Players[other].Cards[2] = Card{}
+	if err := game.UseSpecialCardFromDiscard(current, "8", map[string]interface{}{"targetPlayerID": other, "targetIndex": float64(2)}); !errors.Is(err, ErrInvalidCardIndex) {
+		t.Errorf("Expected ErrInvalidCardIndex for a stacked-away slot, got %v", err)
+	}
+	if err := game.StackOpponentCard(current, "nobody", 0); !errors.Is(err, ErrTargetNotFound) {
+		t.Errorf("Expected ErrTargetNotFound stacking on an unknown player, got %v", err)
+	}
+}
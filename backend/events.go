@@ -0,0 +1,390 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"pablo/protocol"
+)
+
+// eventSchemaVersion is bumped whenever a field of GameEvent changes meaning or is removed.
+// Adding fields to Data does not bump it.
+const eventSchemaVersion = 1
+
+// GameEvent is the record published for everything that happens in a game. Consumers
+// should switch on Type and ignore types they don't know.
+//
+// Types and their Data:
+//
+//	playerJoined  name, seat
+//	playerLeft    (none)
+//	gameStarted   seatOrder, bots, config
+//	action        action, plus the action's arguments and outcome
+//	roundEnded    scores, winners, pabloCaller, durationMs
+//	turnStarted   (none; playerID is whose turn it is)
+//	startingSoon  scheduledAt, players (a scheduled game's lobby opens shortly)
+//	lobbyOpened   (none; a scheduled game can now be started)
+type GameEvent struct {
+	Version  int                    `json:"version"`
+	ID       string                 `json:"id"`
+	Type     string                 `json:"type"`
+	GameID   string                 `json:"gameID"`
+	PlayerID string                 `json:"playerID,omitempty"`
+	Time     time.Time              `json:"time"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+}
+
+// EventPublisher sends game events to a message broker
+type EventPublisher interface {
+	Publish(event GameEvent) error
+	Close() error
+}
+
+const eventQueueSize = 1024
+
+// eventBus hands events to the publishers on its own goroutine so a slow broker never
+// holds up a game. Events are dropped when the queue is full.
+type eventBus struct {
+	publishers []EventPublisher
+	queue      chan GameEvent
+	done       chan struct{}
+}
+
+// events is the server's event bus; nil when no publisher is configured
+var events *eventBus
+
+func newEventBus(publishers ...EventPublisher) *eventBus {
+	bus := &eventBus{
+		publishers: publishers,
+		queue:      make(chan GameEvent, eventQueueSize),
+		done:       make(chan struct{}),
+	}
+	go bus.run()
+	return bus
+}
+
+func (b *eventBus) run() {
+	defer close(b.done)
+	for event := range b.queue {
+		for _, publisher := range b.publishers {
+			if err := publisher.Publish(event); err != nil {
+				log.Println("Event publish error:", err)
+			}
+		}
+	}
+}
+
+func (b *eventBus) enqueue(event GameEvent) {
+	select {
+	case b.queue <- event:
+	default:
+		log.Println("Event queue full, dropping", event.Type, "event for game", event.GameID)
+	}
+}
+
+// Close publishes whatever is still queued, then closes the publishers
+func (b *eventBus) Close() {
+	close(b.queue)
+	<-b.done
+	for _, publisher := range b.publishers {
+		publisher.Close()
+	}
+}
+
+// auditLogLimit is how many events, chat lines and connections a game keeps for reports
+// and audit exports; past it the oldest are dropped so a marathon table can't grow forever
+const auditLogLimit = 10000
+
+// emit publishes an event for this game and adds it to the game's event log.
+// Caller must hold g.mu.
+func (g *Game) emit(eventType, playerID string, data map[string]interface{}) {
+	event := GameEvent{
+		Version:  eventSchemaVersion,
+		ID:       newUUID(),
+		Type:     eventType,
+		GameID:   g.ID,
+		PlayerID: playerID,
+		Time:     time.Now().UTC(),
+		Data:     data,
+	}
+	g.eventLog = appendCapped(g.eventLog, event)
+	if events != nil {
+		events.enqueue(event)
+	}
+	g.narrate(event)
+	g.followTutorial(eventType, playerID, data)
+}
+
+// emitAction publishes a gameplay action. Caller must hold g.mu.
+func (g *Game) emitAction(playerID, action string, data map[string]interface{}) {
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	data["action"] = action
+	g.actedThisRound[playerID] = true
+	g.emit(protocol.EventAction, playerID, data)
+}
+
+// emitTurnStarted publishes that it is now CurrentPlayer's turn and starts the clock on it.
+// Caller must hold g.mu.
+func (g *Game) emitTurnStarted() {
+	g.emit(protocol.EventTurnStarted, g.CurrentPlayer, nil)
+	g.startTurnTimer()
+}
+
+// roundStarted marks the start of a round once the cards are dealt. Caller must hold g.mu.
+func (g *Game) roundStarted() {
+	g.RoundStartedAt = time.Now()
+	if g.match.StartedAt.IsZero() {
+		g.match.StartedAt = g.RoundStartedAt
+	}
+	g.ClaimWinner = ""
+	g.actedThisRound = make(map[string]bool)
+	bots := []string{}
+	for _, id := range g.SeatOrder {
+		if _, isBot := g.Bots[id]; isBot {
+			bots = append(bots, id)
+		}
+	}
+	started := map[string]interface{}{
+		"seatOrder": append([]string(nil), g.SeatOrder...),
+		"bots":      bots,
+		"config":    g.Config,
+	}
+	if g.deal != nil {
+		started["dealCommitment"] = g.deal.Hash
+	}
+	g.emit(protocol.EventGameStarted, "", started)
+}
+
+// emitRoundEnded publishes the final scores of a round. Caller must hold g.mu.
+func (g *Game) emitRoundEnded(pabloCaller string) {
+	scores := make(map[string]int)
+	for _, id := range g.SeatOrder {
+		scores[id] = g.Players[id].Score
+	}
+	data := map[string]interface{}{
+		"scores":      scores,
+		"winners":     g.roundWinners(),
+		"pabloCaller": pabloCaller,
+	}
+	if g.ClaimWinner != "" {
+		data["claimWinner"] = g.ClaimWinner
+	}
+	if handicaps := g.roundHandicaps(); len(handicaps) > 0 {
+		data["handicaps"] = handicaps
+	}
+	if deal := g.revealedDeal(); deal != nil {
+		data["deal"] = deal
+	}
+	if !g.RoundStartedAt.IsZero() {
+		data["durationMs"] = time.Since(g.RoundStartedAt).Milliseconds()
+	}
+	g.emit(protocol.EventRoundEnded, "", data)
+}
+
+// natsPublisher publishes each event on "<subject>.<type>" using the NATS text protocol
+type natsPublisher struct {
+	addr    string
+	subject string
+	conn    net.Conn
+	mu      sync.Mutex
+}
+
+// newNATSPublisher connects to a nats://host:port URL
+func newNATSPublisher(rawURL, subject string) (*natsPublisher, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "nats" {
+		return nil, fmt.Errorf("unsupported NATS URL scheme %q", u.Scheme)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	p := &natsPublisher{addr: addr, subject: subject}
+	if err := p.connect(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// connect dials the server and answers its keepalive pings. Caller must hold p.mu
+// (or be the only user of p).
+func (p *natsPublisher) connect() error {
+	conn, err := net.DialTimeout("tcp", p.addr, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write([]byte(`CONNECT {"verbose":false,"pedantic":false,"name":"pablo"}` + "\r\n")); err != nil {
+		conn.Close()
+		return err
+	}
+	p.conn = conn
+	go p.readLoop(conn)
+	return nil
+}
+
+func (p *natsPublisher) readLoop(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch {
+		case strings.HasPrefix(line, "PING"):
+			p.mu.Lock()
+			conn.Write([]byte("PONG\r\n"))
+			p.mu.Unlock()
+		case strings.HasPrefix(line, "-ERR"):
+			log.Println("NATS error:", strings.TrimSpace(line))
+		}
+	}
+}
+
+func (p *natsPublisher) Publish(event GameEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	var frame bytes.Buffer
+	fmt.Fprintf(&frame, "PUB %s.%s %d\r\n", p.subject, event.Type, len(data))
+	frame.Write(data)
+	frame.WriteString("\r\n")
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn != nil {
+		if _, err := p.conn.Write(frame.Bytes()); err == nil {
+			return nil
+		}
+		p.conn.Close()
+		p.conn = nil
+	}
+	// Reconnect once and retry; if that fails the event is lost
+	if err := p.connect(); err != nil {
+		return err
+	}
+	_, err = p.conn.Write(frame.Bytes())
+	return err
+}
+
+func (p *natsPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn = nil
+	return err
+}
+
+// kafkaRESTPublisher produces events to a Kafka topic through a Kafka REST proxy,
+// keyed by game ID so each game's events stay in order on one partition
+type kafkaRESTPublisher struct {
+	endpoint string
+	client   *http.Client
+}
+
+func newKafkaRESTPublisher(proxyURL, topic string) *kafkaRESTPublisher {
+	return &kafkaRESTPublisher{
+		endpoint: strings.TrimRight(proxyURL, "/") + "/topics/" + url.PathEscape(topic),
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (p *kafkaRESTPublisher) Publish(event GameEvent) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"records": []map[string]interface{}{
+			{"key": event.GameID, "value": event},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Post(p.endpoint, "application/vnd.kafka.json.v2+json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("kafka REST proxy returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (p *kafkaRESTPublisher) Close() error {
+	return nil
+}
+
+// eventLogPublisher appends events as JSON lines to one file per UTC day
+// (events-YYYY-MM-DD.jsonl), which the analytics job reads back
+type eventLogPublisher struct {
+	dir  string
+	date string
+	file *os.File
+	mu   sync.Mutex
+}
+
+func newEventLogPublisher(dir string) (*eventLogPublisher, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &eventLogPublisher{dir: dir}, nil
+}
+
+func eventLogName(date string) string {
+	return "events-" + date + ".jsonl"
+}
+
+func (p *eventLogPublisher) Publish(event GameEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	date := event.Time.UTC().Format("2006-01-02")
+	if p.file == nil || date != p.date {
+		if p.file != nil {
+			p.file.Close()
+		}
+		file, err := os.OpenFile(filepath.Join(p.dir, eventLogName(date)), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			p.file = nil
+			return err
+		}
+		p.file = file
+		p.date = date
+	}
+	_, err = p.file.Write(append(data, '\n'))
+	return err
+}
+
+func (p *eventLogPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.file == nil {
+		return nil
+	}
+	err := p.file.Close()
+	p.file = nil
+	return err
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recordingPublisher struct {
+	events []GameEvent
+	mu     sync.Mutex
+}
+
+func (p *recordingPublisher) Publish(event GameEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+func (p *recordingPublisher) Close() error { return nil }
+
+// recordEvents installs an event bus that records into the returned publisher.
+// Call the returned function to flush the bus and restore the previous one.
+func recordEvents() (*recordingPublisher, func()) {
+	recorder := &recordingPublisher{}
+	previous := events
+	events = newEventBus(recorder)
+	return recorder, func() {
+		events.Close()
+		events = previous
+	}
+}
+
+func TestGameEventsPublished(t *testing.T) {
+	recorder, flush := recordEvents()
+
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+	game.Deck[0] = Card{Suit: "hearts", Rank: "5"}
+	game.DrawCard(playerIDs[0])
+	game.DiscardDrawnCard(playerIDs[0])
+	game.EndTurn(playerIDs[0])
+	flush()
+
+	var got []string
+	for _, event := range recorder.events {
+		if event.Version != eventSchemaVersion || event.GameID != "test-game" || event.ID == "" {
+			t.Errorf("Event missing envelope fields: %+v", event)
+		}
+		name := event.Type
+		if event.Type == "action" {
+			name = event.Data["action"].(string)
+		}
+		got = append(got, name)
+	}
+	want := []string{"playerJoined", "playerJoined", "gameStarted", "turnStarted", "drawCard", "discardDrawnCard", "endTurn", "turnStarted"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected events %v, got %v", want, got)
+	}
+}
+
+func TestRoundEndedEvent(t *testing.T) {
+	recorder, flush := recordEvents()
+
+	game := createTestGame("test-game")
+	addTestPlayers(game, 2)
+	game.StartGame()
+	game.mu.Lock()
+	game.EndRound()
+	game.mu.Unlock()
+	flush()
+
+	last := recorder.events[len(recorder.events)-1]
+	if last.Type != "roundEnded" {
+		t.Fatalf("Expected roundEnded last, got %s", last.Type)
+	}
+	for _, field := range []string{"scores", "winners", "pabloCaller", "durationMs"} {
+		if _, ok := last.Data[field]; !ok {
+			t.Errorf("roundEnded missing %s", field)
+		}
+	}
+}
+
+func TestNATSPublisher(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	lines := make(chan string, 4)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		// CONNECT, then answer a keepalive, then PUB header and payload
+		line, _ := reader.ReadString('\n')
+		lines <- line
+		conn.Write([]byte("PING\r\n"))
+		for i := 0; i < 3; i++ {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			lines <- line
+		}
+	}()
+
+	publisher, err := newNATSPublisher("nats://"+listener.Addr().String(), "pablo.events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer publisher.Close()
+
+	if line := <-lines; !strings.HasPrefix(line, "CONNECT ") {
+		t.Fatalf("Expected CONNECT, got %q", line)
+	}
+	if line := <-lines; line != "PONG\r\n" {
+		t.Fatalf("Expected PONG reply to PING, got %q", line)
+	}
+
+	if err := publisher.Publish(GameEvent{Version: eventSchemaVersion, Type: "roundEnded", GameID: "g1"}); err != nil {
+		t.Fatal(err)
+	}
+	header := <-lines
+	if !strings.HasPrefix(header, "PUB pablo.events.roundEnded ") {
+		t.Fatalf("Unexpected PUB header %q", header)
+	}
+	var event GameEvent
+	if err := json.Unmarshal([]byte(strings.TrimSpace(<-lines)), &event); err != nil || event.GameID != "g1" {
+		t.Errorf("Expected the event as JSON payload, got %+v (%v)", event, err)
+	}
+}
+
+func TestKafkaRESTPublisher(t *testing.T) {
+	var path, contentType string
+	var body struct {
+		Records []struct {
+			Key   string    `json:"key"`
+			Value GameEvent `json:"value"`
+		} `json:"records"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		contentType = r.Header.Get("Content-Type")
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &body)
+	}))
+	defer server.Close()
+
+	publisher := newKafkaRESTPublisher(server.URL, "pablo.events")
+	if err := publisher.Publish(GameEvent{Type: "gameStarted", GameID: "g1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if path != "/topics/pablo.events" {
+		t.Errorf("Unexpected path %s", path)
+	}
+	if contentType != "application/vnd.kafka.json.v2+json" {
+		t.Errorf("Unexpected content type %s", contentType)
+	}
+	if len(body.Records) != 1 || body.Records[0].Key != "g1" || body.Records[0].Value.Type != "gameStarted" {
+		t.Errorf("Unexpected records %+v", body.Records)
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"pablo/protocol"
+)
+
+// matchRecord is how one player did in one game, read back from the event logs
+type matchRecord struct {
+	GameID     string
+	PlayerID   string
+	Name       string
+	Seat       int
+	Players    int // Seats at the table in the last round
+	Rounds     int
+	RoundsWon  int
+	TotalScore int
+	LastScore  int
+	LastRound  time.Time // When their last round ended
+}
+
+// readMatchRecords goes through every daily event log in dir and sums up each player's
+// rounds, game by game. keep picks which games to read; events for the rest are skipped.
+func readMatchRecords(dir string, keep func(event GameEvent) bool) (map[string][]*matchRecord, error) {
+	files, err := filepath.Glob(filepath.Join(dir, eventLogName("*")))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	games := make(map[string]map[string]*matchRecord) // Game ID -> player ID -> record
+	record := func(gameID, playerID string) *matchRecord {
+		if games[gameID] == nil {
+			games[gameID] = make(map[string]*matchRecord)
+		}
+		rec, exists := games[gameID][playerID]
+		if !exists {
+			rec = &matchRecord{GameID: gameID, PlayerID: playerID, Seat: -1}
+			games[gameID][playerID] = rec
+		}
+		return rec
+	}
+
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var event GameEvent
+			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+				continue // A line cut short by a crash
+			}
+			if !keep(event) {
+				continue
+			}
+			switch event.Type {
+			case protocol.EventPlayerJoined:
+				rec := record(event.GameID, event.PlayerID)
+				rec.Name, _ = event.Data["name"].(string)
+				if seat, ok := event.Data["seat"].(float64); ok {
+					rec.Seat = int(seat)
+				}
+			case protocol.EventRoundEnded:
+				scores, _ := event.Data["scores"].(map[string]interface{})
+				won := make(map[string]bool)
+				winners, _ := event.Data["winners"].([]interface{})
+				for _, winner := range winners {
+					id, _ := winner.(string)
+					won[id] = true
+				}
+				for id, value := range scores {
+					score, _ := value.(float64)
+					rec := record(event.GameID, id)
+					rec.Players = len(scores)
+					rec.Rounds++
+					rec.TotalScore += int(score)
+					rec.LastScore = int(score)
+					rec.LastRound = event.Time
+					if won[id] {
+						rec.RoundsWon++
+					}
+				}
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Only players who finished a round have results
+	results := make(map[string][]*matchRecord, len(games))
+	for gameID, players := range games {
+		for _, rec := range players {
+			if rec.Rounds > 0 {
+				results[gameID] = append(results[gameID], rec)
+			}
+		}
+	}
+	return results, nil
+}
+
+var matchRecordHeader = []string{"gameID", "playerID", "name", "seat", "players", "rounds", "roundsWon", "totalScore", "lastScore", "lastRoundAt"}
+
+func (rec *matchRecord) csvRow() []string {
+	return []string{
+		rec.GameID,
+		rec.PlayerID,
+		rec.Name,
+		strconv.Itoa(rec.Seat),
+		strconv.Itoa(rec.Players),
+		strconv.Itoa(rec.Rounds),
+		strconv.Itoa(rec.RoundsWon),
+		strconv.Itoa(rec.TotalScore),
+		strconv.Itoa(rec.LastScore),
+		rec.LastRound.UTC().Format(time.RFC3339),
+	}
+}
+
+func writeMatchRecordsCSV(w http.ResponseWriter, filename string, records []*matchRecord) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	out := csv.NewWriter(w)
+	out.Write(matchRecordHeader)
+	for _, rec := range records {
+		out.Write(rec.csvRow())
+	}
+	out.Flush()
+}
+
+// handleGameResults serves GET /games/{gameID}/results.csv: each player's rounds, round
+// wins and total score in the game, lowest total first, from the event logs
+func handleGameResults(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/games/"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "results.csv" {
+		http.NotFound(w, r)
+		return
+	}
+	if eventLogStore == nil {
+		http.Error(w, "event log disabled", http.StatusNotFound)
+		return
+	}
+	gameID := parts[0]
+	games, err := readMatchRecords(eventLogStore.dir, func(event GameEvent) bool { return event.GameID == gameID })
+	if err != nil {
+		http.Error(w, "reading event log failed", http.StatusInternalServerError)
+		return
+	}
+	records := games[gameID]
+	if len(records) == 0 {
+		http.Error(w, "no finished rounds for this game", http.StatusNotFound)
+		return
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].TotalScore != records[j].TotalScore {
+			return records[i].TotalScore < records[j].TotalScore
+		}
+		return records[i].Seat < records[j].Seat
+	})
+	writeMatchRecordsCSV(w, gameID+"-results.csv", records)
+}
+
+// handlePlayerHistory serves GET /players/{name}/history.csv: every game a player sitting
+// under that name finished a round in, newest first. Players have no accounts, so games
+// are matched by name, ignoring case, as bans are.
+func handlePlayerHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.EscapedPath(), "/players/"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "history.csv" {
+		http.NotFound(w, r)
+		return
+	}
+	name, err := url.PathUnescape(parts[0])
+	if err != nil || strings.TrimSpace(name) == "" {
+		http.NotFound(w, r)
+		return
+	}
+	name = strings.TrimSpace(name)
+	if eventLogStore == nil {
+		http.Error(w, "event log disabled", http.StatusNotFound)
+		return
+	}
+	games, err := readMatchRecords(eventLogStore.dir, func(GameEvent) bool { return true })
+	if err != nil {
+		http.Error(w, "reading event log failed", http.StatusInternalServerError)
+		return
+	}
+	var records []*matchRecord
+	for _, players := range games {
+		for _, rec := range players {
+			if strings.EqualFold(strings.TrimSpace(rec.Name), name) {
+				records = append(records, rec)
+			}
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].LastRound.Equal(records[j].LastRound) {
+			return records[i].LastRound.After(records[j].LastRound)
+		}
+		return records[i].GameID < records[j].GameID
+	})
+	writeMatchRecordsCSV(w, "history.csv", records)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResultsAndHistoryCSV(t *testing.T) {
+	saved := eventLogStore
+	eventLogStore, _ = newEventLogPublisher(t.TempDir())
+	defer func() { eventLogStore = saved }()
+
+	day := time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC)
+	publish := func(gameID, eventType, playerID string, at time.Time, data map[string]interface{}) {
+		eventLogStore.Publish(GameEvent{Type: eventType, GameID: gameID, PlayerID: playerID, Time: at, Data: data})
+	}
+	publish("g1", "playerJoined", "a", day, map[string]interface{}{"name": "Ada", "seat": 0})
+	publish("g1", "playerJoined", "b", day, map[string]interface{}{"name": "Bo", "seat": 1})
+	publish("g1", "roundEnded", "", day.Add(time.Minute), map[string]interface{}{"scores": map[string]int{"a": 9, "b": 4}, "winners": []string{"b"}})
+	publish("g1", "roundEnded", "", day.Add(2*time.Minute), map[string]interface{}{"scores": map[string]int{"a": 1, "b": 12}, "winners": []string{"a"}})
+	// The next day, Ada plays again somewhere else
+	publish("g2", "playerJoined", "c", day.Add(24*time.Hour), map[string]interface{}{"name": "ada", "seat": 0})
+	publish("g2", "playerJoined", "d", day.Add(24*time.Hour), map[string]interface{}{"name": "Cy", "seat": 1})
+	publish("g2", "roundEnded", "", day.Add(25*time.Hour), map[string]interface{}{"scores": map[string]int{"c": 3, "d": 7}, "winners": []string{"c"}})
+	publish("g3", "playerJoined", "e", day, map[string]interface{}{"name": "Ada", "seat": 0}) // Never finished a round
+	eventLogStore.Close()
+
+	get := func(path string) (int, [][]string) {
+		rec := httptest.NewRecorder()
+		handler := handleGames
+		if strings.HasPrefix(path, "/players/") {
+			handler = handlePlayerHistory
+		}
+		handler(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		rows, _ := csv.NewReader(rec.Body).ReadAll()
+		return rec.Code, rows
+	}
+
+	code, rows := get("/games/g1/results.csv")
+	if code != http.StatusOK || len(rows) != 3 {
+		t.Fatalf("Expected a header and two players, got %d %v", code, rows)
+	}
+	if got, want := rows[1], []string{"g1", "a", "Ada", "0", "2", "2", "1", "10", "1", "2024-03-01T20:02:00Z"}; !slices.Equal(got, want) {
+		t.Errorf("Expected the lowest total first %v, got %v", want, got)
+	}
+	if rows[2][1] != "b" || rows[2][7] != "16" {
+		t.Errorf("Unexpected second row %v", rows[2])
+	}
+	if code, _ := get("/games/g3/results.csv"); code != http.StatusNotFound {
+		t.Errorf("A game without finished rounds has no results, got %d", code)
+	}
+
+	code, rows = get("/players/ADA/history.csv")
+	if code != http.StatusOK || len(rows) != 3 {
+		t.Fatalf("Expected a header and two games, got %d %v", code, rows)
+	}
+	if rows[1][0] != "g2" || rows[2][0] != "g1" {
+		t.Errorf("Expected the newest game first, got %v", rows)
+	}
+	if _, rows = get("/players/Nobody/history.csv"); len(rows) != 1 {
+		t.Errorf("Expected only the header for an unknown name, got %v", rows)
+	}
+}
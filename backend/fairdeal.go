@@ -0,0 +1,79 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"pablo/protocol"
+)
+
+// dealCommitment is what a FairShuffle table promises about a round before it's played:
+// the hash of a random salt and the deck as it stood when the cards were dealt. The hash
+// goes out as the round starts; the salt and deck follow when it ends, so anyone can hash
+// them again and check the deal against what was promised.
+type dealCommitment struct {
+	Hash string `json:"commitment"`
+	Salt string `json:"salt"`
+	Deck string `json:"deck"`
+}
+
+// encodeDeck writes cards in order as "rank:suit" joined by commas, top of the deck first.
+// Hands are dealt off the top, four cards at a time in seat order.
+func encodeDeck(cards []Card) string {
+	parts := make([]string, len(cards))
+	for i, card := range cards {
+		parts[i] = card.Rank + ":" + card.Suit
+	}
+	return strings.Join(parts, ",")
+}
+
+// dealHash is the commitment to deck under salt: hex SHA-256 of the salt, a newline, and
+// the encoded deck.
+func dealHash(salt, deck string) string {
+	sum := sha256.Sum256([]byte(salt + "\n" + deck))
+	return hex.EncodeToString(sum[:])
+}
+
+// verifyDeal reports whether salt and deck are what commitment was made from
+func verifyDeal(commitment, salt, deck string) bool {
+	return commitment != "" && dealHash(salt, deck) == commitment
+}
+
+// commitDeal shuffles the deck and commits to it before a FairShuffle table deals. It
+// shuffles again each round so the cards left over from a revealed deck can't be counted
+// on in the next one. Caller must hold g.mu.
+func (g *Game) commitDeal() {
+	g.deal = nil
+	if !g.Config.FairShuffle {
+		return
+	}
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return
+	}
+	shuffleDeck(g.rng, g.Deck)
+	deal := &dealCommitment{Salt: hex.EncodeToString(salt), Deck: encodeDeck(g.Deck)}
+	deal.Hash = dealHash(deal.Salt, deal.Deck)
+	g.deal = deal
+}
+
+// dealCommitmentHash is the commitment for the round in play, or "" at tables without one.
+// Caller must hold g.mu.
+func (g *Game) dealCommitmentHash() string {
+	if g.deal == nil {
+		return ""
+	}
+	return g.deal.Hash
+}
+
+// revealedDeal is the commitment with its preimage, for the end of the round. Nil while
+// the round is still being played. Caller must hold g.mu.
+func (g *Game) revealedDeal() *dealCommitment {
+	if g.deal == nil || g.Status != protocol.StatusEnded {
+		return nil
+	}
+	deal := *g.deal
+	return &deal
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"pablo/protocol"
+)
+
+func TestFairShuffleCommitsAndReveals(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	playerIDs := addTestPlayers(game, 2)
+	game.Config.FairShuffle = true
+	game.StartGame()
+
+	commitment := game.getGameStateForPlayer(playerIDs[0]).DealCommitment
+	if commitment == "" {
+		t.Fatal("Expected the state to carry the deal commitment")
+	}
+	if game.revealedDeal() != nil {
+		t.Fatal("The deal should stay hidden while the round is played")
+	}
+	dealt := []string{}
+	for _, id := range game.SeatOrder {
+		dealt = append(dealt, encodeDeck(game.Players[id].Cards))
+	}
+
+	game.EndRound()
+
+	var deal *dealCommitment
+	for _, message := range recorder.players[playerIDs[0]] {
+		if message.Type == protocol.MsgRoundSummary {
+			deal, _ = message.Payload.(map[string]interface{})["deal"].(*dealCommitment)
+		}
+	}
+	if deal == nil {
+		t.Fatal("Expected the round summary to reveal the deal")
+	}
+	if deal.Hash != commitment {
+		t.Errorf("Expected the revealed commitment %q, got %q", commitment, deal.Hash)
+	}
+	if !verifyDeal(commitment, deal.Salt, deal.Deck) {
+		t.Error("The revealed salt and deck should hash to the commitment")
+	}
+	if !strings.HasPrefix(deal.Deck, strings.Join(dealt, ",")+",") {
+		t.Error("The hands should have been dealt off the top of the revealed deck")
+	}
+
+	tampered := strings.Replace(deal.Deck, ",", ";", 1)
+	if verifyDeal(commitment, deal.Salt, tampered) {
+		t.Error("A different deck should not verify")
+	}
+}
+
+func TestNoCommitmentWithoutFairShuffle(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+
+	if commitment := game.getGameStateForPlayer(playerIDs[0]).DealCommitment; commitment != "" {
+		t.Errorf("Expected no commitment, got %q", commitment)
+	}
+	game.EndRound()
+	if game.revealedDeal() != nil {
+		t.Error("Expected nothing to reveal")
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"pablo/protocol"
+)
+
+// Friends are invited to a new lobby the moment it opens. Players have no accounts, so a
+// client announces itself on any open connection with a presence message: a secret friend
+// key, from which the server derives the friend code the player shares, and the codes of
+// the friends they follow. Two players are friends when each follows the other, so knowing
+// someone's code isn't enough to send them invites. Accepting an invite holds a seat in
+// the lobby for seatHoldTime, so it can't fill up before the friend gets there.
+
+const (
+	minFriendKeyLength = 16
+	friendCodeLength   = 12
+	maxFriends         = 200
+	inviteTTL          = 5 * time.Minute // How long an unanswered invite can be accepted
+	seatHoldTime       = 2 * time.Minute // How long an accepted invite keeps a seat free
+)
+
+// maxSeats is how many players a table has room for
+const maxSeats = 6
+
+// friendCode is the public code for a friend key, safe to share
+func friendCode(key string) string {
+	sum := sha256.Sum256([]byte("pablo-friend:" + key))
+	return hex.EncodeToString(sum[:])[:friendCodeLength]
+}
+
+// presenceEntry is what a connection announced about its player
+type presenceEntry struct {
+	code    string
+	name    string
+	friends map[string]bool // Codes this player follows
+}
+
+// invite is a pending invitation to a lobby
+type invite struct {
+	ID        string
+	GameID    string
+	InviterID string // The inviter's seat in the game
+	FromCode  string
+	ToCode    string
+	ExpiresAt time.Time
+}
+
+// presenceRegistry tracks which connections belong to which friend codes, and the
+// invites sent to them
+type presenceRegistry struct {
+	byConn  map[*websocket.Conn]*presenceEntry
+	byCode  map[string]map[*websocket.Conn]bool
+	invites map[string]*invite
+	mu      sync.Mutex
+}
+
+var presence = newPresenceRegistry()
+
+func newPresenceRegistry() *presenceRegistry {
+	return &presenceRegistry{
+		byConn:  make(map[*websocket.Conn]*presenceEntry),
+		byCode:  make(map[string]map[*websocket.Conn]bool),
+		invites: make(map[string]*invite),
+	}
+}
+
+// Announce registers conn as online under key's friend code, following friends. It
+// returns the code, or "" if the key is too short to be secret.
+func (p *presenceRegistry) Announce(conn *websocket.Conn, key, name string, friends []string) string {
+	if len(key) < minFriendKeyLength {
+		return ""
+	}
+	entry := &presenceEntry{code: friendCode(key), name: name, friends: make(map[string]bool)}
+	for _, friend := range friends {
+		if len(entry.friends) >= maxFriends {
+			break
+		}
+		if friend != "" && friend != entry.code {
+			entry.friends[friend] = true
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.remove(conn)
+	p.byConn[conn] = entry
+	if p.byCode[entry.code] == nil {
+		p.byCode[entry.code] = make(map[*websocket.Conn]bool)
+	}
+	p.byCode[entry.code][conn] = true
+	return entry.code
+}
+
+// Forget takes a closed connection off the registry
+func (p *presenceRegistry) Forget(conn *websocket.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.remove(conn)
+}
+
+// remove takes conn off the registry. Caller must hold p.mu.
+func (p *presenceRegistry) remove(conn *websocket.Conn) {
+	entry, exists := p.byConn[conn]
+	if !exists {
+		return
+	}
+	delete(p.byConn, conn)
+	delete(p.byCode[entry.code], conn)
+	if len(p.byCode[entry.code]) == 0 {
+		delete(p.byCode, entry.code)
+	}
+}
+
+// CodeOf returns the friend code conn announced, or ""
+func (p *presenceRegistry) CodeOf(conn *websocket.Conn) string {
+	if conn == nil {
+		return ""
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if entry, exists := p.byConn[conn]; exists {
+		return entry.code
+	}
+	return ""
+}
+
+// InviteFriends sends an invite to gameID over every open connection of conn's friends
+// who follow them back, returning how many friends were invited
+func (p *presenceRegistry) InviteFriends(conn *websocket.Conn, gameID, inviterID string, now time.Time) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	from, exists := p.byConn[conn]
+	if !exists {
+		return 0
+	}
+	p.prune(now)
+	invited := 0
+	for code := range from.friends {
+		var inv *invite
+		for friendConn := range p.byCode[code] {
+			if !p.byConn[friendConn].friends[from.code] {
+				continue
+			}
+			if inv == nil {
+				inv = &invite{
+					ID:        newUUID(),
+					GameID:    gameID,
+					InviterID: inviterID,
+					FromCode:  from.code,
+					ToCode:    code,
+					ExpiresAt: now.Add(inviteTTL),
+				}
+				p.invites[inv.ID] = inv
+				invited++
+			}
+			writeJSON(friendConn, Message{
+				Type: protocol.MsgInvite,
+				Payload: map[string]interface{}{
+					"inviteID":  inv.ID,
+					"gameID":    gameID,
+					"from":      from.name,
+					"fromCode":  from.code,
+					"expiresAt": inv.ExpiresAt.UTC().Format(time.RFC3339),
+				},
+			})
+		}
+	}
+	return invited
+}
+
+// Answer takes the invite conn is answering off the registry and returns it with the
+// name conn announced, or nil if there's no such invite for conn's player or it has expired
+func (p *presenceRegistry) Answer(conn *websocket.Conn, inviteID string, now time.Time) (*invite, string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prune(now)
+	inv, exists := p.invites[inviteID]
+	entry := p.byConn[conn]
+	if !exists || entry == nil || entry.code != inv.ToCode {
+		return nil, ""
+	}
+	delete(p.invites, inviteID)
+	return inv, entry.name
+}
+
+// prune drops expired invites. Caller must hold p.mu.
+func (p *presenceRegistry) prune(now time.Time) {
+	for id, inv := range p.invites {
+		if !now.Before(inv.ExpiresAt) {
+			delete(p.invites, id)
+		}
+	}
+}
+
+// holdSeat keeps a seat in the lobby free for the player with code until until. Returns
+// false if the lobby has started or has no seat to spare.
+func (g *Game) holdSeat(code string, until time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.Status != protocol.StatusWaiting {
+		return false
+	}
+	if _, held := g.seatHolds[code]; !held && len(g.Players)+g.heldSeats("", time.Now()) >= maxSeats {
+		return false
+	}
+	if g.seatHolds == nil {
+		g.seatHolds = make(map[string]time.Time)
+	}
+	g.seatHolds[code] = until
+	return true
+}
+
+// heldSeats counts the seats held for accepted invites other than code's, dropping holds
+// that have run out. Caller must hold g.mu.
+func (g *Game) heldSeats(code string, now time.Time) int {
+	held := 0
+	for holder, until := range g.seatHolds {
+		if !now.Before(until) {
+			delete(g.seatHolds, holder)
+		} else if holder != code {
+			held++
+		}
+	}
+	return held
+}
+
+// sendInviteAnswer tells the inviter how their friend answered
+func (g *Game) sendInviteAnswer(inviterID, name string, accepted bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.sendToPlayer(inviterID, Message{
+		Type:    protocol.MsgInviteAnswered,
+		Payload: map[string]interface{}{"name": name, "accepted": accepted},
+	})
+}
+
+// answerInvite handles acceptInvite and declineInvite. Accepting holds a seat and tells
+// conn which game to join; either way the inviter hears the answer.
+func answerInvite(conn *websocket.Conn, inviteID string, accepted bool) {
+	now := time.Now()
+	inv, name := presence.Answer(conn, inviteID, now)
+	if inv == nil {
+		sendError(conn, protocol.CodeInviteInvalid)
+		return
+	}
+	game := gameManager.GetGame(context.Background(), inv.GameID)
+	if game == nil {
+		sendError(conn, protocol.CodeGameNotFound)
+		return
+	}
+	if !accepted {
+		game.sendInviteAnswer(inv.InviterID, name, false)
+		return
+	}
+	holdUntil := now.Add(seatHoldTime)
+	if !game.holdSeat(inv.ToCode, holdUntil) {
+		sendError(conn, protocol.CodeGameFull)
+		return
+	}
+	game.sendInviteAnswer(inv.InviterID, name, true)
+	writeJSON(conn, Message{
+		Type: protocol.MsgSeatHeld,
+		Payload: map[string]interface{}{
+			"gameID":    inv.GameID,
+			"holdUntil": holdUntil.UTC().Format(time.RFC3339),
+		},
+	})
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFriendInviteHoldsSeat(t *testing.T) {
+	hostKey, friendKey := "friends-test-host-key", "friends-test-friend-key"
+
+	friend := dialTestServer(t)
+	sendTestMessage(t, friend, "presence", map[string]interface{}{
+		"friendKey": friendKey, "name": "Friend", "friends": []string{friendCode(hostKey)},
+	})
+	if code := readMessageOfType(t, friend, "friendCode")["friendCode"]; code != friendCode(friendKey) {
+		t.Fatalf("Expected the friend's code back, got %v", code)
+	}
+
+	host := dialTestServer(t)
+	sendTestMessage(t, host, "presence", map[string]interface{}{
+		"friendKey": hostKey, "name": "Host", "friends": []string{friendCode(friendKey)},
+	})
+	readMessageOfType(t, host, "friendCode")
+	sendTestMessage(t, host, "createGame", map[string]interface{}{"name": "Host"})
+	gameID := readMessageOfType(t, host, "session")["gameID"].(string)
+
+	invite := readMessageOfType(t, friend, "invite")
+	if invite["gameID"] != gameID || invite["from"] != "Host" {
+		t.Fatalf("Expected an invite to %s from Host, got %v", gameID, invite)
+	}
+	sendTestMessage(t, friend, "acceptInvite", map[string]interface{}{"inviteID": invite["inviteID"]})
+	if held := readMessageOfType(t, friend, "seatHeld"); held["gameID"] != gameID {
+		t.Errorf("Expected a seat held in %s, got %v", gameID, held)
+	}
+	if answer := readMessageOfType(t, host, "inviteAnswered"); answer["name"] != "Friend" || answer["accepted"] != true {
+		t.Errorf("Expected the host told Friend accepted, got %v", answer)
+	}
+
+	// An invite is answered once
+	sendTestMessage(t, friend, "declineInvite", map[string]interface{}{"inviteID": invite["inviteID"]})
+	if code := readMessageOfType(t, friend, "error")["code"]; code != "INVITE_INVALID" {
+		t.Errorf("Expected INVITE_INVALID, got %v", code)
+	}
+}
+
+func TestInvitesNeedBothFriends(t *testing.T) {
+	registry := newPresenceRegistry()
+	host, stranger := newTestConn(t), newTestConn(t)
+	registry.Announce(host, "one-sided-host-key", "Host", []string{friendCode("one-sided-stranger")})
+	registry.Announce(stranger, "one-sided-stranger", "Stranger", nil)
+
+	if invited := registry.InviteFriends(host, "game", "p1", time.Now()); invited != 0 {
+		t.Errorf("Someone who doesn't follow the host back shouldn't be invited, invited %d", invited)
+	}
+}
+
+func TestHeldSeatKeepsTableFromFilling(t *testing.T) {
+	game := createTestGame("test-game")
+	addTestPlayers(game, maxSeats-1)
+	holder := newTestConn(t)
+	code := presence.Announce(holder, "held-seat-holder-key", "Holder", nil)
+	t.Cleanup(func() { presence.Forget(holder) })
+
+	if !game.holdSeat(code, time.Now().Add(time.Minute)) {
+		t.Fatal("Expected the last seat held")
+	}
+	if game.AddPlayer("stranger", "Stranger", nil) {
+		t.Error("A held seat shouldn't go to someone else")
+	}
+	if !game.AddPlayer("holder", "Holder", holder) {
+		t.Error("The friend the seat is held for should get it")
+	}
+	if len(game.seatHolds) != 0 {
+		t.Error("Taking the held seat should release the hold")
+	}
+}
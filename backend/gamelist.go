@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"pablo/protocol"
+)
+
+// maxListedGames caps how many games one listing returns
+const maxListedGames = 100
+
+// GameListing is one game as shown in the list of games to join
+type GameListing struct {
+	GameID     string `json:"gameID"`
+	Status     string `json:"status"`
+	Host       string `json:"host"`
+	Players    int    `json:"players"`
+	MaxPlayers int    `json:"maxPlayers"`
+	Spectators int    `json:"spectators"`
+	Joinable   bool   `json:"joinable"` // A new player would get a seat, dealt in at the next round if one is under way
+}
+
+// listing describes the game for the list of games to join, or reports false for games
+// nobody else can join at all: one-player games, adjourned ones, and ones that are over.
+func (g *Game) listing(now time.Time) (GameListing, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if g.DailyDate != "" || g.Puzzle != nil || g.Tutorial != nil || g.Adjourned || g.GameOver || g.hibernated {
+		return GameListing{}, false
+	}
+	// Seats held for invited friends aren't free; expired holds are let go by the next join
+	held := 0
+	for _, until := range g.seatHolds {
+		if now.Before(until) {
+			held++
+		}
+	}
+	return GameListing{
+		GameID:     g.ID,
+		Status:     g.Status,
+		Host:       g.displayName(g.HostID),
+		Players:    len(g.Players),
+		MaxPlayers: maxSeats,
+		Spectators: len(g.Spectators),
+		Joinable:   !g.Locked && len(g.Players)+held < maxSeats && !leagues.IsLeagueGame(g.ID),
+	}, true
+}
+
+// Listings returns the games on this server that players could look for, joinable ones
+// first, then lobbies before games under way and fuller tables before emptier ones. With
+// joinableOnly set the rest are left out. At most maxListedGames are returned.
+func (gm *GameManager) Listings(joinableOnly bool) []GameListing {
+	gm.mu.RLock()
+	games := make([]*Game, 0, len(gm.games))
+	for _, game := range gm.games {
+		games = append(games, game)
+	}
+	gm.mu.RUnlock()
+
+	now := time.Now()
+	listings := []GameListing{}
+	for _, game := range games {
+		listing, listed := game.listing(now)
+		if listed && (listing.Joinable || !joinableOnly) {
+			listings = append(listings, listing)
+		}
+	}
+	sort.Slice(listings, func(i, j int) bool {
+		a, b := listings[i], listings[j]
+		if a.Joinable != b.Joinable {
+			return a.Joinable
+		}
+		if aWaiting, bWaiting := a.Status == protocol.StatusWaiting, b.Status == protocol.StatusWaiting; aWaiting != bWaiting {
+			return aWaiting
+		}
+		if a.Players != b.Players {
+			return a.Players > b.Players
+		}
+		return a.GameID < b.GameID
+	})
+	if len(listings) > maxListedGames {
+		listings = listings[:maxListedGames]
+	}
+	return listings
+}
+
+// sendGameList answers a listGames message
+func sendGameList(conn *websocket.Conn, joinableOnly bool) {
+	writeJSON(conn, Message{
+		Type:    protocol.MsgGameList,
+		Payload: map[string]interface{}{"games": gameManager.Listings(joinableOnly)},
+	})
+}
+
+// handleListGames serves GET /games with the games on this server, optionally only the
+// joinable ones with ?joinable=true
+func handleListGames(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	joinableOnly := r.URL.Query().Get("joinable") == "true"
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"games": gameManager.Listings(joinableOnly),
+	})
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pablo/protocol"
+)
+
+func TestListingsShowJoinableGamesFirst(t *testing.T) {
+	gm := &GameManager{games: make(map[string]*Game)}
+	add := func(id string, players int) *Game {
+		game := createTestGame(id)
+		addTestPlayers(game, players)
+		gm.addGame(game)
+		return game
+	}
+	add("lobby-small", 1)
+	add("lobby-big", 3)
+	add("full", maxSeats)
+	add("locked", 2).Locked = true
+	add("playing", 2).StartGame()
+	add("over", 2).GameOver = true
+	add("daily", 1).DailyDate = "2026-10-16"
+
+	listings := gm.Listings(false)
+	order := []string{}
+	for _, listing := range listings {
+		order = append(order, listing.GameID)
+	}
+	expected := []string{"lobby-big", "lobby-small", "playing", "full", "locked"}
+	if len(order) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, order)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, order)
+		}
+	}
+	if first := listings[0]; first.Players != 3 || first.MaxPlayers != maxSeats || first.Status != protocol.StatusWaiting || first.Host != "Player 1" {
+		t.Errorf("Unexpected listing %+v", first)
+	}
+	if listings[3].Joinable || listings[4].Joinable {
+		t.Error("Full and locked tables shouldn't be joinable")
+	}
+
+	if joinable := gm.Listings(true); len(joinable) != 3 {
+		t.Errorf("Expected 3 joinable games, got %d", len(joinable))
+	}
+}
+
+func TestHandleListGames(t *testing.T) {
+	game := gameManager.CreateGame(context.Background())
+	game.AddPlayer("host", "Host", nil)
+
+	rec := httptest.NewRecorder()
+	handleListGames(rec, httptest.NewRequest(http.MethodGet, "/games?joinable=true", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	var body struct {
+		Games []GameListing `json:"games"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	for _, listing := range body.Games {
+		if listing.GameID == game.ID {
+			if !listing.Joinable || listing.Players != 1 || listing.Host != "Host" {
+				t.Errorf("Unexpected listing %+v", listing)
+			}
+			return
+		}
+	}
+	t.Error("Expected the new game in the list")
+}
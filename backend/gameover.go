@@ -0,0 +1,159 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// matchStats is what a game keeps across its rounds for the gameOver summary and players'
+// stats. The event log has all of it too, but is capped and can be anonymized away, so it
+// isn't relied on.
+type matchStats struct {
+	StartedAt   time.Time         `json:"startedAt"`             // When the first round was dealt
+	Rounds      []map[string]int  `json:"rounds,omitempty"`      // Each finished round's scores by player ID, in order
+	Stacks      map[string]int    `json:"stacks,omitempty"`      // Successful stacks per player ID
+	RoundStacks map[string]int    `json:"roundStacks,omitempty"` // Successful stacks per player ID this round, for players' stats
+	Identities  map[string]string `json:"identities,omitempty"`  // The friend code each player's stats are kept under
+}
+
+// gameRanking is one seat's place in the final standings
+type gameRanking struct {
+	Rank     int    `json:"rank"` // 1 is the winner; tied totals share a rank
+	PlayerID string `json:"playerID"`
+	Name     string `json:"name"`
+	Total    int    `json:"total"`
+	Bot      bool   `json:"bot,omitempty"`
+}
+
+// roundScores is one row of the final score table
+type roundScores struct {
+	Round  int            `json:"round"` // From 1
+	Scores map[string]int `json:"scores"`
+}
+
+// stackLeader is the player with the most successful stacks
+type stackLeader struct {
+	PlayerID string `json:"playerID"`
+	Name     string `json:"name"`
+	Stacks   int    `json:"stacks"`
+}
+
+// bigRound is the highest score anyone took in a single round
+type bigRound struct {
+	PlayerID string `json:"playerID"`
+	Name     string `json:"name"`
+	Round    int    `json:"round"`
+	Score    int    `json:"score"`
+}
+
+// gameStats are the notable moments of a game; either is left out if nothing qualifies
+type gameStats struct {
+	MostStacks   *stackLeader `json:"mostStacks,omitempty"`
+	BiggestRound *bigRound    `json:"biggestRound,omitempty"`
+}
+
+// gameOverSummary is the gameOver payload: everything a client needs to show how the
+// game finished, without piecing it together from the round summaries
+type gameOverSummary struct {
+	Reason     string         `json:"reason"`
+	Winners    []string       `json:"winners"`
+	Totals     map[string]int `json:"totals"`
+	Rankings   []gameRanking  `json:"rankings"` // Best first
+	Rounds     []roundScores  `json:"rounds"`
+	DurationMs int64          `json:"durationMs,omitempty"` // From the first deal to the end
+	Stats      gameStats      `json:"stats"`
+	Margin     int            `json:"margin,omitempty"`   // For the mercy rule, the margin that was passed
+	Trailing   []string       `json:"trailing,omitempty"` // For the mercy rule, the players more than the margin behind
+}
+
+// recordRoundScores adds the round just scored to the match's score table.
+// Caller must hold g.mu.
+func (g *Game) recordRoundScores() {
+	scores := make(map[string]int, len(g.SeatOrder))
+	for _, id := range g.SeatOrder {
+		scores[id] = g.Players[id].Score
+	}
+	g.match.Rounds = append(g.match.Rounds, scores)
+}
+
+// recordStack counts a successful stack for the match stats. Caller must hold g.mu.
+func (g *Game) recordStack(playerID string) {
+	if g.match.Stacks == nil {
+		g.match.Stacks = make(map[string]int)
+	}
+	g.match.Stacks[playerID]++
+	if g.match.RoundStacks == nil {
+		g.match.RoundStacks = make(map[string]int)
+	}
+	g.match.RoundStacks[playerID]++
+}
+
+// anonymize files a player's scores and stacks under alias, for a player who deleted their
+// data. They stay in the score table since they decided who won.
+func (m *matchStats) anonymize(playerID, alias string) {
+	for _, scores := range m.Rounds {
+		if score, played := scores[playerID]; played {
+			delete(scores, playerID)
+			scores[alias] = score
+		}
+	}
+	if stacks, exists := m.Stacks[playerID]; exists {
+		delete(m.Stacks, playerID)
+		m.Stacks[alias] = stacks
+	}
+	delete(m.RoundStacks, playerID)
+	delete(m.Identities, playerID)
+}
+
+// gameOverSummary builds the gameOver payload for a game ending for reason. The lowest
+// total wins. Caller must hold g.mu.
+func (g *Game) gameOverSummary(reason string) gameOverSummary {
+	summary := gameOverSummary{
+		Reason:  reason,
+		Winners: []string{},
+		Totals:  make(map[string]int),
+		Rounds:  []roundScores{},
+	}
+	for _, id := range g.SeatOrder {
+		_, isBot := g.Bots[id]
+		summary.Totals[id] = g.Players[id].Total
+		summary.Rankings = append(summary.Rankings, gameRanking{
+			PlayerID: id,
+			Name:     g.displayName(id),
+			Total:    g.Players[id].Total,
+			Bot:      isBot,
+		})
+	}
+	sort.SliceStable(summary.Rankings, func(i, j int) bool {
+		return summary.Rankings[i].Total < summary.Rankings[j].Total
+	})
+	for i := range summary.Rankings {
+		summary.Rankings[i].Rank = i + 1
+		if i > 0 && summary.Rankings[i].Total == summary.Rankings[i-1].Total {
+			summary.Rankings[i].Rank = summary.Rankings[i-1].Rank
+		}
+		if summary.Rankings[i].Rank == 1 {
+			summary.Winners = append(summary.Winners, summary.Rankings[i].PlayerID)
+		}
+	}
+
+	for i, scores := range g.match.Rounds {
+		summary.Rounds = append(summary.Rounds, roundScores{Round: i + 1, Scores: scores})
+		for _, id := range g.SeatOrder {
+			score, played := scores[id]
+			if best := summary.Stats.BiggestRound; played && (best == nil || score > best.Score) {
+				summary.Stats.BiggestRound = &bigRound{PlayerID: id, Name: g.displayName(id), Round: i + 1, Score: score}
+			}
+		}
+	}
+	for _, id := range g.SeatOrder {
+		stacks := g.match.Stacks[id]
+		if best := summary.Stats.MostStacks; stacks > 0 && (best == nil || stacks > best.Stacks) {
+			summary.Stats.MostStacks = &stackLeader{PlayerID: id, Name: g.displayName(id), Stacks: stacks}
+		}
+	}
+	if !g.match.StartedAt.IsZero() {
+		summary.DurationMs = time.Since(g.match.StartedAt).Milliseconds()
+	}
+	return summary
+}
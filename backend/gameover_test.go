@@ -0,0 +1,48 @@
+package main
+
+import "testing"
+
+func TestGameOverSummary(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	playerIDs := addTestPlayers(game, 3)
+	first, second, last := playerIDs[0], playerIDs[1], playerIDs[2]
+	game.Config.MercyMargin = 60
+
+	playScoredRound(game, map[string][]string{first: {"A", "A", "A", "A"}, second: {"2", "2", "2", "2"}, last: {"10", "10", "10", "9"}})
+	game.mu.Lock()
+	game.recordStack(second)
+	game.recordStack(second)
+	game.recordStack(first)
+	game.mu.Unlock()
+	playScoredRound(game, map[string][]string{first: {"A", "A", "A", "A"}, second: {"A", "A", "A", "A"}, last: {"10", "10", "10", "10"}})
+	if !game.GameOver {
+		t.Fatal("Expected the mercy rule to end the game")
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	var summary gameOverSummary
+	for _, message := range recorder.players[last] {
+		if message.Type == "gameOver" {
+			summary = message.Payload.(gameOverSummary)
+		}
+	}
+	if len(summary.Rankings) != 3 || summary.Rankings[0].PlayerID != first || summary.Rankings[0].Rank != 1 ||
+		summary.Rankings[1].PlayerID != second || summary.Rankings[2].PlayerID != last || summary.Rankings[2].Rank != 3 {
+		t.Errorf("Expected the lowest total ranked first, got %+v", summary.Rankings)
+	}
+	if len(summary.Rounds) != 2 || summary.Rounds[0].Round != 1 || summary.Rounds[0].Scores[second] != 8 {
+		t.Errorf("Expected both rounds in the score table, got %+v", summary.Rounds)
+	}
+	if best := summary.Stats.BiggestRound; best == nil || best.PlayerID != last || best.Round != 2 || best.Score != 40 {
+		t.Errorf("Expected the 40 in round 2 as the biggest round, got %+v", best)
+	}
+	if most := summary.Stats.MostStacks; most == nil || most.PlayerID != second || most.Stacks != 2 {
+		t.Errorf("Expected two stacks to lead, got %+v", most)
+	}
+	if len(summary.Trailing) != 1 || summary.Trailing[0] != last || summary.Margin != 60 {
+		t.Errorf("Expected the mercy details kept, got %v and %d", summary.Trailing, summary.Margin)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"time"
+
+	"pablo/protocol"
+)
+
+// Nobody can move while a give is pending, so at tables with a turn time limit the player
+// who stacked an opponent's card gets the same limit to pick the card they give back. When
+// it runs out the server gives the first card they hold for them. Tutorials and puzzles
+// leave the give untimed, like their stack windows.
+
+// giveTimer is the clock on one pending give
+type giveTimer struct {
+	give     *PendingGive
+	deadline time.Time
+	checked  time.Time // When the timer last looked at the game
+}
+
+// startGiveTimer puts the clock on the give StackOpponentCard just left pending, if the
+// table plays with a turn time limit. Caller must hold g.mu.
+func (g *Game) startGiveTimer() {
+	g.giveTimer = nil
+	if g.Config.TurnTimeLimitMs <= 0 || g.PendingGive == nil || g.Tutorial != nil || g.Puzzle != nil {
+		return
+	}
+	now := time.Now()
+	timer := &giveTimer{
+		give:     g.PendingGive,
+		deadline: now.Add(time.Duration(g.Config.TurnTimeLimitMs) * time.Millisecond),
+		checked:  now,
+	}
+	g.giveTimer = timer
+	go g.runGiveTimer(timer)
+}
+
+// resumeGiveTimer restarts the clock on a pending give after a pause, with the time that
+// was left when the game paused. A game restored from an adjournment or a checkpoint has no
+// clock running, so its give gets a full limit again. Caller must hold g.mu.
+func (g *Game) resumeGiveTimer() {
+	timer := g.giveTimer
+	if timer == nil || timer.give != g.PendingGive {
+		g.startGiveTimer()
+		return
+	}
+	now := time.Now()
+	timer.deadline = timer.deadline.Add(now.Sub(timer.checked))
+	timer.checked = now
+}
+
+// giveDeadline is when the pending give is made for its player, or zero if there is none
+// or it isn't timed. Caller must hold g.mu.
+func (g *Game) giveDeadline() time.Time {
+	if g.giveTimer == nil || g.giveTimer.give != g.PendingGive || g.Status != protocol.StatusPlaying {
+		return time.Time{}
+	}
+	return g.giveTimer.deadline
+}
+
+// runGiveTimer waits out timer and makes the give if it is still pending. The clock stops
+// while the game is paused.
+func (g *Game) runGiveTimer(timer *giveTimer) {
+	for {
+		g.mu.Lock()
+		if g.giveTimer != timer || g.PendingGive != timer.give || (g.Status != protocol.StatusPlaying && g.Status != protocol.StatusPaused) {
+			g.mu.Unlock()
+			return
+		}
+		now := time.Now()
+		if g.Status == protocol.StatusPaused {
+			timer.deadline = timer.deadline.Add(now.Sub(timer.checked))
+		}
+		timer.checked = now
+		if !now.Before(timer.deadline) {
+			g.giveTimer = nil
+			g.forfeitGive(timer.give)
+			g.mu.Unlock()
+			return
+		}
+		remaining := timer.deadline.Sub(now)
+		if g.Status == protocol.StatusPaused {
+			remaining = turnTimerTick
+		}
+		g.mu.Unlock()
+
+		select {
+		case <-g.ctx.Done():
+			return
+		case <-time.After(remaining):
+		}
+	}
+}
+
+// forfeitGive gives the first card the actor of give holds when their time to pick one
+// runs out. Caller must hold g.mu.
+func (g *Game) forfeitGive(give *PendingGive) {
+	actor, exists := g.Players[give.ActorID]
+	if !exists {
+		return
+	}
+	for i, card := range actor.Cards {
+		if card.Rank != "" {
+			g.giveCard(give.ActorID, i, true)
+			return
+		}
+	}
+}
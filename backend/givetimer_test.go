@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// pendGive leaves actor owing target a card for target's first slot, as a stack on one of
+// target's cards does
+func pendGive(game *Game, actorID, targetID string) {
+	game.mu.Lock()
+	defer game.mu.Unlock()
+	game.Players[targetID].Cards[0] = Card{}
+	game.PendingGive = &PendingGive{ActorID: actorID, TargetPlayerID: targetID, TargetIndex: 0}
+	game.startGiveTimer()
+}
+
+func TestGiveTimerGivesFirstCard(t *testing.T) {
+	game := createTestGame("test-game")
+	game.SetBroadcaster(newRecordingBroadcaster())
+	playerIDs := addTestPlayers(game, 2)
+	game.Config.TurnTimeLimitMs = 50
+	// Stop the turn clock once the test is done, so it doesn't go on playing
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	game.ctx = ctx
+	game.StartGame()
+	setHand(game, playerIDs[0], "", "7", "9", "9")
+
+	pendGive(game, playerIDs[0], playerIDs[1])
+	game.mu.RLock()
+	deadline := game.getGameStateForPlayer(playerIDs[0]).Deadlines.Give
+	game.mu.RUnlock()
+	if deadline.IsZero() {
+		t.Error("Expected the state to carry the give deadline")
+	}
+
+	timeout := time.Now().Add(time.Second)
+	for {
+		game.mu.RLock()
+		pending := game.PendingGive != nil
+		game.mu.RUnlock()
+		if !pending {
+			break
+		}
+		if time.Now().After(timeout) {
+			t.Fatal("Expected the give to be made once its time ran out")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	game.mu.RLock()
+	defer game.mu.RUnlock()
+	if game.Players[playerIDs[1]].Cards[0].Rank != "7" || game.Players[playerIDs[0]].Cards[1].Rank != "" {
+		t.Error("Expected the first card the player held to be given")
+	}
+}
+
+func TestNoGiveTimerByDefault(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+
+	pendGive(game, playerIDs[0], playerIDs[1])
+	if game.giveTimer != nil {
+		t.Error("Expected no clock on the give")
+	}
+	if deadline := game.getGameStateForPlayer(playerIDs[0]).Deadlines.Give; !deadline.IsZero() {
+		t.Errorf("Expected no give deadline, got %v", deadline)
+	}
+}
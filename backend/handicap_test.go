@@ -0,0 +1,64 @@
+package main
+
+import "testing"
+
+func TestHandicapScoring(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	playerIDs := addTestPlayers(game, 2)
+	host, newbie := playerIDs[0], playerIDs[1]
+
+	if !game.SetHandicap(host, newbie, -5) {
+		t.Fatal("Host should be able to set a handicap")
+	}
+	game.StartGame()
+	setHand(game, host, "A", "A", "A", "A")
+	setHand(game, newbie, "2", "2", "2", "2")
+	game.EndRound()
+
+	if score := game.Players[newbie].Score; score != 3 {
+		t.Errorf("Expected 8 - 5 = 3, got %d", score)
+	}
+	if winners := game.roundWinners(); len(winners) != 1 || winners[0] != newbie {
+		t.Errorf("The handicap should decide the round, winners %v", winners)
+	}
+	if seat := game.getGameStateForPlayer(host).Players[newbie]; seat.Handicap != -5 {
+		t.Errorf("Handicap should show on the seat, got %d", seat.Handicap)
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	found := false
+	for _, message := range recorder.players[host] {
+		if message.Type != "roundSummary" {
+			continue
+		}
+		handicaps, _ := message.Payload.(map[string]interface{})["handicaps"].(map[string]int)
+		found = handicaps[newbie] == -5
+	}
+	if !found {
+		t.Error("The round summary should list the handicaps applied")
+	}
+}
+
+func TestSetHandicapRules(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	host, other := playerIDs[0], playerIDs[1]
+
+	if game.SetHandicap(other, host, 5) {
+		t.Error("Only the host should set handicaps")
+	}
+	if game.SetHandicap(host, other, maxHandicap+1) {
+		t.Error("Handicaps beyond the limit should be refused")
+	}
+	if game.SetHandicap(host, "nobody", 5) {
+		t.Error("Handicaps need a seated player")
+	}
+	game.SetHandicap(host, other, 5)
+	game.SetHandicap(host, other, 0)
+	if _, set := game.Handicaps[other]; set {
+		t.Error("A handicap of 0 should remove it")
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// dialTestServer starts the WebSocket handler and connects a client to it
+func dialTestServer(t *testing.T) *websocket.Conn {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(handleWebSocket))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func sendTestMessage(t *testing.T, conn *websocket.Conn, msgType string, payload map[string]interface{}) {
+	t.Helper()
+	if err := conn.WriteJSON(Message{Type: msgType, Payload: payload}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readMessageOfType reads until a message of the given type arrives and returns its payload
+func readMessageOfType(t *testing.T, conn *websocket.Conn, msgType string) map[string]interface{} {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		var msg Message
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("Waiting for %s: %v", msgType, err)
+		}
+		if msg.Type == msgType {
+			payload, _ := msg.Payload.(map[string]interface{})
+			return payload
+		}
+	}
+}
+
+func TestJoinUnknownGameFails(t *testing.T) {
+	conn := dialTestServer(t)
+	sendTestMessage(t, conn, "join", map[string]interface{}{
+		"gameID": "no-such-game", "playerID": "p1", "name": "P1",
+	})
+
+	if code := readMessageOfType(t, conn, "error")["code"]; code != "GAME_NOT_FOUND" {
+		t.Errorf("Expected GAME_NOT_FOUND, got %v", code)
+	}
+	if gameManager.GetGame(context.Background(), "no-such-game") != nil {
+		t.Error("Joining an unknown ID should not create a game")
+	}
+}
+
+func TestActionBeforeJoinFails(t *testing.T) {
+	conn := dialTestServer(t)
+	sendTestMessage(t, conn, "drawCard", nil)
+
+	if code := readMessageOfType(t, conn, "error")["code"]; code != "NOT_IN_GAME" {
+		t.Errorf("Expected NOT_IN_GAME, got %v", code)
+	}
+}
+
+// createTestGameOverWS creates a game from a new connection and returns the connection and its session
+func createTestGameOverWS(t *testing.T) (*websocket.Conn, map[string]interface{}) {
+	t.Helper()
+	conn := dialTestServer(t)
+	sendTestMessage(t, conn, "createGame", map[string]interface{}{"name": "Host"})
+	return conn, readMessageOfType(t, conn, "session")
+}
+
+func TestCreateThenJoinGame(t *testing.T) {
+	host, hostSession := createTestGameOverWS(t)
+	gameID := hostSession["gameID"].(string)
+
+	other := dialTestServer(t)
+	sendTestMessage(t, other, "join", map[string]interface{}{
+		"gameID": gameID, "playerID": hostSession["playerID"], "name": "Guest",
+	})
+	otherSession := readMessageOfType(t, other, "session")
+
+	// Client-supplied IDs are ignored; every seat gets its own server ID
+	if otherSession["playerID"] == hostSession["playerID"] {
+		t.Fatal("Joining player should get a new ID, not the one they asked for")
+	}
+
+	game := gameManager.GetGame(context.Background(), gameID)
+	if len(game.SeatOrder) != 2 || game.HostID != hostSession["playerID"] {
+		t.Errorf("Expected host and guest seated, got %v (host %s)", game.SeatOrder, game.HostID)
+	}
+
+	// Actions go to the connection's game without naming it
+	sendTestMessage(t, host, "startGame", nil)
+	for {
+		if state := readMessageOfType(t, other, "gameState"); state["status"] == "playing" {
+			break
+		}
+	}
+}
+
+func TestSpectatorCannotAct(t *testing.T) {
+	_, session := createTestGameOverWS(t)
+
+	watcher := dialTestServer(t)
+	sendTestMessage(t, watcher, "spectate", map[string]interface{}{"gameID": session["gameID"], "name": "Watcher"})
+	readMessageOfType(t, watcher, "spectating")
+
+	sendTestMessage(t, watcher, "startGame", nil)
+	if code := readMessageOfType(t, watcher, "error")["code"]; code != "NOT_IN_GAME" {
+		t.Errorf("Expected NOT_IN_GAME for a spectator, got %v", code)
+	}
+}
+
+func TestReplacedConnectionIsClosed(t *testing.T) {
+	first, session := createTestGameOverWS(t)
+
+	// A second tab presenting the session token takes over the seat
+	second := dialTestServer(t)
+	sendTestMessage(t, second, "join", map[string]interface{}{
+		"gameID": session["gameID"], "name": "Host", "sessionToken": session["sessionToken"],
+	})
+	if restored := readMessageOfType(t, second, "session"); restored["playerID"] != session["playerID"] {
+		t.Fatalf("Session token should restore seat %v, got %v", session["playerID"], restored["playerID"])
+	}
+
+	readMessageOfType(t, first, "sessionReplaced")
+	first.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		if _, _, err := first.ReadMessage(); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				t.Errorf("Expected the replaced connection to be closed, got %v", err)
+			}
+			break
+		}
+	}
+
+	sendTestMessage(t, second, "getState", nil)
+	if state := readMessageOfType(t, second, "gameState"); state["gameID"] != session["gameID"] {
+		t.Errorf("The new connection should hold the seat, got %v", state)
+	}
+}
+
+func TestGetState(t *testing.T) {
+	conn := dialTestServer(t)
+	sendTestMessage(t, conn, "getState", nil)
+	if code := readMessageOfType(t, conn, "error")["code"]; code != "NOT_IN_GAME" {
+		t.Errorf("Expected NOT_IN_GAME before joining, got %v", code)
+	}
+
+	host, session := createTestGameOverWS(t)
+	readMessageOfType(t, host, "gameState")
+	sendTestMessage(t, host, "getState", nil)
+	state := readMessageOfType(t, host, "gameState")
+	if state["gameID"] != session["gameID"] || state["spectator"] != nil {
+		t.Errorf("Expected the host's own view of the game, got %v", state)
+	}
+
+	sendTestMessage(t, conn, "spectate", map[string]interface{}{"gameID": session["gameID"], "name": "Watcher"})
+	readMessageOfType(t, conn, "gameState")
+	sendTestMessage(t, conn, "getState", nil)
+	if state := readMessageOfType(t, conn, "gameState"); state["spectator"] != true {
+		t.Errorf("Expected the spectator view, got %v", state)
+	}
+}
+
+// newTestConn returns the server side of a live WebSocket connection whose client
+// discards everything it receives, for engine tests that need a connected seat
+func newTestConn(t *testing.T) *websocket.Conn {
+	t.Helper()
+	serverConn := make(chan *websocket.Conn, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		serverConn <- conn
+	}))
+	t.Cleanup(server.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			if _, _, err := client.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	conn := <-serverConn
+	t.Cleanup(func() {
+		client.Close()
+		conn.Close()
+	})
+	return conn
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"pablo/protocol"
+)
+
+// A lobby nobody is sitting in, or a paused game everyone has left, can go untouched for
+// hours. Once such a game has been idle for hibernateAfter it is saved to the hibernation
+// store and dropped from memory, so memory grows with the tables in use rather than every
+// table ever opened. Looking the game up again, as joining or reconnecting does, loads it
+// back. Adjourned games already have a saved copy; it is brought up to date instead.
+
+// defaultHibernateAfter is how long a game sits idle before it is moved to disk
+const defaultHibernateAfter = 10 * time.Minute
+
+// hibernatable reports whether nothing is holding the game in memory: it is waiting or
+// paused, nobody is connected to it, and it has nothing running that a snapshot would
+// lose. Caller must hold g.mu.
+func (g *Game) hibernatable() bool {
+	if g.Status != protocol.StatusWaiting && g.Status != protocol.StatusPaused {
+		return false
+	}
+	for _, player := range g.Players {
+		if player.Conn != nil {
+			return false
+		}
+	}
+	if len(g.Observers) > 0 || len(g.Spectators) > 0 || len(g.Bots) > 0 {
+		return false
+	}
+	return g.DailyDate == "" && g.Puzzle == nil && g.revealed == nil && !g.streams.active()
+}
+
+// touch notes that a game was just used, putting off its hibernation. Caller must hold gm.mu.
+func (gm *GameManager) touch(gameID string, now time.Time) {
+	if gm.lastUsed == nil {
+		gm.lastUsed = make(map[string]time.Time)
+	}
+	gm.lastUsed[gameID] = now
+}
+
+// hibernateIdle moves every game that has been hibernatable for at least after to disk,
+// returning how many it moved. A game counts as used whenever it's looked up, so one that
+// someone is about to join stays put.
+func (gm *GameManager) hibernateIdle(ctx context.Context, now time.Time, after time.Duration) int {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	if gm.hibernated == nil {
+		return 0
+	}
+	moved := 0
+	for id, game := range gm.games {
+		game.mu.Lock()
+		idle := game.hibernatable()
+		if !idle {
+			gm.touch(id, now)
+		} else if used, seen := gm.lastUsed[id]; !seen {
+			gm.touch(id, now)
+		} else if now.Sub(used) >= after {
+			if err := gm.hibernate(ctx, game, now); err != nil {
+				log.Printf("Hibernating game %s: %v", id, err)
+			} else {
+				game.hibernated = true
+				if game.checkpoints != nil {
+					// Its hibernated copy is the one to load now
+					game.checkpoints.mark(game)
+				}
+				delete(gm.games, id)
+				delete(gm.lastUsed, id)
+				moved++
+			}
+		}
+		game.mu.Unlock()
+	}
+	return moved
+}
+
+// hibernate saves the game so it can be dropped from memory. Caller must hold gm.mu and game.mu.
+func (gm *GameManager) hibernate(ctx context.Context, game *Game, now time.Time) error {
+	snap := game.snapshot()
+	if game.Adjourned && game.adjournStore != nil {
+		saved, err := game.adjournStore.Load(ctx, game.ID)
+		if err != nil {
+			return err
+		}
+		snap.AdjournedAt = saved.AdjournedAt
+		snap.ExpiresAt = saved.ExpiresAt
+		return game.adjournStore.Save(ctx, snap)
+	}
+	snap.ExpiresAt = now.Add(gm.hibernated.ttl)
+	return gm.hibernated.Save(ctx, snap)
+}
+
+// wake loads a hibernated game back into memory, or returns nil if there isn't one.
+// Caller must not hold gm.mu.
+func (gm *GameManager) wake(ctx context.Context, gameID string) *Game {
+	if gm.hibernated == nil {
+		return nil
+	}
+	snap, err := gm.hibernated.Load(ctx, gameID)
+	if err != nil {
+		return nil
+	}
+	gm.hibernated.Delete(gameID)
+
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+	if game, exists := gm.games[gameID]; exists {
+		return game
+	}
+	game := gameFromSnapshot(snap)
+	gm.addGame(game)
+	return game
+}
+
+// runHibernation checks for idle games every so often until ctx is done
+func (gm *GameManager) runHibernation(ctx context.Context, after time.Duration) {
+	ticker := time.NewTicker(after / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if moved := gm.hibernateIdle(ctx, now, after); moved > 0 {
+				log.Printf("Hibernated %d idle games", moved)
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestIdleGameHibernates(t *testing.T) {
+	gm := &GameManager{games: make(map[string]*Game), hibernated: newAdjournStore(t.TempDir(), 24*time.Hour)}
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.SetReady(playerIDs[0], true)
+	gm.mu.Lock()
+	gm.addGame(game)
+	gm.mu.Unlock()
+
+	ctx := context.Background()
+	now := time.Now()
+	if moved := gm.hibernateIdle(ctx, now.Add(time.Minute), 10*time.Minute); moved != 0 {
+		t.Fatal("A game shouldn't hibernate before it has been idle long enough")
+	}
+	if moved := gm.hibernateIdle(ctx, now.Add(time.Hour), 10*time.Minute); moved != 1 {
+		t.Fatalf("Expected the idle lobby to hibernate, moved %d", moved)
+	}
+	if len(gm.games) != 0 {
+		t.Fatal("A hibernated game should be dropped from memory")
+	}
+	if game.holdsOpenSeat(playerIDs[0]) {
+		t.Error("A hibernated lobby shouldn't count toward the open game cap")
+	}
+
+	woken := gm.GetGame(ctx, "test-game")
+	if woken == nil {
+		t.Fatal("Looking a hibernated game up should load it back")
+	}
+	if woken == game || woken.hibernated {
+		t.Error("A woken game should be a fresh copy")
+	}
+	if len(woken.SeatOrder) != 2 || !woken.Players[playerIDs[0]].Ready || woken.Players[playerIDs[1]].Ready {
+		t.Errorf("Seats should survive hibernation, got %v", woken.LobbyPlayers())
+	}
+	if !woken.AddPlayer(playerIDs[1], "Player 2", nil) {
+		t.Error("A player should be able to come back to a woken game")
+	}
+	if _, err := gm.hibernated.Load(ctx, "test-game"); err == nil {
+		t.Error("The saved copy should be removed once the game wakes")
+	}
+}
+
+func TestBusyGamesStayInMemory(t *testing.T) {
+	gm := &GameManager{games: make(map[string]*Game), hibernated: newAdjournStore(t.TempDir(), 24*time.Hour)}
+	playing := createTestGame("playing")
+	addTestPlayers(playing, 2)
+	playing.StartGame()
+	watched := createTestGame("watched")
+	addTestPlayers(watched, 2)
+	watched.AddSpectator("spec", "Spec", nil)
+	gm.mu.Lock()
+	gm.addGame(playing)
+	gm.addGame(watched)
+	gm.mu.Unlock()
+
+	if moved := gm.hibernateIdle(context.Background(), time.Now().Add(time.Hour), 10*time.Minute); moved != 0 {
+		t.Errorf("Games in play or being watched shouldn't hibernate, moved %d", moved)
+	}
+}
+
+func TestLookupPutsOffHibernation(t *testing.T) {
+	gm := &GameManager{games: make(map[string]*Game), hibernated: newAdjournStore(t.TempDir(), 24*time.Hour)}
+	game := createTestGame("test-game")
+	addTestPlayers(game, 2)
+	gm.mu.Lock()
+	gm.addGame(game)
+	gm.mu.Unlock()
+
+	gm.mu.Lock()
+	gm.touch("test-game", time.Now().Add(-time.Hour))
+	gm.mu.Unlock()
+
+	gm.GetGame(context.Background(), "test-game")
+	if moved := gm.hibernateIdle(context.Background(), time.Now(), time.Minute); moved != 0 {
+		t.Error("A game that was just looked up shouldn't hibernate")
+	}
+}
+
+func TestAdjournedGameHibernates(t *testing.T) {
+	adjourned := newAdjournStore(t.TempDir(), 24*time.Hour)
+	gm := &GameManager{games: make(map[string]*Game), adjourned: adjourned, hibernated: newAdjournStore(t.TempDir(), 24*time.Hour)}
+	game := createTestGame("test-game")
+	addTestPlayers(game, 2)
+	game.StartGame()
+	if !game.Adjourn(context.Background(), game.HostID, adjourned) {
+		t.Fatal("Host should be able to adjourn")
+	}
+	saved, _ := adjourned.Load(context.Background(), "test-game")
+	gm.mu.Lock()
+	gm.addGame(game)
+	gm.mu.Unlock()
+
+	if moved := gm.hibernateIdle(context.Background(), time.Now().Add(time.Hour), 10*time.Minute); moved != 1 {
+		t.Fatal("An adjourned game nobody is at should hibernate")
+	}
+	resaved, err := adjourned.Load(context.Background(), "test-game")
+	if err != nil || !resaved.ExpiresAt.Equal(saved.ExpiresAt) {
+		t.Errorf("The adjourned copy should be kept with its expiry, got %v", err)
+	}
+	if woken := gm.GetGame(context.Background(), "test-game"); woken == nil || !woken.Adjourned {
+		t.Error("A hibernated adjourned game should come back adjourned")
+	}
+}
+
+// slowGameStore holds every load until release is closed
+type slowGameStore struct {
+	GameStore
+	loading chan struct{} // Gets a value as each load starts
+	release chan struct{}
+}
+
+func (s *slowGameStore) LoadGame(ctx context.Context, gameID string) (*gameSnapshot, error) {
+	s.loading <- struct{}{}
+	<-s.release
+	return s.GameStore.LoadGame(ctx, gameID)
+}
+
+func TestSlowStoreDoesNotBlockOtherLookups(t *testing.T) {
+	store := &slowGameStore{GameStore: newMemoryGameStore(), loading: make(chan struct{}, 2), release: make(chan struct{})}
+	gm := &GameManager{games: make(map[string]*Game), hibernated: &adjournStore{games: store, ttl: time.Hour}}
+	game := createTestGame("in-memory")
+	gm.mu.Lock()
+	gm.addGame(game)
+	gm.mu.Unlock()
+
+	ctx := context.Background()
+	missing := make(chan *Game, 2)
+	for i := 0; i < 2; i++ {
+		go func() { missing <- gm.GetGame(ctx, "elsewhere") }()
+	}
+	<-store.loading
+
+	found := make(chan *Game, 1)
+	go func() { found <- gm.GetGame(ctx, "in-memory") }()
+	select {
+	case got := <-found:
+		if got != game {
+			t.Error("Expected the game in memory")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("A lookup of a game in memory waited on another game's store")
+	}
+	if created := gm.CreateGame(ctx); created == nil {
+		t.Error("Expected a game to be created while another loads")
+	}
+
+	close(store.release)
+	for i := 0; i < 2; i++ {
+		if <-missing != nil {
+			t.Error("Expected no game for an ID no store has")
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import "pablo/protocol"
+
+// IsHost reports whether playerID controls the table
+func (g *Game) IsHost(playerID string) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return playerID != "" && playerID == g.HostID
+}
+
+// MutePlayer lets the host silence (or unmute) another player's chat. Lines from a
+// muted player are dropped by the server rather than hidden by clients.
+func (g *Game) MutePlayer(hostID, targetID string, muted bool) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if hostID != g.HostID || hostID == targetID {
+		return false
+	}
+	if _, exists := g.Players[targetID]; !exists {
+		return false
+	}
+
+	if muted {
+		g.Muted[targetID] = true
+	} else {
+		delete(g.Muted, targetID)
+	}
+	g.broadcast(Message{
+		Type: protocol.MsgPlayerMuted,
+		Payload: map[string]interface{}{
+			"playerID": targetID,
+			"muted":    muted,
+		},
+	})
+	g.broadcastLobby()
+	return true
+}
+
+// TransferHost hands control of the table to another seated player. Bots can't host.
+func (g *Game) TransferHost(hostID, targetID string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if hostID != g.HostID || hostID == targetID {
+		return false
+	}
+	if _, exists := g.Players[targetID]; !exists {
+		return false
+	}
+	if _, isBot := g.Bots[targetID]; isBot {
+		return false
+	}
+
+	g.HostID = targetID
+	g.broadcast(Message{
+		Type: protocol.MsgHostChanged,
+		Payload: map[string]interface{}{
+			"hostID":         targetID,
+			"previousHostID": hostID,
+		},
+	})
+	g.broadcastLobby()
+	g.broadcastGameState()
+	return true
+}
+
+// SetLocked lets the host close the table to new players, or open it again.
+// Seated players can still reconnect to a locked table.
+func (g *Game) SetLocked(hostID string, locked bool) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if hostID != g.HostID {
+		return false
+	}
+
+	g.Locked = locked
+	g.broadcast(Message{
+		Type:    protocol.MsgTableLocked,
+		Payload: map[string]interface{}{"locked": locked},
+	})
+	g.broadcastGameState()
+	return true
+}
+
+// IsLocked reports whether the host has closed the table to new players
+func (g *Game) IsLocked() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.Locked
+}
+
+// maxHandicap bounds a handicap either way, so it evens a table out rather than decides it
+const maxHandicap = 20
+
+// SetHandicap lets the host give a player points added to their score every round, e.g. -5
+// for someone new to the game. 0 removes it. It counts from the next round scored and is
+// shown on the player's seat and in round summaries.
+func (g *Game) SetHandicap(hostID, targetID string, points int) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if hostID != g.HostID || points < -maxHandicap || points > maxHandicap {
+		return false
+	}
+	if _, exists := g.Players[targetID]; !exists {
+		return false
+	}
+
+	if points == 0 {
+		delete(g.Handicaps, targetID)
+	} else {
+		g.Handicaps[targetID] = points
+	}
+	g.broadcast(Message{
+		Type: protocol.MsgHandicapChanged,
+		Payload: map[string]interface{}{
+			"playerID": targetID,
+			"points":   points,
+		},
+	})
+	g.broadcastGameState()
+	return true
+}
+
+// roundHandicaps returns the handicaps of the players dealt into the round. Caller must
+// hold g.mu.
+func (g *Game) roundHandicaps() map[string]int {
+	handicaps := make(map[string]int)
+	for _, id := range g.SeatOrder {
+		if points := g.Handicaps[id]; points != 0 {
+			handicaps[id] = points
+		}
+	}
+	return handicaps
+}
+
+// applyHandicaps adds each player's handicap to their score for the round. Caller must
+// hold g.mu.
+func (g *Game) applyHandicaps() {
+	for id, points := range g.roundHandicaps() {
+		g.Players[id].Score += points
+	}
+}
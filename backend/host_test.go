@@ -0,0 +1,114 @@
+package main
+
+import "testing"
+
+func TestMutePlayer(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	playerIDs := addTestPlayers(game, 3)
+	host, target := playerIDs[0], playerIDs[1]
+
+	if game.MutePlayer(target, playerIDs[2], true) {
+		t.Error("Only the host can mute players")
+	}
+	if game.MutePlayer(host, host, true) {
+		t.Error("The host can't mute themselves")
+	}
+	if !game.MutePlayer(host, target, true) {
+		t.Fatal("Host should be able to mute a player")
+	}
+	if game.Chat(target, "hello?") {
+		t.Error("Chat from a muted player should be dropped")
+	}
+	for _, player := range game.LobbyPlayers() {
+		if player.ID == target && !player.Muted {
+			t.Error("Lobby should show the player as muted")
+		}
+	}
+
+	recorder.mu.Lock()
+	if countOfType(recorder.players[playerIDs[2]], "playerMuted") != 1 {
+		t.Error("The table should hear about the mute")
+	}
+	if countOfType(recorder.players[host], "chat") != 0 {
+		t.Error("Muted chat should not reach anyone")
+	}
+	recorder.mu.Unlock()
+
+	game.MutePlayer(host, target, false)
+	if !game.Chat(target, "thanks") {
+		t.Error("Unmuted player should be able to chat again")
+	}
+}
+
+func TestTransferHost(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.AddBot("bot", "Bot")
+
+	if game.TransferHost(playerIDs[1], playerIDs[1]) || game.TransferHost(playerIDs[1], playerIDs[0]) {
+		t.Error("Only the host can hand over the table")
+	}
+	if game.TransferHost(playerIDs[0], "bot") {
+		t.Error("Bots can't host")
+	}
+	if !game.TransferHost(playerIDs[0], playerIDs[1]) {
+		t.Fatal("Host should be able to hand over the table")
+	}
+	if !game.IsHost(playerIDs[1]) || game.IsHost(playerIDs[0]) {
+		t.Error("Expected the second player to be host")
+	}
+	if game.MutePlayer(playerIDs[0], playerIDs[1], true) {
+		t.Error("The previous host should have lost host powers")
+	}
+}
+
+func TestLockTable(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+
+	if game.SetLocked(playerIDs[1], true) {
+		t.Error("Only the host can lock the table")
+	}
+	if !game.SetLocked(playerIDs[0], true) {
+		t.Fatal("Host should be able to lock the table")
+	}
+	if game.AddPlayer("late", "Late", nil) {
+		t.Error("New players can't join a locked table")
+	}
+	if !game.AddPlayer(playerIDs[1], "Player 2", nil) {
+		t.Error("Seated players should still be able to reconnect")
+	}
+	game.SetLocked(playerIDs[0], false)
+	if !game.AddPlayer("late", "Late", nil) {
+		t.Error("Unlocked table should take new players")
+	}
+}
+
+func TestHostControlsOverWS(t *testing.T) {
+	host, session := createTestGameOverWS(t)
+	guest := dialTestServer(t)
+	sendTestMessage(t, guest, "join", map[string]interface{}{"gameID": session["gameID"], "name": "Guest"})
+	guestSession := readMessageOfType(t, guest, "session")
+
+	sendTestMessage(t, guest, "lockTable", map[string]interface{}{"locked": true})
+	if code := readMessageOfType(t, guest, "error")["code"]; code != "NOT_AUTHORIZED" {
+		t.Errorf("Expected NOT_AUTHORIZED, got %v", code)
+	}
+
+	sendTestMessage(t, host, "lockTable", map[string]interface{}{"locked": true})
+	if locked := readMessageOfType(t, guest, "tableLocked")["locked"]; locked != true {
+		t.Errorf("Expected the table to be locked, got %v", locked)
+	}
+	late := dialTestServer(t)
+	sendTestMessage(t, late, "join", map[string]interface{}{"gameID": session["gameID"], "name": "Late"})
+	if code := readMessageOfType(t, late, "error")["code"]; code != "TABLE_LOCKED" {
+		t.Errorf("Expected TABLE_LOCKED, got %v", code)
+	}
+
+	sendTestMessage(t, host, "transferHost", map[string]interface{}{"targetID": guestSession["playerID"]})
+	if hostID := readMessageOfType(t, host, "hostChanged")["hostID"]; hostID != guestSession["playerID"] {
+		t.Errorf("Expected the guest to become host, got %v", hostID)
+	}
+}
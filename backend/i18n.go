@@ -0,0 +1,397 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// Everything the server tells players in words comes from catalogs, one per language,
+// keyed by the error code or message type it goes with. Codes that cover more than one
+// situation add a variant after a dot, like "NOT_AUTHORIZED.mute"; the client still only
+// sees the code. Each connection picks its language with the locale field of the message
+// it joins with, and gets English until it does or if its language has no catalog.
+
+// defaultLocale is the language every key has a message in
+const defaultLocale = "en"
+
+// catalogs holds the messages of each language. Keys missing from a translation fall
+// back to English.
+var catalogs = map[string]map[string]string{
+	"en": {
+		"NOT_IN_GAME":                      "Join a game first",
+		"NOT_IN_GAME.spectate":             "Spectate a game first",
+		"GAME_PAUSED":                      "The game is paused",
+		"GAME_NOT_FOUND":                   "Game not found",
+		"GAME_FULL":                        "Game is full",
+		"TABLE_LOCKED":                     "The host has locked this table",
+		"BANNED":                           "You are banned from this server",
+		"BANNED.reason":                    "You are banned from this server: %s",
+		"BANNED.until":                     "You are banned from this server (until %s)",
+		"BANNED.reasonUntil":               "You are banned from this server: %s (until %s)",
+		"CAPTCHA_REQUIRED":                 "Complete the CAPTCHA to start a game",
+		"RATE_LIMITED":                     "Too many games joined from your address; try again in a minute",
+		"TOO_MANY_GAMES":                   "Your address already has %d games open; leave one before starting or joining another",
+		"INVALID_SCHEDULE.format":          "scheduledAt must be an RFC 3339 time",
+		"INVALID_SCHEDULE.past":            "scheduledAt must be in the future",
+		"INVALID_SCHEDULE.tooFar":          "games can't be scheduled that far ahead",
+		"NOT_AUTHORIZED.observe":           "Not authorized to observe",
+		"NOT_AUTHORIZED.mute":              "Only the host can mute players",
+		"NOT_AUTHORIZED.transfer":          "Only the host can hand over the table",
+		"NOT_AUTHORIZED.lock":              "Only the host can lock the table",
+		"NOT_AUTHORIZED.handicap":          "Only the host can set handicaps",
+		"PREDICTION_CLOSED":                "Predictions can't be submitted right now",
+		"PUZZLE_NOT_FOUND":                 "Puzzle not found",
+		"INVALID_CONFIG":                   "Invalid config",
+		"ADJOURN_FAILED":                   "The game could not be adjourned",
+		"REPORT_INVALID":                   "Reports need another player at the table and a reason",
+		"REPORT_INVALID.duplicate":         "You have already reported this player",
+		"REPORT_INVALID.unsaved":           "The report could not be saved",
+		"CHAT_REJECTED":                    "Your message was not sent",
+		"CHAT_REJECTED.length":             "Chat messages can be at most %d characters",
+		"INVALID_MOVE.rename":              "Names can only be changed in the lobby, and can't be blank",
+		"INVALID_MOVE.handicap":            "Handicaps are whole points from -20 to 20 for a seated player",
+		"INVALID_PAYLOAD":                  "The %s message couldn't be read",
+		"INVALID_PAYLOAD.field":            "The %s message has a missing or invalid %s",
+		"PRESET_NOT_FOUND":                 "You don't have a preset with that ID",
+		"NO_NOTES_KEY.preset":              "Send a notes key to keep house rule presets",
+		"INVALID_CONFIG.preset":            "Give the preset a name of up to 40 characters; you can keep up to 20",
+		"INVALID_MOVE.block":               "You can block another person at the table, but not a bot or while names are hidden",
+		"INVALID_MOVE.presence":            "A friend key must be at least %d characters",
+		"INVITE_INVALID":                   "That invite has expired or isn't yours",
+		"INVALID_MOVE.note":                "Notes are about another player at the table, up to %d characters, and can't be kept while names are hidden",
+		"NO_NOTES_KEY":                     "Send a notes key when joining to keep notes on other players",
+		"kicked":                           "You were removed from the game by vote.",
+		"kicked.privacy":                   "Your data was deleted and you have left the game.",
+		"sessionReplaced":                  "Your seat was opened on another connection.",
+		"upgradeRequired":                  "This version of Pablo is out of date. Reload the page to get the latest one.",
+		"upgradeRequired.readOnly":         "This version of Pablo is out of date, so you're watching this game. Reload the page to play.",
+		"NOT_PLAYING":                      "The game is not in play",
+		"NOT_YOUR_TURN":                    "It's not your turn",
+		"ALREADY_DRAWN":                    "You have already drawn this turn",
+		"NO_DRAWN_CARD":                    "You have no drawn card",
+		"UNPLAYED_CARD":                    "Your drawn card must be discarded or swapped first",
+		"MUST_SWAP":                        "A card taken from the discard pile must be swapped in",
+		"PENDING_POWER":                    "A special card power must be used or skipped first",
+		"NO_PENDING_POWER":                 "There is no special card power to use",
+		"PENDING_GIVE":                     "A card must be given first",
+		"NO_PENDING_GIVE":                  "There is no card to give",
+		"EMPTY_DECK":                       "The deck is empty",
+		"EMPTY_DISCARD":                    "The discard pile is empty",
+		"NOT_STACKABLE":                    "The top card can't be stacked on",
+		"CARD_MISMATCH":                    "Card rank does not match; penalty card added",
+		"RULE_DISABLED":                    "That move is not enabled at this table",
+		"PABLO_CALLED":                     "Pablo has already been called",
+		"STACK_COOLDOWN":                   "Too many failed stacks; stacking is on cooldown",
+		"STACK_SIT_OUT":                    "After a failed stack you sit out stacking on this card and the next",
+		"OUT_OF_PHASE":                     "That move isn't allowed at this point in the turn",
+		"NOT_PEEKING":                      "The table isn't peeking at its cards",
+		"PEEK_LIMIT":                       "You've already looked at two of your cards",
+		"INVALID_CARD_INDEX":               "There's no card in that slot",
+		"TARGET_NOT_FOUND":                 "That player isn't dealt in at this table",
+		"narrate.playerJoined":             "%s joined the table",
+		"narrate.playerLeft":               "%s left the table",
+		"narrate.gameStarted":              "The cards are dealt. A new round begins",
+		"narrate.turnStarted":              "It's %s's turn",
+		"narrate.turnStarted.self":         "It's your turn",
+		"narrate.roundEnded":               "The round is over",
+		"narrate.roundEnded.winners":       "The round is over. Won by %s",
+		"narrate.gameOver":                 "The game is over",
+		"narrate.lobbyOpened":              "The lobby is open",
+		"narrate.drawCard":                 "%s drew a card from the deck",
+		"narrate.drawFromDiscard":          "%s took the %s from the discard pile",
+		"narrate.discardDrawnCard":         "%s discarded the %s",
+		"narrate.discardDrawnCard.power":   "%s discarded the %s and may now %s",
+		"narrate.swapCard":                 "%s swapped their %s card for the card they drew, discarding the %s",
+		"narrate.swapCard.power":           "%s swapped their %s card for the card they drew, discarding the %s, and may now %s",
+		"narrate.swapMultipleCards":        "%s swapped %d matching cards for the card they drew",
+		"narrate.swapMultipleCards.failed": "%s's cards didn't match, so they took a penalty card",
+		"narrate.power.peekOwn":            "look at one of their own cards",
+		"narrate.power.peekOther":          "look at someone else's card",
+		"narrate.power.swap":               "swap any two cards",
+		"narrate.power.peekAndSwap":        "look at an opponent's card and swap it for one of their own",
+		"narrate.peekOwn":                  "%s looked at their %s card",
+		"narrate.peekOther":                "%s looked at %s's %s card",
+		"narrate.swapAny":                  "%s swapped %s's %s card with %s's %s card",
+		"narrate.kingPeek":                 "%s looked at %s's %s card and is deciding whether to swap it",
+		"narrate.skipSpecialCard":          "%s didn't use the power",
+		"narrate.confirmKingSwap":          "%s swapped their %s card with %s's %s card",
+		"narrate.declineKingSwap":          "%s kept their cards",
+		"narrate.callPablo":                "%s called Pablo! Everyone else gets one more turn",
+		"narrate.callPablo.blind":          "%s called Pablo without drawing! Everyone else gets one more turn",
+		"narrate.endTurn":                  "%s ended their turn",
+		"narrate.endTurn.absent":           "%s is away, so their turn was skipped",
+		"narrate.stackCard":                "%s stacked their %s card, the %s",
+		"narrate.stackCard.failed":         "%s tried to stack their %s card, the %s, but it didn't match and they took a penalty card",
+		"narrate.stackOpponentCard":        "%s stacked %s's %s card, the %s, and must give them a card",
+		"narrate.stackOpponentCard.failed": "%s tried to stack %s's %s card, the %s, but it didn't match and they took it as a penalty",
+		"narrate.giveCardToPlayer":         "%s gave their %s card to %s",
+		"narrate.revealClaim":              "%s revealed their hand for %d points and won the round",
+		"narrate.revealClaim.failed":       "%s revealed their hand for %d points, too many, and takes a penalty",
+		"TUTORIAL_STEP":                    "Follow the tutorial's instruction first",
+		"tutorial.draw":                    "Welcome to Pablo! The lowest score wins. Start your turn by drawing the top card of the deck.",
+		"tutorial.swapAce":                 "You drew an ace, worth 1 point. Your first card is a king of spades, worth 10: swap the ace into that slot.",
+		"tutorial.endTurn":                 "Nice. End your turn.",
+		"tutorial.watch":                   "Now the coach plays. Watch the discard pile; you'll be back in a moment.",
+		"tutorial.drawAgain":               "Your turn again. Draw from the deck.",
+		"tutorial.discardSeven":            "A 7 has a power. Discard it to peek at one of your own cards.",
+		"tutorial.peek":                    "Pick one of your cards to look at. Remembering what you hold is the whole game.",
+		"tutorial.callPablo":               "Your hand is low now. Call Pablo: everyone else gets one more turn, then the hands are scored.",
+		"tutorial.drawLast":                "You still play this turn. Draw a card.",
+		"tutorial.swapTwo":                 "A 2! Swap it for the 6, your third card.",
+		"tutorial.lastLap":                 "The coach takes a last turn, then every hand is turned over.",
+		"tutorial.done":                    "That's a round of Pablo. Keep your hand low, remember your cards, and call Pablo at the right moment. You're ready for a real game!",
+	},
+	"es": {
+		"NOT_IN_GAME":              "Primero únete a una partida",
+		"NOT_IN_GAME.spectate":     "Primero mira una partida",
+		"GAME_PAUSED":              "La partida está en pausa",
+		"GAME_NOT_FOUND":           "No se encontró la partida",
+		"GAME_FULL":                "La partida está llena",
+		"TABLE_LOCKED":             "El anfitrión ha cerrado esta mesa",
+		"BANNED":                   "Tienes prohibido el acceso a este servidor",
+		"BANNED.reason":            "Tienes prohibido el acceso a este servidor: %s",
+		"BANNED.until":             "Tienes prohibido el acceso a este servidor (hasta %s)",
+		"BANNED.reasonUntil":       "Tienes prohibido el acceso a este servidor: %s (hasta %s)",
+		"CAPTCHA_REQUIRED":         "Completa el CAPTCHA para empezar una partida",
+		"RATE_LIMITED":             "Demasiadas partidas desde tu dirección; inténtalo de nuevo en un minuto",
+		"TOO_MANY_GAMES":           "Tu dirección ya tiene %d partidas abiertas; sal de una antes de empezar o unirte a otra",
+		"INVALID_SCHEDULE.format":  "scheduledAt debe ser una hora RFC 3339",
+		"INVALID_SCHEDULE.past":    "scheduledAt debe estar en el futuro",
+		"INVALID_SCHEDULE.tooFar":  "No se pueden programar partidas con tanta antelación",
+		"NOT_AUTHORIZED.observe":   "No tienes permiso para observar",
+		"NOT_AUTHORIZED.mute":      "Solo el anfitrión puede silenciar jugadores",
+		"NOT_AUTHORIZED.transfer":  "Solo el anfitrión puede ceder la mesa",
+		"NOT_AUTHORIZED.lock":      "Solo el anfitrión puede cerrar la mesa",
+		"NOT_AUTHORIZED.handicap":  "Solo el anfitrión puede fijar hándicaps",
+		"PREDICTION_CLOSED":        "Ahora no se pueden enviar predicciones",
+		"PUZZLE_NOT_FOUND":         "No se encontró el problema",
+		"INVALID_CONFIG":           "Configuración no válida",
+		"ADJOURN_FAILED":           "No se pudo aplazar la partida",
+		"REPORT_INVALID":           "Las denuncias necesitan otro jugador de la mesa y un motivo",
+		"REPORT_INVALID.duplicate": "Ya has denunciado a este jugador",
+		"REPORT_INVALID.unsaved":   "No se pudo guardar la denuncia",
+		"CHAT_REJECTED":            "Tu mensaje no se envió",
+		"CHAT_REJECTED.length":     "Los mensajes del chat pueden tener como máximo %d caracteres",
+		"INVALID_MOVE.rename":      "Solo puedes cambiar de nombre en la sala de espera, y no puede quedar vacío",
+		"INVALID_MOVE.handicap":    "Los hándicaps son puntos enteros de -20 a 20 para un jugador sentado",
+		"INVALID_PAYLOAD":          "No se pudo leer el mensaje %s",
+		"INVALID_PAYLOAD.field":    "Al mensaje %s le falta %s o no es válido",
+		"PRESET_NOT_FOUND":         "No tienes ningún preajuste con ese ID",
+		"NO_NOTES_KEY.preset":      "Envía una clave de notas para guardar preajustes de reglas",
+		"INVALID_CONFIG.preset":    "Ponle al preajuste un nombre de hasta 40 caracteres; puedes guardar hasta 20",
+		"INVALID_MOVE.block":       "Puedes bloquear a otra persona de la mesa, pero no a un bot ni mientras los nombres están ocultos",
+		"INVALID_MOVE.presence":    "Una clave de amigo debe tener al menos %d caracteres",
+		"INVITE_INVALID":           "Esa invitación ha caducado o no es para ti",
+		"INVALID_MOVE.note":        "Las notas son sobre otro jugador de la mesa, de hasta %d caracteres, y no se pueden guardar mientras los nombres están ocultos",
+		"NO_NOTES_KEY":             "Envía una clave de notas al unirte para guardar notas sobre otros jugadores",
+		"kicked":                   "Fuiste expulsado de la partida por votación.",
+		"kicked.privacy":           "Tus datos se eliminaron y has salido de la partida.",
+		"sessionReplaced":          "Tu asiento se abrió en otra conexión.",
+		"upgradeRequired":          "Esta versión de Pablo está desactualizada. Recarga la página para obtener la última.",
+		"upgradeRequired.readOnly": "Esta versión de Pablo está desactualizada, así que estás mirando esta partida. Recarga la página para jugar.",
+		"NOT_PLAYING":              "La partida no está en juego",
+		"NOT_YOUR_TURN":            "No es tu turno",
+		"ALREADY_DRAWN":            "Ya has robado en este turno",
+		"NO_DRAWN_CARD":            "No tienes ninguna carta robada",
+		"UNPLAYED_CARD":            "Primero debes descartar o cambiar la carta robada",
+		"MUST_SWAP":                "Una carta tomada del descarte debe cambiarse por una tuya",
+		"PENDING_POWER":            "Primero debes usar u omitir el poder de la carta especial",
+		"NO_PENDING_POWER":         "No hay ningún poder de carta especial que usar",
+		"PENDING_GIVE":             "Primero hay que dar una carta",
+		"NO_PENDING_GIVE":          "No hay ninguna carta que dar",
+		"EMPTY_DECK":               "El mazo está vacío",
+		"EMPTY_DISCARD":            "El descarte está vacío",
+		"NOT_STACKABLE":            "No se puede apilar sobre la carta de arriba",
+		"CARD_MISMATCH":            "El valor de la carta no coincide; recibes una carta de penalización",
+		"RULE_DISABLED":            "Esa jugada no está permitida en esta mesa",
+		"PABLO_CALLED":             "Ya se ha cantado Pablo",
+		"STACK_COOLDOWN":           "Demasiados apilamientos fallidos; apilar está en espera",
+		"STACK_SIT_OUT":            "Tras un apilamiento fallido no puedes apilar en esta carta ni en la siguiente",
+		"OUT_OF_PHASE":             "Esa jugada no está permitida en este momento del turno",
+		"NOT_PEEKING":              "La mesa no está mirando sus cartas",
+		"PEEK_LIMIT":               "Ya has mirado dos de tus cartas",
+		"INVALID_CARD_INDEX":       "No hay ninguna carta en esa posición",
+		"TARGET_NOT_FOUND":         "Ese jugador no está en juego en esta mesa",
+		"TUTORIAL_STEP":            "Primero sigue la instrucción del tutorial",
+		"tutorial.draw":            "¡Bienvenido a Pablo! Gana la puntuación más baja. Empieza tu turno robando la carta de arriba del mazo.",
+		"tutorial.swapAce":         "Has robado un as, que vale 1 punto. Tu primera carta es un rey de picas, que vale 10: cambia el as por esa carta.",
+		"tutorial.endTurn":         "Bien. Termina tu turno.",
+		"tutorial.watch":           "Ahora juega el entrenador. Mira el descarte; enseguida te toca.",
+		"tutorial.drawAgain":       "Te toca otra vez. Roba del mazo.",
+		"tutorial.discardSeven":    "Un 7 tiene un poder. Descártalo para mirar una de tus cartas.",
+		"tutorial.peek":            "Elige una de tus cartas para mirarla. Recordar lo que tienes es todo el juego.",
+		"tutorial.callPablo":       "Ya tienes una mano baja. Canta Pablo: los demás juegan un turno más y luego se puntúan las manos.",
+		"tutorial.drawLast":        "Aún juegas este turno. Roba una carta.",
+		"tutorial.swapTwo":         "¡Un 2! Cámbialo por el 6, tu tercera carta.",
+		"tutorial.lastLap":         "El entrenador juega su último turno y luego se destapan todas las manos.",
+		"tutorial.done":            "Eso es una ronda de Pablo. Mantén la mano baja, recuerda tus cartas y canta Pablo en el momento justo. ¡Ya puedes jugar una partida de verdad!",
+	},
+	"fr": {
+		"NOT_IN_GAME":              "Rejoignez d'abord une partie",
+		"NOT_IN_GAME.spectate":     "Regardez d'abord une partie",
+		"GAME_PAUSED":              "La partie est en pause",
+		"GAME_NOT_FOUND":           "Partie introuvable",
+		"GAME_FULL":                "La partie est complète",
+		"TABLE_LOCKED":             "L'hôte a fermé cette table",
+		"BANNED":                   "Vous êtes banni de ce serveur",
+		"BANNED.reason":            "Vous êtes banni de ce serveur : %s",
+		"BANNED.until":             "Vous êtes banni de ce serveur (jusqu'au %s)",
+		"BANNED.reasonUntil":       "Vous êtes banni de ce serveur : %s (jusqu'au %s)",
+		"CAPTCHA_REQUIRED":         "Complétez le CAPTCHA pour lancer une partie",
+		"RATE_LIMITED":             "Trop de parties rejointes depuis votre adresse ; réessayez dans une minute",
+		"TOO_MANY_GAMES":           "Votre adresse a déjà %d parties ouvertes ; quittez-en une avant d'en lancer ou d'en rejoindre une autre",
+		"INVALID_SCHEDULE.format":  "scheduledAt doit être une heure RFC 3339",
+		"INVALID_SCHEDULE.past":    "scheduledAt doit être dans le futur",
+		"INVALID_SCHEDULE.tooFar":  "Les parties ne peuvent pas être programmées aussi loin à l'avance",
+		"NOT_AUTHORIZED.observe":   "Vous n'êtes pas autorisé à observer",
+		"NOT_AUTHORIZED.mute":      "Seul l'hôte peut rendre des joueurs muets",
+		"NOT_AUTHORIZED.transfer":  "Seul l'hôte peut céder la table",
+		"NOT_AUTHORIZED.lock":      "Seul l'hôte peut fermer la table",
+		"NOT_AUTHORIZED.handicap":  "Seul l'hôte peut fixer les handicaps",
+		"PREDICTION_CLOSED":        "Les pronostics ne peuvent pas être envoyés pour le moment",
+		"PUZZLE_NOT_FOUND":         "Problème introuvable",
+		"INVALID_CONFIG":           "Configuration invalide",
+		"ADJOURN_FAILED":           "La partie n'a pas pu être ajournée",
+		"REPORT_INVALID":           "Un signalement doit viser un autre joueur de la table et donner un motif",
+		"REPORT_INVALID.duplicate": "Vous avez déjà signalé ce joueur",
+		"REPORT_INVALID.unsaved":   "Le signalement n'a pas pu être enregistré",
+		"CHAT_REJECTED":            "Votre message n'a pas été envoyé",
+		"CHAT_REJECTED.length":     "Les messages du chat font au plus %d caractères",
+		"INVALID_MOVE.rename":      "Le nom ne peut être changé que dans le salon, et ne peut pas être vide",
+		"INVALID_MOVE.handicap":    "Les handicaps sont des points entiers de -20 à 20 pour un joueur assis",
+		"INVALID_PAYLOAD":          "Le message %s n'a pas pu être lu",
+		"INVALID_PAYLOAD.field":    "Le message %s a un champ %s manquant ou invalide",
+		"PRESET_NOT_FOUND":         "Vous n'avez aucun préréglage avec cet identifiant",
+		"NO_NOTES_KEY.preset":      "Envoyez une clé de notes pour garder des préréglages de règles",
+		"INVALID_CONFIG.preset":    "Donnez au préréglage un nom de 40 caractères au plus ; vous pouvez en garder 20",
+		"INVALID_MOVE.block":       "Vous pouvez bloquer une autre personne de la table, mais pas un bot ni quand les noms sont masqués",
+		"INVALID_MOVE.presence":    "Une clé d'ami doit comporter au moins %d caractères",
+		"INVITE_INVALID":           "Cette invitation a expiré ou ne vous est pas destinée",
+		"INVALID_MOVE.note":        "Les notes portent sur un autre joueur de la table, font au plus %d caractères et ne peuvent pas être gardées quand les noms sont masqués",
+		"NO_NOTES_KEY":             "Envoyez une clé de notes en rejoignant pour garder des notes sur les autres joueurs",
+		"kicked":                   "Vous avez été exclu de la partie par un vote.",
+		"kicked.privacy":           "Vos données ont été supprimées et vous avez quitté la partie.",
+		"sessionReplaced":          "Votre place a été ouverte sur une autre connexion.",
+		"upgradeRequired":          "Cette version de Pablo n'est plus à jour. Rechargez la page pour obtenir la dernière.",
+		"upgradeRequired.readOnly": "Cette version de Pablo n'est plus à jour, vous regardez donc cette partie. Rechargez la page pour jouer.",
+		"NOT_PLAYING":              "La partie n'est pas en cours",
+		"NOT_YOUR_TURN":            "Ce n'est pas votre tour",
+		"ALREADY_DRAWN":            "Vous avez déjà pioché ce tour-ci",
+		"NO_DRAWN_CARD":            "Vous n'avez pas de carte piochée",
+		"UNPLAYED_CARD":            "Défaussez ou échangez d'abord la carte piochée",
+		"MUST_SWAP":                "Une carte prise dans la défausse doit être échangée",
+		"PENDING_POWER":            "Utilisez ou passez d'abord le pouvoir de la carte spéciale",
+		"NO_PENDING_POWER":         "Il n'y a pas de pouvoir de carte spéciale à utiliser",
+		"PENDING_GIVE":             "Une carte doit d'abord être donnée",
+		"NO_PENDING_GIVE":          "Il n'y a pas de carte à donner",
+		"EMPTY_DECK":               "La pioche est vide",
+		"EMPTY_DISCARD":            "La défausse est vide",
+		"NOT_STACKABLE":            "On ne peut pas empiler sur la carte du dessus",
+		"CARD_MISMATCH":            "La valeur de la carte ne correspond pas ; carte de pénalité ajoutée",
+		"RULE_DISABLED":            "Ce coup n'est pas activé à cette table",
+		"PABLO_CALLED":             "Pablo a déjà été annoncé",
+		"STACK_COOLDOWN":           "Trop d'empilements ratés ; l'empilement est suspendu",
+		"STACK_SIT_OUT":            "Après un empilement raté, vous n'empilez ni sur cette carte ni sur la suivante",
+		"OUT_OF_PHASE":             "Ce coup n'est pas permis à ce moment du tour",
+		"NOT_PEEKING":              "La table ne regarde pas ses cartes",
+		"PEEK_LIMIT":               "Vous avez déjà regardé deux de vos cartes",
+		"INVALID_CARD_INDEX":       "Il n'y a pas de carte à cet emplacement",
+		"TARGET_NOT_FOUND":         "Ce joueur n'est pas en jeu à cette table",
+		"TUTORIAL_STEP":            "Suivez d'abord l'instruction du tutoriel",
+		"tutorial.draw":            "Bienvenue dans Pablo ! Le score le plus bas gagne. Commencez votre tour en piochant la carte du dessus.",
+		"tutorial.swapAce":         "Vous avez pioché un as, qui vaut 1 point. Votre première carte est un roi de pique, qui vaut 10 : échangez l'as contre elle.",
+		"tutorial.endTurn":         "Bien joué. Terminez votre tour.",
+		"tutorial.watch":           "C'est au tour de l'entraîneur. Regardez la défausse ; vous rejouez dans un instant.",
+		"tutorial.drawAgain":       "À vous de nouveau. Piochez.",
+		"tutorial.discardSeven":    "Un 7 a un pouvoir. Défaussez-le pour regarder une de vos cartes.",
+		"tutorial.peek":            "Choisissez une de vos cartes à regarder. Se souvenir de sa main, c'est tout le jeu.",
+		"tutorial.callPablo":       "Votre main est basse. Annoncez Pablo : les autres jouent un dernier tour, puis les mains sont comptées.",
+		"tutorial.drawLast":        "Vous jouez encore ce tour. Piochez une carte.",
+		"tutorial.swapTwo":         "Un 2 ! Échangez-le contre le 6, votre troisième carte.",
+		"tutorial.lastLap":         "L'entraîneur joue son dernier tour, puis toutes les mains sont retournées.",
+		"tutorial.done":            "Voilà une manche de Pablo. Gardez une main basse, retenez vos cartes et annoncez Pablo au bon moment. Vous êtes prêt pour une vraie partie !",
+	},
+}
+
+// locales remembers the language each connection joined with
+var locales sync.Map // *websocket.Conn -> string
+
+// matchLocale picks the catalog for a requested locale like "es-MX" or "fr", or "" if
+// there's none
+func matchLocale(requested string) string {
+	tag := strings.ToLower(strings.TrimSpace(requested))
+	if _, ok := catalogs[tag]; ok {
+		return tag
+	}
+	if base, _, found := strings.Cut(tag, "-"); found {
+		if _, ok := catalogs[base]; ok {
+			return base
+		}
+	}
+	return ""
+}
+
+// setLocale records the language conn asked for. One without a catalog leaves it as it was.
+func setLocale(conn *websocket.Conn, requested string) {
+	if locale := matchLocale(requested); locale != "" {
+		locales.Store(conn, locale)
+	}
+}
+
+// localeOf returns the language conn gets messages in
+func localeOf(conn *websocket.Conn) string {
+	if conn != nil {
+		if locale, ok := locales.Load(conn); ok {
+			return locale.(string)
+		}
+	}
+	return defaultLocale
+}
+
+// forgetLocale drops what was recorded for a closed connection
+func forgetLocale(conn *websocket.Conn) {
+	locales.Delete(conn)
+}
+
+// translate returns the message for key in locale, with args filled in
+func translate(locale, key string, args ...interface{}) string {
+	message, ok := catalogs[locale][key]
+	if !ok {
+		message = catalogs[defaultLocale][key]
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// localize returns the message for key in conn's language
+func localize(conn *websocket.Conn, key string, args ...interface{}) string {
+	return translate(localeOf(conn), key, args...)
+}
+
+// localizeFor returns the message for key in the language playerID joined with. Caller
+// must hold g.mu.
+func (g *Game) localizeFor(playerID, key string, args ...interface{}) string {
+	var conn *websocket.Conn
+	if player, exists := g.Players[playerID]; exists {
+		conn = player.Conn
+	}
+	return localize(conn, key, args...)
+}
+
+// localizeError returns the message for an action's error. English keeps the error's own
+// text, which says more; other languages get the message for its code where they have one.
+func localizeError(conn *websocket.Conn, code, message string) string {
+	locale := localeOf(conn)
+	if locale == defaultLocale {
+		return message
+	}
+	if translated, ok := catalogs[locale][code]; ok {
+		return translated
+	}
+	return message
+}
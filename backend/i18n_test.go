@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"pablo/protocol"
+)
+
+func TestCatalogsMatchEnglish(t *testing.T) {
+	english := catalogs[defaultLocale]
+	for locale, catalog := range catalogs {
+		for key, message := range catalog {
+			source, ok := english[key]
+			if !ok {
+				t.Errorf("%s has %q, which English doesn't", locale, key)
+				continue
+			}
+			if strings.Count(message, "%") != strings.Count(source, "%") {
+				t.Errorf("%s %q should take the same arguments as English", locale, key)
+			}
+		}
+	}
+}
+
+func TestMatchLocale(t *testing.T) {
+	for requested, want := range map[string]string{"es": "es", "fr-CA": "fr", " EN-gb ": "en", "de": "", "": ""} {
+		if got := matchLocale(requested); got != want {
+			t.Errorf("matchLocale(%q) = %q, want %q", requested, got, want)
+		}
+	}
+}
+
+func TestLocalizeError(t *testing.T) {
+	conn := newTestConn(t)
+	defer forgetLocale(conn)
+	if got := localizeError(conn, protocol.CodeCardMismatch, "card rank does not match: penalty card added"); got != "card rank does not match: penalty card added" {
+		t.Errorf("English should keep the error's own text, got %q", got)
+	}
+	setLocale(conn, "es-ES")
+	if got := localizeError(conn, protocol.CodeNotYourTurn, "it's not your turn"); got != "No es tu turno" {
+		t.Errorf("Expected the Spanish message, got %q", got)
+	}
+	if got := localizeError(conn, protocol.CodeInvalidMove, "invalid target"); got != "invalid target" {
+		t.Errorf("Errors without a translation should keep their text, got %q", got)
+	}
+}
+
+func TestErrorsInJoinLocaleOverWS(t *testing.T) {
+	conn := dialTestServer(t)
+	sendTestMessage(t, conn, "join", map[string]interface{}{"gameID": "nope", "name": "Zoé", "locale": "fr-FR"})
+	reply := readMessageOfType(t, conn, "error")
+	if reply["code"] != protocol.CodeGameNotFound || reply["message"] != "Partie introuvable" {
+		t.Errorf("Expected the French not-found message, got %v", reply)
+	}
+
+	// Later errors on the same connection stay in French
+	sendTestMessage(t, conn, "startGame", map[string]interface{}{})
+	if reply := readMessageOfType(t, conn, "error"); reply["message"] != "Rejoignez d'abord une partie" {
+		t.Errorf("Expected the French not-in-game message, got %v", reply)
+	}
+}
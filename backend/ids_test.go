@@ -0,0 +1,35 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestNewUUID(t *testing.T) {
+	pattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id := newUUID()
+		if !pattern.MatchString(id) {
+			t.Fatalf("Not a version 4 UUID: %s", id)
+		}
+		if seen[id] {
+			t.Fatalf("Duplicate UUID %s", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestGamesCreatedTogetherGetDifferentDecks(t *testing.T) {
+	a, b := NewGame("a"), NewGame("b")
+	same := true
+	for i := range a.Deck {
+		if a.Deck[i] != b.Deck[i] {
+			same = false
+			break
+		}
+	}
+	if same {
+		t.Error("Two games created back to back should be shuffled differently")
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+// Players who join once a round has been dealt go on g.Waiting instead of into the seat
+// order. They keep a seat, session token and connection like anyone else, and get the
+// table's updates with no hand of their own, so they watch the round. When the next round
+// starts they take the next seats in the order they joined and are dealt in.
+
+// seatWaiting moves every waiting player into the seat order, ready for the deal.
+// Caller must hold g.mu.
+func (g *Game) seatWaiting() {
+	for _, id := range g.Waiting {
+		g.SeatOrder = append(g.SeatOrder, id)
+	}
+	g.Waiting = nil
+}
+
+// isWaiting reports whether playerID joined after the deal and hasn't been dealt in yet.
+// Caller must hold g.mu.
+func (g *Game) isWaiting(playerID string) bool {
+	for _, id := range g.Waiting {
+		if id == playerID {
+			return true
+		}
+	}
+	return false
+}
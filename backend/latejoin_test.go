@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLateJoinerWaitsForNextRound(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+
+	if !game.AddPlayer("late", "Late", nil) {
+		t.Fatal("Joining mid-round should be allowed")
+	}
+	if slices.Contains(game.SeatOrder, "late") || !slices.Equal(game.Waiting, []string{"late"}) {
+		t.Fatalf("Late joiner should wait, seats %v, waiting %v", game.SeatOrder, game.Waiting)
+	}
+	if seat := game.getGameStateForPlayer("late").Players["late"]; !seat.Waiting || len(seat.Cards) != 0 {
+		t.Errorf("Late joiner should show as waiting with no hand, got %+v", seat)
+	}
+
+	game.BroadcastState()
+	recorder.mu.Lock()
+	states := countOfType(recorder.players["late"], "gameState")
+	recorder.mu.Unlock()
+	if states == 0 {
+		t.Error("A waiting player should watch the round")
+	}
+
+	// Turns rotate among the dealt-in players only
+	current := game.CurrentPlayer
+	game.Deck[0] = Card{Suit: "clubs", Rank: "2"}
+	game.DrawCard(current)
+	game.DiscardDrawnCard(current)
+	game.EndTurn(current)
+	if game.CurrentPlayer == "late" {
+		t.Error("A waiting player should not get a turn")
+	}
+
+	game.EndRound()
+	if !game.isWaiting("late") {
+		t.Fatal("Late joiner should wait through the end of the round")
+	}
+
+	game.StartGame()
+	if !slices.Equal(game.SeatOrder, append(playerIDs, "late")) || len(game.Waiting) != 0 {
+		t.Fatalf("Late joiner should be seated when the next round starts, seats %v", game.SeatOrder)
+	}
+	dealt := 0
+	for _, card := range game.Players["late"].Cards {
+		if card.Rank != "" {
+			dealt++
+		}
+	}
+	if dealt != 4 {
+		t.Errorf("Late joiner should be dealt four cards, got %d", dealt)
+	}
+}
+
+func TestWaitingPlayerCannotAct(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+	game.AddPlayer("late", "Late", nil)
+
+	game.DiscardPile = append(game.DiscardPile, Card{Suit: "hearts", Rank: "5", FaceUp: true})
+	game.StackableCardIndex = len(game.DiscardPile) - 1
+	game.Players[playerIDs[0]].Cards[0] = Card{Suit: "clubs", Rank: "5"}
+
+	if err := game.StackCard("late", 0); !errors.Is(err, ErrNotInGame) {
+		t.Errorf("Expected ErrNotInGame stacking, got %v", err)
+	}
+	if err := game.StackOpponentCard("late", playerIDs[0], 0); !errors.Is(err, ErrNotInGame) {
+		t.Errorf("Expected ErrNotInGame stacking an opponent's card, got %v", err)
+	}
+	if err := game.CallPablo("late"); !errors.Is(err, ErrNotInGame) {
+		t.Errorf("Expected ErrNotInGame calling Pablo, got %v", err)
+	}
+}
+
+func TestWaitingPlayerNotScored(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+	game.AddPlayer("late", "Late", nil)
+
+	game.EndRound()
+	winners := game.roundWinners()
+	if slices.Contains(winners, "late") {
+		t.Errorf("A player who wasn't dealt in can't win the round, winners %v", winners)
+	}
+	for _, event := range game.eventLog {
+		if event.Type != "roundEnded" {
+			continue
+		}
+		scores := event.Data["scores"].(map[string]int)
+		if _, scored := scores["late"]; scored || len(scores) != len(playerIDs) {
+			t.Errorf("Only dealt-in players should be scored, got %v", scores)
+		}
+	}
+}
+
+func TestWaitingPlayerLeaves(t *testing.T) {
+	game := createTestGame("test-game")
+	addTestPlayers(game, 2)
+	game.StartGame()
+	game.AddPlayer("late", "Late", nil)
+
+	game.removePlayer("late")
+	if len(game.Waiting) != 0 || game.Status != "playing" {
+		t.Errorf("Leaving while waiting should not disturb the round, waiting %v, status %s", game.Waiting, game.Status)
+	}
+}
+
+func TestWaitingPlayerSurvivesAdjourn(t *testing.T) {
+	store := newAdjournStore(t.TempDir(), 24*time.Hour)
+	game := createTestGame("test-game")
+	addTestPlayers(game, 2)
+	game.StartGame()
+	game.AddPlayer("late", "Late", nil)
+
+	if !game.Adjourn(context.Background(), game.HostID, store) {
+		t.Fatal("Host should be able to adjourn")
+	}
+	gm := &GameManager{games: make(map[string]*Game), adjourned: store}
+	restored := gm.GetGame(context.Background(), "test-game")
+	if restored == nil {
+		t.Fatal("Adjourned game should be found after a restart")
+	}
+	if _, seated := restored.Players["late"]; !seated || !restored.isWaiting("late") {
+		t.Error("A waiting player should still be waiting after a restore")
+	}
+}
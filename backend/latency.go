@@ -0,0 +1,162 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// defaultLatencyPingInterval is how often the server pings each socket to time the round trip
+const defaultLatencyPingInterval = 5 * time.Second
+
+// missedPongLimit is how many ping intervals a connection may go without answering before
+// its read deadline passes and it is taken for dead (see connWatch). Browsers answer pings
+// on their own, so only a peer that's gone, without its socket having been closed, stays
+// quiet that long.
+const missedPongLimit = 3
+
+// latencyPingInterval holds the ping interval as a time.Duration. It is atomic so it can
+// change while connections are open.
+var latencyPingInterval atomic.Int64
+
+func init() {
+	latencyPingInterval.Store(int64(defaultLatencyPingInterval))
+}
+
+// connLatency is the measured round-trip time of one connection
+type connLatency struct {
+	last     atomic.Int64 // Most recent round trip, as a time.Duration
+	smoothed atomic.Int64 // Moving average that one slow ping doesn't swing much
+}
+
+// latencies holds a *connLatency for every connection that has answered a ping
+var latencies sync.Map
+
+// pingForLatency pings conn every latencyPingInterval until done is closed. Each ping carries
+// the time it was sent, which the client's pong echoes back to notePong. The pongs also
+// keep the connection's read deadline from passing.
+func pingForLatency(conn *websocket.Conn, done <-chan struct{}) {
+	ticker := time.NewTicker(time.Duration(latencyPingInterval.Load()))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		sent := strconv.FormatInt(time.Now().UnixNano(), 10)
+		if err := conn.WriteControl(websocket.PingMessage, []byte(sent), time.Now().Add(time.Second)); err != nil {
+			return
+		}
+	}
+}
+
+// notePong records the round trip of a ping sent by pingForLatency. Pongs the client
+// sends unasked, or for pings it didn't get from us, are ignored.
+func notePong(conn *websocket.Conn, data string) {
+	sent, err := strconv.ParseInt(data, 10, 64)
+	if err != nil {
+		return
+	}
+	rtt := time.Since(time.Unix(0, sent))
+	if rtt < 0 || rtt > time.Minute {
+		return
+	}
+	value, _ := latencies.LoadOrStore(conn, &connLatency{})
+	l := value.(*connLatency)
+	l.last.Store(int64(rtt))
+	if old := l.smoothed.Load(); old == 0 {
+		l.smoothed.Store(int64(rtt))
+	} else {
+		l.smoothed.Store(old + (int64(rtt)-old)/4)
+	}
+}
+
+// latencyOf returns conn's smoothed and most recent round-trip times, or zeros if it hasn't
+// answered a ping yet
+func latencyOf(conn *websocket.Conn) (smoothed, last time.Duration) {
+	if conn == nil {
+		return 0, 0
+	}
+	value, exists := latencies.Load(conn)
+	if !exists {
+		return 0, 0
+	}
+	l := value.(*connLatency)
+	return time.Duration(l.smoothed.Load()), time.Duration(l.last.Load())
+}
+
+// forgetLatency drops what was measured for a closed connection
+func forgetLatency(conn *websocket.Conn) {
+	latencies.Delete(conn)
+}
+
+// PlayerLatency is one seat's connection quality, for the admin latency feed
+type PlayerLatency struct {
+	GameID    string  `json:"gameID"`
+	PlayerID  string  `json:"playerID"`
+	Name      string  `json:"name"`
+	Connected bool    `json:"connected"`
+	RTTMs     float64 `json:"rttMs"`     // Smoothed round trip; 0 until measured
+	LastRTTMs float64 `json:"lastRttMs"` // Most recent round trip
+}
+
+// Latencies lists the round-trip times of the game's human seats, in seat order
+func (g *Game) Latencies() []PlayerLatency {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	list := []PlayerLatency{}
+	for _, id := range g.SeatOrder {
+		player, exists := g.Players[id]
+		if _, isBot := g.Bots[id]; !exists || isBot {
+			continue
+		}
+		smoothed, last := latencyOf(player.Conn)
+		list = append(list, PlayerLatency{
+			GameID:    g.ID,
+			PlayerID:  id,
+			Name:      player.Name,
+			Connected: player.Conn != nil,
+			RTTMs:     float64(smoothed) / float64(time.Millisecond),
+			LastRTTMs: float64(last) / float64(time.Millisecond),
+		})
+	}
+	return list
+}
+
+// handleAdminLatency serves GET /admin/latency: every seated player's round-trip time,
+// slowest first
+func handleAdminLatency(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	gameManager.mu.RLock()
+	games := make([]*Game, 0, len(gameManager.games))
+	for _, game := range gameManager.games {
+		games = append(games, game)
+	}
+	gameManager.mu.RUnlock()
+
+	players := []PlayerLatency{}
+	for _, game := range games {
+		players = append(players, game.Latencies()...)
+	}
+	sort.Slice(players, func(i, j int) bool {
+		if players[i].RTTMs != players[j].RTTMs {
+			return players[i].RTTMs > players[j].RTTMs
+		}
+		return players[i].GameID+players[i].PlayerID < players[j].GameID+players[j].PlayerID
+	})
+	writeAdminJSON(w, http.StatusOK, map[string]interface{}{"players": players})
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLatencyMeasuredFromPings(t *testing.T) {
+	saved := latencyPingInterval.Load()
+	latencyPingInterval.Store(int64(10 * time.Millisecond))
+	defer latencyPingInterval.Store(saved)
+
+	conn, session := createTestGameOverWS(t)
+	game := gameManager.GetGame(context.Background(), session["gameID"].(string))
+	// The client answers pings while it reads
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for game.Latencies()[0].RTTMs == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	latency := game.Latencies()[0]
+	if latency.RTTMs <= 0 || latency.LastRTTMs <= 0 || !latency.Connected || latency.Name != "Host" {
+		t.Fatalf("Expected a measured round trip, got %+v", latency)
+	}
+
+	// Tables that ask for it show it in the state; a seat with no measurement leaves it out
+	shown, _ := json.Marshal(PlayerState{ID: "p", LatencyMs: 42})
+	hidden, _ := json.Marshal(PlayerState{ID: "p"})
+	if !strings.Contains(string(shown), `"latencyMs":42`) || strings.Contains(string(hidden), "latencyMs") {
+		t.Errorf("Unexpected seat JSON %s / %s", shown, hidden)
+	}
+
+	savedKey := adminKey
+	adminKey = "secret"
+	defer func() { adminKey = savedKey }()
+	req := httptest.NewRequest(http.MethodGet, "/admin/latency", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	handleAdminLatency(rec, req)
+	var body struct {
+		Players []PlayerLatency `json:"players"`
+	}
+	json.NewDecoder(rec.Body).Decode(&body)
+	found := false
+	for _, player := range body.Players {
+		found = found || player.PlayerID == latency.PlayerID
+	}
+	if rec.Code != http.StatusOK || !found {
+		t.Errorf("Expected the player in the admin feed, got %d %+v", rec.Code, body)
+	}
+}
+
+func TestSilentConnectionIsClosed(t *testing.T) {
+	saved := latencyPingInterval.Load()
+	latencyPingInterval.Store(int64(10 * time.Millisecond))
+	defer latencyPingInterval.Store(saved)
+
+	// The client stops reading, so it stops answering pings
+	_, session := createTestGameOverWS(t)
+	game := gameManager.GetGame(context.Background(), session["gameID"].(string))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for game.LobbyPlayers()[0].Status != seatDisconnected {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the seat disconnected once its connection stopped answering")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Unlike an idle socket, a dead one keeps its lobby seat, and the audit says why it went
+	game.mu.Lock()
+	defer game.mu.Unlock()
+	if len(game.Players) != 1 {
+		t.Error("Expected the seat kept for the player to come back to")
+	}
+	if last := game.connectionLog[len(game.connectionLog)-1]; last.Event != connTimedOut {
+		t.Errorf("Expected the connection logged as timed out, got %q", last.Event)
+	}
+}
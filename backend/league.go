@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Leagues run a Swiss-system event over a fixed number of rounds. Every round, players are
+// paired from the standings so far, best with best, avoiding rematches where possible, and
+// each pair plays at a table of its own; an odd player out gets a bye. A league game
+// counts once its first round ends: the lower score wins the point (half each for a tie)
+// and both scores go on the players' cumulative score, which breaks ties in points, lower
+// being better. Once every game of a round is in, the next round is paired.
+//
+// Players have no accounts, so league players are names, and a league table only seats
+// the two names paired at it. Leagues are kept in memory.
+
+const (
+	maxLeaguePlayers = 64
+	maxLeagueNameLen = 60
+)
+
+var errInvalidLeague = errors.New("invalid league")
+
+// leaguePlayer is one entrant's standing
+type leaguePlayer struct {
+	Name      string          `json:"name"`
+	Points    float64         `json:"points"` // 1 per win or bye, 0.5 per tie
+	Score     int             `json:"score"`  // Pablo points over every league game; lower breaks ties
+	Played    int             `json:"played"`
+	opponents map[string]bool // Lowercased names already played
+	hadBye    bool
+	seed      int // Order of entry, the last tie-break
+}
+
+// leaguePairing is one table (or bye) in a round
+type leaguePairing struct {
+	GameID  string         `json:"gameID,omitempty"`
+	Players []string       `json:"players"`
+	Bye     bool           `json:"bye,omitempty"`
+	Scores  map[string]int `json:"scores,omitempty"` // By name, once the game is in
+	Done    bool           `json:"done"`
+}
+
+// league is one Swiss event
+type league struct {
+	ID       string
+	Name     string
+	Rounds   int
+	players  []*leaguePlayer
+	pairings [][]*leaguePairing // By round, from round 1
+}
+
+// leagueRegistry holds every league and which league game each table is. Its lock is
+// never held while taking a game's lock or the game manager's, since games report to it
+// with their own lock held.
+type leagueRegistry struct {
+	leagues map[string]*league
+	byGame  map[string]*leaguePairing
+	ofGame  map[string]*league
+	mu      sync.Mutex
+}
+
+var leagues = newLeagueRegistry()
+
+func newLeagueRegistry() *leagueRegistry {
+	return &leagueRegistry{
+		leagues: make(map[string]*league),
+		byGame:  make(map[string]*leaguePairing),
+		ofGame:  make(map[string]*league),
+	}
+}
+
+func leagueKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Create starts a league of rounds rounds between names and opens its first round's
+// tables. There must be fewer rounds than players, so nobody has to play anyone twice.
+func (r *leagueRegistry) Create(ctx context.Context, name string, names []string, rounds int) (*league, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxLeagueNameLen || len(names) < 2 || len(names) > maxLeaguePlayers || rounds < 1 || rounds >= len(names) {
+		return nil, errInvalidLeague
+	}
+	l := &league{ID: newUUID(), Name: name, Rounds: rounds}
+	seen := make(map[string]bool)
+	for i, entrant := range names {
+		entrant = strings.TrimSpace(entrant)
+		if entrant == "" || seen[leagueKey(entrant)] {
+			return nil, errInvalidLeague
+		}
+		seen[leagueKey(entrant)] = true
+		l.players = append(l.players, &leaguePlayer{Name: entrant, opponents: make(map[string]bool), seed: i})
+	}
+
+	r.mu.Lock()
+	r.leagues[l.ID] = l
+	round := l.pairNextRound()
+	r.mu.Unlock()
+	r.openTables(ctx, l, round)
+	return l, nil
+}
+
+// openTables creates a game for each pairing in round that needs one
+func (r *leagueRegistry) openTables(ctx context.Context, l *league, round []*leaguePairing) {
+	games := make([]*Game, len(round))
+	for i, pairing := range round {
+		if !pairing.Bye {
+			games[i] = gameManager.CreateGame(ctx)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, game := range games {
+		if game != nil {
+			round[i].GameID = game.ID
+			r.byGame[game.ID] = round[i]
+			r.ofGame[game.ID] = l
+		}
+	}
+}
+
+// pairNextRound pairs the next round from the standings and returns it. Byes are scored
+// straight away. Caller must hold r.mu.
+func (l *league) pairNextRound() []*leaguePairing {
+	ranked := l.standings()
+	var round []*leaguePairing
+
+	// The lowest-ranked player without a bye yet sits out an odd round
+	if len(ranked)%2 == 1 {
+		for i := len(ranked) - 1; i >= 0; i-- {
+			if !ranked[i].hadBye || i == 0 {
+				bye := ranked[i]
+				bye.hadBye = true
+				bye.Points++
+				round = append(round, &leaguePairing{Players: []string{bye.Name}, Bye: true, Done: true})
+				ranked = append(ranked[:i:i], ranked[i+1:]...)
+				break
+			}
+		}
+	}
+
+	// Best first, each with the best-placed player they haven't met, or the next one if
+	// they've met everyone left
+	for len(ranked) > 0 {
+		player := ranked[0]
+		opponent := 1
+		for i := 1; i < len(ranked); i++ {
+			if !player.opponents[leagueKey(ranked[i].Name)] {
+				opponent = i
+				break
+			}
+		}
+		round = append(round, &leaguePairing{Players: []string{player.Name, ranked[opponent].Name}})
+		ranked = append(ranked[1:opponent:opponent], ranked[opponent+1:]...)
+	}
+	l.pairings = append(l.pairings, round)
+	return round
+}
+
+// standings returns the players best first: most points, then lowest cumulative score,
+// then order of entry. Caller must hold the registry's lock.
+func (l *league) standings() []*leaguePlayer {
+	ranked := append([]*leaguePlayer(nil), l.players...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		return a.seed < b.seed
+	})
+	return ranked
+}
+
+func (l *league) player(name string) *leaguePlayer {
+	for _, player := range l.players {
+		if leagueKey(player.Name) == leagueKey(name) {
+			return player
+		}
+	}
+	return nil
+}
+
+// finished reports whether every round has been played. Caller must hold the registry's lock.
+func (l *league) finished() bool {
+	if len(l.pairings) < l.Rounds {
+		return false
+	}
+	for _, pairing := range l.pairings[len(l.pairings)-1] {
+		if !pairing.Done {
+			return false
+		}
+	}
+	return true
+}
+
+// MayJoin reports whether name may sit at gameID: anyone at a table that isn't a league's,
+// and only the paired players at one that is
+func (r *leagueRegistry) MayJoin(gameID, name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pairing, exists := r.byGame[gameID]
+	if !exists {
+		return true
+	}
+	for _, paired := range pairing.Players {
+		if leagueKey(paired) == leagueKey(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsLeagueGame reports whether gameID is a league table, which only seats its pairing
+func (r *leagueRegistry) IsLeagueGame(gameID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, exists := r.byGame[gameID]
+	return exists
+}
+
+// Report records the result of league game gameID from each seat's score by name, and
+// pairs the next round once the round is complete. Games that aren't league games, or
+// whose result is already in, are ignored, as is a result missing one of the pair.
+func (r *leagueRegistry) Report(ctx context.Context, gameID string, scores map[string]int) {
+	r.mu.Lock()
+	pairing, l := r.byGame[gameID], r.ofGame[gameID]
+	if pairing == nil || pairing.Done {
+		r.mu.Unlock()
+		return
+	}
+	byName := make(map[string]int)
+	for name, score := range scores {
+		byName[leagueKey(name)] = score
+	}
+	a, b := l.player(pairing.Players[0]), l.player(pairing.Players[1])
+	scoreA, playedA := byName[leagueKey(a.Name)]
+	scoreB, playedB := byName[leagueKey(b.Name)]
+	if !playedA || !playedB {
+		r.mu.Unlock()
+		return
+	}
+
+	pairing.Done = true
+	pairing.Scores = map[string]int{a.Name: scoreA, b.Name: scoreB}
+	switch {
+	case scoreA < scoreB:
+		a.Points++
+	case scoreB < scoreA:
+		b.Points++
+	default:
+		a.Points += 0.5
+		b.Points += 0.5
+	}
+	a.Score += scoreA
+	b.Score += scoreB
+	a.Played++
+	b.Played++
+	a.opponents[leagueKey(b.Name)] = true
+	b.opponents[leagueKey(a.Name)] = true
+
+	var next []*leaguePairing
+	roundDone := true
+	for _, other := range l.pairings[len(l.pairings)-1] {
+		roundDone = roundDone && other.Done
+	}
+	if roundDone && len(l.pairings) < l.Rounds {
+		next = l.pairNextRound()
+	}
+	r.mu.Unlock()
+	if next != nil {
+		r.openTables(ctx, l, next)
+	}
+}
+
+// leagueStanding is one row of a league's table
+type leagueStanding struct {
+	Rank int `json:"rank"` // Tied on points and score share a rank
+	leaguePlayer
+}
+
+// leagueView is a league as the API shows it
+type leagueView struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Rounds    int               `json:"rounds"`
+	Round     int               `json:"round"` // The round being played, or the last one once finished
+	Finished  bool              `json:"finished"`
+	Standings []leagueStanding  `json:"standings"`
+	Pairings  [][]leaguePairing `json:"pairings"` // By round
+}
+
+// View returns the league with the given ID as the API shows it
+func (r *leagueRegistry) View(id string) (leagueView, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l, exists := r.leagues[id]
+	if !exists {
+		return leagueView{}, false
+	}
+	view := leagueView{
+		ID:       l.ID,
+		Name:     l.Name,
+		Rounds:   l.Rounds,
+		Round:    len(l.pairings),
+		Finished: l.finished(),
+		Pairings: [][]leaguePairing{},
+	}
+	for i, player := range l.standings() {
+		standing := leagueStanding{Rank: i + 1, leaguePlayer: *player}
+		if prev := view.Standings; i > 0 && prev[i-1].Points == player.Points && prev[i-1].Score == player.Score {
+			standing.Rank = prev[i-1].Rank
+		}
+		view.Standings = append(view.Standings, standing)
+	}
+	for _, round := range l.pairings {
+		copied := make([]leaguePairing, len(round))
+		for i, pairing := range round {
+			copied[i] = *pairing
+		}
+		view.Pairings = append(view.Pairings, copied)
+	}
+	return view, true
+}
+
+// reportLeagueGame sends a league table's result to its league when its first round
+// ends. The league may open new tables, which takes the game manager's lock, so it is
+// told on its own goroutine. Caller must hold g.mu.
+func (g *Game) reportLeagueGame() {
+	scores := make(map[string]int, len(g.SeatOrder))
+	for _, id := range g.SeatOrder {
+		scores[g.Players[id].Name] = g.Players[id].Score
+	}
+	go leagues.Report(context.Background(), g.ID, scores)
+}
+
+// handleLeagues serves GET /leagues/{id} with the standings and every round's pairings
+func handleLeagues(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	view, exists := leagues.View(strings.Trim(strings.TrimPrefix(r.URL.Path, "/leagues/"), "/"))
+	if !exists {
+		http.Error(w, "league not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(view)
+}
+
+// handleAdminLeagues starts a league:
+//
+//	POST /admin/leagues  {"name", "players": [names], "rounds"}
+func handleAdminLeagues(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Name    string   `json:"name"`
+		Players []string `json:"players"`
+		Rounds  int      `json:"rounds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	l, err := leagues.Create(r.Context(), req.Name, req.Players, req.Rounds)
+	if err != nil {
+		http.Error(w, "a league needs a name, 2 to 64 different player names and at least 1 round but fewer rounds than players", http.StatusBadRequest)
+		return
+	}
+	view, _ := leagues.View(l.ID)
+	writeAdminJSON(w, http.StatusCreated, view)
+}
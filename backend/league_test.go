@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLeaguePairsFromStandings(t *testing.T) {
+	l := &league{Rounds: 3}
+	for i, name := range []string{"Ana", "Ben", "Cal", "Dee", "Eve"} {
+		l.players = append(l.players, &leaguePlayer{Name: name, opponents: make(map[string]bool), seed: i})
+	}
+
+	round := l.pairNextRound()
+	if !round[0].Bye || round[0].Players[0] != "Eve" {
+		t.Fatalf("Expected the lowest seed to get the bye, got %+v", round[0])
+	}
+	if round[1].Players[0] != "Ana" || round[1].Players[1] != "Ben" || round[2].Players[0] != "Cal" {
+		t.Fatalf("Expected the first round paired in seed order, got %+v %+v", round[1], round[2])
+	}
+
+	// Ana and Ben drew, Cal beat Dee; Eve has a bye point
+	results := map[string]struct {
+		points float64
+		score  int
+	}{"Ana": {0.5, 10}, "Ben": {0.5, 10}, "Cal": {1, 3}, "Dee": {0, 20}}
+	for name, result := range results {
+		player := l.player(name)
+		player.Points, player.Score = result.points, result.score
+	}
+	l.player("Ana").opponents["ben"], l.player("Ben").opponents["ana"] = true, true
+	l.player("Cal").opponents["dee"], l.player("Dee").opponents["cal"] = true, true
+
+	round = l.pairNextRound()
+	if !round[0].Bye || round[0].Players[0] != "Dee" {
+		t.Fatalf("Expected the bye to pass to the lowest player without one, got %+v", round[0])
+	}
+	if round[1].Players[0] != "Eve" || round[1].Players[1] != "Cal" {
+		t.Errorf("Expected the leaders paired, the bye ahead on score, got %+v", round[1])
+	}
+	if round[2].Players[0] != "Ana" || round[2].Players[1] != "Ben" {
+		t.Errorf("With nobody else left, a rematch is better than no game, got %+v", round[2])
+	}
+}
+
+func TestLeagueStandingsBreakTiesOnScore(t *testing.T) {
+	l := &league{ID: "tie-breaks", Rounds: 1}
+	for i, player := range []*leaguePlayer{
+		{Name: "Ana", Points: 1, Score: 12},
+		{Name: "Ben", Points: 1, Score: 7},
+		{Name: "Cal", Points: 2, Score: 30},
+		{Name: "Dee", Points: 1, Score: 7},
+	} {
+		player.seed = i
+		l.players = append(l.players, player)
+	}
+	leagues.mu.Lock()
+	leagues.leagues["tie-breaks"] = l
+	leagues.mu.Unlock()
+
+	view, _ := leagues.View("tie-breaks")
+	var order []string
+	var ranks []int
+	for _, standing := range view.Standings {
+		order = append(order, standing.Name)
+		ranks = append(ranks, standing.Rank)
+	}
+	if strings.Join(order, ",") != "Cal,Ben,Dee,Ana" {
+		t.Errorf("Expected points then lower score to rank players, got %v", order)
+	}
+	if ranks[1] != 2 || ranks[2] != 2 || ranks[3] != 4 {
+		t.Errorf("Expected players level on points and score to share a rank, got %v", ranks)
+	}
+}
+
+func TestLeagueRoundFlow(t *testing.T) {
+	savedKey := adminKey
+	adminKey = "secret"
+	defer func() { adminKey = savedKey }()
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/leagues", strings.NewReader(`{"name":"Autumn","players":["Ana","Ben","Cal"],"rounds":3}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	handleAdminLeagues(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for as many rounds as players, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/leagues", strings.NewReader(`{"name":"Autumn","players":["Ana","Ben","Cal"],"rounds":2}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	handleAdminLeagues(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	var view leagueView
+	json.Unmarshal(rec.Body.Bytes(), &view)
+	table := view.Pairings[0][1]
+	if table.GameID == "" || table.Players[0] != "Ana" || table.Players[1] != "Ben" {
+		t.Fatalf("Expected Ana and Ben at a table, got %+v", table)
+	}
+
+	game := gameManager.GetGame(context.Background(), table.GameID)
+	if game.AddPlayer("p3", "Cal", nil) {
+		t.Error("A league table shouldn't seat anyone not paired at it")
+	}
+	game.AddPlayer("p1", "Ana", nil)
+	game.AddPlayer("p2", "ben", nil)
+	playScoredRound(game, map[string][]string{"p1": {"A", "2", "3", "4"}, "p2": {"K", "Q", "J", "10"}})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for view.Round < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		rec = httptest.NewRecorder()
+		handleLeagues(rec, httptest.NewRequest(http.MethodGet, "/leagues/"+view.ID, nil))
+		json.Unmarshal(rec.Body.Bytes(), &view)
+	}
+	if view.Round != 2 {
+		t.Fatalf("Expected the second round paired once the first was in, got %+v", view)
+	}
+	if leader := view.Standings[0]; leader.Points != 1 || leader.Played != 0 {
+		t.Errorf("Expected Cal's bye level with Ana's win and ahead on score, got %+v", view.Standings)
+	}
+	if ana := view.Standings[1]; ana.Name != "Ana" || ana.Points != 1 || ana.Played != 1 {
+		t.Errorf("Expected Ana's win counted, got %+v", view.Standings)
+	}
+	if next := view.Pairings[1]; !next[0].Bye || next[0].Players[0] != "Ben" || next[1].GameID == "" {
+		t.Errorf("Expected Ben to sit out and Cal to meet Ana, got %+v", next)
+	}
+}
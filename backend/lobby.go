@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"pablo/protocol"
+)
+
+// Connection status of a seat in the lobby list
+const (
+	seatConnected    = "connected"
+	seatDisconnected = "disconnected"
+	seatBot          = "bot"
+	seatVacant       = "vacant"
+)
+
+// LobbyPlayer is one seat as shown in the lobby
+type LobbyPlayer struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Seat   int    `json:"seat"`
+	Ready  bool   `json:"ready"`
+	Host   bool   `json:"host"`
+	Muted  bool   `json:"muted"`
+	Status string `json:"status"` // "connected", "disconnected", "vacant" or "bot"
+}
+
+// LobbyPlayers lists everyone seated, in seat order, with whether they're still connected.
+// It reads the published view, so it never waits on the game; don't modify the result.
+func (g *Game) LobbyPlayers() []LobbyPlayer {
+	return g.public().lobby
+}
+
+// lobbyPlayers builds the lobby list. Caller must hold g.mu.
+func (g *Game) lobbyPlayers() []LobbyPlayer {
+	players := make([]LobbyPlayer, 0, len(g.SeatOrder))
+	for seat, id := range g.SeatOrder {
+		player := g.Players[id]
+		status := seatConnected
+		if _, isBot := g.Bots[id]; isBot {
+			status = seatBot
+		} else if g.vacant[id] {
+			status = seatVacant
+		} else if player.Conn == nil {
+			status = seatDisconnected
+		}
+		players = append(players, LobbyPlayer{
+			ID:     id,
+			Name:   g.displayName(id),
+			Seat:   seat,
+			Ready:  player.Ready,
+			Host:   id == g.HostID,
+			Muted:  g.Muted[id],
+			Status: status,
+		})
+	}
+	return players
+}
+
+// broadcastLobby sends the lobby list to everyone at the table. Caller must hold g.mu.
+func (g *Game) broadcastLobby() {
+	view := g.publish()
+	g.broadcast(Message{
+		Type: protocol.MsgLobbyPlayers,
+		Payload: map[string]interface{}{
+			"gameID":  g.ID,
+			"players": view.lobby,
+		},
+	})
+}
+
+// SetReady marks a seated player ready (or not) while the game is waiting to start
+func (g *Game) SetReady(playerID string, ready bool) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	player, exists := g.Players[playerID]
+	if !exists || g.Status != protocol.StatusWaiting {
+		return false
+	}
+	player.Ready = ready
+	g.broadcastLobby()
+	return true
+}
+
+// bindConn moves player's seat to conn. A different connection still holding the seat,
+// such as a second tab or a socket the client gave up on, is told and closed rather than
+// left open receiving nothing. Caller must hold g.mu.
+func (g *Game) bindConn(player *Player, conn *websocket.Conn) {
+	if old := player.Conn; old != nil && old != conn {
+		closeReplaced(old, g.ID)
+	}
+	player.Conn = conn
+	delete(g.absent, player.ID)
+	delete(g.vacant, player.ID)
+}
+
+// uniqueName returns name, or name with a number added if someone at the table other than
+// playerID already goes by it, so two players can't be told apart only by seat. Caller
+// must hold g.mu.
+func (g *Game) uniqueName(playerID, name string) string {
+	taken := func(candidate string) bool {
+		for id, player := range g.Players {
+			if id != playerID && strings.EqualFold(player.Name, candidate) {
+				return true
+			}
+		}
+		return false
+	}
+	unique := name
+	for n := 2; taken(unique); n++ {
+		unique = name + " " + strconv.Itoa(n)
+	}
+	return unique
+}
+
+// Rename changes a seated player's name while the game is waiting to start, numbering it
+// like a joining player's if someone else has it. Returns the name they got, or false if
+// the game has started or the name is blank.
+func (g *Game) Rename(playerID, name string) (string, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	player, exists := g.Players[playerID]
+	name = strings.TrimSpace(name)
+	if !exists || name == "" || g.Status != protocol.StatusWaiting {
+		return "", false
+	}
+	player.Name = g.uniqueName(playerID, name)
+	message := Message{
+		Type: protocol.MsgPlayerRenamed,
+		Payload: map[string]interface{}{
+			"playerID": playerID,
+			"name":     g.displayName(playerID),
+		},
+	}
+	g.broadcast(message)
+	g.broadcastToSpectators(message)
+	g.broadcastLobby()
+	g.broadcastGameState()
+	return player.Name, true
+}
+
+// holdsOpenSeat reports whether playerID's seat counts toward their address's cap on open
+// games: it does while they're connected to it, and in a lobby that hasn't started even
+// if they aren't, since an abandoned lobby stays around until its seats are given up. A
+// lobby that has been hibernated no longer takes up memory and stops counting.
+func (g *Game) holdsOpenSeat(playerID string) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	player, exists := g.Players[playerID]
+	if !exists || g.GameOver || g.hibernated {
+		return false
+	}
+	return player.Conn != nil || g.Status == protocol.StatusWaiting || g.Status == protocol.StatusScheduled
+}
+
+// Disconnect records that a player's connection closed and tells the table. The seat is
+// kept so they can come back; nothing happens if the seat has already moved to a newer
+// connection. During the last lap after a Pablo call their turns are closed for them (see
+// skipAbsentTurn), and if they stay away the seat turns vacant or, in a lobby, is given up
+// (see reapVacantSeats).
+func (g *Game) Disconnect(playerID string, conn *websocket.Conn) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	player, exists := g.Players[playerID]
+	if !exists || conn == nil || player.Conn != conn {
+		return
+	}
+	player.Conn = nil
+	g.absent[playerID] = time.Now()
+	message := Message{
+		Type: protocol.MsgPlayerDisconnected,
+		Payload: map[string]interface{}{
+			"playerID":   playerID,
+			"playerName": g.displayName(playerID),
+		},
+	}
+	g.broadcast(message)
+	g.broadcastToSpectators(message)
+	skipped := playerID == g.CurrentPlayer && g.skipAbsentTurn()
+	g.broadcastLobby()
+	if !skipped {
+		// Seats show who is away
+		g.broadcastGameState()
+	}
+}
+
+// ReleaseIdleSeat frees the seat of a player whose socket was closed for going quiet, so an
+// abandoned tab doesn't keep a place at a table that hasn't started. Once a game is under
+// way the seat is kept for them to come back to, as with any other disconnect.
+func (g *Game) ReleaseIdleSeat(playerID string, conn *websocket.Conn) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	player, exists := g.Players[playerID]
+	if !exists || conn == nil || player.Conn != conn || g.Status != protocol.StatusWaiting {
+		return false
+	}
+	g.removePlayer(playerID)
+	return true
+}
+
+// handleGames routes the public per-game endpoints under /games/
+func handleGames(w http.ResponseWriter, r *http.Request) {
+	switch path := strings.TrimRight(r.URL.Path, "/"); {
+	case path == "/games/recent":
+		handleRecentGames(w, r)
+	case strings.HasSuffix(path, "/state"):
+		handleGameState(w, r)
+	case strings.HasSuffix(path, "/stream"):
+		handleGameStream(w, r)
+	case strings.HasSuffix(path, "/results.csv"):
+		handleGameResults(w, r)
+	default:
+		handleGamePlayers(w, r)
+	}
+}
+
+// handleGamePlayers serves GET /games/{gameID}/players with the lobby list
+func handleGamePlayers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/games/"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "players" {
+		http.NotFound(w, r)
+		return
+	}
+	game := gameManager.GetGame(r.Context(), parts[0])
+	if game == nil {
+		http.Error(w, "game not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"gameID":  game.ID,
+		"players": game.LobbyPlayers(),
+	})
+}
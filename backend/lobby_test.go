@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"pablo/protocol"
+)
+
+func TestLobbyPlayers(t *testing.T) {
+	game := createTestGame("test-game")
+	conn := newTestConn(t)
+	game.AddPlayer("alice", "Alice", conn)
+	game.AddPlayer("bob", "Bob", nil)
+	game.AddBot("bot-1", "Bot 1")
+
+	players := game.LobbyPlayers()
+	if len(players) != 3 {
+		t.Fatalf("Expected 3 players, got %d", len(players))
+	}
+	expected := []struct {
+		id, status string
+		host       bool
+	}{
+		{"alice", seatConnected, true},
+		{"bob", seatDisconnected, false},
+		{"bot-1", seatBot, false},
+	}
+	for i, want := range expected {
+		got := players[i]
+		if got.ID != want.id || got.Seat != i || got.Status != want.status || got.Host != want.host {
+			t.Errorf("Seat %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+}
+
+func TestSetReady(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+
+	if !game.SetReady(playerIDs[0], true) || !game.LobbyPlayers()[0].Ready {
+		t.Error("Player should be marked ready")
+	}
+	if game.SetReady("stranger", true) {
+		t.Error("Unseated players can't be ready")
+	}
+
+	game.StartGame()
+	if game.SetReady(playerIDs[1], true) {
+		t.Error("Ready can only change before the game starts")
+	}
+}
+
+func TestDisconnectKeepsSeat(t *testing.T) {
+	game := createTestGame("test-game")
+	oldConn, newConn := newTestConn(t), newTestConn(t)
+	game.AddPlayer("alice", "Alice", oldConn)
+	game.AddPlayer("alice", "Alice", newConn)
+
+	// The old tab closing doesn't disconnect the seat's newer connection
+	game.Disconnect("alice", oldConn)
+	if game.LobbyPlayers()[0].Status != seatConnected {
+		t.Error("Closing a replaced connection should not disconnect the seat")
+	}
+
+	game.Disconnect("alice", newConn)
+	players := game.LobbyPlayers()
+	if len(players) != 1 || players[0].Status != seatDisconnected {
+		t.Errorf("Seat should stay, marked disconnected, got %+v", players)
+	}
+}
+
+func TestDisconnectTellsTheTable(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	aliceConn := newTestConn(t)
+	game.AddPlayer("alice", "Alice", aliceConn)
+	game.AddPlayer("bob", "Bob", newTestConn(t))
+
+	game.Disconnect("alice", aliceConn)
+	if countOfType(recorder.players["bob"], protocol.MsgPlayerDisconnected) != 1 {
+		t.Error("Expected the table told Alice disconnected")
+	}
+	state := game.getGameStateForPlayer("bob")
+	if !state.Players["alice"].Disconnected || state.Players["bob"].Disconnected {
+		t.Errorf("Expected only Alice shown disconnected, got %+v", state.Players)
+	}
+
+	// Away from the lobby long enough, the seat is given up
+	now := time.Now()
+	game.mu.Lock()
+	freed := game.reapVacantSeats(now.Add(time.Minute), time.Minute)
+	game.mu.Unlock()
+	if _, seated := game.Players["alice"]; freed != 1 || seated {
+		t.Errorf("Expected Alice's lobby seat freed, got %d", freed)
+	}
+	if _, seated := game.Players["bob"]; !seated {
+		t.Error("Bob is still connected and should keep the seat")
+	}
+}
+
+func TestHandleGamePlayers(t *testing.T) {
+	game := gameManager.CreateGame(context.Background())
+	game.AddPlayer("alice", "Alice", nil)
+
+	rec := httptest.NewRecorder()
+	handleGamePlayers(rec, httptest.NewRequest(http.MethodGet, "/games/"+game.ID+"/players", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	var body struct {
+		GameID  string        `json:"gameID"`
+		Players []LobbyPlayer `json:"players"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.GameID != game.ID || len(body.Players) != 1 || body.Players[0].Name != "Alice" {
+		t.Errorf("Unexpected lobby %+v", body)
+	}
+
+	for _, path := range []string{"/games/no-such-game/players", "/games/" + game.ID, "/games/" + game.ID + "/cards"} {
+		rec = httptest.NewRecorder()
+		handleGamePlayers(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", path, rec.Code)
+		}
+	}
+}
+
+func TestDuplicateNamesAreNumbered(t *testing.T) {
+	game := createTestGame("test-game")
+	game.AddPlayer("p1", "Sam", nil)
+	game.AddPlayer("p2", "sam", nil)
+	game.AddPlayer("p3", "Sam", nil)
+
+	if name := game.Players["p2"].Name; name != "sam 2" {
+		t.Errorf("Expected the second Sam to be numbered, got %q", name)
+	}
+	if name := game.Players["p3"].Name; name != "Sam 3" {
+		t.Errorf("Expected the third Sam to be numbered, got %q", name)
+	}
+
+	// Rejoining keeps the name a player already has
+	game.AddPlayer("p1", "Sam", nil)
+	if name := game.Players["p1"].Name; name != "Sam" {
+		t.Errorf("Rejoining shouldn't rename a player, got %q", name)
+	}
+}
+
+func TestRenameInLobby(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	game.AddPlayer("p1", "Sma", nil)
+	game.AddPlayer("p2", "Sam", nil)
+
+	if _, ok := game.Rename("p1", "   "); ok {
+		t.Error("A blank name should be refused")
+	}
+	if name, ok := game.Rename("p1", " Sam "); !ok || name != "Sam 2" {
+		t.Errorf("Expected the new name to be numbered, got %q, %v", name, ok)
+	}
+	if name, _ := game.Rename("p2", "SAM"); name != "SAM" {
+		t.Errorf("Changing the case of your own name shouldn't number it, got %q", name)
+	}
+	recorder.mu.Lock()
+	renames := countOfType(recorder.players["p2"], "playerRenamed")
+	recorder.mu.Unlock()
+	if renames != 2 {
+		t.Errorf("Expected both renames to be broadcast, got %d", renames)
+	}
+
+	game.StartGame()
+	if _, ok := game.Rename("p1", "Sammy"); ok {
+		t.Error("Names shouldn't change once the game has started")
+	}
+}
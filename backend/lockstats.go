@@ -0,0 +1,189 @@
+package main
+
+import (
+	"net/http"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// lockBuckets are the upper bounds of the lock timing histograms; a last bucket counts
+// everything slower
+var lockBuckets = []time.Duration{
+	10 * time.Microsecond,
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+}
+
+// gameMutex is the game's lock. With lock stats on, every write lock records how long the
+// caller waited for it and how long it was held, under the name of the method that took it.
+// Read locks aren't timed: readers don't block each other, and the cost worth measuring
+// is gameplay holding the write lock while it broadcasts.
+type gameMutex struct {
+	sync.RWMutex
+	op        string    // Who holds the write lock, while stats are on
+	heldSince time.Time // When they got it
+}
+
+func (m *gameMutex) Lock() {
+	if !lockStats.enabled.Load() {
+		m.RWMutex.Lock()
+		return
+	}
+	op := lockCaller()
+	start := time.Now()
+	m.RWMutex.Lock()
+	m.op, m.heldSince = op, time.Now()
+	lockStats.record(op, m.heldSince.Sub(start), false)
+}
+
+func (m *gameMutex) Unlock() {
+	if m.op != "" {
+		lockStats.record(m.op, time.Since(m.heldSince), true)
+		m.op = ""
+	}
+	m.RWMutex.Unlock()
+}
+
+// lockCaller names the function that called Lock, e.g. "(*Game).DrawCard"
+func lockCaller() string {
+	pc, _, _, ok := runtime.Caller(2)
+	if !ok {
+		return "unknown"
+	}
+	name := runtime.FuncForPC(pc).Name()
+	// Drop the package path; closures keep their "funcN" suffix so they can be told apart
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// lockHistogram is the distribution of one kind of lock timing
+type lockHistogram struct {
+	Count   int64   `json:"count"`
+	TotalMs float64 `json:"totalMs"`
+	MaxMs   float64 `json:"maxMs"`
+	Buckets []int64 `json:"buckets"` // Counts per lockBuckets bound, plus one for anything slower
+}
+
+func (h *lockHistogram) add(d time.Duration) {
+	if h.Buckets == nil {
+		h.Buckets = make([]int64, len(lockBuckets)+1)
+	}
+	h.Count++
+	ms := float64(d) / float64(time.Millisecond)
+	h.TotalMs += ms
+	if ms > h.MaxMs {
+		h.MaxMs = ms
+	}
+	i := sort.Search(len(lockBuckets), func(i int) bool { return d <= lockBuckets[i] })
+	h.Buckets[i]++
+}
+
+// lockOpStats is what one operation has cost
+type lockOpStats struct {
+	Op   string        `json:"op"`
+	Wait lockHistogram `json:"wait"` // Time spent waiting for the lock
+	Hold lockHistogram `json:"hold"` // Time spent holding it
+}
+
+// lockStatsCollector gathers lock timings across every game. It is off unless
+// PABLO_LOCK_STATS is set, and costs nothing but an atomic load while off.
+type lockStatsCollector struct {
+	enabled atomic.Bool
+	ops     map[string]*lockOpStats
+	since   time.Time
+	mu      sync.Mutex
+}
+
+var lockStats = &lockStatsCollector{ops: make(map[string]*lockOpStats), since: time.Now()}
+
+// record adds how long op waited for the lock, or with held set, how long it held it
+func (c *lockStatsCollector) record(op string, d time.Duration, held bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats, exists := c.ops[op]
+	if !exists {
+		stats = &lockOpStats{Op: op}
+		c.ops[op] = stats
+	}
+	if held {
+		stats.Hold.add(d)
+	} else {
+		stats.Wait.add(d)
+	}
+}
+
+// lockStatsReport is the admin view of the collected timings
+type lockStatsReport struct {
+	Enabled      bool          `json:"enabled"`
+	Since        time.Time     `json:"since"`
+	BucketBounds []float64     `json:"bucketBoundsMs"`
+	Ops          []lockOpStats `json:"ops"` // Longest total hold first
+}
+
+// Report copies the timings collected so far
+func (c *lockStatsCollector) Report() lockStatsReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := lockStatsReport{Enabled: c.enabled.Load(), Since: c.since, Ops: []lockOpStats{}}
+	for _, bound := range lockBuckets {
+		report.BucketBounds = append(report.BucketBounds, float64(bound)/float64(time.Millisecond))
+	}
+	for _, stats := range c.ops {
+		op := *stats
+		op.Wait.Buckets = append([]int64(nil), stats.Wait.Buckets...)
+		op.Hold.Buckets = append([]int64(nil), stats.Hold.Buckets...)
+		report.Ops = append(report.Ops, op)
+	}
+	sort.Slice(report.Ops, func(i, j int) bool {
+		if report.Ops[i].Hold.TotalMs != report.Ops[j].Hold.TotalMs {
+			return report.Ops[i].Hold.TotalMs > report.Ops[j].Hold.TotalMs
+		}
+		return report.Ops[i].Op < report.Ops[j].Op
+	})
+	return report
+}
+
+// Reset forgets everything collected, to start a fresh measurement
+func (c *lockStatsCollector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ops = make(map[string]*lockOpStats)
+	c.since = time.Now()
+}
+
+// handleAdminLockStats exposes the lock timings:
+//
+//	GET    /admin/lockstats  per-operation wait and hold histograms
+//	DELETE /admin/lockstats  start collecting afresh
+func handleAdminLockStats(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeAdminJSON(w, http.StatusOK, lockStats.Report())
+	case http.MethodDelete:
+		lockStats.Reset()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
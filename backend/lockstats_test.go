@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockStatsRecordsHoldTimes(t *testing.T) {
+	lockStats.enabled.Store(true)
+	lockStats.Reset()
+	defer lockStats.enabled.Store(false)
+
+	game := createTestGame("test-game")
+	game.SetBroadcaster(newRecordingBroadcaster())
+	addTestPlayers(game, 2)
+	game.mu.Lock()
+	time.Sleep(2 * time.Millisecond)
+	game.mu.Unlock()
+
+	ops := make(map[string]lockOpStats)
+	for _, op := range lockStats.Report().Ops {
+		ops[op.Op] = op
+	}
+	if ops["(*Game).AddPlayer"].Hold.Count != 2 {
+		t.Errorf("Expected two AddPlayer holds, got %+v", ops["(*Game).AddPlayer"])
+	}
+	held := ops["TestLockStatsRecordsHoldTimes"].Hold
+	if held.Count != 1 || held.MaxMs < 2 {
+		t.Errorf("Expected one hold of at least 2ms, got %+v", held)
+	}
+	var bucketed int64
+	for _, n := range held.Buckets {
+		bucketed += n
+	}
+	if bucketed != held.Count {
+		t.Errorf("Every hold should land in a bucket, got %v", held.Buckets)
+	}
+}
@@ -1,37 +1,126 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"math/rand"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"pablo/protocol"
 )
 
+// allowedOrigins are the pages allowed to open a WebSocket or call POST /privacy/delete
+// from a browser, as set by PABLO_ALLOWED_ORIGINS. When it's empty every origin is
+// allowed, which suits development but not a public server.
+var allowedOrigins map[string]bool
+
 var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all origins in development
-	},
+	CheckOrigin: originAllowed,
+}
+
+// originAllowed reports whether r carries no Origin, or one allowedOrigins lets in
+func originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	return origin == "" || len(allowedOrigins) == 0 || allowedOrigins[origin]
+}
+
+// parseAllowedOrigins reads a comma-separated list of origins such as
+// "https://pablo.example,http://localhost:3000"
+func parseAllowedOrigins(value string) map[string]bool {
+	origins := make(map[string]bool)
+	for _, origin := range strings.Split(value, ",") {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			origins[origin] = true
+		}
+	}
+	return origins
 }
 
 type Game struct {
 	ID                 string
 	Players            map[string]*Player
+	SeatOrder          []string // Player IDs in the order they sat down; turns rotate through this
+	Waiting            []string // Players who joined after the deal, in join order; seated and dealt in when the next round starts
 	Deck               []Card
-	DiscardPile        []Card
+	DiscardPile        []Card // Only the top discardPileLimit cards are kept
+	DiscardedBelow     int    // Cards trimmed off the bottom of DiscardPile
 	DrawnCards         map[string]*Card // Track drawn card per player
 	HasDrawnThisTurn   map[string]bool  // Track if player has drawn this turn
+	DrawnFromDiscard   map[string]bool  // Track if the drawn card was taken from the discard pile (must be swapped in)
 	PendingSpecialCard string           // Track if a special card was just discarded and needs activation
 	CurrentPlayer      string
-	Status             string // "waiting", "playing", "ended"
+	Status             string // "scheduled", "waiting", "playing", "paused", "ended"
+	ScheduledAt        time.Time // When a scheduled game's lobby opens; zero once open or if never scheduled
+	HostID             string // Player who controls the table (first to sit down)
 	PabloCalled        bool
 	PabloCaller        string
+	PabloBlind         bool   // The caller called on their first turn before doing anything else; scored double with the BlindPablo rule
+	GameOver           bool   // The mercy rule ended the game; no more rounds are dealt
+	ClaimWinner        string // Player whose reveal claim held up and ended the round; they win it
 	StackableCardIndex int    // Index of the last card in discard pile that can be stacked on (placed via end turn, not via stacking)
 	StackedSpecialCardPlayers []string // Players who stacked on a special card, waiting for original player to complete
 	PendingGive        *PendingGive // When non-nil, actor must give one of their cards to target at targetIndex
-	mu                 sync.RWMutex
+	PendingKingSwap    *PendingKingSwap // When non-nil, actor has peeked at target's card and must confirm or decline the swap
+	Config             GameConfig   // House rules chosen before the game starts
+	KickVotes          map[string]*KickVote // Open kick votes keyed by target player ID
+	KickCooldowns      map[string]time.Time // When each player may next open a kick vote
+	PauseVotes         map[string]bool      // Players asking to pause (or resume, while paused)
+	PausedAt           time.Time            // When the game was paused; zero while not paused
+	RejoinedSincePause map[string]bool      // Players who re-joined while paused; all of them back resumes play
+	Muted              map[string]bool      // Players the host has muted; their chat is dropped
+	Handicaps          map[string]int       // Points the host adds to a player's score every round; negative ones help
+	ShadowMuted        map[string]bool      // Players a moderator has shadow-muted; only they see their own chat
+	Locked             bool                 // Set by the host to keep new players from joining
+	Adjourned          bool                 // Saved to disk to be continued later; seats are reclaimed by session token
+	adjournStore       *adjournStore
+	Observers          map[string]*websocket.Conn // Authorized organizers watching with every hand revealed
+	Spectators         map[string]*Spectator      // Watchers who see only public information
+	Bots               map[string]*botBrain       // Seats played by the server, keyed by player ID
+	DailyDate          string                     // Set for daily challenge games; the human's score goes on that day's leaderboard
+	Puzzle             *puzzleState               // Set for puzzle games; tracks the solver's turns and result
+	Tutorial           *tutorialState             // Set for tutorial games; tracks the learner's place in the script
+	seatHolds          map[string]time.Time       // Friend codes with an accepted invite -> when their held seat frees up
+	RoundStartedAt     time.Time                  // When the current round was dealt
+	match              matchStats                 // Scores and stats kept across rounds for the gameOver summary
+	botsRunning        bool
+	eventLog           []GameEvent        // Everything that happened, oldest first, for reports and audits
+	chatLog            []AuditChatLine    // Chat lines sent at the table, oldest first
+	connectionLog      []AuditConnection  // Seats connecting and disconnecting, oldest first
+	stackSpam          map[string]*stackSpamRecord // Failed stacks, strikes and cooldowns per player
+	stackChances       int // How many cards have been open to stacking this game; numbers each chance to stack
+	stackSitOut        map[string]int // Players sitting out stacking after a failure, and the last chance they sit out
+	stackGrace         *stackGrace    // The discard last stacked on, for stacks that arrive late through lag
+	stackWindow        *stackWindow   // At tables with a stack window, the clock on the last chance to stack
+	deal               *dealCommitment // At FairShuffle tables, the commitment to this round's deal
+	turnTimer          *turnTimer      // The clock on the current turn at tables with a turn time limit
+	giveTimer          *giveTimer      // The clock on the pending give at tables with a turn time limit
+	peeking            *peekPhase      // At InitialPeek tables, set from the deal until the first turn
+	claimPenalties     map[string]int // Points for false reveal claims, added at the end of the round
+	actedThisRound     map[string]bool // Players who have made any move this round
+	revealed           map[string]bool // During a staged reveal, the hands turned over so far; nil otherwise
+	absent             map[string]time.Time // Players whose connection dropped and who haven't come back yet, and since when
+	vacant             map[string]bool      // Seats given up on after being absent too long; skipped in turn order until they come back
+	hibernated         bool // Moved to disk and dropped from the manager; a lookup loads a fresh copy
+	checkpoints        *checkpointer // Saves the game after changes so it survives a restart; nil when off
+	rng                *rand.Rand // All shuffles for this game come from here
+	ctx                context.Context // Background goroutines (bots) stop when this is done
+	broadcaster        Broadcaster // Delivers messages; writes to the seats' connections unless replaced
+	published          atomic.Pointer[publicView] // What spectators and the REST API read, swapped by publish
+	streams            *streamFeed // Delayed feeds for players streaming the game
+	catchUp            *catchUpBuffer // Recent events sent to each seat, replayed to clients that reconnect
+	mu                 gameMutex
 }
 
 type PendingGive struct {
@@ -39,13 +128,24 @@ type PendingGive struct {
 	TargetPlayerID string `json:"targetPlayerID"`
 	TargetIndex    int    `json:"targetIndex"`
 }
+
+// PendingKingSwap is the second step of the black king power: the actor has already
+// seen the target card and now decides whether to swap one of their own cards with it.
+type PendingKingSwap struct {
+	ActorID        string `json:"actorID"`
+	TargetPlayerID string `json:"targetPlayerID"`
+	TargetIndex    int    `json:"targetIndex"`
+}
+
 type Player struct {
-	ID    string
-	Name  string
-	Cards []Card // Changed to slice to support variable number of cards
-	Conn  *websocket.Conn
-	Ready bool
-	Score int
+	ID           string
+	Name         string
+	Cards        []Card // Changed to slice to support variable number of cards
+	Conn         *websocket.Conn
+	Ready        bool
+	Score        int
+	Total        int // Sum of their round scores so far this game
+	SessionToken string // Secret handed to the player at join; presenting it reclaims the seat
 }
 
 type Card struct {
@@ -56,50 +156,71 @@ type Card struct {
 
 type Message struct {
 	Type    string      `json:"type"`
+	GameID  string      `json:"gameID,omitempty"` // The game a message is about, for connections in more than one
 	Payload interface{} `json:"payload"`
+	Seq     uint64      `json:"seq,omitempty"` // Set on events a reconnecting client can be caught up on; see catchUpBuffer
 }
 
+// NewGame creates a game with a seed from crypto/rand, so games created at the same
+// moment don't share a deal and nobody can work the deck out from the clock.
 func NewGame(id string) *Game {
+	return NewGameWithSeed(id, newSeed())
+}
+
+// NewGameWithSeed creates a game whose shuffles all come from the given seed,
+// so two games with the same seed and seating get the same deal.
+func NewGameWithSeed(id string, seed int64) *Game {
 	game := &Game{
 		ID:                 id,
 		Players:            make(map[string]*Player),
+		SeatOrder:          []string{},
 		Deck:               createDeck(),
 		DiscardPile:        []Card{},
 		DrawnCards:         make(map[string]*Card),
 		HasDrawnThisTurn:   make(map[string]bool),
+		DrawnFromDiscard:   make(map[string]bool),
 		PendingSpecialCard: "",
-		Status:             "waiting",
+		Status:             protocol.StatusWaiting,
 		CurrentPlayer:      "",
 		PabloCalled:        false,
 		PabloCaller:        "",
 		StackableCardIndex: -1, // -1 means no stackable card
 		StackedSpecialCardPlayers: []string{},
 		PendingGive:        nil,
-	}
-	shuffleDeck(game.Deck)
+		Config:             DefaultGameConfig(),
+		KickVotes:          make(map[string]*KickVote),
+		KickCooldowns:      make(map[string]time.Time),
+		PauseVotes:         make(map[string]bool),
+		RejoinedSincePause: make(map[string]bool),
+		Muted:              make(map[string]bool),
+		Handicaps:          make(map[string]int),
+		ShadowMuted:        make(map[string]bool),
+		Observers:          make(map[string]*websocket.Conn),
+		Spectators:         make(map[string]*Spectator),
+		Bots:               make(map[string]*botBrain),
+		stackSpam:          make(map[string]*stackSpamRecord),
+		stackSitOut:        make(map[string]int),
+		claimPenalties:     make(map[string]int),
+		actedThisRound:     make(map[string]bool),
+		absent:             make(map[string]time.Time),
+		vacant:             make(map[string]bool),
+		rng:                rand.New(rand.NewSource(seed)),
+		ctx:                context.Background(),
+		streams:            newStreamFeed(),
+		catchUp:            newCatchUpBuffer(),
+	}
+	game.broadcaster = connBroadcaster{g: game}
+	shuffleDeck(game.rng, game.Deck)
+	game.publish()
 	return game
 }
 
 func createDeck() []Card {
-	suits := []string{"hearts", "diamonds", "clubs", "spades"}
-	ranks := []string{"A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K"}
-
-	deck := make([]Card, 0, 52)
-	for _, suit := range suits {
-		for _, rank := range ranks {
-			deck = append(deck, Card{
-				Suit:   suit,
-				Rank:   rank,
-				FaceUp: false,
-			})
-		}
-	}
-	return deck
+	return (*DeckDefinition)(nil).cards()
 }
 
-func shuffleDeck(deck []Card) {
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(deck), func(i, j int) {
+func shuffleDeck(rng *rand.Rand, deck []Card) {
+	rng.Shuffle(len(deck), func(i, j int) {
 		deck[i], deck[j] = deck[j], deck[i]
 	})
 }
@@ -108,34 +229,93 @@ func (g *Game) AddPlayer(id, name string, conn *websocket.Conn) bool {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	if len(g.Players) >= 6 {
+	// Joining again with a seated ID is a reconnect: keep the seat and hand
+	if player, exists := g.Players[id]; exists {
+		// Adjourned seats can only be reclaimed with the session token
+		if g.Adjourned {
+			return false
+		}
+		player.Name = name
+		g.bindConn(player, conn)
+		g.noteRejoin(id)
+		g.broadcastLobby()
+		return true
+	}
+
+	// Seats held for friends who accepted an invite aren't free, but a locked table still
+	// lets the friend a seat is held for in
+	code := presence.CodeOf(conn)
+	held := g.heldSeats(code, time.Now())
+	_, holdsSeat := g.seatHolds[code]
+	if len(g.Players)+held >= maxSeats || (g.Locked && !holdsSeat) {
+		return false
+	}
+	// League tables only seat the pair drawn at them
+	if !leagues.MayJoin(g.ID, name) {
 		return false
 	}
+	delete(g.seatHolds, code)
+
+	// Once the first deal is out, joiners watch until the next one deals them in
+	dealtOut := g.Status == protocol.StatusPlaying || g.Status == protocol.StatusPaused || g.Status == protocol.StatusEnded
+	var cards []Card
+	if dealtOut {
+		g.Waiting = append(g.Waiting, id)
+	} else {
+		g.SeatOrder = append(g.SeatOrder, id)
+		cards = make([]Card, 4)
+	}
+	if g.HostID == "" {
+		g.HostID = id
+	}
+	name = g.uniqueName(id, name)
 
 	g.Players[id] = &Player{
 		ID:    id,
 		Name:  name,
-		Cards: make([]Card, 4),
+		Cards: cards,
 		Conn:  conn,
 		Ready: false,
 		Score: 0,
+		SessionToken: newSessionToken(),
 	}
+	recordSeat(g.Players[id].SessionToken, seatRecord{GameID: g.ID, PlayerID: id, Name: name})
+	joined := map[string]interface{}{
+		"name": name,
+		"seat": len(g.SeatOrder) + len(g.Waiting) - 1,
+	}
+	if dealtOut {
+		joined["waiting"] = true
+	}
+	g.emit(protocol.EventPlayerJoined, id, joined)
+	g.broadcastLobby()
 	return true
 }
 
+// HoldsSeat reports whether conn is the connection currently bound to a seated player
+func (g *Game) HoldsSeat(playerID string, conn *websocket.Conn) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	player, exists := g.Players[playerID]
+	return exists && conn != nil && player.Conn == conn
+}
+
 func (g *Game) StartGame() {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	if len(g.Players) < 2 {
+	if len(g.Players) < 2 || g.Status == protocol.StatusScheduled || g.GameOver || g.revealed != nil {
 		return
 	}
 
-	g.Status = "playing"
+	g.Status = protocol.StatusPlaying
+	g.seatWaiting()
+	g.commitDeal()
 
-	// Deal 4 cards to each player
+	// Deal 4 cards to each player in seat order
 	// Ensure each player has exactly 4 cards
-	for playerID := range g.Players {
+	for _, playerID := range g.SeatOrder {
 		// Reset to exactly 4 empty cards first
 		g.Players[playerID].Cards = make([]Card, 4)
 		for i := 0; i < 4; i++ {
@@ -146,42 +326,47 @@ func (g *Game) StartGame() {
 		}
 	}
 
-	// Set first player
-	firstPlayer := ""
-	for id := range g.Players {
-		firstPlayer = id
-		break
+	// First seat starts, or the first one still occupied
+	g.CurrentPlayer = g.SeatOrder[0]
+	if g.vacant[g.CurrentPlayer] {
+		g.CurrentPlayer = g.nextSeatAfter(g.CurrentPlayer)
+	}
+	g.roundStarted()
+	if g.Config.InitialPeek {
+		g.startPeekPhase()
+	} else {
+		g.emitTurnStarted()
 	}
-	g.CurrentPlayer = firstPlayer
 
 	g.broadcastGameState()
+	g.scheduleBots()
 }
 
-func (g *Game) DrawCard(playerID string) bool {
+func (g *Game) DrawCard(playerID string) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
 	if g.CurrentPlayer != playerID {
-		return false
+		return ErrNotYourTurn
 	}
 
 	// Block draws while a pending give is active
 	if g.PendingGive != nil {
-		return false
+		return ErrPendingGive
 	}
 
 	// If the deck is empty, automatically end the round and game.
 	if len(g.Deck) == 0 {
 		// Only end the round if we're still in a playing state
-		if g.Status == "playing" {
+		if g.Status == protocol.StatusPlaying {
 			g.EndRound()
 		}
-		return false
+		return ErrEmptyDeck
 	}
 
 	// Can only draw one card per turn - check if they've already drawn this turn
 	if g.HasDrawnThisTurn[playerID] {
-		return false
+		return ErrAlreadyDrawn
 	}
 
 	// Draw card and show it to the player
@@ -191,70 +376,130 @@ func (g *Game) DrawCard(playerID string) bool {
 	g.DrawnCards[playerID] = &card
 	g.HasDrawnThisTurn[playerID] = true // Mark that they've drawn this turn
 
+	g.emitAction(playerID, protocol.MsgDrawCard, nil)
+	g.broadcastPlayerDrew(playerID, "deck")
 	g.broadcastGameState()
-	return true
+	return nil
 }
 
-func (g *Game) DiscardDrawnCard(playerID string) bool {
+// DrawFromDiscard takes the visible top card of the discard pile instead of a blind deck card.
+// Only available when the table enabled AllowDrawFromDiscard. The taken card must be swapped
+// into the player's hand; it cannot be discarded straight back onto the pile.
+func (g *Game) DrawFromDiscard(playerID string) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	if !g.Config.AllowDrawFromDiscard {
+		return ErrRuleDisabled
+	}
+
 	if g.CurrentPlayer != playerID {
-		return false
+		return ErrNotYourTurn
+	}
+	if g.Status != protocol.StatusPlaying {
+		return ErrNotPlaying
 	}
 
 	if g.PendingGive != nil {
-		return false
+		return ErrPendingGive
+	}
+
+	// A special card still waiting to be used belongs to whoever discarded it
+	if g.PendingSpecialCard != "" {
+		return ErrPendingPower
+	}
+
+	if g.HasDrawnThisTurn[playerID] {
+		return ErrAlreadyDrawn
+	}
+
+	if len(g.DiscardPile) == 0 {
+		return ErrEmptyDiscard
+	}
+
+	card := g.DiscardPile[len(g.DiscardPile)-1]
+	g.DiscardPile = g.DiscardPile[:len(g.DiscardPile)-1]
+	card.FaceUp = true
+	g.DrawnCards[playerID] = &card
+	g.HasDrawnThisTurn[playerID] = true
+	g.DrawnFromDiscard[playerID] = true
+
+	// The card underneath was already covered once, so it can't be stacked on
+	g.StackableCardIndex = -1
+
+	g.emitAction(playerID, protocol.MsgDrawFromDiscard, map[string]interface{}{"card": card})
+	g.broadcastPlayerDrew(playerID, "discard")
+	g.broadcastGameState()
+	return nil
+}
+
+func (g *Game) DiscardDrawnCard(playerID string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.CurrentPlayer != playerID {
+		return ErrNotYourTurn
+	}
+
+	if g.PendingGive != nil {
+		return ErrPendingGive
 	}
 
 	drawnCard, hasDrawnCard := g.DrawnCards[playerID]
 	if !hasDrawnCard || drawnCard == nil {
-		return false
+		return ErrNoDrawnCard
+	}
+
+	// A card taken from the discard pile must be swapped into the hand
+	if g.DrawnFromDiscard[playerID] {
+		return ErrMustSwap
 	}
 
 	// Add drawn card to discard pile (face up so everyone can see)
 	card := *drawnCard
 	card.FaceUp = true
-	g.DiscardPile = append(g.DiscardPile, card)
+	g.pushDiscard(card)
 
 	// Clear drawn card
 	delete(g.DrawnCards, playerID)
 
 	// Mark this new card as stackable (placed via discard, not via stacking)
-	g.StackableCardIndex = len(g.DiscardPile) - 1
+	g.markStackable()
+	g.emitAction(playerID, protocol.MsgDiscardDrawnCard, map[string]interface{}{"card": card})
 
 	// If it's a special card, mark it as pending activation
-	if card.Rank == "7" || card.Rank == "8" || card.Rank == "9" {
+	if g.isSpecialCard(card) {
 		g.PendingSpecialCard = card.Rank
 		g.broadcastGameState()
-		return true
+		return nil
 	}
 
 	// Clear any pending special card if a non-special card was discarded
 	g.PendingSpecialCard = ""
 	g.broadcastGameState()
-	return true
+	return nil
 }
 
-func (g *Game) SwapCard(playerID string, cardIndex int) bool {
+func (g *Game) SwapCard(playerID string, cardIndex int) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
 	if g.CurrentPlayer != playerID {
-		return false
+		return ErrNotYourTurn
 	}
 
 	if g.PendingGive != nil {
-		return false
+		return ErrPendingGive
 	}
 
 	drawnCard, hasDrawnCard := g.DrawnCards[playerID]
 	if !hasDrawnCard || drawnCard == nil {
-		return false
+		return ErrNoDrawnCard
 	}
 
-	if cardIndex < 0 || cardIndex >= len(g.Players[playerID].Cards) {
-		return false
+	// A slot emptied by stacking or a power has no card to swap out
+	if cardIndex < 0 || cardIndex >= len(g.Players[playerID].Cards) || g.Players[playerID].Cards[cardIndex].Rank == "" {
+		return ErrInvalidCardIndex
 	}
 
 	// Swap the drawn card with player's card
@@ -264,335 +509,753 @@ func (g *Game) SwapCard(playerID string, cardIndex int) bool {
 
 	// Add old card to discard pile (face up so everyone can see)
 	oldCard.FaceUp = true
-	g.DiscardPile = append(g.DiscardPile, oldCard)
+	g.pushDiscard(oldCard)
 
 	// Clear drawn card
 	delete(g.DrawnCards, playerID)
+	delete(g.DrawnFromDiscard, playerID)
 
 	// Mark this new card as stackable (placed via swap, not via stacking)
-	g.StackableCardIndex = len(g.DiscardPile) - 1
+	g.markStackable()
+	g.emitAction(playerID, protocol.MsgSwapCard, map[string]interface{}{
+		"cardIndex": cardIndex,
+		"discarded": oldCard,
+	})
 
 	// If the discarded card is special, mark it as pending activation
-	if oldCard.Rank == "7" || oldCard.Rank == "8" || oldCard.Rank == "9" {
+	if g.isSpecialCard(oldCard) {
 		g.PendingSpecialCard = oldCard.Rank
 		g.broadcastGameState()
-		return true
+		return nil
 	}
 
 	// Clear any pending special card if a non-special card was discarded
 	g.PendingSpecialCard = ""
 	g.broadcastGameState()
-	return true
+	return nil
+}
+
+// SwapMultipleCards swaps the drawn card in for two or more of the player's own cards that the
+// player declares share the same rank. All declared cards go to the discard pile and the hand
+// shrinks; the drawn card takes the first declared slot. If the declared cards don't actually
+// match, they stay in the hand, the drawn card is discarded and the player takes a penalty card.
+func (g *Game) SwapMultipleCards(playerID string, cardIndices []int) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.Config.AllowMultiDiscard {
+		return ErrRuleDisabled
+	}
+
+	if g.CurrentPlayer != playerID {
+		return ErrNotYourTurn
+	}
+
+	if g.PendingGive != nil {
+		return ErrPendingGive
+	}
+
+	drawnCard, hasDrawnCard := g.DrawnCards[playerID]
+	if !hasDrawnCard || drawnCard == nil {
+		return ErrNoDrawnCard
+	}
+
+	if len(cardIndices) < 2 {
+		return fmt.Errorf("%w: declare at least two cards", ErrInvalidCard)
+	}
+
+	player := g.Players[playerID]
+	seen := make(map[int]bool)
+	for _, idx := range cardIndices {
+		if idx < 0 || idx >= len(player.Cards) || player.Cards[idx].Rank == "" {
+			return fmt.Errorf("%w (slot %d)", ErrInvalidCardIndex, idx)
+		}
+		if seen[idx] {
+			return fmt.Errorf("%w: card %d was declared twice", ErrInvalidCard, idx)
+		}
+		seen[idx] = true
+	}
+
+	rank := player.Cards[cardIndices[0]].Rank
+	matches := true
+	for _, idx := range cardIndices[1:] {
+		if player.Cards[idx].Rank != rank {
+			matches = false
+			break
+		}
+	}
+
+	if !matches {
+		// Declared cards stay put; the drawn card is discarded without its power. One taken
+		// from the discard pile just goes back, so it isn't a fresh card to stack on.
+		card := *drawnCard
+		card.FaceUp = true
+		g.pushDiscard(card)
+		if !g.DrawnFromDiscard[playerID] {
+			g.markStackable()
+		}
+		delete(g.DrawnCards, playerID)
+		delete(g.DrawnFromDiscard, playerID)
+		g.PendingSpecialCard = ""
+
+		if len(g.Deck) > 0 {
+			penaltyCard := g.Deck[0]
+			g.Deck = g.Deck[1:]
+			penaltyCard.FaceUp = false
+			player.Cards = append(player.Cards, penaltyCard)
+		}
+
+		g.emitAction(playerID, protocol.MsgSwapMultipleCards, map[string]interface{}{
+			"cardIndices": cardIndices,
+			"success":     false,
+		})
+		g.broadcastGameState()
+		return fmt.Errorf("%w: penalty card added", ErrCardMismatch)
+	}
+
+	// Move every declared card to the discard pile (face up so everyone can see)
+	var lastDiscarded Card
+	for _, idx := range cardIndices {
+		lastDiscarded = player.Cards[idx]
+		lastDiscarded.FaceUp = true
+		g.pushDiscard(lastDiscarded)
+		// Leave an empty placeholder so other cards don't shift
+		player.Cards[idx] = Card{Suit: "", Rank: "", FaceUp: false}
+	}
+
+	// Drawn card takes the first declared slot
+	player.Cards[cardIndices[0]] = *drawnCard
+	player.Cards[cardIndices[0]].FaceUp = false
+
+	delete(g.DrawnCards, playerID)
+	delete(g.DrawnFromDiscard, playerID)
+
+	// The top discard was placed via swap, so it can be stacked on
+	g.markStackable()
+
+	if g.isSpecialCard(lastDiscarded) {
+		g.PendingSpecialCard = lastDiscarded.Rank
+	} else {
+		g.PendingSpecialCard = ""
+	}
+
+	g.emitAction(playerID, protocol.MsgSwapMultipleCards, map[string]interface{}{
+		"cardIndices": cardIndices,
+		"success":     true,
+	})
+	g.broadcastGameState()
+	return nil
 }
 
 // UseSpecialCardFromDiscard is called when a special card is placed in discard pile
-func (g *Game) UseSpecialCardFromDiscard(playerID string, cardRank string, params map[string]interface{}) bool {
+func (g *Game) UseSpecialCardFromDiscard(playerID string, cardRank string, params map[string]interface{}) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
 	if g.CurrentPlayer != playerID {
-		return false
+		return ErrNotYourTurn
 	}
 
 	if g.PendingGive != nil {
-		return false
+		return ErrPendingGive
 	}
 
 	// Check if the top card of discard pile is the special card
 	if len(g.DiscardPile) == 0 {
-		return false
+		return ErrNoPendingPower
 	}
 	topCard := g.DiscardPile[len(g.DiscardPile)-1]
 	if topCard.Rank != cardRank {
-		return false
+		return ErrNoPendingPower
 	}
 
 	// Also check pending flag for consistency
 	if g.PendingSpecialCard != cardRank {
-		return false
+		return ErrNoPendingPower
 	}
 
-	switch cardRank {
-	case "7": // Look at one of your own cards
-		if targetIndex, ok := params["targetIndex"].(float64); ok {
-			idx := int(targetIndex)
-			if idx >= 0 && idx < 4 {
-				card := g.Players[playerID].Cards[idx]
-				g.sendToPlayer(playerID, Message{
-					Type: "cardRevealed",
-					Payload: map[string]interface{}{
-						"index": idx,
-						"card":  card,
-					},
-				})
-			}
+	// The king power is already waiting on a swap decision
+	if g.PendingKingSwap != nil {
+		return ErrPendingPower
+	}
+
+	switch g.cardPower(topCard) {
+	case protocol.PowerPeekOwn: // Look at one of your own cards
+		player, idx, err := g.cardSlot(playerID, params["targetIndex"])
+		if err != nil {
+			return err
 		}
+		g.sendToPlayer(playerID, Message{
+			Type: protocol.MsgCardRevealed,
+			Payload: map[string]interface{}{
+				"index": idx,
+				"card":  player.Cards[idx],
+			},
+		})
 
-	case "8": // Look at someone else's card
-		if targetPlayerID, ok := params["targetPlayerID"].(string); ok {
-			if targetIndex, ok2 := params["targetIndex"].(float64); ok2 {
-				idx := int(targetIndex)
-				if targetPlayer, exists := g.Players[targetPlayerID]; exists && idx >= 0 && idx < 4 {
-					card := targetPlayer.Cards[idx]
-					g.sendToPlayer(playerID, Message{
-						Type: "cardRevealed",
-						Payload: map[string]interface{}{
-							"playerID": targetPlayerID,
-							"index":    idx,
-							"card":     card,
-						},
-					})
-				}
-			}
+	case protocol.PowerPeekOther: // Look at someone else's card
+		targetPlayerID, _ := params["targetPlayerID"].(string)
+		targetPlayer, idx, err := g.cardSlot(targetPlayerID, params["targetIndex"])
+		if err != nil {
+			return err
 		}
+		g.sendToPlayer(playerID, Message{
+			Type: protocol.MsgCardRevealed,
+			Payload: map[string]interface{}{
+				"playerID": targetPlayerID,
+				"index":    idx,
+				"card":     targetPlayer.Cards[idx],
+			},
+		})
 
-	case "9": // Swap any two cards on the table
-		if player1ID, ok := params["player1ID"].(string); ok {
-			if card1Index, ok2 := params["card1Index"].(float64); ok2 {
-				if player2ID, ok3 := params["player2ID"].(string); ok3 {
-					if card2Index, ok4 := params["card2Index"].(float64); ok4 {
-						idx1 := int(card1Index)
-						idx2 := int(card2Index)
-						if p1, exists1 := g.Players[player1ID]; exists1 && idx1 >= 0 && idx1 < len(p1.Cards) {
-							if p2, exists2 := g.Players[player2ID]; exists2 && idx2 >= 0 && idx2 < len(p2.Cards) {
-								// Capture card data BEFORE swap for animation
-								card1Before := p1.Cards[idx1]
-								card2Before := p2.Cards[idx2]
-								
-								// Broadcast swap event BEFORE swapping so frontend can capture original positions
-								g.broadcastSwapEventWithCards(player1ID, idx1, card1Before, player2ID, idx2, card2Before)
-								
-								// Swap the cards
-								p1.Cards[idx1], p2.Cards[idx2] = p2.Cards[idx2], p1.Cards[idx1]
-							}
-						}
-					}
-				}
-			}
+	case protocol.PowerSwap: // Swap any two cards on the table
+		player1ID, _ := params["player1ID"].(string)
+		p1, idx1, err := g.cardSlot(player1ID, params["card1Index"])
+		if err != nil {
+			return err
+		}
+		player2ID, _ := params["player2ID"].(string)
+		p2, idx2, err := g.cardSlot(player2ID, params["card2Index"])
+		if err != nil {
+			return err
 		}
-	}
 
-	// Clear the pending special card after use
-	g.PendingSpecialCard = ""
-	
-	// Check if there are players who stacked on this special card
-	// They should get the special card power now
-	if len(g.StackedSpecialCardPlayers) > 0 {
-		// Get the first player who stacked (FIFO queue)
-		stackedPlayerID := g.StackedSpecialCardPlayers[0]
-		g.StackedSpecialCardPlayers = g.StackedSpecialCardPlayers[1:]
-		
-		// Set them as the current player and reactivate the special card
-		// This will allow them to use the special card power
-		if _, exists := g.Players[stackedPlayerID]; exists {
-			g.CurrentPlayer = stackedPlayerID
-			g.PendingSpecialCard = cardRank
-			g.broadcastGameState()
-			return true
+		// Broadcast swap event BEFORE swapping so frontend can capture original positions
+		g.broadcastSwapEventWithCards(player1ID, idx1, p1.Cards[idx1], player2ID, idx2, p2.Cards[idx2])
+
+		// Swap the cards
+		p1.Cards[idx1], p2.Cards[idx2] = p2.Cards[idx2], p1.Cards[idx1]
+
+	case protocol.PowerKingSwap: // Peek at an opponent's card, then decide whether to swap
+		targetPlayerID, _ := params["targetPlayerID"].(string)
+		if targetPlayerID == playerID {
+			return fmt.Errorf("%w: the king looks at an opponent's card", ErrInvalidTarget)
+		}
+		targetPlayer, idx, err := g.cardSlot(targetPlayerID, params["targetIndex"])
+		if err != nil {
+			return err
+		}
+
+		g.sendToPlayer(playerID, Message{
+			Type: protocol.MsgKingPeek,
+			Payload: map[string]interface{}{
+				"playerID": targetPlayerID,
+				"index":    idx,
+				"card":     targetPlayer.Cards[idx],
+			},
+		})
+
+		// Power stays pending until the actor confirms or declines the swap
+		g.PendingKingSwap = &PendingKingSwap{
+			ActorID:        playerID,
+			TargetPlayerID: targetPlayerID,
+			TargetIndex:    idx,
 		}
+		g.emitAction(playerID, "useSpecialCard", map[string]interface{}{"cardRank": cardRank, "params": params})
+		g.broadcastGameState()
+		return nil
 	}
-	
+
+	g.emitAction(playerID, "useSpecialCard", map[string]interface{}{"cardRank": cardRank, "params": params})
+	g.finishSpecialCard()
 	g.broadcastGameState()
-	return true
+	return nil
 }
 
-func (g *Game) SkipSpecialCard(playerID string) {
+func (g *Game) SkipSpecialCard(playerID string) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
 	if g.CurrentPlayer != playerID {
-		return
+		return ErrNotYourTurn
 	}
 
 	// Can't skip while a give is pending
 	if g.PendingGive != nil {
-		return
+		return ErrPendingGive
+	}
+
+	// Skipping while deciding on a king swap counts as declining it
+	g.PendingKingSwap = nil
+
+	g.emitAction(playerID, protocol.MsgSkipSpecialCard, nil)
+	g.finishSpecialCard()
+	g.broadcastGameState()
+	return nil
+}
+
+// ConfirmKingSwap completes the black king power by swapping one of the actor's
+// cards with the opponent card they peeked at.
+func (g *Game) ConfirmKingSwap(playerID string, ownIndex int) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pks := g.PendingKingSwap
+	if pks == nil || pks.ActorID != playerID || g.CurrentPlayer != playerID {
+		return ErrNoPendingPower
 	}
 
-	// Clear the pending special card
+	actor, okA := g.Players[pks.ActorID]
+	target, okT := g.Players[pks.TargetPlayerID]
+	if !okA || !okT {
+		return ErrNotInGame
+	}
+	if ownIndex < 0 || ownIndex >= len(actor.Cards) || actor.Cards[ownIndex].Rank == "" {
+		return ErrInvalidCardIndex
+	}
+	if pks.TargetIndex < 0 || pks.TargetIndex >= len(target.Cards) {
+		return ErrInvalidTarget
+	}
+
+	g.broadcastSwapEventWithCards(pks.ActorID, ownIndex, actor.Cards[ownIndex], pks.TargetPlayerID, pks.TargetIndex, target.Cards[pks.TargetIndex])
+	actor.Cards[ownIndex], target.Cards[pks.TargetIndex] = target.Cards[pks.TargetIndex], actor.Cards[ownIndex]
+
+	g.emitAction(playerID, protocol.MsgConfirmKingSwap, map[string]interface{}{
+		"ownIndex":       ownIndex,
+		"targetPlayerID": pks.TargetPlayerID,
+		"targetIndex":    pks.TargetIndex,
+	})
+	g.PendingKingSwap = nil
+	g.finishSpecialCard()
+	g.broadcastGameState()
+	return nil
+}
+
+// DeclineKingSwap completes the black king power without swapping.
+func (g *Game) DeclineKingSwap(playerID string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.PendingKingSwap == nil || g.PendingKingSwap.ActorID != playerID || g.CurrentPlayer != playerID {
+		return ErrNoPendingPower
+	}
+
+	g.emitAction(playerID, protocol.MsgDeclineKingSwap, nil)
+	g.PendingKingSwap = nil
+	g.finishSpecialCard()
+	g.broadcastGameState()
+	return nil
+}
+
+// cardSlot finds the card a move names by player and slot, checking the player is dealt
+// in and the slot holds a card. rawIndex is the slot as it came in the message.
+// Caller must hold g.mu.
+func (g *Game) cardSlot(playerID string, rawIndex interface{}) (*Player, int, error) {
+	player, exists := g.Players[playerID]
+	if !exists || g.isWaiting(playerID) {
+		return nil, 0, ErrTargetNotFound
+	}
+	idx, ok := slotIndex(rawIndex)
+	if !ok || idx < 0 || idx >= len(player.Cards) || player.Cards[idx].Rank == "" {
+		return nil, 0, ErrInvalidCardIndex
+	}
+	return player, idx, nil
+}
+
+// slotIndex reads a slot number from a message payload, where JSON numbers are float64s
+func slotIndex(raw interface{}) (int, bool) {
+	switch v := raw.(type) {
+	case float64:
+		return int(v), v == float64(int(v))
+	case int:
+		return v, true
+	}
+	return 0, false
+}
+
+// finishSpecialCard clears the special card that was just used (or skipped) and, if players
+// stacked on it, hands the turn to the first of them so they get to use the same power.
+func (g *Game) finishSpecialCard() {
 	g.PendingSpecialCard = ""
-	
+
 	// Check if there are players who stacked on this special card
 	// They should get the special card power now
 	if len(g.StackedSpecialCardPlayers) > 0 {
 		// Get the first player who stacked (FIFO queue)
 		stackedPlayerID := g.StackedSpecialCardPlayers[0]
 		g.StackedSpecialCardPlayers = g.StackedSpecialCardPlayers[1:]
-		
+
 		// Set them as the current player and reactivate the special card
 		// This will allow them to use the special card power
 		if _, exists := g.Players[stackedPlayerID]; exists {
 			g.CurrentPlayer = stackedPlayerID
+			g.emitTurnStarted()
 			// Get the special card rank from the discard pile
 			if len(g.DiscardPile) > 0 {
 				topCard := g.DiscardPile[len(g.DiscardPile)-1]
-				if topCard.Rank == "7" || topCard.Rank == "8" || topCard.Rank == "9" {
+				if g.isSpecialCard(topCard) {
 					g.PendingSpecialCard = topCard.Rank
 				}
 			}
-			g.broadcastGameState()
-			return
 		}
 	}
-	
-	g.broadcastGameState()
 }
 
-func (g *Game) CallPablo(playerID string) {
+// isSpecialCard reports whether a card activates a power when it lands on the discard pile.
+// 7, 8 and 9 do in a standard deck, and black kings when the table plays the king
+// peek-and-swap rule; a custom deck says which do.
+func (g *Game) isSpecialCard(card Card) bool {
+	return g.cardPower(card) != ""
+}
+
+func (g *Game) CallPablo(playerID string) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	if g.Status != "playing" || g.PabloCalled {
-		return
+	if g.Status != protocol.StatusPlaying {
+		return ErrNotPlaying
+	}
+	if g.isWaiting(playerID) {
+		return fmt.Errorf("%w: you're dealt in at the next round", ErrNotInGame)
+	}
+	if g.PabloCalled {
+		return ErrPabloCalled
 	}
 
 	// Can't call Pablo while a give is pending
 	if g.PendingGive != nil {
-		return
+		return ErrPendingGive
 	}
 
 	g.PabloCalled = true
 	g.PabloCaller = playerID
+	g.PabloBlind = g.Config.BlindPablo && g.CurrentPlayer == playerID && !g.actedThisRound[playerID]
+	var data map[string]interface{}
+	if g.PabloBlind {
+		data = map[string]interface{}{"blind": true}
+	}
+	g.emitAction(playerID, protocol.MsgCallPablo, data)
+	// A call made out of turn can start the last lap on an absent player's turn
+	if g.skipAbsentTurn() {
+		return nil
+	}
 	g.broadcastGameState()
+	return nil
 }
 
-func (g *Game) EndTurn(playerID string) {
+func (g *Game) EndTurn(playerID string) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
 	if g.CurrentPlayer != playerID {
-		return
+		return ErrNotYourTurn
 	}
 
 	// Must resolve pending give before ending turn
 	if g.PendingGive != nil {
-		return
+		return ErrPendingGive
 	}
 
 	// Player must handle drawn card (discard or swap) before ending turn
 	if _, hasDrawn := g.DrawnCards[playerID]; hasDrawn {
-		return // Can't end turn with a drawn card - must discard or swap first
+		return ErrUnplayedCard // Can't end turn with a drawn card - must discard or swap first
 	}
 
 	// Player must use special card power if one is in the discard pile
 	if len(g.DiscardPile) > 0 {
 		topCard := g.DiscardPile[len(g.DiscardPile)-1]
-		if topCard.Rank == "7" || topCard.Rank == "8" || topCard.Rank == "9" {
+		if g.isSpecialCard(topCard) {
 			if g.PendingSpecialCard != "" {
-				return // Can't end turn with a pending special card - must use it or skip
+				return ErrPendingPower // Can't end turn with a pending special card - must use it or skip
 			}
 		}
 	}
 
-	// Move to next player
-	playerIDs := make([]string, 0, len(g.Players))
-	for id := range g.Players {
-		playerIDs = append(playerIDs, id)
-	}
-
-	currentIdx := -1
-	for i, id := range playerIDs {
-		if id == playerID {
-			currentIdx = i
-			break
-		}
-	}
+	g.emitAction(playerID, protocol.MsgEndTurn, nil)
+	g.countPuzzleTurn(playerID)
+	g.passTurn(playerID)
+	return nil
+}
 
-	if currentIdx >= 0 {
-		nextIdx := (currentIdx + 1) % len(playerIDs)
+// passTurn hands the turn on from playerID, whose turn is over, ending the round instead
+// when a Pablo call's last lap is done. Caller must hold g.mu.
+func (g *Game) passTurn(playerID string) {
+	// Move to next player
+	nextPlayer := g.nextSeatAfter(playerID)
 
+	if nextPlayer != "" {
 		// Clear any drawn cards from the previous player (safety check)
 		delete(g.DrawnCards, playerID)
+		delete(g.DrawnFromDiscard, playerID)
 		// Reset the "has drawn" flag for the previous player
 		delete(g.HasDrawnThisTurn, playerID)
 
 		// If Pablo was called, everyone except the caller gets one more turn.
 		// When turn order would come back to the caller, we end the round instead.
-		if g.PabloCalled && playerIDs[nextIdx] == g.PabloCaller {
+		if g.PabloCalled && nextPlayer == g.PabloCaller {
 			g.EndRound()
 			return
 		}
 
 		// Otherwise, pass turn to the next player
-		g.CurrentPlayer = playerIDs[nextIdx]
+		g.CurrentPlayer = nextPlayer
 		// Reset the "has drawn" flag for the new current player (fresh turn)
 		delete(g.HasDrawnThisTurn, g.CurrentPlayer)
+		g.emitTurnStarted()
+
+		if g.puzzleOutOfTurns() {
+			return
+		}
+		if g.skipAbsentTurn() {
+			return
+		}
 	}
 
 	g.broadcastGameState()
+	g.scheduleBots()
 }
 
-func (g *Game) EndRound() {
-	g.Status = "ended"
-	g.PabloCalled = false
-	g.PabloCaller = ""
-	g.PendingGive = nil
-
-	// Reveal all cards
-	for _, player := range g.Players {
-		for i := range player.Cards {
+// nextSeatAfter returns the player seated after playerID, wrapping around the table and
+// passing over vacant seats. Returns "" if playerID isn't seated.
+func (g *Game) nextSeatAfter(playerID string) string {
+	for i, id := range g.SeatOrder {
+		if id != playerID {
+			continue
+		}
+		for step := 1; step < len(g.SeatOrder); step++ {
+			if next := g.SeatOrder[(i+step)%len(g.SeatOrder)]; !g.vacant[next] {
+				return next
+			}
+		}
+		return playerID
+	}
+	return ""
+}
+
+// removePlayer takes a player out of the game. Their cards (and any drawn card) go back
+// to the bottom of the deck, anything pending on them is cancelled, and if it was their
+// turn play moves on to the next seat. A round left with fewer than two players ends.
+// Caller must hold g.mu.
+func (g *Game) removePlayer(playerID string) {
+	player, exists := g.Players[playerID]
+	if !exists {
+		return
+	}
+
+	// Work out rotation before the seat disappears
+	nextPlayer := g.nextSeatAfter(playerID)
+
+	for _, card := range player.Cards {
+		if card.Rank != "" {
+			card.FaceUp = false
+			g.Deck = append(g.Deck, card)
+		}
+	}
+	if drawnCard, hasDrawn := g.DrawnCards[playerID]; hasDrawn && drawnCard != nil && !g.DrawnFromDiscard[playerID] {
+		card := *drawnCard
+		card.FaceUp = false
+		g.Deck = append(g.Deck, card)
+	}
+
+	g.emit(protocol.EventPlayerLeft, playerID, nil)
+	delete(g.Players, playerID)
+	delete(g.DrawnCards, playerID)
+	delete(g.HasDrawnThisTurn, playerID)
+	delete(g.DrawnFromDiscard, playerID)
+	delete(g.KickVotes, playerID)
+	delete(g.PauseVotes, playerID)
+	delete(g.Muted, playerID)
+	delete(g.Handicaps, playerID)
+	delete(g.ShadowMuted, playerID)
+	delete(g.stackSpam, playerID)
+	delete(g.stackSitOut, playerID)
+	delete(g.absent, playerID)
+	delete(g.vacant, playerID)
+	g.streams.stop(playerID)
+	delete(g.RejoinedSincePause, playerID)
+	for i, id := range g.SeatOrder {
+		if id == playerID {
+			g.SeatOrder = append(g.SeatOrder[:i], g.SeatOrder[i+1:]...)
+			break
+		}
+	}
+	for i, id := range g.Waiting {
+		if id == playerID {
+			g.Waiting = append(g.Waiting[:i], g.Waiting[i+1:]...)
+			break
+		}
+	}
+	for i, id := range g.StackedSpecialCardPlayers {
+		if id == playerID {
+			g.StackedSpecialCardPlayers = append(g.StackedSpecialCardPlayers[:i], g.StackedSpecialCardPlayers[i+1:]...)
+			break
+		}
+	}
+	if g.PendingGive != nil && (g.PendingGive.ActorID == playerID || g.PendingGive.TargetPlayerID == playerID) {
+		g.PendingGive = nil
+	}
+	if g.PendingKingSwap != nil && (g.PendingKingSwap.ActorID == playerID || g.PendingKingSwap.TargetPlayerID == playerID) {
+		actorLeft := g.PendingKingSwap.ActorID == playerID
+		g.PendingKingSwap = nil
+		// The peeked card is gone, so the power resolves without a swap
+		if !actorLeft {
+			g.finishSpecialCard()
+		}
+	}
+
+	// Host passes to the next seat
+	if g.HostID == playerID {
+		g.HostID = ""
+		if len(g.SeatOrder) > 0 {
+			g.HostID = nextPlayer
+		}
+	}
+	g.broadcastLobby()
+
+	if g.Status != protocol.StatusPlaying && g.Status != protocol.StatusPaused {
+		return
+	}
+
+	if len(g.SeatOrder) < 2 {
+		g.EndRound()
+		return
+	}
+
+	// The round now ends when rotation reaches the seat that followed the caller
+	wasCaller := g.PabloCalled && g.PabloCaller == playerID
+	if wasCaller {
+		g.PabloCaller = nextPlayer
+	}
+
+	if g.CurrentPlayer == playerID {
+		g.PendingSpecialCard = ""
+		// Same check as EndTurn: the removed player's turn counts as taken
+		if g.PabloCalled && !wasCaller && nextPlayer == g.PabloCaller {
+			g.EndRound()
+			return
+		}
+		g.CurrentPlayer = nextPlayer
+		delete(g.HasDrawnThisTurn, nextPlayer)
+		g.emitTurnStarted()
+		g.scheduleBots()
+	}
+}
+
+func (g *Game) EndRound() {
+	pabloCaller := g.PabloCaller
+	blindPablo := g.PabloBlind
+	g.Status = protocol.StatusEnded
+	g.PabloCalled = false
+	g.PabloCaller = ""
+	g.PabloBlind = false
+	g.PendingGive = nil
+	g.PendingKingSwap = nil
+	g.peeking = nil
+
+	// Reveal all cards
+	for _, player := range g.Players {
+		for i := range player.Cards {
 			player.Cards[i].FaceUp = true
 		}
 	}
 
 	// Calculate scores
-	for _, player := range g.Players {
+	for _, id := range g.SeatOrder {
+		player := g.Players[id]
 		score := 0
 		for _, card := range player.Cards {
 			if card.Rank != "" {
-				value := getCardValue(card)
-				score += value
+				score += g.cardValue(card)
 			}
 		}
 		player.Score = score
 	}
+	g.applyStackPenalties()
+	g.applyClaimPenalties()
+	g.applyHandicaps()
+	if blindPablo {
+		g.applyBlindPablo(pabloCaller)
+	}
+	for _, id := range g.SeatOrder {
+		g.Players[id].Total += g.Players[id].Score
+	}
+	g.recordRoundScores()
+	if len(g.match.Rounds) == 1 {
+		g.reportLeagueGame()
+	}
 
+	g.recordDailyResult()
+	g.recordPlayerStats(pabloCaller)
+	g.archiveRound()
+	g.finishPuzzle()
+	g.finishTutorial()
+	g.emitRoundEnded(pabloCaller)
+	if g.Config.RevealDelayMs > 0 {
+		g.broadcastGameState()
+		g.stageReveal(pabloCaller, blindPablo)
+		return
+	}
 	g.broadcastGameState()
+	g.broadcastRoundSummary(pabloCaller, blindPablo)
+	g.checkMercyRule()
 }
 
-func getCardValue(card Card) int {
-	// Red kings (hearts, diamonds) are worth -1
-	if card.Rank == "K" && (card.Suit == "hearts" || card.Suit == "diamonds") {
-		return -1
+// roundWinners returns the players with the lowest score this round (ties all win)
+func (g *Game) roundWinners() []string {
+	if _, seated := g.Players[g.ClaimWinner]; seated {
+		return []string{g.ClaimWinner}
+	}
+	winners := []string{}
+	best := 0
+	for _, id := range g.SeatOrder {
+		score := g.Players[id].Score
+		if len(winners) == 0 || score < best {
+			winners = []string{id}
+			best = score
+		} else if score == best {
+			winners = append(winners, id)
+		}
 	}
+	return winners
+}
 
-	// Face cards
-	if card.Rank == "J" || card.Rank == "Q" {
-		return 10
-	}
-	if card.Rank == "K" {
-		return 10
+// broadcastRoundSummary tells everyone at the table (and watching) how the round ended:
+// final scores, winners, whether a Pablo call held up, and the spectator prediction results.
+func (g *Game) broadcastRoundSummary(pabloCaller string, blindPablo bool) {
+	winners := g.roundWinners()
+	pabloSucceeded := false
+	for _, id := range winners {
+		if pabloCaller != "" && id == pabloCaller {
+			pabloSucceeded = true
+		}
 	}
 
-	// Ace
-	if card.Rank == "A" {
-		return 1
+	scores := make(map[string]int)
+	for _, id := range g.SeatOrder {
+		scores[id] = g.Players[id].Score
 	}
 
-	// Number cards
-	value := 0
-	switch card.Rank {
-	case "2":
-		value = 2
-	case "3":
-		value = 3
-	case "4":
-		value = 4
-	case "5":
-		value = 5
-	case "6":
-		value = 6
-	case "7":
-		value = 7
-	case "8":
-		value = 8
-	case "9":
-		value = 9
-	case "10":
-		value = 10
+	summary := map[string]interface{}{
+		"scores":      scores,
+		"winners":     winners,
+		"pabloCaller": pabloCaller,
+	}
+	if pabloCaller != "" {
+		summary["pabloSucceeded"] = pabloSucceeded
+	}
+	if g.ClaimWinner != "" {
+		summary["claimWinner"] = g.ClaimWinner
 	}
+	if blindPablo {
+		summary["blindPablo"] = true
+	}
+	if deal := g.revealedDeal(); deal != nil {
+		summary["deal"] = deal
+	}
+	if handicaps := g.roundHandicaps(); len(handicaps) > 0 {
+		summary["handicaps"] = handicaps
+	}
+	summary["predictions"] = g.scorePredictions(winners, pabloCaller, pabloSucceeded)
+	summary["spectatorLeaderboard"] = g.spectatorLeaderboard()
 
-	return value
+	message := Message{Type: protocol.MsgRoundSummary, Payload: summary}
+	g.broadcast(message)
+	g.broadcastToSpectators(message)
+}
+
+// getCardValue is what a card scores in a standard deck; tables score with g.cardValue
+func getCardValue(card Card) int {
+	return (*DeckDefinition)(nil).value(card)
 }
 
 // getNumericRank returns the numeric value of a card rank for comparison
@@ -625,14 +1288,13 @@ func getNumericRank(rank string) int {
 }
 
 // StackCard attempts to stack a player's card on top of the discard pile
-// Returns: (success bool, error message string)
-func (g *Game) StackCard(playerID string, cardIndex int) (bool, string) {
+func (g *Game) StackCard(playerID string, cardIndex int) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
 	// Check if discard pile has a card
 	if len(g.DiscardPile) == 0 {
-		return false, "No card in discard pile to stack on."
+		return ErrEmptyDiscard
 	}
 
 	// Check if the top card is stackable (not placed via stacking)
@@ -643,63 +1305,79 @@ func (g *Game) StackCard(playerID string, cardIndex int) (bool, string) {
 	topCardIndex := len(g.DiscardPile) - 1
 	
 	// Stacking is only allowed if the top card was placed via end turn (not via stacking)
-	// This means StackableCardIndex must match topCardIndex
-	if g.StackableCardIndex == -1 {
-		return false, "Cannot stack on this card. Cards placed via stacking cannot be stacked on."
+	// This means StackableCardIndex must match topCardIndex, unless the player's lag
+	// covers the moment someone else stacked on it
+	graceCard, late := g.inStackGrace(playerID)
+	if g.StackableCardIndex == -1 && !late {
+		return fmt.Errorf("%w: cards placed by stacking can't be stacked on", ErrNotStackable)
 	}
-	if g.StackableCardIndex != topCardIndex {
-		return false, "Cannot stack on this card. Only the most recent card placed via end turn can be stacked on."
+	if g.StackableCardIndex != topCardIndex && !late {
+		return fmt.Errorf("%w: only the most recently discarded card can be stacked on", ErrNotStackable)
 	}
 
 	// Check if player exists
 	player, exists := g.Players[playerID]
 	if !exists {
-		return false, "Player not found."
+		return ErrNotInGame
+	}
+	if g.isWaiting(playerID) {
+		return fmt.Errorf("%w: you're dealt in at the next round", ErrNotInGame)
+	}
+	if until := g.stackCooldown(playerID); !until.IsZero() {
+		return fmt.Errorf("%w for %ds", ErrStackCooldown, int(time.Until(until).Seconds())+1)
+	}
+	if g.sittingOutStack(playerID) {
+		return ErrStackSitOut
 	}
 
 	// Check if card index is valid
 	if cardIndex < 0 || cardIndex >= len(player.Cards) {
-		return false, "Invalid card index."
+		return ErrInvalidCardIndex
 	}
 
 	// Get the card to stack
 	cardToStack := player.Cards[cardIndex]
 	if cardToStack.Rank == "" {
-		return false, "Invalid card. Card has no rank."
+		return ErrInvalidCardIndex
 	}
 
-	// Get the top card of discard pile
+	// Get the top card of discard pile, or the one stacked on for a late stack
 	topCard := g.DiscardPile[topCardIndex]
+	if late {
+		topCard = graceCard
+	}
 	if topCard.Rank == "" {
-		return false, "Invalid discard pile card. Card has no rank."
+		return fmt.Errorf("%w on the discard pile", ErrInvalidCard)
 	}
 
 	// Check if ranks match (any rank can stack, including face cards J, Q, K)
 	// Suit doesn't matter, only the rank/number needs to match
 	if cardToStack.Rank != topCard.Rank {
-		// Stack failed - add penalty card
-		if len(g.Deck) > 0 {
+		// Stack failed - add penalty cards
+		for i := 0; i < g.Config.FailedStack.penaltyCards() && len(g.Deck) > 0; i++ {
 			penaltyCard := g.Deck[0]
 			g.Deck = g.Deck[1:]
 			penaltyCard.FaceUp = false
 			player.Cards = append(player.Cards, penaltyCard)
 		}
+		g.noteFailedStack(playerID)
 
 		// Immediately broadcast updated game state with penalty card
 		g.broadcastGameState()
 
 		// Notify all players about the failed stack attempt
 		g.broadcastStackAttempt(playerID, false)
+		g.emitAction(playerID, protocol.MsgStackCard, map[string]interface{}{"cardIndex": cardIndex, "card": cardToStack, "success": false})
 
-		return false, "Card rank does not match. Penalty card added."
+		return fmt.Errorf("%w: penalty card added", ErrCardMismatch)
 	}
 
 	// Stack successful - remove card from player and add to discard pile
 	cardToStack.FaceUp = true
-	g.DiscardPile = append(g.DiscardPile, cardToStack)
+	g.pushDiscard(cardToStack)
 
-	// Check if the card being stacked on is a special card (7, 8, 9)
-	isStackingOnSpecialCard := topCard.Rank == "7" || topCard.Rank == "8" || topCard.Rank == "9"
+	// Check if the card being stacked on is a special card (7, 8, 9, or a black king under the king rule)
+	isStackingOnSpecialCard := g.isSpecialCard(topCard)
 	
 	// Replace the stacked card with an empty card to preserve positions
 	// This prevents other cards from shifting when a card is stacked
@@ -722,55 +1400,78 @@ func (g *Game) StackCard(playerID string, cardIndex int) (bool, string) {
 
 	// Mark that the new top card (placed via stacking) cannot be stacked on
 	g.StackableCardIndex = -1
+	g.noteStack(playerID, topCard, late)
 
 	// Notify all players about the successful stack
+	g.recordStack(playerID)
 	g.broadcastStackAttempt(playerID, true)
+	g.emitAction(playerID, protocol.MsgStackCard, map[string]interface{}{"cardIndex": cardIndex, "card": cardToStack, "success": true})
 
 	// Check zero-card win condition for this player
-	if g.countNonEmptyCards(g.Players[playerID]) == 0 && g.Status == "playing" {
+	if g.countNonEmptyCards(g.Players[playerID]) == 0 && g.Status == protocol.StatusPlaying {
 		g.EndRound()
-		return true, ""
+		return nil
 	}
 
 	g.broadcastGameState()
-	return true, ""
+	return nil
 }
 
 // StackOpponentCard attempts to stack an opponent's card on top of discard pile by the acting player.
 // On success: opponent's card (at index) is placed on discard and their slot becomes empty (removed placeholder).
 // On failure (rank mismatch): that opponent card is moved as a penalty card to the acting player's hand
 // and the opponent's slot becomes empty (removed placeholder). Broadcasts a stackAttempt to all players.
-func (g *Game) StackOpponentCard(actorID string, targetPlayerID string, cardIndex int) (bool, string) {
+func (g *Game) StackOpponentCard(actorID string, targetPlayerID string, cardIndex int) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	// Tables playing classic rules only stack their own cards
+	if g.Config.NoOpponentStacking {
+		return fmt.Errorf("%w: this table only stacks its own cards", ErrRuleDisabled)
+	}
+
 	// Must have a top discard card
 	if len(g.DiscardPile) == 0 {
-		return false, "No card in discard pile to stack on."
+		return ErrEmptyDiscard
 	}
 
-	// Only allow when the last placed card was via end turn (stackable)
+	// Only allow when the last placed card was via end turn (stackable), or the actor's lag
+	// covers the moment someone else stacked on it. A late stack can't start a second give.
 	topCardIndex := len(g.DiscardPile) - 1
-	if g.StackableCardIndex == -1 || g.StackableCardIndex != topCardIndex {
-		return false, "Cannot stack on this card right now."
+	graceCard, late := g.inStackGrace(actorID)
+	late = late && g.PendingGive == nil
+	if (g.StackableCardIndex == -1 || g.StackableCardIndex != topCardIndex) && !late {
+		return ErrNotStackable
 	}
 
 	actor, ok := g.Players[actorID]
 	if !ok {
-		return false, "Player not found."
+		return ErrNotInGame
+	}
+	if g.isWaiting(actorID) {
+		return fmt.Errorf("%w: you're dealt in at the next round", ErrNotInGame)
+	}
+	if until := g.stackCooldown(actorID); !until.IsZero() {
+		return fmt.Errorf("%w for %ds", ErrStackCooldown, int(time.Until(until).Seconds())+1)
+	}
+	if g.sittingOutStack(actorID) {
+		return ErrStackSitOut
 	}
 	target, ok := g.Players[targetPlayerID]
-	if !ok {
-		return false, "Target player not found."
+	if !ok || g.isWaiting(targetPlayerID) {
+		return ErrTargetNotFound
 	}
 	if cardIndex < 0 || cardIndex >= len(target.Cards) {
-		return false, "Invalid card index."
+		return ErrInvalidCardIndex
 	}
 
 	topCard := g.DiscardPile[topCardIndex]
+	if late {
+		topCard = graceCard
+	}
 	opCard := target.Cards[cardIndex]
 	if opCard.Rank == "" {
-		return false, "Invalid target card."
+		return ErrInvalidCardIndex
 	}
 
 	if opCard.Rank != topCard.Rank {
@@ -778,25 +1479,32 @@ func (g *Game) StackOpponentCard(actorID string, targetPlayerID string, cardInde
 		opCard.FaceUp = false
 		actor.Cards = append(actor.Cards, opCard)
 		target.Cards[cardIndex] = Card{Suit: "", Rank: "", FaceUp: false} // removed placeholder
+		g.noteFailedStack(actorID)
 
 		// Notify and broadcast
 		g.broadcastStackAttempt(actorID, false)
+		g.emitAction(actorID, protocol.MsgStackOpponentCard, map[string]interface{}{
+			"targetPlayerID": targetPlayerID,
+			"cardIndex":      cardIndex,
+			"card":           opCard,
+			"success":        false,
+		})
 		// Check zero-card win condition for target (they lost a card)
-		if g.countNonEmptyCards(target) == 0 && g.Status == "playing" {
+		if g.countNonEmptyCards(target) == 0 && g.Status == protocol.StatusPlaying {
 			g.EndRound()
-			return false, "Card rank does not match. Opponent card taken as penalty."
+			return fmt.Errorf("%w: opponent card taken as penalty", ErrCardMismatch)
 		}
 		g.broadcastGameState()
-		return false, "Card rank does not match. Opponent card taken as penalty."
+		return fmt.Errorf("%w: opponent card taken as penalty", ErrCardMismatch)
 	}
 
 	// Success: stack opponent's card on discard; clear opponent slot
 	opCard.FaceUp = true
-	g.DiscardPile = append(g.DiscardPile, opCard)
+	g.pushDiscard(opCard)
 	target.Cards[cardIndex] = Card{Suit: "", Rank: "", FaceUp: false} // removed placeholder
 
 	// If stacking on special, queue actor for special resolution
-	isStackingOnSpecialCard := topCard.Rank == "7" || topCard.Rank == "8" || topCard.Rank == "9"
+	isStackingOnSpecialCard := g.isSpecialCard(topCard)
 	if isStackingOnSpecialCard {
 		alreadyQueued := false
 		for _, q := range g.StackedSpecialCardPlayers {
@@ -812,44 +1520,59 @@ func (g *Game) StackOpponentCard(actorID string, targetPlayerID string, cardInde
 
 	// New top came from stacking; prevent immediate re-stacking
 	g.StackableCardIndex = -1
+	g.noteStack(actorID, topCard, late)
 
+	g.recordStack(actorID)
 	g.broadcastStackAttempt(actorID, true)
+	g.emitAction(actorID, protocol.MsgStackOpponentCard, map[string]interface{}{
+		"targetPlayerID": targetPlayerID,
+		"cardIndex":      cardIndex,
+		"card":           opCard,
+		"success":        true,
+	})
 	// Set pending give: actor must give a card to target into this slot
 	g.PendingGive = &PendingGive{
 		ActorID:        actorID,
 		TargetPlayerID: targetPlayerID,
 		TargetIndex:    cardIndex,
 	}
+	g.startGiveTimer()
 	g.broadcastGameState() // Frontend will prompt actor to give a card
-	return true, ""
+	return nil
 }
 
 // broadcastStackAttempt notifies all players about a stack attempt
 func (g *Game) broadcastStackAttempt(playerID string, success bool) {
-	playerName := ""
-	if player, exists := g.Players[playerID]; exists {
-		playerName = player.Name
-	}
+	g.broadcast(Message{
+		Type: protocol.MsgStackAttempt,
+		Payload: map[string]interface{}{
+			"playerID":   playerID,
+			"playerName": g.displayName(playerID),
+			"success":    success,
+		},
+	})
+}
 
-	for _, player := range g.Players {
-		if player.Conn != nil {
-			message := Message{
-				Type: "stackAttempt",
-				Payload: map[string]interface{}{
-					"playerID":   playerID,
-					"playerName": playerName,
-					"success":    success,
-				},
-			}
-			player.Conn.WriteJSON(message)
-		}
+// broadcastPlayerDrew tells the table and its spectators that playerID drew, from "deck"
+// or "discard", so clients can animate the draw. The card isn't included: a deck card is
+// only the drawer's to see, and a discard was already face up on the pile.
+func (g *Game) broadcastPlayerDrew(playerID, source string) {
+	message := Message{
+		Type: protocol.MsgPlayerDrew,
+		Payload: map[string]interface{}{
+			"playerID":   playerID,
+			"playerName": g.displayName(playerID),
+			"source":     source,
+		},
 	}
+	g.broadcast(message)
+	g.broadcastToSpectators(message)
 }
 
 // broadcastSwapEventWithCards notifies all players about a card swap with card data for animation
 func (g *Game) broadcastSwapEventWithCards(player1ID string, card1Index int, card1 Card, player2ID string, card2Index int, card2 Card) {
 	message := Message{
-		Type: "swapEvent",
+		Type: protocol.MsgSwapEvent,
 		Payload: map[string]interface{}{
 			"player1ID":  player1ID,
 			"card1Index": card1Index,
@@ -867,12 +1590,7 @@ func (g *Game) broadcastSwapEventWithCards(player1ID string, card1Index int, car
 			},
 		},
 	}
-
-	for _, player := range g.Players {
-		if player.Conn != nil {
-			player.Conn.WriteJSON(message)
-		}
-	}
+	g.broadcast(message)
 }
 
 // countNonEmptyCards returns how many cards in a player's hand actually exist
@@ -890,73 +1608,130 @@ func (g *Game) countNonEmptyCards(p *Player) int {
 	return count
 }
 
-func (g *Game) sendToPlayer(playerID string, message Message) {
-	if player, exists := g.Players[playerID]; exists && player.Conn != nil {
-		player.Conn.WriteJSON(message)
+// Viewer roles for StateFor
+const (
+	viewerPlayer    = "player"
+	viewerSpectator = "spectator"
+	viewerObserver  = "observer"
+)
+
+// StateFor returns the game state as one viewer sees it right now: a player's own view,
+// the public spectator view, or the observers' omniscient view.
+func (g *Game) StateFor(role, playerID string) *GameState {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	switch role {
+	case viewerPlayer:
+		return g.getGameStateForPlayer(playerID)
+	case viewerObserver:
+		return g.getGameStateForObserver()
+	default:
+		return g.getGameStateForSpectator()
 	}
 }
 
-func (g *Game) broadcastGameState() {
-	for playerID, player := range g.Players {
-		if player.Conn != nil {
-			state := g.getGameStateForPlayer(playerID)
-			message := Message{
-				Type:    "gameState",
-				Payload: state,
-			}
-			player.Conn.WriteJSON(message)
-		}
-	}
+// PublicState returns the spectator view as last published. It doesn't wait on g.mu;
+// the state is shared and must not be modified.
+func (g *Game) PublicState() *GameState {
+	return g.public().state
 }
 
-func (g *Game) getGameStateForPlayer(viewerID string) map[string]interface{} {
-	players := make(map[string]interface{})
+// BroadcastState sends everyone their current view of the game. For callers outside the engine;
+// engine methods already hold g.mu and call broadcastGameState.
+func (g *Game) BroadcastState() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.broadcastGameState()
+}
+
+func (g *Game) getGameStateForPlayer(viewerID string) *GameState {
+	return g.buildGameState(viewerID, false)
+}
+
+// getGameStateForSpectator is the public view: no one's hidden cards are shown
+func (g *Game) getGameStateForSpectator() *GameState {
+	state := g.buildGameState("", false)
+	state.Spectator = true
+	return state
+}
+
+// getGameStateForObserver is the state feed for authorized organizers: every card is shown
+// face up, with "hidden" marking the ones players at the table can't see.
+func (g *Game) getGameStateForObserver() *GameState {
+	return g.buildGameState("", true)
+}
+
+func (g *Game) buildGameState(viewerID string, omniscient bool) *GameState {
+	players := make(map[string]PlayerState, len(g.Players))
 	for id, player := range g.Players {
+		// A hand still waiting its turn in a staged reveal stays face down, score and all
+		pending := g.revealPending(id)
 		// Include ALL cards (including empty ones) to preserve positions
-		var cards []map[string]interface{}
+		cards := make([]CardView, 0, len(player.Cards))
 		for _, card := range player.Cards {
 			// Check if card is empty (removed via stacking)
 			if card.Rank == "" && card.Suit == "" {
 				// Include empty card as placeholder to maintain position
 				// Mark it as removed so frontend knows it's a stacked card, not a face-down card
-				cards = append(cards, map[string]interface{}{
-					"suit":   "",
-					"rank":   "",
-					"faceUp": false,
-					"removed": true, // Flag to indicate this card was removed via stacking
+				cards = append(cards, CardView{Removed: true, showHidden: omniscient})
+			} else if omniscient {
+				cards = append(cards, CardView{
+					Card:       Card{Suit: card.Suit, Rank: card.Rank, FaceUp: true},
+					Hidden:     !card.FaceUp && g.Status != protocol.StatusEnded, // Face down to everyone at the table
+					showHidden: true,
 				})
 			} else {
 				// Only show card details if it's the viewer's card, or if it's face up, or if game ended
-				if id == viewerID || card.FaceUp || g.Status == "ended" {
-					cards = append(cards, map[string]interface{}{
-						"suit":   card.Suit,
-						"rank":   card.Rank,
-						"faceUp": card.FaceUp || g.Status == "ended",
-						"removed": false,
-					})
+				if id == viewerID || (!pending && (card.FaceUp || g.Status == protocol.StatusEnded)) {
+					cards = append(cards, CardView{Card: Card{
+						Suit:   card.Suit,
+						Rank:   card.Rank,
+						FaceUp: card.FaceUp || g.Status == protocol.StatusEnded,
+					}})
 				} else {
 					// Hide other players' cards (face down) - card exists but details hidden
-					cards = append(cards, map[string]interface{}{
-						"suit":   "",
-						"rank":   "",
-						"faceUp": false,
-						"removed": false, // Card exists, just hidden
-					})
+					cards = append(cards, CardView{})
 				}
 			}
 		}
-		players[id] = map[string]interface{}{
-			"id":    player.ID,
-			"name":  player.Name,
-			"cards": cards,
-			"score": player.Score,
+		_, isBot := g.Bots[id]
+		seat := PlayerState{
+			ID:    player.ID,
+			Name:  g.displayName(id),
+			Cards: cards,
+			Score:   player.Score,
+			Total:   player.Total,
+			IsBot:   isBot,
+			Waiting:      g.isWaiting(id),
+			HasDrawn:     g.HasDrawnThisTurn[id],
+			Handicap:     g.Handicaps[id],
+			Vacant:       g.vacant[id],
+			Disconnected: !isBot && player.Conn == nil && !g.vacant[id],
+		}
+		if g.Config.ShowLatency {
+			// As of this state; it isn't rebroadcast when only the latency changes
+			rtt, _ := latencyOf(player.Conn)
+			seat.LatencyMs = int(rtt.Round(time.Millisecond) / time.Millisecond)
 		}
+		if viewerID != "" {
+			seat.Note = g.noteFor(viewerID, id)
+		}
+		if pending {
+			seat.Total -= seat.Score
+			seat.Score = 0
+		}
+		players[id] = seat
 	}
 
-	// Include drawn cards in state (only show your own drawn card)
+	// Include drawn cards in state (only show your own drawn card). Copied, since the
+	// state may be published and outlive the next change to the game.
 	drawnCards := make(map[string]*Card)
-	if drawnCard, exists := g.DrawnCards[viewerID]; exists && drawnCard != nil {
-		drawnCards[viewerID] = drawnCard
+	for id, drawnCard := range g.DrawnCards {
+		if drawnCard != nil && (id == viewerID || omniscient) {
+			card := *drawnCard
+			drawnCards[id] = &card
+		}
 	}
 
 	// Check if stacking is enabled (top card is stackable)
@@ -966,76 +1741,115 @@ func (g *Game) getGameStateForPlayer(viewerID string) map[string]interface{} {
 		stackingEnabled = g.StackableCardIndex == topCardIndex
 	}
 
-	state := map[string]interface{}{
-		"gameID":             g.ID,
-		"players":            players,
-		"currentPlayer":      g.CurrentPlayer,
-		"status":             g.Status,
-		"pabloCalled":        g.PabloCalled,
-		"deckSize":           len(g.Deck),
-		"discardTop":         getDiscardTop(g.DiscardPile),
-		"drawnCards":         drawnCards,
-		"pendingSpecialCard": g.PendingSpecialCard,
-		"stackingEnabled":    stackingEnabled,
+	state := &GameState{
+		GameID:             g.ID,
+		Players:            players,
+		CurrentPlayer:      g.CurrentPlayer,
+		Status:             g.Status,
+		HostID:             g.HostID,
+		Locked:             g.Locked,
+		PabloCalled:        g.PabloCalled,
+		DeckSize:           len(g.Deck),
+		DiscardTop:         getDiscardTop(g.DiscardPile),
+		DiscardCount:       g.discardCount(),
+		DiscardHistory:     discardHistory(g.DiscardPile),
+		DrawnCards:         drawnCards,
+		PendingSpecialCard: g.PendingSpecialCard,
+		StackingEnabled:    stackingEnabled,
+		Config:             g.Config,
+		Omniscient:         omniscient,
+		ScheduledAt:        g.ScheduledAt,
+		GameOver:           g.GameOver,
+		TurnPhase:          g.turnPhase(),
+		DealCommitment:     g.dealCommitmentHash(),
+		ServerTime:         time.Now(),
+	}
+	if _, seated := g.Players[viewerID]; seated {
+		state.AllowedActions = g.allowedActions(viewerID)
+		state.Deadlines.StackCooldown = g.stackCooldown(viewerID)
+	}
+	state.Deadlines.Turn = g.turnDeadline()
+	state.Deadlines.Peek = g.peekDeadline()
+	state.Deadlines.StackWindow = g.stackWindowDeadline()
+	state.Deadlines.Give = g.giveDeadline()
+	if g.PendingKingSwap != nil {
+		kingSwap := *g.PendingKingSwap
+		state.PendingKingSwap = &kingSwap
 	}
-	// Include pendingGive but only necessary fields for the viewer
 	if g.PendingGive != nil {
-		state["pendingGive"] = map[string]interface{}{
-			"actorID":        g.PendingGive.ActorID,
-			"targetPlayerID": g.PendingGive.TargetPlayerID,
-			"targetIndex":    g.PendingGive.TargetIndex,
-		}
+		give := *g.PendingGive
+		state.PendingGive = &give
 	}
 	return state
 }
 
 // HandleGiveCard moves a card from actor (PendingGive.ActorID) to target (PendingGive.TargetPlayerID) at TargetIndex.
-func (g *Game) HandleGiveCard(actorID string, sourceIndex int) {
+func (g *Game) HandleGiveCard(actorID string, sourceIndex int) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
+	return g.giveCard(actorID, sourceIndex, false)
+}
 
+// giveCard makes the pending give, for the player or, when timedOut, for them once their
+// time ran out. Caller must hold g.mu.
+func (g *Game) giveCard(actorID string, sourceIndex int, timedOut bool) error {
 	if g.PendingGive == nil {
-		return
+		return ErrNoPendingGive
 	}
 	pg := g.PendingGive
 	if pg.ActorID != actorID {
-		return
+		return ErrNoPendingGive
 	}
 
 	actor, okA := g.Players[pg.ActorID]
 	target, okT := g.Players[pg.TargetPlayerID]
 	if !okA || !okT {
-		return
+		return ErrNotInGame
 	}
 	if sourceIndex < 0 || sourceIndex >= len(actor.Cards) {
-		return
+		return ErrInvalidCardIndex
 	}
 	// Card to give must be an existing card (non-empty)
 	card := actor.Cards[sourceIndex]
 	if card.Rank == "" {
-		return
+		return ErrInvalidCardIndex
 	}
 
 	// Place card into target at TargetIndex
 	if pg.TargetIndex < 0 || pg.TargetIndex >= len(target.Cards) {
-		return
+		return ErrInvalidTarget
 	}
 	target.Cards[pg.TargetIndex] = card
 	// Remove from actor (leave empty placeholder)
 	actor.Cards[sourceIndex] = Card{Suit: "", Rank: "", FaceUp: false}
 
+	payload := map[string]interface{}{
+		"sourceIndex":    sourceIndex,
+		"targetPlayerID": pg.TargetPlayerID,
+		"targetIndex":    pg.TargetIndex,
+	}
+	if timedOut {
+		payload["timedOut"] = true
+	}
+	g.emitAction(actorID, protocol.MsgGiveCardToPlayer, payload)
+
 	// Clear pending give
 	g.PendingGive = nil
 
 	// If target now has zero cards (unlikely since we just gave), or actor now zero cards, check win
-	if g.Status == "playing" {
+	if g.Status == protocol.StatusPlaying {
 		if g.countNonEmptyCards(actor) == 0 || g.countNonEmptyCards(target) == 0 {
 			g.EndRound()
-			return
+			return nil
 		}
 	}
+	if g.skipAbsentTurn() {
+		return nil
+	}
 
 	g.broadcastGameState()
+	g.scheduleBots()
+	return nil
 }
 func getDiscardTop(discardPile []Card) *Card {
 	if len(discardPile) == 0 {
@@ -1046,138 +1860,1052 @@ func getDiscardTop(discardPile []Card) *Card {
 }
 
 type GameManager struct {
-	games map[string]*Game
-	mu    sync.RWMutex
+	ctx         context.Context // Parent of every game's context; cancelled at shutdown
+	games       map[string]*Game
+	adjourned   *adjournStore // Optional; games missing from memory are looked up here first
+	hibernated  *adjournStore // Optional; idle games are moved here and loaded back when looked up
+	lastUsed    map[string]time.Time // When each game was last looked up or busy; see hibernateIdle
+	checkpoints *checkpointer // Optional; every game is saved here after it changes and loaded back at startup or when looked up
+	observerKey string        // Secret organizers present to watch games omnisciently; empty disables observing
+	loading     map[string]*gameLoad // Games being loaded from the stores; see GetGame
+	mu          sync.RWMutex
+}
+
+// gameLoad is one game being loaded from the stores. Lookups of the same game while it
+// loads wait for it rather than loading it again.
+type gameLoad struct {
+	done chan struct{} // Closed once game is set
+	game *Game
 }
 
 var gameManager = &GameManager{
 	games: make(map[string]*Game),
 }
 
-func (gm *GameManager) GetOrCreateGame(gameID string) *Game {
+// CreateGame starts a new, empty game under a fresh server-generated ID
+func (gm *GameManager) CreateGame(ctx context.Context) *Game {
 	gm.mu.Lock()
 	defer gm.mu.Unlock()
 
-	if game, exists := gm.games[gameID]; exists {
-		return game
+	// In a cluster, only IDs that hash to this node, so joins can find it without a lookup.
+	// A fresh UUID isn't going to be in a store, so only memory is checked.
+	gameID := newUUID()
+	for gm.games[gameID] != nil || !cluster.owns(gameID) {
+		gameID = newUUID()
 	}
 
 	game := NewGame(gameID)
-	gm.games[gameID] = game
+	gm.addGame(game)
+	registerGame(gameID)
 	return game
 }
 
+// GetGame returns the game with this ID, or nil if there is none. A game missing from memory
+// is looked up in the stores without holding gm.mu, since a store may be across the network
+// and other games shouldn't wait on it.
+func (gm *GameManager) GetGame(ctx context.Context, gameID string) *Game {
+	gm.mu.Lock()
+	if game, exists := gm.games[gameID]; exists {
+		gm.touch(gameID, time.Now())
+		gm.mu.Unlock()
+		return game
+	}
+	if load, loading := gm.loading[gameID]; loading {
+		gm.mu.Unlock()
+		select {
+		case <-load.done:
+			return load.game
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	load := &gameLoad{done: make(chan struct{})}
+	if gm.loading == nil {
+		gm.loading = make(map[string]*gameLoad)
+	}
+	gm.loading[gameID] = load
+	gm.mu.Unlock()
+
+	load.game = gm.load(ctx, gameID)
+	gm.mu.Lock()
+	delete(gm.loading, gameID)
+	gm.mu.Unlock()
+	close(load.done)
+	return load.game
+}
+
+// load brings a game back into memory from the adjourned store if it was adjourned before
+// a restart, from the hibernation store if it went idle or from the checkpoint store if it
+// was being played elsewhere, or returns nil if none has it. Caller must not hold gm.mu.
+func (gm *GameManager) load(ctx context.Context, gameID string) *Game {
+	if gm.adjourned != nil {
+		if snap, err := gm.adjourned.Load(ctx, gameID); err == nil {
+			gm.mu.Lock()
+			defer gm.mu.Unlock()
+			if game, exists := gm.games[gameID]; exists {
+				return game
+			}
+			game := gameFromSnapshot(snap)
+			game.Adjourned = true
+			game.adjournStore = gm.adjourned
+			gm.addGame(game)
+			return game
+		}
+	}
+	if game := gm.wake(ctx, gameID); game != nil {
+		return game
+	}
+	return gm.loadCheckpoint(ctx, gameID)
+}
+
+// addGame registers a game and ties its goroutines to the manager's lifetime.
+// Caller must hold gm.mu.
+func (gm *GameManager) addGame(game *Game) {
+	if gm.ctx != nil {
+		game.ctx = gm.ctx
+	}
+	game.checkpoints = gm.checkpoints
+	gm.games[game.ID] = game
+	gm.touch(game.ID, time.Now())
+}
+
+// joinActions are the messages that take a seat or a place watching a game
+var joinActions = map[string]bool{
+	protocol.MsgCreateGame:    true,
+	protocol.MsgJoin:          true,
+	protocol.MsgSpectate:      true,
+	protocol.MsgStartDaily:    true,
+	protocol.MsgStartPuzzle:   true,
+	protocol.MsgStartTutorial: true,
+}
+
+// playerActions are the messages besides pausableActions that only a seated player may send
+var playerActions = map[string]bool{
+	protocol.MsgStartGame:    true,
+	protocol.MsgSetReady:     true,
+	protocol.MsgRename:       true,
+	protocol.MsgUpdateConfig: true,
+	protocol.MsgVoteKick:     true,
+	protocol.MsgPauseGame:    true,
+	protocol.MsgResumeGame:   true,
+	protocol.MsgAdjournGame:  true,
+	protocol.MsgReportPlayer: true,
+	protocol.MsgSendChat:     true,
+	protocol.MsgMutePlayer:   true,
+	protocol.MsgTransferHost: true,
+	protocol.MsgLockTable:    true,
+	protocol.MsgSetStreamerMode: true,
+	protocol.MsgSetHandicap:  true,
+	protocol.MsgSetNote:      true,
+	protocol.MsgBlockPlayer:  true,
+	protocol.MsgResync:       true,
+}
+
+// sendError tells conn its request was refused, in the language it joined with. key is
+// the error code, with a variant after a dot for codes that have more than one message;
+// args fill in the message. See catalogs.
+func sendError(conn *websocket.Conn, key string, args ...interface{}) {
+	code, _, _ := strings.Cut(key, ".")
+	writeJSON(conn, Message{
+		Type: protocol.MsgError,
+		Payload: map[string]string{
+			"code":    code,
+			"message": localize(conn, key, args...),
+		},
+	})
+}
+
+// sendSession tells a connection which game and seat it now holds, and the token that reclaims the seat
+func sendSession(conn *websocket.Conn, game *Game, playerID string) {
+	writeJSON(conn, Message{
+		Type: protocol.MsgSession,
+		Payload: map[string]string{
+			"gameID":       game.ID,
+			"playerID":     playerID,
+			"sessionToken": game.SessionToken(playerID),
+		},
+	})
+}
+
+// sendActionResult answers the player who sent an action
+func sendActionResult(conn *websocket.Conn, result ActionResult) {
+	result.Error = localizeError(conn, result.Code, result.Error)
+	writeJSON(conn, Message{Type: protocol.MsgActionResult, Payload: result})
+}
+
 func handleWebSocket(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	ip := clientIP(r)
+	if ban := bans.Check(ip, ""); ban != nil {
+		http.Error(w, banMessage(ban), http.StatusForbidden)
+		return
+	}
+
+	conn, err := upgradeWithWireFormat(w, r)
 	if err != nil {
 		log.Println("Upgrade error:", err)
 		return
 	}
 	defer conn.Close()
+	defer forgetWireFormat(conn)
+	defer releaseConn(conn)
+	defer forgetLatency(conn)
+	defer forgetLocale(conn)
+	defer forgetNotesKey(conn)
+	defer presence.Forget(conn)
+	defer forgetBlocklist(conn)
+
+	// Shutdown cancels ctx, which closes the socket and ends the read loop below
+	ctx := r.Context()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	// The game this connection joined, observes or spectates last, or that the current
+	// message names; actions go to it. The connection's other games wait in sessions.
+	var game *Game
+	var playerID, observerID, spectatorID string
+	sessions := make(connSessions)
+	current := func() *connSession {
+		return &connSession{game: game, playerID: playerID, observerID: observerID, spectatorID: spectatorID}
+	}
+	switchTo := func(s *connSession) {
+		game, playerID, observerID, spectatorID = s.game, s.playerID, s.observerID, s.spectatorID
+	}
+	reason := leftByClient
+	defer func() {
+		sessions.stash(current())
+		for _, s := range sessions {
+			s.leave(conn, ip, reason)
+		}
+	}()
 
-	var playerID, gameID string
-
+	watch := watchConn(conn)
+	pingDone := make(chan struct{})
+	defer close(pingDone)
+	go pingForLatency(conn, pingDone)
 	for {
-		var msg Message
-		err := conn.ReadJSON(&msg)
+		var msg clientMessage
+		err := readMessage(conn, &msg)
+		if reason = watch.reason(err); reason == leftIdle {
+			closeIdle(conn)
+			break
+		}
+		if reason == leftDead {
+			log.Printf("No pong from %s; closing", conn.RemoteAddr())
+			break
+		}
 		if err != nil {
 			log.Println("Read error:", err)
 			break
 		}
+		watch.touch()
 
-		switch msg.Type {
-		case "join":
-			payload := msg.Payload.(map[string]interface{})
-			gameID = payload["gameID"].(string)
-			playerID = payload["playerID"].(string)
-			name := payload["name"].(string)
-
-			game := gameManager.GetOrCreateGame(gameID)
-			if !game.AddPlayer(playerID, name, conn) {
-				conn.WriteJSON(Message{
-					Type:    "error",
-					Payload: map[string]string{"message": "Game is full"},
-				})
+		payload, err := decodePayload(&msg)
+		if err != nil {
+			sendPayloadError(conn, msg.Type, err)
+			continue
+		}
+
+		// A gameID on the message picks which of the connection's games it is for
+		if msg.GameID != "" && (game == nil || msg.GameID != game.ID) && !joinActions[msg.Type] && msg.Type != protocol.MsgObserve {
+			next := sessions.take(msg.GameID)
+			if next == nil {
+				sendError(conn, protocol.CodeNotInGame)
+				continue
+			}
+			sessions.stash(current())
+			switchTo(next)
+		}
+
+		// Actions are only taken from the connection currently holding a seat in the game
+		if (playerActions[msg.Type] || pausableActions[msg.Type]) && (game == nil || !game.HoldsSeat(playerID, conn)) {
+			sendError(conn, protocol.CodeNotInGame)
+			continue
+		}
+
+		if pausableActions[msg.Type] && game.IsPaused() {
+			sendError(conn, protocol.CodeGamePaused)
+			continue
+		}
+
+		// A tutorial only takes the move its current step asks for
+		if pausableActions[msg.Type] {
+			if err := game.CheckTutorial(playerID, msg.Type, cardIndexOf(payload)); err != nil {
+				sendActionResult(conn, game.ActionResult(playerID, msg.Type, err))
+				continue
+			}
+		}
+
+		// Turn moves sent at the wrong point in the turn all get the same answer
+		if pausableActions[msg.Type] {
+			if err := game.CheckPhase(playerID, msg.Type); err != nil {
+				sendActionResult(conn, game.ActionResult(playerID, msg.Type, err))
+				continue
+			}
+		}
+
+		// Bans are checked again on the way into a game, so one added after the socket opened still applies
+		if joinActions[msg.Type] {
+			options := joinOptionsOf(payload)
+			if options.Locale != "" {
+				setLocale(conn, options.Locale)
+			}
+			if options.NotesKey != "" {
+				setNotesKey(conn, options.NotesKey)
+			}
+			if options.Blocked != nil {
+				setBlocklist(conn, options.Blocked)
+			}
+			if ban := bans.Check(ip, options.Name); ban != nil {
+				sendBanned(conn, ban)
 				return
 			}
+			// Watching needs nothing new from a client, so only playing is gated on its version
+			if msg.Type != protocol.MsgSpectate && clientOutdated(options.ClientVersion) {
+				readOnly := outdatedReadOnly && msg.Type == protocol.MsgJoin
+				sendUpgradeRequired(conn, options.ClientVersion, readOnly)
+				if !readOnly {
+					continue
+				}
+				msg.Type = protocol.MsgSpectate
+				payload = &SpectatePayload{joinOptions: options}
+			}
+			if creatingActions[msg.Type] && captcha != nil {
+				if err := captcha.Verify(ctx, options.CaptchaToken, ip); err != nil {
+					sendError(conn, protocol.CodeCaptchaRequired)
+					continue
+				}
+			}
+			if !joinLimiter.Allow(ip) {
+				sendError(conn, protocol.CodeRateLimited)
+				continue
+			}
+			if msg.Type != protocol.MsgSpectate && !openGames.Allow(ip, options.GameID) {
+				sendError(conn, protocol.CodeTooManyGames, openGames.limit)
+				continue
+			}
+		}
+
+		// Joining another game keeps the connection in the ones it is already in
+		var previous *connSession
+		if joinActions[msg.Type] || msg.Type == protocol.MsgObserve {
+			previous = current()
+			switchTo(&connSession{})
+		}
+
+		seatedAs := playerID
+		switch msg.Type {
+		case protocol.MsgCreateGame:
+			payload := payload.(*CreateGamePayload)
+			var scheduledAt time.Time
+			if payload.ScheduledAt != "" {
+				var err error
+				if scheduledAt, err = parseScheduledAt(payload.ScheduledAt, time.Now()); err != nil {
+					sendError(conn, scheduleErrorKey(err))
+					break
+				}
+			}
+			var preset gamePreset
+			if payload.PresetID != "" {
+				var err error
+				if preset, err = presets.Get(notesKeyOf(conn), payload.PresetID); err != nil {
+					sendError(conn, protocol.CodePresetNotFound)
+					break
+				}
+			}
+			game = gameManager.CreateGame(ctx)
+			if !scheduledAt.IsZero() {
+				game.Schedule(scheduledAt)
+			}
+			playerID = newUUID()
+			game.AddPlayer(playerID, payload.Name, conn)
+			if preset.ID != "" {
+				game.UpdateConfig(playerID, preset.Config)
+			}
+			sendSession(conn, game, playerID)
+			game.BroadcastState()
+			presence.InviteFriends(conn, game.ID, playerID, time.Now())
+
+		case protocol.MsgJoin:
+			payload := payload.(*JoinPayload)
+			joining := gameManager.GetGame(ctx, payload.GameID)
+			if joining == nil && redirectToGame(conn, payload.GameID) {
+				break
+			}
+			if joining == nil {
+				sendError(conn, protocol.CodeGameNotFound)
+				break
+			}
+
+			// A session token reclaims an existing seat; otherwise the player gets a new one
+			game = joining
+			if restoredID := game.RestoreSeat(payload.SessionToken, conn, lastSeqOf(payload.LastSeq)); restoredID != "" {
+				playerID = restoredID
+			} else {
+				playerID = newUUID()
+				if !game.AddPlayer(playerID, payload.Name, conn) {
+					if game.IsLocked() {
+						sendError(conn, protocol.CodeTableLocked)
+					} else {
+						sendError(conn, protocol.CodeGameFull)
+					}
+					game, playerID = nil, ""
+					break
+				}
+			}
 
-			game.broadcastGameState()
+			sendSession(conn, game, playerID)
+			game.BroadcastState()
 
-		case "startGame":
-			game := gameManager.GetOrCreateGame(gameID)
+		case protocol.MsgObserve:
+			payload := payload.(*ObservePayload)
+			if !gameManager.authorizeObserver(payload.ObserverKey) {
+				sendError(conn, protocol.CodeNotAuthorized+".observe")
+				break
+			}
+			observed := gameManager.GetGame(ctx, payload.GameID)
+			if observed == nil && redirectToGame(conn, payload.GameID) {
+				break
+			}
+			if observed == nil {
+				sendError(conn, protocol.CodeGameNotFound)
+				break
+			}
+			game = observed
+			observerID = newSessionToken()
+			game.AddObserver(observerID, conn)
+
+		case protocol.MsgSpectate:
+			payload := payload.(*SpectatePayload)
+			watched := gameManager.GetGame(ctx, payload.GameID)
+			if watched == nil && redirectToGame(conn, payload.GameID) {
+				break
+			}
+			if watched == nil {
+				sendError(conn, protocol.CodeGameNotFound)
+				break
+			}
+			game = watched
+			spectatorID = newSessionToken()
+			game.AddSpectator(spectatorID, payload.Name, conn)
+			writeJSON(conn, Message{
+				Type:    protocol.MsgSpectating,
+				Payload: map[string]string{"spectatorID": spectatorID},
+			})
+
+		case protocol.MsgSubmitPrediction:
+			if game == nil || spectatorID == "" {
+				sendError(conn, protocol.CodeNotInGame+".spectate")
+				break
+			}
+			payload := payload.(*SubmitPredictionPayload)
+			prediction := Prediction{WinnerID: payload.WinnerID, PabloSucceeds: payload.PabloSucceeds}
+			if !game.SubmitPrediction(spectatorID, prediction) {
+				sendError(conn, protocol.CodePredictionClosed)
+			}
+
+		case protocol.MsgStartDaily:
+			payload := payload.(*StartDailyPayload)
+			playerID = newUUID()
+			game = gameManager.CreateDailyGame(playerID, payload.Name, conn)
+			sendSession(conn, game, playerID)
+
+		case protocol.MsgListPuzzles:
+			writeJSON(conn, Message{
+				Type:    protocol.MsgPuzzleList,
+				Payload: puzzles.List(),
+			})
+
+		case protocol.MsgStartPuzzle:
+			payload := payload.(*StartPuzzlePayload)
+			puzzle := puzzles.Get(payload.PuzzleID)
+			if puzzle == nil {
+				sendError(conn, protocol.CodePuzzleNotFound)
+				break
+			}
+			playerID = newUUID()
+			game = gameManager.CreatePuzzleGame(puzzle, playerID, payload.Name, conn)
+			sendSession(conn, game, playerID)
+
+		case protocol.MsgStartTutorial:
+			payload := payload.(*StartTutorialPayload)
+			playerID = newUUID()
+			game = gameManager.CreateTutorialGame(playerID, payload.Name, conn)
+			sendSession(conn, game, playerID)
+
+		case protocol.MsgPing:
+			writeJSON(conn, Message{Type: protocol.MsgPong})
+
+		case protocol.MsgLeaveGame:
+			// Stops following the game, e.g. a table a lobby page was previewing; a seat is kept as on disconnect
+			if game == nil {
+				sendError(conn, protocol.CodeNotInGame)
+				break
+			}
+			current().leave(conn, ip, leftByClient)
+			switchTo(&connSession{})
+
+		case protocol.MsgGetState:
+			// Lets a client that missed frames or just reconnected catch up without waiting for the next broadcast
+			var state *GameState
+			switch {
+			case game == nil:
+			case observerID != "":
+				state = game.StateFor(viewerObserver, "")
+			case game.HoldsSeat(playerID, conn):
+				state = game.StateFor(viewerPlayer, playerID)
+			case spectatorID != "":
+				state = game.PublicState()
+			}
+			if state == nil {
+				sendError(conn, protocol.CodeNotInGame)
+				break
+			}
+			writeJSON(conn, Message{Type: protocol.MsgGameState, Payload: state})
+
+		case protocol.MsgSetReady:
+			game.SetReady(playerID, payload.(*SetReadyPayload).Ready)
+
+		case protocol.MsgRename:
+			name := payload.(*RenamePayload).Name
+			// New names get the same ban check as the names players join with
+			if ban := bans.Check(ip, name); ban != nil {
+				sendBanned(conn, ban)
+				return
+			}
+			if _, ok := game.Rename(playerID, name); !ok {
+				sendError(conn, protocol.CodeInvalidMove+".rename")
+			}
+
+		case protocol.MsgStartGame:
 			game.StartGame()
 
-		case "drawCard":
-			game := gameManager.GetOrCreateGame(gameID)
-			game.DrawCard(playerID)
-
-		case "discardDrawnCard":
-			game := gameManager.GetOrCreateGame(gameID)
-			game.DiscardDrawnCard(playerID)
-
-		case "swapCard":
-			payload := msg.Payload.(map[string]interface{})
-			cardIndex := int(payload["cardIndex"].(float64))
-			game := gameManager.GetOrCreateGame(gameID)
-			game.SwapCard(playerID, cardIndex)
-
-		case "useSpecialCardFromDiscard":
-			payload := msg.Payload.(map[string]interface{})
-			cardRank := payload["cardRank"].(string)
-			params := payload["params"].(map[string]interface{})
-			game := gameManager.GetOrCreateGame(gameID)
-			game.UseSpecialCardFromDiscard(playerID, cardRank, params)
-
-		case "skipSpecialCard":
-			game := gameManager.GetOrCreateGame(gameID)
-			game.SkipSpecialCard(playerID)
-
-		case "callPablo":
-			game := gameManager.GetOrCreateGame(gameID)
-			game.CallPablo(playerID)
-
-		case "endTurn":
-			game := gameManager.GetOrCreateGame(gameID)
-			game.EndTurn(playerID)
-
-		case "stackCard":
-			payload := msg.Payload.(map[string]interface{})
-			cardIndex := int(payload["cardIndex"].(float64))
-			game := gameManager.GetOrCreateGame(gameID)
-			success, errorMsg := game.StackCard(playerID, cardIndex)
-			if !success {
-				// Send error message to the player who attempted to stack
-				conn.WriteJSON(Message{
-					Type:    "stackError",
-					Payload: map[string]string{"message": errorMsg},
+		case protocol.MsgDrawCard:
+			err := game.DrawCard(playerID)
+			sendActionResult(conn, game.ActionResult(playerID, msg.Type, err))
+
+		case protocol.MsgDrawFromDiscard:
+			err := game.DrawFromDiscard(playerID)
+			sendActionResult(conn, game.ActionResult(playerID, msg.Type, err))
+
+		case protocol.MsgUpdateConfig:
+			config, err := decodeGameConfig(payload.(*UpdateConfigPayload).Config)
+			if err != nil {
+				sendError(conn, protocol.CodeInvalidConfig)
+				break
+			}
+			game.UpdateConfig(playerID, config)
+
+		case protocol.MsgDiscardDrawnCard:
+			err := game.DiscardDrawnCard(playerID)
+			sendActionResult(conn, game.ActionResult(playerID, msg.Type, err))
+
+		case protocol.MsgSwapCard:
+			cardIndex := *payload.(*SwapCardPayload).CardIndex
+			err := game.SwapCard(playerID, cardIndex)
+			sendActionResult(conn, game.ActionResult(playerID, msg.Type, err, cardIndex))
+
+		case protocol.MsgSwapMultipleCards:
+			cardIndices := payload.(*SwapMultipleCardsPayload).CardIndices
+			err := game.SwapMultipleCards(playerID, cardIndices)
+			if err != nil {
+				writeJSON(conn, Message{
+					Type:    protocol.MsgSwapError,
+					Payload: map[string]string{"code": errorCode(err), "message": localizeError(conn, errorCode(err), err.Error())},
 				})
 			}
+			sendActionResult(conn, game.ActionResult(playerID, msg.Type, err, cardIndices...))
+
+		case protocol.MsgUseSpecialCardFromDiscard:
+			payload := payload.(*UseSpecialCardPayload)
+			err := game.UseSpecialCardFromDiscard(playerID, payload.CardRank, payload.Params)
+			sendActionResult(conn, game.ActionResult(playerID, msg.Type, err))
+
+		case protocol.MsgSkipSpecialCard:
+			err := game.SkipSpecialCard(playerID)
+			sendActionResult(conn, game.ActionResult(playerID, msg.Type, err))
 
-		case "stackOpponentCard":
-			payload := msg.Payload.(map[string]interface{})
-			targetPlayerID := payload["targetPlayerID"].(string)
-			cardIndex := int(payload["cardIndex"].(float64))
-			game := gameManager.GetOrCreateGame(gameID)
-			success, errorMsg := game.StackOpponentCard(playerID, targetPlayerID, cardIndex)
-			if !success && errorMsg != "" {
-				conn.WriteJSON(Message{
-					Type:    "stackError",
-					Payload: map[string]string{"message": errorMsg},
+		case protocol.MsgConfirmKingSwap:
+			ownIndex := *payload.(*ConfirmKingSwapPayload).OwnIndex
+			err := game.ConfirmKingSwap(playerID, ownIndex)
+			sendActionResult(conn, game.ActionResult(playerID, msg.Type, err, ownIndex))
+
+		case protocol.MsgDeclineKingSwap:
+			err := game.DeclineKingSwap(playerID)
+			sendActionResult(conn, game.ActionResult(playerID, msg.Type, err))
+
+		case protocol.MsgVoteKick:
+			game.VoteKick(playerID, payload.(*VoteKickPayload).TargetID)
+
+		case protocol.MsgPauseGame:
+			game.RequestPause(playerID)
+
+		case protocol.MsgResumeGame:
+			game.RequestResume(playerID)
+
+		case protocol.MsgAdjournGame:
+			if !game.Adjourn(ctx, playerID, gameManager.adjourned) {
+				sendError(conn, protocol.CodeAdjournFailed)
+			}
+
+		case protocol.MsgReportPlayer:
+			payload := payload.(*ReportPlayerPayload)
+			report, err := game.NewReport(playerID, payload.TargetID, payload.Reason)
+			if err == nil {
+				err = reports.Add(report)
+			}
+			switch {
+			case err == errDuplicateReport:
+				sendError(conn, protocol.CodeReportInvalid+".duplicate")
+			case err == errInvalidReport:
+				sendError(conn, protocol.CodeReportInvalid)
+			case err != nil:
+				log.Println("Saving report:", err)
+				sendError(conn, protocol.CodeReportInvalid+".unsaved")
+			default:
+				writeJSON(conn, Message{
+					Type:    protocol.MsgReportReceived,
+					Payload: map[string]string{"reportID": report.ID},
 				})
 			}
 
-		case "giveCardToPlayer":
-			payload := msg.Payload.(map[string]interface{})
-			sourceIndex := int(payload["sourceIndex"].(float64))
-			game := gameManager.GetOrCreateGame(gameID)
-			game.HandleGiveCard(playerID, sourceIndex)
+		case protocol.MsgSendChat:
+			text, err := checkChat(payload.(*SendChatPayload).Text)
+			if err == nil {
+				text, err = chatFilters.Run(ctx, ChatMessage{GameID: game.ID, PlayerID: playerID, Text: text})
+			}
+			switch err {
+			case nil:
+				game.Chat(playerID, text)
+			case errChatEmpty:
+			case errChatTooLong:
+				sendError(conn, protocol.CodeChatRejected+".length", chatLengthLimit)
+			default:
+				sendError(conn, protocol.CodeChatRejected)
+			}
+
+		case protocol.MsgBlockPlayer:
+			payload := payload.(*BlockPlayerPayload)
+			switch err := game.SetBlocked(playerID, payload.PlayerID, payload.Blocked); {
+			case errors.Is(err, errCantBlock):
+				sendError(conn, protocol.CodeInvalidMove+".block")
+			case err != nil:
+				sendError(conn, errorCode(err))
+			}
+
+		case protocol.MsgMutePlayer:
+			payload := payload.(*MutePlayerPayload)
+			if !game.IsHost(playerID) {
+				sendError(conn, protocol.CodeNotAuthorized+".mute")
+				break
+			}
+			game.MutePlayer(playerID, payload.TargetID, payload.Muted)
+
+		case protocol.MsgTransferHost:
+			if !game.IsHost(playerID) {
+				sendError(conn, protocol.CodeNotAuthorized+".transfer")
+				break
+			}
+			game.TransferHost(playerID, payload.(*TransferHostPayload).TargetID)
+
+		case protocol.MsgSetStreamerMode:
+			enabled := payload.(*SetStreamerModePayload).Enabled
+			reply := map[string]interface{}{"enabled": enabled}
+			if enabled {
+				key, _ := game.StartStream(playerID)
+				reply["key"] = key
+				reply["delaySecs"] = int(streamDelay / time.Second)
+			} else {
+				game.StopStream(playerID)
+			}
+			writeJSON(conn, Message{Type: protocol.MsgStreamerMode, Payload: reply})
+
+		case protocol.MsgLockTable:
+			if !game.SetLocked(playerID, payload.(*LockTablePayload).Locked) {
+				sendError(conn, protocol.CodeNotAuthorized+".lock")
+			}
+
+		case protocol.MsgSetHandicap:
+			payload := payload.(*SetHandicapPayload)
+			if !game.IsHost(playerID) {
+				sendError(conn, protocol.CodeNotAuthorized+".handicap")
+				break
+			}
+			if !game.SetHandicap(playerID, payload.TargetID, payload.Points) {
+				sendError(conn, protocol.CodeInvalidMove+".handicap")
+			}
+
+		case protocol.MsgSetNote:
+			payload := payload.(*SetNotePayload)
+			switch err := game.SetNote(playerID, payload.PlayerID, payload.Text); {
+			case errors.Is(err, errInvalidNote):
+				sendError(conn, protocol.CodeInvalidMove+".note", maxNoteLength)
+			case err != nil:
+				sendError(conn, errorCode(err))
+			}
+
+		case protocol.MsgResync:
+			game.Resync(playerID, lastSeqOf(payload.(*ResyncPayload).LastSeq))
+
+		case protocol.MsgGetNotes:
+			sendNotes(conn)
+
+		case protocol.MsgSavePreset, protocol.MsgDeletePreset, protocol.MsgGetPresets:
+			handlePresetMessage(conn, msg.Type, payload.(*PresetPayload))
+
+		case protocol.MsgPresence:
+			payload := payload.(*PresencePayload)
+			code := presence.Announce(conn, payload.FriendKey, payload.Name, payload.Friends)
+			if code == "" {
+				sendError(conn, protocol.CodeInvalidMove+".presence", minFriendKeyLength)
+				break
+			}
+			writeJSON(conn, Message{Type: protocol.MsgFriendCode, Payload: map[string]string{"friendCode": code}})
+
+		case protocol.MsgListGames:
+			sendGameList(conn, payload.(*ListGamesPayload).Joinable)
+
+		case protocol.MsgGetLeaderboard:
+			sendLeaderboard(conn)
+
+		case protocol.MsgAcceptInvite, protocol.MsgDeclineInvite:
+			answerInvite(conn, payload.(*InvitePayload).InviteID, msg.Type == protocol.MsgAcceptInvite)
+
+		case protocol.MsgCallPablo:
+			err := game.CallPablo(playerID)
+			sendActionResult(conn, game.ActionResult(playerID, msg.Type, err))
+
+		case protocol.MsgEndTurn:
+			err := game.EndTurn(playerID)
+			sendActionResult(conn, game.ActionResult(playerID, msg.Type, err))
+
+		case protocol.MsgRevealClaim:
+			err := game.RevealClaim(playerID)
+			sendActionResult(conn, game.ActionResult(playerID, msg.Type, err))
+
+		case protocol.MsgPeekCard:
+			cardIndex := *payload.(*PeekCardPayload).CardIndex
+			err := game.PeekCard(playerID, cardIndex)
+			sendActionResult(conn, game.ActionResult(playerID, msg.Type, err, cardIndex))
+
+		case protocol.MsgConfirmPeek:
+			err := game.ConfirmPeek(playerID)
+			sendActionResult(conn, game.ActionResult(playerID, msg.Type, err))
+
+		case protocol.MsgStackCard:
+			cardIndex := *payload.(*StackCardPayload).CardIndex
+			err := game.StackCard(playerID, cardIndex)
+			if err != nil {
+				// Send error message to the player who attempted to stack
+				writeJSON(conn, Message{
+					Type:    protocol.MsgStackError,
+					Payload: map[string]string{"code": errorCode(err), "message": localizeError(conn, errorCode(err), err.Error())},
+				})
+			}
+			sendActionResult(conn, game.ActionResult(playerID, msg.Type, err, cardIndex))
+
+		case protocol.MsgStackOpponentCard:
+			payload := payload.(*StackOpponentCardPayload)
+			err := game.StackOpponentCard(playerID, payload.TargetPlayerID, *payload.CardIndex)
+			if err != nil {
+				writeJSON(conn, Message{
+					Type:    protocol.MsgStackError,
+					Payload: map[string]string{"code": errorCode(err), "message": localizeError(conn, errorCode(err), err.Error())},
+				})
+			}
+			sendActionResult(conn, game.ActionResult(playerID, msg.Type, err))
+
+		case protocol.MsgGiveCardToPlayer:
+			sourceIndex := *payload.(*GiveCardPayload).SourceIndex
+			err := game.HandleGiveCard(playerID, sourceIndex)
+			sendActionResult(conn, game.ActionResult(playerID, msg.Type, err, sourceIndex))
+		}
+
+		if previous != nil {
+			if game == nil {
+				switchTo(previous) // Nothing was joined; carry on as before
+			} else {
+				sessions.stash(previous)
+				// Whatever the connection was already doing in this game ends, except the seat it may have taken back
+				sessions.end(conn, ip, game.ID, playerID)
+			}
+		}
+
+		if playerID != "" && playerID != seatedAs {
+			game.RecordConnection(playerID, ip, connConnected)
+			openGames.Hold(ip, game, playerID)
 		}
 	}
 }
 
 func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	gameManager.ctx = ctx
+
+	adjournDir := os.Getenv("PABLO_ADJOURN_DIR")
+	if adjournDir == "" {
+		adjournDir = "adjourned"
+	}
+	adjournDays := 7
+	if days, err := strconv.Atoi(os.Getenv("PABLO_ADJOURN_DAYS")); err == nil && days > 0 {
+		adjournDays = days
+	}
+	gameManager.observerKey = os.Getenv("PABLO_OBSERVER_KEY")
+	allowedOrigins = parseAllowedOrigins(os.Getenv("PABLO_ALLOWED_ORIGINS"))
+	if len(allowedOrigins) == 0 {
+		log.Println("PABLO_ALLOWED_ORIGINS is unset; pages from any origin may connect")
+	}
+	if nodes := os.Getenv("PABLO_CLUSTER_NODES"); nodes != "" {
+		var directory *redisDirectory
+		var err error
+		if redisURL := os.Getenv("PABLO_REDIS_URL"); redisURL != "" {
+			if directory, err = newRedisDirectory(redisURL); err != nil {
+				log.Fatal("Setting up the game directory: ", err)
+			}
+		}
+		if cluster, err = newGameCluster(os.Getenv("PABLO_NODE_ID"), nodes, directory); err != nil {
+			log.Fatal("Setting up the cluster: ", err)
+		}
+	}
+	adminKey = os.Getenv("PABLO_ADMIN_KEY")
+	lockStats.enabled.Store(os.Getenv("PABLO_LOCK_STATS") != "")
+	if d, err := time.ParseDuration(os.Getenv("PABLO_IDLE_TIMEOUT")); err == nil && d >= 0 {
+		idleTimeout.Store(int64(d))
+	}
+	if d, err := time.ParseDuration(os.Getenv("PABLO_STREAM_DELAY")); err == nil && d >= 0 {
+		streamDelay = d
+	}
+	joinLimit, joinWindow := defaultJoinLimit, defaultJoinWindow
+	if n, err := strconv.Atoi(os.Getenv("PABLO_JOIN_LIMIT")); err == nil && n >= 0 {
+		joinLimit = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("PABLO_JOIN_WINDOW")); err == nil && d > 0 {
+		joinWindow = d
+	}
+	joinLimiter = newRateLimiter(joinLimit, joinWindow)
+	openGameLimit := defaultOpenGameLimit
+	if n, err := strconv.Atoi(os.Getenv("PABLO_OPEN_GAME_LIMIT")); err == nil && n >= 0 {
+		openGameLimit = n
+	}
+	openGames = newOpenGameCap(openGameLimit)
+	if n, err := strconv.Atoi(os.Getenv("PABLO_RECENT_GAMES")); err == nil && n >= 0 {
+		recentGames.limit = n
+	}
+	minClientVersion = os.Getenv("PABLO_MIN_CLIENT_VERSION")
+	outdatedReadOnly = os.Getenv("PABLO_OUTDATED_READ_ONLY") != ""
+	if verifyURL, secret := os.Getenv("PABLO_CAPTCHA_VERIFY_URL"), os.Getenv("PABLO_CAPTCHA_SECRET"); verifyURL != "" && secret != "" {
+		captcha = newCaptchaVerifier(verifyURL, secret)
+	}
+
+	banFile := os.Getenv("PABLO_BAN_FILE")
+	if banFile == "" {
+		banFile = "bans.json"
+	}
+	loaded, err := loadBanList(banFile)
+	if err != nil {
+		log.Fatal("Loading bans: ", err)
+	}
+	bans = loaded
+
+	reportFile := os.Getenv("PABLO_REPORT_FILE")
+	if reportFile == "" {
+		reportFile = "reports.json"
+	}
+	if reports, err = loadReportStore(reportFile); err != nil {
+		log.Fatal("Loading reports: ", err)
+	}
+	notesFile := os.Getenv("PABLO_NOTES_FILE")
+	if notesFile == "" {
+		notesFile = "notes.json"
+	}
+	if notes, err = loadNoteStore(notesFile); err != nil {
+		log.Fatal("Loading notes: ", err)
+	}
+	presetsFile := os.Getenv("PABLO_PRESETS_FILE")
+	if presetsFile == "" {
+		presetsFile = "presets.json"
+	}
+	if presets, err = loadPresetStore(presetsFile); err != nil {
+		log.Fatal("Loading presets: ", err)
+	}
+	statsFile := os.Getenv("PABLO_STATS_FILE")
+	if statsFile == "" {
+		statsFile = "stats.json"
+	}
+	if playerStatsBoard, err = loadPlayerStatsStore(statsFile); err != nil {
+		log.Fatal("Loading player stats: ", err)
+	}
+	seatsFile := os.Getenv("PABLO_SEATS_FILE")
+	if seatsFile == "" {
+		seatsFile = "seats.json"
+	}
+	if seatRecords, err = loadSeatRecordStore(seatsFile); err != nil {
+		log.Fatal("Loading seat records: ", err)
+	}
+	storeDriver := os.Getenv("PABLO_STORE_DRIVER")
+	if storeDriver == "" {
+		storeDriver = "postgres"
+	}
+	storage, err := openGameStoreBackend(ctx, os.Getenv("PABLO_STORE"), os.Getenv("PABLO_STORE_URL"), storeDriver)
+	if err != nil {
+		log.Fatal("Opening the game store: ", err)
+	}
+	adjournTTL := time.Duration(adjournDays) * 24 * time.Hour
+	gameManager.adjourned = &adjournStore{games: storage.store("adjourned", adjournDir), ttl: adjournTTL}
+	hibernateAfter := defaultHibernateAfter
+	if d, err := time.ParseDuration(os.Getenv("PABLO_HIBERNATE_AFTER")); err == nil && d >= 0 {
+		hibernateAfter = d
+	}
+	if hibernateAfter > 0 {
+		hibernateDir := os.Getenv("PABLO_HIBERNATE_DIR")
+		if hibernateDir == "" {
+			hibernateDir = "hibernated"
+		}
+		gameManager.hibernated = &adjournStore{games: storage.store("hibernated", hibernateDir), ttl: adjournTTL}
+		go gameManager.runHibernation(ctx, hibernateAfter)
+	}
+	checkpointDelay := defaultCheckpointDelay
+	if d, err := time.ParseDuration(os.Getenv("PABLO_CHECKPOINT_DELAY")); err == nil && d >= 0 {
+		checkpointDelay = d
+	}
+	if checkpointDelay > 0 {
+		checkpointDir := os.Getenv("PABLO_CHECKPOINT_DIR")
+		if checkpointDir == "" {
+			checkpointDir = "checkpoints"
+		}
+		gameManager.checkpoints = newCheckpointer(&adjournStore{games: storage.store("checkpoints", checkpointDir), ttl: adjournTTL}, checkpointDelay)
+		if restored, err := gameManager.restoreCheckpoints(ctx); err != nil {
+			log.Println("Restoring checkpointed games: ", err)
+		} else if restored > 0 {
+			log.Printf("Restored %d checkpointed games", restored)
+		}
+		go gameManager.checkpoints.run(ctx)
+	}
+	go storeWrites.run(ctx)
+	go func() {
+		for _, store := range []*adjournStore{gameManager.adjourned, gameManager.hibernated} {
+			if store == nil {
+				continue
+			}
+			if purged, err := store.PurgeExpired(ctx); err != nil {
+				log.Println("Purging expired games: ", err)
+			} else if purged > 0 {
+				log.Printf("Purged %d expired saved games", purged)
+			}
+		}
+	}()
+
+	vacateAfter := defaultVacateAfter
+	if d, err := time.ParseDuration(os.Getenv("PABLO_VACATE_AFTER")); err == nil && d >= 0 {
+		vacateAfter = d
+	}
+	if vacateAfter > 0 {
+		go gameManager.runVacancyReaper(ctx, vacateAfter)
+	}
+
+	var publishers []EventPublisher
+	eventTopic := os.Getenv("PABLO_EVENTS_TOPIC")
+	if eventTopic == "" {
+		eventTopic = "pablo.events"
+	}
+	if natsURL := os.Getenv("PABLO_EVENTS_NATS_URL"); natsURL != "" {
+		publisher, err := newNATSPublisher(natsURL, eventTopic)
+		if err != nil {
+			log.Fatal("Connecting to NATS: ", err)
+		}
+		publishers = append(publishers, publisher)
+	}
+	if proxyURL := os.Getenv("PABLO_EVENTS_KAFKA_REST_URL"); proxyURL != "" {
+		publishers = append(publishers, newKafkaRESTPublisher(proxyURL, eventTopic))
+	}
+	if logDir := os.Getenv("PABLO_EVENTS_LOG_DIR"); logDir != "" {
+		publisher, err := newEventLogPublisher(logDir)
+		if err != nil {
+			log.Fatal("Opening event log: ", err)
+		}
+		publishers = append(publishers, publisher)
+		eventLogStore = publisher
+
+		interval := time.Hour
+		if d, err := time.ParseDuration(os.Getenv("PABLO_ANALYTICS_INTERVAL")); err == nil && d > 0 {
+			interval = d
+		}
+		analytics = newAnalyticsJob(logDir)
+		go analytics.Run(ctx, interval)
+	}
+	if token := os.Getenv("PABLO_TELEGRAM_TOKEN"); token != "" {
+		apiURL := os.Getenv("PABLO_TELEGRAM_API_URL")
+		if apiURL == "" {
+			apiURL = telegramAPI
+		}
+		telegram = newTelegramBot(apiURL+token, os.Getenv("PABLO_TELEGRAM_SECRET"), os.Getenv("PABLO_TELEGRAM_GAME_URL"))
+		publishers = append(publishers, telegram)
+		log.Println("Telegram bot enabled; set its webhook to /telegram/webhook")
+	}
+	if storage.kind == "redis" || storage.kind == "sql" {
+		gameEvents = storage.store("events", "")
+		publishers = append(publishers, storeEventPublisher{store: gameEvents})
+	}
+	if len(publishers) > 0 {
+		events = newEventBus(publishers...)
+	}
+
+	chatFilters, err = newChatPipeline(os.Getenv("PABLO_CHAT_FILTERS"), os.Getenv("PABLO_CHAT_WORDLIST"), os.Getenv("PABLO_CHAT_MODERATION_URL"))
+	if err != nil {
+		log.Fatal("Setting up chat filters: ", err)
+	}
+
+	if err := puzzles.loadPuzzles(os.Getenv("PABLO_PUZZLE_DIR")); err != nil {
+		log.Fatal("Loading puzzles: ", err)
+	}
+
 	http.HandleFunc("/ws", handleWebSocket)
+	http.HandleFunc("/daily/leaderboard", handleDailyLeaderboard)
+	http.HandleFunc("/analytics", handleAnalytics)
+	http.HandleFunc("/players/", handlePlayers)
+	http.HandleFunc("/games", handleListGames)
+	http.HandleFunc("/games/", handleGames)
+	http.HandleFunc("/leagues/", handleLeagues)
+	http.HandleFunc("/privacy/delete", handlePrivacyDelete)
+	http.HandleFunc("/admin/bans", handleAdminBans)
+	http.HandleFunc("/admin/bans/", handleAdminBans)
+	http.HandleFunc("/admin/reports", handleAdminReports)
+	http.HandleFunc("/admin/reports/", handleAdminReports)
+	http.HandleFunc("/admin/games/", handleAdminGames)
+	http.HandleFunc("/admin/leagues", handleAdminLeagues)
+	http.HandleFunc("/admin/lockstats", handleAdminLockStats)
+	http.HandleFunc("/admin/latency", handleAdminLatency)
+	http.HandleFunc("/telegram/webhook", handleTelegramWebhook)
+
+	server := &http.Server{
+		Addr:        ":8080",
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		log.Println("Shutting down")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Println("Shutdown error:", err)
+		}
+	}()
 
 	log.Println("Server starting on :8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	<-shutdownDone
+	if gameManager.checkpoints != nil {
+		// Save the games changed since the last checkpoint, so the next process picks up from here
+		<-gameManager.checkpoints.done
+	}
+	// Make the directory and seat record writes still queued
+	<-storeWrites.done
+	if events != nil {
+		// Publish whatever the last games emitted before exiting
+		events.Close()
+	}
 }
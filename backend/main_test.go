@@ -1,7 +1,11 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"testing"
+
+	"github.com/gorilla/websocket"
 )
 
 // Helper function to create a test game
@@ -195,8 +199,8 @@ func TestDrawCard(t *testing.T) {
 	initialDeckSize := len(game.Deck)
 	
 	// Current player can draw
-	success := game.DrawCard(currentPlayer)
-	if !success {
+	err := game.DrawCard(currentPlayer)
+	if err != nil {
 		t.Error("Current player should be able to draw")
 	}
 	
@@ -218,8 +222,8 @@ func TestDrawCard(t *testing.T) {
 	}
 	
 	// Can't draw again in same turn
-	success = game.DrawCard(currentPlayer)
-	if success {
+	err = game.DrawCard(currentPlayer)
+	if err == nil {
 		t.Error("Should not be able to draw twice in same turn")
 	}
 	
@@ -229,8 +233,8 @@ func TestDrawCard(t *testing.T) {
 		otherPlayer = playerIDs[1]
 	}
 	
-	success = game.DrawCard(otherPlayer)
-	if success {
+	err = game.DrawCard(otherPlayer)
+	if err == nil {
 		t.Error("Non-current player should not be able to draw")
 	}
 }
@@ -244,8 +248,8 @@ func TestDrawCardEmptyDeck(t *testing.T) {
 	game.Deck = []Card{}
 	
 	// Drawing should end the round
-	success := game.DrawCard(game.CurrentPlayer)
-	if success {
+	err := game.DrawCard(game.CurrentPlayer)
+	if err == nil {
 		t.Error("Should not be able to draw from empty deck")
 	}
 	
@@ -265,8 +269,8 @@ func TestDiscardDrawnCard(t *testing.T) {
 	initialDiscardSize := len(game.DiscardPile)
 	drawnCard := game.DrawnCards[currentPlayer]
 	
-	success := game.DiscardDrawnCard(currentPlayer)
-	if !success {
+	err := game.DiscardDrawnCard(currentPlayer)
+	if err != nil {
 		t.Error("Should be able to discard drawn card")
 	}
 	
@@ -330,8 +334,8 @@ func TestSwapCard(t *testing.T) {
 	originalCard := game.Players[currentPlayer].Cards[0]
 	drawnCard := game.DrawnCards[currentPlayer]
 	
-	success := game.SwapCard(currentPlayer, 0)
-	if !success {
+	err := game.SwapCard(currentPlayer, 0)
+	if err != nil {
 		t.Error("Should be able to swap card")
 	}
 	
@@ -352,6 +356,229 @@ func TestSwapCard(t *testing.T) {
 	}
 }
 
+func TestDrawFromDiscard(t *testing.T) {
+	game := createTestGame("test-game")
+	addTestPlayers(game, 2)
+	game.Config.AllowDrawFromDiscard = true
+	game.StartGame()
+	
+	currentPlayer := game.CurrentPlayer
+	
+	// Put a known non-special card on the discard pile
+	discarded := Card{Suit: "spades", Rank: "4", FaceUp: true}
+	game.DiscardPile = append(game.DiscardPile, discarded)
+	game.StackableCardIndex = len(game.DiscardPile) - 1
+	initialDeckSize := len(game.Deck)
+	
+	err := game.DrawFromDiscard(currentPlayer)
+	if err != nil {
+		t.Fatal("Current player should be able to draw from discard")
+	}
+	
+	if len(game.Deck) != initialDeckSize {
+		t.Error("Deck should not change when drawing from discard")
+	}
+	
+	if len(game.DiscardPile) != 0 {
+		t.Errorf("Expected empty discard pile, got %d cards", len(game.DiscardPile))
+	}
+	
+	if game.StackableCardIndex != -1 {
+		t.Error("StackableCardIndex should be cleared after taking the top discard")
+	}
+	
+	drawnCard := game.DrawnCards[currentPlayer]
+	if drawnCard == nil || drawnCard.Rank != "4" || drawnCard.Suit != "spades" {
+		t.Fatal("Drawn card should be the former top discard")
+	}
+	
+	// Can't put it straight back
+	if game.DiscardDrawnCard(currentPlayer) == nil {
+		t.Error("Card taken from discard should not be discardable")
+	}
+	
+	// Can't also draw from the deck
+	if game.DrawCard(currentPlayer) == nil {
+		t.Error("Should not be able to draw again in the same turn")
+	}
+	
+	originalCard := game.Players[currentPlayer].Cards[0]
+	if game.SwapCard(currentPlayer, 0) != nil {
+		t.Fatal("Should be able to swap card taken from discard")
+	}
+	
+	if game.Players[currentPlayer].Cards[0].Rank != "4" {
+		t.Error("Swapped-in card should be the one taken from discard")
+	}
+	
+	topCard := game.DiscardPile[len(game.DiscardPile)-1]
+	if topCard.Rank != originalCard.Rank || topCard.Suit != originalCard.Suit {
+		t.Error("Replaced card should be on top of the discard pile")
+	}
+	
+	if game.DrawnFromDiscard[currentPlayer] {
+		t.Error("DrawnFromDiscard should be cleared after swap")
+	}
+}
+
+func TestDrawFromDiscardDisabled(t *testing.T) {
+	game := createTestGame("test-game")
+	addTestPlayers(game, 2)
+	game.StartGame()
+	
+	game.DiscardPile = append(game.DiscardPile, Card{Suit: "spades", Rank: "4", FaceUp: true})
+	
+	if game.DrawFromDiscard(game.CurrentPlayer) == nil {
+		t.Error("Drawing from discard should be rejected when the rule is off")
+	}
+}
+
+func TestUpdateConfig(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	
+	if game.UpdateConfig("stranger", GameConfig{AllowDrawFromDiscard: true}) {
+		t.Error("Non-players should not be able to change the config")
+	}
+	
+	if !game.UpdateConfig(playerIDs[0], GameConfig{AllowDrawFromDiscard: true}) {
+		t.Error("Players should be able to change the config while waiting")
+	}
+	
+	if !game.Config.AllowDrawFromDiscard {
+		t.Error("Config should be updated")
+	}
+	
+	game.StartGame()
+	if game.UpdateConfig(playerIDs[0], DefaultGameConfig()) {
+		t.Error("Config should not change once the game has started")
+	}
+}
+
+func TestSwapMultipleCards(t *testing.T) {
+	game := createTestGame("test-game")
+	addTestPlayers(game, 2)
+	game.Config.AllowMultiDiscard = true
+	game.StartGame()
+	
+	currentPlayer := game.CurrentPlayer
+	player := game.Players[currentPlayer]
+	player.Cards[0] = Card{Suit: "hearts", Rank: "5"}
+	player.Cards[2] = Card{Suit: "clubs", Rank: "5"}
+	game.DrawnCards[currentPlayer] = &Card{Suit: "spades", Rank: "2", FaceUp: true}
+	game.HasDrawnThisTurn[currentPlayer] = true
+	
+	err := game.SwapMultipleCards(currentPlayer, []int{0, 2})
+	if err != nil {
+		t.Fatalf("Should be able to discard matching cards: %v", err)
+	}
+	
+	if player.Cards[0].Rank != "2" || player.Cards[0].FaceUp {
+		t.Error("Drawn card should take the first declared slot face down")
+	}
+	
+	if player.Cards[2].Rank != "" {
+		t.Error("Second declared slot should be empty")
+	}
+	
+	if game.countNonEmptyCards(player) != 3 {
+		t.Errorf("Expected 3 cards after discarding two, got %d", game.countNonEmptyCards(player))
+	}
+	
+	if len(game.DiscardPile) != 2 {
+		t.Errorf("Expected 2 cards on discard pile, got %d", len(game.DiscardPile))
+	}
+	
+	if game.StackableCardIndex != len(game.DiscardPile)-1 {
+		t.Error("Top discard should be stackable")
+	}
+	
+	if _, exists := game.DrawnCards[currentPlayer]; exists {
+		t.Error("Drawn card should be removed")
+	}
+}
+
+func TestSwapMultipleCardsMismatch(t *testing.T) {
+	game := createTestGame("test-game")
+	addTestPlayers(game, 2)
+	game.Config.AllowMultiDiscard = true
+	game.StartGame()
+	
+	currentPlayer := game.CurrentPlayer
+	player := game.Players[currentPlayer]
+	player.Cards[0] = Card{Suit: "hearts", Rank: "5"}
+	player.Cards[1] = Card{Suit: "clubs", Rank: "6"}
+	game.DrawnCards[currentPlayer] = &Card{Suit: "spades", Rank: "2", FaceUp: true}
+	game.HasDrawnThisTurn[currentPlayer] = true
+	
+	err := game.SwapMultipleCards(currentPlayer, []int{0, 1})
+	if err == nil {
+		t.Fatal("Mismatched declaration should fail")
+	}
+	
+	if player.Cards[0].Rank != "5" || player.Cards[1].Rank != "6" {
+		t.Error("Declared cards should stay in the hand")
+	}
+	
+	if len(player.Cards) != 5 {
+		t.Errorf("Expected penalty card, hand has %d slots", len(player.Cards))
+	}
+	
+	topCard := game.DiscardPile[len(game.DiscardPile)-1]
+	if topCard.Rank != "2" || topCard.Suit != "spades" {
+		t.Error("Drawn card should be discarded")
+	}
+}
+
+func TestSwapMultipleCardsMismatchFromDiscard(t *testing.T) {
+	game := createTestGame("test-game")
+	addTestPlayers(game, 2)
+	game.Config.AllowMultiDiscard = true
+	game.Config.AllowDrawFromDiscard = true
+	game.StartGame()
+	
+	currentPlayer := game.CurrentPlayer
+	player := game.Players[currentPlayer]
+	player.Cards[0] = Card{Suit: "hearts", Rank: "5"}
+	player.Cards[1] = Card{Suit: "clubs", Rank: "6"}
+	game.DiscardPile = []Card{{Suit: "spades", Rank: "7", FaceUp: true}}
+	game.StackableCardIndex = -1
+	if err := game.DrawFromDiscard(currentPlayer); err != nil {
+		t.Fatalf("Failed to draw from discard: %v", err)
+	}
+	
+	if err := game.SwapMultipleCards(currentPlayer, []int{0, 1}); !errors.Is(err, ErrCardMismatch) {
+		t.Fatalf("Expected ErrCardMismatch, got %v", err)
+	}
+	
+	if len(player.Cards) != 5 {
+		t.Errorf("Expected penalty card, hand has %d slots", len(player.Cards))
+	}
+	topCard := game.DiscardPile[len(game.DiscardPile)-1]
+	if topCard.Rank != "7" || topCard.Suit != "spades" {
+		t.Error("Card taken from the discard pile should go back on it")
+	}
+	if game.StackableCardIndex != -1 {
+		t.Error("Card returned to the discard pile shouldn't be open to stacking")
+	}
+	if game.DrawnFromDiscard[currentPlayer] {
+		t.Error("DrawnFromDiscard should be cleared")
+	}
+}
+
+func TestSwapMultipleCardsDisabled(t *testing.T) {
+	game := createTestGame("test-game")
+	addTestPlayers(game, 2)
+	game.StartGame()
+	
+	currentPlayer := game.CurrentPlayer
+	game.DrawnCards[currentPlayer] = &Card{Suit: "spades", Rank: "2", FaceUp: true}
+	
+	if err := game.SwapMultipleCards(currentPlayer, []int{0, 1}); !errors.Is(err, ErrRuleDisabled) {
+		t.Error("Multi-discard should be rejected when the rule is off")
+	}
+}
+
 func TestCallPablo(t *testing.T) {
 	game := createTestGame("test-game")
 	addTestPlayers(game, 2)
@@ -377,7 +604,8 @@ func TestEndTurn(t *testing.T) {
 	
 	currentPlayer := game.CurrentPlayer
 	
-	// Draw and discard to complete a turn
+	// Draw and discard to complete a turn (non-special card so no power blocks the turn)
+	game.Deck[0] = Card{Suit: "clubs", Rank: "2"}
 	game.DrawCard(currentPlayer)
 	game.DiscardDrawnCard(currentPlayer)
 	
@@ -428,7 +656,9 @@ func TestEndTurnWithPablo(t *testing.T) {
 		t.Fatal("Pablo should be called")
 	}
 	
-	// Complete pablo caller's turn
+	// Complete pablo caller's turn (non-special cards so no power blocks the turn)
+	game.Deck[0] = Card{Suit: "clubs", Rank: "2"}
+	game.Deck[1] = Card{Suit: "clubs", Rank: "3"}
 	game.DrawCard(pabloCaller)
 	game.DiscardDrawnCard(pabloCaller)
 	game.EndTurn(pabloCaller)
@@ -473,9 +703,9 @@ func TestStackCard(t *testing.T) {
 	
 	initialDiscardSize := len(game.DiscardPile)
 	
-	success, msg := game.StackCard(currentPlayer, 0)
-	if !success {
-		t.Errorf("Should be able to stack matching card: %s", msg)
+	err := game.StackCard(currentPlayer, 0)
+	if err != nil {
+		t.Errorf("Should be able to stack matching card: %v", err)
 	}
 	
 	if len(game.DiscardPile) != initialDiscardSize+1 {
@@ -511,8 +741,8 @@ func TestStackCardMismatch(t *testing.T) {
 	
 	initialCardCount := len(game.Players[currentPlayer].Cards)
 	
-	success, _ := game.StackCard(currentPlayer, 0)
-	if success {
+	err := game.StackCard(currentPlayer, 0)
+	if err == nil {
 		t.Error("Should not be able to stack non-matching card")
 	}
 	
@@ -543,9 +773,9 @@ func TestStackOpponentCard(t *testing.T) {
 	matchingCard := Card{Suit: "clubs", Rank: topCard.Rank, FaceUp: false}
 	game.Players[otherPlayer].Cards[0] = matchingCard
 	
-	success, msg := game.StackOpponentCard(currentPlayer, otherPlayer, 0)
-	if !success {
-		t.Errorf("Should be able to stack opponent's matching card: %s", msg)
+	err := game.StackOpponentCard(currentPlayer, otherPlayer, 0)
+	if err != nil {
+		t.Errorf("Should be able to stack opponent's matching card: %v", err)
 	}
 	
 	// Check that opponent's card slot is empty
@@ -619,8 +849,8 @@ func TestZeroCardsWinCondition(t *testing.T) {
 	matchingCard := Card{Suit: "clubs", Rank: topCard.Rank, FaceUp: false}
 	game.Players[currentPlayer].Cards[len(game.Players[currentPlayer].Cards)-1] = matchingCard
 	
-	success, _ := game.StackCard(currentPlayer, len(game.Players[currentPlayer].Cards)-1)
-	if success {
+	err := game.StackCard(currentPlayer, len(game.Players[currentPlayer].Cards)-1)
+	if err == nil {
 		// After successful stack, player should have 0 cards
 		if game.countNonEmptyCards(game.Players[currentPlayer]) == 0 && game.Status == "playing" {
 			// This should trigger EndRound in the actual implementation
@@ -702,9 +932,9 @@ func TestUseSpecialCard7(t *testing.T) {
 	
 	// Use special card 7 to look at own card
 	params := map[string]interface{}{"targetIndex": 0}
-	success := game.UseSpecialCardFromDiscard(currentPlayer, "7", params)
+	err := game.UseSpecialCardFromDiscard(currentPlayer, "7", params)
 	
-	if !success {
+	if err != nil {
 		t.Error("Should be able to use special card 7")
 	}
 	
@@ -734,9 +964,9 @@ func TestUseSpecialCard8(t *testing.T) {
 		"targetPlayerID": otherPlayer,
 		"targetIndex":    0,
 	}
-	success := game.UseSpecialCardFromDiscard(currentPlayer, "8", params)
+	err := game.UseSpecialCardFromDiscard(currentPlayer, "8", params)
 	
-	if !success {
+	if err != nil {
 		t.Error("Should be able to use special card 8")
 	}
 	
@@ -772,9 +1002,9 @@ func TestUseSpecialCard9(t *testing.T) {
 	card1Before := game.Players[currentPlayer].Cards[0]
 	card2Before := game.Players[otherPlayer].Cards[0]
 	
-	success := game.UseSpecialCardFromDiscard(currentPlayer, "9", params)
+	err := game.UseSpecialCardFromDiscard(currentPlayer, "9", params)
 	
-	if !success {
+	if err != nil {
 		t.Error("Should be able to use special card 9")
 	}
 	
@@ -817,6 +1047,124 @@ func TestSkipSpecialCard(t *testing.T) {
 	}
 }
 
+func TestKingPeekAndSwap(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.Config.KingPeekAndSwap = true
+	game.StartGame()
+	
+	currentPlayer := game.CurrentPlayer
+	otherPlayer := playerIDs[0]
+	if otherPlayer == currentPlayer {
+		otherPlayer = playerIDs[1]
+	}
+	
+	// Discard a black king
+	game.DrawnCards[currentPlayer] = &Card{Suit: "spades", Rank: "K", FaceUp: true}
+	game.HasDrawnThisTurn[currentPlayer] = true
+	game.DiscardDrawnCard(currentPlayer)
+	
+	if game.PendingSpecialCard != "K" {
+		t.Fatalf("Expected PendingSpecialCard 'K', got '%s'", game.PendingSpecialCard)
+	}
+	
+	// Step 1: peek at the opponent's card
+	params := map[string]interface{}{
+		"targetPlayerID": otherPlayer,
+		"targetIndex":    float64(2),
+	}
+	if game.UseSpecialCardFromDiscard(currentPlayer, "K", params) != nil {
+		t.Fatal("Should be able to use the black king")
+	}
+	
+	if game.PendingKingSwap == nil {
+		t.Fatal("PendingKingSwap should be set after peeking")
+	}
+	if game.PendingSpecialCard != "K" {
+		t.Error("Power should stay pending until the swap is confirmed or declined")
+	}
+	
+	// Can't end the turn while deciding
+	game.EndTurn(currentPlayer)
+	if game.CurrentPlayer != currentPlayer {
+		t.Error("Should not be able to end turn while a king swap is pending")
+	}
+	
+	ownBefore := game.Players[currentPlayer].Cards[1]
+	targetBefore := game.Players[otherPlayer].Cards[2]
+	
+	// Step 2: confirm the swap
+	if game.ConfirmKingSwap(currentPlayer, 1) != nil {
+		t.Fatal("Should be able to confirm the king swap")
+	}
+	
+	if game.Players[currentPlayer].Cards[1] != targetBefore || game.Players[otherPlayer].Cards[2] != ownBefore {
+		t.Error("Cards should be swapped after confirming")
+	}
+	
+	if game.PendingKingSwap != nil || game.PendingSpecialCard != "" {
+		t.Error("King power should be resolved after confirming")
+	}
+}
+
+func TestKingPeekAndSwapDecline(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.Config.KingPeekAndSwap = true
+	game.StartGame()
+	
+	currentPlayer := game.CurrentPlayer
+	otherPlayer := playerIDs[0]
+	if otherPlayer == currentPlayer {
+		otherPlayer = playerIDs[1]
+	}
+	
+	game.DrawnCards[currentPlayer] = &Card{Suit: "clubs", Rank: "K", FaceUp: true}
+	game.HasDrawnThisTurn[currentPlayer] = true
+	game.DiscardDrawnCard(currentPlayer)
+	
+	params := map[string]interface{}{
+		"targetPlayerID": otherPlayer,
+		"targetIndex":    float64(0),
+	}
+	game.UseSpecialCardFromDiscard(currentPlayer, "K", params)
+	
+	ownBefore := game.Players[currentPlayer].Cards[0]
+	targetBefore := game.Players[otherPlayer].Cards[0]
+	
+	if game.DeclineKingSwap(otherPlayer) == nil {
+		t.Error("Only the actor should be able to decline")
+	}
+	
+	if game.DeclineKingSwap(currentPlayer) != nil {
+		t.Fatal("Actor should be able to decline the swap")
+	}
+	
+	if game.Players[currentPlayer].Cards[0] != ownBefore || game.Players[otherPlayer].Cards[0] != targetBefore {
+		t.Error("Cards should not change after declining")
+	}
+	
+	if game.PendingKingSwap != nil || game.PendingSpecialCard != "" {
+		t.Error("King power should be resolved after declining")
+	}
+}
+
+func TestKingNotSpecialWithoutRule(t *testing.T) {
+	game := createTestGame("test-game")
+	
+	if game.isSpecialCard(Card{Suit: "spades", Rank: "K"}) {
+		t.Error("Black king should not be special when the rule is off")
+	}
+	
+	game.Config.KingPeekAndSwap = true
+	if !game.isSpecialCard(Card{Suit: "spades", Rank: "K"}) {
+		t.Error("Black king should be special when the rule is on")
+	}
+	if game.isSpecialCard(Card{Suit: "hearts", Rank: "K"}) {
+		t.Error("Red king should never be special")
+	}
+}
+
 func TestHandleGiveCard(t *testing.T) {
 	game := createTestGame("test-game")
 	playerIDs := addTestPlayers(game, 2)
@@ -855,35 +1203,89 @@ func TestGameManager(t *testing.T) {
 	gm := &GameManager{
 		games: make(map[string]*Game),
 	}
-	
-	// Get or create first game
-	game1 := gm.GetOrCreateGame("game1")
-	if game1 == nil {
-		t.Error("Expected game to be created")
+
+	// Unknown games aren't created by looking them up
+	if gm.GetGame(context.Background(), "game1") != nil {
+		t.Error("Expected no game before it is created")
 	}
-	
-	if game1.ID != "game1" {
-		t.Errorf("Expected game ID 'game1', got '%s'", game1.ID)
+
+	game1 := gm.CreateGame(context.Background())
+	if game1 == nil || game1.ID == "" {
+		t.Fatal("Expected game to be created with an ID")
 	}
-	
+
 	// Get same game again
-	game1Again := gm.GetOrCreateGame("game1")
-	if game1 != game1Again {
+	if gm.GetGame(context.Background(), game1.ID) != game1 {
 		t.Error("Should return same game instance")
 	}
-	
+
 	// Create different game
-	game2 := gm.GetOrCreateGame("game2")
+	game2 := gm.CreateGame(context.Background())
 	if game2 == nil {
-		t.Error("Expected game2 to be created")
+		t.Fatal("Expected game2 to be created")
 	}
-	
-	if game2.ID != "game2" {
-		t.Errorf("Expected game ID 'game2', got '%s'", game2.ID)
+
+	if game1 == game2 || game1.ID == game2.ID {
+		t.Error("Should return different game instances with different IDs")
 	}
-	
-	if game1 == game2 {
-		t.Error("Should return different game instances")
+}
+
+
+func TestHoldsSeat(t *testing.T) {
+	game := createTestGame("test-game")
+	addTestPlayers(game, 2)
+	conn := &websocket.Conn{}
+	game.Players["player1"].Conn = conn
+
+	if !game.HoldsSeat("player1", conn) {
+		t.Error("Player's own connection should hold the seat")
+	}
+	if game.HoldsSeat("player2", conn) {
+		t.Error("Connection should not hold another player's seat")
+	}
+	if game.HoldsSeat("player2", nil) {
+		t.Error("A missing connection should never hold a seat")
+	}
+	if game.HoldsSeat("stranger", conn) {
+		t.Error("Unseated players hold no seat")
+	}
+}
+
+func TestStateForViewer(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+	game.DrawCard(playerIDs[0])
+
+	drawn := game.StateFor(viewerPlayer, playerIDs[0]).DrawnCards
+	if drawn[playerIDs[0]] == nil || drawn[playerIDs[0]].Rank == "" {
+		t.Error("Player should see their own drawn card")
+	}
+
+	public := game.StateFor(viewerSpectator, "")
+	if !public.Spectator {
+		t.Error("Spectator view should be marked as such")
+	}
+	if card := public.DrawnCards[playerIDs[0]]; card != nil && card.Rank != "" {
+		t.Error("Spectators should not see a drawn card")
 	}
 }
 
+func TestStackOpponentCardDisabled(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.Config.NoOpponentStacking = true
+	game.StartGame()
+
+	game.DiscardPile = append(game.DiscardPile, Card{Suit: "hearts", Rank: "5", FaceUp: true})
+	game.StackableCardIndex = len(game.DiscardPile) - 1
+	game.Players[playerIDs[0]].Cards[0] = Card{Suit: "clubs", Rank: "5"}
+
+	err := game.StackOpponentCard(playerIDs[1], playerIDs[0], 0)
+	if !errors.Is(err, ErrRuleDisabled) {
+		t.Fatalf("Expected ErrRuleDisabled, got %v", err)
+	}
+	if game.PendingGive != nil || game.Players[playerIDs[0]].Cards[0].Rank != "5" {
+		t.Error("A refused opponent stack should change nothing")
+	}
+}
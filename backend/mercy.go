@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+
+	"pablo/protocol"
+)
+
+var errInvalidMercyMargin = errors.New("mercy margin can't be negative")
+
+// checkMercyRule ends the game once the round just scored leaves someone's total more than
+// Config.MercyMargin behind the leader's, lowest total leading. Everyone is told with a
+// gameOver summing up the game (see gameOverSummary), and no further rounds are dealt.
+// Caller must hold g.mu.
+func (g *Game) checkMercyRule() {
+	margin := g.Config.MercyMargin
+	if margin <= 0 || len(g.SeatOrder) < 2 || g.GameOver {
+		return
+	}
+
+	best, worst := 0, 0
+	for i, id := range g.SeatOrder {
+		total := g.Players[id].Total
+		if i == 0 || total < best {
+			best = total
+		}
+		if i == 0 || total > worst {
+			worst = total
+		}
+	}
+	if worst-best <= margin {
+		return
+	}
+
+	summary := g.gameOverSummary("mercy")
+	summary.Margin = margin
+	summary.Trailing = []string{}
+	for _, id := range g.SeatOrder {
+		if g.Players[id].Total-best > margin {
+			summary.Trailing = append(summary.Trailing, id)
+		}
+	}
+
+	g.GameOver = true
+	g.emit(protocol.EventGameOver, "", map[string]interface{}{
+		"reason":  "mercy",
+		"totals":  summary.Totals,
+		"winners": summary.Winners,
+	})
+	message := Message{Type: protocol.MsgGameOver, Payload: summary}
+	g.broadcast(message)
+	g.broadcastToSpectators(message)
+	g.broadcastGameState()
+}
@@ -0,0 +1,71 @@
+package main
+
+import "testing"
+
+func playScoredRound(game *Game, hands map[string][]string) {
+	game.StartGame()
+	for id, ranks := range hands {
+		setHand(game, id, ranks...)
+	}
+	game.EndRound()
+}
+
+func TestMercyRuleEndsGame(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	playerIDs := addTestPlayers(game, 2)
+	leader, trailer := playerIDs[0], playerIDs[1]
+	game.Config.MercyMargin = 50
+
+	playScoredRound(game, map[string][]string{leader: {"A", "A", "A", "A"}, trailer: {"10", "10", "10", "10"}})
+	if game.GameOver {
+		t.Fatal("A 36 point gap is within the margin")
+	}
+	playScoredRound(game, map[string][]string{leader: {"A", "A", "A", "A"}, trailer: {"10", "10", "10", "10"}})
+	if !game.GameOver {
+		t.Fatalf("A 72 point gap should end the game, totals %d and %d", game.Players[leader].Total, game.Players[trailer].Total)
+	}
+
+	recorder.mu.Lock()
+	var payload *gameOverSummary
+	for _, message := range recorder.players[leader] {
+		if message.Type == "gameOver" {
+			summary := message.Payload.(gameOverSummary)
+			payload = &summary
+		}
+	}
+	recorder.mu.Unlock()
+	if payload == nil || payload.Reason != "mercy" {
+		t.Fatalf("Expected a mercy gameOver, got %v", payload)
+	}
+	if winners := payload.Winners; len(winners) != 1 || winners[0] != leader {
+		t.Errorf("Expected %s to win, got %v", leader, winners)
+	}
+
+	game.StartGame()
+	if game.Status != "ended" {
+		t.Error("No more rounds should be dealt after the mercy rule")
+	}
+}
+
+func TestMercyRuleOff(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+
+	for i := 0; i < 3; i++ {
+		playScoredRound(game, map[string][]string{playerIDs[0]: {"A", "A", "A", "A"}, playerIDs[1]: {"10", "10", "10", "10"}})
+	}
+	if game.GameOver {
+		t.Error("Without a margin the game plays on")
+	}
+	if total := game.Players[playerIDs[1]].Total; total != 120 {
+		t.Errorf("Expected a total of 120, got %d", total)
+	}
+}
+
+func TestMercyMarginValidation(t *testing.T) {
+	if _, err := decodeGameConfig(map[string]interface{}{"mercyMargin": -1}); err == nil {
+		t.Error("A negative margin should be refused")
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import "github.com/gorilla/websocket"
+
+// connSession is what one connection is doing in one game: playing a seat, observing or
+// spectating. A connection can be in several games at once, e.g. a lobby page previewing
+// a few tables. Messages carrying a gameID go to that game's session and the rest to the
+// one joined last; messages from the server carry the gameID of the game they're about.
+type connSession struct {
+	game        *Game
+	playerID    string
+	observerID  string
+	spectatorID string
+}
+
+// leave ends the session. A seat is kept for the player to come back to, as with any
+// dropped connection, unless it was given up for going idle. A connection that stopped
+// answering pings is logged as timed out, so the audit shows the peer vanished.
+func (s *connSession) leave(conn *websocket.Conn, ip string, reason leaveReason) {
+	if s.playerID != "" {
+		event := connDisconnected
+		if reason == leftDead {
+			event = connTimedOut
+		}
+		s.game.RecordConnection(s.playerID, ip, event)
+		if reason == leftIdle {
+			s.game.ReleaseIdleSeat(s.playerID, conn)
+		}
+		s.game.Disconnect(s.playerID, conn)
+	}
+	if s.observerID != "" {
+		s.game.RemoveObserver(s.observerID)
+	}
+	if s.spectatorID != "" {
+		s.game.RemoveSpectator(s.spectatorID)
+	}
+}
+
+// connSessions are the sessions a connection holds besides its current one, by game ID
+type connSessions map[string]*connSession
+
+// stash keeps s while another session is current
+func (sessions connSessions) stash(s *connSession) {
+	if s.game != nil {
+		sessions[s.game.ID] = s
+	}
+}
+
+// take removes and returns the session for gameID
+func (sessions connSessions) take(gameID string) *connSession {
+	s := sessions[gameID]
+	delete(sessions, gameID)
+	return s
+}
+
+// end leaves the stashed session for gameID, if any, without giving up keepSeat
+func (sessions connSessions) end(conn *websocket.Conn, ip, gameID, keepSeat string) {
+	s := sessions.take(gameID)
+	if s == nil {
+		return
+	}
+	if s.playerID == keepSeat {
+		s.playerID = ""
+	}
+	s.leave(conn, ip, leftByClient)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestOneConnectionWatchesSeveralGames(t *testing.T) {
+	_, first := createTestGameOverWS(t)
+	_, second := createTestGameOverWS(t)
+	firstID, secondID := first["gameID"].(string), second["gameID"].(string)
+
+	viewer := dialTestServer(t)
+	sendTestMessage(t, viewer, "spectate", map[string]interface{}{"gameID": firstID, "name": "Lobby"})
+	readMessageOfType(t, viewer, "spectating")
+	sendTestMessage(t, viewer, "spectate", map[string]interface{}{"gameID": secondID, "name": "Lobby"})
+	readMessageOfType(t, viewer, "spectating")
+
+	// State feeds from both games arrive, each stamped with its game
+	guest := dialTestServer(t)
+	sendTestMessage(t, guest, "join", map[string]interface{}{"gameID": firstID, "name": "Guest"})
+	viewer.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		var msg Message
+		if err := viewer.ReadJSON(&msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Type == "gameState" && msg.GameID == firstID {
+			if players := msg.Payload.(map[string]interface{})["players"].(map[string]interface{}); len(players) == 2 {
+				break
+			}
+		}
+	}
+
+	// A message's gameID picks the game it is for
+	for _, gameID := range []string{firstID, secondID} {
+		if err := viewer.WriteJSON(Message{Type: "getState", GameID: gameID}); err != nil {
+			t.Fatal(err)
+		}
+		if state := readMessageOfType(t, viewer, "gameState"); state["gameID"] != gameID {
+			t.Errorf("Expected the state of %s, got %v", gameID, state["gameID"])
+		}
+	}
+
+	viewer.WriteJSON(Message{Type: "leaveGame", GameID: firstID})
+	viewer.WriteJSON(Message{Type: "getState", GameID: firstID})
+	if code := readMessageOfType(t, viewer, "error")["code"]; code != "NOT_IN_GAME" {
+		t.Errorf("Expected NOT_IN_GAME after leaving, got %v", code)
+	}
+	game := gameManager.GetGame(context.Background(), firstID)
+	game.mu.RLock()
+	spectators := len(game.Spectators)
+	game.mu.RUnlock()
+	if spectators != 0 {
+		t.Errorf("Leaving should remove the spectator, %d left", spectators)
+	}
+}
+
+func TestFailedJoinKeepsCurrentGame(t *testing.T) {
+	host, session := createTestGameOverWS(t)
+	sendTestMessage(t, host, "join", map[string]interface{}{"gameID": "no-such-game", "name": "Host"})
+	readMessageOfType(t, host, "error")
+
+	sendTestMessage(t, host, "getState", nil)
+	if state := readMessageOfType(t, host, "gameState"); state["gameID"] != session["gameID"] {
+		t.Errorf("Expected to still be in %v, got %v", session["gameID"], state["gameID"])
+	}
+}
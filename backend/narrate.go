@@ -0,0 +1,239 @@
+package main
+
+import (
+	"strconv"
+
+	"pablo/protocol"
+)
+
+// Every event the game publishes is also described in a sentence, like "Bob discarded the
+// nine of clubs and may now swap any two cards", and sent to the table as a narration
+// message. Screen readers can speak these as they arrive instead of working out what
+// changed between two game states. Narrations only say what everyone at the table saw:
+// a card drawn from the deck or peeked at is never named.
+
+// catalogKey is a narration argument that is itself a message, translated along with
+// the sentence it goes in
+type catalogKey string
+
+// narration is an event put into words: the catalog key of the sentence and its values
+type narration struct {
+	key  string
+	self string // Sentence, without values, for the player the event is about, if any
+	args []interface{}
+}
+
+// slotOrdinals name a hand's card slots; a hand only grows past four with penalty cards
+var slotOrdinals = []string{"first", "second", "third", "fourth", "fifth", "sixth", "seventh", "eighth"}
+
+// slotName names the card at index in a hand, like "second"
+func slotName(index int) string {
+	if index >= 0 && index < len(slotOrdinals) {
+		return slotOrdinals[index]
+	}
+	return "#" + strconv.Itoa(index+1)
+}
+
+// powerPhrases say what a card's power lets its player do next
+var powerPhrases = map[string]catalogKey{
+	protocol.PowerPeekOwn:   "narrate.power.peekOwn",
+	protocol.PowerPeekOther: "narrate.power.peekOther",
+	protocol.PowerSwap:      "narrate.power.swap",
+	protocol.PowerKingSwap:  "narrate.power.peekAndSwap",
+}
+
+// narrate sends the description of an event to everyone at the table. Caller must hold g.mu.
+func (g *Game) narrate(event GameEvent) {
+	n := g.describe(event)
+	if n == nil {
+		return
+	}
+	payload := func(locale, playerID string) map[string]interface{} {
+		text := n.render(locale)
+		if n.self != "" && playerID == event.PlayerID {
+			text = translate(locale, n.self)
+		}
+		return map[string]interface{}{
+			"text":     text,
+			"event":    event.Type,
+			"playerID": event.PlayerID,
+		}
+	}
+	for playerID, player := range g.Players {
+		if g.vacant[playerID] {
+			continue
+		}
+		g.broadcaster.ToPlayer(playerID, Message{Type: protocol.MsgNarration, Payload: payload(localeOf(player.Conn), playerID)})
+	}
+	for spectatorID, spectator := range g.Spectators {
+		g.broadcaster.ToSpectator(spectatorID, Message{Type: protocol.MsgNarration, Payload: payload(localeOf(spectator.Conn), "")})
+	}
+	for observerID, conn := range g.Observers {
+		g.broadcaster.ToObserver(observerID, Message{Type: protocol.MsgNarration, Payload: payload(localeOf(conn), "")})
+	}
+}
+
+// render fills in the sentence in locale, translating any catalogKey arguments
+func (n *narration) render(locale string) string {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		if phrase, ok := arg.(catalogKey); ok {
+			arg = translate(locale, string(phrase))
+		}
+		args[i] = arg
+	}
+	return translate(locale, n.key, args...)
+}
+
+// describe puts an event into words, or returns nil for one nobody needs to hear about.
+// Caller must hold g.mu.
+func (g *Game) describe(event GameEvent) *narration {
+	name := g.displayName(event.PlayerID)
+	switch event.Type {
+	case protocol.EventPlayerJoined:
+		return &narration{key: "narrate.playerJoined", args: []interface{}{name}}
+	case protocol.EventPlayerLeft:
+		return &narration{key: "narrate.playerLeft", args: []interface{}{name}}
+	case protocol.EventGameStarted:
+		return &narration{key: "narrate.gameStarted"}
+	case protocol.EventTurnStarted:
+		return &narration{key: "narrate.turnStarted", self: "narrate.turnStarted.self", args: []interface{}{name}}
+	case protocol.EventRoundEnded:
+		return g.describeRoundEnded(event.Data)
+	case protocol.EventGameOver:
+		return &narration{key: "narrate.gameOver"}
+	case protocol.EventLobbyOpened:
+		return &narration{key: "narrate.lobbyOpened"}
+	case protocol.EventAction:
+		return g.describeAction(name, event.Data)
+	}
+	return nil
+}
+
+// describeRoundEnded names the round's winners. Caller must hold g.mu.
+func (g *Game) describeRoundEnded(data map[string]interface{}) *narration {
+	winners, _ := data["winners"].([]string)
+	if len(winners) == 0 {
+		return &narration{key: "narrate.roundEnded"}
+	}
+	names := ""
+	for i, id := range winners {
+		if i > 0 {
+			names += ", "
+		}
+		names += g.displayName(id)
+	}
+	return &narration{key: "narrate.roundEnded.winners", args: []interface{}{names}}
+}
+
+// describeAction puts a gameplay action into words. Caller must hold g.mu.
+func (g *Game) describeAction(name string, data map[string]interface{}) *narration {
+	action, _ := data["action"].(string)
+	card, _ := data["card"].(Card)
+	target, _ := data["targetPlayerID"].(string)
+	targetName := g.displayName(target)
+
+	switch action {
+	case protocol.MsgDrawCard:
+		return &narration{key: "narrate.drawCard", args: []interface{}{name}}
+	case protocol.MsgDrawFromDiscard:
+		return &narration{key: "narrate.drawFromDiscard", args: []interface{}{name, card.label()}}
+	case protocol.MsgDiscardDrawnCard:
+		return g.withPower(card, &narration{key: "narrate.discardDrawnCard", args: []interface{}{name, card.label()}})
+	case protocol.MsgSwapCard:
+		discarded, _ := data["discarded"].(Card)
+		index, _ := slotIndex(data["cardIndex"])
+		return g.withPower(discarded, &narration{key: "narrate.swapCard", args: []interface{}{name, slotName(index), discarded.label()}})
+	case protocol.MsgSwapMultipleCards:
+		indices, _ := data["cardIndices"].([]int)
+		if success, _ := data["success"].(bool); !success {
+			return &narration{key: "narrate.swapMultipleCards.failed", args: []interface{}{name}}
+		}
+		return &narration{key: "narrate.swapMultipleCards", args: []interface{}{name, len(indices)}}
+	case "useSpecialCard":
+		return g.describePower(name, data)
+	case protocol.MsgSkipSpecialCard:
+		return &narration{key: "narrate.skipSpecialCard", args: []interface{}{name}}
+	case protocol.MsgConfirmKingSwap:
+		own, _ := slotIndex(data["ownIndex"])
+		theirs, _ := slotIndex(data["targetIndex"])
+		return &narration{key: "narrate.confirmKingSwap", args: []interface{}{name, slotName(own), targetName, slotName(theirs)}}
+	case protocol.MsgDeclineKingSwap:
+		return &narration{key: "narrate.declineKingSwap", args: []interface{}{name}}
+	case protocol.MsgCallPablo:
+		if blind, _ := data["blind"].(bool); blind {
+			return &narration{key: "narrate.callPablo.blind", args: []interface{}{name}}
+		}
+		return &narration{key: "narrate.callPablo", args: []interface{}{name}}
+	case protocol.MsgEndTurn:
+		if absent, _ := data["absent"].(bool); absent {
+			return &narration{key: "narrate.endTurn.absent", args: []interface{}{name}}
+		}
+		return &narration{key: "narrate.endTurn", args: []interface{}{name}}
+	case protocol.MsgStackCard:
+		index, _ := slotIndex(data["cardIndex"])
+		if success, _ := data["success"].(bool); !success {
+			return &narration{key: "narrate.stackCard.failed", args: []interface{}{name, slotName(index), card.label()}}
+		}
+		return &narration{key: "narrate.stackCard", args: []interface{}{name, slotName(index), card.label()}}
+	case protocol.MsgStackOpponentCard:
+		index, _ := slotIndex(data["cardIndex"])
+		if success, _ := data["success"].(bool); !success {
+			return &narration{key: "narrate.stackOpponentCard.failed", args: []interface{}{name, targetName, slotName(index), card.label()}}
+		}
+		return &narration{key: "narrate.stackOpponentCard", args: []interface{}{name, targetName, slotName(index), card.label()}}
+	case protocol.MsgGiveCardToPlayer:
+		index, _ := slotIndex(data["sourceIndex"])
+		return &narration{key: "narrate.giveCardToPlayer", args: []interface{}{name, slotName(index), targetName}}
+	case protocol.MsgRevealClaim:
+		total, _ := data["total"].(int)
+		if held, _ := data["held"].(bool); !held {
+			return &narration{key: "narrate.revealClaim.failed", args: []interface{}{name, total}}
+		}
+		return &narration{key: "narrate.revealClaim", args: []interface{}{name, total}}
+	}
+	return nil
+}
+
+// describePower says what a player did with a card's power. Caller must hold g.mu.
+func (g *Game) describePower(name string, data map[string]interface{}) *narration {
+	rank, _ := data["cardRank"].(string)
+	params, _ := data["params"].(map[string]interface{})
+	power := ""
+	if len(g.DiscardPile) > 0 {
+		power = g.cardPower(g.DiscardPile[len(g.DiscardPile)-1])
+	}
+	if power == "" {
+		power = g.cardPower(Card{Rank: rank})
+	}
+	slot := func(key string) string {
+		index, _ := slotIndex(params[key])
+		return slotName(index)
+	}
+	player := func(key string) string {
+		id, _ := params[key].(string)
+		return g.displayName(id)
+	}
+
+	switch power {
+	case protocol.PowerPeekOwn:
+		return &narration{key: "narrate.peekOwn", args: []interface{}{name, slot("targetIndex")}}
+	case protocol.PowerPeekOther:
+		return &narration{key: "narrate.peekOther", args: []interface{}{name, player("targetPlayerID"), slot("targetIndex")}}
+	case protocol.PowerSwap:
+		return &narration{key: "narrate.swapAny", args: []interface{}{name, player("player1ID"), slot("card1Index"), player("player2ID"), slot("card2Index")}}
+	case protocol.PowerKingSwap:
+		return &narration{key: "narrate.kingPeek", args: []interface{}{name, player("targetPlayerID"), slot("targetIndex")}}
+	}
+	return nil
+}
+
+// withPower adds what the card lets its player do next, if it has a power. Caller must
+// hold g.mu.
+func (g *Game) withPower(card Card, n *narration) *narration {
+	if phrase, ok := powerPhrases[g.cardPower(card)]; ok {
+		n.key += ".power"
+		n.args = append(n.args, phrase)
+	}
+	return n
+}
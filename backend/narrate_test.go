@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"pablo/protocol"
+)
+
+func narrationsFor(recorder *recordingBroadcaster, playerID string) []string {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	texts := []string{}
+	for _, message := range recorder.players[playerID] {
+		if message.Type == protocol.MsgNarration {
+			texts = append(texts, message.Payload.(map[string]interface{})["text"].(string))
+		}
+	}
+	return texts
+}
+
+func TestNarration(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+
+	current := game.CurrentPlayer
+	other := playerIDs[0]
+	if other == current {
+		other = playerIDs[1]
+	}
+	game.Deck[0] = Card{Suit: "clubs", Rank: "9"}
+	game.DrawCard(current)
+	game.DiscardDrawnCard(current)
+
+	name := game.Players[current].Name
+	texts := narrationsFor(recorder, other)
+	want := []string{
+		name + " drew a card from the deck",
+		name + " discarded the nine of clubs and may now swap any two cards",
+	}
+	for _, text := range want {
+		found := false
+		for _, got := range texts {
+			found = found || got == text
+		}
+		if !found {
+			t.Errorf("Expected narration %q, got %q", text, texts)
+		}
+	}
+	for _, text := range texts {
+		if strings.Contains(text, "drew the nine") {
+			t.Errorf("A card drawn from the deck shouldn't be named: %q", text)
+		}
+	}
+
+	// Whose turn it is is spoken in the second person to them alone
+	game.SkipSpecialCard(current)
+	game.EndTurn(current)
+	if texts := narrationsFor(recorder, other); texts[len(texts)-1] != "It's your turn" {
+		t.Errorf("Expected the next player to hear it's their turn, got %q", texts[len(texts)-1])
+	}
+	if texts := narrationsFor(recorder, current); texts[len(texts)-1] != "It's "+game.Players[other].Name+"'s turn" {
+		t.Errorf("Expected the others to hear whose turn it is, got %q", texts[len(texts)-1])
+	}
+}
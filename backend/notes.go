@@ -0,0 +1,239 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+	"pablo/protocol"
+)
+
+// Players can keep private notes on their opponents, like "always calls Pablo early".
+// Players have no accounts, so the author is whoever holds a notes key: a secret the
+// client makes up once and sends when joining, and can copy to another device to see the
+// same notes there. Notes are about a name, the same as bans and player history, so they
+// follow an opponent from table to table. Only a hash of the key is stored. Anonymous
+// tables have no notes at all: a note on a seat would say who is sitting in it.
+
+const (
+	minNotesKeyLength = 16  // Shorter keys could be guessed
+	maxNoteLength     = 500 // Characters in one note
+	maxNotesPerAuthor = 500
+)
+
+var (
+	ErrNoNotesKey  = errors.New("send a notes key when joining to keep notes")
+	errInvalidNote = errors.New("invalid note")
+)
+
+// playerNote is what one author wrote about one opponent
+type playerNote struct {
+	Subject   string    `json:"subject"` // The opponent's name as it was when the note was written
+	Text      string    `json:"text"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// noteStore holds every author's notes in memory, saving them to a JSON file (when one is
+// configured) every time they change
+type noteStore struct {
+	path  string
+	notes map[string]map[string]*playerNote // Hashed notes key -> lowercased subject name -> note
+	mu    sync.RWMutex
+}
+
+// notes is the server's note store; in memory only unless main loads it from a file
+var notes = newNoteStore("")
+
+func newNoteStore(path string) *noteStore {
+	return &noteStore{path: path, notes: make(map[string]map[string]*playerNote)}
+}
+
+// loadNoteStore reads the notes saved at path. A missing file is an empty store.
+func loadNoteStore(path string) (*noteStore, error) {
+	store := newNoteStore(path)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store.notes); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// notesAuthor is how an author's notes are filed: a hash of their key, so the file
+// can't be used to read anyone's notes
+func notesAuthor(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func noteSubject(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Set saves the note key's holder keeps on subject. Empty text deletes the note.
+func (s *noteStore) Set(key, subject, text string) error {
+	text = strings.TrimSpace(text)
+	if noteSubject(subject) == "" || utf8.RuneCountInString(text) > maxNoteLength {
+		return errInvalidNote
+	}
+	author := notesAuthor(key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	authored := s.notes[author]
+	if text == "" {
+		if _, exists := authored[noteSubject(subject)]; !exists {
+			return nil
+		}
+		delete(authored, noteSubject(subject))
+		return s.save()
+	}
+	if authored == nil {
+		authored = make(map[string]*playerNote)
+		s.notes[author] = authored
+	}
+	if _, exists := authored[noteSubject(subject)]; !exists && len(authored) >= maxNotesPerAuthor {
+		return errInvalidNote
+	}
+	authored[noteSubject(subject)] = &playerNote{
+		Subject:   strings.TrimSpace(subject),
+		Text:      text,
+		UpdatedAt: time.Now().UTC(),
+	}
+	return s.save()
+}
+
+// Get returns the note key's holder keeps on subject, or ""
+func (s *noteStore) Get(key, subject string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if note := s.notes[notesAuthor(key)][noteSubject(subject)]; note != nil {
+		return note.Text
+	}
+	return ""
+}
+
+// List returns every note key's holder has written, most recently changed first
+func (s *noteStore) List(key string) []playerNote {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := []playerNote{}
+	for _, note := range s.notes[notesAuthor(key)] {
+		list = append(list, *note)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].UpdatedAt.After(list[j].UpdatedAt)
+	})
+	return list
+}
+
+// save writes the notes to disk. Caller must hold s.mu.
+func (s *noteStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	return writeJSONFile(s.path, s.notes)
+}
+
+// notesKeys remembers the notes key each connection joined with
+var notesKeys sync.Map // *websocket.Conn -> string
+
+// setNotesKey records conn's notes key, ignoring one too short to be secret
+func setNotesKey(conn *websocket.Conn, key string) {
+	if len(key) >= minNotesKeyLength {
+		notesKeys.Store(conn, key)
+	}
+}
+
+// notesKeyOf returns conn's notes key, or "" if it didn't send one
+func notesKeyOf(conn *websocket.Conn) string {
+	if conn == nil {
+		return ""
+	}
+	key, _ := notesKeys.Load(conn)
+	s, _ := key.(string)
+	return s
+}
+
+func forgetNotesKey(conn *websocket.Conn) {
+	notesKeys.Delete(conn)
+}
+
+// noteFor returns viewerID's note on subjectID, for the viewer's own game state. Nobody
+// gets notes at an anonymous table, even once names are revealed between rounds, since a
+// note would tell them who sits where in the next round. Caller must hold g.mu.
+func (g *Game) noteFor(viewerID, subjectID string) string {
+	viewer, subject := g.Players[viewerID], g.Players[subjectID]
+	if viewer == nil || subject == nil || viewerID == subjectID || g.Config.AnonymousNames {
+		return ""
+	}
+	if _, isBot := g.Bots[subjectID]; isBot {
+		return ""
+	}
+	key := notesKeyOf(viewer.Conn)
+	if key == "" {
+		return ""
+	}
+	return notes.Get(key, subject.Name)
+}
+
+// hidesNames reports whether the table is showing seat numbers instead of names.
+// Caller must hold g.mu.
+func (g *Game) hidesNames() bool {
+	return g.Config.AnonymousNames && g.Status != protocol.StatusEnded
+}
+
+// SetNote saves authorID's note on another player at the table and sends the author their
+// state with it. Empty text deletes the note.
+func (g *Game) SetNote(authorID, subjectID, text string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	author, subject := g.Players[authorID], g.Players[subjectID]
+	if author == nil {
+		return ErrNotInGame
+	}
+	key := notesKeyOf(author.Conn)
+	if key == "" {
+		return ErrNoNotesKey
+	}
+	if subject == nil || subjectID == authorID {
+		return ErrTargetNotFound
+	}
+	if _, isBot := g.Bots[subjectID]; isBot || g.Config.AnonymousNames {
+		return errInvalidNote
+	}
+	if err := notes.Set(key, subject.Name, text); err != nil {
+		return err
+	}
+	g.sendToPlayer(authorID, Message{Type: protocol.MsgGameState, Payload: g.getGameStateForPlayer(authorID)})
+	return nil
+}
+
+// sendNotes sends conn every note its notes key has written, so a client can show and
+// edit them away from the table
+func sendNotes(conn *websocket.Conn) {
+	key := notesKeyOf(conn)
+	if key == "" {
+		sendError(conn, protocol.CodeNoNotesKey)
+		return
+	}
+	writeJSON(conn, Message{
+		Type:    protocol.MsgNotes,
+		Payload: map[string]interface{}{"notes": notes.List(key)},
+	})
+}
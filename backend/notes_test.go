@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestNoteStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.json")
+	store := newNoteStore(path)
+	key := "store-test-notes-key"
+	if err := store.Set(key, "Alice", "always calls Pablo early"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(key, "Bob", "stacks a lot"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(key, "Bob", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadNoteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := loaded.Get(key, " alice "); text != "always calls Pablo early" {
+		t.Errorf("Expected the note on Alice to survive a restart, matched ignoring case, got %q", text)
+	}
+	if text := loaded.Get(key, "Bob"); text != "" {
+		t.Errorf("Expected the emptied note deleted, got %q", text)
+	}
+	if text := loaded.Get("someone-elses-notes-key", "Alice"); text != "" {
+		t.Errorf("Another key shouldn't see the note, got %q", text)
+	}
+	if err := store.Set(key, "Alice", string(make([]rune, maxNoteLength+1))); err != errInvalidNote {
+		t.Errorf("Expected an overlong note refused, got %v", err)
+	}
+}
+
+func TestNotesOnlyReachTheirAuthor(t *testing.T) {
+	notesKey := "ws-test-notes-key-0001"
+	host := dialTestServer(t)
+	sendTestMessage(t, host, "createGame", map[string]interface{}{"name": "Note Taker", "notesKey": notesKey})
+	hostSession := readMessageOfType(t, host, "session")
+	gameID, hostID := hostSession["gameID"].(string), hostSession["playerID"].(string)
+
+	guest := dialTestServer(t)
+	sendTestMessage(t, guest, "join", map[string]interface{}{"gameID": gameID, "name": "Early Caller"})
+	guestID := readMessageOfType(t, guest, "session")["playerID"].(string)
+
+	sendTestMessage(t, host, "setNote", map[string]interface{}{"playerID": guestID, "text": "always calls Pablo early"})
+	for {
+		players := readMessageOfType(t, host, "gameState")["players"].(map[string]interface{})
+		seat, _ := players[guestID].(map[string]interface{})
+		if note, _ := seat["note"].(string); note != "" {
+			if note != "always calls Pablo early" {
+				t.Errorf("Unexpected note %q", note)
+			}
+			break
+		}
+	}
+
+	game := gameManager.GetGame(context.Background(), gameID)
+	if state := game.StateFor(viewerPlayer, guestID); state.Players[guestID].Note != "" || state.Players[hostID].Note != "" {
+		t.Error("Notes should only be in their author's state")
+	}
+
+	// The guest sent no key, so has nowhere to keep notes
+	sendTestMessage(t, guest, "setNote", map[string]interface{}{"playerID": hostID, "text": "hmm"})
+	if code := readMessageOfType(t, guest, "error")["code"]; code != "NO_NOTES_KEY" {
+		t.Errorf("Expected NO_NOTES_KEY, got %v", code)
+	}
+
+	// The same key on another device gets the same notes
+	other := dialTestServer(t)
+	sendTestMessage(t, other, "createGame", map[string]interface{}{"name": "Note Taker", "notesKey": notesKey})
+	readMessageOfType(t, other, "session")
+	sendTestMessage(t, other, "getNotes", nil)
+	list, _ := readMessageOfType(t, other, "notes")["notes"].([]interface{})
+	if len(list) != 1 || list[0].(map[string]interface{})["subject"] != "Early Caller" {
+		t.Errorf("Expected the note on Early Caller, got %v", list)
+	}
+}
+
+func TestNoNotesAtAnonymousTables(t *testing.T) {
+	game := createTestGame("test-game")
+	game.SetBroadcaster(newRecordingBroadcaster())
+	playerIDs := addTestPlayers(game, 2)
+	game.Config.AnonymousNames = true
+	conn := newTestConn(t)
+	notesKey := "anonymous-test-notes-key"
+	setNotesKey(conn, notesKey)
+	t.Cleanup(func() { forgetNotesKey(conn) })
+	game.Players[playerIDs[0]].Conn = conn
+	if err := notes.Set(notesKey, "Player 2", "always calls Pablo early"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { notes.Set(notesKey, "Player 2", "") })
+
+	if err := game.SetNote(playerIDs[0], playerIDs[1], "stacks a lot"); err != errInvalidNote {
+		t.Errorf("Expected notes refused at an anonymous table, got %v", err)
+	}
+	game.StartGame()
+	game.mu.Lock()
+	game.Status = "ended"
+	game.mu.Unlock()
+	if note := game.getGameStateForPlayer(playerIDs[0]).Players[playerIDs[1]].Note; note != "" {
+		t.Errorf("Expected no note once names are revealed, got %q", note)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"crypto/subtle"
+
+	"github.com/gorilla/websocket"
+	"pablo/protocol"
+)
+
+// AddObserver attaches an organizer connection that receives the omniscient state feed.
+// Observers don't take a seat and can't act in the game.
+func (g *Game) AddObserver(observerID string, conn *websocket.Conn) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.Observers[observerID] = conn
+	g.broadcaster.ToObserver(observerID, Message{
+		Type:    protocol.MsgGameState,
+		Payload: g.getGameStateForObserver(),
+	})
+}
+
+func (g *Game) RemoveObserver(observerID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.Observers, observerID)
+}
+
+// authorizeObserver checks an organizer's key against the server's configured observer key
+func (gm *GameManager) authorizeObserver(key string) bool {
+	if gm.observerKey == "" || key == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(key), []byte(gm.observerKey)) == 1
+}
@@ -0,0 +1,64 @@
+package main
+
+import "testing"
+
+func TestObserverStateRevealsAllHands(t *testing.T) {
+	game := createTestGame("test-game")
+	addTestPlayers(game, 2)
+	game.StartGame()
+
+	currentPlayer := game.CurrentPlayer
+	game.DrawCard(currentPlayer)
+
+	state := game.getGameStateForObserver()
+	if !state.Omniscient {
+		t.Error("Observer state should be flagged omniscient")
+	}
+
+	for id, p := range state.Players {
+		for i, card := range p.Cards {
+			if card.Rank != game.Players[id].Cards[i].Rank {
+				t.Errorf("Observer should see %s card %d", id, i)
+			}
+			if !card.Hidden {
+				t.Errorf("Face-down card %s/%d should be marked hidden", id, i)
+			}
+		}
+	}
+
+	if state.DrawnCards[currentPlayer] == nil {
+		t.Error("Observer should see the current player's drawn card")
+	}
+}
+
+func TestPlayerStateIsNotOmniscient(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+
+	state := game.getGameStateForPlayer(playerIDs[0])
+	if state.Omniscient {
+		t.Error("Player state should not carry the omniscient flag")
+	}
+
+	for _, card := range state.Players[playerIDs[1]].Cards {
+		if card.Rank != "" {
+			t.Error("Players should not see opponents' face-down cards")
+		}
+	}
+}
+
+func TestAuthorizeObserver(t *testing.T) {
+	gm := &GameManager{games: make(map[string]*Game)}
+	if gm.authorizeObserver("") || gm.authorizeObserver("anything") {
+		t.Error("Observing should be disabled without a configured key")
+	}
+
+	gm.observerKey = "secret"
+	if gm.authorizeObserver("wrong") {
+		t.Error("Wrong key should be rejected")
+	}
+	if !gm.authorizeObserver("secret") {
+		t.Error("Correct key should be accepted")
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"pablo/protocol"
+)
+
+const (
+	writeWorkers  = 32               // Goroutines writing to sockets, shared by every connection
+	sendQueueSize = 256              // Frames a connection can have waiting before new ones are refused
+	writeTimeout  = 10 * time.Second // Longest a single frame may take to write
+	drainTimeout  = time.Second      // How long a closing connection gets to send what's queued
+	maxPooledSize = 64 << 10         // Larger buffers are left for the GC rather than kept in the pool
+
+	// A client that can't keep up is disconnected once it has lost this many frames, or once
+	// its queue has been full this long, whichever comes first
+	maxDroppedFrames = 32
+	maxFullDuration  = 5 * time.Second
+)
+
+var (
+	errSendQueueFull = errors.New("send queue is full")
+	errConnClosed    = errors.New("connection is closed")
+)
+
+// frame is one encoded message. Frames come from framePool and go back to it once they
+// have been written, so the per-broadcast encoding of game states reuses the same few
+// buffers instead of allocating new ones for every recipient.
+type frame struct {
+	buf       bytes.Buffer
+	enc       *json.Encoder // Streams into buf, for formats that encode JSON
+	frameType int           // websocket.TextMessage or websocket.BinaryMessage, per the wire format
+	snapshot  string        // Message type if a newer frame of the same type makes this one useless
+}
+
+// snapshotMessages are the bulk messages that carry a whole view rather than an event.
+// Only the newest one of each type matters, so a backed-up connection skips the rest,
+// and everything else is sent ahead of them.
+var snapshotMessages = map[string]bool{
+	protocol.MsgGameState:    true,
+	protocol.MsgLobbyPlayers: true,
+}
+
+var framePool = sync.Pool{
+	New: func() interface{} {
+		f := &frame{}
+		f.enc = json.NewEncoder(&f.buf)
+		return f
+	},
+}
+
+// encodeFrame encodes v in format into a pooled frame. The caller owns the frame until it
+// is queued or released.
+func encodeFrame(format *wireFormat, v interface{}) (*frame, error) {
+	f := framePool.Get().(*frame)
+	if err := format.encode(f, v); err != nil {
+		f.release()
+		return nil, err
+	}
+	f.frameType = format.frameType
+	if message, ok := v.(Message); ok && snapshotMessages[message.Type] {
+		f.snapshot = message.Type
+	}
+	return f, nil
+}
+
+// bytes returns the encoded message
+func (f *frame) bytes() []byte {
+	return f.buf.Bytes()
+}
+
+func (f *frame) release() {
+	if f.buf.Cap() > maxPooledSize {
+		return
+	}
+	f.buf.Reset()
+	f.snapshot = ""
+	framePool.Put(f)
+}
+
+// outbox holds the frames waiting to go out on one connection, in two lanes: events
+// (turns, stack results, errors, chat) go first, and snapshots follow. At most one
+// worker writes an outbox at a time, which keeps each lane in order and satisfies
+// gorilla's one-writer rule.
+type outbox struct {
+	conn      *websocket.Conn
+	urgent    []*frame
+	snapshots []*frame  // At most one per snapshot type
+	scheduled bool      // Waiting in the pool or being written by a worker
+	closing   bool      // No new frames; the connection closes once these are sent
+	closedAt  time.Time // When closing was set
+	failed    bool      // A write failed; the socket is dead
+	dropped   int       // Frames refused because the queue was full
+	fullSince time.Time // When the queue last filled up; zero while it has room
+	drained   *sync.Cond
+	mu        sync.Mutex
+}
+
+// outboxes maps each open connection to its outbox
+var outboxes sync.Map // *websocket.Conn -> *outbox
+
+func outboxFor(conn *websocket.Conn) *outbox {
+	if box, exists := outboxes.Load(conn); exists {
+		return box.(*outbox)
+	}
+	box := &outbox{conn: conn}
+	box.drained = sync.NewCond(&box.mu)
+	actual, _ := outboxes.LoadOrStore(conn, box)
+	return actual.(*outbox)
+}
+
+// enqueue hands f to the outbox, which releases it once written or if it is refused
+func (b *outbox) enqueue(f *frame) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closing || b.failed {
+		f.release()
+		return errConnClosed
+	}
+	if f.snapshot != "" {
+		b.queueSnapshot(f)
+	} else if len(b.urgent)+len(b.snapshots) >= sendQueueSize {
+		b.dropped++
+		f.release()
+		if b.fullSince.IsZero() {
+			b.fullSince = time.Now()
+		}
+		if b.dropped >= maxDroppedFrames || time.Since(b.fullSince) >= maxFullDuration {
+			b.evict()
+		}
+		return errSendQueueFull
+	} else {
+		b.urgent = append(b.urgent, f)
+	}
+	if !b.scheduled {
+		b.scheduled = true
+		writers.schedule(b)
+	}
+	return nil
+}
+
+// evict gives up on a client that can't drain its queue: the frames are freed and the socket
+// closed, which ends the handler's read loop and disconnects the seat like any dropped
+// connection, keeping it for the player to come back to. Caller must hold b.mu.
+func (b *outbox) evict() {
+	log.Printf("Disconnecting slow client %v after %d dropped frames", b.conn.RemoteAddr(), b.dropped)
+	b.failed = true
+	releaseFrames(b.urgent)
+	releaseFrames(b.snapshots)
+	b.urgent, b.snapshots = nil, nil
+	outboxes.CompareAndDelete(b.conn, b)
+	// Closing can wait on a blocked write, and the caller may be holding the game's lock
+	go closeSlow(b.conn)
+}
+
+// closeSlow tells an evicted client why it was dropped, then closes the socket
+func closeSlow(conn *websocket.Conn) {
+	message := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow")
+	conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
+	conn.Close()
+}
+
+// queueSnapshot queues f in place of any unsent snapshot of the same type. Caller must hold b.mu.
+func (b *outbox) queueSnapshot(f *frame) {
+	for i, queued := range b.snapshots {
+		if queued.snapshot == f.snapshot {
+			queued.release()
+			b.snapshots = append(b.snapshots[:i], b.snapshots[i+1:]...)
+			break
+		}
+	}
+	b.snapshots = append(b.snapshots, f)
+}
+
+// flush writes the frames queued so far, then hands the outbox back to p if more
+// arrived meanwhile. Writing one batch per turn rather than until the outbox is empty keeps
+// a busy connection from holding on to a worker. Called by a pool worker.
+func (b *outbox) flush(p *writePool) {
+	b.mu.Lock()
+	frames := append(b.urgent, b.snapshots...)
+	b.urgent, b.snapshots = nil, nil
+	b.fullSince = time.Time{}
+	closing, closedAt, failed := b.closing, b.closedAt, b.failed
+	b.mu.Unlock()
+
+	if failed {
+		releaseFrames(frames)
+		frames = nil
+	}
+	for i, f := range frames {
+		deadline := time.Now().Add(writeTimeout)
+		if closing {
+			deadline = closedAt.Add(drainTimeout)
+		}
+		b.conn.SetWriteDeadline(deadline)
+		err := b.conn.WriteMessage(f.frameType, f.bytes())
+		f.release()
+		if err != nil {
+			releaseFrames(frames[i+1:])
+			b.mu.Lock()
+			b.failed = true
+			b.mu.Unlock()
+			// A frame sent after releaseConn makes a new outbox; don't let it linger
+			outboxes.CompareAndDelete(b.conn, b)
+			// Wake the read loop so the handler cleans up the dead socket
+			b.conn.Close()
+			break
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.failed && len(b.urgent)+len(b.snapshots) > 0 {
+		p.schedule(b)
+		return
+	}
+	releaseFrames(b.urgent)
+	releaseFrames(b.snapshots)
+	b.urgent, b.snapshots = nil, nil
+	b.scheduled = false
+	b.drained.Broadcast()
+}
+
+func releaseFrames(frames []*frame) {
+	for _, f := range frames {
+		f.release()
+	}
+}
+
+// close stops new frames and waits for the queued ones to be written. A client that has
+// stopped reading holds it up no longer than the write deadline already set.
+func (b *outbox) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closing = true
+	b.closedAt = time.Now()
+	for b.scheduled {
+		b.drained.Wait()
+	}
+}
+
+// writePool is a fixed set of workers writing outboxes that have frames waiting, so
+// sending never blocks the game and a burst of broadcasts across many games doesn't start
+// a goroutine per message or per connection. Outboxes wait in a list rather than a channel
+// so scheduling never blocks either. A client that stops reading holds a worker no longer
+// than the write deadline, or until it is evicted for falling behind, whichever is sooner.
+type writePool struct {
+	ready []*outbox
+	cond  *sync.Cond
+	mu    sync.Mutex
+}
+
+// writers is the server's write pool
+var writers = newWritePool(writeWorkers)
+
+func newWritePool(workers int) *writePool {
+	p := &writePool{}
+	p.cond = sync.NewCond(&p.mu)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+func (p *writePool) schedule(b *outbox) {
+	p.mu.Lock()
+	p.ready = append(p.ready, b)
+	p.mu.Unlock()
+	p.cond.Signal()
+}
+
+func (p *writePool) work() {
+	for {
+		p.mu.Lock()
+		for len(p.ready) == 0 {
+			p.cond.Wait()
+		}
+		b := p.ready[0]
+		p.ready[0] = nil
+		p.ready = p.ready[1:]
+		p.mu.Unlock()
+
+		b.flush(p)
+	}
+}
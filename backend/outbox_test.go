@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newTestConnPair returns both ends of a live WebSocket connection
+func newTestConnPair(t *testing.T) (server, client *websocket.Conn) {
+	t.Helper()
+	serverConn := make(chan *websocket.Conn, 1)
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		serverConn <- conn
+	}))
+	t.Cleanup(httpServer.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server = <-serverConn
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return server, client
+}
+
+func TestWritesArriveInOrder(t *testing.T) {
+	server, client := newTestConnPair(t)
+	for i := 0; i < 100; i++ {
+		if err := writeJSON(server, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	releaseConn(server)
+
+	client.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for i := 0; i < 100; i++ {
+		_, data, err := client.ReadMessage()
+		if err != nil {
+			t.Fatalf("Frame %d: %v", i, err)
+		}
+		if string(data) != strconv.Itoa(i) {
+			t.Fatalf("Expected frame %d, got %s", i, data)
+		}
+	}
+}
+
+func TestConcurrentWritersShareOneConn(t *testing.T) {
+	server, client := newTestConnPair(t)
+	const senders, perSender = 8, 25 // Fewer frames than sendQueueSize, so none are refused
+	var wg sync.WaitGroup
+	for s := 0; s < senders; s++ {
+		wg.Add(1)
+		go func(s int) {
+			defer wg.Done()
+			for i := 0; i < perSender; i++ {
+				if err := writeJSON(server, [2]int{s, i}); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(s)
+	}
+	wg.Wait()
+
+	// Every frame arrives whole, and each sender's frames keep their order
+	next := make([]int, senders)
+	client.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for n := 0; n < senders*perSender; n++ {
+		var got [2]int
+		if err := client.ReadJSON(&got); err != nil {
+			t.Fatalf("Frame %d: %v", n, err)
+		}
+		if got[1] != next[got[0]] {
+			t.Fatalf("Sender %d: expected frame %d, got %d", got[0], next[got[0]], got[1])
+		}
+		next[got[0]]++
+	}
+}
+
+func TestReleasedConnRefusesWrites(t *testing.T) {
+	server, _ := newTestConnPair(t)
+	writeJSON(server, "hello")
+	box := outboxFor(server)
+	releaseConn(server)
+
+	late, _ := encodeFrame(jsonWire, "late")
+	if err := box.enqueue(late); err != errConnClosed {
+		t.Errorf("Expected errConnClosed, got %v", err)
+	}
+}
+
+func TestFullQueueRefusesFrames(t *testing.T) {
+	server, _ := newTestConnPair(t)
+	// Hold the box as scheduled so no worker drains it
+	box := &outbox{conn: server, scheduled: true}
+	for i := 0; i < sendQueueSize; i++ {
+		f, _ := encodeFrame(jsonWire, i)
+		if err := box.enqueue(f); err != nil {
+			t.Fatalf("Frame %d: %v", i, err)
+		}
+	}
+	f, _ := encodeFrame(jsonWire, "one too many")
+	if err := box.enqueue(f); err != errSendQueueFull {
+		t.Errorf("Expected errSendQueueFull, got %v", err)
+	}
+	if box.dropped != 1 {
+		t.Errorf("Expected one dropped frame, got %d", box.dropped)
+	}
+}
+
+func TestEncodeFrameMatchesMarshal(t *testing.T) {
+	message := Message{Type: "chat", Payload: map[string]interface{}{"text": "<b>hi</b> & bye"}}
+	want, _ := json.Marshal(message)
+	// Twice, so the second encode reuses a pooled buffer
+	for i := 0; i < 2; i++ {
+		f, err := encodeFrame(jsonWire, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := f.bytes(); string(got) != string(want) {
+			t.Errorf("Expected %s, got %s", want, got)
+		}
+		f.release()
+	}
+}
+
+func TestEventsGoAheadOfSnapshots(t *testing.T) {
+	server, _ := newTestConnPair(t)
+	box := &outbox{conn: server, scheduled: true}
+	for _, message := range []Message{
+		{Type: "gameState", Payload: 1},
+		{Type: "error", Payload: "oops"},
+		{Type: "lobbyPlayers", Payload: 1},
+		{Type: "gameState", Payload: 2},
+		{Type: "stackAttempt", Payload: true},
+	} {
+		f, _ := encodeFrame(jsonWire, message)
+		if err := box.enqueue(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	for _, f := range append(box.urgent, box.snapshots...) {
+		got = append(got, string(f.bytes()))
+	}
+	want := []string{
+		`{"type":"error","payload":"oops"}`,
+		`{"type":"stackAttempt","payload":true}`,
+		`{"type":"lobbyPlayers","payload":1}`,
+		`{"type":"gameState","payload":2}`,
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// fillOutbox queues frames on a box no worker will drain until one is refused
+func fillOutbox(t *testing.T, box *outbox) {
+	t.Helper()
+	for i := 0; ; i++ {
+		f, _ := encodeFrame(jsonWire, i)
+		if err := box.enqueue(f); err == errSendQueueFull {
+			return
+		} else if err != nil {
+			t.Fatalf("Frame %d: %v", i, err)
+		}
+	}
+}
+
+func TestSlowClientIsEvicted(t *testing.T) {
+	server, client := newTestConnPair(t)
+	box := &outbox{conn: server, scheduled: true}
+	fillOutbox(t, box)
+	for i := 1; i < maxDroppedFrames; i++ {
+		f, _ := encodeFrame(jsonWire, i)
+		box.enqueue(f)
+	}
+
+	if f, _ := encodeFrame(jsonWire, "after"); box.enqueue(f) != errConnClosed {
+		t.Error("An evicted client should take no more frames")
+	}
+	if len(box.urgent) != 0 {
+		t.Errorf("Expected the queue to be freed, %d frames left", len(box.urgent))
+	}
+	client.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := client.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseTryAgainLater) {
+		t.Errorf("Expected the socket to be closed as too slow, got %v", err)
+	}
+}
+
+func TestClientFullTooLongIsEvicted(t *testing.T) {
+	server, _ := newTestConnPair(t)
+	box := &outbox{conn: server, scheduled: true}
+	fillOutbox(t, box)
+	box.fullSince = time.Now().Add(-maxFullDuration)
+
+	f, _ := encodeFrame(jsonWire, "one more")
+	box.enqueue(f)
+	if !box.failed {
+		t.Error("A queue full for too long should get the client evicted")
+	}
+}
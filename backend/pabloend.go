@@ -0,0 +1,59 @@
+package main
+
+import "pablo/protocol"
+
+// Once Pablo has been called the round is on its last lap, and a player whose connection
+// has dropped doesn't hold it up. When the turn comes to someone absent, the caller
+// included if they dropped before ending the turn they called on, the turn is closed for
+// them: a card they drew goes back where it came from, a power waiting on them is skipped,
+// and play moves on. Their hand is scored as it stands when the round ends, which is still
+// when rotation comes back round to the caller's seat.
+
+// skipAbsentTurn closes the current player's turn for them if they're absent during a
+// Pablo call's last lap, or their seat is vacant, and reports whether it did. Caller must
+// hold g.mu.
+func (g *Game) skipAbsentTurn() bool {
+	id := g.CurrentPlayer
+	_, away := g.absent[id]
+	if g.Status != protocol.StatusPlaying || !(g.PabloCalled && away || g.vacant[id]) {
+		return false
+	}
+	if g.PendingGive != nil {
+		if _, away := g.absent[g.PendingGive.ActorID]; !away {
+			return false // Someone here still owes a card; their give moves things on
+		}
+		g.PendingGive = nil
+	}
+
+	if drawn := g.DrawnCards[id]; drawn != nil {
+		card := *drawn
+		if g.DrawnFromDiscard[id] {
+			card.FaceUp = true
+			g.pushDiscard(card)
+		} else {
+			card.FaceUp = false
+			g.Deck = append([]Card{card}, g.Deck...)
+		}
+	}
+	delete(g.DrawnCards, id)
+	delete(g.DrawnFromDiscard, id)
+
+	if g.PendingKingSwap != nil && g.PendingKingSwap.ActorID == id {
+		g.PendingKingSwap = nil
+	}
+	if g.PendingSpecialCard != "" {
+		g.finishSpecialCard()
+		// Someone who stacked on the special card now gets to use it
+		if g.CurrentPlayer != id {
+			if !g.skipAbsentTurn() {
+				g.broadcastGameState()
+				g.scheduleBots()
+			}
+			return true
+		}
+	}
+
+	g.emitAction(id, protocol.MsgEndTurn, map[string]interface{}{"absent": true})
+	g.passTurn(id)
+	return true
+}
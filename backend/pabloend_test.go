@@ -0,0 +1,87 @@
+package main
+
+import "testing"
+
+// disconnectTestPlayer gives playerID a live connection and then drops it
+func disconnectTestPlayer(t *testing.T, game *Game, playerID string) {
+	t.Helper()
+	conn := newTestConn(t)
+	game.Players[playerID].Conn = conn
+	game.Disconnect(playerID, conn)
+}
+
+func TestPabloCallerDisconnectsMidTurn(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+	caller := game.CurrentPlayer
+	other := playerIDs[0]
+	if other == caller {
+		other = playerIDs[1]
+	}
+
+	game.CallPablo(caller)
+	game.Deck[0] = Card{Suit: "clubs", Rank: "Q"}
+	game.DrawCard(caller)
+	deckSize := len(game.Deck)
+	disconnectTestPlayer(t, game, caller)
+
+	if game.CurrentPlayer != other {
+		t.Fatalf("The absent caller's turn should pass on, current is %s", game.CurrentPlayer)
+	}
+	if len(game.Deck) != deckSize+1 || game.Deck[0].Rank != "Q" || game.Deck[0].FaceUp {
+		t.Error("The caller's drawn card should go back face down on the deck")
+	}
+
+	game.DrawCard(other)
+	game.DiscardDrawnCard(other)
+	if game.PendingSpecialCard != "" {
+		game.SkipSpecialCard(other)
+	}
+	game.EndTurn(other)
+	if game.Status != "ended" {
+		t.Errorf("The round should end when rotation returns to the absent caller, status %s", game.Status)
+	}
+}
+
+func TestAbsentPlayerSkippedInLastLap(t *testing.T) {
+	game := createTestGame("test-game")
+	addTestPlayers(game, 3)
+	game.StartGame()
+	caller := game.CurrentPlayer
+	absent := game.nextSeatAfter(caller)
+	last := game.nextSeatAfter(absent)
+	disconnectTestPlayer(t, game, absent)
+	setHand(game, absent, "K", "K", "K", "K")
+
+	game.CallPablo(caller)
+	game.Deck[0] = Card{Suit: "clubs", Rank: "2"}
+	game.DrawCard(caller)
+	game.DiscardDrawnCard(caller)
+	game.EndTurn(caller)
+	if game.CurrentPlayer != last {
+		t.Fatalf("An absent player's last turn should be skipped, current is %s", game.CurrentPlayer)
+	}
+
+	game.Deck[0] = Card{Suit: "clubs", Rank: "2"}
+	game.DrawCard(last)
+	game.DiscardDrawnCard(last)
+	game.EndTurn(last)
+	if game.Status != "ended" {
+		t.Fatalf("The round should end back at the caller, status %s", game.Status)
+	}
+	if score := game.Players[absent].Score; score != 4*game.cardValue(Card{Suit: "clubs", Rank: "K"}) {
+		t.Errorf("The absent player's hand should be scored as it stood, got %d", score)
+	}
+}
+
+func TestAbsentTurnNotSkippedWithoutPablo(t *testing.T) {
+	game := createTestGame("test-game")
+	addTestPlayers(game, 2)
+	game.StartGame()
+	current := game.CurrentPlayer
+	disconnectTestPlayer(t, game, current)
+	if game.CurrentPlayer != current {
+		t.Error("Outside a Pablo call's last lap the seat keeps its turn for them to come back")
+	}
+}
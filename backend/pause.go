@@ -0,0 +1,170 @@
+package main
+
+import (
+	"time"
+
+	"pablo/protocol"
+)
+
+// pausableActions are the gameplay messages rejected with GAME_PAUSED while a game is paused
+var pausableActions = map[string]bool{
+	protocol.MsgDrawCard:                  true,
+	protocol.MsgDrawFromDiscard:           true,
+	protocol.MsgDiscardDrawnCard:          true,
+	protocol.MsgSwapCard:                  true,
+	protocol.MsgSwapMultipleCards:         true,
+	protocol.MsgUseSpecialCardFromDiscard: true,
+	protocol.MsgSkipSpecialCard:           true,
+	protocol.MsgConfirmKingSwap:           true,
+	protocol.MsgDeclineKingSwap:           true,
+	protocol.MsgCallPablo:                 true,
+	protocol.MsgRevealClaim:               true,
+	protocol.MsgEndTurn:                   true,
+	protocol.MsgStackCard:                 true,
+	protocol.MsgStackOpponentCard:         true,
+	protocol.MsgGiveCardToPlayer:          true,
+	protocol.MsgPeekCard:                  true,
+	protocol.MsgConfirmPeek:               true,
+}
+
+// RequestPause pauses a game in progress. The host pauses immediately; anyone else
+// adds their vote and the game pauses once every connected player has asked for it.
+// Bots, players waiting for the next round and seats nobody is at don't get a say.
+func (g *Game) RequestPause(playerID string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.Status != protocol.StatusPlaying {
+		return false
+	}
+	voters := g.pauseVoters()
+	if playerID != g.HostID && !voters[playerID] {
+		return false
+	}
+
+	g.PauseVotes[playerID] = true
+	if playerID != g.HostID && g.pauseVotesFrom(voters) < len(voters) {
+		g.broadcastPauseVotes("pause", voters)
+		return true
+	}
+
+	g.Status = protocol.StatusPaused
+	g.PausedAt = time.Now()
+	g.PauseVotes = make(map[string]bool)
+	g.RejoinedSincePause = make(map[string]bool)
+
+	g.broadcast(Message{
+		Type:    protocol.MsgGamePaused,
+		Payload: map[string]interface{}{"pausedBy": playerID},
+	})
+	g.broadcastGameState()
+	return true
+}
+
+// RequestResume continues a paused game exactly where it left off. Like pausing, the host
+// resumes immediately and anyone else needs every connected player to agree.
+func (g *Game) RequestResume(playerID string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.Status != protocol.StatusPaused {
+		return false
+	}
+	voters := g.pauseVoters()
+	if playerID != g.HostID && !voters[playerID] {
+		return false
+	}
+
+	g.PauseVotes[playerID] = true
+	if playerID != g.HostID && g.pauseVotesFrom(voters) < len(voters) {
+		g.broadcastPauseVotes("resume", voters)
+		return true
+	}
+
+	g.resume(playerID)
+	return true
+}
+
+// IsPaused reports whether gameplay actions are currently frozen
+func (g *Game) IsPaused() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.Status == protocol.StatusPaused
+}
+
+// noteRejoin records that a player joined again while the game was paused.
+// Once everyone seated has come back, play resumes on its own; bots and seats already
+// given up on aren't waited for. Caller must hold g.mu.
+func (g *Game) noteRejoin(playerID string) {
+	if g.Status != protocol.StatusPaused {
+		return
+	}
+	g.RejoinedSincePause[playerID] = true
+	for _, id := range g.SeatOrder {
+		if g.Bots[id] == nil && !g.vacant[id] && !g.RejoinedSincePause[id] {
+			return
+		}
+	}
+	g.resume("")
+}
+
+// resume puts a paused game back into play. Caller must hold g.mu.
+func (g *Game) resume(resumedBy string) {
+	g.clearAdjourned()
+	g.Status = protocol.StatusPlaying
+	g.PausedAt = time.Time{}
+	g.PauseVotes = make(map[string]bool)
+	g.RejoinedSincePause = make(map[string]bool)
+	g.resumeTurnTimer()
+	g.resumeGiveTimer()
+	g.resumeStackWindow()
+	g.resumePeekTimer()
+
+	g.broadcast(Message{
+		Type:    protocol.MsgGameResumed,
+		Payload: map[string]interface{}{"resumedBy": resumedBy},
+	})
+	g.broadcastGameState()
+	g.scheduleBots()
+}
+
+// pauseVoters is who has a say in pausing and resuming: the seated players who are
+// connected, leaving out bots and players waiting for the next round. Caller must hold g.mu.
+func (g *Game) pauseVoters() map[string]bool {
+	voters := make(map[string]bool)
+	for _, id := range g.SeatOrder {
+		if g.Bots[id] == nil && !g.vacant[id] && g.Players[id].Conn != nil {
+			voters[id] = true
+		}
+	}
+	return voters
+}
+
+// pauseVotesFrom counts the votes cast by voters, so a vote from someone who has since
+// dropped off doesn't count. Caller must hold g.mu.
+func (g *Game) pauseVotesFrom(voters map[string]bool) int {
+	votes := 0
+	for id := range g.PauseVotes {
+		if voters[id] {
+			votes++
+		}
+	}
+	return votes
+}
+
+func (g *Game) broadcastPauseVotes(kind string, eligible map[string]bool) {
+	voters := make([]string, 0, len(g.PauseVotes))
+	for id := range g.PauseVotes {
+		if eligible[id] {
+			voters = append(voters, id)
+		}
+	}
+	g.broadcast(Message{
+		Type: protocol.MsgPauseVote,
+		Payload: map[string]interface{}{
+			"kind":     kind,
+			"voters":   voters,
+			"required": len(eligible),
+		},
+	})
+}
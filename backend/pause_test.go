@@ -0,0 +1,165 @@
+package main
+
+import (
+	"testing"
+
+	"pablo/protocol"
+)
+
+func TestHostPausesAndResumes(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 3)
+	game.StartGame()
+
+	host := playerIDs[0]
+	if game.HostID != host {
+		t.Fatalf("Expected first player to be host, got '%s'", game.HostID)
+	}
+
+	currentPlayer := game.CurrentPlayer
+	if !game.RequestPause(host) {
+		t.Fatal("Host should be able to pause")
+	}
+	if !game.IsPaused() {
+		t.Fatal("Game should be paused")
+	}
+
+	if !game.RequestResume(host) {
+		t.Fatal("Host should be able to resume")
+	}
+	if game.Status != "playing" {
+		t.Errorf("Expected status 'playing' after resume, got '%s'", game.Status)
+	}
+	if game.CurrentPlayer != currentPlayer {
+		t.Error("Resuming should continue with the same current player")
+	}
+}
+
+// connectTestPlayers gives each of playerIDs a live connection
+func connectTestPlayers(t *testing.T, game *Game, playerIDs ...string) {
+	for _, id := range playerIDs {
+		game.Players[id].Conn = newTestConn(t)
+	}
+}
+
+func TestUnanimousPause(t *testing.T) {
+	game := createTestGame("test-game")
+	game.SetBroadcaster(newRecordingBroadcaster())
+	playerIDs := addTestPlayers(game, 3)
+	connectTestPlayers(t, game, playerIDs...)
+	game.StartGame()
+
+	game.RequestPause(playerIDs[1])
+	if game.IsPaused() {
+		t.Fatal("One non-host vote should not pause the game")
+	}
+	game.RequestPause(playerIDs[2])
+	if game.IsPaused() {
+		t.Fatal("Game should wait for every player")
+	}
+	game.RequestPause(playerIDs[0])
+	if !game.IsPaused() {
+		t.Fatal("Game should pause once everyone voted")
+	}
+	if len(game.PauseVotes) != 0 {
+		t.Error("Pause votes should be cleared after pausing")
+	}
+}
+
+func TestPauseVotesOnlyFromConnectedHumans(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	playerIDs := addTestPlayers(game, 4)
+	game.AddBot("bot-1", "Bot 1")
+	connectTestPlayers(t, game, playerIDs[:3]...)
+	game.StartGame()
+
+	if game.RequestPause(playerIDs[3]) {
+		t.Error("A disconnected seat should not get a vote")
+	}
+	game.RequestPause(playerIDs[1])
+	game.RequestPause(playerIDs[2])
+	if game.IsPaused() {
+		t.Fatal("Game should still wait for the host")
+	}
+
+	recorder.mu.Lock()
+	messages := recorder.players[playerIDs[1]]
+	recorder.mu.Unlock()
+	var vote Message
+	for _, message := range messages {
+		if message.Type == protocol.MsgPauseVote {
+			vote = message
+		}
+	}
+	payload, _ := vote.Payload.(map[string]interface{})
+	if payload["required"] != 3 || len(payload["voters"].([]string)) != 2 {
+		t.Errorf("Expected 2 of 3 votes, leaving out the bot and the disconnected seat, got %v", payload)
+	}
+
+	game.RequestPause(playerIDs[0])
+	if !game.IsPaused() {
+		t.Error("Game should pause once every connected player voted")
+	}
+}
+
+func TestPauseRequiresPlayingGame(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+
+	if game.RequestPause(playerIDs[0]) {
+		t.Error("Should not be able to pause a game that hasn't started")
+	}
+	if game.RequestResume(playerIDs[0]) {
+		t.Error("Should not be able to resume a game that isn't paused")
+	}
+}
+
+func TestEveryoneRejoiningResumes(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+
+	hand := append([]Card(nil), game.Players[playerIDs[1]].Cards...)
+	game.RequestPause(playerIDs[0])
+
+	game.AddPlayer(playerIDs[1], "Player 2", nil)
+	if !game.IsPaused() {
+		t.Fatal("Game should stay paused until everyone rejoined")
+	}
+	for i, card := range game.Players[playerIDs[1]].Cards {
+		if card != hand[i] {
+			t.Fatal("Rejoining should keep the player's hand")
+		}
+	}
+
+	game.AddPlayer(playerIDs[0], "Player 1", nil)
+	if game.IsPaused() {
+		t.Error("Game should resume once everyone rejoined")
+	}
+}
+
+func TestRejoiningResumesWithoutBots(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.AddBot("bot-1", "Bot 1")
+	game.StartGame()
+	game.RequestPause(playerIDs[0])
+
+	game.AddPlayer(playerIDs[0], "Player 1", nil)
+	game.AddPlayer(playerIDs[1], "Player 2", nil)
+	if game.IsPaused() {
+		t.Error("Game should resume once every human rejoined, without waiting on the bot")
+	}
+}
+
+func TestHostPassesOnRemoval(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 3)
+
+	game.removePlayer(playerIDs[0])
+	if game.HostID != playerIDs[1] {
+		t.Errorf("Expected host to pass to %s, got '%s'", playerIDs[1], game.HostID)
+	}
+}
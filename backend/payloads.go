@@ -0,0 +1,448 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/gorilla/websocket"
+	"pablo/protocol"
+)
+
+// Client messages are read with their payload left as raw JSON, then decoded into the
+// struct clientPayloads has for their type before they are handled. Handlers read typed
+// fields rather than asserting on a map, and a payload of the wrong shape is answered
+// with an error naming the field instead of panicking the connection's goroutine.
+
+// clientMessage is a message as a client sends it
+type clientMessage struct {
+	Type    string          `json:"type"`
+	GameID  string          `json:"gameID,omitempty"` // The game the message is for, for connections in more than one
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// joinOptions are the fields every message that joins or creates a game may carry
+type joinOptions struct {
+	GameID        string   `json:"gameID"`
+	Name          string   `json:"name"`
+	Locale        string   `json:"locale"`
+	NotesKey      string   `json:"notesKey"`
+	Blocked       []string `json:"blocked"` // Names the player has blocked; nil leaves the blocklist as it was
+	ClientVersion string   `json:"clientVersion"`
+	CaptchaToken  string   `json:"captchaToken"`
+}
+
+type CreateGamePayload struct {
+	joinOptions
+	ScheduledAt string `json:"scheduledAt"`
+	PresetID    string `json:"presetID"`
+}
+
+type JoinPayload struct {
+	joinOptions
+	SessionToken string `json:"sessionToken"`
+	LastSeq      *int64 `json:"lastSeq"`
+}
+
+type ObservePayload struct {
+	GameID      string `json:"gameID"`
+	ObserverKey string `json:"observerKey"`
+}
+
+type SpectatePayload struct {
+	joinOptions
+}
+
+type StartDailyPayload struct {
+	joinOptions
+}
+
+type StartPuzzlePayload struct {
+	joinOptions
+	PuzzleID string `json:"puzzleID"`
+}
+
+type StartTutorialPayload struct {
+	joinOptions
+}
+
+type SubmitPredictionPayload struct {
+	WinnerID      string `json:"winnerID"`
+	PabloSucceeds *bool  `json:"pabloSucceeds"`
+}
+
+type SetReadyPayload struct {
+	Ready bool `json:"ready"`
+}
+
+type RenamePayload struct {
+	Name string `json:"name"`
+}
+
+type UpdateConfigPayload struct {
+	Config json.RawMessage `json:"config"`
+}
+
+type SwapCardPayload struct {
+	CardIndex *int `json:"cardIndex"`
+}
+
+type SwapMultipleCardsPayload struct {
+	CardIndices []int `json:"cardIndices"`
+}
+
+type UseSpecialCardPayload struct {
+	CardRank string                 `json:"cardRank"`
+	Params   map[string]interface{} `json:"params"` // Depend on the power; the game checks them
+}
+
+type ConfirmKingSwapPayload struct {
+	OwnIndex *int `json:"ownIndex"`
+}
+
+type VoteKickPayload struct {
+	TargetID string `json:"targetID"`
+}
+
+type StackCardPayload struct {
+	CardIndex *int `json:"cardIndex"`
+}
+
+type StackOpponentCardPayload struct {
+	TargetPlayerID string `json:"targetPlayerID"`
+	CardIndex      *int   `json:"cardIndex"`
+}
+
+type GiveCardPayload struct {
+	SourceIndex *int `json:"sourceIndex"`
+}
+
+type PeekCardPayload struct {
+	CardIndex *int `json:"cardIndex"`
+}
+
+type ReportPlayerPayload struct {
+	TargetID string `json:"targetID"`
+	Reason   string `json:"reason"`
+}
+
+type SendChatPayload struct {
+	Text string `json:"text"`
+}
+
+type BlockPlayerPayload struct {
+	PlayerID string `json:"playerID"`
+	Blocked  bool   `json:"blocked"`
+}
+
+type MutePlayerPayload struct {
+	TargetID string `json:"targetID"`
+	Muted    bool   `json:"muted"`
+}
+
+type TransferHostPayload struct {
+	TargetID string `json:"targetID"`
+}
+
+type SetStreamerModePayload struct {
+	Enabled bool `json:"enabled"`
+}
+
+type LockTablePayload struct {
+	Locked bool `json:"locked"`
+}
+
+type SetHandicapPayload struct {
+	TargetID string `json:"targetID"`
+	Points   int    `json:"points"`
+}
+
+type SetNotePayload struct {
+	PlayerID string `json:"playerID"`
+	Text     string `json:"text"`
+}
+
+type ResyncPayload struct {
+	LastSeq *int64 `json:"lastSeq"`
+}
+
+// PresetPayload is the payload of savePreset, deletePreset and getPresets
+type PresetPayload struct {
+	NotesKey string          `json:"notesKey"`
+	Name     string          `json:"name"`
+	Config   json.RawMessage `json:"config"`
+	PresetID string          `json:"presetID"`
+}
+
+type PresencePayload struct {
+	FriendKey string   `json:"friendKey"`
+	Name      string   `json:"name"`
+	Friends   []string `json:"friends"`
+}
+
+// InvitePayload is the payload of acceptInvite and declineInvite
+type InvitePayload struct {
+	InviteID string `json:"inviteID"`
+}
+
+type ListGamesPayload struct {
+	Joinable bool `json:"joinable"`
+}
+
+func newPayload[T any]() interface{} {
+	return new(T)
+}
+
+// clientPayloads makes the payload struct for each client message type that has one.
+// Messages of other types carry nothing, and anything sent with them is ignored.
+var clientPayloads = map[string]func() interface{}{
+	protocol.MsgCreateGame:                newPayload[CreateGamePayload],
+	protocol.MsgJoin:                      newPayload[JoinPayload],
+	protocol.MsgObserve:                   newPayload[ObservePayload],
+	protocol.MsgSpectate:                  newPayload[SpectatePayload],
+	protocol.MsgSubmitPrediction:          newPayload[SubmitPredictionPayload],
+	protocol.MsgStartDaily:                newPayload[StartDailyPayload],
+	protocol.MsgStartPuzzle:               newPayload[StartPuzzlePayload],
+	protocol.MsgStartTutorial:             newPayload[StartTutorialPayload],
+	protocol.MsgSetReady:                  newPayload[SetReadyPayload],
+	protocol.MsgRename:                    newPayload[RenamePayload],
+	protocol.MsgUpdateConfig:              newPayload[UpdateConfigPayload],
+	protocol.MsgSwapCard:                  newPayload[SwapCardPayload],
+	protocol.MsgSwapMultipleCards:         newPayload[SwapMultipleCardsPayload],
+	protocol.MsgUseSpecialCardFromDiscard: newPayload[UseSpecialCardPayload],
+	protocol.MsgConfirmKingSwap:           newPayload[ConfirmKingSwapPayload],
+	protocol.MsgVoteKick:                  newPayload[VoteKickPayload],
+	protocol.MsgStackCard:                 newPayload[StackCardPayload],
+	protocol.MsgStackOpponentCard:         newPayload[StackOpponentCardPayload],
+	protocol.MsgGiveCardToPlayer:          newPayload[GiveCardPayload],
+	protocol.MsgPeekCard:                  newPayload[PeekCardPayload],
+	protocol.MsgReportPlayer:              newPayload[ReportPlayerPayload],
+	protocol.MsgSendChat:                  newPayload[SendChatPayload],
+	protocol.MsgBlockPlayer:               newPayload[BlockPlayerPayload],
+	protocol.MsgMutePlayer:                newPayload[MutePlayerPayload],
+	protocol.MsgTransferHost:              newPayload[TransferHostPayload],
+	protocol.MsgSetStreamerMode:           newPayload[SetStreamerModePayload],
+	protocol.MsgLockTable:                 newPayload[LockTablePayload],
+	protocol.MsgSetHandicap:               newPayload[SetHandicapPayload],
+	protocol.MsgSetNote:                   newPayload[SetNotePayload],
+	protocol.MsgResync:                    newPayload[ResyncPayload],
+	protocol.MsgSavePreset:                newPayload[PresetPayload],
+	protocol.MsgDeletePreset:              newPayload[PresetPayload],
+	protocol.MsgGetPresets:                newPayload[PresetPayload],
+	protocol.MsgPresence:                  newPayload[PresencePayload],
+	protocol.MsgAcceptInvite:              newPayload[InvitePayload],
+	protocol.MsgDeclineInvite:             newPayload[InvitePayload],
+	protocol.MsgListGames:                 newPayload[ListGamesPayload],
+}
+
+// payloadError is a payload that doesn't have the shape its message type needs
+type payloadError struct {
+	field string // The JSON field that is missing or of the wrong type; "" if the payload couldn't be read at all
+}
+
+func (e *payloadError) Error() string {
+	if e.field == "" {
+		return "payload is not a JSON object of the expected shape"
+	}
+	return "payload field " + e.field + " is missing or invalid"
+}
+
+// missing reports a required field that wasn't sent
+func missing(field string) error {
+	return &payloadError{field: field}
+}
+
+func (p *CreateGamePayload) validate() error {
+	if p.Name == "" {
+		return missing("name")
+	}
+	return nil
+}
+
+func (p *JoinPayload) validate() error {
+	if p.GameID == "" {
+		return missing("gameID")
+	}
+	if p.Name == "" {
+		return missing("name")
+	}
+	return nil
+}
+
+func (p *ObservePayload) validate() error {
+	if p.GameID == "" {
+		return missing("gameID")
+	}
+	return nil
+}
+
+func (p *SpectatePayload) validate() error {
+	if p.GameID == "" {
+		return missing("gameID")
+	}
+	return nil
+}
+
+func (p *StartDailyPayload) validate() error {
+	if p.Name == "" {
+		return missing("name")
+	}
+	return nil
+}
+
+func (p *StartPuzzlePayload) validate() error {
+	if p.PuzzleID == "" {
+		return missing("puzzleID")
+	}
+	if p.Name == "" {
+		return missing("name")
+	}
+	return nil
+}
+
+func (p *SwapCardPayload) validate() error {
+	if p.CardIndex == nil {
+		return missing("cardIndex")
+	}
+	return nil
+}
+
+func (p *SwapMultipleCardsPayload) validate() error {
+	if len(p.CardIndices) == 0 {
+		return missing("cardIndices")
+	}
+	return nil
+}
+
+func (p *UseSpecialCardPayload) validate() error {
+	if p.CardRank == "" {
+		return missing("cardRank")
+	}
+	return nil
+}
+
+func (p *ConfirmKingSwapPayload) validate() error {
+	if p.OwnIndex == nil {
+		return missing("ownIndex")
+	}
+	return nil
+}
+
+func (p *VoteKickPayload) validate() error {
+	if p.TargetID == "" {
+		return missing("targetID")
+	}
+	return nil
+}
+
+func (p *StackCardPayload) validate() error {
+	if p.CardIndex == nil {
+		return missing("cardIndex")
+	}
+	return nil
+}
+
+func (p *StackOpponentCardPayload) validate() error {
+	if p.TargetPlayerID == "" {
+		return missing("targetPlayerID")
+	}
+	if p.CardIndex == nil {
+		return missing("cardIndex")
+	}
+	return nil
+}
+
+func (p *PeekCardPayload) validate() error {
+	if p.CardIndex == nil {
+		return missing("cardIndex")
+	}
+	return nil
+}
+
+func (p *GiveCardPayload) validate() error {
+	if p.SourceIndex == nil {
+		return missing("sourceIndex")
+	}
+	return nil
+}
+
+// decodePayload decodes msg's payload into the struct for its type and checks that the
+// fields it can't do without are there. Types without a payload struct decode to nil.
+func decodePayload(msg *clientMessage) (interface{}, error) {
+	makePayload, exists := clientPayloads[msg.Type]
+	if !exists {
+		return nil, nil
+	}
+	payload := makePayload()
+	if len(msg.Payload) > 0 {
+		if err := json.Unmarshal(msg.Payload, payload); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				return nil, &payloadError{field: typeErr.Field}
+			}
+			return nil, &payloadError{}
+		}
+	}
+	if v, ok := payload.(interface{ validate() error }); ok {
+		if err := v.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return payload, nil
+}
+
+// joinOptionsOf returns the join fields of a decoded payload, if it has them
+func joinOptionsOf(payload interface{}) joinOptions {
+	switch p := payload.(type) {
+	case *CreateGamePayload:
+		return p.joinOptions
+	case *JoinPayload:
+		return p.joinOptions
+	case *SpectatePayload:
+		return p.joinOptions
+	case *StartDailyPayload:
+		return p.joinOptions
+	case *StartPuzzlePayload:
+		return p.joinOptions
+	case *StartTutorialPayload:
+		return p.joinOptions
+	}
+	return joinOptions{}
+}
+
+// cardIndexOf returns the hand slot a decoded payload names, or -1 if it names none
+func cardIndexOf(payload interface{}) int {
+	switch p := payload.(type) {
+	case *SwapCardPayload:
+		return *p.CardIndex
+	case *StackCardPayload:
+		return *p.CardIndex
+	case *StackOpponentCardPayload:
+		return *p.CardIndex
+	case *PeekCardPayload:
+		return *p.CardIndex
+	}
+	return -1
+}
+
+// sendPayloadError tells conn a message of msgType was refused because its payload
+// couldn't be used, naming the field at fault when there is one
+func sendPayloadError(conn *websocket.Conn, msgType string, err error) {
+	field := ""
+	var payloadErr *payloadError
+	if errors.As(err, &payloadErr) {
+		field = payloadErr.field
+	}
+	message := localize(conn, protocol.CodeInvalidPayload, msgType)
+	if field != "" {
+		message = localize(conn, protocol.CodeInvalidPayload+".field", msgType, field)
+	}
+	writeJSON(conn, Message{
+		Type: protocol.MsgError,
+		Payload: map[string]string{
+			"code":        protocol.CodeInvalidPayload,
+			"message":     message,
+			"messageType": msgType,
+			"field":       field,
+		},
+	})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"pablo/protocol"
+)
+
+func TestDecodePayload(t *testing.T) {
+	payload, err := decodePayload(&clientMessage{
+		Type:    protocol.MsgJoin,
+		Payload: json.RawMessage(`{"gameID":"g1","name":"Ann","blocked":["Bob"],"lastSeq":4}`),
+	})
+	if err != nil {
+		t.Fatalf("Decoding a join failed: %v", err)
+	}
+	join, ok := payload.(*JoinPayload)
+	if !ok {
+		t.Fatalf("Expected a *JoinPayload, got %T", payload)
+	}
+	if join.GameID != "g1" || join.Name != "Ann" || len(join.Blocked) != 1 || join.LastSeq == nil || *join.LastSeq != 4 {
+		t.Errorf("Unexpected join payload %+v", join)
+	}
+	if options := joinOptionsOf(payload); options.Name != "Ann" {
+		t.Errorf("Expected the join options to carry the name, got %+v", options)
+	}
+
+	payload, err = decodePayload(&clientMessage{Type: protocol.MsgSwapCard, Payload: json.RawMessage(`{"cardIndex":0}`)})
+	if err != nil {
+		t.Fatalf("Decoding a swap failed: %v", err)
+	}
+	if index := cardIndexOf(payload); index != 0 {
+		t.Errorf("Expected slot 0, got %d", index)
+	}
+
+	// Messages with nothing to read decode to nil
+	if payload, err := decodePayload(&clientMessage{Type: protocol.MsgDrawCard}); payload != nil || err != nil {
+		t.Errorf("Expected nothing for drawCard, got %v, %v", payload, err)
+	}
+}
+
+func TestDecodePayloadNamesTheBadField(t *testing.T) {
+	cases := []struct {
+		msgType string
+		payload string
+		field   string
+	}{
+		{protocol.MsgSwapCard, `{}`, "cardIndex"},
+		{protocol.MsgSwapCard, `{"cardIndex":"two"}`, "cardIndex"},
+		{protocol.MsgJoin, `{"name":"Ann"}`, "gameID"},
+		{protocol.MsgCreateGame, `{"name":42}`, "name"},
+		{protocol.MsgSwapMultipleCards, `{"cardIndices":[]}`, "cardIndices"},
+		{protocol.MsgStackOpponentCard, `{"cardIndex":1}`, "targetPlayerID"},
+		{protocol.MsgSetReady, `[]`, ""},
+	}
+	for _, c := range cases {
+		_, err := decodePayload(&clientMessage{Type: c.msgType, Payload: json.RawMessage(c.payload)})
+		var payloadErr *payloadError
+		if !errors.As(err, &payloadErr) {
+			t.Errorf("%s %s: expected a payloadError, got %v", c.msgType, c.payload, err)
+			continue
+		}
+		if payloadErr.field != c.field {
+			t.Errorf("%s %s: expected field %q, got %q", c.msgType, c.payload, c.field, payloadErr.field)
+		}
+	}
+}
+
+func TestMalformedPayloadKeepsConnection(t *testing.T) {
+	conn, _ := createTestGameOverWS(t)
+	sendTestMessage(t, conn, "swapCard", map[string]interface{}{"cardIndex": "two"})
+
+	reply := readMessageOfType(t, conn, "error")
+	if reply["code"] != protocol.CodeInvalidPayload || reply["field"] != "cardIndex" || reply["messageType"] != "swapCard" {
+		t.Errorf("Unexpected error %v", reply)
+	}
+
+	// The connection is still served
+	sendTestMessage(t, conn, "getState", nil)
+	readMessageOfType(t, conn, "gameState")
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"pablo/protocol"
+)
+
+// At InitialPeek tables a round doesn't open on the first turn. As in standard Pablo, the
+// table first spends a peeking phase in which each player looks at up to two of their own
+// cards and says when they're done. The first turn starts once everyone is done or
+// peekTimeLimit of play runs out, whichever comes first; the clock stops while the game is
+// paused, as the turn clock does. Nothing else can be played meanwhile.
+
+// maxInitialPeeks is how many of their cards each player may look at
+const maxInitialPeeks = 2
+
+// peekTimeLimit is how long the peeking phase lasts at most
+var peekTimeLimit = 30 * time.Second
+
+var (
+	ErrNotPeeking = errors.New("the table isn't peeking at its cards")
+	ErrPeekLimit  = errors.New("you've already looked at two of your cards")
+)
+
+// peekActions are the messages of the peeking phase, the only moves it allows
+var peekActions = map[string]bool{
+	protocol.MsgPeekCard:    true,
+	protocol.MsgConfirmPeek: true,
+}
+
+// peekPhase is where the table is in the peeking phase
+type peekPhase struct {
+	peeked   map[string][]int // The slots each player has looked at
+	done     map[string]bool  // Players ready for the first turn
+	deadline time.Time
+	checked  time.Time // When the phase's clock last looked at the game
+}
+
+// startPeekPhase opens the round with the peeking phase. Bots and vacant seats have
+// nothing to look at, so they are done from the start. Caller must hold g.mu.
+func (g *Game) startPeekPhase() {
+	now := time.Now()
+	phase := &peekPhase{
+		peeked:   make(map[string][]int),
+		done:     make(map[string]bool),
+		deadline: now.Add(peekTimeLimit),
+		checked:  now,
+	}
+	for _, id := range g.SeatOrder {
+		if _, isBot := g.Bots[id]; isBot || g.vacant[id] {
+			phase.done[id] = true
+		}
+	}
+	g.peeking = phase
+	if g.everyonePeeked() {
+		g.endPeekPhase()
+		return
+	}
+	go g.runPeekTimer(phase, peekTimeLimit)
+}
+
+// runPeekTimer ends phase once its time is up, unless the table finished with it first.
+// It first looks after d.
+func (g *Game) runPeekTimer(phase *peekPhase, d time.Duration) {
+	for {
+		select {
+		case <-g.ctx.Done():
+			return
+		case <-time.After(d):
+		}
+
+		g.mu.Lock()
+		if g.peeking != phase {
+			g.mu.Unlock()
+			return
+		}
+		now := time.Now()
+		if g.Status == protocol.StatusPaused {
+			phase.deadline = phase.deadline.Add(now.Sub(phase.checked))
+		}
+		phase.checked = now
+		if !now.Before(phase.deadline) {
+			g.endPeekPhase()
+			g.broadcastGameState()
+			g.scheduleBots()
+			g.mu.Unlock()
+			return
+		}
+		d = min(phase.deadline.Sub(now), turnTimerTick)
+		g.mu.Unlock()
+	}
+}
+
+// resumePeekTimer gives the peeking phase, after a pause, the time it had left when the
+// game paused. Caller must hold g.mu.
+func (g *Game) resumePeekTimer() {
+	if phase := g.peeking; phase != nil {
+		now := time.Now()
+		phase.deadline = phase.deadline.Add(now.Sub(phase.checked))
+		phase.checked = now
+	}
+}
+
+// everyonePeeked reports whether every seat is done peeking. Caller must hold g.mu.
+func (g *Game) everyonePeeked() bool {
+	for _, id := range g.SeatOrder {
+		if !g.peeking.done[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// endPeekPhase closes the peeking phase and starts the first turn. Caller must hold g.mu.
+func (g *Game) endPeekPhase() {
+	g.peeking = nil
+	g.emitTurnStarted()
+}
+
+// peekDeadline is when the peeking phase ends, or zero if the table isn't peeking.
+// Caller must hold g.mu.
+func (g *Game) peekDeadline() time.Time {
+	if g.peeking == nil {
+		return time.Time{}
+	}
+	return g.peeking.deadline
+}
+
+// PeekCard shows playerID the card in one of their slots during the peeking phase
+func (g *Game) PeekCard(playerID string, cardIndex int) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.peeking == nil {
+		return ErrNotPeeking
+	}
+	player, seated := g.Players[playerID]
+	if !seated || g.isWaiting(playerID) {
+		return ErrNotInGame
+	}
+	if g.peeking.done[playerID] || len(g.peeking.peeked[playerID]) >= maxInitialPeeks {
+		return ErrPeekLimit
+	}
+	if cardIndex < 0 || cardIndex >= len(player.Cards) || player.Cards[cardIndex].Rank == "" {
+		return fmt.Errorf("%w: slot %d", ErrInvalidCardIndex, cardIndex)
+	}
+	for _, peeked := range g.peeking.peeked[playerID] {
+		if peeked == cardIndex {
+			return fmt.Errorf("%w: you've seen slot %d already", ErrInvalidCardIndex, cardIndex)
+		}
+	}
+
+	g.peeking.peeked[playerID] = append(g.peeking.peeked[playerID], cardIndex)
+	g.sendToPlayer(playerID, Message{
+		Type: protocol.MsgCardRevealed,
+		Payload: map[string]interface{}{
+			"playerID": playerID,
+			"index":    cardIndex,
+			"card":     player.Cards[cardIndex],
+		},
+	})
+	g.broadcastGameState()
+	return nil
+}
+
+// ConfirmPeek tells the table playerID is done peeking. The first turn starts once the
+// last player is.
+func (g *Game) ConfirmPeek(playerID string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.peeking == nil {
+		return ErrNotPeeking
+	}
+	if _, seated := g.Players[playerID]; !seated || g.isWaiting(playerID) {
+		return ErrNotInGame
+	}
+	g.peeking.done[playerID] = true
+	if g.everyonePeeked() {
+		g.endPeekPhase()
+	}
+	g.broadcastGameState()
+	g.scheduleBots()
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"pablo/protocol"
+)
+
+func startPeekingGame(t *testing.T) (*Game, *recordingBroadcaster) {
+	t.Helper()
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	addTestPlayers(game, 2)
+	game.Config.InitialPeek = true
+	game.StartGame()
+	return game, recorder
+}
+
+func TestPeekPhaseOpensTheRound(t *testing.T) {
+	game, recorder := startPeekingGame(t)
+
+	if phase := game.turnPhase(); phase != protocol.PhasePeeking {
+		t.Fatalf("Expected the peeking phase, got %q", phase)
+	}
+	if err := game.CheckPhase(game.CurrentPlayer, protocol.MsgDrawCard); !errors.Is(err, ErrOutOfPhase) {
+		t.Errorf("Expected drawing to wait for the peek, got %v", err)
+	}
+	if err := game.CheckPhase("player2", protocol.MsgCallPablo); !errors.Is(err, ErrOutOfPhase) {
+		t.Errorf("Expected Pablo to wait for the peek, got %v", err)
+	}
+	if err := game.CheckPhase("player1", protocol.MsgPeekCard); err != nil {
+		t.Errorf("Expected peeking to be allowed, got %v", err)
+	}
+
+	// Two looks each, at different cards, and they're shown only to their owner
+	if err := game.PeekCard("player1", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := game.PeekCard("player1", 0); !errors.Is(err, ErrInvalidCardIndex) {
+		t.Errorf("Expected a second look at the same slot to be refused, got %v", err)
+	}
+	if err := game.PeekCard("player1", 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := game.PeekCard("player1", 1); !errors.Is(err, ErrPeekLimit) {
+		t.Errorf("Expected a third look to be refused, got %v", err)
+	}
+	recorder.mu.Lock()
+	shown, leaked := countOfType(recorder.players["player1"], protocol.MsgCardRevealed), countOfType(recorder.players["player2"], protocol.MsgCardRevealed)
+	recorder.mu.Unlock()
+	if shown != 2 || leaked != 0 {
+		t.Errorf("Expected 2 cards shown to player1 and none to player2, got %d and %d", shown, leaked)
+	}
+
+	game.mu.Lock()
+	allowed := game.allowedActions("player1")
+	game.mu.Unlock()
+	if len(allowed) != 1 || allowed[0] != protocol.MsgConfirmPeek {
+		t.Errorf("Expected only confirmPeek after two looks, got %v", allowed)
+	}
+}
+
+func TestPeekPhaseEndsWhenEveryoneConfirms(t *testing.T) {
+	game, _ := startPeekingGame(t)
+
+	if err := game.ConfirmPeek("player1"); err != nil {
+		t.Fatal(err)
+	}
+	if game.peeking == nil {
+		t.Fatal("Expected the table to wait for player2")
+	}
+	if err := game.ConfirmPeek("player2"); err != nil {
+		t.Fatal(err)
+	}
+	if phase := game.turnPhase(); phase != protocol.PhaseAwaitingDraw {
+		t.Errorf("Expected the first turn to start, got %q", phase)
+	}
+	if err := game.PeekCard("player1", 2); !errors.Is(err, ErrNotPeeking) {
+		t.Errorf("Expected peeking to be over, got %v", err)
+	}
+	if data, err := game.getGameStateForPlayer("player1").MarshalJSON(); err != nil || strings.Contains(string(data), `"peek":`) {
+		t.Errorf("Expected no peek deadline once play starts, got %s", data)
+	}
+}
+
+func TestPeekPhaseTimesOut(t *testing.T) {
+	game, recorder := startPeekingGame(t)
+
+	game.mu.Lock()
+	phase := game.peeking
+	phase.deadline = time.Now()
+	game.mu.Unlock()
+	go game.runPeekTimer(phase, 0)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		game.mu.Lock()
+		peeking := game.peeking != nil
+		game.mu.Unlock()
+		if !peeking {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the peeking phase to end when its time ran out")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if countOfType(recorder.players[game.CurrentPlayer], protocol.MsgGameState) == 0 {
+		t.Error("Expected the table to be told the first turn started")
+	}
+}
+
+func TestPeekTimerStopsWhilePaused(t *testing.T) {
+	saved := peekTimeLimit
+	peekTimeLimit = 100 * time.Millisecond
+	defer func() { peekTimeLimit = saved }()
+	game, _ := startPeekingGame(t)
+
+	game.RequestPause(game.HostID)
+	time.Sleep(300 * time.Millisecond)
+	game.RequestResume(game.HostID)
+	game.mu.Lock()
+	peeking := game.peeking != nil
+	game.mu.Unlock()
+	if !peeking {
+		t.Fatal("The peeking phase should not run out while the game is paused")
+	}
+	if err := game.PeekCard("player1", 0); err != nil {
+		t.Errorf("Expected players to keep peeking after the pause, got %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		game.mu.Lock()
+		peeking = game.peeking != nil
+		game.mu.Unlock()
+		if !peeking {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the peeking phase to end once its time ran out after the pause")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+
+	"pablo/protocol"
+)
+
+// A turn is played as a short sequence of phases: awaiting a draw, holding the drawn
+// card, awaiting a discarded card's power, and done. The phase isn't stored; it follows
+// from the turn's state, so it can never disagree with what the actions themselves check.
+
+// turnPhaseActions lists the messages that belong to the current player's turn, by the
+// phase each may be sent in. Anything else (stacking, giving a card, calling Pablo) can
+// be sent out of turn and isn't gated by phase.
+var turnPhaseActions = map[string]string{
+	protocol.MsgDrawCard:                  protocol.PhaseAwaitingDraw,
+	protocol.MsgDrawFromDiscard:           protocol.PhaseAwaitingDraw,
+	protocol.MsgRevealClaim:               protocol.PhaseAwaitingDraw,
+	protocol.MsgSwapCard:                  protocol.PhaseHoldingCard,
+	protocol.MsgSwapMultipleCards:         protocol.PhaseHoldingCard,
+	protocol.MsgDiscardDrawnCard:          protocol.PhaseHoldingCard,
+	protocol.MsgUseSpecialCardFromDiscard: protocol.PhaseAwaitingPower,
+	protocol.MsgSkipSpecialCard:           protocol.PhaseAwaitingPower,
+	protocol.MsgConfirmKingSwap:           protocol.PhaseAwaitingPower,
+	protocol.MsgDeclineKingSwap:           protocol.PhaseAwaitingPower,
+	protocol.MsgEndTurn:                   protocol.PhaseDone,
+}
+
+// turnPhase returns the phase of the current player's turn, or "" when no round is in
+// play. Caller must hold g.mu.
+func (g *Game) turnPhase() string {
+	if g.Status != protocol.StatusPlaying || g.CurrentPlayer == "" {
+		return ""
+	}
+	switch {
+	case g.peeking != nil:
+		return protocol.PhasePeeking
+	case g.DrawnCards[g.CurrentPlayer] != nil:
+		return protocol.PhaseHoldingCard
+	case g.PendingSpecialCard != "" || g.PendingKingSwap != nil:
+		return protocol.PhaseAwaitingPower
+	case !g.HasDrawnThisTurn[g.CurrentPlayer]:
+		return protocol.PhaseAwaitingDraw
+	}
+	return protocol.PhaseDone
+}
+
+// allowedActions lists the messages playerID may send right now, their turn's moves and
+// any they can make out of turn. Caller must hold g.mu.
+func (g *Game) allowedActions(playerID string) []string {
+	actions := []string{}
+	if g.Status != protocol.StatusPlaying || g.isWaiting(playerID) {
+		return actions
+	}
+	if _, seated := g.Players[playerID]; !seated {
+		return actions
+	}
+
+	// Before the first turn there's only peeking
+	if g.peeking != nil {
+		if !g.peeking.done[playerID] {
+			if len(g.peeking.peeked[playerID]) < maxInitialPeeks {
+				actions = append(actions, protocol.MsgPeekCard)
+			}
+			actions = append(actions, protocol.MsgConfirmPeek)
+		}
+		return actions
+	}
+
+	// A card owed after stacking an opponent's card holds up everything else
+	if g.PendingGive != nil {
+		if g.PendingGive.ActorID == playerID {
+			actions = append(actions, protocol.MsgGiveCardToPlayer)
+		}
+		return actions
+	}
+
+	if playerID == g.CurrentPlayer {
+		switch g.turnPhase() {
+		case protocol.PhaseAwaitingDraw:
+			actions = append(actions, protocol.MsgDrawCard)
+			if g.Config.AllowDrawFromDiscard && len(g.DiscardPile) > 0 {
+				actions = append(actions, protocol.MsgDrawFromDiscard)
+			}
+			if g.Config.RevealClaim {
+				actions = append(actions, protocol.MsgRevealClaim)
+			}
+		case protocol.PhaseHoldingCard:
+			actions = append(actions, protocol.MsgSwapCard)
+			if g.Config.AllowMultiDiscard {
+				actions = append(actions, protocol.MsgSwapMultipleCards)
+			}
+			if !g.DrawnFromDiscard[playerID] {
+				actions = append(actions, protocol.MsgDiscardDrawnCard)
+			}
+		case protocol.PhaseAwaitingPower:
+			if pks := g.PendingKingSwap; pks != nil && pks.ActorID == playerID {
+				actions = append(actions, protocol.MsgConfirmKingSwap, protocol.MsgDeclineKingSwap)
+			} else {
+				actions = append(actions, protocol.MsgUseSpecialCardFromDiscard)
+			}
+			actions = append(actions, protocol.MsgSkipSpecialCard)
+		case protocol.PhaseDone:
+			actions = append(actions, protocol.MsgEndTurn)
+		}
+	}
+
+	if !g.PabloCalled {
+		actions = append(actions, protocol.MsgCallPablo)
+	}
+	if g.canStack(playerID) {
+		actions = append(actions, protocol.MsgStackCard)
+		if !g.Config.NoOpponentStacking {
+			actions = append(actions, protocol.MsgStackOpponentCard)
+		}
+	}
+	return actions
+}
+
+// canStack reports whether playerID could try a stack on the top discard now.
+// Caller must hold g.mu.
+func (g *Game) canStack(playerID string) bool {
+	if len(g.DiscardPile) == 0 || g.StackableCardIndex != len(g.DiscardPile)-1 {
+		return false
+	}
+	return g.stackCooldown(playerID).IsZero() && !g.sittingOutStack(playerID)
+}
+
+// CheckPhase rejects a turn message sent when the turn isn't in the phase it belongs to.
+// Every turn message is checked here before it reaches its action, so an out-of-phase
+// move gets the same answer whichever one it is. Within the right phase the action still
+// makes its own checks (house rules, an empty discard pile); messages that aren't tied to
+// a turn pass.
+func (g *Game) CheckPhase(playerID, action string) error {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	// While the table peeks, nothing else is played, in turn or out of it
+	if g.peeking != nil && !peekActions[action] {
+		return fmt.Errorf("%w: %s waits until the table is done peeking", ErrOutOfPhase, action)
+	}
+	phase, gated := turnPhaseActions[action]
+	if !gated {
+		return nil
+	}
+
+	if g.Status != protocol.StatusPlaying {
+		return ErrNotPlaying
+	}
+	if g.CurrentPlayer != playerID {
+		return ErrNotYourTurn
+	}
+	if g.PendingGive != nil {
+		return ErrPendingGive
+	}
+	if current := g.turnPhase(); current != phase {
+		return fmt.Errorf("%w: %s is for %s, the turn is at %s", ErrOutOfPhase, action, phase, current)
+	}
+	return nil
+}
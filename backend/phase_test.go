@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestTurnPhases(t *testing.T) {
+	game := createTestGame("test-game")
+	addTestPlayers(game, 2)
+	game.StartGame()
+	current := game.CurrentPlayer
+
+	if phase := game.turnPhase(); phase != "awaitingDraw" {
+		t.Fatalf("A turn should start awaiting a draw, got %q", phase)
+	}
+	game.Deck[0] = Card{Suit: "hearts", Rank: "7"}
+	game.DrawCard(current)
+	if phase := game.turnPhase(); phase != "holdingCard" {
+		t.Fatalf("Expected holdingCard after drawing, got %q", phase)
+	}
+	game.DiscardDrawnCard(current)
+	if phase := game.turnPhase(); phase != "awaitingPower" {
+		t.Fatalf("Expected awaitingPower after discarding a 7, got %q", phase)
+	}
+	game.SkipSpecialCard(current)
+	if phase := game.turnPhase(); phase != "done" {
+		t.Fatalf("Expected done after skipping the power, got %q", phase)
+	}
+	game.EndTurn(current)
+	if phase := game.turnPhase(); phase != "awaitingDraw" || game.CurrentPlayer == current {
+		t.Errorf("The next turn should start awaiting a draw, got %q", phase)
+	}
+}
+
+func TestCheckPhase(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+	current := game.CurrentPlayer
+	other := playerIDs[0]
+	if other == current {
+		other = playerIDs[1]
+	}
+
+	for _, action := range []string{"discardDrawnCard", "swapCard", "skipSpecialCard", "endTurn"} {
+		if err := game.CheckPhase(current, action); !errors.Is(err, ErrOutOfPhase) || errorCode(err) != "OUT_OF_PHASE" {
+			t.Errorf("%s before drawing should be out of phase, got %v", action, err)
+		}
+	}
+	if err := game.CheckPhase(current, "drawCard"); err != nil {
+		t.Errorf("Drawing should be in phase, got %v", err)
+	}
+	if err := game.CheckPhase(other, "drawCard"); !errors.Is(err, ErrNotYourTurn) {
+		t.Errorf("Expected ErrNotYourTurn for the other player, got %v", err)
+	}
+	if err := game.CheckPhase(other, "stackCard"); err != nil {
+		t.Errorf("Out-of-turn messages shouldn't be gated, got %v", err)
+	}
+
+	game.Deck[0] = Card{Suit: "clubs", Rank: "2"}
+	game.DrawCard(current)
+	if err := game.CheckPhase(current, "drawCard"); !errors.Is(err, ErrOutOfPhase) {
+		t.Errorf("Drawing twice should be out of phase, got %v", err)
+	}
+	if err := game.CheckPhase(current, "swapCard"); err != nil {
+		t.Errorf("Swapping the drawn card should be in phase, got %v", err)
+	}
+}
+
+func TestAllowedActionsInState(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+	current := game.CurrentPlayer
+	other := playerIDs[0]
+	if other == current {
+		other = playerIDs[1]
+	}
+
+	state := game.getGameStateForPlayer(current)
+	if state.TurnPhase != "awaitingDraw" {
+		t.Errorf("Expected the state to carry the turn phase, got %q", state.TurnPhase)
+	}
+	if !slices.Contains(state.AllowedActions, "drawCard") || slices.Contains(state.AllowedActions, "endTurn") {
+		t.Errorf("Unexpected actions before drawing: %v", state.AllowedActions)
+	}
+	if actions := game.getGameStateForPlayer(other).AllowedActions; slices.Contains(actions, "drawCard") {
+		t.Errorf("Only the current player may draw, got %v", actions)
+	}
+	if actions := game.getGameStateForSpectator().AllowedActions; actions != nil {
+		t.Errorf("Spectators have no actions, got %v", actions)
+	}
+
+	game.Deck[0] = Card{Suit: "clubs", Rank: "2"}
+	game.DrawCard(current)
+	actions := game.getGameStateForPlayer(current).AllowedActions
+	if !slices.Contains(actions, "swapCard") || !slices.Contains(actions, "discardDrawnCard") || slices.Contains(actions, "drawCard") {
+		t.Errorf("Unexpected actions while holding a card: %v", actions)
+	}
+
+	game.DiscardDrawnCard(current)
+	actions = game.getGameStateForPlayer(other).AllowedActions
+	if !slices.Contains(actions, "stackCard") {
+		t.Errorf("A fresh discard should be open to stacking, got %v", actions)
+	}
+}
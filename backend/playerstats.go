@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"pablo/protocol"
+)
+
+// Players build up stats from table to table: rounds played and won, their average score,
+// successful stacks and how their Pablo calls went. Players have no accounts, so stats are
+// kept under the friend code a client announces with its presence message, which comes
+// from a secret key the client keeps and can copy to another device; players who haven't
+// announced one aren't tracked. Games go on round after round until the players leave, so
+// the round is what counts as a win. Rounds at tables with bots, puzzles and tutorials
+// don't count, so the leaderboard ranks play against people.
+
+// leaderboardSize is how many players the leaderboard lists
+const leaderboardSize = 20
+
+// playerStats is one player's record over every round they've finished
+type playerStats struct {
+	Name       string    `json:"name"` // The name they last played under
+	Rounds     int       `json:"rounds"`
+	RoundsWon  int       `json:"roundsWon"`
+	TotalScore int       `json:"totalScore"`
+	Stacks     int       `json:"stacks"`    // Successful stacks
+	PabloWon   int       `json:"pabloWon"`  // Pablo calls that won the round
+	PabloLost  int       `json:"pabloLost"` // Pablo calls that didn't
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// playerStatsView is a player's stats as served, with their average worked out
+type playerStatsView struct {
+	ID string `json:"id"` // Their friend code
+	playerStats
+	AverageScore float64 `json:"averageScore"`
+}
+
+func (s *playerStats) view(code string) playerStatsView {
+	view := playerStatsView{ID: code, playerStats: *s}
+	if s.Rounds > 0 {
+		view.AverageScore = float64(s.TotalScore) / float64(s.Rounds)
+	}
+	return view
+}
+
+// roundResult is how one player did in one round, for their stats
+type roundResult struct {
+	Code   string
+	Name   string
+	Score  int
+	Won    bool
+	Stacks int
+	Pablo  string // "won" or "lost" if they called Pablo this round
+}
+
+// playerStatsStore holds every player's stats in memory, saving them to a JSON file (when
+// one is configured) every time they change
+type playerStatsStore struct {
+	path  string
+	stats map[string]*playerStats // Friend code -> stats
+	mu    sync.RWMutex
+}
+
+// playerStatsBoard is the server's stats store; in memory only unless main loads it from a file
+var playerStatsBoard = newPlayerStatsStore("")
+
+func newPlayerStatsStore(path string) *playerStatsStore {
+	return &playerStatsStore{path: path, stats: make(map[string]*playerStats)}
+}
+
+// loadPlayerStatsStore reads the stats saved at path. A missing file is an empty store.
+func loadPlayerStatsStore(path string) (*playerStatsStore, error) {
+	store := newPlayerStatsStore(path)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store.stats); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Record adds a finished round to the stats of everyone who played it
+func (s *playerStatsStore) Record(results []roundResult, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, result := range results {
+		stats := s.stats[result.Code]
+		if stats == nil {
+			stats = &playerStats{}
+			s.stats[result.Code] = stats
+		}
+		stats.Name = result.Name
+		stats.Rounds++
+		stats.TotalScore += result.Score
+		stats.Stacks += result.Stacks
+		if result.Won {
+			stats.RoundsWon++
+		}
+		switch result.Pablo {
+		case "won":
+			stats.PabloWon++
+		case "lost":
+			stats.PabloLost++
+		}
+		stats.UpdatedAt = now.UTC()
+	}
+	return s.save()
+}
+
+// Get returns the stats kept under code
+func (s *playerStatsStore) Get(code string) (playerStatsView, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stats, exists := s.stats[code]
+	if !exists {
+		return playerStatsView{}, false
+	}
+	return stats.view(code), true
+}
+
+// Leaderboard returns the players who have won the most rounds, best first. A lower
+// average score breaks ties, then the fewest rounds played.
+func (s *playerStatsStore) Leaderboard() []playerStatsView {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	board := make([]playerStatsView, 0, len(s.stats))
+	for code, stats := range s.stats {
+		board = append(board, stats.view(code))
+	}
+	sort.Slice(board, func(i, j int) bool {
+		a, b := board[i], board[j]
+		if a.RoundsWon != b.RoundsWon {
+			return a.RoundsWon > b.RoundsWon
+		}
+		if a.AverageScore != b.AverageScore {
+			return a.AverageScore < b.AverageScore
+		}
+		if a.Rounds != b.Rounds {
+			return a.Rounds < b.Rounds
+		}
+		return a.ID < b.ID
+	})
+	if len(board) > leaderboardSize {
+		board = board[:leaderboardSize]
+	}
+	return board
+}
+
+// Forget deletes the stats kept under code
+func (s *playerStatsStore) Forget(code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.stats[code]; !exists {
+		return nil
+	}
+	delete(s.stats, code)
+	return s.save()
+}
+
+// save writes the stats to disk. Caller must hold s.mu.
+func (s *playerStatsStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	return writeJSONFile(s.path, s.stats)
+}
+
+// statsIdentity is the friend code playerID's stats are kept under: the one their
+// connection announced, or failing that the one it last announced at this table.
+// Caller must hold g.mu.
+func (g *Game) statsIdentity(playerID string) string {
+	if player, exists := g.Players[playerID]; exists {
+		if code := presence.CodeOf(player.Conn); code != "" {
+			return code
+		}
+	}
+	return g.match.Identities[playerID]
+}
+
+// recordPlayerStats adds the round just scored to the stats of the players in it. The
+// store writes to disk, so it is told on its own goroutine. Caller must hold g.mu.
+func (g *Game) recordPlayerStats(pabloCaller string) {
+	stacks := g.match.RoundStacks
+	g.match.RoundStacks = nil
+	if len(g.Bots) > 0 || g.Puzzle != nil || g.Tutorial != nil {
+		return
+	}
+
+	won := make(map[string]bool)
+	for _, id := range g.roundWinners() {
+		won[id] = true
+	}
+	var results []roundResult
+	for _, id := range g.SeatOrder {
+		code := g.statsIdentity(id)
+		if code == "" {
+			continue
+		}
+		if g.match.Identities == nil {
+			g.match.Identities = make(map[string]string)
+		}
+		g.match.Identities[id] = code
+		recordSeat(g.Players[id].SessionToken, seatRecord{GameID: g.ID, PlayerID: id, Name: g.Players[id].Name, Code: code})
+		result := roundResult{
+			Code:   code,
+			Name:   g.Players[id].Name,
+			Score:  g.Players[id].Score,
+			Won:    won[id],
+			Stacks: stacks[id],
+		}
+		if id == pabloCaller {
+			result.Pablo = "lost"
+			if won[id] {
+				result.Pablo = "won"
+			}
+		}
+		results = append(results, result)
+	}
+	if len(results) > 0 {
+		go playerStatsBoard.Record(results, time.Now())
+	}
+}
+
+// sendLeaderboard answers getLeaderboard
+func sendLeaderboard(conn *websocket.Conn) {
+	writeJSON(conn, Message{
+		Type:    protocol.MsgLeaderboard,
+		Payload: map[string]interface{}{"players": playerStatsBoard.Leaderboard()},
+	})
+}
+
+// handlePlayers routes /players/{id}/...
+func handlePlayers(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(strings.TrimRight(r.URL.Path, "/"), "/stats") {
+		handlePlayerStats(w, r)
+		return
+	}
+	handlePlayerHistory(w, r)
+}
+
+// handlePlayerStats serves GET /players/{id}/stats, where id is the player's friend code
+func handlePlayerStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.EscapedPath(), "/players/"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "stats" {
+		http.NotFound(w, r)
+		return
+	}
+	code, err := url.PathUnescape(parts[0])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	stats, exists := playerStatsBoard.Get(code)
+	if !exists {
+		http.Error(w, "no stats for this player", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(stats)
+}
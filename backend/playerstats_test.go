@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// withPlayerStats swaps in an empty stats store for the test
+func withPlayerStats(t *testing.T) *playerStatsStore {
+	saved := playerStatsBoard
+	playerStatsBoard = newPlayerStatsStore("")
+	t.Cleanup(func() { playerStatsBoard = saved })
+	return playerStatsBoard
+}
+
+// waitForStats waits for the stats of a round to reach store
+func waitForStats(t *testing.T, store *playerStatsStore, code string, rounds int) playerStatsView {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		if stats, exists := store.Get(code); exists && stats.Rounds >= rounds {
+			return stats
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d rounds recorded for %s", rounds, code)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPlayerStatsRecordedEachRound(t *testing.T) {
+	store := withPlayerStats(t)
+	game := createTestGame("test-game")
+	game.SetBroadcaster(newRecordingBroadcaster())
+	playerIDs := addTestPlayers(game, 3)
+	conn := newTestConn(t)
+	code := presence.Announce(conn, "stats-player-key-one", "Player 1", nil)
+	t.Cleanup(func() { presence.Forget(conn) })
+	game.Players[playerIDs[0]].Conn = conn
+
+	// player1 calls Pablo with the lowest hand and stacks once; player2 has no friend code
+	game.StartGame()
+	game.mu.Lock()
+	setHand(game, playerIDs[0], "A", "2", "3")
+	setHand(game, playerIDs[1], "9", "9", "9")
+	setHand(game, playerIDs[2], "8", "8", "8")
+	game.PabloCaller = playerIDs[0]
+	game.recordStack(playerIDs[0])
+	game.EndRound()
+	game.mu.Unlock()
+
+	stats := waitForStats(t, store, code, 1)
+	if stats.RoundsWon != 1 || stats.TotalScore != 6 || stats.Stacks != 1 || stats.PabloWon != 1 || stats.PabloLost != 0 {
+		t.Errorf("Unexpected stats after the first round: %+v", stats)
+	}
+	if len(store.Leaderboard()) != 1 {
+		t.Error("Expected only the player with a friend code to be tracked")
+	}
+
+	// Gone from the table, they're still known by the code they played under
+	game.mu.Lock()
+	game.Players[playerIDs[0]].Conn = nil
+	setHand(game, playerIDs[0], "K", "Q", "J")
+	game.PabloCaller = playerIDs[0]
+	game.EndRound()
+	game.mu.Unlock()
+
+	stats = waitForStats(t, store, code, 2)
+	if stats.RoundsWon != 1 || stats.Stacks != 1 || stats.PabloLost != 1 {
+		t.Errorf("Unexpected stats after the second round: %+v", stats)
+	}
+	if stats.AverageScore != float64(stats.TotalScore)/2 {
+		t.Errorf("Expected the average over 2 rounds, got %v", stats.AverageScore)
+	}
+}
+
+func TestPlayerStatsSkipBotTables(t *testing.T) {
+	store := withPlayerStats(t)
+	game := createTestGame("test-game")
+	game.SetBroadcaster(newRecordingBroadcaster())
+	playerIDs := addTestPlayers(game, 1)
+	game.AddBot("bot-1", "Bot 1")
+	conn := newTestConn(t)
+	presence.Announce(conn, "stats-player-key-bot", "Player 1", nil)
+	t.Cleanup(func() { presence.Forget(conn) })
+	game.Players[playerIDs[0]].Conn = conn
+
+	game.mu.Lock()
+	game.recordPlayerStats("")
+	game.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	if board := store.Leaderboard(); len(board) != 0 {
+		t.Errorf("Expected rounds against bots not to count, got %+v", board)
+	}
+}
+
+func TestLeaderboardOrder(t *testing.T) {
+	store := newPlayerStatsStore("")
+	now := time.Now()
+	store.Record([]roundResult{
+		{Code: "steady", Name: "Steady", Score: 4, Won: true},
+		{Code: "lucky", Name: "Lucky", Score: 2, Won: true},
+		{Code: "loser", Name: "Loser", Score: 30},
+	}, now)
+	store.Record([]roundResult{
+		{Code: "steady", Name: "Steady", Score: 3, Won: true},
+		{Code: "lucky", Name: "Lucky", Score: 20},
+	}, now)
+
+	board := store.Leaderboard()
+	if len(board) != 3 || board[0].ID != "steady" || board[1].ID != "lucky" || board[2].ID != "loser" {
+		t.Fatalf("Expected most round wins first, got %+v", board)
+	}
+	if board[0].AverageScore != 3.5 {
+		t.Errorf("Expected an average of 3.5, got %v", board[0].AverageScore)
+	}
+}
+
+func TestPlayerStatsEndpoint(t *testing.T) {
+	store := withPlayerStats(t)
+	store.Record([]roundResult{{Code: "abc123", Name: "Ana", Score: 5, Won: true, Pablo: "won"}}, time.Now())
+
+	rec := httptest.NewRecorder()
+	handlePlayers(rec, httptest.NewRequest(http.MethodGet, "/players/abc123/stats", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	var stats map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats["id"] != "abc123" || stats["name"] != "Ana" || stats["roundsWon"] != 1.0 || stats["pabloWon"] != 1.0 || stats["averageScore"] != 5.0 {
+		t.Errorf("Unexpected stats: %v", stats)
+	}
+
+	rec = httptest.NewRecorder()
+	handlePlayers(rec, httptest.NewRequest(http.MethodGet, "/players/nobody/stats", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a player with no stats, got %d", rec.Code)
+	}
+}
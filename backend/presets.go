@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+	"pablo/protocol"
+)
+
+// Players can save the house rules they like as named presets ("family rules",
+// "tournament strict") and create a game from one. There are no accounts, so presets are
+// kept under the client's notes key, the same secret its private notes are kept under,
+// and follow it to other devices the same way.
+
+const (
+	maxPresetNameLength = 40 // Characters in a preset's name
+	maxPresetsPerOwner  = 20
+)
+
+var (
+	errInvalidPreset  = errors.New("invalid preset")
+	errPresetNotFound = errors.New("preset not found")
+)
+
+// gamePreset is a named set of house rules
+type gamePreset struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Config    GameConfig `json:"config"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// presetStore holds every owner's presets in memory, saving them to a JSON file (when one
+// is configured) every time they change
+type presetStore struct {
+	path    string
+	presets map[string]map[string]*gamePreset // Hashed notes key -> preset ID -> preset
+	mu      sync.RWMutex
+}
+
+// presets is the server's preset store; in memory only unless main loads it from a file
+var presets = newPresetStore("")
+
+func newPresetStore(path string) *presetStore {
+	return &presetStore{path: path, presets: make(map[string]map[string]*gamePreset)}
+}
+
+// loadPresetStore reads the presets saved at path. A missing file is an empty store.
+func loadPresetStore(path string) (*presetStore, error) {
+	store := newPresetStore(path)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store.presets); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Save keeps config as key's holder's preset called name, replacing any preset of theirs
+// with the same name, and returns it
+func (s *presetStore) Save(key, name string, config GameConfig) (gamePreset, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxPresetNameLength {
+		return gamePreset{}, errInvalidPreset
+	}
+	owner := notesAuthor(key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	owned := s.presets[owner]
+	if owned == nil {
+		owned = make(map[string]*gamePreset)
+		s.presets[owner] = owned
+	}
+	preset := &gamePreset{ID: newUUID(), Name: name, Config: config, UpdatedAt: time.Now().UTC()}
+	for id, existing := range owned {
+		if strings.EqualFold(existing.Name, name) {
+			preset.ID = id
+		}
+	}
+	if _, exists := owned[preset.ID]; !exists && len(owned) >= maxPresetsPerOwner {
+		return gamePreset{}, errInvalidPreset
+	}
+	owned[preset.ID] = preset
+	return *preset, s.save()
+}
+
+// Get returns key's holder's preset with the given ID
+func (s *presetStore) Get(key, id string) (gamePreset, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	preset := s.presets[notesAuthor(key)][id]
+	if preset == nil {
+		return gamePreset{}, errPresetNotFound
+	}
+	return *preset, nil
+}
+
+// Delete removes key's holder's preset with the given ID
+func (s *presetStore) Delete(key, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	owned := s.presets[notesAuthor(key)]
+	if owned[id] == nil {
+		return errPresetNotFound
+	}
+	delete(owned, id)
+	return s.save()
+}
+
+// List returns key's holder's presets in name order
+func (s *presetStore) List(key string) []gamePreset {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := []gamePreset{}
+	for _, preset := range s.presets[notesAuthor(key)] {
+		list = append(list, *preset)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name)
+	})
+	return list
+}
+
+// save writes the presets to disk. Caller must hold s.mu.
+func (s *presetStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	return writeJSONFile(s.path, s.presets)
+}
+
+// handlePresetMessage handles savePreset, deletePreset and getPresets, none of which need
+// a seat: a client can keep its presets from the join screen. Each answers with the
+// updated list.
+func handlePresetMessage(conn *websocket.Conn, msgType string, payload *PresetPayload) {
+	if payload.NotesKey != "" {
+		setNotesKey(conn, payload.NotesKey)
+	}
+	key := notesKeyOf(conn)
+	if key == "" {
+		sendError(conn, protocol.CodeNoNotesKey+".preset")
+		return
+	}
+	switch msgType {
+	case protocol.MsgSavePreset:
+		config, err := decodeGameConfig(payload.Config)
+		if err != nil {
+			sendError(conn, protocol.CodeInvalidConfig)
+			return
+		}
+		if _, err := presets.Save(key, payload.Name, config); errors.Is(err, errInvalidPreset) {
+			sendError(conn, protocol.CodeInvalidConfig+".preset")
+			return
+		} else if err != nil {
+			log.Printf("Saving preset: %v", err)
+		}
+	case protocol.MsgDeletePreset:
+		if err := presets.Delete(key, payload.PresetID); errors.Is(err, errPresetNotFound) {
+			sendError(conn, protocol.CodePresetNotFound)
+			return
+		} else if err != nil {
+			log.Printf("Deleting preset: %v", err)
+		}
+	}
+	writeJSON(conn, Message{
+		Type:    protocol.MsgPresets,
+		Payload: map[string]interface{}{"presets": presets.List(key)},
+	})
+}
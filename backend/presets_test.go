@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestPresetStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "presets.json")
+	store := newPresetStore(path)
+	key := "store-test-preset-key"
+	family := DefaultGameConfig()
+	family.AllowDrawFromDiscard = true
+	saved, err := store.Save(key, "Family rules", family)
+	if err != nil {
+		t.Fatal(err)
+	}
+	family.KingPeekAndSwap = true
+	if resaved, err := store.Save(key, " family RULES ", family); err != nil || resaved.ID != saved.ID {
+		t.Errorf("Saving under the same name should replace the preset, got %+v, %v", resaved, err)
+	}
+	if _, err := store.Save(key, "", family); err != errInvalidPreset {
+		t.Errorf("Expected a nameless preset refused, got %v", err)
+	}
+
+	loaded, err := loadPresetStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	preset, err := loaded.Get(key, saved.ID)
+	if err != nil || !preset.Config.AllowDrawFromDiscard || !preset.Config.KingPeekAndSwap {
+		t.Errorf("Expected the replaced preset to survive a restart, got %+v, %v", preset, err)
+	}
+	if _, err := loaded.Get("someone-elses-preset-key", saved.ID); err != errPresetNotFound {
+		t.Errorf("Another key shouldn't see the preset, got %v", err)
+	}
+	if err := loaded.Delete(key, saved.ID); err != nil || len(loaded.List(key)) != 0 {
+		t.Errorf("Expected the preset deleted, got %v", err)
+	}
+}
+
+func TestCreateGameFromPreset(t *testing.T) {
+	notesKey := "ws-test-preset-key-0001"
+	conn := dialTestServer(t)
+	sendTestMessage(t, conn, "savePreset", map[string]interface{}{
+		"notesKey": notesKey,
+		"name":     "Tournament strict",
+		"config":   map[string]interface{}{"noOpponentStacking": true, "mercyMargin": 60},
+	})
+	list, _ := readMessageOfType(t, conn, "presets")["presets"].([]interface{})
+	if len(list) != 1 {
+		t.Fatalf("Expected the saved preset listed, got %v", list)
+	}
+	presetID := list[0].(map[string]interface{})["id"].(string)
+
+	host := dialTestServer(t)
+	sendTestMessage(t, host, "createGame", map[string]interface{}{"name": "Host", "notesKey": notesKey, "presetID": presetID})
+	gameID := readMessageOfType(t, host, "session")["gameID"].(string)
+	config := gameManager.GetGame(context.Background(), gameID).Config
+	if !config.NoOpponentStacking || config.MercyMargin != 60 {
+		t.Errorf("Expected the preset's rules at the new table, got %+v", config)
+	}
+
+	stranger := dialTestServer(t)
+	sendTestMessage(t, stranger, "createGame", map[string]interface{}{"name": "Stranger", "notesKey": "ws-test-other-key-0001", "presetID": presetID})
+	if code := readMessageOfType(t, stranger, "error")["code"]; code != "PRESET_NOT_FOUND" {
+		t.Errorf("Expected PRESET_NOT_FOUND for someone else's preset, got %v", code)
+	}
+}
@@ -0,0 +1,511 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"pablo/protocol"
+)
+
+// deletedPlayerName stands in for the name of a player who deleted their data
+const deletedPlayerName = "Deleted player"
+
+var errUnknownSession = errors.New("no seat holds this session token")
+
+// forgetInEvent is how a published event is kept once playerID deleted their data: events
+// they made (joining, moves, chat) are deleted, and the rest refer to them by alias
+func forgetInEvent(event GameEvent, playerID, alias string) (GameEvent, bool) {
+	if event.PlayerID == playerID {
+		return event, false
+	}
+	return anonymizeEvent(event, playerID, alias), true
+}
+
+// anonymizeEvent swaps playerID for alias everywhere in an event (its player, scores,
+// seat order, targets...) and blanks the name the player joined with
+func anonymizeEvent(event GameEvent, playerID, alias string) GameEvent {
+	data, err := json.Marshal(event)
+	if err != nil || !bytes.Contains(data, []byte(`"`+playerID)) {
+		return event
+	}
+	data = bytes.ReplaceAll(data, []byte(`"`+playerID+`"`), []byte(`"`+alias+`"`))
+	// Map keys and values alike are quoted, so that covers every mention
+	var anonymized GameEvent
+	if err := json.Unmarshal(data, &anonymized); err != nil {
+		return event
+	}
+	if anonymized.PlayerID == alias {
+		if _, named := anonymized.Data["name"]; named {
+			anonymized.Data["name"] = deletedPlayerName
+		}
+	}
+	return anonymized
+}
+
+// ForgetPlayer deletes the seat holding sessionToken and everything the game kept about
+// it: the player leaves the table, their chat lines and connection records are dropped,
+// and the game's event log refers to them only by alias. Returns the seat that was
+// forgotten.
+func (g *Game) ForgetPlayer(ctx context.Context, sessionToken, alias string) (seatRecord, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	playerID := ""
+	for id, player := range g.Players {
+		if sessionToken != "" && player.SessionToken == sessionToken {
+			playerID = id
+			break
+		}
+	}
+	if playerID == "" {
+		return seatRecord{}, errUnknownSession
+	}
+	seat := seatRecord{GameID: g.ID, PlayerID: playerID, Name: g.Players[playerID].Name, Code: g.statsIdentity(playerID)}
+
+	g.sendToPlayer(playerID, Message{
+		Type:    protocol.MsgKicked,
+		Payload: map[string]string{"message": g.localizeFor(playerID, protocol.MsgKicked+".privacy")},
+	})
+	g.removePlayer(playerID)
+
+	chat := g.chatLog[:0]
+	for _, line := range g.chatLog {
+		if line.PlayerID != playerID {
+			chat = append(chat, line)
+		}
+	}
+	g.chatLog = chat
+	connections := g.connectionLog[:0]
+	for _, conn := range g.connectionLog {
+		if conn.PlayerID != playerID {
+			connections = append(connections, conn)
+		}
+	}
+	g.connectionLog = connections
+	for i, event := range g.eventLog {
+		g.eventLog[i] = anonymizeEvent(event, playerID, alias)
+	}
+	g.match.anonymize(playerID, alias)
+
+	// An adjourned game on disk still has the seat; save it again without it
+	if g.Adjourned && g.adjournStore != nil {
+		saved, err := g.adjournStore.Load(ctx, g.ID)
+		if err != nil {
+			return seat, err
+		}
+		snap := g.snapshot()
+		snap.Status = saved.Status
+		snap.AdjournedAt = saved.AdjournedAt
+		snap.ExpiresAt = saved.ExpiresAt
+		if err := g.adjournStore.Save(ctx, snap); err != nil {
+			return seat, err
+		}
+	}
+	return seat, nil
+}
+
+// Forget removes a player's daily challenge results
+func (d *dailyLeaderboard) Forget(playerID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for date, entries := range d.entries {
+		kept := entries[:0]
+		for _, entry := range entries {
+			if entry.PlayerID != playerID {
+				kept = append(kept, entry)
+			}
+		}
+		d.entries[date] = kept
+	}
+}
+
+// Anonymize replaces a player with alias in every report they filed or were named in.
+// The reports themselves stay, since moderators may still need to act on them.
+func (s *reportStore) Anonymize(playerID, alias string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := false
+	for _, report := range s.reports {
+		if report.ReporterID != playerID && report.TargetID != playerID {
+			continue
+		}
+		if report.ReporterID == playerID {
+			report.ReporterID = alias
+			report.ReporterName = deletedPlayerName
+		}
+		if report.TargetID == playerID {
+			report.TargetID = alias
+			report.TargetName = deletedPlayerName
+		}
+		for i, event := range report.Events {
+			report.Events[i] = anonymizeEvent(event, playerID, alias)
+		}
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return s.save()
+}
+
+// ForgetSubject deletes every note kept on name, whoever wrote it
+func (s *noteStore) ForgetSubject(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := false
+	for _, authored := range s.notes {
+		if _, exists := authored[noteSubject(name)]; exists {
+			delete(authored, noteSubject(name))
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return s.save()
+}
+
+// ForgetAuthor deletes every note key's holder has written
+func (s *noteStore) ForgetAuthor(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.notes[notesAuthor(key)]; !exists {
+		return nil
+	}
+	delete(s.notes, notesAuthor(key))
+	return s.save()
+}
+
+// ForgetOwner deletes every preset key's holder has saved
+func (s *presetStore) ForgetOwner(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.presets[notesAuthor(key)]; !exists {
+		return nil
+	}
+	delete(s.presets, notesAuthor(key))
+	return s.save()
+}
+
+// Forget rewrites the daily event logs so the events playerID made are gone and the rest
+// refer to them only by alias
+func (p *eventLogPublisher) Forget(playerID, alias string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// The open file is about to be replaced; the next event opens the new one
+	if p.file != nil {
+		p.file.Close()
+		p.file = nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(p.dir, eventLogName("*")))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := forgetInEventLog(path, playerID, alias); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forgetInEventLog rewrites one day's event log in place, leaving it untouched if the
+// player isn't in it
+func forgetInEventLog(path, playerID, alias string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !bytes.Contains(data, []byte(playerID)) {
+		return nil
+	}
+
+	var out bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var event GameEvent
+		if bytes.Contains(line, []byte(playerID)) && json.Unmarshal(line, &event) == nil {
+			kept, keep := forgetInEvent(event, playerID, alias)
+			if !keep {
+				continue
+			}
+			if anonymized, err := json.Marshal(kept); err == nil {
+				line = anonymized
+			}
+		}
+		out.Write(line)
+		out.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, out.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// eventLogStore is the server's event log on disk, when one is configured
+var eventLogStore *eventLogPublisher
+
+// A game can end, be hibernated or expire from the stores while its players' stats,
+// reports and events are still kept. So for each session token it hands out, the server
+// remembers which seat it was for, under a hash of the token, and that is what lets a
+// player delete their data once the game is gone. The record goes with the rest of it.
+
+// seatRecord is what the server remembers of a seat, to find its player's data
+type seatRecord struct {
+	GameID   string `json:"gameID"`
+	PlayerID string `json:"playerID"`
+	Name     string `json:"name"`           // Notes on them are kept under their name
+	Code     string `json:"code,omitempty"` // The friend code their stats are kept under, once they have any
+}
+
+// seatRecordStore holds the seat records in memory, saving them to a JSON file (when one
+// is configured) every time they change
+type seatRecordStore struct {
+	path  string
+	seats map[string]*seatRecord // Hashed session token -> seat
+	mu    sync.RWMutex
+}
+
+// seatRecords is the server's seat record store; in memory only unless main loads it from a file
+var seatRecords = newSeatRecordStore("")
+
+func newSeatRecordStore(path string) *seatRecordStore {
+	return &seatRecordStore{path: path, seats: make(map[string]*seatRecord)}
+}
+
+// loadSeatRecordStore reads the seat records saved at path. A missing file is an empty store.
+func loadSeatRecordStore(path string) (*seatRecordStore, error) {
+	store := newSeatRecordStore(path)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store.seats); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// seatRecordKey is how a seat is filed: a hash of its session token, so the file can't be
+// used to take anyone's seat
+func seatRecordKey(sessionToken string) string {
+	sum := sha256.Sum256([]byte(sessionToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// Record remembers the seat sessionToken is for, keeping the friend code recorded before
+// if seat has none
+func (s *seatRecordStore) Record(sessionToken string, seat seatRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := seatRecordKey(sessionToken)
+	if old := s.seats[key]; old != nil && seat.Code == "" {
+		seat.Code = old.Code
+	}
+	if old := s.seats[key]; old != nil && *old == seat {
+		return nil
+	}
+	s.seats[key] = &seat
+	return s.save()
+}
+
+// Get returns the seat sessionToken is for
+func (s *seatRecordStore) Get(sessionToken string) (seatRecord, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if seat := s.seats[seatRecordKey(sessionToken)]; seat != nil {
+		return *seat, true
+	}
+	return seatRecord{}, false
+}
+
+// Forget deletes the record of the seat sessionToken is for
+func (s *seatRecordStore) Forget(sessionToken string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.seats[seatRecordKey(sessionToken)]; !exists {
+		return nil
+	}
+	delete(s.seats, seatRecordKey(sessionToken))
+	return s.save()
+}
+
+// save writes the seat records to disk. Caller must hold s.mu.
+func (s *seatRecordStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	return writeJSONFile(s.path, s.seats)
+}
+
+// deletePlayerData removes or anonymizes everything the server keeps about the seat
+// holding sessionToken: the game itself, wherever it is saved, their stats, the daily
+// leaderboard, the recent games archive, player reports, the published events, notes other
+// players keep on them and, given the notes key they played with, the notes and presets
+// they kept. gameID may be left out; it has to match the seat's if it isn't. The game
+// doesn't have to be known any more, as long as the seat is.
+func deletePlayerData(ctx context.Context, gameID, sessionToken, notesKey string) (string, error) {
+	seat, recorded := seatRecords.Get(sessionToken)
+	if recorded && gameID != "" && gameID != seat.GameID {
+		return "", errUnknownSession
+	}
+	if gameID == "" {
+		gameID = seat.GameID
+	}
+	alias := "deleted-" + newUUID()
+
+	var errs []error
+	names := []string{seat.Name} // They may have rejoined under another name
+	var game *Game
+	if gameID != "" {
+		game = gameManager.GetGame(ctx, gameID)
+	}
+	if game != nil {
+		forgotten, err := game.ForgetPlayer(ctx, sessionToken, alias)
+		if forgotten.PlayerID != "" {
+			if forgotten.Code == "" {
+				forgotten.Code = seat.Code
+			}
+			seat, recorded = forgotten, true
+			names = append(names, forgotten.Name)
+		}
+		if err != nil && !errors.Is(err, errUnknownSession) {
+			errs = append(errs, err)
+		}
+	}
+	if !recorded {
+		return "", errUnknownSession
+	}
+	playerID := seat.PlayerID
+
+	if seat.Code != "" {
+		if err := playerStatsBoard.Forget(seat.Code); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	dailyBoard.Forget(playerID)
+	recentGames.Anonymize(playerID, alias)
+	if err := reports.Anonymize(playerID, alias); err != nil {
+		errs = append(errs, err)
+	}
+	if eventLogStore != nil {
+		if err := eventLogStore.Forget(playerID, alias); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if gameEvents != nil {
+		err := gameEvents.RewriteEvents(ctx, seat.GameID, func(event GameEvent) (GameEvent, bool) {
+			return forgetInEvent(event, playerID, alias)
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, name := range names {
+		if err := notes.ForgetSubject(name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if notesKey != "" {
+		if err := notes.ForgetAuthor(notesKey); err != nil {
+			errs = append(errs, err)
+		}
+		if err := presets.ForgetOwner(notesKey); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := seatRecords.Forget(sessionToken); err != nil {
+		errs = append(errs, err)
+	}
+	return playerID, errors.Join(errs...)
+}
+
+// allowCrossOrigin lets a browser page read the response if its origin is one
+// PABLO_ALLOWED_ORIGINS lists, as for the WebSocket upgrade, reporting whether it did.
+// Requests that don't come from a page are always allowed. With no list set, every origin
+// is, as for the WebSocket.
+func allowCrossOrigin(w http.ResponseWriter, r *http.Request) bool {
+	w.Header().Add("Vary", "Origin")
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if !originAllowed(r) {
+		return false
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	return true
+}
+
+// handlePrivacyDelete serves POST /privacy/delete with {"gameID", "sessionToken", "notesKey"}.
+// Players have no accounts, so the session token handed out at join is what proves who
+// is asking; it works after the game is over too. gameID and notesKey are optional.
+func handlePrivacyDelete(w http.ResponseWriter, r *http.Request) {
+	if !allowCrossOrigin(w, r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Methods", "POST")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		GameID       string `json:"gameID"`
+		SessionToken string `json:"sessionToken"`
+		NotesKey     string `json:"notesKey"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.SessionToken) == "" {
+		http.Error(w, "sessionToken is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	playerID, err := deletePlayerData(ctx, req.GameID, req.SessionToken, req.NotesKey)
+	if playerID == "" {
+		http.Error(w, "unknown game or session token", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		// The seat is gone already; what failed was scrubbing one of the saved copies
+		log.Println("Deleting player data:", playerID, err)
+		http.Error(w, "your seat was deleted but some saved data could not be updated", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"deleted": playerID})
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestForgetPlayer(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 3)
+	game.StartGame()
+	game.Chat(playerIDs[1], "hi from two")
+	game.Chat(playerIDs[0], "hi from one")
+	game.RecordConnection(playerIDs[1], "203.0.113.9", connConnected)
+
+	if _, err := game.ForgetPlayer(context.Background(), "wrong-token", "alias"); err != errUnknownSession {
+		t.Errorf("Expected errUnknownSession, got %v", err)
+	}
+	forgotten, err := game.ForgetPlayer(context.Background(), game.SessionToken(playerIDs[1]), "deleted-1")
+	if err != nil || forgotten.PlayerID != playerIDs[1] || forgotten.Name != "Player 2" {
+		t.Fatalf("Expected %s to be forgotten, got %q, %v", playerIDs[1], forgotten, err)
+	}
+
+	if _, seated := game.Players[playerIDs[1]]; seated {
+		t.Error("Forgotten player should leave the table")
+	}
+	export := game.AuditExport(auditRedaction{})
+	if len(export.Chat) != 1 || export.Chat[0].PlayerID != playerIDs[0] {
+		t.Errorf("Only the other player's chat should be left, got %+v", export.Chat)
+	}
+	if len(export.Connections) != 0 {
+		t.Errorf("Connection records should be gone, got %+v", export.Connections)
+	}
+	for _, event := range export.Events {
+		if event.PlayerID == playerIDs[1] || event.Data["name"] == "Player 2" {
+			t.Errorf("Event still names the player: %+v", event)
+		}
+	}
+	started := export.Events[3]
+	if seats := started.Data["seatOrder"].([]interface{}); seats[1] != "deleted-1" {
+		t.Errorf("Expected the alias in the seat order, got %v", seats)
+	}
+}
+
+func TestDeletePlayerData(t *testing.T) {
+	savedReports, savedLog := reports, eventLogStore
+	reports = newReportStore("")
+	logDir := t.TempDir()
+	eventLogStore, _ = newEventLogPublisher(logDir)
+	defer func() { reports, eventLogStore = savedReports, savedLog }()
+
+	game := gameManager.CreateGame(context.Background())
+	game.AddPlayer("alice", "Alice", nil)
+	game.AddPlayer("bob", "Bob", nil)
+	report, _ := game.NewReport("alice", "bob", "rude")
+	reports.Add(report)
+	dailyBoard.Record("2026-01-01", dailyEntry{PlayerID: "bob", Name: "Bob", Score: 3})
+	for _, event := range game.AuditExport(auditRedaction{}).Events {
+		eventLogStore.Publish(event)
+	}
+	eventLogStore.Close()
+
+	if _, err := deletePlayerData(context.Background(), game.ID, game.SessionToken("bob"), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if list := reports.List(false); list[0].TargetName != deletedPlayerName || list[0].TargetID == "bob" {
+		t.Errorf("Report should be anonymized, got %+v", list[0])
+	}
+	for _, entry := range dailyBoard.Top("2026-01-01") {
+		if entry.PlayerID == "bob" {
+			t.Error("Daily results should be deleted")
+		}
+	}
+	files, _ := filepath.Glob(filepath.Join(logDir, "*.jsonl"))
+	for _, file := range files {
+		data, _ := os.ReadFile(file)
+		if strings.Contains(string(data), "Bob") || strings.Contains(string(data), `"bob"`) {
+			t.Errorf("Event log still mentions the player: %s", data)
+		}
+		if !strings.Contains(string(data), "Alice") {
+			t.Error("Other players' events should be kept")
+		}
+	}
+}
+
+func TestDeletePlayerDataAfterGameIsGone(t *testing.T) {
+	statsBoard := withPlayerStats(t)
+	savedEvents, savedNotes, savedPresets, savedSeats := gameEvents, notes, presets, seatRecords
+	events := newMemoryGameStore()
+	gameEvents, notes, presets, seatRecords = events, newNoteStore(""), newPresetStore(""), newSeatRecordStore("")
+	defer func() { gameEvents, notes, presets, seatRecords = savedEvents, savedNotes, savedPresets, savedSeats }()
+
+	ctx := context.Background()
+	game := gameManager.CreateGame(ctx)
+	game.AddPlayer("dave", "Dave", nil)
+	game.AddPlayer("erin", "Erin", nil)
+	token := game.SessionToken("dave")
+	seatRecords.Record(token, seatRecord{GameID: game.ID, PlayerID: "dave", Name: "Dave", Code: "dave-code"})
+	statsBoard.Record([]roundResult{{Code: "dave-code", Name: "Dave", Score: 4, Won: true}}, time.Now())
+	events.AppendEvents(ctx, game.ID, []GameEvent{
+		{ID: "1", Type: "playerJoined", GameID: game.ID, PlayerID: "dave", Data: map[string]interface{}{"name": "Dave"}},
+		{ID: "2", Type: "roundEnded", GameID: game.ID, Data: map[string]interface{}{"scores": map[string]interface{}{"dave": 4, "erin": 9}}},
+	})
+	notes.Set("erin-notes-key-0001", "Dave", "always calls Pablo early")
+	notes.Set("dave-notes-key-0001", "Erin", "stacks a lot")
+	presets.Save("dave-notes-key-0001", "Fast", DefaultGameConfig())
+
+	// The game is over and gone from memory and the stores
+	gameManager.mu.Lock()
+	delete(gameManager.games, game.ID)
+	gameManager.mu.Unlock()
+
+	if _, err := deletePlayerData(ctx, "another-game", token, ""); err != errUnknownSession {
+		t.Errorf("Expected the token refused for another game, got %v", err)
+	}
+	playerID, err := deletePlayerData(ctx, "", token, "dave-notes-key-0001")
+	if err != nil || playerID != "dave" {
+		t.Fatalf("Expected dave's data deleted, got %q, %v", playerID, err)
+	}
+
+	if _, exists := statsBoard.Get("dave-code"); exists {
+		t.Error("Stats should be deleted")
+	}
+	if kept := events.events[game.ID]; len(kept) != 1 || kept[0].ID != "2" || strings.Contains(fmt.Sprint(kept[0].Data), "dave") {
+		t.Errorf("Expected only the round's event left, without dave in it, got %+v", kept)
+	}
+	if notes.Get("erin-notes-key-0001", "Dave") != "" {
+		t.Error("Other players' notes on them should be deleted")
+	}
+	if len(notes.List("dave-notes-key-0001")) != 0 || len(presets.List("dave-notes-key-0001")) != 0 {
+		t.Error("Their own notes and presets should be deleted")
+	}
+	if _, recorded := seatRecords.Get(token); recorded {
+		t.Error("The seat record should be deleted")
+	}
+	if _, err := deletePlayerData(ctx, game.ID, token, ""); err != errUnknownSession {
+		t.Errorf("Expected the token unknown once used, got %v", err)
+	}
+}
+
+func TestHandlePrivacyDelete(t *testing.T) {
+	game := gameManager.CreateGame(context.Background())
+	game.AddPlayer("carol", "Carol", nil)
+
+	request := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/privacy/delete", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		handlePrivacyDelete(rec, req)
+		return rec
+	}
+
+	if rec := request(`{"gameID":"` + game.ID + `","sessionToken":"guess"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a wrong token, got %d", rec.Code)
+	}
+	if rec := request(`{"gameID":"` + game.ID + `"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a token, got %d", rec.Code)
+	}
+	rec := request(`{"gameID":"` + game.ID + `","sessionToken":"` + game.SessionToken("carol") + `"}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "carol") {
+		t.Errorf("Expected the seat to be deleted, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestPrivacyDeleteOriginPolicy(t *testing.T) {
+	request := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, "/privacy/delete", nil)
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		handlePrivacyDelete(rec, req)
+		return rec
+	}
+
+	// With no list, any origin is allowed, as for the WebSocket
+	if rec := request("https://elsewhere.example"); rec.Header().Get("Access-Control-Allow-Origin") != "https://elsewhere.example" {
+		t.Errorf("Expected any origin echoed with no list set, got %q", rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	saved := allowedOrigins
+	allowedOrigins = parseAllowedOrigins(" https://pablo.example/, http://localhost:3000")
+	defer func() { allowedOrigins = saved }()
+	if rec := request("https://pablo.example"); rec.Header().Get("Access-Control-Allow-Origin") != "https://pablo.example" {
+		t.Errorf("Expected a listed origin echoed, got %q", rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+	rec := request("https://elsewhere.example")
+	if rec.Code != http.StatusForbidden || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Expected an origin not on the list to be refused, got %d", rec.Code)
+	}
+
+	upgrade := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	upgrade.Header.Set("Origin", "https://elsewhere.example")
+	if upgrader.CheckOrigin(upgrade) {
+		t.Error("Expected the WebSocket upgrade to refuse an origin not on the list")
+	}
+	upgrade.Header.Set("Origin", "http://localhost:3000")
+	if !upgrader.CheckOrigin(upgrade) {
+		t.Error("Expected the WebSocket upgrade to allow a listed origin")
+	}
+}
@@ -0,0 +1,229 @@
+// Package protocol defines the names used on the wire between the Pablo server and its
+// clients: message types, game statuses, special card ranks, error codes and event types.
+package protocol
+
+// Messages sent by clients
+const (
+	MsgCreateGame                = "createGame"
+	MsgJoin                      = "join"
+	MsgObserve                   = "observe"
+	MsgSpectate                  = "spectate"
+	MsgSubmitPrediction          = "submitPrediction"
+	MsgStartDaily                = "startDaily"
+	MsgListPuzzles               = "listPuzzles"
+	MsgStartPuzzle               = "startPuzzle"
+	MsgStartTutorial             = "startTutorial"
+	MsgGetState                  = "getState"
+	MsgSetReady                  = "setReady"
+	MsgRename                    = "rename"
+	MsgStartGame                 = "startGame"
+	MsgDrawCard                  = "drawCard"
+	MsgDrawFromDiscard           = "drawFromDiscard"
+	MsgUpdateConfig              = "updateConfig"
+	MsgDiscardDrawnCard          = "discardDrawnCard"
+	MsgSwapCard                  = "swapCard"
+	MsgSwapMultipleCards         = "swapMultipleCards"
+	MsgUseSpecialCardFromDiscard = "useSpecialCardFromDiscard"
+	MsgSkipSpecialCard           = "skipSpecialCard"
+	MsgConfirmKingSwap           = "confirmKingSwap"
+	MsgDeclineKingSwap           = "declineKingSwap"
+	MsgVoteKick                  = "voteKick"
+	MsgPauseGame                 = "pauseGame"
+	MsgResumeGame                = "resumeGame"
+	MsgAdjournGame               = "adjournGame"
+	MsgCallPablo                 = "callPablo"
+	MsgRevealClaim               = "revealClaim"
+	MsgEndTurn                   = "endTurn"
+	MsgStackCard                 = "stackCard"
+	MsgStackOpponentCard         = "stackOpponentCard"
+	MsgGiveCardToPlayer          = "giveCardToPlayer"
+	MsgReportPlayer              = "reportPlayer"
+	MsgSendChat                  = "sendChat"
+	MsgMutePlayer                = "mutePlayer"
+	MsgTransferHost              = "transferHost"
+	MsgLockTable                 = "lockTable"
+	MsgPing                      = "ping"
+	MsgLeaveGame                 = "leaveGame"
+	MsgSetStreamerMode           = "setStreamerMode"
+	MsgSetHandicap               = "setHandicap"
+	MsgSetNote                   = "setNote"
+	MsgGetNotes                  = "getNotes"
+	MsgPresence                  = "presence"
+	MsgAcceptInvite              = "acceptInvite"
+	MsgDeclineInvite             = "declineInvite"
+	MsgBlockPlayer               = "blockPlayer"
+	MsgSavePreset                = "savePreset"
+	MsgDeletePreset              = "deletePreset"
+	MsgGetPresets                = "getPresets"
+	MsgResync                    = "resync"
+	MsgListGames                 = "listGames"
+	MsgGetLeaderboard            = "getLeaderboard"
+	MsgPeekCard                  = "peekCard"    // Look at one of your own cards while the table is peeking
+	MsgConfirmPeek               = "confirmPeek" // Done peeking; the first turn starts once everyone is
+)
+
+// Messages sent by the server
+const (
+	MsgSession            = "session"
+	MsgGameState          = "gameState"
+	MsgLobbyPlayers       = "lobbyPlayers"
+	MsgActionResult       = "actionResult"
+	MsgError              = "error"
+	MsgCardRevealed       = "cardRevealed"
+	MsgKingPeek           = "kingPeek"
+	MsgSwapEvent          = "swapEvent"
+	MsgStackAttempt       = "stackAttempt"
+	MsgStackError         = "stackError"
+	MsgSwapError          = "swapError"
+	MsgRoundSummary       = "roundSummary"
+	MsgVoteKickProgress   = "voteKickProgress"
+	MsgKicked             = "kicked"
+	MsgPlayerKicked       = "playerKicked"
+	MsgPauseVote          = "pauseVote"
+	MsgGamePaused         = "gamePaused"
+	MsgGameResumed        = "gameResumed"
+	MsgGameAdjourned      = "gameAdjourned"
+	MsgSpectating         = "spectating"
+	MsgPuzzleList         = "puzzleList"
+	MsgPuzzleResult       = "puzzleResult"
+	MsgReportReceived     = "reportReceived"
+	MsgChat               = "chat"
+	MsgPlayerMuted        = "playerMuted"
+	MsgHostChanged        = "hostChanged"
+	MsgTableLocked        = "tableLocked"
+	MsgStackPenalty       = "stackPenalty"
+	MsgPong               = "pong"
+	MsgGameStartingSoon   = "gameStartingSoon"
+	MsgStreamerMode       = "streamerMode"
+	MsgRedirect           = "redirect"
+	MsgClaimRevealed      = "claimRevealed"
+	MsgHandicapChanged    = "handicapChanged"
+	MsgGameOver           = "gameOver"
+	MsgPlayerDrew         = "playerDrew"
+	MsgSessionReplaced    = "sessionReplaced"
+	MsgHandRevealed       = "handRevealed"
+	MsgUpgradeRequired    = "upgradeRequired"
+	MsgPlayerRenamed      = "playerRenamed"
+	MsgTutorialStep       = "tutorialStep"
+	MsgNarration          = "narration" // An event described in a sentence, for screen readers
+	MsgNotes              = "notes"
+	MsgFriendCode         = "friendCode"
+	MsgInvite             = "invite"
+	MsgInviteAnswered     = "inviteAnswered"
+	MsgSeatHeld           = "seatHeld"
+	MsgBlocklist          = "blocklist"
+	MsgPresets            = "presets"
+	MsgSpectatorStats     = "spectatorStats"
+	MsgSeatVacated        = "seatVacated"
+	MsgTurnTimer          = "turnTimer"   // How long the current turn has left, at tables with a turn time limit
+	MsgTurnTimeout        = "turnTimeout" // A player's turn ran out and was ended for them
+	MsgGameList           = "gameList"
+	MsgPlayerDisconnected = "playerDisconnected" // A seated player's connection dropped; their seat is kept for them
+	MsgStackWindowClosed  = "stackWindowClosed"  // The top discard's time to be stacked on ran out
+	MsgLeaderboard        = "leaderboard"
+)
+
+// Game statuses
+const (
+	StatusScheduled = "scheduled" // Created for a later start; the lobby opens at the scheduled time
+	StatusWaiting   = "waiting"
+	StatusPlaying   = "playing"
+	StatusPaused    = "paused"
+	StatusEnded     = "ended"
+)
+
+// Phases of a turn, in the order they're played. Every turn starts awaiting a draw and
+// passes through holding the drawn card; only a discarded special card leads on to a power.
+// At InitialPeek tables the round opens with the peeking phase, before the first turn.
+const (
+	PhasePeeking       = "peeking"       // Everyone looks at up to two of their own cards
+	PhaseAwaitingDraw  = "awaitingDraw"  // Draw from the deck or the discard pile, or call Pablo
+	PhaseHoldingCard   = "holdingCard"   // Swap the drawn card into the hand or discard it
+	PhaseAwaitingPower = "awaitingPower" // Use or skip the power of the card just discarded
+	PhaseDone          = "done"          // Nothing left but to end the turn
+)
+
+// Ranks whose cards activate a power when discarded. Kings only do when the table
+// plays the king peek-and-swap rule, and only black ones.
+const (
+	RankPeekOwn   = "7" // Look at one of your own cards
+	RankPeekOther = "8" // Look at someone else's card
+	RankSwap      = "9" // Swap any two cards on the table
+	RankKing      = "K" // Peek at an opponent's card, then optionally swap it
+)
+
+// Powers a card can have. The standard deck gives them to the ranks above; a custom deck
+// can give them to any rank or card.
+const (
+	PowerPeekOwn   = "peekOwn"
+	PowerPeekOther = "peekOther"
+	PowerSwap      = "swap"
+	PowerKingSwap  = "peekAndSwap"
+)
+
+// Error codes sent in the payload of MsgError
+const (
+	CodeGameFull         = "GAME_FULL"
+	CodeGameNotFound     = "GAME_NOT_FOUND"
+	CodeGamePaused       = "GAME_PAUSED"
+	CodeNotInGame        = "NOT_IN_GAME"
+	CodeNotAuthorized    = "NOT_AUTHORIZED"
+	CodeInvalidConfig    = "INVALID_CONFIG"
+	CodeAdjournFailed    = "ADJOURN_FAILED"
+	CodePredictionClosed = "PREDICTION_CLOSED"
+	CodePuzzleNotFound   = "PUZZLE_NOT_FOUND"
+	CodeBanned           = "BANNED"
+	CodeReportInvalid    = "REPORT_INVALID"
+	CodeChatRejected     = "CHAT_REJECTED"
+	CodeTableLocked      = "TABLE_LOCKED"
+	CodeRateLimited      = "RATE_LIMITED"
+	CodeTooManyGames     = "TOO_MANY_GAMES"
+	CodeCaptchaRequired  = "CAPTCHA_REQUIRED"
+	CodeInvalidSchedule  = "INVALID_SCHEDULE"
+	CodeNoNotesKey       = "NO_NOTES_KEY"
+	CodeInviteInvalid    = "INVITE_INVALID"
+	CodePresetNotFound   = "PRESET_NOT_FOUND"
+	CodeInvalidPayload   = "INVALID_PAYLOAD" // A message's payload couldn't be decoded, or lacked a required field
+)
+
+// Error codes sent in the code field of MsgActionResult when an action breaks a rule
+const (
+	CodeNotPlaying       = "NOT_PLAYING"
+	CodeNotYourTurn      = "NOT_YOUR_TURN"
+	CodeAlreadyDrawn     = "ALREADY_DRAWN"
+	CodeNoDrawnCard      = "NO_DRAWN_CARD"
+	CodeUnplayedCard     = "UNPLAYED_CARD"
+	CodeMustSwap         = "MUST_SWAP"
+	CodePendingPower     = "PENDING_POWER"
+	CodeNoPendingPower   = "NO_PENDING_POWER"
+	CodePendingGive      = "PENDING_GIVE"
+	CodeNoPendingGive    = "NO_PENDING_GIVE"
+	CodeEmptyDeck        = "EMPTY_DECK"
+	CodeEmptyDiscard     = "EMPTY_DISCARD"
+	CodeNotStackable     = "NOT_STACKABLE"
+	CodeCardMismatch     = "CARD_MISMATCH"
+	CodeRuleDisabled     = "RULE_DISABLED"
+	CodePabloCalled      = "PABLO_CALLED"
+	CodeStackCooldown    = "STACK_COOLDOWN"
+	CodeStackSitOut      = "STACK_SIT_OUT"
+	CodeOutOfPhase       = "OUT_OF_PHASE"
+	CodeInvalidCardIndex = "INVALID_CARD_INDEX"
+	CodeTargetNotFound   = "TARGET_NOT_FOUND"
+	CodeTutorialStep     = "TUTORIAL_STEP"
+	CodeInvalidMove      = "INVALID_MOVE"
+	CodeNotPeeking       = "NOT_PEEKING"
+	CodePeekLimit        = "PEEK_LIMIT"
+)
+
+// Types of published game events
+const (
+	EventPlayerJoined = "playerJoined"
+	EventPlayerLeft   = "playerLeft"
+	EventGameStarted  = "gameStarted"
+	EventAction       = "action"
+	EventRoundEnded   = "roundEnded"
+	EventTurnStarted  = "turnStarted"
+	EventStartingSoon = "startingSoon"
+	EventLobbyOpened  = "lobbyOpened"
+	EventGameOver     = "gameOver"
+)
@@ -0,0 +1,30 @@
+package main
+
+// publicView is the part of a game anyone may see: the spectator view of the state and
+// the lobby list. Once published it is never modified, so readers can use it without
+// g.mu while the game carries on.
+type publicView struct {
+	state *GameState
+	lobby []LobbyPlayer
+}
+
+// publish rebuilds the public view and swaps it in. Every change clients can see ends in
+// a state or lobby broadcast, and both call this, so the view is never behind what was
+// last sent. It is also when the game is marked for its next checkpoint. Caller must hold g.mu, or be building a game nobody else can reach yet.
+func (g *Game) publish() *publicView {
+	view := &publicView{
+		state: g.getGameStateForSpectator(),
+		lobby: g.lobbyPlayers(),
+	}
+	g.published.Store(view)
+	g.streams.record(view.state)
+	if g.checkpoints != nil {
+		g.checkpoints.mark(g)
+	}
+	return view
+}
+
+// public returns the last published view without taking g.mu
+func (g *Game) public() *publicView {
+	return g.published.Load()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPublicViewFollowsTheGame(t *testing.T) {
+	game := createTestGame("test-game")
+	game.SetBroadcaster(newRecordingBroadcaster())
+	if len(game.LobbyPlayers()) != 0 || len(game.PublicState().Players) != 0 {
+		t.Fatal("A new game should publish an empty table")
+	}
+
+	addTestPlayers(game, 2)
+	before := game.PublicState()
+	game.StartGame()
+	if len(game.LobbyPlayers()) != 2 {
+		t.Errorf("Expected 2 players in the published lobby, got %d", len(game.LobbyPlayers()))
+	}
+	if status := game.PublicState().Status; status != "playing" {
+		t.Errorf("Expected the published state to show the game playing, got %v", status)
+	}
+	if before.Status != "waiting" {
+		t.Error("An earlier view should be left as it was")
+	}
+}
+
+func TestPublicViewDoesNotWaitOnTheGame(t *testing.T) {
+	game := createTestGame("test-game")
+	game.SetBroadcaster(newRecordingBroadcaster())
+	addTestPlayers(game, 2)
+
+	game.mu.Lock()
+	defer game.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		game.PublicState()
+		game.LobbyPlayers()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Reading the public view blocked on g.mu")
+	}
+}
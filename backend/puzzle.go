@@ -0,0 +1,283 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"pablo/protocol"
+)
+
+//go:embed puzzles/*.json
+var builtinPuzzles embed.FS
+
+// Puzzle is a preset board position with a goal: finish the round as the sole lowest
+// score within TurnLimit of your own turns. The first non-bot seat is the solver.
+type Puzzle struct {
+	ID          string         `json:"id"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	TurnLimit   int            `json:"turnLimit"`
+	Players     []PuzzlePlayer `json:"players"`
+	Deck        []Card         `json:"deck"` // Top of the deck first
+	DiscardPile []Card         `json:"discardPile"`
+}
+
+type PuzzlePlayer struct {
+	Name  string `json:"name"`
+	Bot   bool   `json:"bot"`
+	Cards []Card `json:"cards"`
+}
+
+// puzzleState tracks a solver's progress through a puzzle game
+type puzzleState struct {
+	ID         string
+	SolverID   string
+	TurnLimit  int
+	TurnsTaken int
+	OutOfTurns bool
+	Solved     bool
+}
+
+var (
+	validSuits = map[string]bool{"hearts": true, "diamonds": true, "clubs": true, "spades": true}
+	validRanks = map[string]bool{"A": true, "2": true, "3": true, "4": true, "5": true, "6": true, "7": true,
+		"8": true, "9": true, "10": true, "J": true, "Q": true, "K": true}
+)
+
+// Validate checks that a puzzle can be played
+func (p *Puzzle) Validate() error {
+	if p.ID == "" {
+		return errors.New("puzzle needs an id")
+	}
+	if p.TurnLimit < 1 {
+		return errors.New("turnLimit must be at least 1")
+	}
+	if len(p.Players) < 2 || len(p.Players) > 6 {
+		return errors.New("puzzle needs 2-6 players")
+	}
+	solvers := 0
+	for _, player := range p.Players {
+		if !player.Bot {
+			solvers++
+		}
+		if len(player.Cards) == 0 {
+			return fmt.Errorf("player %q has no cards", player.Name)
+		}
+		if err := validateCards(player.Cards); err != nil {
+			return err
+		}
+	}
+	if solvers != 1 {
+		return errors.New("puzzle needs exactly one non-bot player")
+	}
+	if err := validateCards(p.Deck); err != nil {
+		return err
+	}
+	return validateCards(p.DiscardPile)
+}
+
+func validateCards(cards []Card) error {
+	for _, card := range cards {
+		if !validSuits[card.Suit] || !validRanks[card.Rank] {
+			return fmt.Errorf("invalid card %q of %q", card.Rank, card.Suit)
+		}
+	}
+	return nil
+}
+
+// puzzleLibrary holds the puzzles players can start
+type puzzleLibrary struct {
+	puzzles map[string]*Puzzle
+	mu      sync.RWMutex
+}
+
+var puzzles = &puzzleLibrary{puzzles: make(map[string]*Puzzle)}
+
+func (l *puzzleLibrary) Add(p *Puzzle) error {
+	if err := p.Validate(); err != nil {
+		return fmt.Errorf("puzzle %q: %w", p.ID, err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.puzzles[p.ID] = p
+	return nil
+}
+
+func (l *puzzleLibrary) Get(id string) *Puzzle {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.puzzles[id]
+}
+
+// List returns a summary of every puzzle, without giving away the board
+func (l *puzzleLibrary) List() []map[string]interface{} {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	list := make([]map[string]interface{}, 0, len(l.puzzles))
+	for _, p := range l.puzzles {
+		list = append(list, map[string]interface{}{
+			"id":          p.ID,
+			"title":       p.Title,
+			"description": p.Description,
+			"turnLimit":   p.TurnLimit,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i]["id"].(string) < list[j]["id"].(string)
+	})
+	return list
+}
+
+// loadPuzzles adds the built-in puzzles plus any *.json puzzles in dir (if set)
+func (l *puzzleLibrary) loadPuzzles(dir string) error {
+	entries, err := builtinPuzzles.ReadDir("puzzles")
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		data, err := builtinPuzzles.ReadFile("puzzles/" + entry.Name())
+		if err != nil {
+			return err
+		}
+		if err := l.addJSON(data); err != nil {
+			return err
+		}
+	}
+
+	if dir == "" {
+		return nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := l.addJSON(data); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+func (l *puzzleLibrary) addJSON(data []byte) error {
+	var p Puzzle
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	return l.Add(&p)
+}
+
+// CreatePuzzleGame sets up a game in the puzzle's position with the solver to play
+func (gm *GameManager) CreatePuzzleGame(p *Puzzle, solverID, name string, conn *websocket.Conn) *Game {
+	game := NewGame("puzzle-" + p.ID + "-" + newSessionToken()[:8])
+	game.Deck = append([]Card{}, p.Deck...)
+	game.DiscardPile = append([]Card{}, p.DiscardPile...)
+	for i := range game.DiscardPile {
+		game.DiscardPile[i].FaceUp = true
+	}
+
+	botCount := 0
+	for _, pp := range p.Players {
+		id := solverID
+		if pp.Bot {
+			botCount++
+			id = fmt.Sprintf("bot-%d", botCount)
+			game.AddBot(id, pp.Name)
+		} else {
+			game.AddPlayer(id, name, conn)
+		}
+		game.Players[id].Cards = append([]Card{}, pp.Cards...)
+	}
+
+	game.Status = protocol.StatusPlaying
+	game.CurrentPlayer = game.SeatOrder[0]
+	game.Puzzle = &puzzleState{
+		ID:        p.ID,
+		SolverID:  solverID,
+		TurnLimit: p.TurnLimit,
+	}
+
+	gm.mu.Lock()
+	gm.addGame(game)
+	gm.mu.Unlock()
+
+	game.mu.Lock()
+	game.roundStarted()
+	game.broadcastGameState()
+	game.scheduleBots()
+	game.mu.Unlock()
+	return game
+}
+
+// countPuzzleTurn counts a finished turn by the solver. Caller must hold g.mu.
+func (g *Game) countPuzzleTurn(endedBy string) {
+	if g.Puzzle != nil && endedBy == g.Puzzle.SolverID {
+		g.Puzzle.TurnsTaken++
+	}
+}
+
+// puzzleOutOfTurns ends the puzzle round if the solver is about to start a turn beyond
+// the limit. Returns true if it ended the round. Caller must hold g.mu.
+func (g *Game) puzzleOutOfTurns() bool {
+	if g.Puzzle == nil || g.Status != protocol.StatusPlaying {
+		return false
+	}
+	if g.CurrentPlayer == g.Puzzle.SolverID && g.Puzzle.TurnsTaken >= g.Puzzle.TurnLimit {
+		g.Puzzle.OutOfTurns = true
+		g.EndRound()
+		return true
+	}
+	return false
+}
+
+// finishPuzzle verifies the solution when a puzzle round ends: the solver must be the only
+// player with the lowest score, within the turn limit. Caller must hold g.mu.
+func (g *Game) finishPuzzle() {
+	if g.Puzzle == nil {
+		return
+	}
+	winners := g.roundWinners()
+	solverWon := false
+	for _, id := range winners {
+		if id == g.Puzzle.SolverID {
+			solverWon = true
+		}
+	}
+	g.Puzzle.Solved = !g.Puzzle.OutOfTurns &&
+		g.Puzzle.TurnsTaken <= g.Puzzle.TurnLimit &&
+		solverWon && len(winners) == 1
+
+	reason := ""
+	switch {
+	case g.Puzzle.Solved:
+	case g.Puzzle.OutOfTurns:
+		reason = "Out of turns"
+	case solverWon:
+		reason = "Tied for the lowest score"
+	default:
+		reason = "Another player has a lower score"
+	}
+
+	g.broadcast(Message{
+		Type: protocol.MsgPuzzleResult,
+		Payload: map[string]interface{}{
+			"puzzleID":   g.Puzzle.ID,
+			"solved":     g.Puzzle.Solved,
+			"turnsTaken": g.Puzzle.TurnsTaken,
+			"turnLimit":  g.Puzzle.TurnLimit,
+			"reason":     reason,
+		},
+	})
+}
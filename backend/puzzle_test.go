@@ -0,0 +1,114 @@
+package main
+
+import "testing"
+
+// startTestPuzzle loads the built-in puzzles and starts id with bots driven by hand
+func startTestPuzzle(t *testing.T, id string) *Game {
+	t.Helper()
+	lib := &puzzleLibrary{puzzles: make(map[string]*Puzzle)}
+	if err := lib.loadPuzzles(""); err != nil {
+		t.Fatalf("Built-in puzzles should load: %v", err)
+	}
+	p := lib.Get(id)
+	if p == nil {
+		t.Fatalf("Puzzle %s not found", id)
+	}
+	gm := &GameManager{games: make(map[string]*Game)}
+	game := gm.CreatePuzzleGame(p, "solver", "Solver", nil)
+	game.botsRunning = true
+	return game
+}
+
+func TestPuzzleSolved(t *testing.T) {
+	game := startTestPuzzle(t, "one-swap")
+
+	if game.CurrentPlayer != "solver" {
+		t.Fatal("Solver should move first")
+	}
+	game.DrawCard("solver")
+	game.SwapCard("solver", 3)
+	game.CallPablo("solver")
+	game.EndTurn("solver")
+
+	if !game.botTakeTurn("bot-1") {
+		t.Fatal("Bot should take its final turn")
+	}
+	if game.Status != "ended" {
+		t.Fatalf("Round should end after the final turn, got '%s'", game.Status)
+	}
+	if !game.Puzzle.Solved {
+		t.Error("Puzzle should be solved")
+	}
+}
+
+func TestPuzzleWrongMoveFails(t *testing.T) {
+	game := startTestPuzzle(t, "one-swap")
+
+	game.DrawCard("solver")
+	game.DiscardDrawnCard("solver")
+	game.CallPablo("solver")
+	game.EndTurn("solver")
+	game.botTakeTurn("bot-1")
+
+	if game.Status != "ended" {
+		t.Fatalf("Round should end after the final turn, got '%s'", game.Status)
+	}
+	if game.Puzzle.Solved {
+		t.Error("Puzzle should not be solved without the swap")
+	}
+}
+
+func TestPuzzleOutOfTurns(t *testing.T) {
+	game := startTestPuzzle(t, "one-swap")
+
+	game.DrawCard("solver")
+	game.SwapCard("solver", 3)
+	game.EndTurn("solver")
+
+	// Keep the bot from ending the round on its own
+	game.PabloCalled = false
+	game.DrawCard("bot-1")
+	game.DiscardDrawnCard("bot-1")
+	game.EndTurn("bot-1")
+
+	if game.Status != "ended" || !game.Puzzle.OutOfTurns {
+		t.Fatal("Round should end once the solver has used every turn")
+	}
+	if game.Puzzle.Solved {
+		t.Error("Puzzle should not be solved after running out of turns")
+	}
+}
+
+func TestPuzzleValidate(t *testing.T) {
+	valid := func() *Puzzle {
+		return &Puzzle{
+			ID:        "p",
+			TurnLimit: 1,
+			Players: []PuzzlePlayer{
+				{Name: "You", Cards: []Card{{Suit: "hearts", Rank: "A"}}},
+				{Name: "Bot", Bot: true, Cards: []Card{{Suit: "clubs", Rank: "5"}}},
+			},
+		}
+	}
+	if err := valid().Validate(); err != nil {
+		t.Fatalf("Expected valid puzzle, got %v", err)
+	}
+
+	tests := map[string]func(p *Puzzle){
+		"no id":       func(p *Puzzle) { p.ID = "" },
+		"no turns":    func(p *Puzzle) { p.TurnLimit = 0 },
+		"one player":  func(p *Puzzle) { p.Players = p.Players[:1] },
+		"no solver":   func(p *Puzzle) { p.Players[0].Bot = true },
+		"two solvers": func(p *Puzzle) { p.Players[1].Bot = false },
+		"empty hand":  func(p *Puzzle) { p.Players[1].Cards = nil },
+		"bad card":    func(p *Puzzle) { p.Deck = []Card{{Suit: "stars", Rank: "A"}} },
+		"bad discard": func(p *Puzzle) { p.DiscardPile = []Card{{Suit: "hearts", Rank: "1"}} },
+	}
+	for name, breakIt := range tests {
+		p := valid()
+		breakIt(p)
+		if err := p.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
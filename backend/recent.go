@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultRecentGames is how many finished games the archive keeps
+const defaultRecentGames = 50
+
+// recentPlayer is one seat's result in an archived game
+type recentPlayer struct {
+	PlayerID string `json:"playerID"`
+	Name     string `json:"name"`
+	Score    int    `json:"score"` // This round
+	Total    int    `json:"total"` // Every round at the table so far
+	Bot      bool   `json:"bot,omitempty"`
+}
+
+// recentGame is the result of a game's latest finished round
+type recentGame struct {
+	GameID     string         `json:"gameID"`
+	Players    []recentPlayer `json:"players"` // In seat order
+	Winners    []string       `json:"winners"`
+	EndedAt    time.Time      `json:"endedAt"`
+	DurationMs int64          `json:"durationMs,omitempty"`
+}
+
+// recentArchive keeps the latest results of the most recently finished games in memory,
+// newest first, so the lobby can show them without the event logs or a database. A table
+// that plays another round moves back to the front with its new results.
+type recentArchive struct {
+	games []recentGame
+	limit int // 0 keeps nothing
+	mu    sync.Mutex
+}
+
+var recentGames = &recentArchive{limit: defaultRecentGames}
+
+func (a *recentArchive) Record(game recentGame) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.limit <= 0 {
+		return
+	}
+	kept := []recentGame{game}
+	for _, archived := range a.games {
+		if archived.GameID != game.GameID && len(kept) < a.limit {
+			kept = append(kept, archived)
+		}
+	}
+	a.games = kept
+}
+
+// List returns up to n games, newest first
+func (a *recentArchive) List(n int) []recentGame {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if n > len(a.games) {
+		n = len(a.games)
+	}
+	return append([]recentGame{}, a.games[:n]...)
+}
+
+// Anonymize replaces a player with alias in every archived game they played. Their
+// results stay, since they decided who won.
+func (a *recentArchive) Anonymize(playerID, alias string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i := range a.games {
+		players := append([]recentPlayer{}, a.games[i].Players...)
+		for j := range players {
+			if players[j].PlayerID == playerID {
+				players[j].PlayerID, players[j].Name = alias, ""
+			}
+		}
+		winners := append([]string{}, a.games[i].Winners...)
+		for j := range winners {
+			if winners[j] == playerID {
+				winners[j] = alias
+			}
+		}
+		a.games[i].Players, a.games[i].Winners = players, winners
+	}
+}
+
+// archiveRound adds the round that just ended to the recent games archive. Puzzles and
+// the tutorial are played alone against a script, so they aren't results anyone is
+// looking for. Caller must hold g.mu.
+func (g *Game) archiveRound() {
+	if g.Puzzle != nil || g.Tutorial != nil {
+		return
+	}
+	game := recentGame{
+		GameID:  g.ID,
+		Winners: g.roundWinners(),
+		EndedAt: time.Now().UTC(),
+	}
+	if !g.RoundStartedAt.IsZero() {
+		game.DurationMs = time.Since(g.RoundStartedAt).Milliseconds()
+	}
+	for _, id := range g.SeatOrder {
+		player := g.Players[id]
+		_, isBot := g.Bots[id]
+		game.Players = append(game.Players, recentPlayer{
+			PlayerID: id,
+			Name:     g.displayName(id),
+			Score:    player.Score,
+			Total:    player.Total,
+			Bot:      isBot,
+		})
+	}
+	recentGames.Record(game)
+}
+
+// handleRecentGames serves GET /games/recent?limit= with the latest finished games
+func handleRecentGames(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit := defaultRecentGames
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"games": recentGames.List(limit),
+	})
+}
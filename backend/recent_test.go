@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecentArchiveKeepsLatestPerGame(t *testing.T) {
+	archive := &recentArchive{limit: 2}
+	archive.Record(recentGame{GameID: "a"})
+	archive.Record(recentGame{GameID: "b"})
+	archive.Record(recentGame{GameID: "a", Winners: []string{"p1"}})
+
+	games := archive.List(10)
+	if len(games) != 2 || games[0].GameID != "a" || games[1].GameID != "b" {
+		t.Fatalf("Expected a's new round first and b kept, got %+v", games)
+	}
+	if len(games[0].Winners) != 1 {
+		t.Error("A table's entry should be replaced by its latest round")
+	}
+
+	archive.Record(recentGame{GameID: "c"})
+	if games := archive.List(10); len(games) != 2 || games[0].GameID != "c" || games[1].GameID != "a" {
+		t.Errorf("Expected the oldest game dropped past the limit, got %+v", games)
+	}
+	if games := archive.List(1); len(games) != 1 {
+		t.Errorf("Expected List to stop at n, got %d", len(games))
+	}
+}
+
+func TestRecentArchiveAnonymize(t *testing.T) {
+	archive := &recentArchive{limit: 5}
+	archive.Record(recentGame{
+		GameID:  "a",
+		Players: []recentPlayer{{PlayerID: "p1", Name: "Alice"}, {PlayerID: "p2", Name: "Bob"}},
+		Winners: []string{"p1"},
+	})
+	archive.Anonymize("p1", "deleted-1")
+
+	game := archive.List(1)[0]
+	if game.Players[0].PlayerID != "deleted-1" || game.Players[0].Name != "" || game.Winners[0] != "deleted-1" {
+		t.Errorf("Expected the player replaced by the alias, got %+v", game)
+	}
+	if game.Players[1].Name != "Bob" {
+		t.Error("Other players should be untouched")
+	}
+}
+
+func TestRoundEndArchivesGame(t *testing.T) {
+	game := createTestGame("recent-game")
+	game.SetBroadcaster(newRecordingBroadcaster())
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+	setHand(game, playerIDs[0], "A", "A", "A", "A")
+	setHand(game, playerIDs[1], "K", "K", "K", "K")
+	game.mu.Lock()
+	game.EndRound()
+	game.mu.Unlock()
+
+	recorder := httptest.NewRecorder()
+	handleGames(recorder, httptest.NewRequest(http.MethodGet, "/games/recent", nil))
+	var body struct {
+		Games []recentGame `json:"games"`
+	}
+	if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	for _, archived := range body.Games {
+		if archived.GameID != game.ID {
+			continue
+		}
+		if len(archived.Players) != 2 || archived.Players[0].Score != 4 || archived.Players[0].Name != "Player 1" {
+			t.Errorf("Expected both seats with their scores, got %+v", archived.Players)
+		}
+		if len(archived.Winners) != 1 || archived.Winners[0] != playerIDs[0] {
+			t.Errorf("Expected %s to have won, got %v", playerIDs[0], archived.Winners)
+		}
+		return
+	}
+	t.Errorf("Expected %s in the recent games, got %+v", game.ID, body.Games)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redisClient speaks RESP to a Redis server over one connection. It is just enough Redis for
+// the cluster directory and the Redis game store.
+type redisClient struct {
+	addr     string
+	password string
+	db       int
+	conn     net.Conn
+	reader   *bufio.Reader
+	mu       sync.Mutex
+}
+
+// newRedisClient takes a redis://[:password@]host[:port][/db] URL. It connects on first use.
+func newRedisClient(rawURL string) (*redisClient, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" {
+		return nil, fmt.Errorf("unsupported Redis URL scheme %q", u.Scheme)
+	}
+	c := &redisClient{addr: u.Host}
+	if u.Port() == "" {
+		c.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		c.password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if c.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid Redis database %q", db)
+		}
+	}
+	return c, nil
+}
+
+// do runs one command with a simple, integer, error or bulk string reply
+func (c *redisClient) do(args ...string) (string, error) {
+	var reply string
+	err := c.run(args, func(r *bufio.Reader) (err error) {
+		reply, err = readRESP(r)
+		return err
+	})
+	return reply, err
+}
+
+// doList runs one command with an array reply, such as SMEMBERS or LRANGE
+func (c *redisClient) doList(args ...string) ([]string, error) {
+	var reply []string
+	err := c.run(args, func(r *bufio.Reader) (err error) {
+		reply, err = readRESPArray(r)
+		return err
+	})
+	return reply, err
+}
+
+// run sends a command and reads its reply with read, reconnecting once if the connection
+// has gone bad
+func (c *redisClient) run(args []string, read func(*bufio.Reader) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	err := c.roundTrip(args, read)
+	var redisErr redisError
+	if err != nil && !errors.As(err, &redisErr) {
+		if c.conn != nil {
+			c.conn.Close()
+			c.conn = nil
+		}
+		err = c.roundTrip(args, read)
+	}
+	return err
+}
+
+// roundTrip sends a command and reads its reply. Caller must hold c.mu.
+func (c *redisClient) roundTrip(args []string, read func(*bufio.Reader) error) error {
+	if c.conn == nil {
+		if err := c.connect(); err != nil {
+			return err
+		}
+	}
+	c.conn.SetDeadline(time.Now().Add(time.Second))
+	if _, err := c.conn.Write(encodeRESP(args)); err != nil {
+		return err
+	}
+	return read(c.reader)
+}
+
+// connect dials Redis, authenticating and picking the database if the URL asked to.
+// Caller must hold c.mu.
+func (c *redisClient) connect() error {
+	conn, err := net.DialTimeout("tcp", c.addr, time.Second)
+	if err != nil {
+		return err
+	}
+	c.conn, c.reader = conn, bufio.NewReader(conn)
+	var setup [][]string
+	if c.password != "" {
+		setup = append(setup, []string{"AUTH", c.password})
+	}
+	if c.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(c.db)})
+	}
+	for _, args := range setup {
+		if err := c.roundTrip(args, func(r *bufio.Reader) error { _, err := readRESP(r); return err }); err != nil {
+			conn.Close()
+			c.conn = nil
+			return err
+		}
+	}
+	return nil
+}
+
+// redisError is an error reply from the server, as opposed to a broken connection
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+func encodeRESP(args []string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return []byte(b.String())
+}
+
+// readRESP reads a simple, integer, error or bulk string reply. A nil bulk reads as "".
+func readRESP(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", errors.New("redis: empty reply")
+	}
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", redisError(line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", err
+		}
+		if n < 0 {
+			return "", nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return "", err
+		}
+		return string(buf[:n]), nil
+	}
+	return "", fmt.Errorf("redis: unexpected reply %q", line)
+}
+
+// readRESPArray reads an array of strings. A nil array reads as empty.
+func readRESPArray(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if strings.HasPrefix(line, "-") {
+		return nil, redisError(line[1:])
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+	items := make([]string, 0, max(n, 0))
+	for i := 0; i < n; i++ {
+		item, err := readRESP(r)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRedis answers the string, set and list commands the server uses from maps
+func fakeRedis(t *testing.T) string {
+	t.Helper()
+	url, _ := fakeRedisServer(t)
+	return url
+}
+
+// fakeRedisServer is fakeRedis, also returning a func listing the commands it was sent
+func fakeRedisServer(t *testing.T) (string, func() [][]string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	var mu sync.Mutex
+	var commands [][]string
+	data := make(map[string]string)
+	sets := make(map[string]map[string]bool)
+	lists := make(map[string][]string)
+	writeArray := func(conn net.Conn, items []string) {
+		fmt.Fprintf(conn, "*%d\r\n", len(items))
+		for _, item := range items {
+			fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(item), item)
+		}
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+					args := make([]string, n)
+					for i := range args {
+						reader.ReadString('\n') // $len
+						arg, _ := reader.ReadString('\n')
+						args[i] = strings.TrimRight(arg, "\r\n")
+					}
+					mu.Lock()
+					commands = append(commands, args)
+					switch args[0] {
+					case "AUTH", "SELECT":
+						conn.Write([]byte("+OK\r\n"))
+					case "SET":
+						data[args[1]] = args[2]
+						conn.Write([]byte("+OK\r\n"))
+					case "GET":
+						if value, ok := data[args[1]]; ok {
+							fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(value), value)
+						} else {
+							conn.Write([]byte("$-1\r\n"))
+						}
+					case "DEL":
+						delete(data, args[1])
+						conn.Write([]byte(":1\r\n"))
+					case "SADD":
+						if sets[args[1]] == nil {
+							sets[args[1]] = make(map[string]bool)
+						}
+						sets[args[1]][args[2]] = true
+						conn.Write([]byte(":1\r\n"))
+					case "SREM":
+						delete(sets[args[1]], args[2])
+						conn.Write([]byte(":1\r\n"))
+					case "SMEMBERS":
+						var members []string
+						for member := range sets[args[1]] {
+							members = append(members, member)
+						}
+						writeArray(conn, members)
+					case "RPUSH":
+						lists[args[1]] = append(lists[args[1]], args[2:]...)
+						fmt.Fprintf(conn, ":%d\r\n", len(lists[args[1]]))
+					case "LRANGE":
+						writeArray(conn, lists[args[1]])
+					case "LSET":
+						i, _ := strconv.Atoi(args[2])
+						lists[args[1]][i] = args[3]
+						conn.Write([]byte("+OK\r\n"))
+					case "LREM":
+						var kept []string
+						for _, item := range lists[args[1]] {
+							if item != args[3] {
+								kept = append(kept, item)
+							}
+						}
+						fmt.Fprintf(conn, ":%d\r\n", len(lists[args[1]])-len(kept))
+						lists[args[1]] = kept
+					default:
+						conn.Write([]byte("-ERR unknown command\r\n"))
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+	}()
+	sent := func() [][]string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([][]string(nil), commands...)
+	}
+	return "redis://" + listener.Addr().String(), sent
+}
+
+func TestNewRedisClient(t *testing.T) {
+	c, err := newRedisClient("redis://:secret@cache.example/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.addr != "cache.example:6379" || c.password != "secret" || c.db != 2 {
+		t.Errorf("Unexpected client %+v", c)
+	}
+	if c, _ := newRedisClient("redis://cache.example:7000"); c.addr != "cache.example:7000" || c.db != 0 {
+		t.Errorf("Expected the port given and database 0, got %+v", c)
+	}
+	for _, bad := range []string{"http://cache.example", "redis://cache.example/first"} {
+		if _, err := newRedisClient(bad); err == nil {
+			t.Errorf("Expected %s refused", bad)
+		}
+	}
+}
+
+func TestRESPReplies(t *testing.T) {
+	if got := string(encodeRESP([]string{"SET", "k", "two words"})); got != "*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$9\r\ntwo words\r\n" {
+		t.Errorf("Unexpected encoding %q", got)
+	}
+
+	read := func(raw string) (string, error) {
+		return readRESP(bufio.NewReader(strings.NewReader(raw)))
+	}
+	for raw, want := range map[string]string{
+		"+OK\r\n":               "OK",
+		":42\r\n":               "42",
+		"$5\r\nhello\r\n":       "hello",
+		"$7\r\na\r\nb\r\nc\r\n": "a\r\nb\r\nc",
+		"$-1\r\n":               "",
+	} {
+		if got, err := read(raw); err != nil || got != want {
+			t.Errorf("%q: expected %q, got %q, %v", raw, want, got, err)
+		}
+	}
+	var redisErr redisError
+	if _, err := read("-ERR wrong type\r\n"); !errors.As(err, &redisErr) || string(redisErr) != "ERR wrong type" {
+		t.Errorf("Expected the error reply as a redisError, got %v", err)
+	}
+	if _, err := read("?\r\n"); err == nil {
+		t.Error("Expected an unknown reply type refused")
+	}
+
+	readArray := func(raw string) ([]string, error) {
+		return readRESPArray(bufio.NewReader(strings.NewReader(raw)))
+	}
+	if got, err := readArray("*2\r\n$1\r\na\r\n$2\r\nbc\r\n"); err != nil || !reflect.DeepEqual(got, []string{"a", "bc"}) {
+		t.Errorf("Unexpected array %v, %v", got, err)
+	}
+	if got, err := readArray("*-1\r\n"); err != nil || len(got) != 0 {
+		t.Errorf("Expected a nil array empty, got %v, %v", got, err)
+	}
+	if _, err := readArray("-NOPERM\r\n"); !errors.As(err, &redisErr) {
+		t.Errorf("Expected an error reply as a redisError, got %v", err)
+	}
+}
+
+func TestRedisClientAuthenticatesAndSelects(t *testing.T) {
+	url, sent := fakeRedisServer(t)
+	c, err := newRedisClient(strings.Replace(url, "redis://", "redis://:secret@", 1) + "/3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.do("SET", "k", "v"); err != nil {
+		t.Fatal(err)
+	}
+	c.do("GET", "k")
+
+	want := [][]string{{"AUTH", "secret"}, {"SELECT", "3"}, {"SET", "k", "v"}, {"GET", "k"}}
+	if got := sent(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected to log in once, then the commands, got %v", got)
+	}
+}
+
+func TestRedisClientReconnects(t *testing.T) {
+	url, sent := fakeRedisServer(t)
+	c, _ := newRedisClient(url)
+	c.do("SET", "k", "v")
+
+	// The connection breaks between commands; the next one goes out on a new one
+	c.conn.Close()
+	if got, err := c.do("GET", "k"); err != nil || got != "v" {
+		t.Errorf("Expected the command retried on a new connection, got %q, %v", got, err)
+	}
+
+	// An error reply is the server's answer, not a broken connection
+	conn := c.conn
+	if _, err := c.do("NOSUCHCOMMAND"); err == nil {
+		t.Error("Expected the error reply")
+	}
+	if c.conn != conn || len(sent()) != 3 {
+		t.Errorf("Expected an error reply to keep the connection and not be retried, got %d commands", len(sent()))
+	}
+}
+
+func TestRedisClientUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	c, _ := newRedisClient("redis://" + addr)
+	if _, err := c.do("GET", "k"); err == nil {
+		t.Error("Expected an error with nothing listening")
+	}
+}
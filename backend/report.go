@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	reportReasonLimit = 500 // Longest reason a player can give, in bytes
+	reportEventLimit  = 20  // How many of the game's latest events go with a report
+)
+
+// Report statuses
+const (
+	reportOpen     = "open"
+	reportResolved = "resolved"
+)
+
+// PlayerReport is one player's complaint about another, with what had just happened in the game
+type PlayerReport struct {
+	ID           string      `json:"id"`
+	GameID       string      `json:"gameID"`
+	ReporterID   string      `json:"reporterID"`
+	ReporterName string      `json:"reporterName"`
+	TargetID     string      `json:"targetID"`
+	TargetName   string      `json:"targetName"`
+	Reason       string      `json:"reason"`
+	Events       []GameEvent `json:"events"` // The game's latest events when the report was made, oldest first
+	CreatedAt    time.Time   `json:"createdAt"`
+	Status       string      `json:"status"` // "open" or "resolved"
+	Resolution   string      `json:"resolution,omitempty"`
+	ResolvedAt   *time.Time  `json:"resolvedAt,omitempty"`
+}
+
+var (
+	errInvalidReport   = errors.New("invalid report")
+	errDuplicateReport = errors.New("already reported")
+)
+
+// NewReport builds a report against another player at the table. Names are the real
+// names even at anonymous tables, so moderators can act on them.
+func (g *Game) NewReport(reporterID, targetID, reason string) (*PlayerReport, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	reason = strings.TrimSpace(reason)
+	reporter, okR := g.Players[reporterID]
+	target, okT := g.Players[targetID]
+	if !okR || !okT || reporterID == targetID || reason == "" || len(reason) > reportReasonLimit {
+		return nil, errInvalidReport
+	}
+
+	excerpt := g.eventLog
+	if len(excerpt) > reportEventLimit {
+		excerpt = excerpt[len(excerpt)-reportEventLimit:]
+	}
+	return &PlayerReport{
+		ID:           newUUID(),
+		GameID:       g.ID,
+		ReporterID:   reporterID,
+		ReporterName: reporter.Name,
+		TargetID:     targetID,
+		TargetName:   target.Name,
+		Reason:       reason,
+		Events:       append([]GameEvent{}, excerpt...),
+		CreatedAt:    time.Now().UTC(),
+		Status:       reportOpen,
+	}, nil
+}
+
+// reportStore is the moderation queue: every report, kept in memory and saved to a
+// JSON file (when one is configured) on every change
+type reportStore struct {
+	path    string
+	reports map[string]*PlayerReport
+	mu      sync.RWMutex
+}
+
+// reports is the server's report store; in memory only unless main loads it from a file
+var reports = newReportStore("")
+
+func newReportStore(path string) *reportStore {
+	return &reportStore{path: path, reports: make(map[string]*PlayerReport)}
+}
+
+// loadReportStore reads the reports saved at path. A missing file is an empty queue.
+func loadReportStore(path string) (*reportStore, error) {
+	store := newReportStore(path)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var saved []*PlayerReport
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, err
+	}
+	for _, report := range saved {
+		store.reports[report.ID] = report
+	}
+	return store, nil
+}
+
+// Add files a report. A player can only have one open report against the same player per game.
+func (s *reportStore) Add(report *PlayerReport) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.reports {
+		if existing.Status == reportOpen && existing.GameID == report.GameID &&
+			existing.ReporterID == report.ReporterID && existing.TargetID == report.TargetID {
+			return errDuplicateReport
+		}
+	}
+	s.reports[report.ID] = report
+	if err := s.save(); err != nil {
+		delete(s.reports, report.ID)
+		return err
+	}
+	return nil
+}
+
+// Resolve closes a report with the moderator's note. Returns nil if there is no such report.
+func (s *reportStore) Resolve(id, resolution string) (*PlayerReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report, exists := s.reports[id]
+	if !exists {
+		return nil, nil
+	}
+	previous := *report
+	now := time.Now().UTC()
+	report.Status = reportResolved
+	report.Resolution = resolution
+	report.ResolvedAt = &now
+	if err := s.save(); err != nil {
+		*report = previous
+		return nil, err
+	}
+	return report, nil
+}
+
+// List returns the reports in the queue, oldest first. Resolved reports are left out
+// unless all is set.
+func (s *reportStore) List(all bool) []*PlayerReport {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]*PlayerReport, 0, len(s.reports))
+	for _, report := range s.reports {
+		if all || report.Status == reportOpen {
+			list = append(list, report)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list
+}
+
+// save writes the reports to disk. Caller must hold s.mu.
+func (s *reportStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	list := make([]*PlayerReport, 0, len(s.reports))
+	for _, report := range s.reports {
+		list = append(list, report)
+	}
+	return writeJSONFile(s.path, list)
+}
+
+// handleAdminReports serves the moderation queue:
+//
+//	GET  /admin/reports[?all=1]       open reports, oldest first (all=1 includes resolved ones)
+//	POST /admin/reports/{id}/resolve  close a report: {"resolution"}
+func handleAdminReports(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/reports"), "/")
+
+	switch {
+	case r.Method == http.MethodGet && path == "":
+		all := r.URL.Query().Get("all") == "1"
+		writeAdminJSON(w, http.StatusOK, map[string]interface{}{"reports": reports.List(all)})
+
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/resolve"):
+		id := strings.TrimSuffix(path, "/resolve")
+		var req struct {
+			Resolution string `json:"resolution"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		report, err := reports.Resolve(id, req.Resolution)
+		if err != nil {
+			http.Error(w, "could not save report", http.StatusInternalServerError)
+			return
+		}
+		if report == nil {
+			http.Error(w, "report not found", http.StatusNotFound)
+			return
+		}
+		writeAdminJSON(w, http.StatusOK, report)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewReport(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+
+	if _, err := game.NewReport(playerIDs[0], playerIDs[0], "spam"); err != errInvalidReport {
+		t.Error("Players can't report themselves")
+	}
+	if _, err := game.NewReport(playerIDs[0], "stranger", "spam"); err != errInvalidReport {
+		t.Error("Only players at the table can be reported")
+	}
+	if _, err := game.NewReport(playerIDs[0], playerIDs[1], "  "); err != errInvalidReport {
+		t.Error("Reports need a reason")
+	}
+
+	report, err := game.NewReport(playerIDs[0], playerIDs[1], "stack spam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.TargetID != playerIDs[1] || report.Status != reportOpen {
+		t.Errorf("Unexpected report %+v", report)
+	}
+	if len(report.Events) == 0 || report.Events[len(report.Events)-1].Type != "turnStarted" {
+		t.Errorf("Report should end with the game's latest event, got %+v", report.Events)
+	}
+}
+
+func TestReportStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reports.json")
+	store, err := loadReportStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+
+	first, _ := game.NewReport(playerIDs[0], playerIDs[1], "rude")
+	if err := store.Add(first); err != nil {
+		t.Fatal(err)
+	}
+	second, _ := game.NewReport(playerIDs[0], playerIDs[1], "still rude")
+	if err := store.Add(second); err != errDuplicateReport {
+		t.Errorf("Expected errDuplicateReport, got %v", err)
+	}
+
+	reloaded, err := loadReportStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.List(false)) != 1 {
+		t.Fatalf("Expected the report to be saved, got %d", len(reloaded.List(false)))
+	}
+
+	if resolved, err := reloaded.Resolve(first.ID, "warned"); err != nil || resolved == nil {
+		t.Fatalf("Expected report to be resolved, got %v, %v", resolved, err)
+	}
+	if len(reloaded.List(false)) != 0 || len(reloaded.List(true)) != 1 {
+		t.Error("Resolved reports should only be listed with all")
+	}
+	if err := reloaded.Add(second); err != nil {
+		t.Errorf("Reporting again after the first report is resolved should work, got %v", err)
+	}
+}
+
+func TestReportPlayerOverWS(t *testing.T) {
+	saved := reports
+	reports = newReportStore("")
+	defer func() { reports = saved }()
+
+	_, session := createTestGameOverWS(t)
+	guest := dialTestServer(t)
+	sendTestMessage(t, guest, "join", map[string]interface{}{"gameID": session["gameID"], "name": "Guest"})
+	readMessageOfType(t, guest, "session")
+
+	sendTestMessage(t, guest, "reportPlayer", map[string]interface{}{"targetID": session["playerID"], "reason": "rude"})
+	if readMessageOfType(t, guest, "reportReceived")["reportID"] == "" {
+		t.Error("Expected a report ID")
+	}
+	sendTestMessage(t, guest, "reportPlayer", map[string]interface{}{"targetID": session["playerID"], "reason": "rude"})
+	if code := readMessageOfType(t, guest, "error")["code"]; code != "REPORT_INVALID" {
+		t.Errorf("Expected REPORT_INVALID for a duplicate, got %v", code)
+	}
+	if list := reports.List(false); len(list) != 1 || list[0].TargetName != "Host" {
+		t.Errorf("Expected one report against Host, got %+v", list)
+	}
+}
+
+func TestHandleAdminReports(t *testing.T) {
+	saved, savedKey := reports, adminKey
+	reports, adminKey = newReportStore(""), "secret"
+	defer func() { reports, adminKey = saved, savedKey }()
+
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	report, _ := game.NewReport(playerIDs[0], playerIDs[1], "rude")
+	reports.Add(report)
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		handleAdminReports(rec, req)
+		return rec
+	}
+
+	if rec := request(http.MethodGet, "/admin/reports", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), report.ID) {
+		t.Errorf("Expected the open report to be listed, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := request(http.MethodPost, "/admin/reports/"+report.ID+"/resolve", `{"resolution":"warned"}`); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/admin/reports/nope/resolve", `{}`); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", rec.Code)
+	}
+	if rec := request(http.MethodGet, "/admin/reports", ""); strings.Contains(rec.Body.String(), report.ID) {
+		t.Error("Resolved report should leave the queue")
+	}
+}
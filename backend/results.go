@@ -0,0 +1,48 @@
+package main
+
+// ActionResult answers a player's action directly, so their client knows what happened
+// without diffing the next broadcast. It only carries private state the actor already knows.
+type ActionResult struct {
+	Action    string      `json:"action"`
+	Success   bool        `json:"success"`
+	Error     string      `json:"error,omitempty"`
+	Code      string      `json:"code,omitempty"` // Protocol error code when the action was refused
+	DrawnCard *Card       `json:"drawnCard"`       // The actor's drawn card after the action, if any
+	Slots     []SlotState `json:"slots,omitempty"` // Hand slots the action changed that the actor has seen
+	HandSize  int         `json:"handSize"`
+}
+
+type SlotState struct {
+	Index int  `json:"index"`
+	Card  Card `json:"card"` // Empty rank means the slot is now empty
+}
+
+// ActionResult reads the actor's state after an action that returned err. slots lists
+// the hand slots whose new contents the actor is allowed to see.
+func (g *Game) ActionResult(playerID, action string, err error, slots ...int) ActionResult {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	result := ActionResult{Action: action, Success: err == nil}
+	if err != nil {
+		result.Error = err.Error()
+		result.Code = errorCode(err)
+	}
+	player, exists := g.Players[playerID]
+	if !exists {
+		return result
+	}
+	if drawn := g.DrawnCards[playerID]; drawn != nil {
+		card := *drawn
+		result.DrawnCard = &card
+	}
+	result.HandSize = len(player.Cards)
+	if result.Success {
+		for _, idx := range slots {
+			if idx >= 0 && idx < len(player.Cards) {
+				result.Slots = append(result.Slots, SlotState{Index: idx, Card: player.Cards[idx]})
+			}
+		}
+	}
+	return result
+}
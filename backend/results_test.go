@@ -0,0 +1,69 @@
+package main
+
+import "testing"
+
+func TestActionResultAfterDrawAndSwap(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+	player := playerIDs[0]
+	game.Deck[0] = Card{Suit: "hearts", Rank: "4"}
+
+	err := game.DrawCard(player)
+	result := game.ActionResult(player, "drawCard", err)
+	if !result.Success || result.DrawnCard == nil || result.DrawnCard.Rank != "4" {
+		t.Fatalf("Draw result should carry the drawn card, got %+v", result)
+	}
+
+	err = game.SwapCard(player, 2)
+	result = game.ActionResult(player, "swapCard", err, 2)
+	if !result.Success || result.DrawnCard != nil {
+		t.Fatalf("Swap result should succeed with no drawn card left, got %+v", result)
+	}
+	if len(result.Slots) != 1 || result.Slots[0].Index != 2 || result.Slots[0].Card.Rank != "4" || result.Slots[0].Card.Suit != "hearts" {
+		t.Errorf("Swap result should show the swapped-in card in slot 2, got %+v", result.Slots)
+	}
+	if result.HandSize != 4 {
+		t.Errorf("Expected hand size 4, got %d", result.HandSize)
+	}
+}
+
+func TestActionResultFailureHidesSlots(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+
+	// Not their turn
+	err := game.SwapCard(playerIDs[1], 0)
+	result := game.ActionResult(playerIDs[1], "swapCard", err, 0)
+	if result.Success || len(result.Slots) != 0 {
+		t.Errorf("Failed action should report failure without revealing slots, got %+v", result)
+	}
+}
+
+func TestActionResultOverWebSocket(t *testing.T) {
+	host, hostSession := createTestGameOverWS(t)
+	guest := dialTestServer(t)
+	sendTestMessage(t, guest, "join", map[string]interface{}{"gameID": hostSession["gameID"], "name": "Guest"})
+	readMessageOfType(t, guest, "session")
+
+	sendTestMessage(t, host, "startGame", nil)
+	for {
+		if state := readMessageOfType(t, guest, "gameState"); state["status"] == "playing" {
+			break
+		}
+	}
+
+	// The host sat first and plays first, so the guest's draw is refused
+	sendTestMessage(t, guest, "drawCard", nil)
+	result := readMessageOfType(t, guest, "actionResult")
+	if result["action"] != "drawCard" || result["success"] != false {
+		t.Errorf("Expected a failed drawCard result, got %v", result)
+	}
+
+	sendTestMessage(t, host, "drawCard", nil)
+	result = readMessageOfType(t, host, "actionResult")
+	if result["success"] != true || result["drawnCard"] == nil {
+		t.Errorf("Expected the host's drawn card in the result, got %v", result)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"pablo/protocol"
+)
+
+// With RevealDelayMs set, the end of a round is played out instead of shown in one frame:
+// the hands are turned over one player at a time in seat order, each as a handRevealed
+// message, and the round summary follows the last of them. Until a hand's turn comes, the
+// table's state keeps it face down and its score out of the totals.
+
+// maxRevealDelayMs keeps a staged reveal short enough that nobody waits on it for long
+const maxRevealDelayMs = 3000
+
+var errInvalidRevealDelay = errors.New("reveal delay must be between 0 and 3000 ms")
+
+// stageReveal starts turning the hands over, then sends the summary and checks the mercy
+// rule once they're all showing. Caller must hold g.mu.
+func (g *Game) stageReveal(pabloCaller string, blindPablo bool) {
+	g.revealed = make(map[string]bool)
+	order := append([]string(nil), g.SeatOrder...)
+	delay := time.Duration(g.Config.RevealDelayMs) * time.Millisecond
+	go g.runReveal(order, delay, pabloCaller, blindPablo)
+}
+
+// runReveal turns over one hand per delay, leaving a last delay before the summary.
+// Shutting down skips straight to the summary.
+func (g *Game) runReveal(order []string, delay time.Duration, pabloCaller string, blindPablo bool) {
+	for i, id := range order {
+		if i > 0 && !g.waitReveal(delay) {
+			break
+		}
+		g.mu.Lock()
+		g.revealHand(i, id)
+		g.mu.Unlock()
+	}
+	g.waitReveal(delay)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.revealed = nil
+	g.broadcastGameState()
+	g.broadcastRoundSummary(pabloCaller, blindPablo)
+	g.checkMercyRule()
+}
+
+// waitReveal sleeps for delay, reporting false if the game is shutting down instead
+func (g *Game) waitReveal(delay time.Duration) bool {
+	select {
+	case <-g.ctx.Done():
+		return false
+	case <-time.After(delay):
+		return true
+	}
+}
+
+// revealHand shows everyone one player's hand and round score. Caller must hold g.mu.
+func (g *Game) revealHand(seat int, playerID string) {
+	player, seated := g.Players[playerID]
+	if !seated || g.revealed == nil {
+		return
+	}
+	g.revealed[playerID] = true
+	message := Message{
+		Type: protocol.MsgHandRevealed,
+		Payload: map[string]interface{}{
+			"playerID":   playerID,
+			"playerName": g.displayName(playerID),
+			"seat":       seat,
+			"cards":      append([]Card(nil), player.Cards...),
+			"score":      player.Score,
+			"total":      player.Total,
+		},
+	}
+	g.broadcast(message)
+	g.broadcastToSpectators(message)
+	g.broadcastGameState()
+}
+
+// revealPending reports whether playerID's hand is still waiting its turn to be shown.
+// Caller must hold g.mu.
+func (g *Game) revealPending(playerID string) bool {
+	return g.revealed != nil && !g.revealed[playerID] && g.Status == protocol.StatusEnded
+}
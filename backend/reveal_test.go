@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStagedReveal(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	playerIDs := addTestPlayers(game, 2)
+	game.Config.RevealDelayMs = 20
+	game.StartGame()
+	setHand(game, playerIDs[0], "A", "2", "3", "4")
+	setHand(game, playerIDs[1], "5", "5", "5", "5")
+
+	game.mu.Lock()
+	game.EndRound()
+	seat := game.getGameStateForPlayer(playerIDs[0]).Players[playerIDs[1]]
+	game.mu.Unlock()
+	if seat.Cards[0].Rank != "" || seat.Score != 0 {
+		t.Errorf("A hand waiting to be revealed should stay hidden, got %+v", seat)
+	}
+
+	game.StartGame()
+	if game.IsPaused() || game.Status != "ended" {
+		t.Error("A new round shouldn't start in the middle of a reveal")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		recorder.mu.Lock()
+		done := countOfType(recorder.players[playerIDs[0]], "roundSummary") > 0
+		recorder.mu.Unlock()
+		if done {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("The round summary should follow the reveal")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	var revealed []string
+	for _, message := range recorder.players[playerIDs[0]] {
+		switch message.Type {
+		case "handRevealed":
+			payload := message.Payload.(map[string]interface{})
+			revealed = append(revealed, payload["playerID"].(string))
+		case "roundSummary":
+			if len(revealed) != len(playerIDs) {
+				t.Errorf("Every hand should be revealed before the summary, got %v", revealed)
+			}
+		}
+	}
+	for i, id := range game.SeatOrder {
+		if i >= len(revealed) || revealed[i] != id {
+			t.Fatalf("Hands should be revealed in seat order %v, got %v", game.SeatOrder, revealed)
+		}
+	}
+}
+
+func TestRevealDelayValidated(t *testing.T) {
+	if _, err := decodeGameConfig(map[string]interface{}{"revealDelayMs": maxRevealDelayMs + 1}); err == nil {
+		t.Error("A reveal delay over the maximum should be rejected")
+	}
+	if config, err := decodeGameConfig(map[string]interface{}{"revealDelayMs": 500}); err != nil || config.RevealDelayMs != 500 {
+		t.Errorf("Expected a 500ms reveal delay, got %v, %v", config.RevealDelayMs, err)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import "pablo/protocol"
+
+// Reveal claims, played with the RevealClaim house rule: before drawing, the player whose
+// turn it is may claim their hand is worth revealClaimMax or less. The server shows their
+// hand to the table. A true claim ends the round at once and the claimer wins it; a false
+// one adds revealClaimPenalty to their score for the round, and their turn goes on.
+const (
+	revealClaimMax     = 5
+	revealClaimPenalty = 20
+)
+
+// RevealClaim makes a reveal claim for playerID
+func (g *Game) RevealClaim(playerID string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.Status != protocol.StatusPlaying {
+		return ErrNotPlaying
+	}
+	if !g.Config.RevealClaim {
+		return ErrRuleDisabled
+	}
+	if g.CurrentPlayer != playerID {
+		return ErrNotYourTurn
+	}
+	if g.PendingGive != nil {
+		return ErrPendingGive
+	}
+	// Only at the start of the turn, before they've seen a new card
+	if g.HasDrawnThisTurn[playerID] {
+		return ErrAlreadyDrawn
+	}
+
+	player := g.Players[playerID]
+	cards := []Card{}
+	total := 0
+	for _, card := range player.Cards {
+		if card.Rank == "" {
+			continue
+		}
+		card.FaceUp = true
+		cards = append(cards, card)
+		total += g.cardValue(card)
+	}
+	held := total <= revealClaimMax
+	penalty := 0
+	if !held {
+		penalty = revealClaimPenalty
+		g.claimPenalties[playerID] += penalty
+	}
+
+	g.emitAction(playerID, protocol.MsgRevealClaim, map[string]interface{}{"total": total, "held": held})
+	message := Message{
+		Type: protocol.MsgClaimRevealed,
+		Payload: map[string]interface{}{
+			"playerID":   playerID,
+			"playerName": g.displayName(playerID),
+			"cards":      cards,
+			"total":      total,
+			"held":       held,
+			"penalty":    penalty,
+		},
+	}
+	g.broadcast(message)
+	g.broadcastToSpectators(message)
+
+	if held {
+		g.ClaimWinner = playerID
+		g.EndRound()
+		return nil
+	}
+	g.broadcastGameState()
+	return nil
+}
+
+// applyClaimPenalties adds this round's false claim points to the scores. Caller must
+// hold g.mu.
+func (g *Game) applyClaimPenalties() {
+	for id, points := range g.claimPenalties {
+		if player, seated := g.Players[id]; seated {
+			player.Score += points
+		}
+		delete(g.claimPenalties, id)
+	}
+}
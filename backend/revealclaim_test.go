@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func startRevealClaimGame(t *testing.T) (*Game, *recordingBroadcaster, string, string) {
+	t.Helper()
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	playerIDs := addTestPlayers(game, 2)
+	game.Config.RevealClaim = true
+	game.StartGame()
+
+	current := game.CurrentPlayer
+	other := playerIDs[0]
+	if other == current {
+		other = playerIDs[1]
+	}
+	// The other player holds less, so only the claim can make the claimer win
+	game.Players[other].Cards = []Card{
+		{Suit: "hearts", Rank: "K"}, {Suit: "diamonds", Rank: "K"}, {Suit: "clubs", Rank: "A"}, {Suit: "spades", Rank: "A"},
+	}
+	return game, recorder, current, other
+}
+
+func TestRevealClaimHeld(t *testing.T) {
+	game, recorder, current, other := startRevealClaimGame(t)
+	game.Players[current].Cards = []Card{
+		{Suit: "hearts", Rank: "A"}, {Suit: "diamonds", Rank: "A"}, {Suit: "spades", Rank: "2"}, {Suit: "hearts", Rank: "K"},
+	}
+
+	if err := game.RevealClaim(current); err != nil {
+		t.Fatalf("Claim should be allowed: %v", err)
+	}
+	if game.Status != "ended" {
+		t.Fatalf("A true claim should end the round, status is %s", game.Status)
+	}
+	winners := game.roundWinners()
+	if len(winners) != 1 || winners[0] != current {
+		t.Errorf("Expected the claimer to win alone, got %v", winners)
+	}
+	if game.Players[other].Score >= game.Players[current].Score {
+		t.Fatal("Test needs the other player to hold less than the claimer")
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if countOfType(recorder.players[other], "claimRevealed") != 1 {
+		t.Error("The table should see the claimer's hand")
+	}
+}
+
+func TestRevealClaimFalse(t *testing.T) {
+	game, recorder, current, _ := startRevealClaimGame(t)
+	game.Players[current].Cards = []Card{
+		{Suit: "hearts", Rank: "10"}, {Suit: "clubs", Rank: "2"}, {Suit: "spades", Rank: "2"}, {Suit: "clubs", Rank: "A"},
+	}
+
+	if err := game.RevealClaim(current); err != nil {
+		t.Fatalf("Claim should be allowed: %v", err)
+	}
+	if game.Status != "playing" {
+		t.Fatal("A false claim should not end the round")
+	}
+	if game.CurrentPlayer != current {
+		t.Error("The claimer's turn should go on")
+	}
+	recorder.mu.Lock()
+	revealed := countOfType(recorder.players[current], "claimRevealed")
+	recorder.mu.Unlock()
+	if revealed != 1 {
+		t.Error("A false claim should still reveal the hand")
+	}
+
+	game.EndRound()
+	if score := game.Players[current].Score; score != 15+revealClaimPenalty {
+		t.Errorf("Expected %d, got %d", 15+revealClaimPenalty, score)
+	}
+	if len(game.claimPenalties) != 0 {
+		t.Error("Penalties should be cleared once applied")
+	}
+}
+
+func TestRevealClaimRules(t *testing.T) {
+	game, _, current, other := startRevealClaimGame(t)
+
+	if err := game.RevealClaim(other); !errors.Is(err, ErrNotYourTurn) {
+		t.Errorf("Expected ErrNotYourTurn, got %v", err)
+	}
+	game.DrawCard(current)
+	if err := game.RevealClaim(current); !errors.Is(err, ErrAlreadyDrawn) {
+		t.Errorf("Expected ErrAlreadyDrawn after drawing, got %v", err)
+	}
+
+	game.Config.RevealClaim = false
+	if err := game.RevealClaim(current); !errors.Is(err, ErrRuleDisabled) {
+		t.Errorf("Expected ErrRuleDisabled, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"pablo/protocol"
+)
+
+var (
+	// scheduleNotice is how long before a scheduled game opens its players are reminded
+	scheduleNotice = 5 * time.Minute
+
+	// maxScheduleAhead is how far ahead a game may be scheduled
+	maxScheduleAhead = 30 * 24 * time.Hour
+)
+
+var (
+	errScheduleFormat = errors.New("scheduledAt must be an RFC 3339 time")
+	errScheduleInPast = errors.New("scheduledAt must be in the future")
+	errScheduleTooFar = errors.New("games can't be scheduled that far ahead")
+)
+
+// scheduleErrorKey is the catalog message for an error from parseScheduledAt
+func scheduleErrorKey(err error) string {
+	switch err {
+	case errScheduleInPast:
+		return protocol.CodeInvalidSchedule + ".past"
+	case errScheduleTooFar:
+		return protocol.CodeInvalidSchedule + ".tooFar"
+	}
+	return protocol.CodeInvalidSchedule + ".format"
+}
+
+// parseScheduledAt reads the RFC 3339 start time sent with createGame. It must be in
+// the future, but not further off than maxScheduleAhead.
+func parseScheduledAt(value string, now time.Time) (time.Time, error) {
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errScheduleFormat
+	}
+	if !at.After(now) {
+		return time.Time{}, errScheduleInPast
+	}
+	if at.Sub(now) > maxScheduleAhead {
+		return time.Time{}, errScheduleTooFar
+	}
+	return at, nil
+}
+
+// Schedule holds a new game back until at. Players can take seats in the meantime, which
+// registers them for the game; they're reminded scheduleNotice beforehand, and at the
+// scheduled time the lobby opens and the host can start as usual.
+func (g *Game) Schedule(at time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.Status = protocol.StatusScheduled
+	g.ScheduledAt = at
+	g.broadcastGameState()
+	go g.runSchedule(at)
+}
+
+// runSchedule sends the reminder and opens the lobby. It gives up if the game is
+// shut down first.
+func (g *Game) runSchedule(at time.Time) {
+	if wait := time.Until(at) - scheduleNotice; wait > 0 {
+		select {
+		case <-g.ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+	g.remindScheduled()
+
+	select {
+	case <-g.ctx.Done():
+		return
+	case <-time.After(time.Until(at)):
+	}
+	g.openLobby()
+}
+
+// remindScheduled tells the registered players their game is about to open
+func (g *Game) remindScheduled() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.Status != protocol.StatusScheduled {
+		return
+	}
+	g.broadcast(Message{
+		Type:    protocol.MsgGameStartingSoon,
+		Payload: map[string]interface{}{"scheduledAt": g.ScheduledAt.UTC().Format(time.RFC3339)},
+	})
+	g.emit(protocol.EventStartingSoon, "", map[string]interface{}{
+		"scheduledAt": g.ScheduledAt.UTC().Format(time.RFC3339),
+		"players":     append([]string{}, g.SeatOrder...),
+	})
+}
+
+// openLobby moves a scheduled game to waiting, so it can be started
+func (g *Game) openLobby() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.Status != protocol.StatusScheduled {
+		return
+	}
+	g.Status = protocol.StatusWaiting
+	g.ScheduledAt = time.Time{}
+	g.emit(protocol.EventLobbyOpened, "", nil)
+	g.broadcastGameState()
+	g.broadcastLobby()
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduledAt(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if at, err := parseScheduledAt("2024-05-01T14:00:00+02:00", now.Add(-time.Hour)); err != nil || !at.Equal(now) {
+		t.Errorf("Expected %v, got %v (%v)", now, at, err)
+	}
+	for _, value := range []string{"tomorrow", "2024-05-01T12:00:00Z", "2024-04-30T12:00:00Z", "2025-05-01T12:00:00Z"} {
+		if _, err := parseScheduledAt(value, now); err == nil {
+			t.Errorf("%s: expected an error", value)
+		}
+	}
+}
+
+func TestScheduledGameOpensOnTime(t *testing.T) {
+	savedNotice := scheduleNotice
+	scheduleNotice = 40 * time.Millisecond
+	defer func() { scheduleNotice = savedNotice }()
+	recorder, flush := recordEvents()
+
+	game := createTestGame("test-game")
+	broadcaster := newRecordingBroadcaster()
+	game.SetBroadcaster(broadcaster)
+	game.Schedule(time.Now().Add(80 * time.Millisecond))
+	addTestPlayers(game, 2)
+
+	if status := game.PublicState().Status; status != "scheduled" {
+		t.Fatalf("Expected the game to be scheduled, got %s", status)
+	}
+	if game.PublicState().ScheduledAt.IsZero() {
+		t.Error("The state should say when the game opens")
+	}
+	game.StartGame()
+	if status := game.PublicState().Status; status != "scheduled" {
+		t.Fatalf("A scheduled game shouldn't start early, got %s", status)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for game.PublicState().Status != "waiting" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if status := game.PublicState().Status; status != "waiting" {
+		t.Fatalf("Expected the lobby to open, got %s", status)
+	}
+	broadcaster.mu.Lock()
+	reminders := countOfType(broadcaster.players["player1"], "gameStartingSoon")
+	broadcaster.mu.Unlock()
+	if reminders != 1 {
+		t.Errorf("Expected one reminder, got %d", reminders)
+	}
+
+	game.StartGame()
+	if status := game.PublicState().Status; status != "playing" {
+		t.Errorf("Expected the game to start once open, got %s", status)
+	}
+
+	flush()
+	var types []string
+	for _, event := range recorder.events {
+		if event.Type == "startingSoon" || event.Type == "lobbyOpened" {
+			types = append(types, event.Type)
+		}
+	}
+	if len(types) != 2 || types[0] != "startingSoon" || types[1] != "lobbyOpened" {
+		t.Errorf("Expected startingSoon then lobbyOpened, got %v", types)
+	}
+}
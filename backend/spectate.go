@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// handleGameState serves GET /games/{gameID}/state with the spectator view of the game,
+// for embeds and status pages that want to show a table without holding a WebSocket.
+// It reads the published view, so polling it never waits on or slows down the game.
+func handleGameState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/games/"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "state" {
+		http.NotFound(w, r)
+		return
+	}
+	game := gameManager.GetGame(r.Context(), parts[0])
+	if game == nil {
+		http.Error(w, "game not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Cache-Control", "no-cache")
+	json.NewEncoder(w).Encode(game.PublicState())
+}
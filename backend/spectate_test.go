@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleGameState(t *testing.T) {
+	game := gameManager.CreateGame(context.Background())
+	game.SetBroadcaster(newRecordingBroadcaster())
+	addTestPlayers(game, 2)
+	game.StartGame()
+
+	rec := httptest.NewRecorder()
+	handleGames(rec, httptest.NewRequest(http.MethodGet, "/games/"+game.ID+"/state", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	var state struct {
+		GameID    string `json:"gameID"`
+		Status    string `json:"status"`
+		Spectator bool   `json:"spectator"`
+		Players   map[string]struct {
+			Cards []Card `json:"cards"`
+		} `json:"players"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&state); err != nil {
+		t.Fatal(err)
+	}
+	if state.GameID != game.ID || state.Status != "playing" || !state.Spectator || len(state.Players) != 2 {
+		t.Fatalf("Unexpected state %+v", state)
+	}
+	for id, player := range state.Players {
+		for _, card := range player.Cards {
+			if card.Rank != "" {
+				t.Errorf("%s's cards should be hidden from spectators, got %+v", id, player.Cards)
+				break
+			}
+		}
+	}
+
+	for _, path := range []string{"/games/no-such-game/state", "/games/" + game.ID + "/state/x"} {
+		rec = httptest.NewRecorder()
+		handleGames(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", path, rec.Code)
+		}
+	}
+	rec = httptest.NewRecorder()
+	handleGames(rec, httptest.NewRequest(http.MethodPost, "/games/"+game.ID+"/state", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected POST to be refused, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/gorilla/websocket"
+	"pablo/protocol"
+)
+
+// Spectator watches a game without a seat. Spectators can predict how a round will end
+// and collect points for correct predictions over the life of the game.
+type Spectator struct {
+	ID         string
+	Name       string
+	Conn       *websocket.Conn
+	Points     int
+	Prediction *Prediction // Current round's prediction, if any
+}
+
+// Prediction is a spectator's guess about the round. Either part may be left out.
+type Prediction struct {
+	WinnerID      string `json:"winnerID,omitempty"`
+	PabloSucceeds *bool  `json:"pabloSucceeds,omitempty"` // Whether the Pablo caller ends with the lowest score
+}
+
+func (g *Game) AddSpectator(spectatorID, name string, conn *websocket.Conn) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.Spectators[spectatorID] = &Spectator{
+		ID:   spectatorID,
+		Name: name,
+		Conn: conn,
+	}
+	g.broadcaster.ToSpectator(spectatorID, Message{Type: protocol.MsgGameState, Payload: g.getGameStateForSpectator()})
+	if g.spectatorStatsLive() {
+		g.broadcaster.ToSpectator(spectatorID, Message{Type: protocol.MsgSpectatorStats, Payload: g.spectatorStats()})
+	}
+}
+
+func (g *Game) RemoveSpectator(spectatorID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.Spectators, spectatorID)
+}
+
+// SubmitPrediction records (or replaces) a spectator's prediction for the current round.
+// Predictions close once the final turn of the round has started.
+func (g *Game) SubmitPrediction(spectatorID string, prediction Prediction) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	spectator, exists := g.Spectators[spectatorID]
+	if !exists || g.Status != protocol.StatusPlaying {
+		return false
+	}
+	if prediction.WinnerID == "" && prediction.PabloSucceeds == nil {
+		return false
+	}
+	if prediction.WinnerID != "" {
+		if _, seated := g.Players[prediction.WinnerID]; !seated {
+			return false
+		}
+	}
+	if g.isFinalTurn() {
+		return false
+	}
+
+	spectator.Prediction = &prediction
+	return true
+}
+
+// isFinalTurn reports whether the current turn is the last one before the Pablo caller's seat
+// comes around again. Caller must hold g.mu.
+func (g *Game) isFinalTurn() bool {
+	return g.PabloCalled && g.nextSeatAfter(g.CurrentPlayer) == g.PabloCaller
+}
+
+// scorePredictions awards a point for each correct part of every spectator's prediction,
+// clears the predictions for the next round and returns what each spectator earned.
+// Caller must hold g.mu.
+func (g *Game) scorePredictions(winners []string, pabloCaller string, pabloSucceeded bool) map[string]interface{} {
+	results := make(map[string]interface{})
+	for id, spectator := range g.Spectators {
+		if spectator.Prediction == nil {
+			continue
+		}
+		prediction := spectator.Prediction
+		earned := 0
+		winnerCorrect := false
+		for _, w := range winners {
+			if prediction.WinnerID != "" && prediction.WinnerID == w {
+				winnerCorrect = true
+			}
+		}
+		if winnerCorrect {
+			earned++
+		}
+		pabloCorrect := pabloCaller != "" && prediction.PabloSucceeds != nil && *prediction.PabloSucceeds == pabloSucceeded
+		if pabloCorrect {
+			earned++
+		}
+		spectator.Points += earned
+		spectator.Prediction = nil
+
+		results[id] = map[string]interface{}{
+			"name":          spectator.Name,
+			"winnerCorrect": winnerCorrect,
+			"pabloCorrect":  pabloCorrect,
+			"earned":        earned,
+		}
+	}
+	return results
+}
+
+// spectatorLeaderboard ranks this game's spectators by points, highest first
+func (g *Game) spectatorLeaderboard() []map[string]interface{} {
+	spectators := make([]*Spectator, 0, len(g.Spectators))
+	for _, spectator := range g.Spectators {
+		spectators = append(spectators, spectator)
+	}
+	sort.Slice(spectators, func(i, j int) bool {
+		if spectators[i].Points != spectators[j].Points {
+			return spectators[i].Points > spectators[j].Points
+		}
+		return spectators[i].Name < spectators[j].Name
+	})
+
+	leaderboard := make([]map[string]interface{}, 0, len(spectators))
+	for _, spectator := range spectators {
+		leaderboard = append(leaderboard, map[string]interface{}{
+			"spectatorID": spectator.ID,
+			"name":        spectator.Name,
+			"points":      spectator.Points,
+		})
+	}
+	return leaderboard
+}
@@ -0,0 +1,91 @@
+package main
+
+import "testing"
+
+func TestSpectatorPredictionsScored(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+
+	game.AddSpectator("s1", "Sam", nil)
+	game.AddSpectator("s2", "Kim", nil)
+
+	// Make player1 the clear winner
+	game.Players[playerIDs[0]].Cards = []Card{{Suit: "hearts", Rank: "A"}}
+	game.Players[playerIDs[1]].Cards = []Card{{Suit: "clubs", Rank: "10"}}
+
+	yes := true
+	if !game.SubmitPrediction("s1", Prediction{WinnerID: playerIDs[0], PabloSucceeds: &yes}) {
+		t.Fatal("Spectator should be able to predict")
+	}
+	if !game.SubmitPrediction("s2", Prediction{WinnerID: playerIDs[1]}) {
+		t.Fatal("Spectator should be able to predict only the winner")
+	}
+
+	game.CallPablo(playerIDs[0])
+	game.EndRound()
+
+	if game.Spectators["s1"].Points != 2 {
+		t.Errorf("Expected 2 points for two correct predictions, got %d", game.Spectators["s1"].Points)
+	}
+	if game.Spectators["s2"].Points != 0 {
+		t.Errorf("Expected 0 points for a wrong prediction, got %d", game.Spectators["s2"].Points)
+	}
+	if game.Spectators["s1"].Prediction != nil {
+		t.Error("Predictions should be cleared after scoring")
+	}
+
+	leaderboard := game.spectatorLeaderboard()
+	if leaderboard[0]["spectatorID"] != "s1" {
+		t.Error("Leaderboard should be ordered by points")
+	}
+}
+
+func TestPredictionsCloseOnFinalTurn(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+	game.AddSpectator("s1", "Sam", nil)
+
+	// player1 calls Pablo; once player2 is up it's the final turn
+	game.CallPablo(playerIDs[0])
+	game.CurrentPlayer = playerIDs[1]
+
+	if game.SubmitPrediction("s1", Prediction{WinnerID: playerIDs[0]}) {
+		t.Error("Predictions should be closed on the final turn")
+	}
+}
+
+func TestSubmitPredictionValidation(t *testing.T) {
+	game := createTestGame("test-game")
+	addTestPlayers(game, 2)
+	game.AddSpectator("s1", "Sam", nil)
+
+	if game.SubmitPrediction("s1", Prediction{WinnerID: "player1"}) {
+		t.Error("Predictions should be rejected before the game starts")
+	}
+
+	game.StartGame()
+	if game.SubmitPrediction("s1", Prediction{WinnerID: "nobody"}) {
+		t.Error("Predicted winner must be seated")
+	}
+	if game.SubmitPrediction("s1", Prediction{}) {
+		t.Error("Empty predictions should be rejected")
+	}
+	if game.SubmitPrediction("unknown", Prediction{WinnerID: "player1"}) {
+		t.Error("Only spectators can predict")
+	}
+}
+
+func TestRoundWinnersTies(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 3)
+	game.Players[playerIDs[0]].Score = 5
+	game.Players[playerIDs[1]].Score = 3
+	game.Players[playerIDs[2]].Score = 3
+
+	winners := game.roundWinners()
+	if len(winners) != 2 || winners[0] != playerIDs[1] || winners[1] != playerIDs[2] {
+		t.Errorf("Expected tied winners %v, got %v", playerIDs[1:], winners)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import "pablo/protocol"
+
+// Spectators get a running commentary of numbers alongside the state: the odds the next
+// card off the deck matches the top discard, how many of each rank are face up, and the
+// least each hand could be worth. Everything is worked out from what a spectator can see.
+// The odds do count the cards nobody can see, but only as one pile: which cards are in it
+// follows from the cards dealt and the ones shown, so its make-up is no secret, only where
+// each card lies.
+
+// handFloor is the least a hand can score from what is known of it
+type handFloor struct {
+	PlayerID string `json:"playerID"`
+	Name     string `json:"name"`
+	Known    int    `json:"known"`   // The face-up cards' points
+	Hidden   int    `json:"hidden"`  // Face-down cards
+	Minimum  int    `json:"minimum"` // Known, plus the lowest-scoring unseen card for each hidden one
+}
+
+// spectatorStats is the spectatorStats payload
+type spectatorStats struct {
+	TopRank       string         `json:"topRank,omitempty"`
+	MatchChance   *float64       `json:"matchChance,omitempty"`   // That the next deck card has the top discard's rank; left out with nothing to draw or match
+	VisibleRanks  map[string]int `json:"visibleRanks"`            // Face-up cards by rank, in hands and on the discard pile
+	UnseenCards   int            `json:"unseenCards"`             // Cards in the deck, face down in hands or drawn and not shown
+	Hands         []handFloor    `json:"hands"`                   // In seat order
+	LowestMinimum string         `json:"lowestMinimum,omitempty"` // The player whose hand could be the lowest; empty on a tie
+}
+
+// spectatorStats works out the stats for the round in play. Caller must hold g.mu.
+func (g *Game) spectatorStats() spectatorStats {
+	stats := spectatorStats{VisibleRanks: make(map[string]int), Hands: []handFloor{}}
+	unseen := make(map[string]int)
+	lowest, anyUnseen := 0, false
+	see := func(card Card) {
+		if card.Rank != "" {
+			stats.VisibleRanks[card.Rank]++
+		}
+	}
+	hide := func(card Card) {
+		if card.Rank == "" {
+			return
+		}
+		unseen[card.Rank]++
+		stats.UnseenCards++
+		if value := g.cardValue(card); !anyUnseen || value < lowest {
+			lowest, anyUnseen = value, true
+		}
+	}
+
+	for _, card := range g.Deck {
+		hide(card)
+	}
+	for _, card := range g.DiscardPile {
+		see(card)
+	}
+	for id, card := range g.DrawnCards {
+		if card == nil {
+			continue
+		}
+		if g.DrawnFromDiscard[id] {
+			see(*card)
+		} else {
+			hide(*card)
+		}
+	}
+	for _, id := range g.SeatOrder {
+		for _, card := range g.Players[id].Cards {
+			if card.FaceUp {
+				see(card)
+			} else {
+				hide(card)
+			}
+		}
+	}
+
+	if len(g.DiscardPile) > 0 {
+		stats.TopRank = g.DiscardPile[len(g.DiscardPile)-1].Rank
+		if len(g.Deck) > 0 && stats.UnseenCards > 0 {
+			chance := float64(unseen[stats.TopRank]) / float64(stats.UnseenCards)
+			stats.MatchChance = &chance
+		}
+	}
+
+	best, tied := 0, false
+	for _, id := range g.SeatOrder {
+		floor := handFloor{PlayerID: id, Name: g.displayName(id)}
+		for _, card := range g.Players[id].Cards {
+			switch {
+			case card.Rank == "":
+			case card.FaceUp:
+				floor.Known += g.cardValue(card)
+			default:
+				floor.Hidden++
+			}
+		}
+		floor.Minimum = floor.Known + floor.Hidden*lowest
+		switch {
+		case len(stats.Hands) == 0 || floor.Minimum < best:
+			best, tied = floor.Minimum, false
+			stats.LowestMinimum = id
+		case floor.Minimum == best:
+			tied = true
+		}
+		stats.Hands = append(stats.Hands, floor)
+	}
+	if tied {
+		stats.LowestMinimum = ""
+	}
+	return stats
+}
+
+// spectatorStatsLive reports whether spectators are sent stats now: while a round is in
+// play. Once it ends every hand is face up and the round summary has the real scores.
+// Caller must hold g.mu.
+func (g *Game) spectatorStatsLive() bool {
+	return g.Status == protocol.StatusPlaying || g.Status == protocol.StatusPaused
+}
+
+// broadcastSpectatorStats sends every spectator the stats. Caller must hold g.mu.
+func (g *Game) broadcastSpectatorStats() {
+	if len(g.Spectators) == 0 || !g.spectatorStatsLive() {
+		return
+	}
+	g.broadcastToSpectators(Message{Type: protocol.MsgSpectatorStats, Payload: g.spectatorStats()})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	"pablo/protocol"
+)
+
+func TestSpectatorStats(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+	setHand(game, playerIDs[0], "5", "K", "2", "9")
+	setHand(game, playerIDs[1], "3", "3", "7", "Q")
+	game.Players[playerIDs[0]].Cards[0].FaceUp = true
+	game.Players[playerIDs[1]].Cards[0].FaceUp = true
+	game.Deck = []Card{{Suit: "hearts", Rank: "3"}, {Suit: "spades", Rank: "A"}, {Suit: "clubs", Rank: "8"}, {Suit: "hearts", Rank: "K"}}
+	game.DiscardPile = []Card{{Suit: "spades", Rank: "6", FaceUp: true}, {Suit: "clubs", Rank: "3", FaceUp: true}}
+
+	stats := game.spectatorStats()
+	if stats.TopRank != "3" || stats.MatchChance == nil || *stats.MatchChance != 2.0/10 {
+		t.Errorf("Expected a 2 in 10 chance of another 3, got %+v", stats)
+	}
+	if stats.VisibleRanks["3"] != 2 || stats.VisibleRanks["5"] != 1 || stats.VisibleRanks["K"] != 0 {
+		t.Errorf("Expected only face-up cards counted, got %v", stats.VisibleRanks)
+	}
+	// The lowest unseen card is the red king, -1
+	if hand := stats.Hands[0]; hand.Known != 5 || hand.Hidden != 3 || hand.Minimum != 2 {
+		t.Errorf("Unexpected floor %+v", hand)
+	}
+	if stats.LowestMinimum != playerIDs[1] {
+		t.Errorf("Expected the 3 showing to give the lowest floor, got %q", stats.LowestMinimum)
+	}
+
+	// Which hidden card lies where must make no difference
+	hand := game.Players[playerIDs[0]].Cards
+	hand[1], game.Deck[1] = game.Deck[1], hand[1]
+	if moved := game.spectatorStats(); *moved.MatchChance != *stats.MatchChance || moved.Hands[0] != stats.Hands[0] {
+		t.Errorf("Stats changed with a hidden card's place: %+v", moved)
+	}
+}
+
+func TestSpectatorsGetStatsDuringPlay(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	addTestPlayers(game, 2)
+	game.AddSpectator("watcher", "Watcher", nil)
+	if countOfType(recorder.spectators["watcher"], protocol.MsgSpectatorStats) != 0 {
+		t.Error("No stats before the deal")
+	}
+
+	game.StartGame()
+	if countOfType(recorder.spectators["watcher"], protocol.MsgSpectatorStats) != 1 {
+		t.Error("Expected stats with the dealt state")
+	}
+	if countOfType(recorder.players["player1"], protocol.MsgSpectatorStats) != 0 {
+		t.Error("Players shouldn't get the spectators' stats")
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import "time"
+
+// Stacking is a race: the first matching card to reach the server takes the discard, and
+// anyone later finds it closed. A player on a slow connection sees the discard late and
+// their stack arrives late, so they'd lose every close race to a player on a fast one. To
+// even that out, a stack is judged by when it was made rather than when it arrived: the
+// player's measured round trip, up to stackGraceMax, is taken off its arrival. A stack that
+// turns out to have been made no later than the one that closed the discard goes down too.
+
+// stackGraceMax bounds the compensation, so a bad connection can't stack long after the fact
+const stackGraceMax = 200 * time.Millisecond
+
+// stackGrace is the discard that was last stacked on, kept while late stacks may still count
+type stackGrace struct {
+	card   Card            // The discard that was stacked on
+	chance int             // The stackChances it was open as
+	pile   int             // discardCount() after the stacks on it so far
+	at     time.Time       // When the first stack arrived
+	lag    time.Duration   // The first stacker's compensation
+	by     map[string]bool // Who has stacked on it; one card each, as when it was open
+}
+
+// stackCompensation is how much earlier than it arrived playerID's stack is taken to have
+// been made: their connection's round trip, capped at stackGraceMax. Caller must hold g.mu.
+func (g *Game) stackCompensation(playerID string) time.Duration {
+	player, exists := g.Players[playerID]
+	if !exists {
+		return 0
+	}
+	rtt, _ := latencyOf(player.Conn)
+	if rtt > stackGraceMax {
+		return stackGraceMax
+	}
+	return rtt
+}
+
+// inStackGrace reports whether playerID may still stack on the discard someone stacked on
+// just before them, and returns that discard. Nothing may have happened on the pile since
+// but other late stacks. Caller must hold g.mu.
+func (g *Game) inStackGrace(playerID string) (Card, bool) {
+	grace := g.stackGrace
+	if grace == nil || grace.chance != g.stackChances || grace.pile != g.discardCount() || grace.by[playerID] {
+		return Card{}, false
+	}
+	if time.Since(grace.at) > g.stackCompensation(playerID)-grace.lag {
+		return Card{}, false
+	}
+	return grace.card, true
+}
+
+// noteStack records a successful stack on card, after it has gone on the pile. The first
+// stack on a discard starts the grace period; late ones only move the pile on.
+// Caller must hold g.mu.
+func (g *Game) noteStack(playerID string, card Card, late bool) {
+	if late {
+		g.stackGrace.pile = g.discardCount()
+		g.stackGrace.by[playerID] = true
+		return
+	}
+	g.stackGrace = &stackGrace{
+		card:   card,
+		chance: g.stackChances,
+		pile:   g.discardCount(),
+		at:     time.Now(),
+		lag:    g.stackCompensation(playerID),
+		by:     map[string]bool{playerID: true},
+	}
+}
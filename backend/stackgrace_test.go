@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// withRTT gives playerID a connection measured at rtt
+func withRTT(t *testing.T, game *Game, playerID string, rtt time.Duration) {
+	conn := newTestConn(t)
+	measured := &connLatency{}
+	measured.smoothed.Store(int64(rtt))
+	latencies.Store(conn, measured)
+	t.Cleanup(func() { forgetLatency(conn) })
+	game.Players[playerID].Conn = conn
+}
+
+func TestLaggedStackWithinGrace(t *testing.T) {
+	game := createTestGame("test-game")
+	game.SetBroadcaster(newRecordingBroadcaster())
+	playerIDs := addTestPlayers(game, 3)
+	game.StartGame()
+	game.DiscardPile = append(game.DiscardPile, Card{Suit: "hearts", Rank: "5", FaceUp: true})
+	game.StackableCardIndex = len(game.DiscardPile) - 1
+	for _, id := range playerIDs {
+		setHand(game, id, "5", "5", "9", "9")
+	}
+	withRTT(t, game, playerIDs[1], time.Second)
+
+	if err := game.StackCard(playerIDs[0], 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := game.StackCard(playerIDs[2], 0); !errors.Is(err, ErrNotStackable) {
+		t.Errorf("A stack after the discard closed, with no lag to cover it, should be refused, got %v", err)
+	}
+	if err := game.StackCard(playerIDs[1], 0); err != nil {
+		t.Fatalf("Expected the lagged player's stack to count, got %v", err)
+	}
+	if top := game.DiscardPile[len(game.DiscardPile)-1]; game.Players[playerIDs[1]].Cards[0].Rank != "" || top.Rank != "5" {
+		t.Error("Expected the late card on the pile")
+	}
+	if err := game.StackCard(playerIDs[1], 1); !errors.Is(err, ErrNotStackable) {
+		t.Errorf("A late stacker should get one card down, as anyone would, got %v", err)
+	}
+}
+
+func TestLaggedStackGraceIsBounded(t *testing.T) {
+	game := createTestGame("test-game")
+	game.SetBroadcaster(newRecordingBroadcaster())
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+	game.DiscardPile = append(game.DiscardPile, Card{Suit: "hearts", Rank: "5", FaceUp: true})
+	game.StackableCardIndex = len(game.DiscardPile) - 1
+	setHand(game, playerIDs[0], "5", "9", "9", "9")
+	setHand(game, playerIDs[1], "5", "9", "9", "9")
+	withRTT(t, game, playerIDs[1], time.Second)
+
+	if err := game.StackCard(playerIDs[0], 0); err != nil {
+		t.Fatal(err)
+	}
+	game.stackGrace.at = time.Now().Add(-stackGraceMax - 10*time.Millisecond)
+	if err := game.StackCard(playerIDs[1], 0); !errors.Is(err, ErrNotStackable) {
+		t.Errorf("Compensation should stop at %v however slow the connection, got %v", stackGraceMax, err)
+	}
+}
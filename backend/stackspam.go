@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"pablo/protocol"
+)
+
+// StackSpamRules decide when failed stacks stop looking like honest mistakes. A player who
+// fails Limit stacks within WindowSeconds earns a strike: they can't stack again for
+// CooldownSeconds times their number of strikes, and from strike PenaltyAfter on each
+// strike also adds PenaltyPoints to their score for the round.
+type StackSpamRules struct {
+	Limit           int `json:"limit"`           // Failed stacks in the window that earn a strike; 0 turns detection off
+	WindowSeconds   int `json:"windowSeconds"`   // How far back failed stacks are counted
+	CooldownSeconds int `json:"cooldownSeconds"` // Stacking lockout for the first strike; later strikes multiply it
+	PenaltyAfter    int `json:"penaltyAfter"`    // First strike that also costs points; 0 never costs points
+	PenaltyPoints   int `json:"penaltyPoints"`   // Points added to the round score for each such strike
+}
+
+var defaultStackSpamRules = StackSpamRules{
+	Limit:           3,
+	WindowSeconds:   20,
+	CooldownSeconds: 10,
+	PenaltyAfter:    2,
+	PenaltyPoints:   5,
+}
+
+var errInvalidStackSpamRules = errors.New("stack spam settings can't be negative")
+
+func (r StackSpamRules) validate() error {
+	if r.Limit < 0 || r.WindowSeconds < 0 || r.CooldownSeconds < 0 || r.PenaltyAfter < 0 || r.PenaltyPoints < 0 {
+		return errInvalidStackSpamRules
+	}
+	return nil
+}
+
+// FailedStackRules are what a failed stack costs on top of the stack spam rules. The zero
+// value is the classic penalty of one card.
+type FailedStackRules struct {
+	PenaltyCards  int  `json:"penaltyCards"`  // Cards dealt face down to the failed stacker; 0 means 1
+	SkipNextStack bool `json:"skipNextStack"` // The failed stacker may not stack again on this card or the next one discarded
+}
+
+// failedStackMaxCards keeps a single failed stack from emptying the deck
+const failedStackMaxCards = 4
+
+var errInvalidFailedStackRules = errors.New("failed stack penalty must be 0-4 cards")
+
+func (r FailedStackRules) validate() error {
+	if r.PenaltyCards < 0 || r.PenaltyCards > failedStackMaxCards {
+		return errInvalidFailedStackRules
+	}
+	return nil
+}
+
+// penaltyCards is how many cards a failed own-card stack deals. A failed stack of an
+// opponent's card always costs that card instead.
+func (r FailedStackRules) penaltyCards() int {
+	if r.PenaltyCards == 0 {
+		return 1
+	}
+	return r.PenaltyCards
+}
+
+// markStackable opens the top discard to stacking, as the next chance to stack.
+// Caller must hold g.mu.
+func (g *Game) markStackable() {
+	g.StackableCardIndex = len(g.DiscardPile) - 1
+	g.stackChances++
+	g.startStackWindow()
+}
+
+// sittingOutStack reports whether playerID failed a stack under the SkipNextStack rule
+// and may not stack on the current card. Caller must hold g.mu.
+func (g *Game) sittingOutStack(playerID string) bool {
+	last, exists := g.stackSitOut[playerID]
+	if !exists {
+		return false
+	}
+	if g.stackChances > last {
+		delete(g.stackSitOut, playerID)
+		return false
+	}
+	return true
+}
+
+// stackSpamRecord is what the game remembers about one player's failed stacks
+type stackSpamRecord struct {
+	failures      []time.Time // Recent failed stacks, oldest first
+	strikes       int         // Strikes earned this game
+	cooldownUntil time.Time   // No stacking before this
+	penalty       int         // Points to add at the end of this round
+}
+
+// stackCooldown returns when playerID may stack again, or the zero time if they may
+// stack now. Caller must hold g.mu.
+func (g *Game) stackCooldown(playerID string) time.Time {
+	record, exists := g.stackSpam[playerID]
+	if !exists || !time.Now().Before(record.cooldownUntil) {
+		return time.Time{}
+	}
+	return record.cooldownUntil
+}
+
+// noteFailedStack counts a failed stack against playerID and hands out a strike when
+// they have failed too often. Caller must hold g.mu.
+func (g *Game) noteFailedStack(playerID string) {
+	if g.Config.FailedStack.SkipNextStack {
+		g.stackSitOut[playerID] = g.stackChances + 1
+	}
+
+	rules := g.Config.StackSpam
+	if rules.Limit <= 0 {
+		return
+	}
+	record, exists := g.stackSpam[playerID]
+	if !exists {
+		record = &stackSpamRecord{}
+		g.stackSpam[playerID] = record
+	}
+
+	now := time.Now()
+	window := time.Duration(rules.WindowSeconds) * time.Second
+	recent := record.failures[:0]
+	for _, at := range record.failures {
+		if now.Sub(at) < window {
+			recent = append(recent, at)
+		}
+	}
+	record.failures = append(recent, now)
+	if len(record.failures) < rules.Limit {
+		return
+	}
+
+	record.failures = nil
+	record.strikes++
+	record.cooldownUntil = now.Add(time.Duration(rules.CooldownSeconds*record.strikes) * time.Second)
+	points := 0
+	if rules.PenaltyAfter > 0 && record.strikes >= rules.PenaltyAfter {
+		points = rules.PenaltyPoints
+		record.penalty += points
+	}
+
+	g.broadcast(Message{
+		Type: protocol.MsgStackPenalty,
+		Payload: map[string]interface{}{
+			"playerID":      playerID,
+			"playerName":    g.displayName(playerID),
+			"strikes":       record.strikes,
+			"cooldownUntil": record.cooldownUntil.UnixMilli(),
+			"points":        points,
+		},
+	})
+}
+
+// applyStackPenalties adds this round's stack spam points to the scores and starts the
+// next round with a clean count. Strikes carry over. Caller must hold g.mu.
+func (g *Game) applyStackPenalties() {
+	for id, record := range g.stackSpam {
+		if player, seated := g.Players[id]; seated {
+			player.Score += record.penalty
+		}
+		record.penalty = 0
+		record.failures = nil
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// setUpFailingStack puts a 5 on the discard pile, stackable, and a 6 in every player's first slot
+func setUpFailingStack(game *Game) {
+	game.DiscardPile = append(game.DiscardPile, Card{Suit: "hearts", Rank: "5", FaceUp: true})
+	game.StackableCardIndex = len(game.DiscardPile) - 1
+	for _, player := range game.Players {
+		player.Cards[0] = Card{Suit: "clubs", Rank: "6"}
+	}
+}
+
+func TestStackSpamCooldown(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+	setUpFailingStack(game)
+	spammer := playerIDs[1]
+
+	for i := 0; i < defaultStackSpamRules.Limit; i++ {
+		if err := game.StackCard(spammer, 0); !errors.Is(err, ErrCardMismatch) {
+			t.Fatalf("Attempt %d: expected ErrCardMismatch, got %v", i+1, err)
+		}
+	}
+	handSize := len(game.Players[spammer].Cards)
+	if err := game.StackCard(spammer, 0); !errors.Is(err, ErrStackCooldown) {
+		t.Fatalf("Expected ErrStackCooldown, got %v", err)
+	}
+	if err := game.StackOpponentCard(spammer, playerIDs[0], 0); !errors.Is(err, ErrStackCooldown) {
+		t.Errorf("Cooldown should cover stacking opponents' cards too, got %v", err)
+	}
+	if len(game.Players[spammer].Cards) != handSize {
+		t.Error("Attempts on cooldown should not draw penalty cards")
+	}
+	if err := game.StackCard(playerIDs[0], 0); !errors.Is(err, ErrCardMismatch) {
+		t.Errorf("Other players should not be on cooldown, got %v", err)
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if countOfType(recorder.players[playerIDs[0]], "stackPenalty") != 1 {
+		t.Error("The table should be told about the strike")
+	}
+}
+
+func TestStackSpamPointPenalty(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+	setUpFailingStack(game)
+	spammer := playerIDs[1]
+
+	strike := func() {
+		for i := 0; i < defaultStackSpamRules.Limit; i++ {
+			game.StackCard(spammer, 0)
+		}
+		game.stackSpam[spammer].cooldownUntil = time.Time{}
+	}
+	strike()
+	if penalty := game.stackSpam[spammer].penalty; penalty != 0 {
+		t.Errorf("The first strike should only cost a cooldown, got %d points", penalty)
+	}
+	strike()
+	if penalty := game.stackSpam[spammer].penalty; penalty != defaultStackSpamRules.PenaltyPoints {
+		t.Errorf("Expected %d penalty points, got %d", defaultStackSpamRules.PenaltyPoints, penalty)
+	}
+
+	hand := 0
+	for _, card := range game.Players[spammer].Cards {
+		if card.Rank != "" {
+			hand += getCardValue(card)
+		}
+	}
+	game.EndRound()
+	if score := game.Players[spammer].Score; score != hand+defaultStackSpamRules.PenaltyPoints {
+		t.Errorf("Expected score %d, got %d", hand+defaultStackSpamRules.PenaltyPoints, score)
+	}
+	if game.stackSpam[spammer].penalty != 0 || game.stackSpam[spammer].strikes != 2 {
+		t.Error("Penalty points should reset for the next round while strikes carry over")
+	}
+}
+
+func TestStackSpamDisabled(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.Config.StackSpam = StackSpamRules{}
+	game.StartGame()
+	setUpFailingStack(game)
+
+	for i := 0; i < 10; i++ {
+		if err := game.StackCard(playerIDs[0], 0); !errors.Is(err, ErrCardMismatch) {
+			t.Fatalf("Attempt %d: expected ErrCardMismatch with detection off, got %v", i+1, err)
+		}
+	}
+}
+
+func TestDecodeStackSpamRules(t *testing.T) {
+	config, err := decodeGameConfig(map[string]interface{}{"stackSpam": map[string]interface{}{"limit": 5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.StackSpam.Limit != 5 || config.StackSpam.CooldownSeconds != defaultStackSpamRules.CooldownSeconds {
+		t.Errorf("Expected limit 5 with the other defaults kept, got %+v", config.StackSpam)
+	}
+	if _, err := decodeGameConfig(map[string]interface{}{"stackSpam": map[string]interface{}{"penaltyPoints": -1}}); err == nil {
+		t.Error("Negative settings should be rejected")
+	}
+}
+
+func TestFailedStackPenaltyCards(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.Config.FailedStack.PenaltyCards = 2
+	game.StartGame()
+	setUpFailingStack(game)
+
+	handSize := len(game.Players[playerIDs[1]].Cards)
+	game.StackCard(playerIDs[1], 0)
+	if got := len(game.Players[playerIDs[1]].Cards); got != handSize+2 {
+		t.Errorf("Expected two penalty cards, hand went from %d to %d", handSize, got)
+	}
+}
+
+func TestFailedStackSitsOutNextCard(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.Config.StackSpam.Limit = 0
+	game.Config.FailedStack.SkipNextStack = true
+	game.StartGame()
+	setUpFailingStack(game)
+	stacker := playerIDs[1]
+
+	game.StackCard(stacker, 0)
+	if err := game.StackCard(stacker, 0); !errors.Is(err, ErrStackSitOut) {
+		t.Fatalf("Expected ErrStackSitOut on the same card, got %v", err)
+	}
+	if err := game.StackCard(playerIDs[0], 0); !errors.Is(err, ErrCardMismatch) {
+		t.Errorf("Other players should still stack, got %v", err)
+	}
+
+	// The next card discarded is sat out too
+	game.DiscardPile = append(game.DiscardPile, Card{Suit: "spades", Rank: "6", FaceUp: true})
+	game.markStackable()
+	if err := game.StackOpponentCard(stacker, playerIDs[0], 0); !errors.Is(err, ErrStackSitOut) {
+		t.Fatalf("Expected ErrStackSitOut on the next card, got %v", err)
+	}
+
+	// And the one after that is fair game again
+	game.DiscardPile = append(game.DiscardPile, Card{Suit: "diamonds", Rank: "6", FaceUp: true})
+	game.markStackable()
+	if err := game.StackCard(stacker, 0); err != nil {
+		t.Errorf("Stacking should be allowed again, got %v", err)
+	}
+}
+
+func TestFailedStackRulesValidation(t *testing.T) {
+	for _, cards := range []float64{-1, failedStackMaxCards + 1} {
+		raw := map[string]interface{}{"failedStack": map[string]interface{}{"penaltyCards": cards}}
+		if _, err := decodeGameConfig(raw); err == nil {
+			t.Errorf("%v penalty cards should be refused", cards)
+		}
+	}
+	if _, err := decodeGameConfig(map[string]interface{}{"failedStack": map[string]interface{}{"penaltyCards": 2, "skipNextStack": true}}); err != nil {
+		t.Errorf("Expected a valid config, got %v", err)
+	}
+}
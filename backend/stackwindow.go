@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"pablo/protocol"
+)
+
+// Stacking is meant to be a race for the card just thrown, not something a player can do
+// whenever they get round to it. At tables with a stack window, a discard is open to
+// stacking for StackWindowMs of play; then the server closes it and tells the table, and
+// the card stays on the pile like any other. The clock stops while the game is paused,
+// since nobody can stack then. Tutorials and puzzles leave the card open, since their
+// players are still working out what to do with it.
+
+const (
+	// A window shorter than this can't be raced over a slow connection, and one longer than
+	// this isn't a race
+	minStackWindowMs = 1000
+	maxStackWindowMs = 30 * 1000
+
+	// defaultStackWindowMs is the window new tables start with
+	defaultStackWindowMs = 3000
+)
+
+var errInvalidStackWindow = errors.New("stack window must be 0 or between 1000 and 30000 ms")
+
+// stackWindow is the clock on one chance to stack
+type stackWindow struct {
+	chance   int // The stackChances it closes
+	deadline time.Time
+	checked  time.Time // When the clock last looked at the game
+}
+
+// startStackWindow puts the clock on the chance to stack markStackable just opened, if the
+// table has a stack window. Caller must hold g.mu.
+func (g *Game) startStackWindow() {
+	if g.Config.StackWindowMs == 0 || g.Tutorial != nil || g.Puzzle != nil {
+		g.stackWindow = nil
+		return
+	}
+	d := time.Duration(g.Config.StackWindowMs) * time.Millisecond
+	now := time.Now()
+	window := &stackWindow{chance: g.stackChances, deadline: now.Add(d), checked: now}
+	g.stackWindow = window
+	go g.runStackWindow(window, d)
+}
+
+// resumeStackWindow gives the open chance to stack, after a pause, the time it had left
+// when the game paused. Caller must hold g.mu.
+func (g *Game) resumeStackWindow() {
+	if window := g.stackWindow; window != nil {
+		now := time.Now()
+		window.deadline = window.deadline.Add(now.Sub(window.checked))
+		window.checked = now
+	}
+}
+
+// runStackWindow closes window's chance to stack once its time is up, unless the card was
+// stacked on or the pile moved on first. It first looks after d.
+func (g *Game) runStackWindow(window *stackWindow, d time.Duration) {
+	for {
+		select {
+		case <-g.ctx.Done():
+			return
+		case <-time.After(d):
+		}
+
+		g.mu.Lock()
+		if g.stackWindow != window {
+			g.mu.Unlock()
+			return
+		}
+		now := time.Now()
+		if g.Status == protocol.StatusPaused {
+			window.deadline = window.deadline.Add(now.Sub(window.checked))
+		}
+		window.checked = now
+		if !now.Before(window.deadline) {
+			g.closeStackWindow(window)
+			g.mu.Unlock()
+			return
+		}
+		d = min(window.deadline.Sub(now), turnTimerTick)
+		g.mu.Unlock()
+	}
+}
+
+// closeStackWindow ends window's chance to stack and tells the table. Caller must hold g.mu.
+func (g *Game) closeStackWindow(window *stackWindow) {
+	g.stackWindow = nil
+	if g.stackChances != window.chance || len(g.DiscardPile) == 0 || g.StackableCardIndex != len(g.DiscardPile)-1 {
+		return
+	}
+	g.StackableCardIndex = -1
+	closed := Message{
+		Type:    protocol.MsgStackWindowClosed,
+		Payload: map[string]interface{}{"discardIndex": len(g.DiscardPile) - 1},
+	}
+	g.broadcast(closed)
+	g.broadcastToSpectators(closed)
+	g.broadcastGameState()
+}
+
+// stackWindowDeadline is when the top discard stops being open to stacking, or zero if it
+// isn't open or stays open until the next move. Caller must hold g.mu.
+func (g *Game) stackWindowDeadline() time.Time {
+	if g.stackWindow == nil || g.stackWindow.chance != g.stackChances || len(g.DiscardPile) == 0 || g.StackableCardIndex != len(g.DiscardPile)-1 {
+		return time.Time{}
+	}
+	return g.stackWindow.deadline
+}
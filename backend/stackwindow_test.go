@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"pablo/protocol"
+)
+
+// openDiscard throws a 5 and opens it to stacking, returning the clock put on it
+func openDiscard(game *Game) *stackWindow {
+	game.mu.Lock()
+	defer game.mu.Unlock()
+	game.DiscardPile = append(game.DiscardPile, Card{Suit: "hearts", Rank: "5", FaceUp: true})
+	game.markStackable()
+	return game.stackWindow
+}
+
+func TestStackWindowCloses(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+	setHand(game, playerIDs[1], "5", "9", "9", "9")
+
+	window := openDiscard(game)
+	if window == nil {
+		t.Fatal("Expected a clock on the discard by default")
+	}
+	if deadline := game.getGameStateForPlayer(playerIDs[0]).Deadlines.StackWindow; !deadline.Equal(window.deadline) {
+		t.Errorf("Expected the window's deadline in the state, got %v", deadline)
+	}
+	game.mu.Lock()
+	window.deadline = time.Now()
+	game.mu.Unlock()
+	go game.runStackWindow(window, 0)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		game.mu.Lock()
+		open := game.StackableCardIndex >= 0
+		game.mu.Unlock()
+		if !open {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the window to close")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := game.StackCard(playerIDs[1], 0); !errors.Is(err, ErrNotStackable) {
+		t.Errorf("Expected a stack after the window closed to be refused, got %v", err)
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	for _, id := range playerIDs {
+		if countOfType(recorder.players[id], protocol.MsgStackWindowClosed) != 1 {
+			t.Errorf("Expected %s to be told the window closed", id)
+		}
+	}
+}
+
+func TestStackWindowLeavesLaterDiscardOpen(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+
+	first := openDiscard(game)
+	openDiscard(game)
+	game.runStackWindow(first, 0)
+
+	if game.StackableCardIndex != len(game.DiscardPile)-1 {
+		t.Error("Expected the earlier card's clock to leave the new discard open")
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if countOfType(recorder.players[playerIDs[0]], protocol.MsgStackWindowClosed) != 0 {
+		t.Error("Expected no stackWindowClosed for a card already covered")
+	}
+}
+
+func TestStackWindowStopsWhilePaused(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	playerIDs := addTestPlayers(game, 2)
+	game.Config.StackWindowMs = 100
+	game.StartGame()
+
+	openDiscard(game)
+	game.RequestPause(game.HostID)
+	time.Sleep(300 * time.Millisecond)
+	game.RequestResume(game.HostID)
+	game.mu.Lock()
+	open := game.StackableCardIndex >= 0
+	game.mu.Unlock()
+	if !open {
+		t.Fatal("The window should not run out while the game is paused")
+	}
+
+	if !waitForMessage(t, recorder, playerIDs[0], protocol.MsgStackWindowClosed, time.Second) {
+		t.Error("Expected the window to close once play resumed")
+	}
+}
+
+func TestNoStackWindowWhenOff(t *testing.T) {
+	game := createTestGame("test-game")
+	game.SetBroadcaster(newRecordingBroadcaster())
+	playerIDs := addTestPlayers(game, 2)
+	game.Config.StackWindowMs = 0
+	game.StartGame()
+
+	if openDiscard(game) != nil {
+		t.Error("Expected no clock on the discard")
+	}
+	if deadline := game.getGameStateForPlayer(playerIDs[0]).Deadlines.StackWindow; !deadline.IsZero() {
+		t.Errorf("Expected no stack window deadline, got %v", deadline)
+	}
+}
+
+func TestStackWindowValidation(t *testing.T) {
+	for _, window := range []int{-1, 500, maxStackWindowMs + 1} {
+		if _, err := decodeGameConfig(map[string]interface{}{"stackWindowMs": window}); err != errInvalidStackWindow {
+			t.Errorf("Window %d: expected errInvalidStackWindow, got %v", window, err)
+		}
+	}
+	for _, window := range []int{0, minStackWindowMs, maxStackWindowMs} {
+		if _, err := decodeGameConfig(map[string]interface{}{"stackWindowMs": window}); err != nil {
+			t.Errorf("Window %d: unexpected error %v", window, err)
+		}
+	}
+	if config, _ := decodeGameConfig(map[string]interface{}{}); config.StackWindowMs != defaultStackWindowMs {
+		t.Errorf("Expected the default window when none is given, got %d", config.StackWindowMs)
+	}
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// GameState is one viewer's view of the game, sent as the payload of gameState.
+// What it shows depends on who is looking; see buildGameState.
+type GameState struct {
+	GameID             string
+	Players            map[string]PlayerState
+	CurrentPlayer      string
+	Status             string
+	HostID             string
+	Locked             bool
+	PabloCalled        bool
+	DeckSize           int
+	DiscardTop         *Card
+	DiscardCount       int              // Every card in the discard pile, not just the ones kept
+	DiscardHistory     []Card           // The last few discards, oldest first, ending with DiscardTop
+	DrawnCards         map[string]*Card // Only the drawn cards this viewer may see
+	PendingSpecialCard string
+	StackingEnabled    bool // The top discard can be stacked on
+	Config             GameConfig
+	Omniscient         bool // Observer feeds reveal hidden information; clients must never show this to players
+	Spectator          bool
+	PendingKingSwap    *PendingKingSwap
+	PendingGive        *PendingGive
+	ScheduledAt        time.Time // Zero unless the game is waiting for its scheduled start
+	GameOver           bool      // The mercy rule ended the game
+	TurnPhase          string    // Where the current player's turn is; empty unless a round is in play
+	DealCommitment     string    // At FairShuffle tables, the hash committing to this round's deal; see dealCommitment
+	AllowedActions     []string  // The messages this viewer may send now; nil for anyone not seated
+	ServerTime         time.Time // When the state was built, for clients to correct their clock against
+	Deadlines          Deadlines // Timed limits running for this viewer
+}
+
+// Deadlines are the moments timed limits run out, as server times. Clients count down
+// to them from ServerTime rather than their own clock, so skew and latency don't matter.
+type Deadlines struct {
+	StackCooldown time.Time // The viewer may stack again; zero when they aren't on cooldown
+	Turn          time.Time // The current turn is ended for its player; zero unless the table has a turn time limit
+	Peek          time.Time // The peeking phase ends and the first turn starts; zero unless the table is peeking
+	StackWindow   time.Time // The top discard stops being open to stacking; zero unless it is and the table has a stack window
+	Give          time.Time // The pending give is made for its player; zero unless one is pending and the table has a turn time limit
+}
+
+// timestampFormat is RFC 3339 to the millisecond, enough for a countdown
+const timestampFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// PlayerState is one seat in a GameState
+type PlayerState struct {
+	ID           string
+	Name         string
+	Cards        []CardView // Every slot, including stacked-away ones, so positions line up
+	Score        int
+	Total        int // Sum of their round scores so far this game
+	IsBot        bool
+	Waiting      bool   // Joined after the deal; watching until the next round deals them in
+	Vacant       bool   // Given up on after being away too long; turns pass it by until they come back
+	Disconnected bool   // Their connection is gone and they haven't come back yet; not set once the seat is vacant
+	HasDrawn     bool   // Has drawn this turn; the card itself is only in the drawer's DrawnCards
+	Handicap     int    // Points the host adds to their score each round; 0 for none
+	LatencyMs    int    // Round trip to the player's connection, at tables that show it; 0 if unknown
+	Note         string // The viewer's private note on this player; only ever in the author's own state
+}
+
+// CardView is a card slot as one viewer sees it
+type CardView struct {
+	Card
+	Removed    bool // The slot's card was stacked away; it isn't a face-down card
+	Hidden     bool // Observer feeds only: face down to everyone at the table
+	showHidden bool
+}
+
+// The state types write their own JSON: a gameState goes to every viewer on every change,
+// and building it by hand skips reflection and the maps the encoder would otherwise
+// walk, and keeps fields in a fixed order and with fixed types.
+
+func (c Card) MarshalJSON() ([]byte, error) {
+	return c.appendJSON(make([]byte, 0, 48)), nil
+}
+
+func (c Card) appendJSON(b []byte) []byte {
+	b = append(b, `{"suit":`...)
+	b = appendJSONString(b, c.Suit)
+	b = append(b, `,"rank":`...)
+	b = appendJSONString(b, c.Rank)
+	b = append(b, `,"faceUp":`...)
+	b = strconv.AppendBool(b, c.FaceUp)
+	if color := c.color(); color != "" {
+		b = append(b, `,"color":`...)
+		b = appendJSONString(b, color)
+	}
+	if label := c.label(); label != "" {
+		b = append(b, `,"label":`...)
+		b = appendJSONString(b, label)
+	}
+	return append(b, '}')
+}
+
+func (c CardView) MarshalJSON() ([]byte, error) {
+	return c.appendJSON(make([]byte, 0, 80)), nil
+}
+
+func (c CardView) appendJSON(b []byte) []byte {
+	b = c.Card.appendJSON(b)
+	b = b[:len(b)-1]
+	b = append(b, `,"removed":`...)
+	b = strconv.AppendBool(b, c.Removed)
+	if c.showHidden {
+		b = append(b, `,"hidden":`...)
+		b = strconv.AppendBool(b, c.Hidden)
+	}
+	return append(b, '}')
+}
+
+func (p PlayerState) MarshalJSON() ([]byte, error) {
+	return p.appendJSON(make([]byte, 0, 512)), nil
+}
+
+func (p PlayerState) appendJSON(b []byte) []byte {
+	b = append(b, `{"id":`...)
+	b = appendJSONString(b, p.ID)
+	b = append(b, `,"name":`...)
+	b = appendJSONString(b, p.Name)
+	b = append(b, `,"cards":`...)
+	if p.Cards == nil {
+		b = append(b, "null"...)
+	} else {
+		b = append(b, '[')
+		for i, card := range p.Cards {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = card.appendJSON(b)
+		}
+		b = append(b, ']')
+	}
+	b = append(b, `,"score":`...)
+	b = strconv.AppendInt(b, int64(p.Score), 10)
+	b = append(b, `,"total":`...)
+	b = strconv.AppendInt(b, int64(p.Total), 10)
+	b = append(b, `,"isBot":`...)
+	b = strconv.AppendBool(b, p.IsBot)
+	if p.Waiting {
+		b = append(b, `,"waiting":true`...)
+	}
+	if p.Vacant {
+		b = append(b, `,"vacant":true`...)
+	}
+	if p.Disconnected {
+		b = append(b, `,"disconnected":true`...)
+	}
+	if p.HasDrawn {
+		b = append(b, `,"hasDrawn":true`...)
+	}
+	if p.Handicap != 0 {
+		b = append(b, `,"handicap":`...)
+		b = strconv.AppendInt(b, int64(p.Handicap), 10)
+	}
+	if p.LatencyMs > 0 {
+		b = append(b, `,"latencyMs":`...)
+		b = strconv.AppendInt(b, int64(p.LatencyMs), 10)
+	}
+	if p.Note != "" {
+		b = append(b, `,"note":`...)
+		b = appendJSONString(b, p.Note)
+	}
+	return append(b, '}')
+}
+
+func (s *GameState) MarshalJSON() ([]byte, error) {
+	b := make([]byte, 0, 2048)
+	b = append(b, `{"gameID":`...)
+	b = appendJSONString(b, s.GameID)
+
+	b = append(b, `,"players":{`...)
+	for i, id := range sortedKeys(s.Players) {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendJSONString(b, id)
+		b = append(b, ':')
+		b = s.Players[id].appendJSON(b)
+	}
+	b = append(b, '}')
+
+	b = append(b, `,"currentPlayer":`...)
+	b = appendJSONString(b, s.CurrentPlayer)
+	b = append(b, `,"status":`...)
+	b = appendJSONString(b, s.Status)
+	b = append(b, `,"hostID":`...)
+	b = appendJSONString(b, s.HostID)
+	b = append(b, `,"locked":`...)
+	b = strconv.AppendBool(b, s.Locked)
+	b = append(b, `,"pabloCalled":`...)
+	b = strconv.AppendBool(b, s.PabloCalled)
+	b = append(b, `,"deckSize":`...)
+	b = strconv.AppendInt(b, int64(s.DeckSize), 10)
+	b = append(b, `,"discardTop":`...)
+	b = appendCard(b, s.DiscardTop)
+	b = append(b, `,"discardCount":`...)
+	b = strconv.AppendInt(b, int64(s.DiscardCount), 10)
+	b = append(b, `,"discardHistory":[`...)
+	for i, card := range s.DiscardHistory {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = card.appendJSON(b)
+	}
+	b = append(b, ']')
+
+	b = append(b, `,"drawnCards":{`...)
+	for i, id := range sortedKeys(s.DrawnCards) {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendJSONString(b, id)
+		b = append(b, ':')
+		b = appendCard(b, s.DrawnCards[id])
+	}
+	b = append(b, '}')
+
+	b = append(b, `,"pendingSpecialCard":`...)
+	b = appendJSONString(b, s.PendingSpecialCard)
+	b = append(b, `,"stackingEnabled":`...)
+	b = strconv.AppendBool(b, s.StackingEnabled)
+	// House rules change rarely and only while waiting; not worth writing by hand
+	config, err := json.Marshal(s.Config)
+	if err != nil {
+		return nil, err
+	}
+	b = append(b, `,"config":`...)
+	b = append(b, config...)
+	if s.Omniscient {
+		b = append(b, `,"omniscient":true`...)
+	}
+	if s.Spectator {
+		b = append(b, `,"spectator":true`...)
+	}
+	if s.PendingKingSwap != nil {
+		b = append(b, `,"pendingKingSwap":{"actorID":`...)
+		b = appendJSONString(b, s.PendingKingSwap.ActorID)
+		b = append(b, `,"targetPlayerID":`...)
+		b = appendJSONString(b, s.PendingKingSwap.TargetPlayerID)
+		b = append(b, `,"targetIndex":`...)
+		b = strconv.AppendInt(b, int64(s.PendingKingSwap.TargetIndex), 10)
+		b = append(b, '}')
+	}
+	if !s.ScheduledAt.IsZero() {
+		b = append(b, `,"scheduledAt":"`...)
+		b = s.ScheduledAt.UTC().AppendFormat(b, time.RFC3339)
+		b = append(b, '"')
+	}
+	if s.GameOver {
+		b = append(b, `,"gameOver":true`...)
+	}
+	if s.TurnPhase != "" {
+		b = append(b, `,"turnPhase":`...)
+		b = appendJSONString(b, s.TurnPhase)
+	}
+	if s.DealCommitment != "" {
+		b = append(b, `,"dealCommitment":`...)
+		b = appendJSONString(b, s.DealCommitment)
+	}
+	if !s.ServerTime.IsZero() {
+		b = append(b, `,"serverTime":"`...)
+		b = s.ServerTime.UTC().AppendFormat(b, timestampFormat)
+		b = append(b, '"')
+	}
+	b = append(b, `,"deadlines":{`...)
+	start := len(b)
+	b = appendDeadline(b, start, "stackCooldown", s.Deadlines.StackCooldown)
+	b = appendDeadline(b, start, "turn", s.Deadlines.Turn)
+	b = appendDeadline(b, start, "peek", s.Deadlines.Peek)
+	b = appendDeadline(b, start, "stackWindow", s.Deadlines.StackWindow)
+	b = appendDeadline(b, start, "give", s.Deadlines.Give)
+	b = append(b, '}')
+	if s.AllowedActions != nil {
+		b = append(b, `,"allowedActions":[`...)
+		for i, action := range s.AllowedActions {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendJSONString(b, action)
+		}
+		b = append(b, ']')
+	}
+	if s.PendingGive != nil {
+		b = append(b, `,"pendingGive":{"actorID":`...)
+		b = appendJSONString(b, s.PendingGive.ActorID)
+		b = append(b, `,"targetPlayerID":`...)
+		b = appendJSONString(b, s.PendingGive.TargetPlayerID)
+		b = append(b, `,"targetIndex":`...)
+		b = strconv.AppendInt(b, int64(s.PendingGive.TargetIndex), 10)
+		b = append(b, '}')
+	}
+	return append(b, '}'), nil
+}
+
+func appendCard(b []byte, card *Card) []byte {
+	if card == nil {
+		return append(b, "null"...)
+	}
+	return card.appendJSON(b)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// appendDeadline appends a deadline to the object whose fields begin at start, unless it's zero
+func appendDeadline(b []byte, start int, name string, at time.Time) []byte {
+	if at.IsZero() {
+		return b
+	}
+	if len(b) > start {
+		b = append(b, ',')
+	}
+	b = append(b, '"')
+	b = append(b, name...)
+	b = append(b, `":"`...)
+	b = at.UTC().AppendFormat(b, timestampFormat)
+	return append(b, '"')
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends s as a JSON string, escaped the way encoding/json does it
+func appendJSONString(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		// Valid JSON, but they break JavaScript string literals
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hexDigits[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestAppendJSONStringMatchesEncodingJSON(t *testing.T) {
+	for _, s := range []string{
+		"", "Alice", `quote " and \ slash`, "<script>&</script>", "tab\tnew\nline\r\x00\x1f",
+		"héllo wörld 🎴", "bad \xff utf8", "line\u2028para\u2029sep",
+	} {
+		want, _ := json.Marshal(s)
+		if got := appendJSONString(nil, s); string(got) != string(want) {
+			t.Errorf("For %q expected %s, got %s", s, want, got)
+		}
+	}
+}
+
+func TestCardJSONMatchesStructTags(t *testing.T) {
+	// The shape Card would have with plain struct tags
+	type plainCard struct {
+		Suit   string `json:"suit"`
+		Rank   string `json:"rank"`
+		FaceUp bool   `json:"faceUp"`
+		Color  string `json:"color,omitempty"`
+		Label  string `json:"label,omitempty"`
+	}
+	want, _ := json.Marshal([]plainCard{{"hearts", "10", true, "red", "ten of hearts"}, {"stars", "Q", false, "", "queen of stars"}, {}})
+	got, _ := json.Marshal([]Card{{Suit: "hearts", Rank: "10", FaceUp: true}, {Suit: "stars", Rank: "Q"}, {}})
+	if string(got) != string(want) {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestGameStateJSON(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.Players[playerIDs[1]].Name = `Bob "the <b>" Builder`
+	game.StartGame()
+	game.DrawCard(game.CurrentPlayer)
+
+	data, err := json.Marshal(Message{Type: "gameState", Payload: game.getGameStateForObserver()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Payload map[string]interface{} `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Not valid JSON: %v\n%s", err, data)
+	}
+	state := decoded.Payload
+	if state["gameID"] != "test-game" || state["status"] != "playing" || state["omniscient"] != true {
+		t.Errorf("Unexpected state %v", state)
+	}
+	if _, present := state["spectator"]; present {
+		t.Error("Flags that aren't set should be left out")
+	}
+	if state["deckSize"] != float64(len(game.Deck)) {
+		t.Errorf("Expected deckSize %d, got %v", len(game.Deck), state["deckSize"])
+	}
+	bob := state["players"].(map[string]interface{})[playerIDs[1]].(map[string]interface{})
+	if bob["name"] != game.Players[playerIDs[1]].Name {
+		t.Errorf("Name didn't survive the round trip: %v", bob["name"])
+	}
+	card := bob["cards"].([]interface{})[0].(map[string]interface{})
+	if card["rank"] != game.Players[playerIDs[1]].Cards[0].Rank || card["hidden"] != true || card["removed"] != false {
+		t.Errorf("Unexpected card %v", card)
+	}
+	drawn := state["drawnCards"].(map[string]interface{})
+	if drawn[game.CurrentPlayer] == nil {
+		t.Error("Observer should see the drawn card")
+	}
+	if _, ok := state["config"].(map[string]interface{})["stackSpam"]; !ok {
+		t.Error("Config should be included")
+	}
+}
+
+func TestGameStateTimestamps(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+	until := time.Now().Add(30 * time.Second)
+	game.stackSpam[playerIDs[0]] = &stackSpamRecord{cooldownUntil: until}
+
+	decode := func(state *GameState) map[string]interface{} {
+		data, err := json.Marshal(state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded map[string]interface{}
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Not valid JSON: %v\n%s", err, data)
+		}
+		return decoded
+	}
+
+	state := decode(game.getGameStateForPlayer(playerIDs[0]))
+	serverTime, err := time.Parse(time.RFC3339, state["serverTime"].(string))
+	if err != nil || time.Since(serverTime) > time.Minute {
+		t.Errorf("Expected a current serverTime, got %v", state["serverTime"])
+	}
+	deadlines := state["deadlines"].(map[string]interface{})
+	cooldown, err := time.Parse(time.RFC3339, deadlines["stackCooldown"].(string))
+	if err != nil || cooldown.Sub(until).Abs() > time.Millisecond {
+		t.Errorf("Expected the stack cooldown deadline %v, got %v", until, deadlines["stackCooldown"])
+	}
+
+	if deadlines := decode(game.getGameStateForPlayer(playerIDs[1]))["deadlines"].(map[string]interface{}); len(deadlines) != 0 {
+		t.Errorf("Another player's cooldown shouldn't show, got %v", deadlines)
+	}
+}
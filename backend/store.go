@@ -0,0 +1,602 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GameStore keeps saved games and their events. The GameManager's map holds the games being
+// played, connections and all, in front of its stores: what goes into a store is a game's
+// snapshot, when it is adjourned, hibernated or checkpointed, and a game missing from the
+// map is looked up in the stores and loaded back, maybe by another process. Each store
+// holds one namespace of games, so the adjourned and the hibernated can share a backend.
+//
+// PABLO_STORE picks the backend: "file" (the default), "memory", "redis" or "sql".
+type GameStore interface {
+	// LoadGame returns a saved game, or an error wrapping os.ErrNotExist if there is none
+	LoadGame(ctx context.Context, gameID string) (*gameSnapshot, error)
+	// SaveGame saves snap under its ID, replacing what was saved before
+	SaveGame(ctx context.Context, snap *gameSnapshot) error
+	// ListGames returns the IDs of the saved games, sorted
+	ListGames(ctx context.Context) ([]string, error)
+	// DeleteGame removes a saved game; removing one that isn't there is not an error
+	DeleteGame(ctx context.Context, gameID string) error
+	// AppendEvents adds events to the end of a game's event history. The history is kept
+	// apart from the saved game, and stays when the game is deleted.
+	AppendEvents(ctx context.Context, gameID string, events []GameEvent) error
+	// RewriteEvents passes each event in a game's history through rewrite, keeping what it
+	// returns in the event's place, or deleting the event if it returns false
+	RewriteEvents(ctx context.Context, gameID string, rewrite func(GameEvent) (GameEvent, bool)) error
+}
+
+var errGameNotStored = fmt.Errorf("game not stored: %w", os.ErrNotExist)
+
+// memoryGameStore keeps games in a map, encoded, so a loaded game shares nothing with the
+// saved one. It's lost on restart, so it suits tests and deployments that don't need saved
+// games to outlive the process.
+type memoryGameStore struct {
+	games  map[string][]byte
+	events map[string][]GameEvent
+	mu     sync.Mutex
+}
+
+func newMemoryGameStore() *memoryGameStore {
+	return &memoryGameStore{games: make(map[string][]byte), events: make(map[string][]GameEvent)}
+}
+
+func (s *memoryGameStore) LoadGame(ctx context.Context, gameID string) (*gameSnapshot, error) {
+	s.mu.Lock()
+	data, exists := s.games[gameID]
+	s.mu.Unlock()
+	if !exists {
+		return nil, errGameNotStored
+	}
+	var snap gameSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+func (s *memoryGameStore) SaveGame(ctx context.Context, snap *gameSnapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.games[snap.ID] = data
+	return nil
+}
+
+func (s *memoryGameStore) ListGames(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]string, 0, len(s.games))
+	for id := range s.games {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (s *memoryGameStore) DeleteGame(ctx context.Context, gameID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.games, gameID)
+	return nil
+}
+
+func (s *memoryGameStore) AppendEvents(ctx context.Context, gameID string, events []GameEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events[gameID] = append(s.events[gameID], events...)
+	return nil
+}
+
+func (s *memoryGameStore) RewriteEvents(ctx context.Context, gameID string, rewrite func(GameEvent) (GameEvent, bool)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var kept []GameEvent
+	for _, event := range s.events[gameID] {
+		if rewritten, keep := rewrite(event); keep {
+			kept = append(kept, rewritten)
+		}
+	}
+	s.events[gameID] = kept
+	return nil
+}
+
+// fileGameStore keeps each game as a JSON file in a directory, and its events as a
+// JSON-lines file next to it
+type fileGameStore struct {
+	dir string
+	mu  sync.Mutex // Serializes appends to event files
+}
+
+func newFileGameStore(dir string) *fileGameStore {
+	return &fileGameStore{dir: dir}
+}
+
+func (s *fileGameStore) path(gameID, suffix string) string {
+	// Game IDs come from clients; keep them from escaping the directory
+	return filepath.Join(s.dir, hex.EncodeToString([]byte(gameID))+suffix)
+}
+
+func (s *fileGameStore) LoadGame(ctx context.Context, gameID string) (*gameSnapshot, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(s.path(gameID, ".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errGameNotStored
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snap gameSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+func (s *fileGameStore) SaveGame(ctx context.Context, snap *gameSnapshot) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	tmp := s.path(snap.ID, ".json.tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path(snap.ID, ".json"))
+}
+
+func (s *fileGameStore) ListGames(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, entry := range entries {
+		name, isGame := strings.CutSuffix(entry.Name(), ".json")
+		if !isGame {
+			continue
+		}
+		if id, err := hex.DecodeString(name); err == nil {
+			ids = append(ids, string(id))
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (s *fileGameStore) DeleteGame(ctx context.Context, gameID string) error {
+	err := os.Remove(s.path(gameID, ".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *fileGameStore) AppendEvents(ctx context.Context, gameID string, events []GameEvent) error {
+	var lines []byte
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		lines = append(append(lines, data...), '\n')
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(s.path(gameID, ".events.jsonl"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(lines)
+	return err
+}
+
+func (s *fileGameStore) RewriteEvents(ctx context.Context, gameID string, rewrite func(GameEvent) (GameEvent, bool)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := s.path(gameID, ".events.jsonl")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var lines []byte
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		var event GameEvent
+		if len(line) == 0 || json.Unmarshal(line, &event) != nil {
+			continue
+		}
+		rewritten, keep := rewrite(event)
+		if !keep {
+			continue
+		}
+		if line, err = json.Marshal(rewritten); err != nil {
+			return err
+		}
+		lines = append(append(lines, line...), '\n')
+	}
+	tmp := s.path(gameID, ".events.jsonl.tmp")
+	if err := os.WriteFile(tmp, lines, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// redisGameStore keeps games in Redis: each game as a string under
+// "pablo:store:<namespace>:<id>", the namespace's IDs in the set "pablo:store:<namespace>",
+// and each game's events in the list "pablo:events:<id>"
+type redisGameStore struct {
+	client    *redisClient
+	namespace string
+}
+
+func (s *redisGameStore) key(gameID string) string {
+	return "pablo:store:" + s.namespace + ":" + gameID
+}
+
+func (s *redisGameStore) LoadGame(ctx context.Context, gameID string) (*gameSnapshot, error) {
+	data, err := s.client.do("GET", s.key(gameID))
+	if err != nil {
+		return nil, err
+	}
+	if data == "" {
+		return nil, errGameNotStored
+	}
+	var snap gameSnapshot
+	if err := json.Unmarshal([]byte(data), &snap); err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+func (s *redisGameStore) SaveGame(ctx context.Context, snap *gameSnapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	if _, err := s.client.do("SET", s.key(snap.ID), string(data)); err != nil {
+		return err
+	}
+	_, err = s.client.do("SADD", "pablo:store:"+s.namespace, snap.ID)
+	return err
+}
+
+func (s *redisGameStore) ListGames(ctx context.Context) ([]string, error) {
+	ids, err := s.client.doList("SMEMBERS", "pablo:store:"+s.namespace)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (s *redisGameStore) DeleteGame(ctx context.Context, gameID string) error {
+	if _, err := s.client.do("DEL", s.key(gameID)); err != nil {
+		return err
+	}
+	_, err := s.client.do("SREM", "pablo:store:"+s.namespace, gameID)
+	return err
+}
+
+func (s *redisGameStore) AppendEvents(ctx context.Context, gameID string, events []GameEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	args := []string{"RPUSH", "pablo:events:" + gameID}
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		args = append(args, string(data))
+	}
+	_, err := s.client.do(args...)
+	return err
+}
+
+// RewriteEvents changes the events in place, so events appended meanwhile are kept: each
+// rewritten event is set at its index, and deleted ones are marked and then removed together
+func (s *redisGameStore) RewriteEvents(ctx context.Context, gameID string, rewrite func(GameEvent) (GameEvent, bool)) error {
+	key := "pablo:events:" + gameID
+	stored, err := s.client.doList("LRANGE", key, "0", "-1")
+	if err != nil {
+		return err
+	}
+	deleted := "pablo:deleted:" + newUUID()
+	anyDeleted := false
+	for i, data := range stored {
+		var event GameEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			continue
+		}
+		replacement := deleted
+		if rewritten, keep := rewrite(event); keep {
+			encoded, err := json.Marshal(rewritten)
+			if err != nil {
+				return err
+			}
+			replacement = string(encoded)
+		} else {
+			anyDeleted = true
+		}
+		if replacement == data {
+			continue
+		}
+		if _, err := s.client.do("LSET", key, strconv.Itoa(i), replacement); err != nil {
+			return err
+		}
+	}
+	if !anyDeleted {
+		return nil
+	}
+	_, err = s.client.do("LREM", key, "0", deleted)
+	return err
+}
+
+// sqlGameStore keeps games in a SQL database through database/sql, in two tables it creates
+// if they're missing. It is written for PostgreSQL. The server links in no drivers itself:
+// the binary has to be built with one registered under the name PABLO_STORE_DRIVER gives,
+// by a blank import of it in a file of its own.
+type sqlGameStore struct {
+	db        *sql.DB
+	namespace string
+	numbered  bool // The driver wants $1, $2, ... rather than ? placeholders
+}
+
+const sqlGameStoreSchema = `
+CREATE TABLE IF NOT EXISTS pablo_games (
+	namespace  VARCHAR(32)  NOT NULL,
+	id         VARCHAR(128) NOT NULL,
+	data       TEXT         NOT NULL,
+	updated_at TIMESTAMP    NOT NULL,
+	PRIMARY KEY (namespace, id)
+);
+CREATE TABLE IF NOT EXISTS pablo_game_events (
+	game_id    VARCHAR(128) NOT NULL,
+	id         VARCHAR(64)  NOT NULL,
+	data       TEXT         NOT NULL,
+	created_at TIMESTAMP    NOT NULL
+)`
+
+// openSQLGameDB opens the database and creates the tables
+func openSQLGameDB(ctx context.Context, driver, dsn string) (*sql.DB, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	for _, statement := range strings.Split(sqlGameStoreSchema, ";") {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// query rewrites ? placeholders for drivers that number them
+func (s *sqlGameStore) query(q string) string {
+	if !s.numbered {
+		return q
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (s *sqlGameStore) LoadGame(ctx context.Context, gameID string) (*gameSnapshot, error) {
+	var data string
+	err := s.db.QueryRowContext(ctx, s.query(`SELECT data FROM pablo_games WHERE namespace = ? AND id = ?`), s.namespace, gameID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errGameNotStored
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snap gameSnapshot
+	if err := json.Unmarshal([]byte(data), &snap); err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+func (s *sqlGameStore) SaveGame(ctx context.Context, snap *gameSnapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, s.query(`INSERT INTO pablo_games (namespace, id, data, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (namespace, id) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at`),
+		s.namespace, snap.ID, string(data), time.Now().UTC())
+	return err
+}
+
+func (s *sqlGameStore) ListGames(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, s.query(`SELECT id FROM pablo_games WHERE namespace = ? ORDER BY id`), s.namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (s *sqlGameStore) DeleteGame(ctx context.Context, gameID string) error {
+	_, err := s.db.ExecContext(ctx, s.query(`DELETE FROM pablo_games WHERE namespace = ? AND id = ?`), s.namespace, gameID)
+	return err
+}
+
+func (s *sqlGameStore) AppendEvents(ctx context.Context, gameID string, events []GameEvent) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, s.query(`INSERT INTO pablo_game_events (game_id, id, data, created_at) VALUES (?, ?, ?, ?)`),
+			gameID, event.ID, string(data), event.Time.UTC()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RewriteEvents updates or deletes each event by its ID, in one transaction
+func (s *sqlGameStore) RewriteEvents(ctx context.Context, gameID string, rewrite func(GameEvent) (GameEvent, bool)) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	rows, err := tx.QueryContext(ctx, s.query(`SELECT id, data FROM pablo_game_events WHERE game_id = ?`), gameID)
+	if err != nil {
+		return err
+	}
+	stored := make(map[string]string)
+	for rows.Next() {
+		var id, data string
+		if err := rows.Scan(&id, &data); err != nil {
+			rows.Close()
+			return err
+		}
+		stored[id] = data
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, data := range stored {
+		var event GameEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			continue
+		}
+		rewritten, keep := rewrite(event)
+		if !keep {
+			_, err = tx.ExecContext(ctx, s.query(`DELETE FROM pablo_game_events WHERE game_id = ? AND id = ?`), gameID, id)
+		} else if encoded, encodeErr := json.Marshal(rewritten); encodeErr != nil {
+			return encodeErr
+		} else if string(encoded) != data {
+			_, err = tx.ExecContext(ctx, s.query(`UPDATE pablo_game_events SET data = ? WHERE game_id = ? AND id = ?`), string(encoded), gameID, id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// gameStoreBackend is where the configured backend's stores live. Each namespace gets its
+// own store; for files, its own directory.
+type gameStoreBackend struct {
+	kind   string
+	redis  *redisClient
+	db     *sql.DB
+	driver string
+}
+
+// openGameStoreBackend sets up a backend: "file" or "" for files, "memory", "redis" with a
+// redis:// URL, or "sql" with a driver name and data source name
+func openGameStoreBackend(ctx context.Context, kind, rawURL, driver string) (*gameStoreBackend, error) {
+	backend := &gameStoreBackend{kind: kind, driver: driver}
+	var err error
+	switch kind {
+	case "", "file", "memory":
+	case "redis":
+		backend.redis, err = newRedisClient(rawURL)
+	case "sql":
+		backend.db, err = openSQLGameDB(ctx, driver, rawURL)
+	default:
+		err = fmt.Errorf("unknown game store %q", kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return backend, nil
+}
+
+// store returns the backend's store for namespace; dir is where a file store keeps it
+func (b *gameStoreBackend) store(namespace, dir string) GameStore {
+	switch b.kind {
+	case "memory":
+		return newMemoryGameStore()
+	case "redis":
+		return &redisGameStore{client: b.redis, namespace: namespace}
+	case "sql":
+		return &sqlGameStore{db: b.db, namespace: namespace, numbered: b.driver == "postgres" || b.driver == "pgx"}
+	default:
+		return newFileGameStore(dir)
+	}
+}
+
+// gameEvents is the store every game event is appended to, when one is configured
+var gameEvents GameStore
+
+// storeEventPublisher appends every game event to its game's history in a store
+type storeEventPublisher struct {
+	store GameStore
+}
+
+func (p storeEventPublisher) Publish(event GameEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return p.store.AppendEvents(ctx, event.GameID, []GameEvent{event})
+}
+
+func (p storeEventPublisher) Close() error {
+	return nil
+}
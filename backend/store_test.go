@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testGameStore runs the GameStore contract against store
+func testGameStore(t *testing.T, store GameStore) {
+	ctx := context.Background()
+	if _, err := store.LoadGame(ctx, "missing"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a missing game to be os.ErrNotExist, got %v", err)
+	}
+
+	game := createTestGame("game/../b")
+	addTestPlayers(game, 2)
+	game.StartGame()
+	snap := game.snapshot()
+	if err := store.SaveGame(ctx, snap); err != nil {
+		t.Fatal(err)
+	}
+	snap.Status = "paused"
+	if err := store.SaveGame(ctx, snap); err != nil {
+		t.Fatal(err)
+	}
+	store.SaveGame(ctx, &gameSnapshot{ID: "a"})
+	loaded, err := store.LoadGame(ctx, "game/../b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Status != "paused" || !reflect.DeepEqual(loaded.SeatOrder, snap.SeatOrder) || len(loaded.Deck) != len(snap.Deck) {
+		t.Errorf("Expected the latest save back, got %+v", loaded)
+	}
+	if ids, err := store.ListGames(ctx); err != nil || !reflect.DeepEqual(ids, []string{"a", "game/../b"}) {
+		t.Errorf("Unexpected list %v, %v", ids, err)
+	}
+
+	events := []GameEvent{{ID: "1", Type: "turnStarted", GameID: "a", Time: time.Now()}, {ID: "2", Type: "roundEnded", GameID: "a", Time: time.Now()}}
+	if err := store.AppendEvents(ctx, "a", events); err != nil {
+		t.Fatal(err)
+	}
+	// The first event is deleted and the second kept, changed
+	rewrite := func(event GameEvent) (GameEvent, bool) {
+		event.PlayerID = "rewritten"
+		return event, event.ID != "1"
+	}
+	if err := store.RewriteEvents(ctx, "a", rewrite); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RewriteEvents(ctx, "missing", rewrite); err != nil {
+		t.Errorf("Rewriting a game with no events shouldn't fail, got %v", err)
+	}
+
+	if err := store.DeleteGame(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DeleteGame(ctx, "a"); err != nil {
+		t.Errorf("Deleting twice shouldn't fail, got %v", err)
+	}
+	if ids, _ := store.ListGames(ctx); !reflect.DeepEqual(ids, []string{"game/../b"}) {
+		t.Errorf("Expected the deleted game gone from the list, got %v", ids)
+	}
+}
+
+func TestMemoryGameStore(t *testing.T) {
+	store := newMemoryGameStore()
+	testGameStore(t, store)
+	if events := store.events["a"]; len(events) != 1 || events[0].ID != "2" || events[0].PlayerID != "rewritten" {
+		t.Errorf("Expected the rewritten event kept after the game was deleted, got %+v", events)
+	}
+}
+
+func TestFileGameStore(t *testing.T) {
+	dir := t.TempDir()
+	testGameStore(t, newFileGameStore(dir))
+	data, err := os.ReadFile(newFileGameStore(dir).path("a", ".events.jsonl"))
+	if err != nil || strings.Count(string(data), "\n") != 1 || !strings.Contains(string(data), `"rewritten"`) {
+		t.Errorf("Expected the rewritten event on file, got %s, %v", data, err)
+	}
+}
+
+func TestRedisGameStore(t *testing.T) {
+	client, err := newRedisClient(fakeRedis(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testGameStore(t, &redisGameStore{client: client, namespace: "adjourned"})
+	if events, _ := client.doList("LRANGE", "pablo:events:a", "0", "-1"); len(events) != 1 || !strings.Contains(events[0], `"rewritten"`) {
+		t.Errorf("Expected the rewritten event in the game's list, got %v", events)
+	}
+	if ids, _ := (&redisGameStore{client: client, namespace: "hibernated"}).ListGames(context.Background()); len(ids) != 0 {
+		t.Errorf("Namespaces should keep their games apart, got %v", ids)
+	}
+}
+
+// fakeSQLDriver is a database/sql driver that understands just the statements sqlGameStore
+// runs, against tables kept in memory, so the store is tested without a database
+type fakeSQLDriver struct {
+	games  map[[2]string]string // Namespace and ID -> data
+	events []fakeSQLEvent
+	mu     sync.Mutex
+}
+
+type fakeSQLEvent struct {
+	gameID, id, data string
+}
+
+var fakeSQLDrivers atomic.Int32
+
+// fakeSQL registers a fresh fake driver and opens a store backend on it
+func fakeSQL(t *testing.T) (*gameStoreBackend, *fakeSQLDriver) {
+	t.Helper()
+	fake := &fakeSQLDriver{games: make(map[[2]string]string)}
+	name := fmt.Sprintf("pablofake%d", fakeSQLDrivers.Add(1))
+	sql.Register(name, fake)
+	backend, err := openGameStoreBackend(context.Background(), "sql", "", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { backend.db.Close() })
+	return backend, fake
+}
+
+func (d *fakeSQLDriver) Open(name string) (driver.Conn, error) {
+	return fakeSQLConn{d}, nil
+}
+
+type fakeSQLConn struct{ d *fakeSQLDriver }
+
+func (c fakeSQLConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeSQLStmt{c.d, strings.Join(strings.Fields(query), " ")}, nil
+}
+
+func (c fakeSQLConn) Close() error              { return nil }
+func (c fakeSQLConn) Begin() (driver.Tx, error) { return fakeSQLTx{}, nil }
+
+type fakeSQLTx struct{}
+
+func (fakeSQLTx) Commit() error   { return nil }
+func (fakeSQLTx) Rollback() error { return nil }
+
+type fakeSQLStmt struct {
+	d     *fakeSQLDriver
+	query string
+}
+
+func (s fakeSQLStmt) Close() error  { return nil }
+func (s fakeSQLStmt) NumInput() int { return -1 }
+
+func (s fakeSQLStmt) Exec(args []driver.Value) (driver.Result, error) {
+	_, err := s.d.run(s.query, args)
+	return driver.RowsAffected(1), err
+}
+
+func (s fakeSQLStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.d.run(s.query, args)
+}
+
+type fakeSQLRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeSQLRows) Columns() []string { return r.columns }
+func (r *fakeSQLRows) Close() error      { return nil }
+
+func (r *fakeSQLRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func (d *fakeSQLDriver) run(query string, args []driver.Value) (*fakeSQLRows, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	arg := func(i int) string {
+		s, _ := args[i].(string)
+		return s
+	}
+	rows := &fakeSQLRows{}
+	switch {
+	case strings.HasPrefix(query, "CREATE TABLE IF NOT EXISTS"):
+	case strings.HasPrefix(query, "SELECT data FROM pablo_games WHERE"):
+		rows.columns = []string{"data"}
+		if data, exists := d.games[[2]string{arg(0), arg(1)}]; exists {
+			rows.rows = append(rows.rows, []driver.Value{data})
+		}
+	case strings.HasPrefix(query, "INSERT INTO pablo_games ") && strings.Contains(query, "ON CONFLICT (namespace, id) DO UPDATE"):
+		d.games[[2]string{arg(0), arg(1)}] = arg(2)
+	case strings.HasPrefix(query, "SELECT id FROM pablo_games WHERE"):
+		rows.columns = []string{"id"}
+		var ids []string
+		for key := range d.games {
+			if key[0] == arg(0) {
+				ids = append(ids, key[1])
+			}
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			rows.rows = append(rows.rows, []driver.Value{id})
+		}
+	case strings.HasPrefix(query, "DELETE FROM pablo_games WHERE"):
+		delete(d.games, [2]string{arg(0), arg(1)})
+	case strings.HasPrefix(query, "INSERT INTO pablo_game_events"):
+		d.events = append(d.events, fakeSQLEvent{gameID: arg(0), id: arg(1), data: arg(2)})
+	case strings.HasPrefix(query, "SELECT id, data FROM pablo_game_events WHERE"):
+		rows.columns = []string{"id", "data"}
+		for _, event := range d.events {
+			if event.gameID == arg(0) {
+				rows.rows = append(rows.rows, []driver.Value{event.id, event.data})
+			}
+		}
+	case strings.HasPrefix(query, "UPDATE pablo_game_events SET data"):
+		for i, event := range d.events {
+			if event.gameID == arg(1) && event.id == arg(2) {
+				d.events[i].data = arg(0)
+			}
+		}
+	case strings.HasPrefix(query, "DELETE FROM pablo_game_events WHERE"):
+		kept := d.events[:0]
+		for _, event := range d.events {
+			if event.gameID != arg(0) || event.id != arg(1) {
+				kept = append(kept, event)
+			}
+		}
+		d.events = kept
+	default:
+		return nil, fmt.Errorf("unexpected query %q", query)
+	}
+	return rows, nil
+}
+
+func TestSQLGameStore(t *testing.T) {
+	backend, fake := fakeSQL(t)
+	testGameStore(t, backend.store("adjourned", ""))
+	if len(fake.events) != 1 || !strings.Contains(fake.events[0].data, `"rewritten"`) {
+		t.Errorf("Expected the rewritten event in the events table, got %+v", fake.events)
+	}
+	if ids, _ := backend.store("hibernated", "").ListGames(context.Background()); len(ids) != 0 {
+		t.Errorf("Namespaces should keep their games apart, got %v", ids)
+	}
+}
+
+func TestSQLGameStoreNeedsDriver(t *testing.T) {
+	if _, err := openGameStoreBackend(context.Background(), "sql", "", "no-such-driver"); err == nil {
+		t.Error("Expected an error for a driver that isn't linked in")
+	}
+}
+
+func TestSQLPlaceholders(t *testing.T) {
+	store := &sqlGameStore{numbered: true}
+	if got := store.query(`SELECT data FROM pablo_games WHERE namespace = ? AND id = ?`); got != `SELECT data FROM pablo_games WHERE namespace = $1 AND id = $2` {
+		t.Errorf("Unexpected query %s", got)
+	}
+}
+
+func TestPurgeExpired(t *testing.T) {
+	store := &adjournStore{games: newMemoryGameStore(), ttl: time.Hour}
+	ctx := context.Background()
+	store.Save(ctx, &gameSnapshot{ID: "old", ExpiresAt: time.Now().Add(-time.Minute)})
+	store.Save(ctx, &gameSnapshot{ID: "new", ExpiresAt: time.Now().Add(time.Hour)})
+	if purged, err := store.PurgeExpired(ctx); err != nil || purged != 1 {
+		t.Errorf("Expected the expired game purged, got %d, %v", purged, err)
+	}
+	if ids, _ := store.games.ListGames(ctx); !reflect.DeepEqual(ids, []string{"new"}) {
+		t.Errorf("Unexpected games left %v", ids)
+	}
+}
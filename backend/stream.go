@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// streamDelay is how far behind the game a streamer feed runs, so opponents watching the
+// stream can't act on it. Set from PABLO_STREAM_DELAY.
+var streamDelay = 30 * time.Second
+
+// streamFeed is the game as a streamer can safely show it. A player turns on streamer mode
+// and gets a key for an overlay (e.g. a browser source) that polls /games/{id}/stream.
+// The feed is the spectator view from the streamer's seat, so their face-down cards and
+// drawn card never appear in it; they only see those on their own client. It runs
+// streamDelay behind the game.
+type streamFeed struct {
+	keys    map[string]string // Streamer key -> the streaming player's ID
+	history []streamFrame     // Spectator states while anyone is streaming, oldest first
+	mu      sync.Mutex
+}
+
+type streamFrame struct {
+	at    time.Time
+	state *GameState
+}
+
+func newStreamFeed() *streamFeed {
+	return &streamFeed{keys: make(map[string]string)}
+}
+
+// start hands playerID a fresh key, replacing any they had. current is the state as of
+// now, so the feed has something to show once the delay has passed.
+func (f *streamFeed) start(playerID string, current *GameState) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.removeKeys(playerID)
+	key := newSessionToken()
+	f.keys[key] = playerID
+	if len(f.history) == 0 {
+		f.history = append(f.history, streamFrame{at: time.Now(), state: current})
+	}
+	return key
+}
+
+// stop ends playerID's feed
+func (f *streamFeed) stop(playerID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.removeKeys(playerID)
+	if len(f.keys) == 0 {
+		f.history = nil
+	}
+}
+
+// active reports whether anyone is streaming
+func (f *streamFeed) active() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.keys) > 0
+}
+
+// removeKeys forgets every key of playerID. Caller must hold f.mu.
+func (f *streamFeed) removeKeys(playerID string) {
+	for key, id := range f.keys {
+		if id == playerID {
+			delete(f.keys, key)
+		}
+	}
+}
+
+// record keeps a newly published spectator state while anyone is streaming, dropping
+// frames that are past the delay and no longer the newest one that is
+func (f *streamFeed) record(state *GameState) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.keys) == 0 {
+		return
+	}
+	now := time.Now()
+	f.history = append(f.history, streamFrame{at: now, state: state})
+	shown := 0
+	for i, frame := range f.history {
+		if now.Sub(frame.at) >= streamDelay {
+			shown = i
+		}
+	}
+	if shown > 0 {
+		f.history = append(f.history[:0], f.history[shown:]...)
+	}
+}
+
+// view returns who key streams as and the state to show now: the newest one at least
+// streamDelay old, or nil if streaming began more recently than that
+func (f *streamFeed) view(key string) (playerID string, state *GameState, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if playerID, ok = f.keys[key]; !ok {
+		return "", nil, false
+	}
+	now := time.Now()
+	for _, frame := range f.history {
+		if now.Sub(frame.at) < streamDelay {
+			break
+		}
+		state = frame.state
+	}
+	return playerID, state, true
+}
+
+// StartStream turns on streamer mode for a seated player and returns their feed key
+func (g *Game) StartStream(playerID string) (string, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if _, exists := g.Players[playerID]; !exists {
+		return "", false
+	}
+	return g.streams.start(playerID, g.public().state), true
+}
+
+// StopStream turns streamer mode off for a player
+func (g *Game) StopStream(playerID string) {
+	g.streams.stop(playerID)
+}
+
+// handleGameStream serves GET /games/{gameID}/stream?key=... with a streamer's delayed feed:
+//
+//	{"streamer": playerID, "delaySecs": n, "state": <spectator gameState, or null until the delay has passed>}
+func handleGameStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/games/"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "stream" {
+		http.NotFound(w, r)
+		return
+	}
+	game := gameManager.GetGame(r.Context(), parts[0])
+	if game == nil {
+		http.Error(w, "game not found", http.StatusNotFound)
+		return
+	}
+	playerID, state, ok := game.streams.view(r.URL.Query().Get("key"))
+	if !ok {
+		http.Error(w, "unknown streamer key", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Cache-Control", "no-cache")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"streamer":  playerID,
+		"delaySecs": int(streamDelay / time.Second),
+		"state":     state,
+	})
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func getStream(t *testing.T, gameID, key string) (int, map[string]json.RawMessage) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handleGames(rec, httptest.NewRequest(http.MethodGet, "/games/"+gameID+"/stream?key="+key, nil))
+	var body map[string]json.RawMessage
+	json.NewDecoder(rec.Body).Decode(&body)
+	return rec.Code, body
+}
+
+func TestStreamerFeedIsDelayed(t *testing.T) {
+	saved := streamDelay
+	streamDelay = 200 * time.Millisecond
+	defer func() { streamDelay = saved }()
+
+	game := gameManager.CreateGame(context.Background())
+	game.SetBroadcaster(newRecordingBroadcaster())
+	playerIDs := addTestPlayers(game, 2)
+	key, ok := game.StartStream(playerIDs[0])
+	if !ok || key == "" {
+		t.Fatal("A seated player should get a streamer key")
+	}
+	if _, ok := game.StartStream("nobody"); ok {
+		t.Error("Only seated players can stream")
+	}
+
+	if code, _ := getStream(t, game.ID, "wrong"); code != http.StatusForbidden {
+		t.Errorf("Expected an unknown key to be refused, got %d", code)
+	}
+	code, body := getStream(t, game.ID, key)
+	if code != http.StatusOK || string(body["state"]) != "null" || string(body["streamer"]) != `"player1"` {
+		t.Fatalf("Expected no state before the delay has passed, got %d %s", code, body)
+	}
+
+	game.StartGame()
+	game.mu.RLock()
+	drawn := game.Deck[0]
+	game.mu.RUnlock()
+	game.DrawCard(playerIDs[0])
+
+	time.Sleep(streamDelay / 2)
+	_, body = getStream(t, game.ID, key)
+	if string(body["state"]) != "null" {
+		t.Fatalf("The feed shouldn't show anything newer than the delay, got %s", body["state"])
+	}
+
+	time.Sleep(streamDelay)
+	_, body = getStream(t, game.ID, key)
+	var state struct {
+		Status     string           `json:"status"`
+		DrawnCards map[string]*Card `json:"drawnCards"`
+		Players    map[string]struct {
+			Cards []Card `json:"cards"`
+		} `json:"players"`
+	}
+	if err := json.Unmarshal(body["state"], &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.Status != "playing" {
+		t.Errorf("Expected the delayed feed to have caught up, got %q", state.Status)
+	}
+	if len(state.DrawnCards) != 0 {
+		t.Errorf("The streamer's drawn card (%+v) must not be in the feed, got %+v", drawn, state.DrawnCards)
+	}
+	for _, card := range state.Players[playerIDs[0]].Cards {
+		if card.Rank != "" && !card.FaceUp {
+			t.Errorf("The streamer's face-down cards must not be in the feed, got %+v", card)
+		}
+	}
+
+	game.StopStream(playerIDs[0])
+	if code, _ := getStream(t, game.ID, key); code != http.StatusForbidden {
+		t.Errorf("Expected the key to stop working, got %d", code)
+	}
+}
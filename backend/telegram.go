@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"pablo/protocol"
+)
+
+// telegramAPI is where Bot API calls go; the token is appended
+const telegramAPI = "https://api.telegram.org/bot"
+
+// telegramBot lets people use Pablo from Telegram: start a game and get a link to play it,
+// be told when it's their turn and see each round's results. Telegram posts what users send
+// to /telegram/webhook; game events reach the bot through the event bus like any publisher.
+type telegramBot struct {
+	apiURL  string // Bot API base including the token, e.g. https://api.telegram.org/bot<token>
+	secret  string // Telegram's X-Telegram-Bot-Api-Secret-Token; empty skips the check
+	gameURL string // Link to a seat, with {gameID} and {sessionToken}; empty sends them as text
+	client  *http.Client
+	follows map[string]map[int64]string // Game ID -> chat ID -> player the chat plays as ("" only watches)
+	mu      sync.Mutex
+}
+
+// telegram is the bot when PABLO_TELEGRAM_TOKEN is set
+var telegram *telegramBot
+
+func newTelegramBot(apiURL, secret, gameURL string) *telegramBot {
+	return &telegramBot{
+		apiURL:  strings.TrimRight(apiURL, "/"),
+		secret:  secret,
+		gameURL: gameURL,
+		client:  &http.Client{Timeout: 5 * time.Second},
+		follows: make(map[string]map[int64]string),
+	}
+}
+
+// telegramUpdate is the part of a Telegram update the bot reads
+type telegramUpdate struct {
+	Message *struct {
+		Text string `json:"text"`
+		Chat struct {
+			ID   int64  `json:"id"`
+			Type string `json:"type"` // "private", "group", ...
+		} `json:"chat"`
+		From struct {
+			FirstName string `json:"first_name"`
+		} `json:"from"`
+	} `json:"message"`
+}
+
+// handleTelegramWebhook serves POST /telegram/webhook. Replies go back in the response,
+// which Telegram runs as a sendMessage call.
+func handleTelegramWebhook(w http.ResponseWriter, r *http.Request) {
+	bot := telegram
+	if bot == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if bot.secret != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Telegram-Bot-Api-Secret-Token")), []byte(bot.secret)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var update telegramUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil || update.Message == nil {
+		// Nothing for us; answering with an error would only make Telegram retry
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	chatID := update.Message.Chat.ID
+	reply := bot.command(r.Context(), chatID, update.Message.Chat.Type == "private", update.Message.From.FirstName, update.Message.Text)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"method":  "sendMessage",
+		"chat_id": chatID,
+		"text":    reply,
+	})
+}
+
+const telegramHelp = `/newgame [name] – start a game; you get a link to your seat and are told when it's your turn
+/follow <gameID> [sessionToken] – get a game's round results, and with your session token, your turns too
+/unfollow <gameID> – stop following a game
+/stop – stop following every game`
+
+// command runs one message from a chat and returns the reply
+func (b *telegramBot) command(ctx context.Context, chatID int64, private bool, firstName, text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return telegramHelp
+	}
+	// In groups commands come as /newgame@SomeBot
+	name, _, _ := strings.Cut(fields[0], "@")
+	args := fields[1:]
+
+	switch name {
+	case "/newgame":
+		if !joinLimiter.Allow("telegram:" + strconv.FormatInt(chatID, 10)) {
+			return "You've started too many games; try again in a minute."
+		}
+		playerName := strings.Join(args, " ")
+		if playerName == "" {
+			playerName = firstName
+		}
+		if playerName == "" {
+			playerName = "Player"
+		}
+		if ban := bans.Check("", playerName); ban != nil {
+			return banMessage(ban)
+		}
+		game := gameManager.CreateGame(ctx)
+		playerID := newUUID()
+		game.AddPlayer(playerID, playerName, nil)
+		b.follow(game.ID, chatID, playerID)
+		return fmt.Sprintf("Game %s is ready. Share the ID so friends can join, and take your seat here:\n%s",
+			game.ID, b.seatLink(game.ID, game.SessionToken(playerID)))
+
+	case "/follow":
+		if len(args) == 0 {
+			return "Usage: /follow <gameID> [sessionToken]"
+		}
+		game := gameManager.GetGame(ctx, args[0])
+		if game == nil {
+			return "Game not found."
+		}
+		playerID := ""
+		if len(args) > 1 {
+			if !private {
+				return "Send your session token in a private chat with the bot, not in a group."
+			}
+			if playerID = game.SeatForToken(args[1]); playerID == "" {
+				return "That session token doesn't hold a seat in this game."
+			}
+		}
+		b.follow(game.ID, chatID, playerID)
+		if playerID != "" {
+			return "Following " + game.ID + ". You'll hear when it's your turn and when each round ends."
+		}
+		return "Following " + game.ID + ". You'll hear when each round ends."
+
+	case "/unfollow":
+		if len(args) == 0 {
+			return "Usage: /unfollow <gameID>"
+		}
+		b.unfollow(args[0], chatID)
+		return "Stopped following " + args[0] + "."
+
+	case "/stop":
+		b.unfollow("", chatID)
+		return "Stopped following all games."
+
+	default:
+		return telegramHelp
+	}
+}
+
+func (b *telegramBot) seatLink(gameID, sessionToken string) string {
+	if b.gameURL == "" {
+		return "Game ID: " + gameID + "\nSession token: " + sessionToken
+	}
+	return strings.NewReplacer("{gameID}", gameID, "{sessionToken}", sessionToken).Replace(b.gameURL)
+}
+
+func (b *telegramBot) follow(gameID string, chatID int64, playerID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.follows[gameID] == nil {
+		b.follows[gameID] = make(map[int64]string)
+	}
+	b.follows[gameID][chatID] = playerID
+}
+
+// unfollow stops chatID following gameID, or every game if gameID is ""
+func (b *telegramBot) unfollow(gameID string, chatID int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for id, chats := range b.follows {
+		if gameID == "" || id == gameID {
+			delete(chats, chatID)
+			if len(chats) == 0 {
+				delete(b.follows, id)
+			}
+		}
+	}
+}
+
+// Publish tells following chats about turns and round results. It runs on the event bus,
+// so a slow Telegram never holds up a game.
+func (b *telegramBot) Publish(event GameEvent) error {
+	b.mu.Lock()
+	chats := make(map[int64]string, len(b.follows[event.GameID]))
+	for chatID, playerID := range b.follows[event.GameID] {
+		chats[chatID] = playerID
+	}
+	b.mu.Unlock()
+	if len(chats) == 0 {
+		return nil
+	}
+
+	var errs []error
+	switch event.Type {
+	case protocol.EventTurnStarted:
+		for chatID, playerID := range chats {
+			if playerID != "" && playerID == event.PlayerID {
+				errs = append(errs, b.send(chatID, "It's your turn in game "+event.GameID+"."))
+			}
+		}
+	case protocol.EventStartingSoon:
+		text := "Game " + event.GameID + " opens in under a minute."
+		at, err := time.Parse(time.RFC3339, fmt.Sprint(event.Data["scheduledAt"]))
+		if minutes := int(time.Until(at).Round(time.Minute).Minutes()); err == nil && minutes > 0 {
+			text = fmt.Sprintf("Game %s opens in %d min.", event.GameID, minutes)
+		}
+		for chatID := range chats {
+			errs = append(errs, b.send(chatID, text))
+		}
+	case protocol.EventRoundEnded:
+		text := b.roundResults(event)
+		for chatID := range chats {
+			errs = append(errs, b.send(chatID, text))
+		}
+	case protocol.EventPlayerLeft:
+		// Someone who left (or was removed) has no turns to hear about
+		b.mu.Lock()
+		for chatID, playerID := range b.follows[event.GameID] {
+			if playerID == event.PlayerID {
+				b.follows[event.GameID][chatID] = ""
+			}
+		}
+		b.mu.Unlock()
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// roundResults describes a roundEnded event, lowest score first
+func (b *telegramBot) roundResults(event GameEvent) string {
+	names := make(map[string]string)
+	if game := gameManager.GetGame(context.Background(), event.GameID); game != nil {
+		for _, player := range game.LobbyPlayers() {
+			names[player.ID] = player.Name
+		}
+	}
+	name := func(id string) string {
+		if n, ok := names[id]; ok {
+			return n
+		}
+		return id
+	}
+
+	scores, _ := event.Data["scores"].(map[string]int)
+	ids := make([]string, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] < scores[ids[j]]
+		}
+		return name(ids[i]) < name(ids[j])
+	})
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Round over in game %s.", event.GameID)
+	if winners, _ := event.Data["winners"].([]string); len(winners) > 0 {
+		winnerNames := make([]string, len(winners))
+		for i, id := range winners {
+			winnerNames[i] = name(id)
+		}
+		fmt.Fprintf(&text, " Won by %s.", strings.Join(winnerNames, " and "))
+	}
+	for _, id := range ids {
+		fmt.Fprintf(&text, "\n%s: %d", name(id), scores[id])
+	}
+	return text.String()
+}
+
+// send posts a message to a chat through the Bot API
+func (b *telegramBot) send(chatID int64, text string) error {
+	body, err := json.Marshal(map[string]interface{}{"chat_id": chatID, "text": text})
+	if err != nil {
+		return err
+	}
+	resp, err := b.client.Post(b.apiURL+"/sendMessage", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("telegram sendMessage: %s", resp.Status)
+	}
+	return nil
+}
+
+func (b *telegramBot) Close() error {
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeTelegram stands in for the Bot API and records what was sent
+type fakeTelegram struct {
+	sent []map[string]interface{}
+	mu   sync.Mutex
+}
+
+func (f *fakeTelegram) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&body)
+	f.mu.Lock()
+	f.sent = append(f.sent, body)
+	f.mu.Unlock()
+	w.Write([]byte(`{"ok":true}`))
+}
+
+func telegramWebhook(t *testing.T, secret string, chatID int64, text string) (int, map[string]interface{}) {
+	t.Helper()
+	update := `{"message":{"text":` + jsonString(text) + `,"chat":{"id":` + jsonNumber(chatID) + `,"type":"private"},"from":{"first_name":"Ada"}}}`
+	req := httptest.NewRequest(http.MethodPost, "/telegram/webhook", strings.NewReader(update))
+	req.Header.Set("X-Telegram-Bot-Api-Secret-Token", secret)
+	rec := httptest.NewRecorder()
+	handleTelegramWebhook(rec, req)
+	var reply map[string]interface{}
+	json.NewDecoder(rec.Body).Decode(&reply)
+	return rec.Code, reply
+}
+
+func jsonString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func jsonNumber(n int64) string {
+	b, _ := json.Marshal(n)
+	return string(b)
+}
+
+func TestTelegramNewGame(t *testing.T) {
+	api := &fakeTelegram{}
+	server := httptest.NewServer(api)
+	defer server.Close()
+	saved := telegram
+	telegram = newTelegramBot(server.URL, "hook-secret", "https://pablo.example/play?game={gameID}&token={sessionToken}")
+	defer func() { telegram = saved }()
+
+	if code, _ := telegramWebhook(t, "wrong", 42, "/newgame"); code != http.StatusUnauthorized {
+		t.Fatalf("Expected a bad secret to be refused, got %d", code)
+	}
+
+	code, reply := telegramWebhook(t, "hook-secret", 42, "/newgame Grace")
+	if code != http.StatusOK || reply["method"] != "sendMessage" || reply["chat_id"] != float64(42) {
+		t.Fatalf("Unexpected webhook reply %d %v", code, reply)
+	}
+	text := reply["text"].(string)
+	start := strings.Index(text, "game=")
+	if start < 0 {
+		t.Fatalf("Expected a seat link, got %q", text)
+	}
+	link := text[start+len("game="):]
+	gameID, token, _ := strings.Cut(link, "&token=")
+
+	game := gameManager.GetGame(context.Background(), gameID)
+	if game == nil {
+		t.Fatalf("Game %q from the link doesn't exist", gameID)
+	}
+	playerID := game.SeatForToken(token)
+	if playerID == "" || game.LobbyPlayers()[0].Name != "Grace" {
+		t.Fatalf("Expected Grace seated with the linked token, got %+v", game.LobbyPlayers())
+	}
+
+	// The chat hears about its own turns only
+	if err := telegram.Publish(GameEvent{Type: "turnStarted", GameID: gameID, PlayerID: "someone-else"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := telegram.Publish(GameEvent{Type: "turnStarted", GameID: gameID, PlayerID: playerID}); err != nil {
+		t.Fatal(err)
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if len(api.sent) != 1 || api.sent[0]["chat_id"] != float64(42) || !strings.Contains(api.sent[0]["text"].(string), "your turn") {
+		t.Errorf("Expected one turn notification, got %v", api.sent)
+	}
+}
+
+func TestTelegramRoundResults(t *testing.T) {
+	api := &fakeTelegram{}
+	server := httptest.NewServer(api)
+	defer server.Close()
+	saved := telegram
+	telegram = newTelegramBot(server.URL, "", "")
+	defer func() { telegram = saved }()
+
+	game := gameManager.CreateGame(context.Background())
+	addTestPlayers(game, 2)
+
+	if _, reply := telegramWebhook(t, "", 7, "/follow "+game.ID); !strings.HasPrefix(reply["text"].(string), "Following") {
+		t.Fatalf("Expected to follow the game, got %v", reply)
+	}
+	if _, reply := telegramWebhook(t, "", 7, "/follow "+game.ID+" not-a-token"); !strings.Contains(reply["text"].(string), "doesn't hold a seat") {
+		t.Errorf("Expected an unknown token to be refused, got %v", reply)
+	}
+
+	err := telegram.Publish(GameEvent{
+		Type:   "roundEnded",
+		GameID: game.ID,
+		Data: map[string]interface{}{
+			"scores":  map[string]int{"player1": 12, "player2": 3},
+			"winners": []string{"player2"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api.mu.Lock()
+	sent := api.sent
+	api.mu.Unlock()
+	want := "Round over in game " + game.ID + ". Won by Player 2.\nPlayer 2: 3\nPlayer 1: 12"
+	if len(sent) != 1 || sent[0]["text"] != want {
+		t.Fatalf("Expected round results %q, got %v", want, sent)
+	}
+
+	telegramWebhook(t, "", 7, "/stop")
+	telegram.Publish(GameEvent{Type: "roundEnded", GameID: game.ID})
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if len(api.sent) != 1 {
+		t.Errorf("Expected nothing sent after /stop, got %v", api.sent[1:])
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"pablo/protocol"
+)
+
+// Default join throttling: each IP may create or join this many games per window
+const (
+	defaultJoinLimit  = 20
+	defaultJoinWindow = time.Minute
+)
+
+// defaultOpenGameLimit is how many games one IP may have seats in at once
+const defaultOpenGameLimit = 10
+
+// creatingActions are the join messages that start a new game, which the CAPTCHA gate covers
+var creatingActions = map[string]bool{
+	protocol.MsgCreateGame:    true,
+	protocol.MsgStartDaily:    true,
+	protocol.MsgStartPuzzle:   true,
+	protocol.MsgStartTutorial: true,
+}
+
+// rateLimiter allows each key a number of events per sliding window
+type rateLimiter struct {
+	limit     int // 0 allows everything
+	window    time.Duration
+	hits      map[string][]time.Time // Recent events per key, oldest first
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+// joinLimiter throttles game creations and joins per IP address; off unless main sets it up
+var joinLimiter = newRateLimiter(0, 0)
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{limit: limit, window: window, hits: make(map[string][]time.Time)}
+}
+
+// Allow records an event for key and reports whether it is within the limit.
+// Refused events don't count against the key.
+func (l *rateLimiter) Allow(key string) bool {
+	if l.limit <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastSweep) > l.window {
+		// Forget keys that have gone quiet so the map doesn't keep every address ever seen
+		for k, hits := range l.hits {
+			if len(hits) == 0 || now.Sub(hits[len(hits)-1]) >= l.window {
+				delete(l.hits, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	var recent []time.Time
+	for _, at := range l.hits[key] {
+		if now.Sub(at) < l.window {
+			recent = append(recent, at)
+		}
+	}
+	if len(recent) >= l.limit {
+		l.hits[key] = recent
+		return false
+	}
+	l.hits[key] = append(recent, now)
+	return true
+}
+
+// openGameCap limits how many games each IP address holds seats in at the same time, so
+// one client can't leave thousands of empty games behind. The rate limiter only slows
+// that down; this puts a ceiling on it.
+type openGameCap struct {
+	limit int                   // 0 allows any number
+	seats map[string][]heldSeat // Seats taken from each address, pruned as they're given up
+	mu    sync.Mutex
+}
+
+type heldSeat struct {
+	game     *Game
+	playerID string
+}
+
+// openGames caps the games each IP is in; off unless main sets it up
+var openGames = newOpenGameCap(0)
+
+func newOpenGameCap(limit int) *openGameCap {
+	return &openGameCap{limit: limit, seats: make(map[string][]heldSeat)}
+}
+
+// Allow reports whether ip may take a seat in another game. Going back to a game it
+// already holds a seat in is always allowed; pass "" for a game that doesn't exist yet.
+func (c *openGameCap) Allow(ip, gameID string) bool {
+	if c.limit <= 0 {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	held := c.prune(ip)
+	open := make(map[string]bool, len(held))
+	for _, seat := range held {
+		open[seat.game.ID] = true
+	}
+	return open[gameID] || len(open) < c.limit
+}
+
+// Hold records that ip took playerID's seat in game
+func (c *openGameCap) Hold(ip string, game *Game, playerID string) {
+	if c.limit <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, seat := range c.seats[ip] {
+		if seat.game == game && seat.playerID == playerID {
+			return
+		}
+	}
+	c.seats[ip] = append(c.prune(ip), heldSeat{game: game, playerID: playerID})
+}
+
+// prune drops ip's seats that no longer count and returns the rest. Caller must hold c.mu.
+func (c *openGameCap) prune(ip string) []heldSeat {
+	held := c.seats[ip][:0]
+	for _, seat := range c.seats[ip] {
+		if seat.game.holdsOpenSeat(seat.playerID) {
+			held = append(held, seat)
+		}
+	}
+	if len(held) == 0 {
+		delete(c.seats, ip)
+		return nil
+	}
+	c.seats[ip] = held
+	return held
+}
+
+var errCaptchaFailed = errors.New("captcha verification failed")
+
+// captchaVerifier checks the token a client got from a CAPTCHA widget with the provider.
+// hCaptcha, reCAPTCHA and Turnstile all take the same form post and answer {"success": bool}.
+type captchaVerifier struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// captcha gates game creation when configured; nil lets everyone through
+var captcha *captchaVerifier
+
+func newCaptchaVerifier(verifyURL, secret string) *captchaVerifier {
+	return &captchaVerifier{url: verifyURL, secret: secret, client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+// Verify asks the provider about token. Unlike chat moderation this fails closed: if the
+// provider can't be reached, nobody gets through the gate.
+func (v *captchaVerifier) Verify(ctx context.Context, token, ip string) error {
+	if strings.TrimSpace(token) == "" {
+		return errCaptchaFailed
+	}
+	form := url.Values{"secret": {v.secret}, "response": {token}}
+	if ip != "" {
+		form.Set("remoteip", ip)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := v.client.Do(req)
+	if err != nil {
+		log.Println("CAPTCHA provider unavailable:", err)
+		return errCaptchaFailed
+	}
+	defer resp.Body.Close()
+
+	var answer struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&answer); err != nil || !answer.Success {
+		return errCaptchaFailed
+	}
+	return nil
+}
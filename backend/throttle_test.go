@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(2, 100*time.Millisecond)
+	if !limiter.Allow("1.2.3.4") || !limiter.Allow("1.2.3.4") {
+		t.Fatal("The first two should be allowed")
+	}
+	if limiter.Allow("1.2.3.4") {
+		t.Error("The third within the window should be refused")
+	}
+	if !limiter.Allow("5.6.7.8") {
+		t.Error("Other addresses have their own allowance")
+	}
+	time.Sleep(120 * time.Millisecond)
+	if !limiter.Allow("1.2.3.4") {
+		t.Error("The allowance should come back once the window has passed")
+	}
+}
+
+func TestCaptchaVerifier(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		ok := r.Form.Get("secret") == "s3cret" && r.Form.Get("response") == "human"
+		json.NewEncoder(w).Encode(map[string]bool{"success": ok})
+	}))
+	verifier := newCaptchaVerifier(server.URL, "s3cret")
+	ctx := context.Background()
+
+	if err := verifier.Verify(ctx, "human", "1.2.3.4"); err != nil {
+		t.Errorf("Expected a valid token to pass, got %v", err)
+	}
+	if err := verifier.Verify(ctx, "robot", ""); err != errCaptchaFailed {
+		t.Errorf("Expected errCaptchaFailed, got %v", err)
+	}
+	if err := verifier.Verify(ctx, "", ""); err != errCaptchaFailed {
+		t.Errorf("A missing token should fail, got %v", err)
+	}
+	server.Close()
+	if err := verifier.Verify(ctx, "human", ""); err != errCaptchaFailed {
+		t.Errorf("An unreachable provider should fail closed, got %v", err)
+	}
+}
+
+func TestJoinGatesOverWS(t *testing.T) {
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		json.NewEncoder(w).Encode(map[string]bool{"success": r.Form.Get("response") == "human"})
+	}))
+	defer provider.Close()
+	savedCaptcha, savedLimiter := captcha, joinLimiter
+	captcha, joinLimiter = newCaptchaVerifier(provider.URL, "secret"), newRateLimiter(2, time.Minute)
+	defer func() { captcha, joinLimiter = savedCaptcha, savedLimiter }()
+
+	conn := dialTestServer(t)
+	sendTestMessage(t, conn, "createGame", map[string]interface{}{"name": "Bot"})
+	if code := readMessageOfType(t, conn, "error")["code"]; code != "CAPTCHA_REQUIRED" {
+		t.Errorf("Expected CAPTCHA_REQUIRED, got %v", code)
+	}
+
+	sendTestMessage(t, conn, "createGame", map[string]interface{}{"name": "Host", "captchaToken": "human"})
+	session := readMessageOfType(t, conn, "session")
+
+	// Joining doesn't need the CAPTCHA, but it does count against the limit
+	guest := dialTestServer(t)
+	sendTestMessage(t, guest, "join", map[string]interface{}{"gameID": session["gameID"], "name": "Guest"})
+	readMessageOfType(t, guest, "session")
+	late := dialTestServer(t)
+	sendTestMessage(t, late, "join", map[string]interface{}{"gameID": session["gameID"], "name": "Late"})
+	if code := readMessageOfType(t, late, "error")["code"]; code != "RATE_LIMITED" {
+		t.Errorf("Expected RATE_LIMITED, got %v", code)
+	}
+}
+
+func TestOpenGameCap(t *testing.T) {
+	held := newOpenGameCap(2)
+	first, second, third := createTestGame("first"), createTestGame("second"), createTestGame("third")
+	for _, game := range []*Game{first, second} {
+		game.AddPlayer("p1", "P1", nil)
+		held.Hold("1.2.3.4", game, "p1")
+	}
+
+	if held.Allow("1.2.3.4", "") {
+		t.Error("A third game should be refused at the held")
+	}
+	if !held.Allow("1.2.3.4", "first") {
+		t.Error("Going back to a game already held should be allowed")
+	}
+	if !held.Allow("5.6.7.8", "") {
+		t.Error("Other addresses have their own held")
+	}
+
+	second.removePlayer("p1")
+	if !held.Allow("1.2.3.4", "") {
+		t.Error("Leaving a game should free a place under the held")
+	}
+	third.AddPlayer("p1", "P1", nil)
+	held.Hold("1.2.3.4", third, "p1")
+
+	// A disconnected seat stops counting once its game is under way
+	first.AddPlayer("p2", "P2", nil)
+	first.StartGame()
+	if !held.Allow("1.2.3.4", "") {
+		t.Error("A disconnected seat in a started game shouldn't count")
+	}
+}
+
+func TestOpenGameCapOverWS(t *testing.T) {
+	saved := openGames
+	openGames = newOpenGameCap(1)
+	defer func() { openGames = saved }()
+
+	conn := dialTestServer(t)
+	sendTestMessage(t, conn, "createGame", map[string]interface{}{"name": "Host"})
+	session := readMessageOfType(t, conn, "session")
+
+	sendTestMessage(t, conn, "createGame", map[string]interface{}{"name": "Host"})
+	if code := readMessageOfType(t, conn, "error")["code"]; code != "TOO_MANY_GAMES" {
+		t.Errorf("Expected TOO_MANY_GAMES, got %v", code)
+	}
+
+	// The seat already held can still be taken back, and watching doesn't count
+	sendTestMessage(t, conn, "join", map[string]interface{}{
+		"gameID": session["gameID"], "name": "Host", "sessionToken": session["sessionToken"],
+	})
+	readMessageOfType(t, conn, "session")
+	other := dialTestServer(t)
+	sendTestMessage(t, other, "spectate", map[string]interface{}{"gameID": session["gameID"], "name": "Watcher"})
+	readMessageOfType(t, other, "spectating")
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"pablo/protocol"
+)
+
+const (
+	// A turn limit shorter than this can't be played over a slow connection, and one longer
+	// than this doesn't keep the game moving
+	minTurnTimeLimitMs = 5000
+	maxTurnTimeLimitMs = 10 * 60 * 1000
+
+	// turnTimerTick is how often players are told how long the turn has left
+	turnTimerTick = 5 * time.Second
+)
+
+var errInvalidTurnTimeLimit = errors.New("turn time limit must be 0 or between 5000 and 600000 ms")
+
+// turnTimer is the clock on one turn at a table with a turn time limit. A new turn gets a
+// new timer; the one running for an earlier turn sees it has been replaced and stops.
+type turnTimer struct {
+	playerID string
+	deadline time.Time
+	checked  time.Time // When the timer last looked at the game
+}
+
+// startTurnTimer puts the clock on CurrentPlayer's turn if the table plays with a turn
+// time limit. Caller must hold g.mu.
+func (g *Game) startTurnTimer() {
+	g.turnTimer = nil
+	if g.Config.TurnTimeLimitMs <= 0 || g.Status != protocol.StatusPlaying || g.peeking != nil {
+		return
+	}
+	now := time.Now()
+	timer := &turnTimer{
+		playerID: g.CurrentPlayer,
+		deadline: now.Add(time.Duration(g.Config.TurnTimeLimitMs) * time.Millisecond),
+		checked:  now,
+	}
+	g.turnTimer = timer
+	go g.runTurnTimer(timer)
+}
+
+// resumeTurnTimer restarts the clock on the turn after a pause, with the time that was left
+// when the game paused. A game restored from an adjournment has no clock running, so its
+// turn starts over. Caller must hold g.mu.
+func (g *Game) resumeTurnTimer() {
+	timer := g.turnTimer
+	if timer == nil || timer.playerID != g.CurrentPlayer {
+		g.startTurnTimer()
+		return
+	}
+	now := time.Now()
+	timer.deadline = timer.deadline.Add(now.Sub(timer.checked))
+	timer.checked = now
+}
+
+// turnDeadline is when the current turn runs out, or zero if it isn't timed.
+// Caller must hold g.mu.
+func (g *Game) turnDeadline() time.Time {
+	if g.turnTimer == nil || g.Status != protocol.StatusPlaying {
+		return time.Time{}
+	}
+	return g.turnTimer.deadline
+}
+
+// runTurnTimer counts down timer, telling the table every turnTimerTick how long is left,
+// and forfeits the turn if it runs out. The clock stops while the game is paused and while
+// a give is pending, since the player can't move until someone else does.
+func (g *Game) runTurnTimer(timer *turnTimer) {
+	for {
+		g.mu.Lock()
+		if g.turnTimer != timer || (g.Status != protocol.StatusPlaying && g.Status != protocol.StatusPaused) {
+			g.mu.Unlock()
+			return
+		}
+		now := time.Now()
+		if g.Status == protocol.StatusPaused || g.PendingGive != nil {
+			timer.deadline = timer.deadline.Add(now.Sub(timer.checked))
+		}
+		timer.checked = now
+		if !now.Before(timer.deadline) {
+			g.turnTimer = nil
+			g.forfeitTurn(timer.playerID)
+			g.mu.Unlock()
+			return
+		}
+		remaining := timer.deadline.Sub(now)
+		if g.Status == protocol.StatusPlaying {
+			g.broadcastTurnTimer(timer, remaining)
+		}
+		g.mu.Unlock()
+
+		select {
+		case <-g.ctx.Done():
+			return
+		case <-time.After(min(remaining, turnTimerTick)):
+		}
+	}
+}
+
+// broadcastTurnTimer tells the table and its spectators how long the current turn has
+// left. Caller must hold g.mu.
+func (g *Game) broadcastTurnTimer(timer *turnTimer, remaining time.Duration) {
+	message := Message{
+		Type: protocol.MsgTurnTimer,
+		Payload: map[string]interface{}{
+			"playerID":    timer.playerID,
+			"remainingMs": remaining.Milliseconds(),
+			"deadline":    timer.deadline.UTC().Format(timestampFormat),
+		},
+	}
+	g.broadcast(message)
+	g.broadcastToSpectators(message)
+}
+
+// forfeitTurn ends playerID's turn for them when its time runs out. A card they drew from
+// the deck is discarded without using its power, one taken from the discard pile goes back,
+// and a power they hadn't used yet is skipped. Caller must hold g.mu.
+func (g *Game) forfeitTurn(playerID string) {
+	if g.Status != protocol.StatusPlaying || g.CurrentPlayer != playerID || g.PendingGive != nil {
+		return
+	}
+
+	var discarded *Card
+	if drawn := g.DrawnCards[playerID]; drawn != nil {
+		card := *drawn
+		card.FaceUp = true
+		g.pushDiscard(card)
+		if !g.DrawnFromDiscard[playerID] {
+			g.markStackable()
+			discarded = &card
+		}
+	}
+	delete(g.DrawnCards, playerID)
+	delete(g.DrawnFromDiscard, playerID)
+	if g.PendingKingSwap != nil && g.PendingKingSwap.ActorID == playerID {
+		g.PendingKingSwap = nil
+	}
+
+	payload := map[string]interface{}{"playerID": playerID}
+	if discarded != nil {
+		payload["card"] = *discarded
+	}
+	message := Message{Type: protocol.MsgTurnTimeout, Payload: payload}
+	g.broadcast(message)
+	g.broadcastToSpectators(message)
+	g.emitAction(playerID, protocol.MsgEndTurn, map[string]interface{}{"timedOut": true})
+
+	if g.PendingSpecialCard != "" {
+		g.finishSpecialCard()
+		// Someone who stacked on the special card now gets to use it
+		if g.CurrentPlayer != playerID {
+			if !g.skipAbsentTurn() {
+				g.broadcastGameState()
+				g.scheduleBots()
+			}
+			return
+		}
+	}
+	g.passTurn(playerID)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"pablo/protocol"
+)
+
+// waitForMessage polls recorder until playerID has been sent a message of msgType
+func waitForMessage(t *testing.T, recorder *recordingBroadcaster, playerID, msgType string, within time.Duration) bool {
+	t.Helper()
+	deadline := time.Now().Add(within)
+	for time.Now().Before(deadline) {
+		recorder.mu.Lock()
+		count := countOfType(recorder.players[playerID], msgType)
+		recorder.mu.Unlock()
+		if count > 0 {
+			return true
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return false
+}
+
+func TestForfeitTurnDiscardsDrawnCard(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	addTestPlayers(game, 2)
+	game.StartGame()
+
+	current := game.CurrentPlayer
+	game.Deck[0] = Card{Suit: "clubs", Rank: "2"}
+	if err := game.DrawCard(current); err != nil {
+		t.Fatal(err)
+	}
+
+	game.mu.Lock()
+	game.forfeitTurn(current)
+	game.mu.Unlock()
+
+	if game.CurrentPlayer == current {
+		t.Error("Expected the turn to move on")
+	}
+	if _, held := game.DrawnCards[current]; held {
+		t.Error("Expected the drawn card to be let go")
+	}
+	top := game.DiscardPile[len(game.DiscardPile)-1]
+	if top.Rank != "2" || !top.FaceUp {
+		t.Errorf("Expected the drawn 2 face up on the discard pile, got %+v", top)
+	}
+	if game.StackableCardIndex != len(game.DiscardPile)-1 {
+		t.Error("Expected the discarded card to be open to stacking")
+	}
+	if countOfType(recorder.players[current], protocol.MsgTurnTimeout) != 1 {
+		t.Error("Expected the table to be told the turn timed out")
+	}
+}
+
+func TestTurnTimerEndsTurn(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	playerIDs := addTestPlayers(game, 2)
+	game.Config.TurnTimeLimitMs = 50
+	game.StartGame()
+
+	game.mu.RLock()
+	first := game.CurrentPlayer
+	deadline := game.getGameStateForPlayer(first).Deadlines.Turn
+	game.mu.RUnlock()
+	if deadline.IsZero() {
+		t.Error("Expected the state to carry the turn deadline")
+	}
+
+	if !waitForMessage(t, recorder, playerIDs[0], protocol.MsgTurnTimer, time.Second) {
+		t.Error("Expected an update on the time left")
+	}
+	if !waitForMessage(t, recorder, playerIDs[0], protocol.MsgTurnTimeout, time.Second) {
+		t.Fatal("Expected the turn to time out")
+	}
+	game.mu.RLock()
+	defer game.mu.RUnlock()
+	if game.Status != protocol.StatusPlaying {
+		t.Errorf("Expected play to go on, status %s", game.Status)
+	}
+}
+
+func TestTurnTimerStopsWhilePaused(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	playerIDs := addTestPlayers(game, 2)
+	game.Config.TurnTimeLimitMs = 100
+	game.StartGame()
+	game.RequestPause(game.HostID)
+
+	if waitForMessage(t, recorder, playerIDs[0], protocol.MsgTurnTimeout, 300*time.Millisecond) {
+		t.Error("The clock should not run while the game is paused")
+	}
+	game.RequestResume(game.HostID)
+	if !waitForMessage(t, recorder, playerIDs[0], protocol.MsgTurnTimeout, time.Second) {
+		t.Error("Expected the turn to time out once play resumed")
+	}
+}
+
+func TestNoTurnTimerByDefault(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+
+	if game.turnTimer != nil {
+		t.Error("Expected no clock on the turn")
+	}
+	if deadline := game.getGameStateForPlayer(playerIDs[0]).Deadlines.Turn; !deadline.IsZero() {
+		t.Errorf("Expected no turn deadline, got %v", deadline)
+	}
+}
+
+func TestTurnTimeLimitValidation(t *testing.T) {
+	for _, limit := range []int{-1, 1000, maxTurnTimeLimitMs + 1} {
+		if _, err := decodeGameConfig(map[string]interface{}{"turnTimeLimitMs": limit}); err != errInvalidTurnTimeLimit {
+			t.Errorf("Limit %d: expected errInvalidTurnTimeLimit, got %v", limit, err)
+		}
+	}
+	for _, limit := range []int{0, minTurnTimeLimitMs, maxTurnTimeLimitMs} {
+		if _, err := decodeGameConfig(map[string]interface{}{"turnTimeLimitMs": limit}); err != nil {
+			t.Errorf("Limit %d: unexpected error %v", limit, err)
+		}
+	}
+}
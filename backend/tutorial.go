@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/gorilla/websocket"
+	"pablo/protocol"
+)
+
+// A tutorial is a round against a bot coach with the deck stacked so every step turns out
+// the way the instructions say. The learner is sent a tutorialStep message telling them
+// what to do next, and gameplay messages the step isn't waiting for are turned away with
+// TUTORIAL_STEP and the instruction again. Steps move on from the same events the game
+// publishes, so the script follows the game rather than the other way round.
+
+// ErrTutorialStep is returned for a move the tutorial isn't asking for yet
+var ErrTutorialStep = errors.New("follow the tutorial's instruction first")
+
+// tutorialStep is one instruction. A step with no actions waits for the learner's turn to
+// come round again; slot, if not -1, is the card a swap must go into.
+type tutorialStep struct {
+	key     string // Catalog key of the instruction
+	actions []string
+	slot    int
+}
+
+// tutorialSteps are played in order; the deck and hands below are arranged to match
+var tutorialSteps = []tutorialStep{
+	{"tutorial.draw", []string{protocol.MsgDrawCard}, -1},
+	{"tutorial.swapAce", []string{protocol.MsgSwapCard}, 0},
+	{"tutorial.endTurn", []string{protocol.MsgEndTurn}, -1},
+	{"tutorial.watch", nil, -1},
+	{"tutorial.drawAgain", []string{protocol.MsgDrawCard}, -1},
+	{"tutorial.discardSeven", []string{protocol.MsgDiscardDrawnCard}, -1},
+	{"tutorial.peek", []string{protocol.MsgUseSpecialCardFromDiscard}, -1},
+	{"tutorial.endTurn", []string{protocol.MsgEndTurn}, -1},
+	{"tutorial.watch", nil, -1},
+	{"tutorial.callPablo", []string{protocol.MsgCallPablo}, -1},
+	{"tutorial.drawLast", []string{protocol.MsgDrawCard}, -1},
+	{"tutorial.swapTwo", []string{protocol.MsgSwapCard}, 2},
+	{"tutorial.endTurn", []string{protocol.MsgEndTurn}, -1},
+	{"tutorial.lastLap", nil, -1},
+}
+
+// The learner draws every other card from the deck and the coach, who always draws from
+// the deck and throws away anything high, the ones in between
+var (
+	tutorialLearnerHand = []Card{{Suit: "spades", Rank: "K"}, {Suit: "hearts", Rank: "4"}, {Suit: "clubs", Rank: "6"}, {Suit: "diamonds", Rank: "3"}}
+	tutorialCoachHand   = []Card{{Suit: "hearts", Rank: "8"}, {Suit: "spades", Rank: "9"}, {Suit: "clubs", Rank: "Q"}, {Suit: "diamonds", Rank: "J"}}
+	tutorialDeck        = []Card{
+		{Suit: "hearts", Rank: "A"}, {Suit: "diamonds", Rank: "10"},
+		{Suit: "clubs", Rank: "7"}, {Suit: "diamonds", Rank: "Q"},
+		{Suit: "spades", Rank: "2"}, {Suit: "hearts", Rank: "J"},
+		{Suit: "clubs", Rank: "5"}, {Suit: "spades", Rank: "10"},
+	}
+)
+
+// tutorialState tracks the learner's place in the script
+type tutorialState struct {
+	LearnerID string
+	Step      int // Index into tutorialSteps; len(tutorialSteps) once the round is over
+}
+
+// actionNames maps the names actions are published under to the messages that make them
+var actionNames = map[string]string{
+	"useSpecialCard": protocol.MsgUseSpecialCardFromDiscard,
+}
+
+// CreateTutorialGame seats the learner against the coach and sends the first instruction
+func (gm *GameManager) CreateTutorialGame(learnerID, name string, conn *websocket.Conn) *Game {
+	game := NewGame("tutorial-" + newSessionToken()[:8])
+	game.Deck = append([]Card{}, tutorialDeck...)
+	game.AddPlayer(learnerID, name, conn)
+	game.AddBot("coach", "Coach")
+	game.Players[learnerID].Cards = append([]Card{}, tutorialLearnerHand...)
+	game.Players["coach"].Cards = append([]Card{}, tutorialCoachHand...)
+	game.Status = protocol.StatusPlaying
+	game.CurrentPlayer = learnerID
+	game.Tutorial = &tutorialState{LearnerID: learnerID}
+
+	gm.mu.Lock()
+	gm.addGame(game)
+	gm.mu.Unlock()
+
+	game.mu.Lock()
+	game.roundStarted()
+	game.broadcastGameState()
+	game.sendTutorialStep()
+	game.mu.Unlock()
+	return game
+}
+
+// CheckTutorial reports whether the learner's move is the one the current step asks for.
+// A move that isn't is answered with ErrTutorialStep and a reminder of the step. cardIndex
+// is the slot the move plays into, or -1 for moves without one.
+func (g *Game) CheckTutorial(playerID, action string, cardIndex int) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.Tutorial == nil || playerID != g.Tutorial.LearnerID || g.Tutorial.Step >= len(tutorialSteps) {
+		return nil
+	}
+	step := tutorialSteps[g.Tutorial.Step]
+	for _, expected := range step.actions {
+		if action != expected {
+			continue
+		}
+		if step.slot < 0 {
+			return nil
+		}
+		if cardIndex == step.slot {
+			return nil
+		}
+	}
+	g.sendTutorialStep()
+	return ErrTutorialStep
+}
+
+// followTutorial moves the script on when the learner makes the move a step asks for, or
+// when their turn comes back round after a step spent watching. Caller must hold g.mu.
+func (g *Game) followTutorial(eventType, playerID string, data map[string]interface{}) {
+	if g.Tutorial == nil || g.Tutorial.Step >= len(tutorialSteps) || playerID != g.Tutorial.LearnerID {
+		return
+	}
+	step := tutorialSteps[g.Tutorial.Step]
+	switch eventType {
+	case protocol.EventAction:
+		action, _ := data["action"].(string)
+		if name, renamed := actionNames[action]; renamed {
+			action = name
+		}
+		for _, expected := range step.actions {
+			if action == expected {
+				g.Tutorial.Step++
+				g.sendTutorialStep()
+				return
+			}
+		}
+	case protocol.EventTurnStarted:
+		if step.actions == nil {
+			g.Tutorial.Step++
+			g.sendTutorialStep()
+		}
+	}
+}
+
+// finishTutorial ends the script when the round is scored. Caller must hold g.mu.
+func (g *Game) finishTutorial() {
+	if g.Tutorial == nil {
+		return
+	}
+	g.Tutorial.Step = len(tutorialSteps)
+	g.sendTutorialStep()
+}
+
+// sendTutorialStep tells the learner what to do now. Caller must hold g.mu.
+func (g *Game) sendTutorialStep() {
+	learner := g.Tutorial.LearnerID
+	payload := map[string]interface{}{
+		"step":  g.Tutorial.Step,
+		"steps": len(tutorialSteps),
+	}
+	if g.Tutorial.Step >= len(tutorialSteps) {
+		payload["instruction"] = g.localizeFor(learner, "tutorial.done")
+		payload["done"] = true
+	} else {
+		step := tutorialSteps[g.Tutorial.Step]
+		payload["instruction"] = g.localizeFor(learner, step.key)
+		payload["expected"] = append([]string{}, step.actions...)
+		if step.slot >= 0 {
+			payload["cardIndex"] = step.slot
+		}
+	}
+	g.sendToPlayer(learner, Message{Type: protocol.MsgTutorialStep, Payload: payload})
+}
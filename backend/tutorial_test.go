@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"pablo/protocol"
+)
+
+// waitForTurn polls until playerID is up, as the coach plays in the background
+func waitForTurn(t *testing.T, game *Game, playerID string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		game.mu.RLock()
+		current, status := game.CurrentPlayer, game.Status
+		game.mu.RUnlock()
+		if current == playerID || status != protocol.StatusPlaying {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %s to be up, still waiting on %s", playerID, current)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func lastTutorialStep(recorder *recordingBroadcaster, playerID string) map[string]interface{} {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	messages := recorder.players[playerID]
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Type == protocol.MsgTutorialStep {
+			return messages[i].Payload.(map[string]interface{})
+		}
+	}
+	return nil
+}
+
+func TestTutorialPlaysThrough(t *testing.T) {
+	defer func(delay time.Duration) { botTurnDelay = delay }(botTurnDelay)
+	botTurnDelay = time.Millisecond
+
+	gm := &GameManager{ctx: context.Background(), games: make(map[string]*Game)}
+	game := gm.CreateTutorialGame("learner", "Learner", nil)
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	game.mu.Lock()
+	game.sendTutorialStep()
+	game.mu.Unlock()
+
+	if step := lastTutorialStep(recorder, "learner"); step == nil || step["instruction"] == "" || step["step"] != 0 {
+		t.Fatalf("Expected the first instruction, got %v", step)
+	}
+
+	// Anything but the move asked for is turned away
+	if err := game.CheckTutorial("learner", protocol.MsgCallPablo, -1); !errors.Is(err, ErrTutorialStep) {
+		t.Fatalf("Expected ErrTutorialStep for an early Pablo call, got %v", err)
+	}
+	if err := game.CheckTutorial("learner", protocol.MsgDrawCard, -1); err != nil {
+		t.Fatalf("Drawing should be allowed, got %v", err)
+	}
+	game.DrawCard("learner")
+
+	if err := game.CheckTutorial("learner", protocol.MsgSwapCard, 3); !errors.Is(err, ErrTutorialStep) {
+		t.Fatalf("Expected ErrTutorialStep for a swap into the wrong slot, got %v", err)
+	}
+	if err := game.SwapCard("learner", 0); err != nil {
+		t.Fatalf("Swap failed: %v", err)
+	}
+	if err := game.EndTurn("learner"); err != nil {
+		t.Fatalf("End turn failed: %v", err)
+	}
+
+	waitForTurn(t, game, "learner")
+	game.DrawCard("learner")
+	if err := game.DiscardDrawnCard("learner"); err != nil {
+		t.Fatalf("Discarding the 7 failed: %v", err)
+	}
+	if err := game.UseSpecialCardFromDiscard("learner", "7", map[string]interface{}{"targetIndex": float64(1)}); err != nil {
+		t.Fatalf("Peeking failed: %v", err)
+	}
+	if err := game.EndTurn("learner"); err != nil {
+		t.Fatalf("End turn failed: %v", err)
+	}
+
+	waitForTurn(t, game, "learner")
+	if step := lastTutorialStep(recorder, "learner"); step["expected"].([]string)[0] != protocol.MsgCallPablo {
+		t.Fatalf("Expected to be asked to call Pablo, got %v", step)
+	}
+	game.CallPablo("learner")
+	game.DrawCard("learner")
+	if err := game.SwapCard("learner", 2); err != nil {
+		t.Fatalf("Swap failed: %v", err)
+	}
+	if err := game.EndTurn("learner"); err != nil {
+		t.Fatalf("End turn failed: %v", err)
+	}
+
+	waitForTurn(t, game, "")
+	step := lastTutorialStep(recorder, "learner")
+	if step["done"] != true {
+		t.Fatalf("Expected the tutorial to finish with the round, got %v", step)
+	}
+	game.mu.RLock()
+	defer game.mu.RUnlock()
+	if game.Tutorial.Step != len(tutorialSteps) {
+		t.Errorf("Expected every step played, stopped at %d", game.Tutorial.Step)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"pablo/protocol"
+)
+
+// A player whose connection is gone still has a seat, and a round in play waits on them
+// whenever their turn comes round. After defaultVacateAfter away the seat is given up on:
+// it turns vacant, turns pass it by, and broadcasts stop going to it. The hand stays and
+// is scored as it stands. Seats that never had a connection, or lost it without the
+// disconnect being seen, count as away from when the reaper first finds them. The player
+// gets the seat back by rejoining, as after any disconnect. In a lobby nobody is waiting
+// on them, but a seat held by someone who left keeps others out and the table from
+// starting, so one away as long is given up altogether.
+
+// defaultVacateAfter is how long a seat may be away during a round before it turns vacant
+const defaultVacateAfter = 2 * time.Minute
+
+// reapVacantSeats turns vacant every seat in the game that has been away for at least after,
+// or removes it if the game is waiting to start, returning how many it vacated or removed.
+// Bots never have a connection and are left alone; so are games between rounds, paused or
+// scheduled, where nobody is waiting on a seat. Caller must hold g.mu.
+func (g *Game) reapVacantSeats(now time.Time, after time.Duration) int {
+	if g.Status == protocol.StatusWaiting {
+		return g.reapLobbySeats(now, after)
+	}
+	vacated := 0
+	for _, id := range g.SeatOrder {
+		if g.Status != protocol.StatusPlaying {
+			break
+		}
+		if g.awayFor(id, now) >= after {
+			g.vacateSeat(id)
+			vacated++
+		}
+	}
+	return vacated
+}
+
+// reapLobbySeats removes from a lobby every seat that has been away for at least after.
+// Caller must hold g.mu.
+func (g *Game) reapLobbySeats(now time.Time, after time.Duration) int {
+	var gone []string
+	for _, id := range g.SeatOrder {
+		if g.awayFor(id, now) >= after {
+			gone = append(gone, id)
+		}
+	}
+	for _, id := range gone {
+		g.removePlayer(id)
+	}
+	if len(gone) > 0 {
+		g.broadcastGameState()
+	}
+	return len(gone)
+}
+
+// awayFor returns how long playerID's seat has been without a connection, starting the
+// count for seats found without one. Bots and vacant seats aren't away. Caller must hold g.mu.
+func (g *Game) awayFor(playerID string, now time.Time) time.Duration {
+	if _, isBot := g.Bots[playerID]; isBot || g.Players[playerID].Conn != nil || g.vacant[playerID] {
+		return 0
+	}
+	since, away := g.absent[playerID]
+	if !away {
+		g.absent[playerID] = now
+		return 0
+	}
+	return now.Sub(since)
+}
+
+// vacateSeat turns playerID's seat vacant and tells the table. A vacant Pablo caller hands
+// the end of the round to the next seat, as when the caller leaves, and a round left with
+// fewer than two occupied seats ends. Caller must hold g.mu.
+func (g *Game) vacateSeat(playerID string) {
+	g.vacant[playerID] = true
+	message := Message{
+		Type: protocol.MsgSeatVacated,
+		Payload: map[string]interface{}{
+			"playerID":   playerID,
+			"playerName": g.displayName(playerID),
+		},
+	}
+	g.broadcast(message)
+	g.broadcastToSpectators(message)
+	g.broadcastLobby()
+
+	if g.PabloCalled && g.PabloCaller == playerID {
+		g.PabloCaller = g.nextSeatAfter(playerID)
+	}
+	occupied := 0
+	for _, id := range g.SeatOrder {
+		if !g.vacant[id] {
+			occupied++
+		}
+	}
+	if occupied < 2 {
+		g.EndRound()
+		return
+	}
+	if g.CurrentPlayer == playerID && g.skipAbsentTurn() {
+		return
+	}
+	g.broadcastGameState()
+}
+
+// reapVacantSeats checks every game in memory for seats away for at least after
+func (gm *GameManager) reapVacantSeats(now time.Time, after time.Duration) int {
+	gm.mu.RLock()
+	games := make([]*Game, 0, len(gm.games))
+	for _, game := range gm.games {
+		games = append(games, game)
+	}
+	gm.mu.RUnlock()
+
+	vacated := 0
+	for _, game := range games {
+		game.mu.Lock()
+		vacated += game.reapVacantSeats(now, after)
+		game.mu.Unlock()
+	}
+	return vacated
+}
+
+// runVacancyReaper looks for seats to vacate every so often until ctx is done
+func (gm *GameManager) runVacancyReaper(ctx context.Context, after time.Duration) {
+	ticker := time.NewTicker(after / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if vacated := gm.reapVacantSeats(now, after); vacated > 0 {
+				log.Printf("Vacated or freed %d abandoned seats", vacated)
+			}
+		}
+	}
+}
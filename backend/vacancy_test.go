@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"pablo/protocol"
+)
+
+func TestAbsentSeatTurnsVacant(t *testing.T) {
+	game := createTestGame("test-game")
+	recorder := newRecordingBroadcaster()
+	game.SetBroadcaster(recorder)
+	playerIDs := addTestPlayers(game, 3)
+	game.StartGame()
+	first, zombie, last := playerIDs[0], playerIDs[1], playerIDs[2]
+	game.Players[first].Conn = newTestConn(t)
+	game.Players[last].Conn = newTestConn(t)
+
+	now := time.Now()
+	if vacated := game.reapVacantSeats(now, time.Minute); vacated != 0 {
+		t.Fatal("A seat found without a connection should get the full time to come back")
+	}
+	if vacated := game.reapVacantSeats(now.Add(time.Minute), time.Minute); vacated != 1 || !game.vacant[zombie] {
+		t.Fatalf("Expected only the seat without a connection vacated, got %d", vacated)
+	}
+	if countOfType(recorder.players[first], protocol.MsgSeatVacated) != 1 {
+		t.Error("Expected the table told")
+	}
+	if lobby := game.lobbyPlayers(); lobby[1].Status != seatVacant {
+		t.Errorf("Expected the seat shown vacant, got %q", lobby[1].Status)
+	}
+
+	sent := len(recorder.players[zombie])
+	game.Deck[0] = Card{Suit: "clubs", Rank: "2"}
+	game.DrawCard(first)
+	game.DiscardDrawnCard(first)
+	game.EndTurn(first)
+	if game.CurrentPlayer != last {
+		t.Fatalf("Expected the turn to pass the vacant seat by, got %s", game.CurrentPlayer)
+	}
+	if len(recorder.players[zombie]) != sent {
+		t.Error("Nothing should be sent to a vacant seat")
+	}
+
+	game.AddPlayer(zombie, "Player 2", newTestConn(t))
+	if game.vacant[zombie] || game.nextSeatAfter(first) != zombie {
+		t.Error("Rejoining should take the seat back")
+	}
+}
+
+func TestVacatingCurrentSeat(t *testing.T) {
+	game := createTestGame("test-game")
+	game.SetBroadcaster(newRecordingBroadcaster())
+	playerIDs := addTestPlayers(game, 3)
+	game.AddBot("bot1", "Bot")
+	game.StartGame()
+	for _, id := range playerIDs[1:] {
+		game.Players[id].Conn = newTestConn(t)
+	}
+	game.DrawCard(playerIDs[0])
+
+	now := time.Now()
+	game.reapVacantSeats(now, time.Minute)
+	game.reapVacantSeats(now.Add(time.Minute), time.Minute)
+	if game.CurrentPlayer != playerIDs[1] || game.DrawnCards[playerIDs[0]] != nil {
+		t.Errorf("Expected the vacated player's turn closed and passed on, current is %s", game.CurrentPlayer)
+	}
+	for id := range game.Bots {
+		if game.vacant[id] {
+			t.Error("Bots have no connection and shouldn't be vacated")
+		}
+	}
+
+	game.Players[playerIDs[1]].Conn = nil
+	game.Players[playerIDs[2]].Conn = nil
+	game.reapVacantSeats(now.Add(2*time.Minute), time.Minute)
+	game.reapVacantSeats(now.Add(3*time.Minute), time.Minute)
+	if game.Status != protocol.StatusEnded {
+		t.Errorf("A round with one occupied seat left should end, status %s", game.Status)
+	}
+}
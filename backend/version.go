@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	"pablo/protocol"
+)
+
+// Clients send their build version as clientVersion with every join message. After a
+// deploy that changes the protocol the server can require a minimum, and clients older
+// than that are told to upgrade instead of failing in ways nobody can explain.
+
+// minClientVersion is the oldest client build allowed to play; empty accepts any
+var minClientVersion string
+
+// outdatedReadOnly lets outdated clients that join a game watch it as spectators
+// rather than turning them away
+var outdatedReadOnly bool
+
+// clientOutdated reports whether a client of this version is older than the minimum.
+// A client that doesn't say which version it is predates the handshake, so it is.
+func clientOutdated(version string) bool {
+	if minClientVersion == "" {
+		return false
+	}
+	return version == "" || compareVersions(version, minClientVersion) < 0
+}
+
+// compareVersions compares dotted version numbers like "1.4.2" part by part, returning
+// -1, 0 or 1. Missing parts count as 0 and anything after a "-" or "+" is ignored.
+func compareVersions(a, b string) int {
+	as, bs := versionParts(a), versionParts(b)
+	for len(as) < len(bs) {
+		as = append(as, 0)
+	}
+	for len(bs) < len(as) {
+		bs = append(bs, 0)
+	}
+	for i := range as {
+		switch {
+		case as[i] < bs[i]:
+			return -1
+		case as[i] > bs[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(version string) []int {
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	var parts []int
+	for _, part := range strings.Split(version, ".") {
+		n, _ := strconv.Atoi(part)
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// sendUpgradeRequired tells an outdated client which version it needs. readOnly says
+// whether it has been let in to watch anyway.
+func sendUpgradeRequired(conn *websocket.Conn, clientVersion string, readOnly bool) {
+	key := protocol.MsgUpgradeRequired
+	if readOnly {
+		key += ".readOnly"
+	}
+	writeJSON(conn, Message{
+		Type: protocol.MsgUpgradeRequired,
+		Payload: map[string]interface{}{
+			"minVersion":    minClientVersion,
+			"clientVersion": clientVersion,
+			"readOnly":      readOnly,
+			"message":       localize(conn, key),
+		},
+	})
+}
@@ -0,0 +1,48 @@
+package main
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2", "1.2.0", 0},
+		{"1.2.3", "1.10.0", -1},
+		{"2.0.0", "1.99.99", 1},
+		{"v1.4.0", "1.4.0", 0},
+		{"1.4.0-beta.2", "1.4.0", 0},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestOutdatedClientOverWS(t *testing.T) {
+	savedMin, savedReadOnly := minClientVersion, outdatedReadOnly
+	minClientVersion, outdatedReadOnly = "1.2.0", false
+	defer func() { minClientVersion, outdatedReadOnly = savedMin, savedReadOnly }()
+
+	conn := dialTestServer(t)
+	sendTestMessage(t, conn, "createGame", map[string]interface{}{"name": "Old", "clientVersion": "1.1.9"})
+	if upgrade := readMessageOfType(t, conn, "upgradeRequired"); upgrade["minVersion"] != "1.2.0" || upgrade["readOnly"] != false {
+		t.Errorf("Unexpected upgrade prompt %v", upgrade)
+	}
+
+	sendTestMessage(t, conn, "createGame", map[string]interface{}{"name": "New", "clientVersion": "1.2.0"})
+	session := readMessageOfType(t, conn, "session")
+
+	// With read-only on, an outdated client joining watches instead
+	outdatedReadOnly = true
+	old := dialTestServer(t)
+	sendTestMessage(t, old, "join", map[string]interface{}{"gameID": session["gameID"], "name": "Old"})
+	if upgrade := readMessageOfType(t, old, "upgradeRequired"); upgrade["readOnly"] != true {
+		t.Errorf("Expected a read-only prompt, got %v", upgrade)
+	}
+	if state := readMessageOfType(t, old, "gameState"); state["spectator"] != true {
+		t.Errorf("An outdated client should watch as a spectator, got %v", state)
+	}
+}
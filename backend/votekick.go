@@ -0,0 +1,126 @@
+package main
+
+import (
+	"time"
+
+	"pablo/protocol"
+)
+
+const (
+	kickVoteWindow   = 60 * time.Second  // How long a kick vote stays open
+	kickVoteCooldown = 120 * time.Second // How long a player must wait before starting another kick vote
+)
+
+// KickVote tracks an open vote to remove a player from the game.
+type KickVote struct {
+	TargetID  string
+	Initiator string
+	Voters    map[string]bool
+	ExpiresAt time.Time
+}
+
+// minKickVotes is the fewest votes that can remove a player, so no one can be kicked by a
+// single other player
+const minKickVotes = 2
+
+// VoteKick records voterID's vote to remove targetID. Only the seated people at the table
+// vote: bots and players waiting for the next round don't. The first vote against a target
+// opens a vote that stays open for kickVoteWindow; once a majority of the voters other
+// than the target (and at least minKickVotes) have voted, the target is removed. Tables
+// with too few voters for that can't kick anyone. Players who open a vote can't open
+// another one until kickVoteCooldown has passed.
+func (g *Game) VoteKick(voterID, targetID string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if voterID == targetID {
+		return false
+	}
+	if _, exists := g.Players[targetID]; !exists {
+		return false
+	}
+	electorate := g.kickElectorate(targetID)
+	if !electorate[voterID] || len(electorate) < minKickVotes {
+		return false
+	}
+
+	now := time.Now()
+	vote, open := g.KickVotes[targetID]
+	if open && now.After(vote.ExpiresAt) {
+		// Window ran out without a majority
+		delete(g.KickVotes, targetID)
+		open = false
+	}
+
+	if !open {
+		if until, cooling := g.KickCooldowns[voterID]; cooling && now.Before(until) {
+			return false
+		}
+		vote = &KickVote{
+			TargetID:  targetID,
+			Initiator: voterID,
+			Voters:    make(map[string]bool),
+			ExpiresAt: now.Add(kickVoteWindow),
+		}
+		g.KickVotes[targetID] = vote
+		g.KickCooldowns[voterID] = now.Add(kickVoteCooldown)
+	}
+
+	vote.Voters[voterID] = true
+
+	required := len(electorate)/2 + 1
+	if required < minKickVotes {
+		required = minKickVotes
+	}
+	votes := 0
+	for id := range vote.Voters {
+		if electorate[id] {
+			votes++
+		}
+	}
+
+	g.broadcast(Message{
+		Type: protocol.MsgVoteKickProgress,
+		Payload: map[string]interface{}{
+			"targetID":  targetID,
+			"votes":     votes,
+			"required":  required,
+			"expiresAt": vote.ExpiresAt.UnixMilli(),
+		},
+	})
+
+	if votes < required {
+		return true
+	}
+
+	delete(g.KickVotes, targetID)
+	g.sendToPlayer(targetID, Message{
+		Type:    protocol.MsgKicked,
+		Payload: map[string]string{"message": g.localizeFor(targetID, protocol.MsgKicked)},
+	})
+	playerName := g.displayName(targetID)
+	g.removePlayer(targetID)
+
+	g.broadcast(Message{
+		Type: protocol.MsgPlayerKicked,
+		Payload: map[string]interface{}{
+			"playerID":   targetID,
+			"playerName": playerName,
+		},
+	})
+	g.broadcastGameState()
+	return true
+}
+
+// kickElectorate is the players who get a vote on kicking targetID: everyone seated except
+// the target, bots and players waiting for the next round. Caller must hold g.mu.
+func (g *Game) kickElectorate(targetID string) map[string]bool {
+	electorate := make(map[string]bool)
+	for _, id := range g.SeatOrder {
+		if id == targetID || g.Bots[id] != nil {
+			continue
+		}
+		electorate[id] = true
+	}
+	return electorate
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVoteKickMajority(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 3)
+	game.StartGame()
+
+	target := playerIDs[2]
+	deckSize := len(game.Deck)
+
+	if !game.VoteKick(playerIDs[0], target) {
+		t.Fatal("First vote should be accepted")
+	}
+	if _, exists := game.Players[target]; !exists {
+		t.Fatal("One of two required votes should not remove the target")
+	}
+
+	if !game.VoteKick(playerIDs[1], target) {
+		t.Fatal("Second vote should be accepted")
+	}
+	if _, exists := game.Players[target]; exists {
+		t.Fatal("Target should be removed once a majority voted")
+	}
+
+	if len(game.SeatOrder) != 2 {
+		t.Errorf("Expected 2 seats after kick, got %d", len(game.SeatOrder))
+	}
+	if len(game.Deck) != deckSize+4 {
+		t.Errorf("Expected kicked player's cards returned to the deck, deck is %d (was %d)", len(game.Deck), deckSize)
+	}
+	if game.Status != "playing" {
+		t.Error("Game should continue with two players left")
+	}
+}
+
+func TestVoteKickCurrentPlayerAdvancesTurn(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 3)
+	game.StartGame()
+
+	target := game.CurrentPlayer
+	expectedNext := game.nextSeatAfter(target)
+	for _, id := range playerIDs {
+		if id != target {
+			game.VoteKick(id, target)
+		}
+	}
+
+	if game.CurrentPlayer != expectedNext {
+		t.Errorf("Expected turn to pass to %s, got %s", expectedNext, game.CurrentPlayer)
+	}
+}
+
+func TestVoteKickRefusedAtTwoPlayerTable(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.StartGame()
+
+	if game.VoteKick(playerIDs[0], playerIDs[1]) {
+		t.Error("A lone opponent should not be able to open a kick vote")
+	}
+	if _, exists := game.Players[playerIDs[1]]; !exists {
+		t.Fatal("A single vote should never remove a player")
+	}
+	if game.Status != "playing" {
+		t.Errorf("Expected the round to go on, got '%s'", game.Status)
+	}
+}
+
+func TestVoteKickOnlyCountsSeatedHumans(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 2)
+	game.AddBot("bot-1", "Bot 1")
+	game.AddBot("bot-2", "Bot 2")
+	game.StartGame()
+	game.AddPlayer("late", "Late", nil)
+
+	// Bots and a player waiting for the next round would make five; only player1 votes
+	if game.VoteKick("late", playerIDs[1]) || game.VoteKick("bot-1", playerIDs[1]) {
+		t.Error("Waiting players and bots should not get a vote")
+	}
+	if game.VoteKick(playerIDs[0], playerIDs[1]) {
+		t.Error("One seated human against another should not be able to kick")
+	}
+
+	// Against a bot both humans vote, and both are needed
+	game.VoteKick(playerIDs[0], "bot-1")
+	if _, exists := game.Players["bot-1"]; !exists {
+		t.Fatal("One vote should not be enough")
+	}
+	game.VoteKick(playerIDs[1], "bot-1")
+	if _, exists := game.Players["bot-1"]; exists {
+		t.Error("Both humans voting should remove the bot")
+	}
+}
+
+func TestVoteKickRejectsInvalidVotes(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 3)
+
+	if game.VoteKick(playerIDs[0], playerIDs[0]) {
+		t.Error("Players should not be able to vote to kick themselves")
+	}
+	if game.VoteKick("stranger", playerIDs[0]) {
+		t.Error("Non-players should not be able to vote")
+	}
+	if game.VoteKick(playerIDs[0], "stranger") {
+		t.Error("Should not be able to vote against a non-player")
+	}
+}
+
+func TestVoteKickCooldownAndExpiry(t *testing.T) {
+	game := createTestGame("test-game")
+	playerIDs := addTestPlayers(game, 4)
+
+	game.VoteKick(playerIDs[0], playerIDs[3])
+
+	// Opening a second vote right away is blocked
+	if game.VoteKick(playerIDs[0], playerIDs[2]) {
+		t.Error("Should not be able to open another vote during cooldown")
+	}
+
+	// Let the first vote and the cooldown run out
+	game.KickVotes[playerIDs[3]].ExpiresAt = time.Now().Add(-time.Second)
+	game.KickCooldowns[playerIDs[0]] = time.Now().Add(-time.Second)
+
+	if !game.VoteKick(playerIDs[1], playerIDs[3]) {
+		t.Fatal("Should be able to open a new vote after the old one expired")
+	}
+	if len(game.KickVotes[playerIDs[3]].Voters) != 1 {
+		t.Error("Votes from an expired window should not carry over")
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// Clients pick a wire format by offering WebSocket subprotocols, named
+// pablo.<version>.<encoding>. The server takes the first one offered that it supports, so
+// a new format can be rolled out next to the old one and clients moved over one release
+// at a time. A client that offers none gets JSON, as every client did before formats
+// were negotiated.
+
+// wireFormat is one way of putting messages on the wire
+type wireFormat struct {
+	subprotocol string
+	frameType   int // websocket.TextMessage or websocket.BinaryMessage
+	encode      func(f *frame, v interface{}) error
+	decode      func(data []byte, msg *clientMessage) error
+}
+
+var jsonWire = &wireFormat{
+	subprotocol: "pablo.v1.json",
+	frameType:   websocket.TextMessage,
+	encode: func(f *frame, v interface{}) error {
+		if err := f.enc.Encode(v); err != nil {
+			return err
+		}
+		f.buf.Truncate(f.buf.Len() - 1) // The encoder ends every value with a newline
+		return nil
+	},
+	decode: func(data []byte, msg *clientMessage) error {
+		return json.Unmarshal(data, msg)
+	},
+}
+
+var errUnsupportedSubprotocol = errors.New("unsupported subprotocol")
+
+// wireFormats are the formats the server speaks
+var wireFormats = []*wireFormat{jsonWire}
+
+// negotiateWireFormat picks the first of the offered subprotocols the server supports.
+// With nothing offered it is JSON; ok is false if something was offered but none of it
+// is supported.
+func negotiateWireFormat(offered []string) (format *wireFormat, ok bool) {
+	if len(offered) == 0 {
+		return jsonWire, true
+	}
+	for _, subprotocol := range offered {
+		for _, format := range wireFormats {
+			if format.subprotocol == subprotocol {
+				return format, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// supportedSubprotocols lists the subprotocols the server accepts, for refusing a client
+// that offered none of them
+func supportedSubprotocols() string {
+	names := make([]string, len(wireFormats))
+	for i, format := range wireFormats {
+		names[i] = format.subprotocol
+	}
+	return strings.Join(names, ", ")
+}
+
+// upgradeWithWireFormat negotiates a wire format for r and upgrades it. A client offering
+// only formats the server doesn't speak is refused before the upgrade, with the ones it
+// does in the body.
+func upgradeWithWireFormat(w http.ResponseWriter, r *http.Request) (*websocket.Conn, error) {
+	offered := websocket.Subprotocols(r)
+	format, ok := negotiateWireFormat(offered)
+	if !ok {
+		http.Error(w, "unsupported subprotocol; this server speaks "+supportedSubprotocols(), http.StatusBadRequest)
+		return nil, errUnsupportedSubprotocol
+	}
+	var header http.Header
+	if len(offered) > 0 {
+		header = http.Header{"Sec-Websocket-Protocol": {format.subprotocol}}
+	}
+	conn, err := upgrader.Upgrade(w, r, header)
+	if err != nil {
+		return nil, err
+	}
+	connFormats.Store(conn, format)
+	return conn, nil
+}
+
+// connFormats remembers the wire format each connection negotiated
+var connFormats sync.Map // *websocket.Conn -> *wireFormat
+
+// wireFormatOf returns conn's wire format: JSON unless it negotiated another
+func wireFormatOf(conn *websocket.Conn) *wireFormat {
+	if format, exists := connFormats.Load(conn); exists {
+		return format.(*wireFormat)
+	}
+	return jsonWire
+}
+
+func forgetWireFormat(conn *websocket.Conn) {
+	connFormats.Delete(conn)
+}
+
+// readMessage reads the next message from conn in its wire format
+func readMessage(conn *websocket.Conn, msg *clientMessage) error {
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return wireFormatOf(conn).decode(data, msg)
+}
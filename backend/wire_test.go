@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func dialWithSubprotocols(t *testing.T, subprotocols ...string) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(handleWebSocket))
+	t.Cleanup(server.Close)
+	dialer := websocket.Dialer{Subprotocols: subprotocols}
+	conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err == nil {
+		t.Cleanup(func() { conn.Close() })
+	}
+	return conn, resp, err
+}
+
+func TestSubprotocolNegotiation(t *testing.T) {
+	// A second format next to JSON, as during a migration: the same JSON in binary frames
+	binaryJSON := &wireFormat{
+		subprotocol: "pablo.v2.test",
+		frameType:   websocket.BinaryMessage,
+		encode:      jsonWire.encode,
+		decode:      jsonWire.decode,
+	}
+	saved := wireFormats
+	wireFormats = []*wireFormat{jsonWire, binaryJSON}
+	t.Cleanup(func() { wireFormats = saved })
+
+	conn, _, err := dialWithSubprotocols(t, "pablo.v9.future", "pablo.v2.test", "pablo.v1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn.Subprotocol() != "pablo.v2.test" {
+		t.Errorf("Expected the first supported offer picked, got %q", conn.Subprotocol())
+	}
+	sendTestMessage(t, conn, "createGame", map[string]interface{}{"name": "Host"})
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	frameType, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var message Message
+	if frameType != websocket.BinaryMessage || json.Unmarshal(data, &message) != nil {
+		t.Errorf("Expected the negotiated format's frames, got type %d: %s", frameType, data)
+	}
+
+	if old, _, err := dialWithSubprotocols(t); err != nil || old.Subprotocol() != "" {
+		t.Errorf("A client offering nothing should still connect with JSON, got %v", err)
+	}
+
+	_, resp, err := dialWithSubprotocols(t, "pablo.v9.future")
+	if err == nil || resp == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected a client offering only unknown formats refused, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Some store writes come up while the manager's or a game's lock is held: registering a
+// new game in the cluster directory, and recording which seat a session token holds. A
+// store may be across the network, so they aren't made there. They are queued instead and
+// made by one worker, in the order they were queued, so two quick joins can't land out of
+// order. A write that fails is logged. The worker runs under the manager's context, and
+// makes what is still queued at shutdown before main exits.
+
+// storeWriteTimeout bounds each queued write
+const storeWriteTimeout = 5 * time.Second
+
+// storeWrite is one queued write
+type storeWrite struct {
+	what  string // What it does, for the log
+	write func(ctx context.Context) error
+}
+
+// writeBehind queues store writes for its worker
+type writeBehind struct {
+	queue []storeWrite
+	wake  chan struct{}
+	done  chan struct{} // Closed once run has made what was left at shutdown
+	mu    sync.Mutex
+}
+
+// storeWrites is the server's write queue; main runs its worker
+var storeWrites = newWriteBehind()
+
+func newWriteBehind() *writeBehind {
+	return &writeBehind{wake: make(chan struct{}, 1), done: make(chan struct{})}
+}
+
+// add queues write. It never blocks, so it's safe to call with locks held.
+func (w *writeBehind) add(what string, write func(ctx context.Context) error) {
+	w.mu.Lock()
+	w.queue = append(w.queue, storeWrite{what: what, write: write})
+	w.mu.Unlock()
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run makes queued writes as they come until ctx is done. It makes what is still queued
+// then before closing w.done.
+func (w *writeBehind) run(ctx context.Context) {
+	defer close(w.done)
+	for {
+		select {
+		case <-ctx.Done():
+			w.flush()
+			return
+		case <-w.wake:
+		}
+		w.flush()
+	}
+}
+
+// flush makes every queued write, oldest first
+func (w *writeBehind) flush() {
+	w.mu.Lock()
+	queue := w.queue
+	w.queue = nil
+	w.mu.Unlock()
+
+	for _, queued := range queue {
+		ctx, cancel := context.WithTimeout(context.Background(), storeWriteTimeout)
+		if err := queued.write(ctx); err != nil {
+			log.Printf("%s: %v", queued.what, err)
+		}
+		cancel()
+	}
+}
+
+// registerGame queues gameID's entry in the cluster directory
+func registerGame(gameID string) {
+	storeWrites.add("Registering game "+gameID+" in the directory", func(ctx context.Context) error {
+		return cluster.register(gameID)
+	})
+}
+
+// recordSeat queues the record of the seat sessionToken holds
+func recordSeat(sessionToken string, seat seatRecord) {
+	store := seatRecords
+	storeWrites.add("Recording a seat in game "+seat.GameID, func(ctx context.Context) error {
+		return store.Record(sessionToken, seat)
+	})
+}
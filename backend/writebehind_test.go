@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWriteBehindKeepsOrder(t *testing.T) {
+	w := newWriteBehind()
+	var made []int
+	queue := func(n int, err error) {
+		w.add("Test write", func(ctx context.Context) error {
+			made = append(made, n)
+			return err
+		})
+	}
+	queue(0, nil)
+	queue(1, errors.New("store unreachable"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go w.run(ctx)
+	for n := 2; n < 20; n++ {
+		queue(n, nil)
+	}
+	cancel()
+	<-w.done
+
+	// Each write runs after the one queued before it, a failure doesn't stop the rest, and
+	// what was queued at shutdown is still made
+	want := make([]int, 20)
+	for n := range want {
+		want[n] = n
+	}
+	if !reflect.DeepEqual(made, want) {
+		t.Errorf("Expected every write in order, got %v", made)
+	}
+}
@@ -1,12 +1,15 @@
 'use client'
 
-import { useState, useEffect, useRef } from 'react'
+import { useState, useRef, useEffect } from 'react'
 import styles from './page.module.css'
+import packageInfo from '../package.json'
 
 interface Card {
   suit: string
   rank: string
   faceUp: boolean
+  color?: 'red' | 'black' // Only for standard suits
+  label?: string // e.g. "seven of hearts"; absent while the card is hidden
   removed?: boolean // Flag to indicate if card was removed via stacking (vs just hidden face-down)
 }
 
@@ -15,6 +18,32 @@ interface Player {
   name: string
   cards: Card[]
   score: number
+  disconnected?: boolean // Their connection dropped and they haven't come back yet
+  note?: string // Our own private note on this player
+}
+
+interface LobbyPlayer {
+  id: string
+  name: string
+  seat: number
+  ready: boolean
+  host: boolean
+  status: 'connected' | 'disconnected' | 'vacant' | 'bot'
+}
+
+interface RecentGame {
+  gameID: string
+  players: { playerID: string; name: string; score: number; total: number; bot?: boolean }[]
+  winners: string[]
+  endedAt: string
+}
+
+interface OpenGame {
+  gameID: string
+  status: string
+  host: string
+  players: number
+  maxPlayers: number
 }
 
 interface GameState {
@@ -28,6 +57,9 @@ interface GameState {
   drawnCards: { [key: string]: Card }
   pendingSpecialCard: string
   stackingEnabled: boolean
+  config?: { [key: string]: any }
+  turnPhase?: string
+  allowedActions?: string[]
   pendingGive?: {
     actorID: string
     targetPlayerID: string
@@ -35,12 +67,47 @@ interface GameState {
   }
 }
 
+// The secret our private notes on other players are kept under. Entering the same key on
+// another device shows the same notes there.
+function notesKey(): string {
+  let key = localStorage.getItem('pablo-notes-key')
+  if (!key) {
+    key = crypto.randomUUID()
+    localStorage.setItem('pablo-notes-key', key)
+  }
+  return key
+}
+
+// Our secret friend key and the friend codes we follow. A friend's new lobby invites us
+// only when they follow our code too.
+function friendKey(): string {
+  let key = localStorage.getItem('pablo-friend-key')
+  if (!key) {
+    key = crypto.randomUUID()
+    localStorage.setItem('pablo-friend-key', key)
+  }
+  return key
+}
+
+function friendCodes(): string[] {
+  return JSON.parse(localStorage.getItem('pablo-friends') || '[]')
+}
+
+// The wire format we speak, offered to the server as a WebSocket subprotocol
+const WIRE_FORMAT = 'pablo.v1.json'
+
+// Names we've blocked. Their chat is hidden from us, and ours from them.
+function blockedNames(): string[] {
+  return JSON.parse(localStorage.getItem('pablo-blocked') || '[]')
+}
+
 export default function Home() {
   const [gameID, setGameID] = useState('')
   const [playerID, setPlayerID] = useState('')
   const [playerName, setPlayerName] = useState('')
   const [connected, setConnected] = useState(false)
   const [gameState, setGameState] = useState<GameState | null>(null)
+  const [lobbyPlayers, setLobbyPlayers] = useState<LobbyPlayer[]>([])
   const [drawnCard, setDrawnCard] = useState<Card | null>(null)
   const [isDrawing, setIsDrawing] = useState(false)
   const [revealedCard, setRevealedCard] = useState<{ playerID: string; index: number; card: Card } | null>(null)
@@ -61,23 +128,97 @@ export default function Home() {
   const [stackError, setStackError] = useState<string | null>(null)
   const [stackAttempts, setStackAttempts] = useState<{ [playerID: string]: { success: boolean; timestamp: number } }>({})
   const [isConnecting, setIsConnecting] = useState(false)
+  const [narration, setNarration] = useState('')
+  const [recentGames, setRecentGames] = useState<RecentGame[]>([])
+  const [openGames, setOpenGames] = useState<OpenGame[]>([])
+  const [friendCode, setFriendCode] = useState('')
+  // House rules we've saved by name; creating a game from one starts it with those rules
+  const [presets, setPresets] = useState<{ id: string; name: string }[]>([])
+  const [presetID, setPresetID] = useState('')
+  const [friendsVersion, setFriendsVersion] = useState(0)
+  const [tutorialStep, setTutorialStep] = useState<{ instruction: string; step: number; steps: number; done?: boolean } | null>(null)
+  // The clock on the current turn at tables with a turn time limit, by our own clock
+  const [turnTimer, setTurnTimer] = useState<{ playerID: string; endsAt: number } | null>(null)
+  const [now, setNow] = useState(Date.now())
   const wsRef = useRef<WebSocket | null>(null)
+  // The server assigns our player ID at join; handlers read it from here so they always see the latest
+  const playerIDRef = useRef('')
 
+  // A backgrounded tab can miss messages without its socket dropping; catch up when it's back
   useEffect(() => {
-    // Generate player ID if not set
-    if (!playerID) {
-      setPlayerID(`player_${Math.random().toString(36).substr(2, 9)}`)
+    if (!connected) return
+    const onVisible = () => {
+      if (document.visibilityState === 'visible') {
+        sendMessage('resync', { lastSeq: Number(sessionStorage.getItem(`pablo-seq-${gameID}`) || 0) })
+      }
     }
-  }, [playerID])
+    document.addEventListener('visibilitychange', onVisible)
+    return () => document.removeEventListener('visibilitychange', onVisible)
+  }, [connected, gameID])
 
-  const connectWebSocket = () => {
-    if (!gameID || !playerName) {
-      alert('Please enter game ID and your name')
+  // The server says how long the turn has left every few seconds; count down in between
+  useEffect(() => {
+    if (!turnTimer) return
+    const tick = setInterval(() => setNow(Date.now()), 1000)
+    return () => clearInterval(tick)
+  }, [turnTimer])
+
+  // Latest results for the join screen; the server only remembers them since it started
+  useEffect(() => {
+    if (connected) return
+    fetch('http://localhost:8080/games/recent?limit=5')
+      .then(res => res.json())
+      .then(body => setRecentGames(body.games || []))
+      .catch(() => setRecentGames([]))
+    fetch('http://localhost:8080/games?joinable=true')
+      .then(res => res.json())
+      .then(body => setOpenGames(body.games || []))
+      .catch(() => setOpenGames([]))
+  }, [connected])
+
+  // While we're not in a game, keep a socket open so friends' new lobbies can invite us
+  useEffect(() => {
+    if (connected || !playerName) return
+    const ws = new WebSocket('ws://localhost:8080/ws', WIRE_FORMAT)
+    ws.onopen = () => {
+      ws.send(JSON.stringify({ type: 'presence', payload: { friendKey: friendKey(), name: playerName, friends: friendCodes() } }))
+      ws.send(JSON.stringify({ type: 'getPresets', payload: { notesKey: notesKey() } }))
+    }
+    ws.onmessage = (event) => {
+      const message = JSON.parse(event.data)
+      if (message.type === 'friendCode') {
+        setFriendCode(message.payload.friendCode)
+      } else if (message.type === 'presets') {
+        setPresets(message.payload.presets)
+      } else if (message.type === 'invite') {
+        const accepted = window.confirm(`${message.payload.from} invited you to a game. Join?`)
+        ws.send(JSON.stringify({ type: accepted ? 'acceptInvite' : 'declineInvite', payload: { inviteID: message.payload.inviteID } }))
+      } else if (message.type === 'seatHeld') {
+        ws.close(1000)
+        setGameID(message.payload.gameID)
+        connectWebSocket('join', message.payload.gameID)
+      }
+    }
+    return () => ws.close(1000)
+  }, [connected, playerName, friendsVersion])
+
+  const handleAddFriend = () => {
+    const code = window.prompt(`Your friend code is ${friendCode || '(connecting...)'}. Enter a friend's code to add them:`)
+    if (code && code.trim()) {
+      localStorage.setItem('pablo-friends', JSON.stringify(Array.from(new Set([...friendCodes(), code.trim()]))))
+      setFriendsVersion(v => v + 1)
+    }
+  }
+
+  const connectWebSocket = (mode: 'create' | 'join' | 'tutorial', joinGameID: string = gameID) => {
+    const gameID = joinGameID
+    if (!playerName || (mode === 'join' && !gameID)) {
+      alert(mode === 'join' ? 'Please enter game ID and your name' : 'Please enter your name')
       return
     }
 
     setIsConnecting(true)
-    const ws = new WebSocket('ws://localhost:8080/ws')
+    const ws = new WebSocket('ws://localhost:8080/ws', WIRE_FORMAT)
     wsRef.current = ws
 
     // Set a timeout for connection
@@ -93,20 +234,42 @@ export default function Home() {
       clearTimeout(connectionTimeout)
       setIsConnecting(false)
       setConnected(true)
+      // Invites our friends to a new lobby, and lets us into a seat one of them holds for us
+      ws.send(JSON.stringify({ type: 'presence', payload: { friendKey: friendKey(), name: playerName, friends: friendCodes() } }))
       ws.send(JSON.stringify({
-        type: 'join',
+        type: mode === 'create' ? 'createGame' : mode === 'tutorial' ? 'startTutorial' : 'join',
         payload: {
           gameID,
-          playerID,
           name: playerName,
+          // Reclaims our old seat if we were in this game before (e.g. after a refresh)
+          sessionToken: sessionStorage.getItem(`pablo-session-${gameID}`) || undefined,
+          // With it, the server first replays the chat, reveals and stacks we missed while away
+          lastSeq: Number(sessionStorage.getItem(`pablo-seq-${gameID}`) || 0),
+          // Lets the server ask us to reload after a deploy we're too old for
+          clientVersion: packageInfo.version,
+          // Server messages come back in this language where it has them
+          locale: navigator.language,
+          notesKey: notesKey(),
+          blocked: blockedNames(),
+          presetID: mode === 'create' && presetID ? presetID : undefined,
         },
       }))
     }
 
     ws.onmessage = (event) => {
       const message = JSON.parse(event.data)
-      
-      if (message.type === 'gameState') {
+      const playerID = playerIDRef.current
+      if (message.seq && message.gameID) {
+        sessionStorage.setItem(`pablo-seq-${message.gameID}`, String(message.seq))
+      }
+
+      if (message.type === 'session') {
+        const { gameID: sessionGameID, playerID: sessionPlayerID, sessionToken } = message.payload
+        playerIDRef.current = sessionPlayerID
+        setPlayerID(sessionPlayerID)
+        setGameID(sessionGameID)
+        sessionStorage.setItem(`pablo-session-${sessionGameID}`, sessionToken)
+      } else if (message.type === 'gameState') {
         const state = message.payload
         setGameState(state)
         
@@ -132,6 +295,12 @@ export default function Home() {
           setDrawnCard(null)
           setIsDrawing(false) // Reset drawing flag
         }
+      } else if (message.type === 'lobbyPlayers') {
+        setLobbyPlayers(message.payload.players)
+      } else if (message.type === 'playerRenamed') {
+        if (message.payload.playerID === playerID) {
+          setPlayerName(message.payload.name)
+        }
       } else if (message.type === 'cardRevealed') {
         setRevealedCard(message.payload)
         setTimeout(() => setRevealedCard(null), 3000)
@@ -230,8 +399,47 @@ export default function Home() {
             })
           })
         })
+      } else if (message.type === 'turnTimer') {
+        setTurnTimer({ playerID: message.payload.playerID, endsAt: Date.now() + message.payload.remainingMs })
+        setNow(Date.now())
+      } else if (message.type === 'turnTimeout') {
+        setTurnTimer(null)
+      } else if (message.type === 'stackWindowClosed') {
+        setNarration('Too late to stack on that card')
+      } else if (message.type === 'playerDisconnected') {
+        setNarration(`${message.payload.playerName} disconnected`)
+      } else if (message.type === 'narration') {
+        setNarration(message.payload.text)
+      } else if (message.type === 'presets') {
+        setPresets(message.payload.presets)
+      } else if (message.type === 'gameOver') {
+        const { rankings, rounds, stats } = message.payload
+        const lines = rankings.map((r: { rank: number; name: string; total: number }) => `${r.rank}. ${r.name}: ${r.total}`)
+        lines.push(`${rounds.length} round${rounds.length === 1 ? '' : 's'} played`)
+        if (stats.biggestRound) lines.push(`Biggest round: ${stats.biggestRound.name} with ${stats.biggestRound.score} in round ${stats.biggestRound.round}`)
+        if (stats.mostStacks) lines.push(`Most stacks: ${stats.mostStacks.name} (${stats.mostStacks.stacks})`)
+        alert(`Game over!\n\n${lines.join('\n')}`)
+      } else if (message.type === 'blocklist') {
+        localStorage.setItem('pablo-blocked', JSON.stringify(message.payload.blocked))
+      } else if (message.type === 'inviteAnswered') {
+        alert(message.payload.accepted ? `${message.payload.name} is on their way` : `${message.payload.name} can't make it`)
+      } else if (message.type === 'tutorialStep') {
+        setTutorialStep(message.payload)
+      } else if (message.type === 'upgradeRequired') {
+        alert(message.payload.message)
+        if (!message.payload.readOnly) {
+          ws.close(1000)
+        }
       } else if (message.type === 'error') {
+        // The tutorial repeats its instruction instead
+        if (message.payload.code === 'TUTORIAL_STEP') {
+          return
+        }
         alert(message.payload.message)
+        // Couldn't get into the game; go back to the join form
+        if (message.payload.code === 'GAME_NOT_FOUND') {
+          ws.close(1000)
+        }
       }
     }
 
@@ -262,6 +470,33 @@ export default function Home() {
     }
   }
 
+  const handleEditNote = (player: Player) => {
+    const text = window.prompt(`Private note on ${player.name}`, player.note || '')
+    if (text !== null) {
+      sendMessage('setNote', { playerID: player.id, text })
+    }
+  }
+
+  const handleBlock = (player: Player) => {
+    if (window.confirm(`Block ${player.name}? You won't see each other's chat, here or at any table after.`)) {
+      sendMessage('blockPlayer', { playerID: player.id, blocked: true })
+    }
+  }
+
+  const handleNotesKey = () => {
+    const key = window.prompt('Your notes key. Copy it to another device, or paste the key from one to see its notes here.', notesKey())
+    if (key && key.trim().length >= 16) {
+      localStorage.setItem('pablo-notes-key', key.trim())
+    }
+  }
+
+  const handleSavePreset = () => {
+    const name = window.prompt('Save these house rules as:')
+    if (name && name.trim() && gameState?.config) {
+      sendMessage('savePreset', { name: name.trim(), config: gameState.config })
+    }
+  }
+
   const handleStartGame = () => {
     sendMessage('startGame', {})
   }
@@ -309,6 +544,9 @@ export default function Home() {
     return parseInt(card.rank) || 0
   }
 
+  // Prefer the card's own color field; it's only missing from older servers
+  const isRedCard = (card: Card): boolean => (card.color ? card.color === 'red' : isRedSuit(card.suit))
+
   const isRedSuit = (suit?: string): boolean => {
     return suit === 'hearts' || suit === 'diamonds'
   }
@@ -369,6 +607,13 @@ export default function Home() {
   }
 
   const handleMyCardClick = (idx: number) => {
+    // Before the first turn everyone looks at two of their own cards, turn or not
+    if (gameState?.turnPhase === 'peeking') {
+      if (gameState.allowedActions?.includes('peekCard')) {
+        sendMessage('peekCard', { cardIndex: idx })
+      }
+      return
+    }
     if (!isMyTurn) return
     // Pending give: choose a card to give to target
     if (gameState?.pendingGive && gameState.pendingGive.actorID === playerID) {
@@ -446,14 +691,58 @@ export default function Home() {
           />
           <input
             type="text"
-            placeholder="Game ID"
+            placeholder="Game ID (to join)"
             value={gameID}
             onChange={(e) => setGameID(e.target.value)}
             className={styles.input}
           />
-          <button onClick={connectWebSocket} className={styles.button} disabled={isConnecting}>
+          {presets.length > 0 && (
+            <select value={presetID} onChange={(e) => setPresetID(e.target.value)} className={styles.input}>
+              <option value="">Standard rules</option>
+              {presets.map((preset) => (
+                <option key={preset.id} value={preset.id}>
+                  {preset.name}
+                </option>
+              ))}
+            </select>
+          )}
+          <button onClick={() => connectWebSocket('create')} className={styles.button} disabled={isConnecting}>
+            {isConnecting ? 'Connecting...' : 'Create Game'}
+          </button>
+          <button onClick={() => connectWebSocket('join')} className={styles.button} disabled={isConnecting}>
             {isConnecting ? 'Connecting...' : 'Join Game'}
           </button>
+          <button onClick={() => connectWebSocket('tutorial')} className={styles.button} disabled={isConnecting}>
+            {isConnecting ? 'Connecting...' : 'Learn to Play'}
+          </button>
+          <button onClick={handleAddFriend} className={styles.button}>
+            Friends
+          </button>
+          <button onClick={handleNotesKey} className={styles.button}>
+            Sync Notes
+          </button>
+          {openGames.length > 0 && (
+            <div className={styles.recentGames}>
+              <h2>Open Games</h2>
+              {openGames.map((game) => (
+                <div key={game.gameID} className={styles.recentGame} onClick={() => setGameID(game.gameID)} style={{ cursor: 'pointer' }}>
+                  {game.host || 'Empty table'} · {game.players}/{game.maxPlayers} players{game.status === 'waiting' ? '' : ' · in play'}
+                </div>
+              ))}
+            </div>
+          )}
+          {recentGames.length > 0 && (
+            <div className={styles.recentGames}>
+              <h2>Latest Results</h2>
+              {recentGames.map((game) => (
+                <div key={game.gameID} className={styles.recentGame}>
+                  {game.players
+                    .map(p => `${game.winners.includes(p.playerID) ? '🏆 ' : ''}${p.name || 'Deleted player'} ${p.score}`)
+                    .join(' · ')}
+                </div>
+              ))}
+            </div>
+          )}
         </div>
       </div>
     )
@@ -470,16 +759,71 @@ export default function Home() {
         </div>
       </div>
 
+      {/* Screen readers speak each table event the server describes */}
+      <div className={styles.visuallyHidden} aria-live="polite" role="log">
+        {narration}
+      </div>
+
+      {tutorialStep && (
+        <div className={styles.tutorialStep} role="status">
+          {!tutorialStep.done && <span>Step {tutorialStep.step + 1} of {tutorialStep.steps}: </span>}
+          {tutorialStep.instruction}
+        </div>
+      )}
+
       {gameState?.status === 'waiting' && (
         <div className={styles.waitingRoom}>
           <h2>Waiting for players...</h2>
           <div className={styles.playerList}>
-            {Object.values(gameState.players).map((player) => (
-              <div key={player.id} className={styles.playerCard}>
-                {player.name}
-              </div>
-            ))}
+            {lobbyPlayers.length > 0
+              ? lobbyPlayers.map((player) => (
+                  <div
+                    key={player.id}
+                    className={`${styles.playerCard} ${player.status === 'disconnected' ? styles.playerCardDisconnected : ''}`}
+                  >
+                    {player.host && '👑 '}
+                    {player.name}
+                    {player.ready && ' ✅'}
+                    {player.status === 'disconnected' && ' (disconnected)'}
+                    {player.status === 'bot' && ' 🤖'}
+                    {player.id !== playerID && player.status !== 'bot' && gameState.players[player.id] && (
+                      <button
+                        onClick={() => handleEditNote(gameState.players[player.id])}
+                        className={styles.noteButton}
+                        title={gameState.players[player.id].note || 'Add a private note'}
+                      >
+                        📝
+                      </button>
+                    )}
+                    {gameState.players[player.id]?.note && <div className={styles.note}>{gameState.players[player.id].note}</div>}
+                  </div>
+                ))
+              : Object.values(gameState.players).map((player) => (
+                  <div key={player.id} className={styles.playerCard}>
+                    {player.name}
+                  </div>
+                ))}
           </div>
+          <button
+            onClick={() => sendMessage('setReady', { ready: !lobbyPlayers.find(p => p.id === playerID)?.ready })}
+            className={styles.button}
+          >
+            {lobbyPlayers.find(p => p.id === playerID)?.ready ? 'Not Ready' : 'Ready'}
+          </button>
+          <button
+            onClick={() => {
+              const name = window.prompt('Your name', playerName)
+              if (name && name.trim()) {
+                sendMessage('rename', { name: name.trim() })
+              }
+            }}
+            className={styles.button}
+          >
+            Change Name
+          </button>
+          <button onClick={handleSavePreset} className={styles.button}>
+            Save Rules as Preset
+          </button>
           {Object.keys(gameState.players).length >= 2 && (
             <button onClick={handleStartGame} className={styles.button}>
               Start Game
@@ -497,6 +841,7 @@ export default function Home() {
               <div className={`${styles.discardPile} ${styles.discardCenter}`}>
                 <h3>Discard Pile</h3>
                 <div
+                  aria-label={gameState.discardTop.label}
                   className={`${styles.card} ${
                     isMyTurn &&
                     gameState.discardTop &&
@@ -535,7 +880,7 @@ export default function Home() {
                     <span className={styles.rank}>{gameState.discardTop.rank}</span>
                     <span
                       className={`${styles.suit} ${
-                        isRedSuit(gameState.discardTop.suit) ? styles.redSuit : styles.blackSuit
+                        isRedCard(gameState.discardTop) ? styles.redSuit : styles.blackSuit
                       }`}
                     >
                       {getSuitSymbol(gameState.discardTop.suit)}
@@ -604,7 +949,7 @@ export default function Home() {
                       <span className={styles.rank}>{swapAnim.from.card.rank}</span>
                       <span
                         className={`${styles.suit} ${
-                          isRedSuit(swapAnim.from.card.suit) ? styles.redSuit : styles.blackSuit
+                          isRedCard(swapAnim.from.card) ? styles.redSuit : styles.blackSuit
                         }`}
                       >
                         {getSuitSymbol(swapAnim.from.card.suit)}
@@ -635,7 +980,7 @@ export default function Home() {
                       <span className={styles.rank}>{swapAnim.to.card.rank}</span>
                       <span
                         className={`${styles.suit} ${
-                          isRedSuit(swapAnim.to.card.suit) ? styles.redSuit : styles.blackSuit
+                          isRedCard(swapAnim.to.card) ? styles.redSuit : styles.blackSuit
                         }`}
                       >
                         {getSuitSymbol(swapAnim.to.card.suit)}
@@ -697,7 +1042,18 @@ export default function Home() {
                       <div className={styles.playerArea}>
                         <h3>
                           {player.name} {gameState.currentPlayer === player.id && '👈'}
+                          {player.disconnected && <span title="Disconnected"> 🔌</span>}
+                          {turnTimer?.playerID === player.id && gameState.currentPlayer === player.id && (
+                            <span title="Time left this turn"> ⏱ {Math.max(0, Math.ceil((turnTimer.endsAt - now) / 1000))}s</span>
+                          )}
+                          <button onClick={() => handleEditNote(player)} className={styles.noteButton} title={player.note || 'Add a private note'}>
+                            📝
+                          </button>
+                          <button onClick={() => handleBlock(player)} className={styles.noteButton} title="Block">
+                            🚫
+                          </button>
                         </h3>
+                        {player.note && <div className={styles.note}>{player.note}</div>}
                         <div className={styles.myCardsContainer}>
                           <div className={styles.opponentGrid}>
                             {Array.from({ length: 4 }, (_, idx) => {
@@ -759,7 +1115,7 @@ export default function Home() {
                                       <span className={styles.rank}>{card.rank}</span>
                                       <span
                                         className={`${styles.suit} ${
-                                          isRedSuit(card.suit) ? styles.redSuit : styles.blackSuit
+                                          isRedCard(card) ? styles.redSuit : styles.blackSuit
                                         }`}
                                       >
                                         {getSuitSymbol(card.suit)}
@@ -830,7 +1186,19 @@ export default function Home() {
           {/* My Cards */}
           {myPlayer && (
             <div className={styles.myArea}>
-              <h2>Your Cards {isMyTurn && '👈 Your Turn'}</h2>
+              <h2>Your Cards {isMyTurn && gameState?.turnPhase !== 'peeking' && '👈 Your Turn'}</h2>
+              {gameState?.turnPhase === 'peeking' && (
+                <p>
+                  {gameState.allowedActions?.includes('confirmPeek') ? (
+                    <>
+                      Click two of your cards to look at them.{' '}
+                      <button onClick={() => sendMessage('confirmPeek', {})}>Done peeking</button>
+                    </>
+                  ) : (
+                    'Waiting for the others to finish peeking…'
+                  )}
+                </p>
+              )}
               <div className={styles.myCardsContainer}>
                 <div className={styles.myGrid}>
                   {Array.from({ length: 4 }, (_, idx) => {
@@ -862,6 +1230,7 @@ export default function Home() {
                       <div
                         key={`card-slot-${idx}`}
                         id={`card-${playerID}-${idx}`}
+                        aria-label={card.label || 'face-down card'}
                         className={`${styles.card} ${card.faceUp ? styles.cardFace : styles.cardBack} ${
                           isSwapSelected(playerID, idx) ? styles.swapSelected : ''
                       } ${gameState?.stackingEnabled ? styles.stackableCard : ''}`}
@@ -882,7 +1251,7 @@ export default function Home() {
                             <span className={styles.rank}>{card.rank}</span>
                             <span
                               className={`${styles.suit} ${
-                                isRedSuit(card.suit) ? styles.redSuit : styles.blackSuit
+                                isRedCard(card) ? styles.redSuit : styles.blackSuit
                               }`}
                             >
                               {getSuitSymbol(card.suit)}
@@ -938,7 +1307,7 @@ export default function Home() {
                                 <span className={styles.rank}>{card.rank}</span>
                                 <span
                                   className={`${styles.suit} ${
-                                    isRedSuit(card.suit) ? styles.redSuit : styles.blackSuit
+                                    isRedCard(card) ? styles.redSuit : styles.blackSuit
                                   }`}
                                 >
                                   {getSuitSymbol(card.suit)}
@@ -1004,7 +1373,7 @@ export default function Home() {
                     <span className={styles.rank}>{revealedCard.card.rank}</span>
                     <span
                       className={`${styles.suit} ${
-                        isRedSuit(revealedCard.card.suit) ? styles.redSuit : styles.blackSuit
+                        isRedCard(revealedCard.card) ? styles.redSuit : styles.blackSuit
                       }`}
                     >
                       {getSuitSymbol(revealedCard.card.suit)}
@@ -1034,7 +1403,7 @@ export default function Home() {
                       <span className={styles.rank}>{drawnCard.rank}</span>
                       <span
                         className={`${styles.suit} ${
-                          isRedSuit(drawnCard.suit) ? styles.redSuit : styles.blackSuit
+                          isRedCard(drawnCard) ? styles.redSuit : styles.blackSuit
                         }`}
                       >
                         {getSuitSymbol(drawnCard.suit)}
@@ -45,7 +45,7 @@ cd ..
 # Start backend in background
 cd backend
 echo "Starting Go backend on :8080..."
-go run main.go &
+go run . &
 BACKEND_PID=$!
 cd ..
 